@@ -0,0 +1,236 @@
+// commands.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// repairPollInterval 等待战绩修复任务完成时的查询间隔
+const repairPollInterval = 2 * time.Second
+
+// parsePlayerID 解析命令参数中的玩家ID
+func parsePlayerID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("无效的玩家ID: %s", arg)
+	}
+	return id, nil
+}
+
+// newBanCommand 封禁玩家账号，通过限流策略拒绝该账号的所有请求
+func newBanCommand(c *client) *cobra.Command {
+	var reason string
+	var duration time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "ban <玩家ID>",
+		Short: "封禁玩家账号",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			playerID, err := parsePlayerID(args[0])
+			if err != nil {
+				return err
+			}
+			if duration < 0 {
+				return fmt.Errorf("封禁时长不能为负数")
+			}
+			resp, err := c.call(http.MethodPut, fmt.Sprintf("/admin/ratelimit/policies/player:%d", playerID), map[string]interface{}{
+				"banned":           true,
+				"reason":           reason,
+				"duration_seconds": int(duration.Seconds()),
+			})
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "封禁原因")
+	cmd.Flags().DurationVar(&duration, "duration", 0, "封禁时长，如 24h，0 表示永久")
+	return cmd
+}
+
+// newUnbanCommand 解除玩家账号的封禁
+func newUnbanCommand(c *client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unban <玩家ID>",
+		Short: "解除玩家账号的封禁",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			playerID, err := parsePlayerID(args[0])
+			if err != nil {
+				return err
+			}
+			resp, err := c.call(http.MethodDelete, fmt.Sprintf("/admin/ratelimit/policies/player:%d", playerID), nil)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+}
+
+// newGrantCommand 向玩家发放货币，负数表示扣除
+func newGrantCommand(c *client) *cobra.Command {
+	var reason, reference, idempotencyKey string
+
+	cmd := &cobra.Command{
+		Use:   "grant <玩家ID> <coins|gems> <数量>",
+		Short: "向玩家发放货币，数量为负数时扣除",
+		Args:  cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			playerID, err := parsePlayerID(args[0])
+			if err != nil {
+				return err
+			}
+			currency := strings.ToLower(args[1])
+			if currency != "coins" && currency != "gems" {
+				return fmt.Errorf("无效的货币类型: %s，可选值: coins, gems", args[1])
+			}
+			amount, err := strconv.ParseInt(args[2], 10, 64)
+			if err != nil || amount == 0 {
+				return fmt.Errorf("无效的数量: %s", args[2])
+			}
+			// 未指定幂等键时每次执行生成新的键，重试同一次发放时应指定相同的键
+			if idempotencyKey == "" {
+				idempotencyKey = "admin-cli-" + uuid.New().String()
+			}
+
+			resp, err := c.call(http.MethodPost, "/admin/wallet/grant", map[string]interface{}{
+				"player_id":       playerID,
+				"currency":        currency,
+				"amount":          amount,
+				"reason":          reason,
+				"reference_id":    reference,
+				"idempotency_key": idempotencyKey,
+			})
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+	cmd.Flags().StringVar(&reason, "reason", "", "流水原因，默认为 grant")
+	cmd.Flags().StringVar(&reference, "reference", "", "关联的工单号等外部ID")
+	cmd.Flags().StringVar(&idempotencyKey, "idempotency-key", "", "幂等键，相同的键只发放一次")
+	return cmd
+}
+
+// newResetPasswordCommand 重置玩家密码
+func newResetPasswordCommand(c *client) *cobra.Command {
+	var password string
+
+	cmd := &cobra.Command{
+		Use:   "reset-password <玩家ID>",
+		Short: "重置玩家密码，未指定新密码时生成随机密码",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			playerID, err := parsePlayerID(args[0])
+			if err != nil {
+				return err
+			}
+			resp, err := c.call(http.MethodPost, fmt.Sprintf("/admin/players/%d/password", playerID), map[string]string{
+				"password": password,
+			})
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+	cmd.Flags().StringVar(&password, "password", "", "新密码，至少8个字符")
+	return cmd
+}
+
+// newRecalcStatsCommand 根据对局记录重新计算玩家战绩汇总
+func newRecalcStatsCommand(c *client) *cobra.Command {
+	var batchSize int
+	var dryRun, wait bool
+
+	cmd := &cobra.Command{
+		Use:   "recalc-stats",
+		Short: "根据对局记录重新计算所有玩家的战绩汇总",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := c.call(http.MethodPost, "/admin/stats/recalculate", map[string]interface{}{
+				"batch_size": batchSize,
+				"dry_run":    dryRun,
+			})
+			if err != nil {
+				return err
+			}
+			if !wait {
+				return printResult(cmd, resp)
+			}
+			fmt.Fprintln(cmd.ErrOrStderr(), resp.Message)
+
+			for {
+				time.Sleep(repairPollInterval)
+				resp, err = c.call(http.MethodGet, "/admin/stats/recalculate", nil)
+				if err != nil {
+					return err
+				}
+				var job struct {
+					Status   string  `json:"status"`
+					Progress float64 `json:"progress"`
+				}
+				if err := json.Unmarshal(resp.Data, &job); err != nil {
+					return fmt.Errorf("解析修复任务状态失败: %w", err)
+				}
+				if job.Status != "running" {
+					return printResult(cmd, resp)
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "进度 %.1f%%\n", job.Progress)
+			}
+		},
+	}
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "每批处理的玩家数，0 表示默认值")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只统计有偏差的玩家，不写入")
+	cmd.Flags().BoolVar(&wait, "wait", false, "等待任务完成并输出结果")
+	return cmd
+}
+
+// newRefreshLeaderboardCommand 立即刷新排行榜
+func newRefreshLeaderboardCommand(c *client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh-leaderboard",
+		Short: "立即刷新排行榜",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := c.call(http.MethodPost, "/stats/leaderboard/refresh", nil)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+}
+
+// newInspectCommand 查看玩家资料、统计和封禁状态
+func newInspectCommand(c *client) *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <玩家ID>",
+		Short: "查看玩家资料、统计和封禁状态",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			playerID, err := parsePlayerID(args[0])
+			if err != nil {
+				return err
+			}
+			resp, err := c.call(http.MethodGet, fmt.Sprintf("/admin/players/%d", playerID), nil)
+			if err != nil {
+				return err
+			}
+			return printResult(cmd, resp)
+		},
+	}
+}
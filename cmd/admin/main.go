@@ -0,0 +1,159 @@
+// main.go
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// adminKeyHeader 管理接口密钥请求头，与网关一致
+	adminKeyHeader = "X-Admin-Key"
+	// adminActorHeader 审计日志中的操作者请求头，与网关一致
+	adminActorHeader = "X-Admin-Actor"
+
+	// 未通过参数指定时读取的环境变量
+	urlEnv   = config.EnvPrefix + "_ADMIN_URL"
+	keyEnv   = config.EnvPrefix + "_ADMIN_API_KEY"
+	actorEnv = config.EnvPrefix + "_ADMIN_ACTOR"
+
+	// defaultURL 未指定时访问的网关地址
+	defaultURL = "http://localhost:8082"
+)
+
+// apiResponse 网关的统一响应格式
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Code    string          `json:"code,omitempty"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// client 管理接口客户端
+type client struct {
+	baseURL string
+	apiKey  string
+	actor   string
+	http    *http.Client
+}
+
+// call 发送请求，body 不为nil时编码为 JSON。失败响应转换为错误
+func (c *client) call(method, path string, body interface{}) (*apiResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(c.baseURL, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(adminKeyHeader, c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if c.actor != "" {
+		req.Header.Set(adminActorHeader, c.actor)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求网关失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("网关返回状态码 %d，响应无法解析: %w", resp.StatusCode, err)
+	}
+	if !result.Success || resp.StatusCode >= 400 {
+		if result.Code != "" {
+			return nil, fmt.Errorf("%s (%s, 状态码 %d)", result.Message, result.Code, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("%s (状态码 %d)", result.Message, resp.StatusCode)
+	}
+	return &result, nil
+}
+
+// printResult 输出提示信息和格式化后的数据
+func printResult(cmd *cobra.Command, resp *apiResponse) error {
+	fmt.Fprintln(cmd.ErrOrStderr(), resp.Message)
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		return nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, resp.Data, "", "  "); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), out.String())
+	return nil
+}
+
+// newRootCommand 创建根命令，各子命令共用网关地址、密钥和操作者参数
+func newRootCommand() *cobra.Command {
+	c := &client{http: &http.Client{Timeout: 30 * time.Second}}
+
+	root := &cobra.Command{
+		Use:           "admin",
+		Short:         "PixelStorm 管理工具，通过网关的管理接口操作玩家账号和排行榜",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if c.baseURL == "" {
+				c.baseURL = defaultURL
+			}
+			if c.apiKey == "" {
+				return fmt.Errorf("未设置管理密钥，请使用 --api-key 或环境变量 %s", keyEnv)
+			}
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&c.baseURL, "url", os.Getenv(urlEnv), "网关地址，默认读取环境变量 "+urlEnv+"，未设置时为 "+defaultURL)
+	flags.StringVar(&c.apiKey, "api-key", os.Getenv(keyEnv), "管理接口密钥，默认读取环境变量 "+keyEnv)
+	flags.StringVar(&c.actor, "actor", firstNonEmpty(os.Getenv(actorEnv), os.Getenv("USER")), "记录在审计日志中的操作者")
+
+	root.AddCommand(
+		newBanCommand(c),
+		newUnbanCommand(c),
+		newGrantCommand(c),
+		newResetPasswordCommand(c),
+		newRecalcStatsCommand(c),
+		newRefreshLeaderboardCommand(c),
+		newInspectCommand(c),
+	)
+	return root
+}
+
+// firstNonEmpty 第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "错误:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,221 @@
+// bot.go
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/client"
+)
+
+const (
+	// directionChangeInterval 平均多久改变一次移动方向
+	directionChangeInterval = time.Second
+	// skillInterval 平均多久释放一次技能
+	skillInterval = 2 * time.Second
+	// rttSampleInterval 采样服务端测得的往返延迟的间隔
+	rttSampleInterval = time.Second
+	// skillCount 随机释放的技能ID范围
+	skillCount = 5
+)
+
+// errMatchTimeout 等待匹配超时
+var errMatchTimeout = errors.New("等待匹配超时")
+
+// Bot 模拟一个玩家：登录、连接游戏服务器、加入匹配并按固定频率发送输入
+type Bot struct {
+	index    int
+	cfg      *Config
+	recorder *Recorder
+	rng      *rand.Rand
+
+	api   *client.Client
+	game  *client.GameClient
+	found chan struct{} // 收到 match_found 消息
+}
+
+// NewBot 创建第 index 个机器人
+func NewBot(index int, cfg *Config, recorder *Recorder) *Bot {
+	return &Bot{
+		index:    index,
+		cfg:      cfg,
+		recorder: recorder,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano() + int64(index))),
+		api:      client.New(cfg.GatewayURL),
+		found:    make(chan struct{}, 1),
+	}
+}
+
+// Run 运行到 ctx 结束，出错的步骤已记录在统计中
+func (b *Bot) Run(ctx context.Context) {
+	if err := b.authenticate(ctx); err != nil {
+		return
+	}
+	if err := b.connect(ctx); err != nil {
+		return
+	}
+	defer b.game.Close()
+
+	b.recorder.active.Add(1)
+	defer b.recorder.active.Add(-1)
+
+	if b.cfg.Queue {
+		if err := b.waitMatch(ctx); err != nil {
+			return
+		}
+	}
+	b.play(ctx)
+}
+
+// authenticate 登录机器人账号，账号不存在时注册
+func (b *Bot) authenticate(ctx context.Context) error {
+	username := fmt.Sprintf("%s%d", b.cfg.Prefix, b.index)
+	loginErr := b.recorder.Time(opLogin, func() error {
+		_, err := b.api.Login(ctx, username, b.cfg.Password)
+		return err
+	})
+	if loginErr == nil || ctx.Err() != nil {
+		return loginErr
+	}
+	return b.recorder.Time(opRegister, func() error {
+		_, err := b.api.Register(ctx, username, b.cfg.Password, username+"@loadtest.local")
+		return err
+	})
+}
+
+// connect 连接游戏服务器并完成握手
+func (b *Bot) connect(ctx context.Context) error {
+	cfg := b.api.GameConfig(b.cfg.GameURL)
+	cfg.ClientVersion = "loadtest"
+	cfg.MaxReconnectAttempts = 3
+	if b.cfg.Msgpack {
+		cfg.Capabilities = []string{protocol.CapabilityMsgpack}
+	}
+	b.game = client.NewGameClient(cfg)
+	b.game.On("game_frame", func(*client.Message) { b.recorder.frames.Add(1) })
+	b.game.On("match_found", func(*client.Message) {
+		select {
+		case b.found <- struct{}{}:
+		default:
+		}
+	})
+	for _, msgType := range []string{"game_frame", "heartbeat", "match_found", "room_state", "room_history", "match_phase", "match_status", "match_point", "chat", "kill", "error"} {
+		b.game.On(msgType, func(*client.Message) { b.recorder.received.Add(1) })
+	}
+
+	return b.recorder.Time(opConnect, func() error {
+		return b.game.Connect(ctx)
+	})
+}
+
+// waitMatch 加入匹配队列并等待 match_found 消息
+func (b *Bot) waitMatch(ctx context.Context) error {
+	err := b.recorder.Time(opJoinQueue, func() error {
+		return b.api.JoinQueue(ctx, models.GameMode(b.cfg.Mode), b.cfg.CharacterID)
+	})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	timer := time.NewTimer(b.cfg.MatchTimeout)
+	defer timer.Stop()
+	select {
+	case <-b.found:
+		b.recorder.Observe(opMatchWait, time.Since(start))
+		b.recorder.matched.Add(1)
+		return nil
+	case <-timer.C:
+		b.recorder.Fail(opMatchWait, errMatchTimeout)
+		b.api.LeaveQueue(context.Background(), models.GameMode(b.cfg.Mode))
+		return errMatchTimeout
+	case <-b.game.Done():
+		err := b.game.Err()
+		if err == nil {
+			err = client.ErrClosed
+		}
+		b.recorder.Fail(opMatchWait, err)
+		return err
+	case <-ctx.Done():
+		b.api.LeaveQueue(context.Background(), models.GameMode(b.cfg.Mode))
+		return ctx.Err()
+	}
+}
+
+// play 按机器人的输入频率发送移动、转向和技能输入，模拟玩家在场内游走
+func (b *Bot) play(ctx context.Context) {
+	rate := b.cfg.MinRate
+	if b.cfg.MaxRate > b.cfg.MinRate {
+		rate += b.rng.Intn(b.cfg.MaxRate - b.cfg.MinRate + 1)
+	}
+	interval := time.Second / time.Duration(rate)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	rttTicker := time.NewTicker(rttSampleInterval)
+	defer rttTicker.Stop()
+
+	angle := b.rng.Float64() * 2 * math.Pi
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.game.Done():
+			if err := b.game.Err(); err != nil {
+				b.recorder.Fail(opInput, err)
+			}
+			return
+		case <-rttTicker.C:
+			if rtt := b.game.RTT(); rtt > 0 {
+				b.recorder.Observe(opRTT, rtt)
+			}
+		case <-ticker.C:
+			// 按泊松过程随机转向和释放技能，频率与输入频率无关
+			if b.chance(interval, directionChangeInterval) {
+				angle += (b.rng.Float64() - 0.5) * math.Pi
+			}
+			input := b.input(angle, b.chance(interval, skillInterval))
+			err := b.recorder.Time(opInput, func() error {
+				return b.game.Send("player_input", input)
+			})
+			if err == nil {
+				b.recorder.inputs.Add(1)
+			}
+		}
+	}
+}
+
+// chance 在 tick 时长内发生平均间隔为 mean 的事件的概率
+func (b *Bot) chance(tick, mean time.Duration) bool {
+	return b.rng.Float64() < float64(tick)/float64(mean)
+}
+
+// input 生成一条玩家输入，载荷与 protocol.PlayerInput 的 JSON 格式一致
+func (b *Bot) input(angle float64, skill bool) map[string]interface{} {
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	rotation := math.Mod(angle*180/math.Pi, 360)
+	if rotation < 0 {
+		rotation += 360
+	}
+	input := map[string]interface{}{
+		"timestamp": time.Now().UnixMilli(),
+		"move": map[string]interface{}{
+			"direction": map[string]float64{"x": dx, "y": dy},
+			"speed":     1,
+		},
+		"rotate": map[string]float64{"rotation": rotation},
+	}
+	if skill {
+		input["skill"] = map[string]interface{}{
+			"skill_id": b.rng.Intn(skillCount) + 1,
+			"target":   map[string]float64{"x": dx * 200, "y": dy * 200},
+		}
+	}
+	return input
+}
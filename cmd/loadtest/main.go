@@ -0,0 +1,128 @@
+// main.go
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// Config 压测参数
+type Config struct {
+	GatewayURL   string
+	GameURL      string
+	Players      int
+	RampUp       time.Duration
+	Duration     time.Duration
+	MinRate      int
+	MaxRate      int
+	Mode         string
+	CharacterID  int
+	Queue        bool
+	MatchTimeout time.Duration
+	Prefix       string
+	Password     string
+	Msgpack      bool
+	Interval     time.Duration
+}
+
+// validate 校验参数
+func (c *Config) validate() error {
+	switch {
+	case c.Players <= 0:
+		return fmt.Errorf("players 必须大于0")
+	case c.Duration <= 0:
+		return fmt.Errorf("duration 必须大于0")
+	case c.RampUp < 0:
+		return fmt.Errorf("ramp 不能为负数")
+	case c.MinRate <= 0 || c.MaxRate < c.MinRate:
+		return fmt.Errorf("输入频率应满足 0 < rate-min <= rate-max")
+	case c.Queue && !models.GameMode(c.Mode).IsValid():
+		return fmt.Errorf("无效的游戏模式: %s", c.Mode)
+	case c.Queue && c.MatchTimeout <= 0:
+		return fmt.Errorf("match-timeout 必须大于0")
+	case c.Password == "":
+		return fmt.Errorf("password 不能为空")
+	}
+	return nil
+}
+
+func main() {
+	cfg := &Config{}
+	flag.StringVar(&cfg.GatewayURL, "gateway", "http://localhost:8082", "网关地址")
+	flag.StringVar(&cfg.GameURL, "ws", "ws://localhost:8080/ws", "游戏服务器 WebSocket 地址")
+	flag.IntVar(&cfg.Players, "players", 100, "模拟玩家数")
+	flag.DurationVar(&cfg.RampUp, "ramp", 10*time.Second, "在这段时间内均匀启动所有玩家")
+	flag.DurationVar(&cfg.Duration, "duration", time.Minute, "压测时长，包含启动时间")
+	flag.IntVar(&cfg.MinRate, "rate-min", 20, "每个玩家每秒发送输入的最小次数")
+	flag.IntVar(&cfg.MaxRate, "rate-max", 60, "每个玩家每秒发送输入的最大次数，每个玩家在范围内随机取值")
+	flag.StringVar(&cfg.Mode, "mode", string(models.DeathMatch), "匹配的游戏模式")
+	flag.IntVar(&cfg.CharacterID, "character", 1, "匹配时使用的角色ID")
+	flag.BoolVar(&cfg.Queue, "queue", true, "加入匹配并在匹配成功后发送输入，为 false 时连接后直接发送输入")
+	flag.DurationVar(&cfg.MatchTimeout, "match-timeout", 30*time.Second, "等待匹配成功的超时时间")
+	flag.StringVar(&cfg.Prefix, "prefix", "loadtest_", "机器人账号的用户名前缀，账号不存在时自动注册")
+	flag.StringVar(&cfg.Password, "password", "loadtest123", "机器人账号的密码")
+	flag.BoolVar(&cfg.Msgpack, "msgpack", false, "使用 MessagePack 编码游戏消息")
+	flag.DurationVar(&cfg.Interval, "interval", 5*time.Second, "输出进度的间隔，0 表示不输出")
+	flag.Parse()
+
+	if err := cfg.validate(); err != nil {
+		log.Fatalf("参数错误: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Duration)
+	defer cancel()
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	recorder := NewRecorder()
+	log.Printf("启动 %d 个模拟玩家，网关 %s，游戏服务器 %s，时长 %s", cfg.Players, cfg.GatewayURL, cfg.GameURL, cfg.Duration)
+
+	if cfg.Interval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					log.Println(recorder.Progress())
+				}
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	var step time.Duration
+	if cfg.Players > 1 {
+		step = cfg.RampUp / time.Duration(cfg.Players-1)
+	}
+launch:
+	for i := 0; i < cfg.Players; i++ {
+		if i > 0 && step > 0 {
+			select {
+			case <-ctx.Done():
+				break launch
+			case <-time.After(step):
+			}
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			NewBot(index, cfg, recorder).Run(ctx)
+		}(i)
+	}
+
+	wg.Wait()
+	recorder.Report(os.Stdout)
+}
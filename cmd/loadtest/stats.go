@@ -0,0 +1,192 @@
+// stats.go
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/client"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 统计的操作，按报告中的输出顺序排列
+const (
+	opLogin     = "login"
+	opRegister  = "register"
+	opConnect   = "connect"
+	opJoinQueue = "join_queue"
+	opMatchWait = "match_wait"
+	opInput     = "input"
+	opRTT       = "rtt"
+)
+
+var operations = []string{opLogin, opRegister, opConnect, opJoinQueue, opMatchWait, opInput, opRTT}
+
+// maxErrorKinds 报告中每个操作列出的错误种类数
+const maxErrorKinds = 5
+
+// opStats 单个操作的耗时和错误
+type opStats struct {
+	latencies []time.Duration
+	errors    int
+	kinds     map[string]int
+}
+
+// Recorder 汇总所有机器人的操作耗时和错误，可被多个协程共用
+type Recorder struct {
+	mu  sync.Mutex
+	ops map[string]*opStats
+
+	// 消息计数，用于输出进度
+	inputs   atomic.Int64
+	received atomic.Int64
+	frames   atomic.Int64
+
+	active  atomic.Int64
+	matched atomic.Int64
+	start   time.Time
+}
+
+// NewRecorder 创建统计器
+func NewRecorder() *Recorder {
+	return &Recorder{ops: make(map[string]*opStats), start: time.Now()}
+}
+
+// stats 操作的统计项，调用方需持有锁
+func (r *Recorder) stats(op string) *opStats {
+	s, ok := r.ops[op]
+	if !ok {
+		s = &opStats{kinds: make(map[string]int)}
+		r.ops[op] = s
+	}
+	return s
+}
+
+// Observe 记录一次成功操作的耗时
+func (r *Recorder) Observe(op string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats(op)
+	s.latencies = append(s.latencies, d)
+}
+
+// Fail 记录一次失败的操作，按错误码或错误类型归类
+func (r *Recorder) Fail(op string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats(op)
+	s.errors++
+	s.kinds[errorKind(err)]++
+}
+
+// Time 执行 fn 并记录耗时或错误
+func (r *Recorder) Time(op string, fn func() error) error {
+	start := time.Now()
+	if err := fn(); err != nil {
+		r.Fail(op, err)
+		return err
+	}
+	r.Observe(op, time.Since(start))
+	return nil
+}
+
+// errorKind 错误的归类，优先使用网关返回的错误码
+func errorKind(err error) string {
+	var apiErr *client.APIError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return fmt.Sprintf("%d %s", apiErr.StatusCode, apiErr.Code)
+	case errcode.Of(err) != "":
+		return string(errcode.Of(err))
+	case errors.Is(err, errMatchTimeout):
+		return "timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "network timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		msg := []rune(err.Error())
+		if len(msg) > 60 {
+			return string(msg[:60]) + "..."
+		}
+		return string(msg)
+	}
+}
+
+// Progress 运行中的简要进度
+func (r *Recorder) Progress() string {
+	r.mu.Lock()
+	errorCount := 0
+	for _, s := range r.ops {
+		errorCount += s.errors
+	}
+	r.mu.Unlock()
+
+	elapsed := time.Since(r.start).Seconds()
+	return fmt.Sprintf("[%5.0fs] 在线 %d, 已匹配 %d, 输入 %d (%.0f/s), 收到消息 %d, 错误 %d",
+		elapsed, r.active.Load(), r.matched.Load(), r.inputs.Load(), float64(r.inputs.Load())/elapsed,
+		r.received.Load(), errorCount)
+}
+
+// Report 输出各操作的耗时分位数和错误率
+func (r *Recorder) Report(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := time.Since(r.start)
+	fmt.Fprintf(w, "\n运行时间 %s, 发送输入 %d (%.0f/s), 收到消息 %d, 其中游戏帧 %d\n\n",
+		elapsed.Round(time.Second), r.inputs.Load(), float64(r.inputs.Load())/elapsed.Seconds(),
+		r.received.Load(), r.frames.Load())
+	fmt.Fprintf(w, "%-11s %8s %8s %7s %9s %9s %9s %9s\n", "operation", "ok", "errors", "error%", "p50", "p90", "p99", "max")
+
+	for _, op := range operations {
+		s, ok := r.ops[op]
+		if !ok {
+			continue
+		}
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		total := len(s.latencies) + s.errors
+		fmt.Fprintf(w, "%-11s %8d %8d %6.2f%% %9s %9s %9s %9s\n", op, len(s.latencies), s.errors,
+			100*float64(s.errors)/float64(total),
+			percentile(s.latencies, 0.50), percentile(s.latencies, 0.90), percentile(s.latencies, 0.99),
+			percentile(s.latencies, 1))
+	}
+
+	for _, op := range operations {
+		s, ok := r.ops[op]
+		if !ok || len(s.kinds) == 0 {
+			continue
+		}
+		kinds := make([]string, 0, len(s.kinds))
+		for kind := range s.kinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Slice(kinds, func(i, j int) bool { return s.kinds[kinds[i]] > s.kinds[kinds[j]] })
+		if len(kinds) > maxErrorKinds {
+			kinds = kinds[:maxErrorKinds]
+		}
+		parts := make([]string, len(kinds))
+		for i, kind := range kinds {
+			parts[i] = fmt.Sprintf("%s ×%d", kind, s.kinds[kind])
+		}
+		fmt.Fprintf(w, "%s 错误: %s\n", op, strings.Join(parts, ", "))
+	}
+}
+
+// percentile 已排序耗时的分位数，没有数据时为 "-"
+func percentile(sorted []time.Duration, p float64) string {
+	if len(sorted) == 0 {
+		return "-"
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i].Round(100 * time.Microsecond).String()
+}
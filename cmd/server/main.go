@@ -3,17 +3,28 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/alert"
+	"github.com/jacl-coder/PixelStorm-Server/internal/balance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
 	"github.com/jacl-coder/PixelStorm-Server/internal/game"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
 	"github.com/jacl-coder/PixelStorm-Server/internal/gateway"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
 	"github.com/jacl-coder/PixelStorm-Server/internal/match"
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
 	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/logging"
 )
 
 func main() {
@@ -27,30 +38,71 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 启用日志文件
+	logFile, err := logging.Setup(config.GlobalConfig.Log, *serviceType)
+	if err != nil {
+		log.Fatalf("初始化日志失败: %v", err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
 	// 初始化数据库连接
-	if err := db.InitPostgres(); err != nil {
-		log.Fatalf("初始化PostgreSQL失败: %v", err)
+	if err := db.Init(); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
 	}
 	defer db.Close()
 
+	// 检查数据库表结构版本
+	if err := db.CheckSchema(config.GlobalConfig.Database.SchemaCheck); err != nil {
+		log.Fatalf("数据库表结构检查失败: %v", err)
+	}
+
 	// 初始化Redis连接
 	if err := db.InitRedis(); err != nil {
 		log.Fatalf("初始化Redis失败: %v", err)
 	}
 	defer db.CloseRedis()
 
+	// 初始化事件总线
+	events.Init(config.GlobalConfig.Events)
+	defer events.Close()
+
+	// 启用健康告警
+	alert.Init(config.GlobalConfig.Alerts, *serviceType)
+
+	// 启用错误上报，退出前发送完剩余的事件
+	report.Init(config.GlobalConfig.ErrorReporting, *serviceType)
+	defer report.Flush(5 * time.Second)
+
+	// 预热静态游戏数据缓存
+	gamedata.Init()
+
+	// 加载功能开关
+	feature.Init()
+
+	// 加载游戏平衡配置
+	balance.Init(config.GlobalConfig.GameModes)
+
+	// 加载计划维护
+	maintenance.Init(config.GlobalConfig.Maintenance)
+
+	// 加载限时活动
+	liveevent.Init()
+
 
 
 	// 根据服务类型启动不同的服务
+	var services []service
 	switch *serviceType {
 	case "game":
-		startGameServer()
+		services = startGameServer()
 	case "match":
-		startMatchServer()
+		services = startMatchServer()
 	case "gateway":
-		startGatewayServer()
+		services = startGatewayServer()
 	case "all":
-		startAllServices()
+		services = startAllServices()
 	default:
 		log.Fatalf("未知的服务类型: %s", *serviceType)
 	}
@@ -62,11 +114,46 @@ func main() {
 
 	log.Println("接收到关闭信号，正在关闭服务器...")
 
+	// 数据库和Redis连接在各服务停止后才由defer关闭
+	timeout := config.GlobalConfig.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if !stopServices(ctx, services) {
+		log.Println("部分服务未能在超时时间内停止，已强制关闭剩余连接")
+		return
+	}
+
 	log.Println("服务器已安全关闭")
 }
 
+// defaultShutdownTimeout 未配置时等待各服务停止的最长时间
+const defaultShutdownTimeout = 15 * time.Second
+
+// service 已启动的服务
+type service struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// stopServices 按启动的相反顺序（网关 → 匹配 → 游戏）依次停止服务，
+// 先停止接收新请求的入口，再停止其依赖的服务。各服务共用 ctx 的截止时间，超时后强制关闭剩余连接，
+// 返回时所有服务都已停止，调用方可以安全关闭数据库等依赖。在截止时间内全部停止返回true
+func stopServices(ctx context.Context, services []service) bool {
+	for i := len(services) - 1; i >= 0; i-- {
+		s := services[i]
+		log.Printf("正在停止%s...", s.name)
+		if err := s.stop(ctx); err != nil {
+			log.Printf("停止%s失败: %v", s.name, err)
+		}
+	}
+	return ctx.Err() == nil
+}
+
 // startGameServer 启动游戏服务器
-func startGameServer() {
+func startGameServer() []service {
 	// 创建游戏服务器
 	server := game.NewGameServer(&config.GlobalConfig)
 
@@ -76,10 +163,11 @@ func startGameServer() {
 	}
 
 	log.Println("游戏服务器已启动")
+	return []service{{name: "游戏服务器", stop: server.Stop}}
 }
 
 // startMatchServer 启动匹配服务器
-func startMatchServer() {
+func startMatchServer() []service {
 	// 创建游戏服务器（匹配服务需要游戏服务器引用）
 	gameServer := game.NewGameServer(&config.GlobalConfig)
 
@@ -92,10 +180,11 @@ func startMatchServer() {
 	}
 
 	log.Println("匹配服务已启动")
+	return []service{matchStopper(matchService)}
 }
 
 // startGatewayServer 启动网关服务器
-func startGatewayServer() {
+func startGatewayServer() []service {
 	// 创建网关服务
 	gatewayServer := gateway.NewGateway(&config.GlobalConfig)
 
@@ -105,10 +194,11 @@ func startGatewayServer() {
 	}
 
 	log.Println("网关服务已启动")
+	return []service{{name: "网关服务", stop: gatewayServer.Stop}}
 }
 
-// startAllServices 启动所有服务
-func startAllServices() {
+// startAllServices 启动所有服务，返回的服务按启动顺序排列
+func startAllServices() []service {
 	// 创建游戏服务器
 	gameServer := game.NewGameServer(&config.GlobalConfig)
 
@@ -117,21 +207,35 @@ func startAllServices() {
 		log.Fatalf("启动游戏服务器失败: %v", err)
 	}
 
-	// 创建匹配服务
+	// 创建网关服务
+	gatewayServer := gateway.NewGateway(&config.GlobalConfig)
+
+	// 创建匹配服务，使用网关的会话存储校验登录会话
 	matchService := match.NewMatchService(&config.GlobalConfig, gameServer)
+	matchService.SetSessionValidator(gatewayServer.ValidateSession)
 
 	// 启动匹配服务
 	if err := matchService.Start(); err != nil {
 		log.Fatalf("启动匹配服务失败: %v", err)
 	}
 
-	// 创建网关服务
-	gatewayServer := gateway.NewGateway(&config.GlobalConfig)
-
 	// 启动网关服务
 	if err := gatewayServer.Start(); err != nil {
 		log.Fatalf("启动网关服务失败: %v", err)
 	}
 
 	log.Println("所有服务已启动")
+	return []service{
+		{name: "游戏服务器", stop: gameServer.Stop},
+		matchStopper(matchService),
+		{name: "网关服务", stop: gatewayServer.Stop},
+	}
+}
+
+// matchStopper 匹配服务的停止函数
+func matchStopper(s *match.MatchService) service {
+	return service{name: "匹配服务", stop: func(ctx context.Context) error {
+		s.Stop(ctx)
+		return nil
+	}}
 }
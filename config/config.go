@@ -4,6 +4,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +14,32 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
+	Admin    AdminConfig    `mapstructure:"admin"`
+	Session  SessionConfig  `mapstructure:"session"`
+	Stats    StatsConfig    `mapstructure:"stats"`
+	Match    MatchConfig    `mapstructure:"match"`
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Avatar   AvatarConfig   `mapstructure:"avatar"`
+
+	// 各游戏模式房间的默认数值，键为游戏模式
+	GameModes map[string]GameModeConfig `mapstructure:"game_modes"`
+
+	CharacterLevel CharacterLevelConfig `mapstructure:"character_level"`
+	Gift           GiftConfig           `mapstructure:"gift"`
+	Party          PartyConfig          `mapstructure:"party"`
+	Push           PushConfig           `mapstructure:"push"`
+	Payments       PaymentsConfig       `mapstructure:"payments"`
+	Exchange       ExchangeConfig       `mapstructure:"exchange"`
+	Trial          TrialConfig          `mapstructure:"trial"`
+	Events         EventsConfig         `mapstructure:"events"`
+	Retention      RetentionConfig      `mapstructure:"retention"`
+	WebSocket      WebSocketConfig      `mapstructure:"websocket"`
+	Log            LogConfig            `mapstructure:"log"`
+	Alerts         AlertsConfig         `mapstructure:"alerts"`
+	Maintenance    MaintenanceConfig    `mapstructure:"maintenance"`
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"`
+	DebugCapture   DebugCaptureConfig   `mapstructure:"debug_capture"`
+	Registry       RegistryConfig       `mapstructure:"registry"`
 }
 
 // ServerConfig 服务器基本配置
@@ -24,16 +51,49 @@ type ServerConfig struct {
 	LogLevel     string `mapstructure:"log_level"`
 	MaxRoomCount int    `mapstructure:"max_room_count"`
 	MaxPlayers   int    `mapstructure:"max_players"`
+
+	// 允许连接的最低客户端协议版本，低于该版本的客户端握手时被要求升级，0 表示服务端兼容的最低版本
+	MinProtocolVersion int `mapstructure:"min_protocol_version"`
+
+	// 收到关闭信号后等待各服务停止的最长时间，超时后强制关闭剩余连接，0 表示默认15秒
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	// 网关前的反向代理地址(IP 或 CIDR)，只信任来自这些地址的 X-Forwarded-For 和 X-Real-IP，
+	// 为空时一律使用连接的对端地址作为客户端IP
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	// 数据库驱动：postgres（默认）或 sqlite。sqlite 仅用于本地开发，启动时自动建表
+	Driver     string `mapstructure:"driver"`
+	SQLitePath string `mapstructure:"sqlite_path"` // SQLite 数据库文件路径，:memory: 表示内存库
+
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	DBName   string `mapstructure:"dbname"`
 	SSLMode  string `mapstructure:"sslmode"`
+
+	// 连接池配置（pgxpool），0 表示使用 pgxpool 的默认值
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`    // 最大打开连接数
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`    // 常驻的最少连接数
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"` // 连接最长复用时间
+
+	// 语句执行模式：cache_statement（缓存预编译语句）、cache_describe、describe_exec、exec、simple_protocol，
+	// 经 PgBouncer 事务池连接时应使用 exec 或 simple_protocol
+	QueryExecMode          string `mapstructure:"query_exec_mode"`
+	StatementCacheCapacity int    `mapstructure:"statement_cache_capacity"` // 每个连接缓存的预编译语句数
+
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"` // 慢查询日志阈值，0 表示不记录
+
+	// 启动时的表结构版本检查：strict（版本不一致时拒绝启动，默认）、readonly（以只读模式启动）、off（不检查）
+	SchemaCheck string `mapstructure:"schema_check"`
+
+	// 只读从库，排行榜、角色列表和战绩等只读查询优先发往从库
+	Replicas             []string      `mapstructure:"replicas"`               // 从库连接字符串
+	ReplicaCheckInterval time.Duration `mapstructure:"replica_check_interval"` // 从库健康检查间隔
 }
 
 // RedisConfig Redis配置
@@ -42,6 +102,272 @@ type RedisConfig struct {
 	Port     int    `mapstructure:"port"`
 	Password string `mapstructure:"password"`
 	DB       int    `mapstructure:"db"`
+
+	// 部署模式：standalone（默认，使用 host/port）、sentinel、cluster
+	Mode             string   `mapstructure:"mode"`
+	MasterName       string   `mapstructure:"master_name"`       // Sentinel 监控的主节点名称
+	Addrs            []string `mapstructure:"addrs"`             // Sentinel 地址或 Cluster 种子节点地址
+	SentinelPassword string   `mapstructure:"sentinel_password"` // Sentinel 自身的密码
+}
+
+// AdminConfig 管理接口配置
+type AdminConfig struct {
+	APIKey string `mapstructure:"api_key"` // 管理接口密钥，为空时禁用所有管理接口
+}
+
+// SessionConfig 登录会话配置
+type SessionConfig struct {
+	TTL         time.Duration `mapstructure:"ttl"`          // 会话有效期，开启续期时从最后一次有效请求起算，0 表示默认24小时
+	Sliding     bool          `mapstructure:"sliding"`      // 携带有效令牌的请求自动续期会话
+	MaxLifetime time.Duration `mapstructure:"max_lifetime"` // 续期后距登录的最长时间，到期后必须重新登录，0 表示不限
+
+	// Redis 不可用时会话暂存在内存中，超过上限时淘汰最久未使用的会话，0 表示默认10000
+	LocalMaxEntries int `mapstructure:"local_max_entries"`
+}
+
+// StatsConfig 战绩与排行榜配置
+type StatsConfig struct {
+	LeaderboardRefreshInterval time.Duration `mapstructure:"leaderboard_refresh_interval"` // 排行榜定时刷新间隔
+	LeaderboardCheckInterval   time.Duration `mapstructure:"leaderboard_check_interval"`   // 排行榜过期检查间隔
+}
+
+// MatchConfig 匹配与分队配置
+type MatchConfig struct {
+	AvoidBlockedTeammates bool `mapstructure:"avoid_blocked_teammates"` // 分队时尽量避免互相屏蔽的玩家同队
+}
+
+// GameModeConfig 游戏模式的房间默认数值，为0的字段使用代码中的默认值
+type GameModeConfig struct {
+	TimeLimit   int `mapstructure:"time_limit"`   // 时间限制(秒)
+	ScoreLimit  int `mapstructure:"score_limit"`  // 分数限制
+	RespawnTime int `mapstructure:"respawn_time"` // 重生时间(秒)
+
+	// 重生时间随对局进行线性延长，时间到时达到最长重生时间(秒)，不大于 respawn_time 时固定不变
+	RespawnMaxTime int `mapstructure:"respawn_max_time"`
+	// 重生波次间隔(秒)，阵亡玩家等待重生时间后在下一个波次统一重生，为0时不分波次
+	RespawnWave int `mapstructure:"respawn_wave"`
+
+	// 所有玩家准备后的热身时间(秒)，热身期间的击杀不计分，结束后重置战绩并倒计时开始正式对局
+	WarmupTime    int `mapstructure:"warmup_time"`
+	CountdownTime int `mapstructure:"countdown_time"` // 热身结束后的倒计时(秒)
+
+	// 时间到时比分持平的加时方式: none, sudden_death, extra_time
+	Overtime     string `mapstructure:"overtime"`
+	OvertimeTime int    `mapstructure:"overtime_time"` // 加时时长(秒)，突然死亡为0时不限时
+
+	// 击杀、助攻、占点计分周期和夺旗的得分，每获得1分所需的治疗量，未配置时使用代码中的默认值，
+	// 可配置为0关闭该项得分，治疗量为0时治疗不得分
+	KillScore        *int `mapstructure:"kill_score"`
+	AssistScore      *int `mapstructure:"assist_score"`
+	CaptureTickScore *int `mapstructure:"capture_tick_score"`
+	FlagCaptureScore *int `mapstructure:"flag_capture_score"`
+	HealingPerPoint  *int `mapstructure:"healing_per_point"`
+
+	// 玩家之间有碰撞体积，不能互相穿过
+	BodyBlocking bool `mapstructure:"body_blocking"`
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Driver    string   `mapstructure:"driver"`     // local 或 s3
+	LocalDir  string   `mapstructure:"local_dir"`  // 本地存储目录
+	PublicURL string   `mapstructure:"public_url"` // 对外访问地址前缀，为空时本地存储使用 /uploads
+	S3        S3Config `mapstructure:"s3"`
+}
+
+// S3Config S3兼容对象存储配置
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"` // 为空时使用AWS官方地址，兼容MinIO等自建服务
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// AvatarConfig 头像上传配置
+type AvatarConfig struct {
+	MaxUploadSize int64 `mapstructure:"max_upload_size"` // 上传文件大小上限(字节)
+	MaxDimension  int   `mapstructure:"max_dimension"`   // 图片宽高上限(像素)
+}
+
+// CharacterLevelConfig 角色等级成长曲线配置
+type CharacterLevelConfig struct {
+	MaxLevel int     `mapstructure:"max_level"` // 等级上限
+	Curve    string  `mapstructure:"curve"`     // linear 或 exponential
+	BaseExp  int     `mapstructure:"base_exp"`  // 1级升2级所需经验
+	Growth   float64 `mapstructure:"growth"`    // linear 为每级递增的经验，exponential 为每级的倍率
+	ExpTable []int   `mapstructure:"exp_table"` // 自定义每级升级所需经验，设置后忽略曲线参数
+}
+
+// GiftConfig 玩家赠礼防刷限制
+type GiftConfig struct {
+	MinAccountAgeDays int   `mapstructure:"min_account_age_days"` // 注册满指定天数才能赠礼
+	DailyGiftLimit    int   `mapstructure:"daily_gift_limit"`     // 每日最多赠礼次数，0为不限
+	DailyGemCap       int64 `mapstructure:"daily_gem_cap"`        // 每日最多赠送宝石数，0为不限
+	MaxMessageLength  int   `mapstructure:"max_message_length"`   // 留言最大字符数
+}
+
+// PartyConfig 组队配置
+type PartyConfig struct {
+	MaxSize     int           `mapstructure:"max_size"`     // 队伍人数上限
+	InviteTTL   time.Duration `mapstructure:"invite_ttl"`   // 组队邀请有效期
+	ChatHistory int           `mapstructure:"chat_history"` // 队伍聊天保留的消息条数
+}
+
+// PushConfig 移动推送配置
+type PushConfig struct {
+	Enabled bool       `mapstructure:"enabled"` // 关闭时只记录设备，不发送推送
+	FCM     FCMConfig  `mapstructure:"fcm"`
+	APNs    APNsConfig `mapstructure:"apns"`
+}
+
+// FCMConfig Firebase Cloud Messaging 配置（HTTP v1 接口）
+type FCMConfig struct {
+	ProjectID       string `mapstructure:"project_id"`       // 为空时使用服务账号文件中的项目ID
+	CredentialsFile string `mapstructure:"credentials_file"` // 服务账号JSON文件，为空时不推送安卓设备
+}
+
+// APNsConfig Apple Push Notification service 配置（令牌认证）
+type APNsConfig struct {
+	KeyFile string `mapstructure:"key_file"` // .p8 私钥文件，为空时不推送iOS设备
+	KeyID   string `mapstructure:"key_id"`
+	TeamID  string `mapstructure:"team_id"`
+	Topic   string `mapstructure:"topic"`   // 应用的 Bundle ID
+	Sandbox bool   `mapstructure:"sandbox"` // 使用开发环境
+}
+
+// PaymentsConfig 应用内购买配置
+type PaymentsConfig struct {
+	Apple        AppleIAPConfig  `mapstructure:"apple"`
+	Google       GoogleIAPConfig `mapstructure:"google"`
+	AllowSandbox bool            `mapstructure:"allow_sandbox"` // 是否接受沙盒/测试购买，生产环境应关闭
+	Products     []IAPProduct    `mapstructure:"products"`
+}
+
+// AppleIAPConfig App Store 收据校验配置
+type AppleIAPConfig struct {
+	BundleID     string `mapstructure:"bundle_id"`     // 为空时不支持App Store购买
+	SharedSecret string `mapstructure:"shared_secret"` // App专用共享密钥
+}
+
+// GoogleIAPConfig Google Play 购买校验配置
+type GoogleIAPConfig struct {
+	PackageName     string `mapstructure:"package_name"`     // 为空时不支持Google Play购买
+	CredentialsFile string `mapstructure:"credentials_file"` // 有 Android Publisher 权限的服务账号文件
+}
+
+// ExchangeConfig 宝石兑换金币配置
+type ExchangeConfig struct {
+	CoinsPerGem   int64 `mapstructure:"coins_per_gem"`   // 每颗宝石兑换的金币数，0为关闭兑换
+	MinGems       int64 `mapstructure:"min_gems"`        // 单次最少兑换的宝石数
+	DailyGemLimit int64 `mapstructure:"daily_gem_limit"` // 每日最多兑换的宝石数，0为不限
+}
+
+// TrialConfig 角色试玩配置
+type TrialConfig struct {
+	RotationSize  int   `mapstructure:"rotation_size"`   // 每周免费轮换的角色数，0为关闭轮换
+	RentalHours   int   `mapstructure:"rental_hours"`    // 每次租用的时长(小时)
+	RentalGemCost int64 `mapstructure:"rental_gem_cost"` // 每次租用花费的宝石，0为关闭租用
+}
+
+// EventsConfig 事件总线配置
+type EventsConfig struct {
+	Backend       string        `mapstructure:"backend"`        // redis（Redis Streams，持久化）或 memory（进程内，不持久化）
+	Stream        string        `mapstructure:"stream"`         // Redis Stream 键名
+	MaxLen        int64         `mapstructure:"max_len"`        // Stream 保留的最大事件数（近似）
+	ClaimIdle     time.Duration `mapstructure:"claim_idle"`     // 未确认的事件超过该时长后重新投递
+	MaxDeliveries int64         `mapstructure:"max_deliveries"` // 单个事件最多投递次数，超过后丢弃并记录日志
+}
+
+// RetentionConfig 对局数据保留与归档配置
+type RetentionConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`      // 是否启用归档任务
+	MatchMonths int           `mapstructure:"match_months"` // 在线表保留的月数，更早的对局按月归档
+	Interval    time.Duration `mapstructure:"interval"`     // 归档任务执行间隔
+	BatchSize   int           `mapstructure:"batch_size"`   // 每个事务归档的对局数
+}
+
+// WebSocketConfig 游戏服务器 WebSocket 配置
+type WebSocketConfig struct {
+	Compression          bool `mapstructure:"compression"`           // 客户端支持时协商 permessage-deflate 压缩
+	CompressionThreshold int  `mapstructure:"compression_threshold"` // 小于该字节数的消息不压缩
+	CompressionLevel     int  `mapstructure:"compression_level"`     // flate 压缩级别 1-9，0 为默认级别
+}
+
+// LogConfig 日志文件配置。日志始终输出到控制台，配置 dir 后同时写入按大小轮转的文件
+type LogConfig struct {
+	Dir        string `mapstructure:"dir"`          // 日志目录，为空时只输出到控制台
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`  // 单个文件达到该大小(MB)后轮转
+	MaxAgeDays int    `mapstructure:"max_age_days"` // 轮转后的文件保留天数，0 表示不按时间清理
+	MaxBackups int    `mapstructure:"max_backups"`  // 保留的轮转文件数，0 表示不按数量清理
+	Compress   bool   `mapstructure:"compress"`     // 是否 gzip 压缩轮转后的文件
+
+	// 按服务类型（game、match、gateway、all）覆盖，未设置的字段使用上面的值
+	Services map[string]LogServiceConfig `mapstructure:"services"`
+}
+
+// LogServiceConfig 单个服务的日志文件配置
+type LogServiceConfig struct {
+	Disabled   bool   `mapstructure:"disabled"`     // 该服务不写日志文件
+	File       string `mapstructure:"file"`         // 文件名，默认为 <服务类型>.log
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`  // 0 表示使用全局值
+	MaxAgeDays int    `mapstructure:"max_age_days"` // 0 表示使用全局值
+	MaxBackups int    `mapstructure:"max_backups"`  // 0 表示使用全局值
+}
+
+// AlertsConfig 服务健康告警配置
+type AlertsConfig struct {
+	Webhooks      []AlertWebhook `mapstructure:"webhooks"`       // 为空时不发送告警
+	Throttle      time.Duration  `mapstructure:"throttle"`       // 同一告警的最短发送间隔，期间重复的告警被抑制
+	CheckInterval time.Duration  `mapstructure:"check_interval"` // 数据库和 Redis 连通性检查间隔
+}
+
+// AlertWebhook 告警 Webhook
+type AlertWebhook struct {
+	URL    string `mapstructure:"url"`
+	Format string `mapstructure:"format"` // slack、discord 或 generic（原始 JSON）
+}
+
+// MaintenanceConfig 计划维护配置
+type MaintenanceConfig struct {
+	Warnings    []time.Duration `mapstructure:"warnings"`     // 维护开始前向在线玩家发送警告的时间点
+	DrainBefore time.Duration   `mapstructure:"drain_before"` // 维护开始前多久停止匹配，0 表示维护开始时才停止
+}
+
+// ErrorReportingConfig 错误上报配置，上报协议兼容 Sentry
+type ErrorReportingConfig struct {
+	DSN         string  `mapstructure:"dsn"`         // 为空时不上报，形如 https://<公钥>@sentry.example.com/<项目ID>
+	Environment string  `mapstructure:"environment"` // 运行环境，如 production、staging
+	Release     string  `mapstructure:"release"`     // 发布版本，为空时使用构建信息中的版本或提交号
+	SampleRate  float64 `mapstructure:"sample_rate"` // 5xx 响应的上报比例(0-1]，0 表示全部上报，panic 总是上报
+}
+
+// DebugCaptureConfig 调试抓包配置，用于复现玩家反馈的接口问题，生产环境默认关闭
+type DebugCaptureConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`        // 为 false 时管理接口也无法开启抓包
+	MaxBodyBytes int           `mapstructure:"max_body_bytes"` // 单个请求体或响应体最多记录的字节数
+	MaxRecords   int           `mapstructure:"max_records"`    // 最多保留的记录数，超出后丢弃最早的记录
+	MaxDuration  time.Duration `mapstructure:"max_duration"`   // 单次抓包的最长时长，到期后自动停止
+}
+
+// RegistryConfig 服务注册配置。游戏和匹配服务启动后向网关注册并定期发送心跳，
+// 网关据此转发请求；未设置 secret 时网关退回到转发本机端口
+type RegistryConfig struct {
+	GatewayURL        string        `mapstructure:"gateway_url"`        // 游戏和匹配服务注册时访问的网关地址，如 http://gateway:8080
+	Secret            string        `mapstructure:"secret"`             // 注册接口密钥，网关和各服务需一致
+	AdvertiseHost     string        `mapstructure:"advertise_host"`     // 网关访问本实例使用的主机名或IP，为空时使用本机主机名
+	Region            string        `mapstructure:"region"`             // 实例所在区域，如 cn-east、eu-west
+	PublicURL         string        `mapstructure:"public_url"`         // 客户端直连本实例的地址，游戏服务器据此提供区域延迟探测
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // 心跳间隔，0 表示默认10秒
+	TTL               time.Duration `mapstructure:"ttl"`                // 网关在该时间内未收到心跳时移除实例，0 表示心跳间隔的3倍
+}
+
+// IAPProduct 应用内购买商品
+type IAPProduct struct {
+	ProductID  string `mapstructure:"product_id"`  // 商店中配置的商品ID，两个商店需一致
+	Gems       int64  `mapstructure:"gems"`        // 发放的宝石数量
+	PriceCents int64  `mapstructure:"price_cents"` // 标价（分），用于收入统计
+	Currency   string `mapstructure:"currency"`    // 标价币种
 }
 
 var (
@@ -49,19 +375,29 @@ var (
 	GlobalConfig Config
 )
 
-// LoadConfig 从文件加载配置
+// LoadConfig 从文件加载配置。优先级从高到低：环境变量（PIXELSTORM_ 前缀，
+// 或 _FILE 后缀指向的密钥文件）、PIXELSTORM_SECRETS_FILE 指定的密钥配置文件、配置文件
 func LoadConfig(configPath string) error {
 	viper.SetConfigFile(configPath)
-	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("无法读取配置文件: %w", err)
 	}
+	if err := mergeSecretsFile(); err != nil {
+		return err
+	}
+	if err := bindEnv(); err != nil {
+		return err
+	}
 
 	if err := viper.Unmarshal(&GlobalConfig); err != nil {
 		return fmt.Errorf("无法解析配置文件: %w", err)
 	}
 
+	if err := GlobalConfig.Validate(); err != nil {
+		return fmt.Errorf("配置校验失败:\n%w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,99 @@
+// env.go
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 环境变量前缀，配置项 database.password 对应 PIXELSTORM_DATABASE_PASSWORD
+const EnvPrefix = "PIXELSTORM"
+
+// SecretsFileEnv 指定额外密钥配置文件的环境变量，文件格式与主配置相同，加载后覆盖主配置中的同名项
+const SecretsFileEnv = EnvPrefix + "_SECRETS_FILE"
+
+// EnvName 配置项对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// configKeys 按 mapstructure 标签列出配置结构中可以用环境变量覆盖的配置项，
+// 结构体切片（如 payments.products）只能在配置文件中设置
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := prefix + tag
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			keys = append(keys, configKeys(field.Type, key+".")...)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			continue
+		default:
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// bindEnv 为每个配置项绑定环境变量。viper.AutomaticEnv 只对配置文件中出现过的键生效，
+// 显式绑定后文件中缺省的嵌套配置项也能由环境变量提供。
+// 以 _FILE 结尾的同名环境变量指向挂载的密钥文件，读取文件内容作为配置值
+func bindEnv() error {
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		name := EnvName(key)
+		if err := viper.BindEnv(key, name); err != nil {
+			return fmt.Errorf("绑定环境变量 %s 失败: %w", name, err)
+		}
+
+		path, ok := os.LookupEnv(name + "_FILE")
+		if !ok {
+			continue
+		}
+		if _, set := os.LookupEnv(name); set {
+			return fmt.Errorf("环境变量 %s 和 %s_FILE 不能同时设置", name, name)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取 %s_FILE 指定的密钥文件失败: %w", name, err)
+		}
+		viper.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return nil
+}
+
+// mergeSecretsFile 合并 PIXELSTORM_SECRETS_FILE 指定的密钥配置文件，未设置时跳过
+func mergeSecretsFile() error {
+	path := os.Getenv(SecretsFileEnv)
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开密钥配置文件失败: %w", err)
+	}
+	defer f.Close()
+
+	// 没有扩展名的文件（如 Kubernetes Secret 挂载的文件）按 yaml 解析
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format == "" {
+		format = "yaml"
+	}
+	viper.SetConfigType(format)
+	if err := viper.MergeConfig(f); err != nil {
+		return fmt.Errorf("解析密钥配置文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
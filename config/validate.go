@@ -0,0 +1,248 @@
+// validate.go
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Validate 检查必填项和取值范围，返回全部问题而不是遇到第一个就停止
+func (c *Config) Validate() error {
+	var errs []error
+	required := func(key string, missing bool) {
+		if missing {
+			errs = append(errs, fmt.Errorf("%s 未设置，可在配置文件中填写，或通过环境变量 %s（或 %s_FILE 指向的密钥文件）提供",
+				key, EnvName(key), EnvName(key)))
+		}
+	}
+	invalid := func(key string, value any, allowed string) {
+		errs = append(errs, fmt.Errorf("%s 的值 %v 无效，可选值: %s", key, value, allowed))
+	}
+
+	// 服务端口
+	ports := map[int]string{}
+	for _, p := range []struct {
+		key  string
+		port int
+	}{
+		{"server.game_port", c.Server.GamePort},
+		{"server.match_port", c.Server.MatchPort},
+		{"server.gateway_port", c.Server.GatewayPort},
+	} {
+		key, port := p.key, p.port
+		required(key, port == 0)
+		if port < 0 || port > 65535 {
+			invalid(key, port, "1-65535")
+		}
+		if other, ok := ports[port]; ok && port != 0 {
+			errs = append(errs, fmt.Errorf("%s 与 %s 使用了相同的端口 %d", key, other, port))
+		}
+		ports[port] = key
+	}
+
+	if c.Server.MinProtocolVersion < 0 {
+		invalid("server.min_protocol_version", c.Server.MinProtocolVersion, "0 或正整数")
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		invalid("server.shutdown_timeout", c.Server.ShutdownTimeout, "0 或正时长")
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, err := ParseTrustedProxy(proxy); err != nil {
+			invalid("server.trusted_proxies", proxy, "IP 地址或 CIDR")
+		}
+	}
+
+	// 数据库
+	switch c.Database.Driver {
+	case "", "postgres":
+		required("database.host", c.Database.Host == "")
+		required("database.port", c.Database.Port == 0)
+		required("database.user", c.Database.User == "")
+		required("database.dbname", c.Database.DBName == "")
+	case "sqlite":
+	default:
+		invalid("database.driver", c.Database.Driver, "postgres, sqlite")
+	}
+	switch c.Database.SchemaCheck {
+	case "", "strict", "readonly", "off":
+	default:
+		invalid("database.schema_check", c.Database.SchemaCheck, "strict, readonly, off")
+	}
+
+	// Redis
+	switch c.Redis.Mode {
+	case "", "standalone":
+		required("redis.host", c.Redis.Host == "")
+		required("redis.port", c.Redis.Port == 0)
+	case "sentinel":
+		required("redis.master_name", c.Redis.MasterName == "")
+		required("redis.addrs", len(c.Redis.Addrs) == 0)
+	case "cluster":
+		required("redis.addrs", len(c.Redis.Addrs) == 0)
+	default:
+		invalid("redis.mode", c.Redis.Mode, "standalone, sentinel, cluster")
+	}
+
+	switch c.Storage.Driver {
+	case "", "local":
+	case "s3":
+		required("storage.s3.bucket", c.Storage.S3.Bucket == "")
+		required("storage.s3.access_key", c.Storage.S3.AccessKey == "")
+		required("storage.s3.secret_key", c.Storage.S3.SecretKey == "")
+	default:
+		invalid("storage.driver", c.Storage.Driver, "local, s3")
+	}
+
+	if c.Session.TTL < 0 {
+		invalid("session.ttl", c.Session.TTL, "0 或正时长")
+	}
+	if c.Session.MaxLifetime < 0 || (c.Session.MaxLifetime > 0 && c.Session.MaxLifetime < c.Session.TTL) {
+		invalid("session.max_lifetime", c.Session.MaxLifetime, "0 或不小于 session.ttl 的时长")
+	}
+	if c.Session.LocalMaxEntries < 0 {
+		invalid("session.local_max_entries", c.Session.LocalMaxEntries, "0 或正整数")
+	}
+
+	switch c.Events.Backend {
+	case "", "redis", "memory":
+	default:
+		invalid("events.backend", c.Events.Backend, "redis, memory")
+	}
+
+	if c.Retention.Enabled {
+		if c.Retention.MatchMonths <= 0 {
+			invalid("retention.match_months", c.Retention.MatchMonths, "大于0的月数")
+		}
+		if c.Retention.Interval <= 0 {
+			invalid("retention.interval", c.Retention.Interval, "大于0的时长，如 24h")
+		}
+	}
+
+	if c.WebSocket.CompressionLevel < 0 || c.WebSocket.CompressionLevel > 9 {
+		invalid("websocket.compression_level", c.WebSocket.CompressionLevel, "0-9")
+	}
+	if c.WebSocket.CompressionThreshold < 0 {
+		invalid("websocket.compression_threshold", c.WebSocket.CompressionThreshold, "0 或正整数")
+	}
+
+	for _, v := range []struct {
+		key   string
+		value int
+	}{
+		{"log.max_size_mb", c.Log.MaxSizeMB},
+		{"log.max_age_days", c.Log.MaxAgeDays},
+		{"log.max_backups", c.Log.MaxBackups},
+	} {
+		if v.value < 0 {
+			invalid(v.key, v.value, "0 或正整数")
+		}
+	}
+	for i, hook := range c.Alerts.Webhooks {
+		if hook.URL == "" {
+			errs = append(errs, fmt.Errorf("alerts.webhooks[%d].url 未设置", i))
+		}
+		switch hook.Format {
+		case "", "slack", "discord", "generic":
+		default:
+			invalid(fmt.Sprintf("alerts.webhooks[%d].format", i), hook.Format, "slack, discord, generic")
+		}
+	}
+	if c.Alerts.Throttle < 0 || c.Alerts.CheckInterval < 0 {
+		errs = append(errs, fmt.Errorf("alerts.throttle 和 alerts.check_interval 不能为负数"))
+	}
+	for i, warning := range c.Maintenance.Warnings {
+		if warning <= 0 {
+			errs = append(errs, fmt.Errorf("maintenance.warnings[%d] 必须为正数", i))
+		}
+	}
+	if c.Maintenance.DrainBefore < 0 {
+		errs = append(errs, fmt.Errorf("maintenance.drain_before 不能为负数"))
+	}
+	if dsn := c.ErrorReporting.DSN; dsn != "" {
+		if u, err := url.Parse(dsn); err != nil || u.Host == "" || u.User.Username() == "" || strings.Trim(u.Path, "/") == "" {
+			errs = append(errs, fmt.Errorf("error_reporting.dsn 格式无效，应为 https://<公钥>@<主机>/<项目ID>"))
+		}
+	}
+	if c.ErrorReporting.SampleRate < 0 || c.ErrorReporting.SampleRate > 1 {
+		invalid("error_reporting.sample_rate", c.ErrorReporting.SampleRate, "0-1")
+	}
+	if c.DebugCapture.MaxBodyBytes < 0 || c.DebugCapture.MaxRecords < 0 || c.DebugCapture.MaxDuration < 0 {
+		errs = append(errs, fmt.Errorf("debug_capture 的参数不能为负数"))
+	}
+	if gatewayURL := c.Registry.GatewayURL; gatewayURL != "" {
+		if u, err := url.Parse(gatewayURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("registry.gateway_url 格式无效，应为 http(s)://<主机>:<端口>"))
+		}
+		required("registry.secret", c.Registry.Secret == "")
+	}
+	if publicURL := c.Registry.PublicURL; publicURL != "" {
+		if u, err := url.Parse(publicURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("registry.public_url 格式无效，应为 http(s)://<主机>[:<端口>]"))
+		}
+	}
+	if c.Registry.HeartbeatInterval < 0 || c.Registry.TTL < 0 {
+		errs = append(errs, fmt.Errorf("registry.heartbeat_interval 和 registry.ttl 不能为负数"))
+	}
+	if c.Registry.TTL > 0 && c.Registry.HeartbeatInterval > 0 && c.Registry.TTL <= c.Registry.HeartbeatInterval {
+		errs = append(errs, fmt.Errorf("registry.ttl 必须大于 registry.heartbeat_interval"))
+	}
+
+	for mode, m := range c.GameModes {
+		switch mode {
+		case "death_match", "team_death_match", "capture_point", "flag_capture":
+		default:
+			invalid("game_modes", mode, "death_match, team_death_match, capture_point, flag_capture")
+		}
+		switch m.Overtime {
+		case "", "none", "sudden_death", "extra_time":
+		default:
+			invalid("game_modes."+mode+".overtime", m.Overtime, "none, sudden_death, extra_time")
+		}
+		if m.TimeLimit < 0 || m.ScoreLimit < 0 || m.RespawnTime < 0 || m.WarmupTime < 0 || m.CountdownTime < 0 || m.OvertimeTime < 0 ||
+			m.RespawnMaxTime < 0 || m.RespawnWave < 0 ||
+			anyNegative(m.KillScore, m.AssistScore, m.CaptureTickScore, m.FlagCaptureScore, m.HealingPerPoint) {
+			errs = append(errs, fmt.Errorf("game_modes.%s 的数值不能为负数", mode))
+		}
+	}
+
+	for service, s := range c.Log.Services {
+		switch service {
+		case "game", "match", "gateway", "all":
+		default:
+			invalid("log.services", service, "game, match, gateway, all")
+		}
+		if s.MaxSizeMB < 0 || s.MaxAgeDays < 0 || s.MaxBackups < 0 {
+			errs = append(errs, fmt.Errorf("log.services.%s 的轮转参数不能为负数", service))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// anyNegative 判断已配置的可选数值中是否有负数
+func anyNegative(values ...*int) bool {
+	for _, v := range values {
+		if v != nil && *v < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTrustedProxy 解析受信任的代理地址，单个IP视为只包含该地址的网段
+func ParseTrustedProxy(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
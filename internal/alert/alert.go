@@ -0,0 +1,201 @@
+// alert.go
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+const (
+	// defaultThrottle 未配置时同一告警的最短发送间隔
+	defaultThrottle = 5 * time.Minute
+
+	// sendTimeout 单次 Webhook 调用超时
+	sendTimeout = 10 * time.Second
+)
+
+// Level 告警级别
+type Level string
+
+const (
+	// LevelCritical 故障
+	LevelCritical Level = "critical"
+	// LevelResolved 故障恢复
+	LevelResolved Level = "resolved"
+)
+
+// Alert 告警内容
+type Alert struct {
+	Key        string    `json:"key"` // 告警对象，如 instance:game-1、dependency:redis，按对象和级别节流
+	Level      Level     `json:"level"`
+	Title      string    `json:"title"`
+	Message    string    `json:"message"`
+	Service    string    `json:"service"` // 发出告警的服务类型
+	Time       time.Time `json:"time"`
+	Suppressed int       `json:"suppressed,omitempty"` // 上次发送后被抑制的相同告警数
+}
+
+// alertsTotal 告警发送结果
+var alertsTotal = metrics.Default.NewCounterVec("pixelstorm_alerts_total",
+	"告警数，status 为 sent、throttled 或 failed", "level", "status")
+
+// throttleState 同一告警最近一次发送的时间和之后被抑制的次数
+type throttleState struct {
+	sentAt     time.Time
+	suppressed int
+}
+
+// Notifier 告警发送器，异步调用 Webhook，同一对象同一级别的告警在节流时间内只发送一次
+type Notifier struct {
+	webhooks []config.AlertWebhook
+	throttle time.Duration
+	service  string
+	client   *http.Client
+
+	mu   sync.Mutex
+	last map[string]*throttleState
+}
+
+// NewNotifier 创建告警发送器
+func NewNotifier(cfg config.AlertsConfig, service string) *Notifier {
+	throttle := cfg.Throttle
+	if throttle <= 0 {
+		throttle = defaultThrottle
+	}
+	return &Notifier{
+		webhooks: cfg.Webhooks,
+		throttle: throttle,
+		service:  service,
+		client:   &http.Client{Timeout: sendTimeout},
+		last:     make(map[string]*throttleState),
+	}
+}
+
+// Notify 发送告警，不阻塞调用方。发送器为nil时忽略
+func (n *Notifier) Notify(a Alert) {
+	if n == nil || len(n.webhooks) == 0 {
+		return
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	a.Service = n.service
+
+	if !n.allow(&a) {
+		alertsTotal.Inc(string(a.Level), "throttled")
+		return
+	}
+	go n.send(a)
+}
+
+// allow 检查告警是否超过节流间隔，通过时带上期间被抑制的次数
+func (n *Notifier) allow(a *Alert) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	key := a.Key + "/" + string(a.Level)
+	state, ok := n.last[key]
+	if !ok {
+		state = &throttleState{}
+		n.last[key] = state
+	} else if a.Time.Sub(state.sentAt) < n.throttle {
+		state.suppressed++
+		return false
+	}
+
+	a.Suppressed = state.suppressed
+	state.sentAt = a.Time
+	state.suppressed = 0
+	return true
+}
+
+// send 向所有 Webhook 发送告警，失败只记录日志
+func (n *Notifier) send(a Alert) {
+	for _, hook := range n.webhooks {
+		if err := n.post(hook, a); err != nil {
+			alertsTotal.Inc(string(a.Level), "failed")
+			log.Printf("发送告警 %s 失败: %v", a.Key, err)
+			continue
+		}
+		alertsTotal.Inc(string(a.Level), "sent")
+	}
+}
+
+// post 按 Webhook 格式编码并发送告警
+func (n *Notifier) post(hook config.AlertWebhook, a Alert) error {
+	body, err := encode(hook.Format, a)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook 返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encode 按 Webhook 格式编码告警：slack 和 discord 为纯文本消息，generic 为原始 JSON
+func encode(format string, a Alert) ([]byte, error) {
+	switch format {
+	case "slack":
+		return json.Marshal(map[string]string{"text": text(a)})
+	case "discord":
+		return json.Marshal(map[string]string{"content": text(a)})
+	default:
+		return json.Marshal(a)
+	}
+}
+
+// text 告警的文本形式
+func text(a Alert) string {
+	tag := "[告警]"
+	if a.Level == LevelResolved {
+		tag = "[恢复]"
+	}
+	s := fmt.Sprintf("%s %s\n%s\n服务: %s  时间: %s", tag, a.Title, a.Message, a.Service, a.Time.Format(time.RFC3339))
+	if a.Suppressed > 0 {
+		s += fmt.Sprintf("\n（上次通知后另有 %d 条相同告警被抑制）", a.Suppressed)
+	}
+	return s
+}
+
+// Default 全局告警发送器，未配置 Webhook 时为nil
+var Default *Notifier
+
+// Init 按配置创建全局告警发送器，并开始监控数据库和 Redis 连通性
+func Init(cfg config.AlertsConfig, service string) {
+	if len(cfg.Webhooks) == 0 {
+		return
+	}
+	Default = NewNotifier(cfg, service)
+	go watchDependencies(Default, cfg.CheckInterval)
+	log.Printf("已启用 %d 个告警 Webhook", len(cfg.Webhooks))
+}
+
+// Notify 通过全局告警发送器发送告警
+func Notify(a Alert) {
+	Default.Notify(a)
+}
@@ -0,0 +1,66 @@
+// dependency.go
+
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/health"
+)
+
+// defaultCheckInterval 未配置时数据库和 Redis 连通性检查间隔
+const defaultCheckInterval = 30 * time.Second
+
+// dependency 需要监控连通性的外部依赖
+type dependency struct {
+	name  string
+	check health.Check
+}
+
+var dependencies = []dependency{
+	{name: "数据库", check: health.Database},
+	{name: "Redis", check: health.Redis},
+}
+
+// watchDependencies 定期检查数据库和 Redis，连接断开和恢复时各告警一次
+func watchDependencies(n *Notifier, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	healthy := make(map[string]bool, len(dependencies))
+	for _, dep := range dependencies {
+		healthy[dep.name] = true
+	}
+
+	for range ticker.C {
+		for _, dep := range dependencies {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := dep.check(ctx)
+			cancel()
+			if errors.Is(err, health.ErrSkipped) {
+				continue
+			}
+
+			ok := err == nil
+			if ok == healthy[dep.name] {
+				continue
+			}
+			healthy[dep.name] = ok
+
+			a := Alert{Key: "dependency:" + dep.name, Level: LevelResolved,
+				Title: dep.name + "连接已恢复", Message: dep.name + "连通性检查通过"}
+			if !ok {
+				a.Level = LevelCritical
+				a.Title = dep.name + "连接断开"
+				a.Message = fmt.Sprintf("%s连通性检查失败: %v", dep.name, err)
+			}
+			n.Notify(a)
+		}
+	}
+}
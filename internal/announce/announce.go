@@ -0,0 +1,144 @@
+// announce.go
+
+package announce
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// Channel 全服公告的 Redis 频道，各游戏服务器实例订阅后广播给本实例上连接的玩家
+const Channel = "announcements"
+
+const (
+	// maxTitleLength 公告标题最大字符数
+	maxTitleLength = 50
+	// maxMessageLength 公告正文最大字符数
+	maxMessageLength = 500
+)
+
+// ErrInvalidAnnouncement 公告参数无效
+var ErrInvalidAnnouncement = errcode.New(errcode.BadRequest, "无效的公告")
+
+var publishedTotal = metrics.Default.NewCounterVec("pixelstorm_announcements_published_total",
+	"发布的全服公告数", "severity")
+
+// Handler 收到公告时的回调
+type Handler func(a models.Announcement)
+
+var (
+	localMu       sync.RWMutex
+	localHandlers []Handler
+)
+
+// Publish 校验并发布全服公告。连接Redis时经频道转发到所有实例，否则只投递给本进程
+func Publish(req *models.AnnounceRequest) (*models.Announcement, error) {
+	a, err := build(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.RedisClient == nil {
+		deliver(*a)
+	} else {
+		data, err := json.Marshal(a)
+		if err != nil {
+			return nil, fmt.Errorf("序列化公告失败: %w", err)
+		}
+		if err := db.RedisClient.Publish(context.Background(), Channel, data).Err(); err != nil {
+			return nil, fmt.Errorf("发布公告失败: %w", err)
+		}
+	}
+
+	publishedTotal.Inc(string(a.Severity))
+	return a, nil
+}
+
+// build 校验请求并生成公告
+func build(req *models.AnnounceRequest) (*models.Announcement, error) {
+	if req.Severity == "" {
+		req.Severity = models.SeverityInfo
+	}
+	if !req.Severity.IsValid() {
+		return nil, fmt.Errorf("%w: 未知的级别 %s", ErrInvalidAnnouncement, req.Severity)
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		return nil, fmt.Errorf("%w: 公告内容不能为空", ErrInvalidAnnouncement)
+	}
+	if utf8.RuneCountInString(req.Title) > maxTitleLength {
+		return nil, fmt.Errorf("%w: 标题不能超过%d个字符", ErrInvalidAnnouncement, maxTitleLength)
+	}
+	if utf8.RuneCountInString(req.Message) > maxMessageLength {
+		return nil, fmt.Errorf("%w: 内容不能超过%d个字符", ErrInvalidAnnouncement, maxMessageLength)
+	}
+
+	now := time.Now()
+	if req.MaintenanceAt != nil && !req.MaintenanceAt.After(now) {
+		return nil, fmt.Errorf("%w: 维护时间必须晚于当前时间", ErrInvalidAnnouncement)
+	}
+
+	return &models.Announcement{
+		ID:            uuid.New().String(),
+		Severity:      req.Severity,
+		Title:         req.Title,
+		Message:       req.Message,
+		MaintenanceAt: req.MaintenanceAt,
+		CreatedAt:     now,
+	}, nil
+}
+
+// Listen 接收全服公告并调用 fn，直到 stop 关闭。
+// 连接Redis时订阅频道，否则接收本进程内发布的公告
+func Listen(stop <-chan struct{}, fn Handler) {
+	if db.RedisClient == nil {
+		localMu.Lock()
+		localHandlers = append(localHandlers, fn)
+		localMu.Unlock()
+		return
+	}
+	pubsub := db.RedisClient.Subscribe(context.Background(), Channel)
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var a models.Announcement
+				if err := json.Unmarshal([]byte(msg.Payload), &a); err != nil {
+					log.Printf("解析全服公告失败: %v", err)
+					continue
+				}
+				fn(a)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// deliver 投递给本进程内的接收者
+func deliver(a models.Announcement) {
+	localMu.RLock()
+	defer localMu.RUnlock()
+	for _, fn := range localHandlers {
+		fn(a)
+	}
+}
@@ -0,0 +1,128 @@
+// audit.go
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+const (
+	// DefaultLimit 查询未指定条数时返回的记录数
+	DefaultLimit = 100
+	// MaxLimit 单次查询的最大记录数
+	MaxLimit = 1000
+)
+
+// Record 写入一条审计记录，修改前状态和请求体都是 JSON 对象时计算变更的字段
+func Record(entry *models.AdminAuditEntry) error {
+	if db.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	if entry.Diff == nil {
+		entry.Diff = Diff(entry.Before, entry.Payload)
+	}
+
+	var diff []byte
+	if len(entry.Diff) > 0 {
+		var err error
+		if diff, err = json.Marshal(entry.Diff); err != nil {
+			return fmt.Errorf("序列化审计变更失败: %w", err)
+		}
+	}
+
+	err := db.DB.QueryRow(`
+		INSERT INTO admin_audit_log (actor, ip, method, target, status, before, payload, diff)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, entry.Actor, entry.IP, entry.Method, entry.Target, entry.Status,
+		nullJSON(entry.Before), nullJSON(entry.Payload), nullJSON(diff),
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("写入审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// Query 按时间区间查询审计记录，最新的在前
+func Query(q models.AuditQuery) ([]models.AdminAuditEntry, error) {
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+
+	conditions := []string{"created_at >= $1", "created_at < $2"}
+	args := []interface{}{q.From, q.To}
+	if q.Actor != "" {
+		args = append(args, q.Actor)
+		conditions = append(conditions, fmt.Sprintf("actor = $%d", len(args)))
+	}
+	if q.Target != "" {
+		args = append(args, q.Target+"%")
+		conditions = append(conditions, fmt.Sprintf("target LIKE $%d", len(args)))
+	}
+	args = append(args, q.Limit)
+
+	rows, err := db.DB.Query(fmt.Sprintf(`
+		SELECT id, actor, ip, method, target, status, before, payload, diff, created_at
+		FROM admin_audit_log
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询审计日志失败: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.AdminAuditEntry, 0)
+	for rows.Next() {
+		var entry models.AdminAuditEntry
+		var before, payload, diff []byte
+		if err := rows.Scan(&entry.ID, &entry.Actor, &entry.IP, &entry.Method, &entry.Target, &entry.Status,
+			&before, &payload, &diff, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描审计日志失败: %w", err)
+		}
+		entry.Before = before
+		entry.Payload = payload
+		if len(diff) > 0 {
+			if err := json.Unmarshal(diff, &entry.Diff); err != nil {
+				return nil, fmt.Errorf("解析审计变更失败: %w", err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Diff 比较请求体与修改前状态，返回请求体中取值发生变化的顶层字段。任一方不是 JSON 对象时返回nil
+func Diff(before, payload json.RawMessage) map[string]models.FieldChange {
+	var old, updated map[string]interface{}
+	if len(before) == 0 || len(payload) == 0 ||
+		json.Unmarshal(before, &old) != nil || json.Unmarshal(payload, &updated) != nil {
+		return nil
+	}
+
+	changes := make(map[string]models.FieldChange)
+	for key, value := range updated {
+		if previous, ok := old[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = models.FieldChange{From: old[key], To: value}
+		}
+	}
+	return changes
+}
+
+// nullJSON 空的 JSON 写入为 NULL
+func nullJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,58 @@
+// authsession.go
+
+package authsession
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record 网关写入 Redis 的登录会话
+type Record struct {
+	PlayerID  int64
+	Username  string
+	IssuedAt  time.Time // 旧版本写入的会话没有登录时间，为零值
+	ExpiresAt time.Time
+}
+
+// Key 登录令牌对应会话的 Redis 键
+func Key(token string) string {
+	return "session:" + token
+}
+
+// Encode 编码会话: 玩家ID:用户名:过期时间:登录时间(Unix秒)
+func Encode(r Record) string {
+	return fmt.Sprintf("%d:%s:%d:%d", r.PlayerID, r.Username, r.ExpiresAt.Unix(), r.IssuedAt.Unix())
+}
+
+// Decode 解析 Encode 编码的会话，兼容旧版本没有登录时间的格式，格式不正确时返回 false
+func Decode(data string) (Record, bool) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return Record{}, false
+	}
+	playerID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return Record{}, false
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Record{}, false
+	}
+
+	r := Record{
+		PlayerID:  playerID,
+		Username:  parts[1],
+		ExpiresAt: time.Unix(expiresAt, 0),
+	}
+	if len(parts) == 4 {
+		issuedAt, err := strconv.ParseInt(parts[3], 10, 64)
+		if err != nil {
+			return Record{}, false
+		}
+		r.IssuedAt = time.Unix(issuedAt, 0)
+	}
+	return r, true
+}
@@ -0,0 +1,45 @@
+// authsession_test.go
+
+package authsession
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	issued := time.Unix(1700000000, 0)
+	expires := issued.Add(24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		data   string
+		want   Record
+		wantOK bool
+	}{
+		{
+			name:   "编码后可解析",
+			data:   Encode(Record{PlayerID: 42, Username: "alice", IssuedAt: issued, ExpiresAt: expires}),
+			want:   Record{PlayerID: 42, Username: "alice", IssuedAt: issued, ExpiresAt: expires},
+			wantOK: true,
+		},
+		{
+			name:   "旧版本没有登录时间",
+			data:   "42:alice:1700086400",
+			want:   Record{PlayerID: 42, Username: "alice", ExpiresAt: expires},
+			wantOK: true,
+		},
+		{name: "字段数不对", data: "42:alice"},
+		{name: "玩家ID无效", data: "x:alice:1700086400"},
+		{name: "过期时间无效", data: "42:alice:x:1700000000"},
+		{name: "登录时间无效", data: "42:alice:1700086400:x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Decode(tt.data)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Decode(%q) = %+v, %v, want %+v, %v", tt.data, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
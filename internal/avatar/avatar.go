@@ -0,0 +1,248 @@
+// avatar.go
+
+package avatar
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image"
+	_ "image/gif"  // 注册GIF解码器
+	_ "image/jpeg" // 注册JPEG解码器
+	_ "image/png"  // 注册PNG解码器
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/storage"
+)
+
+// 默认上传限制
+const (
+	defaultMaxUploadSize = 512 * 1024
+	defaultMaxDimension  = 1024
+)
+
+// allowedTypes 允许上传的图片类型及扩展名
+var allowedTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+var (
+	// ErrAvatarNotFound 头像不存在
+	ErrAvatarNotFound = errcode.New(errcode.NotFound, "头像不存在")
+	// ErrAvatarLocked 未解锁该头像
+	ErrAvatarLocked = errcode.New(errcode.NotOwned, "未解锁该头像")
+	// ErrImageTooLarge 图片过大
+	ErrImageTooLarge = errcode.New(errcode.PayloadTooLarge, "图片过大")
+	// ErrInvalidImage 图片格式无效
+	ErrInvalidImage = errcode.New(errcode.BadRequest, "仅支持PNG、JPEG和GIF图片")
+	// ErrInvalidAvatar 头像参数无效
+	ErrInvalidAvatar = errcode.New(errcode.BadRequest, "无效的头像")
+)
+
+// Service 头像服务
+type Service struct {
+	store         storage.Storage
+	maxUploadSize int64
+	maxDimension  int
+}
+
+// NewService 创建头像服务
+func NewService(store storage.Storage, cfg config.AvatarConfig) *Service {
+	s := &Service{
+		store:         store,
+		maxUploadSize: cfg.MaxUploadSize,
+		maxDimension:  cfg.MaxDimension,
+	}
+	if s.maxUploadSize <= 0 {
+		s.maxUploadSize = defaultMaxUploadSize
+	}
+	if s.maxDimension <= 0 {
+		s.maxDimension = defaultMaxDimension
+	}
+	return s
+}
+
+// MaxUploadSize 上传文件大小上限
+func (s *Service) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// Catalog 查询头像目录，activeOnly为true时只返回可选头像
+func Catalog(activeOnly bool) ([]models.Avatar, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, name, image_url, COALESCE(unlock_item_id, 0), active, created_at
+		FROM avatars
+		WHERE active OR NOT $1
+		ORDER BY id
+	`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("查询头像目录失败: %w", err)
+	}
+	defer rows.Close()
+
+	avatars := make([]models.Avatar, 0)
+	for rows.Next() {
+		var a models.Avatar
+		if err := rows.Scan(&a.ID, &a.Name, &a.ImageURL, &a.UnlockItemID, &a.Active, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描头像目录失败: %w", err)
+		}
+		avatars = append(avatars, a)
+	}
+	return avatars, rows.Err()
+}
+
+// CreateAvatar 添加预设头像
+func CreateAvatar(a *models.Avatar) error {
+	if a.Name == "" || a.ImageURL == "" {
+		return fmt.Errorf("%w: 名称和图片地址不能为空", ErrInvalidAvatar)
+	}
+
+	var unlockItemID interface{}
+	if a.UnlockItemID > 0 {
+		unlockItemID = a.UnlockItemID
+	}
+
+	a.Active = true
+	return db.DB.QueryRow(`
+		INSERT INTO avatars (name, image_url, unlock_item_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, a.Name, a.ImageURL, unlockItemID).Scan(&a.ID, &a.CreatedAt)
+}
+
+// Get 查询玩家当前头像，未设置时返回空地址
+func Get(playerID int64) (*models.PlayerAvatar, error) {
+	pa := &models.PlayerAvatar{PlayerID: playerID}
+	err := db.DB.QueryRow(`
+		SELECT COALESCE(pav.avatar_id, 0), `+models.AvatarURLSQL+`, pav.upload_url IS NOT NULL, pav.updated_at
+		FROM player_avatars pav
+		LEFT JOIN avatars av ON av.id = pav.avatar_id
+		WHERE pav.player_id = $1
+	`, playerID).Scan(&pa.AvatarID, &pa.URL, &pa.Uploaded, &pa.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询玩家头像失败: %w", err)
+	}
+	return pa, nil
+}
+
+// URL 查询玩家头像地址，未设置或数据库未初始化时返回空字符串
+func URL(playerID int64) (string, error) {
+	if db.DB == nil {
+		return "", nil
+	}
+	pa, err := Get(playerID)
+	if err != nil {
+		return "", err
+	}
+	return pa.URL, nil
+}
+
+// Select 选择预设头像，替换之前上传的头像
+func (s *Service) Select(playerID int64, avatarID int) (*models.PlayerAvatar, error) {
+	var unlockItemID int
+	err := db.DB.QueryRow(`
+		SELECT COALESCE(unlock_item_id, 0) FROM avatars WHERE id = $1 AND active
+	`, avatarID).Scan(&unlockItemID)
+	if err == sql.ErrNoRows {
+		return nil, ErrAvatarNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询头像失败: %w", err)
+	}
+
+	if unlockItemID > 0 {
+		var owned bool
+		err := db.DB.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM player_inventory WHERE player_id = $1 AND item_id = $2 AND quantity > 0)
+		`, playerID, unlockItemID).Scan(&owned)
+		if err != nil {
+			return nil, fmt.Errorf("查询头像解锁状态失败: %w", err)
+		}
+		if !owned {
+			return nil, ErrAvatarLocked
+		}
+	}
+
+	if err := s.replace(playerID, avatarID, "", ""); err != nil {
+		return nil, err
+	}
+	return Get(playerID)
+}
+
+// Upload 上传自定义头像，校验大小、格式和尺寸
+func (s *Service) Upload(playerID int64, data []byte) (*models.PlayerAvatar, error) {
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, fmt.Errorf("%w: 最大 %d 字节", ErrImageTooLarge, s.maxUploadSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, ErrInvalidImage
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || allowedTypes["image/"+format] == "" {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > s.maxDimension || cfg.Height > s.maxDimension {
+		return nil, fmt.Errorf("%w: 宽高不能超过 %d 像素", ErrImageTooLarge, s.maxDimension)
+	}
+
+	key := fmt.Sprintf("avatars/%d/%s%s", playerID, uuid.New().String(), ext)
+	url, err := s.store.Put(key, contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("保存头像失败: %w", err)
+	}
+
+	if err := s.replace(playerID, 0, url, key); err != nil {
+		if delErr := s.store.Delete(key); delErr != nil {
+			log.Printf("清理头像文件 %s 失败: %v", key, delErr)
+		}
+		return nil, err
+	}
+	return Get(playerID)
+}
+
+// replace 更新玩家头像并删除旧的上传文件
+func (s *Service) replace(playerID int64, avatarID int, uploadURL, uploadKey string) error {
+	var oldKey sql.NullString
+	err := db.DB.QueryRow(`SELECT upload_key FROM player_avatars WHERE player_id = $1`, playerID).Scan(&oldKey)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("查询玩家头像失败: %w", err)
+	}
+
+	var avatar, url, key interface{}
+	if avatarID > 0 {
+		avatar = avatarID
+	}
+	if uploadURL != "" {
+		url, key = uploadURL, uploadKey
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO player_avatars (player_id, avatar_id, upload_url, upload_key, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (player_id) DO UPDATE SET
+			avatar_id = EXCLUDED.avatar_id, upload_url = EXCLUDED.upload_url,
+			upload_key = EXCLUDED.upload_key, updated_at = NOW()
+	`, playerID, avatar, url, key)
+	if err != nil {
+		return fmt.Errorf("更新玩家头像失败: %w", err)
+	}
+
+	// 旧文件删除失败不影响头像更新
+	if oldKey.Valid && oldKey.String != "" && oldKey.String != uploadKey {
+		if err := s.store.Delete(oldKey.String); err != nil {
+			log.Printf("删除旧头像文件 %s 失败: %v", oldKey.String, err)
+		}
+	}
+	return nil
+}
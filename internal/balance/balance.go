@@ -0,0 +1,389 @@
+// balance.go
+
+package balance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// PushChannel 平衡配置推送通知的 Redis 频道
+const PushChannel = "balance:push"
+
+var (
+	// ErrInvalidBalance 平衡配置参数无效
+	ErrInvalidBalance = errcode.New(errcode.BadRequest, "无效的平衡配置")
+	// ErrBalanceNotFound 该模式没有保存的平衡配置
+	ErrBalanceNotFound = errcode.New(errcode.NotFound, "平衡配置不存在")
+)
+
+// builtin 代码中的默认数值，配置文件和数据库中都没有配置时使用
+var builtin = models.BalanceConfig{
+	Mode:        models.BalanceDefaultMode,
+	RespawnTime: 5,
+	TimeLimit:   300,
+	ScoreLimit:  20,
+	Overtime:    models.OvertimeNone,
+	KillScore:   &defaultKillScore,
+}
+
+// defaultKillScore 代码中的默认击杀得分
+var defaultKillScore = 1
+
+// modes 支持单独配置的游戏模式
+var modes = []models.GameMode{models.DeathMatch, models.TeamDeathMatch, models.CapturePoint, models.FlagCapture}
+
+// snapshot 最近一次推送到本实例的配置，按模式合并后只读
+type snapshot struct {
+	pushedAt time.Time
+	configs  map[models.GameMode]models.BalanceConfig
+}
+
+var current atomic.Pointer[snapshot]
+
+// fileDefaults 配置文件中各模式的默认数值与代码默认值合并的结果，Init 时设置，之后只读
+var fileDefaults map[models.GameMode]models.BalanceConfig
+
+// instanceID 本进程标识，收到自己发出的通知时不重复加载
+var instanceID = uuid.New().String()
+
+// For 获取游戏模式当前生效的平衡配置，房间创建时调用，之后的推送不影响已创建的房间
+func For(mode models.GameMode) models.BalanceConfig {
+	if s := current.Load(); s != nil {
+		if cfg, ok := s.configs[mode]; ok {
+			return cfg
+		}
+	}
+	cfg := builtin
+	cfg.Mode = string(mode)
+	return cfg
+}
+
+// Init 加载配置文件中各模式的默认数值和数据库中的平衡配置，Redis 可用时订阅其他实例的推送通知
+func Init(gameModes map[string]config.GameModeConfig) {
+	metrics.Default.GaugeFunc("pixelstorm_balance_pushed_timestamp_seconds",
+		"最近一次加载平衡配置的时间",
+		func() float64 {
+			if s := current.Load(); s != nil {
+				return float64(s.pushedAt.Unix())
+			}
+			return 0
+		})
+
+	fileDefaults = make(map[models.GameMode]models.BalanceConfig, len(modes))
+	for _, mode := range modes {
+		cfg := builtin
+		if m, ok := gameModes[string(mode)]; ok {
+			bodyBlocking := m.BodyBlocking
+			cfg = merge(cfg, models.BalanceConfig{
+				RespawnTime:      m.RespawnTime,
+				RespawnMaxTime:   m.RespawnMaxTime,
+				RespawnWave:      m.RespawnWave,
+				TimeLimit:        m.TimeLimit,
+				ScoreLimit:       m.ScoreLimit,
+				WarmupTime:       m.WarmupTime,
+				CountdownTime:    m.CountdownTime,
+				Overtime:         models.OvertimeMode(m.Overtime),
+				OvertimeTime:     m.OvertimeTime,
+				KillScore:        m.KillScore,
+				AssistScore:      m.AssistScore,
+				CaptureTickScore: m.CaptureTickScore,
+				FlagCaptureScore: m.FlagCaptureScore,
+				HealingPerPoint:  m.HealingPerPoint,
+				BodyBlocking:     &bodyBlocking,
+			})
+		}
+		fileDefaults[mode] = cfg
+	}
+	store(nil)
+
+	if db.DB == nil {
+		return
+	}
+	if err := Load(); err != nil {
+		log.Printf("加载平衡配置失败，使用默认数值: %v", err)
+	}
+	if db.RedisClient != nil {
+		startPushListener()
+	}
+}
+
+// Load 从数据库加载全部平衡配置，按 代码默认值 < 配置文件 < default < 模式 的顺序合并后替换当前快照
+func Load() error {
+	saved, err := saved()
+	if err != nil {
+		return err
+	}
+	store(saved)
+	return nil
+}
+
+// store 把保存的配置合并到各模式的默认数值上，替换当前快照
+func store(saved []models.BalanceConfig) {
+	var shared *models.BalanceConfig
+	byMode := make(map[string]models.BalanceConfig, len(saved))
+	for i, cfg := range saved {
+		if cfg.Mode == models.BalanceDefaultMode {
+			shared = &saved[i]
+			continue
+		}
+		byMode[cfg.Mode] = cfg
+	}
+
+	s := &snapshot{pushedAt: time.Now(), configs: make(map[models.GameMode]models.BalanceConfig, len(modes))}
+	for _, mode := range modes {
+		cfg, ok := fileDefaults[mode]
+		if !ok {
+			cfg = builtin
+		}
+		if shared != nil {
+			cfg = merge(cfg, *shared)
+		}
+		if over, ok := byMode[string(mode)]; ok {
+			cfg = merge(cfg, over)
+		}
+		cfg.Mode = string(mode)
+		s.configs[mode] = cfg
+	}
+	current.Store(s)
+}
+
+// Status 获取数据库中保存的配置和本实例当前生效的配置
+func Status() (*models.BalanceStatus, error) {
+	configs, err := saved()
+	if err != nil {
+		return nil, err
+	}
+	status := &models.BalanceStatus{
+		Saved:     configs,
+		Effective: make(map[models.GameMode]models.BalanceConfig, len(modes)),
+	}
+	if s := current.Load(); s != nil {
+		status.PushedAt = s.pushedAt
+	}
+	for _, mode := range modes {
+		status.Effective[mode] = For(mode)
+	}
+	return status, nil
+}
+
+// Save 保存模式的平衡配置，推送后才对新房间生效
+func Save(cfg *models.BalanceConfig) (*models.BalanceConfig, error) {
+	if err := validate(cfg); err != nil {
+		return nil, err
+	}
+	skills, err := json.Marshal(cfg.Skills)
+	if err != nil {
+		return nil, fmt.Errorf("序列化技能配置失败: %w", err)
+	}
+	if cfg.Skills == nil {
+		skills = []byte("{}")
+	}
+
+	err = db.DB.QueryRow(`
+		INSERT INTO balance_configs (mode, respawn_time, time_limit, score_limit, warmup_time, countdown_time,
+			overtime, overtime_time, respawn_max_time, respawn_wave, kill_score, assist_score, capture_tick_score,
+			flag_capture_score, healing_per_point, body_blocking, skills, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW())
+		ON CONFLICT (mode) DO UPDATE SET
+			respawn_time = EXCLUDED.respawn_time, time_limit = EXCLUDED.time_limit,
+			score_limit = EXCLUDED.score_limit, warmup_time = EXCLUDED.warmup_time,
+			countdown_time = EXCLUDED.countdown_time, overtime = EXCLUDED.overtime,
+			overtime_time = EXCLUDED.overtime_time, respawn_max_time = EXCLUDED.respawn_max_time,
+			respawn_wave = EXCLUDED.respawn_wave, kill_score = EXCLUDED.kill_score,
+			assist_score = EXCLUDED.assist_score, capture_tick_score = EXCLUDED.capture_tick_score,
+			flag_capture_score = EXCLUDED.flag_capture_score, healing_per_point = EXCLUDED.healing_per_point,
+			body_blocking = EXCLUDED.body_blocking, skills = EXCLUDED.skills, updated_at = NOW()
+		RETURNING updated_at
+	`, cfg.Mode, cfg.RespawnTime, cfg.TimeLimit, cfg.ScoreLimit, cfg.WarmupTime, cfg.CountdownTime,
+		cfg.Overtime, cfg.OvertimeTime, cfg.RespawnMaxTime, cfg.RespawnWave, cfg.KillScore, cfg.AssistScore,
+		cfg.CaptureTickScore, cfg.FlagCaptureScore, cfg.HealingPerPoint, cfg.BodyBlocking, skills).Scan(&cfg.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("保存平衡配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// Delete 删除模式的平衡配置，推送后恢复为上一层的数值
+func Delete(mode string) error {
+	result, err := db.DB.Exec(`DELETE FROM balance_configs WHERE mode = $1`, mode)
+	if err != nil {
+		return fmt.Errorf("删除平衡配置失败: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("%w: %s", ErrBalanceNotFound, mode)
+	}
+	return nil
+}
+
+// Push 重新加载本实例的平衡配置并通知其他实例，之后创建的房间立即使用新配置
+func Push() (*models.BalanceStatus, error) {
+	if err := Load(); err != nil {
+		return nil, err
+	}
+	if db.RedisClient != nil {
+		if err := db.RedisClient.Publish(context.Background(), PushChannel, instanceID).Err(); err != nil {
+			log.Printf("发布平衡配置推送通知失败: %v", err)
+		}
+	}
+	return Status()
+}
+
+// saved 查询数据库中保存的全部配置
+func saved() ([]models.BalanceConfig, error) {
+	rows, err := db.DB.Query(`
+		SELECT mode, respawn_time, time_limit, score_limit, warmup_time, countdown_time,
+			overtime, overtime_time, respawn_max_time, respawn_wave, kill_score, assist_score, capture_tick_score,
+			flag_capture_score, healing_per_point, body_blocking, skills, updated_at
+		FROM balance_configs ORDER BY mode
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询平衡配置失败: %w", err)
+	}
+	defer rows.Close()
+
+	configs := []models.BalanceConfig{}
+	for rows.Next() {
+		var cfg models.BalanceConfig
+		var skills []byte
+		if err := rows.Scan(&cfg.Mode, &cfg.RespawnTime, &cfg.TimeLimit, &cfg.ScoreLimit,
+			&cfg.WarmupTime, &cfg.CountdownTime, &cfg.Overtime, &cfg.OvertimeTime,
+			&cfg.RespawnMaxTime, &cfg.RespawnWave, &cfg.KillScore, &cfg.AssistScore, &cfg.CaptureTickScore,
+			&cfg.FlagCaptureScore, &cfg.HealingPerPoint, &cfg.BodyBlocking, &skills, &cfg.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("扫描平衡配置失败: %w", err)
+		}
+		if err := json.Unmarshal(skills, &cfg.Skills); err != nil {
+			return nil, fmt.Errorf("解析模式 %s 的技能配置失败: %w", cfg.Mode, err)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, rows.Err()
+}
+
+// validate 校验配置的模式和数值
+func validate(cfg *models.BalanceConfig) error {
+	cfg.Mode = strings.TrimSpace(cfg.Mode)
+	if cfg.Mode != models.BalanceDefaultMode && !models.GameMode(cfg.Mode).IsValid() {
+		return fmt.Errorf("%w: 未知的游戏模式 %s", ErrInvalidBalance, cfg.Mode)
+	}
+	if cfg.RespawnTime < 0 || cfg.TimeLimit < 0 || cfg.ScoreLimit < 0 || cfg.WarmupTime < 0 || cfg.CountdownTime < 0 ||
+		cfg.OvertimeTime < 0 || cfg.RespawnMaxTime < 0 || cfg.RespawnWave < 0 ||
+		negative(cfg.KillScore) || negative(cfg.AssistScore) || negative(cfg.CaptureTickScore) ||
+		negative(cfg.FlagCaptureScore) || negative(cfg.HealingPerPoint) {
+		return fmt.Errorf("%w: 数值不能为负数", ErrInvalidBalance)
+	}
+	if cfg.Overtime != "" && !cfg.Overtime.IsValid() {
+		return fmt.Errorf("%w: 未知的加时方式 %s", ErrInvalidBalance, cfg.Overtime)
+	}
+	for skillID, skill := range cfg.Skills {
+		if skill.Damage < 0 || skill.Cooldown < 0 {
+			return fmt.Errorf("%w: 技能 %d 的数值不能为负数", ErrInvalidBalance, skillID)
+		}
+	}
+	return nil
+}
+
+// merge 用 over 中的非零、非空字段覆盖 base，得分规则和玩家碰撞不为空即覆盖(包括0和false)，技能按字段逐个覆盖
+func merge(base, over models.BalanceConfig) models.BalanceConfig {
+	result := base
+	if over.RespawnTime > 0 {
+		result.RespawnTime = over.RespawnTime
+	}
+	if over.RespawnMaxTime > 0 {
+		result.RespawnMaxTime = over.RespawnMaxTime
+	}
+	if over.RespawnWave > 0 {
+		result.RespawnWave = over.RespawnWave
+	}
+	if over.TimeLimit > 0 {
+		result.TimeLimit = over.TimeLimit
+	}
+	if over.ScoreLimit > 0 {
+		result.ScoreLimit = over.ScoreLimit
+	}
+	if over.WarmupTime > 0 {
+		result.WarmupTime = over.WarmupTime
+	}
+	if over.CountdownTime > 0 {
+		result.CountdownTime = over.CountdownTime
+	}
+	if over.Overtime != "" {
+		result.Overtime = over.Overtime
+	}
+	if over.OvertimeTime > 0 {
+		result.OvertimeTime = over.OvertimeTime
+	}
+	if over.KillScore != nil {
+		result.KillScore = over.KillScore
+	}
+	if over.AssistScore != nil {
+		result.AssistScore = over.AssistScore
+	}
+	if over.CaptureTickScore != nil {
+		result.CaptureTickScore = over.CaptureTickScore
+	}
+	if over.FlagCaptureScore != nil {
+		result.FlagCaptureScore = over.FlagCaptureScore
+	}
+	if over.HealingPerPoint != nil {
+		result.HealingPerPoint = over.HealingPerPoint
+	}
+	if over.BodyBlocking != nil {
+		result.BodyBlocking = over.BodyBlocking
+	}
+	if over.UpdatedAt.After(result.UpdatedAt) {
+		result.UpdatedAt = over.UpdatedAt
+	}
+
+	result.Skills = make(map[int]models.SkillBalance, len(base.Skills)+len(over.Skills))
+	for skillID, skill := range base.Skills {
+		result.Skills[skillID] = skill
+	}
+	for skillID, skill := range over.Skills {
+		merged := result.Skills[skillID]
+		if skill.Damage > 0 {
+			merged.Damage = skill.Damage
+		}
+		if skill.Cooldown > 0 {
+			merged.Cooldown = skill.Cooldown
+		}
+		result.Skills[skillID] = merged
+	}
+	return result
+}
+
+// startPushListener 订阅推送通知，收到其他实例的通知后重新加载
+func startPushListener() {
+	pubsub := db.RedisClient.Subscribe(context.Background(), PushChannel)
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			if msg.Payload == instanceID {
+				continue
+			}
+			if err := Load(); err != nil {
+				log.Printf("收到推送通知后重新加载平衡配置失败: %v", err)
+				continue
+			}
+			log.Printf("收到实例 %s 的推送通知，平衡配置已重新加载", msg.Payload)
+		}
+	}()
+}
+
+// negative 判断设置了的得分规则是否为负数
+func negative(v *int) bool {
+	return v != nil && *v < 0
+}
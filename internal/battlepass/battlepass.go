@@ -0,0 +1,464 @@
+// battlepass.go
+
+package battlepass
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/title"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 对局获得的通行证经验
+const (
+	matchXPBase    = 100 // 完成对局
+	matchXPWin     = 50  // 获胜
+	matchXPPerKill = 10  // 每次击杀
+	matchXPMVP     = 50  // MVP
+)
+
+var (
+	// ErrNoActiveSeason 当前没有进行中的赛季
+	ErrNoActiveSeason = errcode.New(errcode.SeasonNotActive, "当前没有进行中的通行证赛季")
+	// ErrTierNotReached 未达到该等级
+	ErrTierNotReached = errcode.New(errcode.TierNotReached, "未达到该等级")
+	// ErrPremiumRequired 需要解锁高级通行证
+	ErrPremiumRequired = errcode.New(errcode.PremiumRequired, "需要解锁高级通行证")
+	// ErrAlreadyClaimed 奖励已领取
+	ErrAlreadyClaimed = errcode.New(errcode.AlreadyClaimed, "奖励已领取")
+	// ErrAlreadyPremium 已解锁高级通行证
+	ErrAlreadyPremium = errcode.New(errcode.AlreadyOwned, "已解锁高级通行证")
+	// ErrRewardNotFound 该等级没有奖励
+	ErrRewardNotFound = errcode.New(errcode.NotFound, "该等级没有奖励")
+	// ErrInvalidSeason 赛季或奖励参数无效
+	ErrInvalidSeason = errcode.New(errcode.BadRequest, "无效的通行证参数")
+)
+
+// Service 通行证服务
+type Service struct {
+	wallet *wallet.Service
+}
+
+// NewService 创建通行证服务
+func NewService(w *wallet.Service) *Service {
+	return &Service{wallet: w}
+}
+
+// MatchXP 计算玩家一局对局获得的通行证经验
+func MatchXP(p *models.PlayerMatchRecord) int {
+	xp := matchXPBase + p.Kills*matchXPPerKill
+	if p.Won {
+		xp += matchXPWin
+	}
+	if p.MVP {
+		xp += matchXPMVP
+	}
+	return xp
+}
+
+// seasonColumns 赛季查询字段
+const seasonColumns = `id, name, starts_at, ends_at, max_tier, xp_per_tier, premium_currency, premium_price, created_at`
+
+// scanSeason 扫描赛季
+func scanSeason(row interface{ Scan(...interface{}) error }) (*models.BattlePassSeason, error) {
+	var s models.BattlePassSeason
+	err := row.Scan(&s.ID, &s.Name, &s.StartsAt, &s.EndsAt, &s.MaxTier, &s.XPPerTier,
+		&s.PremiumCurrency, &s.PremiumPrice, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ActiveSeason 获取当前进行中的赛季
+func (s *Service) ActiveSeason() (*models.BattlePassSeason, error) {
+	season, err := scanSeason(db.DB.QueryRow(`
+		SELECT ` + seasonColumns + `
+		FROM battle_pass_seasons
+		WHERE starts_at <= NOW() AND ends_at > NOW()
+		ORDER BY starts_at DESC
+		LIMIT 1
+	`))
+	if err == sql.ErrNoRows {
+		return nil, ErrNoActiveSeason
+	}
+	return season, err
+}
+
+// tierForXP 根据经验计算等级（从0开始，封顶max_tier）
+func tierForXP(season *models.BattlePassSeason, xp int) int {
+	if season.XPPerTier <= 0 {
+		return 0
+	}
+	tier := xp / season.XPPerTier
+	if tier > season.MaxTier {
+		tier = season.MaxTier
+	}
+	return tier
+}
+
+// AddXP 为玩家当前赛季增加经验，没有进行中的赛季时忽略
+func (s *Service) AddXP(playerID int64, xp int) error {
+	if xp <= 0 {
+		return nil
+	}
+
+	season, err := s.ActiveSeason()
+	if err == ErrNoActiveSeason {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO player_battle_passes (player_id, season_id, xp, updated_at)
+		VALUES ($1, $2, LEAST($3, $4), NOW())
+		ON CONFLICT (player_id, season_id) DO UPDATE SET
+			xp = LEAST(player_battle_passes.xp + EXCLUDED.xp, $4),
+			updated_at = NOW()
+	`, playerID, season.ID, xp, season.MaxTier*season.XPPerTier)
+	if err != nil {
+		return fmt.Errorf("增加通行证经验失败: %w", err)
+	}
+	return nil
+}
+
+// PlayerState 获取玩家当前赛季的通行证进度
+func (s *Service) PlayerState(playerID int64) (*models.PlayerBattlePass, error) {
+	season, err := s.ActiveSeason()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &models.PlayerBattlePass{Season: season}
+	err = db.DB.QueryRow(`
+		SELECT xp, premium FROM player_battle_passes WHERE player_id = $1 AND season_id = $2
+	`, playerID, season.ID).Scan(&state.XP, &state.Premium)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询通行证进度失败: %w", err)
+	}
+
+	state.Tier = tierForXP(season, state.XP)
+	if state.Tier < season.MaxTier && season.XPPerTier > 0 {
+		state.TierXP = state.XP - state.Tier*season.XPPerTier
+		state.NextTierXP = season.XPPerTier - state.TierXP
+	}
+
+	if state.Rewards, err = s.SeasonRewards(season.ID); err != nil {
+		return nil, err
+	}
+	if state.Claimed, err = s.claims(playerID, season.ID); err != nil {
+		return nil, err
+	}
+
+	// 统计可领取的奖励
+	claimed := make(map[string]bool, len(state.Claimed))
+	for _, c := range state.Claimed {
+		claimed[fmt.Sprintf("%d:%s", c.Tier, c.Track)] = true
+	}
+	for _, r := range state.Rewards {
+		if r.Tier > state.Tier || claimed[fmt.Sprintf("%d:%s", r.Tier, r.Track)] {
+			continue
+		}
+		if r.Track == models.PassTrackPremium && !state.Premium {
+			continue
+		}
+		state.Claimable++
+	}
+
+	return state, nil
+}
+
+// SeasonRewards 获取赛季全部奖励
+func (s *Service) SeasonRewards(seasonID int) ([]models.BattlePassReward, error) {
+	rows, err := db.DB.Query(`
+		SELECT season_id, tier, track, reward_type, COALESCE(currency, ''), COALESCE(item_id, 0),
+		       COALESCE(title_id, 0), amount
+		FROM battle_pass_rewards
+		WHERE season_id = $1
+		ORDER BY tier, track
+	`, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("查询通行证奖励失败: %w", err)
+	}
+	defer rows.Close()
+
+	rewards := make([]models.BattlePassReward, 0)
+	for rows.Next() {
+		var r models.BattlePassReward
+		if err := rows.Scan(&r.SeasonID, &r.Tier, &r.Track, &r.RewardType, &r.Currency, &r.ItemID,
+			&r.TitleID, &r.Amount); err != nil {
+			return nil, fmt.Errorf("扫描通行证奖励失败: %w", err)
+		}
+		rewards = append(rewards, r)
+	}
+	return rewards, rows.Err()
+}
+
+// claims 获取玩家已领取的奖励
+func (s *Service) claims(playerID int64, seasonID int) ([]models.BattlePassClaimed, error) {
+	rows, err := db.DB.Query(`
+		SELECT tier, track, claimed_at FROM battle_pass_claims
+		WHERE player_id = $1 AND season_id = $2
+		ORDER BY tier, track
+	`, playerID, seasonID)
+	if err != nil {
+		return nil, fmt.Errorf("查询领取记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := make([]models.BattlePassClaimed, 0)
+	for rows.Next() {
+		var c models.BattlePassClaimed
+		if err := rows.Scan(&c.Tier, &c.Track, &c.ClaimedAt); err != nil {
+			return nil, fmt.Errorf("扫描领取记录失败: %w", err)
+		}
+		claimed = append(claimed, c)
+	}
+	return claimed, rows.Err()
+}
+
+// Claim 领取当前赛季指定等级和轨道的奖励
+func (s *Service) Claim(playerID int64, tier int, track models.PassTrack) (*models.BattlePassReward, error) {
+	if !track.IsValid() {
+		return nil, fmt.Errorf("%w: 无效的奖励轨道 %q", ErrInvalidSeason, track)
+	}
+
+	season, err := s.ActiveSeason()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 锁定玩家进度
+	var xp int
+	var premium bool
+	err = tx.QueryRow(`
+		SELECT xp, premium FROM player_battle_passes
+		WHERE player_id = $1 AND season_id = $2
+		FOR UPDATE
+	`, playerID, season.ID).Scan(&xp, &premium)
+	if err == sql.ErrNoRows {
+		return nil, ErrTierNotReached
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询通行证进度失败: %w", err)
+	}
+	if tier > tierForXP(season, xp) {
+		return nil, ErrTierNotReached
+	}
+	if track == models.PassTrackPremium && !premium {
+		return nil, ErrPremiumRequired
+	}
+
+	var reward models.BattlePassReward
+	err = tx.QueryRow(`
+		SELECT season_id, tier, track, reward_type, COALESCE(currency, ''), COALESCE(item_id, 0),
+		       COALESCE(title_id, 0), amount
+		FROM battle_pass_rewards
+		WHERE season_id = $1 AND tier = $2 AND track = $3
+	`, season.ID, tier, string(track)).Scan(&reward.SeasonID, &reward.Tier, &reward.Track,
+		&reward.RewardType, &reward.Currency, &reward.ItemID, &reward.TitleID, &reward.Amount)
+	if err == sql.ErrNoRows {
+		return nil, ErrRewardNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询通行证奖励失败: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO battle_pass_claims (player_id, season_id, tier, track)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, playerID, season.ID, tier, string(track))
+	if err != nil {
+		return nil, fmt.Errorf("写入领取记录失败: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return nil, ErrAlreadyClaimed
+	}
+
+	if err := s.grantRewardTx(tx, playerID, &reward); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return &reward, nil
+}
+
+// grantRewardTx 发放奖励
+func (s *Service) grantRewardTx(tx *sql.Tx, playerID int64, reward *models.BattlePassReward) error {
+	switch reward.RewardType {
+	case models.PassRewardCurrency:
+		_, err := s.wallet.ApplyTx(tx, models.TransactionRequest{
+			PlayerID:       playerID,
+			Currency:       reward.Currency,
+			Amount:         reward.Amount,
+			Reason:         models.ReasonBattlePass,
+			ReferenceID:    fmt.Sprintf("season:%d", reward.SeasonID),
+			IdempotencyKey: fmt.Sprintf("battlepass:%d:%d:%d:%s", reward.SeasonID, playerID, reward.Tier, reward.Track),
+		})
+		return err
+	case models.PassRewardItem:
+		_, err := inventory.GrantItemTx(tx, playerID, reward.ItemID, int(reward.Amount))
+		if err == inventory.ErrAlreadyOwned {
+			// 已拥有的不可叠加道具视为已发放
+			return nil
+		}
+		return err
+	case models.PassRewardTitle:
+		_, err := title.AwardTx(tx, playerID, reward.TitleID, fmt.Sprintf("season:%d", reward.SeasonID))
+		return err
+	default:
+		return fmt.Errorf("%w: 未知的奖励类型 %q", ErrInvalidSeason, reward.RewardType)
+	}
+}
+
+// UnlockPremium 使用货币解锁当前赛季的高级轨道
+func (s *Service) UnlockPremium(playerID int64) (*models.PlayerBattlePass, error) {
+	season, err := s.ActiveSeason()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	var premium bool
+	err = tx.QueryRow(`
+		INSERT INTO player_battle_passes (player_id, season_id)
+		VALUES ($1, $2)
+		ON CONFLICT (player_id, season_id) DO UPDATE SET updated_at = NOW()
+		RETURNING premium
+	`, playerID, season.ID).Scan(&premium)
+	if err != nil {
+		return nil, fmt.Errorf("查询通行证进度失败: %w", err)
+	}
+	if premium {
+		return nil, ErrAlreadyPremium
+	}
+
+	if season.PremiumPrice > 0 {
+		_, err := s.wallet.ApplyTx(tx, models.TransactionRequest{
+			PlayerID:       playerID,
+			Currency:       season.PremiumCurrency,
+			Amount:         -season.PremiumPrice,
+			Reason:         models.ReasonPurchase,
+			ReferenceID:    fmt.Sprintf("season:%d", season.ID),
+			IdempotencyKey: fmt.Sprintf("battlepass:%d:%d:premium", season.ID, playerID),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE player_battle_passes SET premium = true, updated_at = NOW()
+		WHERE player_id = $1 AND season_id = $2
+	`, playerID, season.ID); err != nil {
+		return nil, fmt.Errorf("解锁高级通行证失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return s.PlayerState(playerID)
+}
+
+// ListSeasons 查询全部赛季
+func (s *Service) ListSeasons() ([]models.BattlePassSeason, error) {
+	rows, err := db.DB.Query(`SELECT ` + seasonColumns + ` FROM battle_pass_seasons ORDER BY starts_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询赛季失败: %w", err)
+	}
+	defer rows.Close()
+
+	seasons := make([]models.BattlePassSeason, 0)
+	for rows.Next() {
+		season, err := scanSeason(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描赛季失败: %w", err)
+		}
+		seasons = append(seasons, *season)
+	}
+	return seasons, rows.Err()
+}
+
+// CreateSeason 创建赛季
+func (s *Service) CreateSeason(season *models.BattlePassSeason) error {
+	if season.Name == "" || !season.StartsAt.Before(season.EndsAt) {
+		return fmt.Errorf("%w: 名称不能为空且开始时间必须早于结束时间", ErrInvalidSeason)
+	}
+	if season.MaxTier <= 0 || season.XPPerTier <= 0 {
+		return fmt.Errorf("%w: 等级数和每级经验必须大于0", ErrInvalidSeason)
+	}
+	if season.PremiumCurrency == "" {
+		season.PremiumCurrency = models.CurrencyGems
+	}
+	if !season.PremiumCurrency.IsValid() || season.PremiumPrice < 0 {
+		return fmt.Errorf("%w: 无效的高级通行证价格", ErrInvalidSeason)
+	}
+
+	return db.DB.QueryRow(`
+		INSERT INTO battle_pass_seasons (name, starts_at, ends_at, max_tier, xp_per_tier, premium_currency, premium_price)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, season.Name, season.StartsAt, season.EndsAt, season.MaxTier, season.XPPerTier,
+		string(season.PremiumCurrency), season.PremiumPrice).Scan(&season.ID, &season.CreatedAt)
+}
+
+// SetReward 设置赛季某等级某轨道的奖励（已存在则覆盖）
+func (s *Service) SetReward(reward *models.BattlePassReward) error {
+	if reward.SeasonID <= 0 || reward.Tier <= 0 || !reward.Track.IsValid() || reward.Amount <= 0 {
+		return fmt.Errorf("%w: 赛季、等级、轨道和数量必须有效", ErrInvalidSeason)
+	}
+
+	var currency interface{}
+	var itemID interface{}
+	var titleID interface{}
+	switch reward.RewardType {
+	case models.PassRewardCurrency:
+		if !reward.Currency.IsValid() {
+			return fmt.Errorf("%w: 无效的货币类型 %q", ErrInvalidSeason, reward.Currency)
+		}
+		currency = string(reward.Currency)
+	case models.PassRewardItem:
+		if reward.ItemID <= 0 {
+			return fmt.Errorf("%w: 道具奖励必须指定 item_id", ErrInvalidSeason)
+		}
+		itemID = reward.ItemID
+	case models.PassRewardTitle:
+		if reward.TitleID <= 0 {
+			return fmt.Errorf("%w: 称号奖励必须指定 title_id", ErrInvalidSeason)
+		}
+		titleID = reward.TitleID
+	default:
+		return fmt.Errorf("%w: 奖励类型必须为 currency、item 或 title", ErrInvalidSeason)
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO battle_pass_rewards (season_id, tier, track, reward_type, currency, item_id, title_id, amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (season_id, tier, track) DO UPDATE SET
+			reward_type = EXCLUDED.reward_type, currency = EXCLUDED.currency,
+			item_id = EXCLUDED.item_id, title_id = EXCLUDED.title_id, amount = EXCLUDED.amount
+	`, reward.SeasonID, reward.Tier, string(reward.Track), string(reward.RewardType), currency, itemID, titleID,
+		reward.Amount)
+	return err
+}
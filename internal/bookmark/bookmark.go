@@ -0,0 +1,89 @@
+// bookmark.go
+
+package bookmark
+
+import (
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// MaxBookmarks 每位玩家最多收藏的对局数，收藏的对局不归档，需限制数量
+const MaxBookmarks = 100
+
+var (
+	// ErrMatchNotFound 对局不存在或玩家未参与
+	ErrMatchNotFound = errcode.New(errcode.NotFound, "对局不存在或未参与该对局")
+	// ErrBookmarkLimit 收藏数已达上限
+	ErrBookmarkLimit = errcode.New(errcode.LimitReached, "收藏的对局已达上限")
+)
+
+// Add 收藏玩家参与过的对局，重复收藏不报错
+func Add(playerID int64, matchID string) error {
+	var played bool
+	err := db.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM player_match_records WHERE match_id = $1 AND player_id = $2)
+	`, matchID, playerID).Scan(&played)
+	if err != nil {
+		return fmt.Errorf("查询对局记录失败: %w", err)
+	}
+	if !played {
+		return ErrMatchNotFound
+	}
+
+	var count int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM player_match_bookmarks WHERE player_id = $1`, playerID).Scan(&count); err != nil {
+		return fmt.Errorf("查询收藏数失败: %w", err)
+	}
+	if count >= MaxBookmarks {
+		return fmt.Errorf("%w: 最多收藏%d场", ErrBookmarkLimit, MaxBookmarks)
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO player_match_bookmarks (player_id, match_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, playerID, matchID)
+	if err != nil {
+		return fmt.Errorf("收藏对局失败: %w", err)
+	}
+	return nil
+}
+
+// Remove 取消收藏
+func Remove(playerID int64, matchID string) error {
+	if _, err := db.DB.Exec(`DELETE FROM player_match_bookmarks WHERE player_id = $1 AND match_id = $2`, playerID, matchID); err != nil {
+		return fmt.Errorf("取消收藏失败: %w", err)
+	}
+	return nil
+}
+
+// List 查询玩家收藏的对局，最近收藏的在前
+func List(playerID int64) ([]models.MatchBookmark, error) {
+	rows, err := db.DB.Query(`
+		SELECT mr.id, mr.game_mode, COALESCE(mr.map_id, 0), mr.start_time, COALESCE(mr.duration, 0),
+		       pmr.character_id, pmr.score, pmr.kills, pmr.deaths, pmr.assists, pmr.won, pmr.mvp, b.created_at
+		FROM player_match_bookmarks b
+		JOIN match_records mr ON mr.id = b.match_id
+		JOIN player_match_records pmr ON pmr.match_id = b.match_id AND pmr.player_id = b.player_id
+		WHERE b.player_id = $1
+		ORDER BY b.created_at DESC
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询收藏的对局失败: %w", err)
+	}
+	defer rows.Close()
+
+	bookmarks := make([]models.MatchBookmark, 0)
+	for rows.Next() {
+		var b models.MatchBookmark
+		if err := rows.Scan(&b.MatchID, &b.GameMode, &b.MapID, &b.StartTime, &b.Duration,
+			&b.CharacterID, &b.Score, &b.Kills, &b.Deaths, &b.Assists, &b.Won, &b.MVP, &b.BookmarkedAt); err != nil {
+			return nil, fmt.Errorf("扫描收藏的对局失败: %w", err)
+		}
+		b.ReplayURL = "/replays/" + b.MatchID
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
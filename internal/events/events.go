@@ -0,0 +1,137 @@
+// events.go
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	// MatchStarted 对局开始，载荷为 models.MatchStartedEvent
+	MatchStarted Type = "match_started"
+	// MatchEnded 对局结果已保存，载荷为 models.MatchEndedEvent
+	MatchEnded Type = "match_ended"
+	// PlayerLeveled 玩家角色升级，载荷为 models.PlayerLeveledEvent
+	PlayerLeveled Type = "player_leveled"
+	// PurchaseMade 购买完成，载荷为 models.PurchaseMadeEvent
+	PurchaseMade Type = "purchase_made"
+)
+
+var (
+	publishedTotal = metrics.Default.NewCounterVec("pixelstorm_events_published_total",
+		"发布的事件数", "type", "status")
+	handledTotal = metrics.Default.NewCounterVec("pixelstorm_events_handled_total",
+		"消费者处理的事件数", "group", "type", "status")
+)
+
+// Event 领域事件
+type Event struct {
+	ID         string          `json:"id"`
+	Type       Type            `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Decode 解析事件载荷
+func (e *Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("解析 %s 事件 %s 失败: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// Handler 事件处理函数，返回错误时事件稍后重新投递
+type Handler func(e *Event) error
+
+// Bus 事件总线。同一消费组内每个事件只由一个实例处理，不同消费组各自收到全部事件
+type Bus interface {
+	// Publish 发布事件
+	Publish(t Type, payload any) error
+	// Subscribe 以消费组 group 订阅指定类型的事件，未指定类型时订阅全部事件
+	Subscribe(group string, handler Handler, types ...Type)
+	// Close 停止消费
+	Close()
+}
+
+// Default 全局事件总线，Init 之前为进程内总线
+var Default Bus = NewMemoryBus()
+
+// Init 按配置初始化全局事件总线，未连接Redis时使用进程内总线
+func Init(cfg config.EventsConfig) {
+	if cfg.Backend == "memory" || db.RedisClient == nil {
+		Default = NewMemoryBus()
+		log.Println("事件总线使用进程内模式，事件不会持久化")
+		return
+	}
+	Default = NewRedisBus(db.RedisClient, cfg)
+	log.Printf("事件总线使用 Redis Stream: %s", cfg.Stream)
+}
+
+// Publish 向全局事件总线发布事件。事件在业务数据提交后发布，发布失败只记录日志
+func Publish(t Type, payload any) {
+	if err := Default.Publish(t, payload); err != nil {
+		log.Printf("发布 %s 事件失败: %v", t, err)
+	}
+}
+
+// Subscribe 订阅全局事件总线
+func Subscribe(group string, handler Handler, types ...Type) {
+	Default.Subscribe(group, handler, types...)
+}
+
+// Close 停止全局事件总线
+func Close() {
+	Default.Close()
+}
+
+// newEvent 生成待发布的事件
+func newEvent(t Type, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化事件载荷失败: %w", err)
+	}
+	return &Event{Type: t, OccurredAt: time.Now(), Payload: data}, nil
+}
+
+// typeFilter 消费组订阅的事件类型，为空时接收全部
+type typeFilter map[Type]bool
+
+func newTypeFilter(types []Type) typeFilter {
+	f := make(typeFilter, len(types))
+	for _, t := range types {
+		f[t] = true
+	}
+	return f
+}
+
+func (f typeFilter) match(t Type) bool {
+	return len(f) == 0 || f[t]
+}
+
+// dispatch 调用处理函数并记录结果，处理函数 panic 视为失败
+func dispatch(group string, handler Handler, e *Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("处理函数 panic: %v", p)
+			report.Panic(p, report.Context{Tags: map[string]string{"consumer_group": group, "event_type": string(e.Type)}})
+		}
+		status := "ok"
+		if err != nil {
+			status = "error"
+			log.Printf("消费组 %s 处理 %s 事件 %s 失败: %v", group, e.Type, e.ID, err)
+		}
+		handledTotal.Inc(group, string(e.Type), status)
+	}()
+	return handler(e)
+}
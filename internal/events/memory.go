@@ -0,0 +1,104 @@
+// memory.go
+
+package events
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// 进程内总线每个消费组的缓冲事件数
+const memoryBufferSize = 1024
+
+// memorySubscription 进程内总线的消费组
+type memorySubscription struct {
+	group   string
+	handler Handler
+	filter  typeFilter
+	ch      chan *Event
+}
+
+// MemoryBus 进程内事件总线，异步投递给各消费组，不持久化也不重试，
+// 用于本地开发和未部署Redis的环境
+type MemoryBus struct {
+	mu     sync.RWMutex
+	subs   []*memorySubscription
+	nextID atomic.Uint64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewMemoryBus 创建进程内事件总线
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+// Publish 发布事件，消费组缓冲已满时丢弃并记录日志
+func (b *MemoryBus) Publish(t Type, payload any) error {
+	e, err := newEvent(t, payload)
+	if err != nil {
+		publishedTotal.Inc(string(t), "error")
+		return err
+	}
+	e.ID = strconv.FormatUint(b.nextID.Add(1), 10)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return nil
+	}
+	for _, sub := range b.subs {
+		if !sub.filter.match(t) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			log.Printf("消费组 %s 积压过多，丢弃 %s 事件 %s", sub.group, t, e.ID)
+		}
+	}
+	publishedTotal.Inc(string(t), "ok")
+	return nil
+}
+
+// Subscribe 订阅事件，每个消费组由一个协程按发布顺序处理
+func (b *MemoryBus) Subscribe(group string, handler Handler, types ...Type) {
+	sub := &memorySubscription{
+		group:   group,
+		handler: handler,
+		filter:  newTypeFilter(types),
+		ch:      make(chan *Event, memoryBufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.subs = append(b.subs, sub)
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for e := range sub.ch {
+			dispatch(group, handler, e)
+		}
+	}()
+}
+
+// Close 停止接收事件，等待已缓冲的事件处理完成
+func (b *MemoryBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.ch)
+	}
+	b.mu.Unlock()
+	b.wg.Wait()
+}
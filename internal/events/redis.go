@@ -0,0 +1,235 @@
+// redis.go
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+)
+
+// Redis Stream 事件总线默认参数
+const (
+	defaultStream        = "events"
+	defaultMaxLen        = 100000
+	defaultClaimIdle     = time.Minute
+	defaultMaxDeliveries = 5
+
+	// 每次读取的事件数和阻塞等待时长
+	readBatchSize = 64
+	readBlock     = 2 * time.Second
+)
+
+// RedisBus 基于 Redis Streams 的事件总线。事件写入同一个 Stream，每个消费组对应一个
+// Redis 消费组：处理成功后确认，失败的事件在 claimIdle 后由同组实例重新认领，
+// 投递次数超过 maxDeliveries 后丢弃
+type RedisBus struct {
+	client        redis.UniversalClient
+	stream        string
+	maxLen        int64
+	claimIdle     time.Duration
+	maxDeliveries int64
+	consumer      string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRedisBus 创建基于 Redis Streams 的事件总线
+func NewRedisBus(client redis.UniversalClient, cfg config.EventsConfig) *RedisBus {
+	b := &RedisBus{
+		client:        client,
+		stream:        cfg.Stream,
+		maxLen:        cfg.MaxLen,
+		claimIdle:     cfg.ClaimIdle,
+		maxDeliveries: cfg.MaxDeliveries,
+	}
+	if b.stream == "" {
+		b.stream = defaultStream
+	}
+	if b.maxLen <= 0 {
+		b.maxLen = defaultMaxLen
+	}
+	if b.claimIdle <= 0 {
+		b.claimIdle = defaultClaimIdle
+	}
+	if b.maxDeliveries <= 0 {
+		b.maxDeliveries = defaultMaxDeliveries
+	}
+
+	// 消费者名称区分同一消费组内的不同进程
+	host, _ := os.Hostname()
+	b.consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	return b
+}
+
+// Publish 将事件追加到 Stream
+func (b *RedisBus) Publish(t Type, payload any) error {
+	e, err := newEvent(t, payload)
+	if err != nil {
+		publishedTotal.Inc(string(t), "error")
+		return err
+	}
+
+	err = b.client.XAdd(b.ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":        string(e.Type),
+			"occurred_at": e.OccurredAt.Format(time.RFC3339Nano),
+			"payload":     string(e.Payload),
+		},
+	}).Err()
+	if err != nil {
+		publishedTotal.Inc(string(t), "error")
+		return fmt.Errorf("写入事件流失败: %w", err)
+	}
+	publishedTotal.Inc(string(t), "ok")
+	return nil
+}
+
+// Subscribe 创建消费组（已存在时复用）并在后台消费。新建的消费组只接收之后发布的事件
+func (b *RedisBus) Subscribe(group string, handler Handler, types ...Type) {
+	err := b.client.XGroupCreateMkStream(b.ctx, b.stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		log.Printf("创建消费组 %s 失败: %v", group, err)
+		return
+	}
+
+	filter := newTypeFilter(types)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.consume(group, handler, filter)
+	}()
+	log.Printf("消费组 %s 开始消费事件流 %s", group, b.stream)
+}
+
+// consume 消费循环，定期认领同组中长时间未确认的事件
+func (b *RedisBus) consume(group string, handler Handler, filter typeFilter) {
+	lastClaim := time.Now()
+	for b.ctx.Err() == nil {
+		if time.Since(lastClaim) >= b.claimIdle {
+			b.reclaim(group, handler, filter)
+			lastClaim = time.Now()
+		}
+
+		streams, err := b.client.XReadGroup(b.ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: b.consumer,
+			Streams:  []string{b.stream, ">"},
+			Count:    readBatchSize,
+			Block:    readBlock,
+		}).Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			if b.ctx.Err() != nil {
+				return
+			}
+			log.Printf("消费组 %s 读取事件失败: %v", group, err)
+			select {
+			case <-time.After(time.Second):
+			case <-b.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				b.handle(group, handler, filter, msg)
+			}
+		}
+	}
+}
+
+// reclaim 认领同组中超过 claimIdle 未确认的事件重新处理，超过最大投递次数的直接确认丢弃
+func (b *RedisBus) reclaim(group string, handler Handler, filter typeFilter) {
+	pending, err := b.client.XPendingExt(b.ctx, &redis.XPendingExtArgs{
+		Stream: b.stream,
+		Group:  group,
+		Idle:   b.claimIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  readBatchSize,
+	}).Result()
+	if err != nil {
+		log.Printf("消费组 %s 查询未确认事件失败: %v", group, err)
+		return
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		if p.RetryCount >= b.maxDeliveries {
+			log.Printf("消费组 %s 的事件 %s 已投递 %d 次仍失败，丢弃", group, p.ID, p.RetryCount)
+			b.client.XAck(b.ctx, b.stream, group, p.ID)
+			handledTotal.Inc(group, "unknown", "dropped")
+			continue
+		}
+		ids = append(ids, p.ID)
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	msgs, err := b.client.XClaim(b.ctx, &redis.XClaimArgs{
+		Stream:   b.stream,
+		Group:    group,
+		Consumer: b.consumer,
+		MinIdle:  b.claimIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		log.Printf("消费组 %s 认领事件失败: %v", group, err)
+		return
+	}
+	for _, msg := range msgs {
+		b.handle(group, handler, filter, msg)
+	}
+}
+
+// handle 处理单个事件，成功或不属于该消费组的事件立即确认
+func (b *RedisBus) handle(group string, handler Handler, filter typeFilter, msg redis.XMessage) {
+	e := parseMessage(msg)
+	if filter.match(e.Type) {
+		if err := dispatch(group, handler, e); err != nil {
+			return
+		}
+	}
+	if err := b.client.XAck(b.ctx, b.stream, group, msg.ID).Err(); err != nil {
+		log.Printf("消费组 %s 确认事件 %s 失败: %v", group, msg.ID, err)
+	}
+}
+
+// parseMessage 将 Stream 消息还原为事件
+func parseMessage(msg redis.XMessage) *Event {
+	e := &Event{ID: msg.ID}
+	if v, ok := msg.Values["type"].(string); ok {
+		e.Type = Type(v)
+	}
+	if v, ok := msg.Values["occurred_at"].(string); ok {
+		e.OccurredAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	if v, ok := msg.Values["payload"].(string); ok {
+		e.Payload = []byte(v)
+	}
+	return e
+}
+
+// Close 停止消费并等待处理中的事件完成
+func (b *RedisBus) Close() {
+	b.cancel()
+	b.wg.Wait()
+}
@@ -0,0 +1,168 @@
+// exchange.go
+
+package exchange
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrExchangeDisabled 未开放兑换
+	ErrExchangeDisabled = errcode.New(errcode.FeatureDisabled, "暂未开放货币兑换")
+	// ErrInvalidExchange 兑换参数无效
+	ErrInvalidExchange = errcode.New(errcode.BadRequest, "无效的兑换请求")
+	// ErrDailyLimit 超过每日兑换额度
+	ErrDailyLimit = errcode.New(errcode.LimitReached, "已达到今日兑换上限")
+)
+
+// Service 宝石兑换金币服务
+type Service struct {
+	wallet *wallet.Service
+	cfg    config.ExchangeConfig
+}
+
+// NewService 创建兑换服务
+func NewService(w *wallet.Service, cfg config.ExchangeConfig) *Service {
+	if cfg.MinGems <= 0 {
+		cfg.MinGems = 1
+	}
+	return &Service{wallet: w, cfg: cfg}
+}
+
+// Rates 查询兑换比例和玩家今日剩余额度
+func (s *Service) Rates(playerID int64) (*models.ExchangeRates, error) {
+	rates := &models.ExchangeRates{
+		CoinsPerGem:   s.cfg.CoinsPerGem,
+		MinGems:       s.cfg.MinGems,
+		DailyGemLimit: s.cfg.DailyGemLimit,
+	}
+	if s.cfg.DailyGemLimit <= 0 {
+		return rates, nil
+	}
+
+	used, err := exchangedToday(db.DB, playerID)
+	if err != nil {
+		return nil, err
+	}
+	rates.DailyRemaining = max(s.cfg.DailyGemLimit-used, 0)
+	return rates, nil
+}
+
+// Exchange 将宝石兑换为金币，扣除和入账分别记入两种货币的流水
+func (s *Service) Exchange(playerID int64, req *models.ExchangeRequest) (*models.ExchangeResult, error) {
+	if s.cfg.CoinsPerGem <= 0 {
+		return nil, ErrExchangeDisabled
+	}
+	if req.RequestID == "" || len(req.RequestID) > 64 {
+		return nil, fmt.Errorf("%w: 请求ID不能为空且不超过64个字符", ErrInvalidExchange)
+	}
+	if req.Gems < s.cfg.MinGems {
+		return nil, fmt.Errorf("%w: 每次至少兑换 %d 宝石", ErrInvalidExchange, s.cfg.MinGems)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 锁定玩家，串行化同一玩家的兑换和额度检查
+	var exists bool
+	err = tx.QueryRow(`SELECT true FROM players WHERE id = $1 FOR UPDATE`, playerID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, wallet.ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("锁定玩家失败: %w", err)
+	}
+
+	// 重复请求直接返回已有流水
+	key := fmt.Sprintf("exchange:%d:%s", playerID, req.RequestID)
+	if result, err := existingResult(tx, key); err != nil || result != nil {
+		return result, err
+	}
+
+	if s.cfg.DailyGemLimit > 0 {
+		used, err := exchangedToday(tx, playerID)
+		if err != nil {
+			return nil, err
+		}
+		if used+req.Gems > s.cfg.DailyGemLimit {
+			return nil, fmt.Errorf("%w: 每日最多兑换 %d 宝石，今日已兑换 %d", ErrDailyLimit, s.cfg.DailyGemLimit, used)
+		}
+	}
+
+	result := &models.ExchangeResult{Gems: req.Gems, Coins: req.Gems * s.cfg.CoinsPerGem}
+	result.GemsTransaction, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       playerID,
+		Currency:       models.CurrencyGems,
+		Amount:         -result.Gems,
+		Reason:         models.ReasonExchange,
+		ReferenceID:    key,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.CoinsTransaction, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       playerID,
+		Currency:       models.CurrencyCoins,
+		Amount:         result.Coins,
+		Reason:         models.ReasonExchange,
+		ReferenceID:    key,
+		IdempotencyKey: key + ":coins",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return result, nil
+}
+
+// existingResult 按幂等键查询已完成的兑换，不存在时返回nil
+func existingResult(tx *sql.Tx, key string) (*models.ExchangeResult, error) {
+	gemsTxn, err := wallet.TransactionByKeyTx(tx, key)
+	if err != nil || gemsTxn == nil {
+		return nil, err
+	}
+	coinsTxn, err := wallet.TransactionByKeyTx(tx, key+":coins")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ExchangeResult{Gems: -gemsTxn.Amount, GemsTransaction: gemsTxn, CoinsTransaction: coinsTxn}
+	if coinsTxn != nil {
+		result.Coins = coinsTxn.Amount
+	}
+	return result, nil
+}
+
+// queryer 兼容 *sql.DB 和 *sql.Tx
+type queryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// exchangedToday 查询玩家今日（UTC）已兑换的宝石数
+func exchangedToday(q queryer, playerID int64) (int64, error) {
+	var used int64
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+	err := q.QueryRow(`
+		SELECT COALESCE(-SUM(amount), 0) FROM currency_transactions
+		WHERE player_id = $1 AND currency = $2 AND reason = $3 AND created_at >= $4
+	`, playerID, string(models.CurrencyGems), string(models.ReasonExchange), dayStart).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("查询今日兑换记录失败: %w", err)
+	}
+	return used, nil
+}
@@ -0,0 +1,301 @@
+// feature.go
+
+package feature
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// InvalidateChannel 功能开关变更通知的 Redis 频道
+const InvalidateChannel = "feature:invalidate"
+
+// refreshInterval 定期重新加载开关的间隔，未连接Redis或错过通知时以此兜底
+const refreshInterval = 30 * time.Second
+
+var (
+	// ErrInvalidFlag 开关参数无效
+	ErrInvalidFlag = errcode.New(errcode.BadRequest, "无效的功能开关")
+	// ErrUnknownFlag 代码中未声明该开关
+	ErrUnknownFlag = errcode.New(errcode.NotFound, "功能开关不存在")
+)
+
+// Flag 功能开关的键
+type Flag string
+
+// definition 代码中声明的开关
+type definition struct {
+	defaultOn   bool
+	description string
+}
+
+var (
+	definitionsMu sync.RWMutex
+	definitions   = make(map[Flag]definition)
+)
+
+// Define 声明功能开关及其默认值，未在数据库中配置时使用默认值。重复声明时以最后一次为准
+func Define(key string, defaultOn bool, description string) Flag {
+	definitionsMu.Lock()
+	defer definitionsMu.Unlock()
+	definitions[Flag(key)] = definition{defaultOn: defaultOn, description: description}
+	return Flag(key)
+}
+
+// 内置功能开关
+var (
+	// NotificationStream 网关 SSE 通知流
+	NotificationStream = Define("gateway.notification_stream", true, "网关 SSE 通知流")
+	// ProjectileCollisions 投射物与其他投射物碰撞抵消（实验性）
+	ProjectileCollisions = Define("game.projectile_collisions", false, "投射物相互碰撞抵消（实验性）")
+	// Maintenance 维护模式，开启后各服务的就绪探针失败，负载均衡不再分配新流量，网关拒绝玩家请求
+	Maintenance = Define("ops.maintenance", false, "维护模式（就绪探针失败，网关拒绝玩家请求）")
+)
+
+// MatchMode 游戏模式的匹配开关，关闭后该模式不再接受新的匹配请求。有效模式的开关默认开启
+func MatchMode(mode models.GameMode) Flag {
+	return Flag("match.mode." + string(mode))
+}
+
+func init() {
+	for _, mode := range []models.GameMode{models.DeathMatch, models.TeamDeathMatch, models.CapturePoint, models.FlagCapture} {
+		Define(string(MatchMode(mode)), true, fmt.Sprintf("%s 模式匹配", mode))
+	}
+}
+
+// instanceID 本进程标识，收到自己发出的通知时不重复加载
+var instanceID = uuid.New().String()
+
+// snapshot 最近一次从数据库加载的开关配置
+var snapshot atomic.Pointer[map[Flag]models.FeatureFlag]
+
+// Enabled 开关是否对所有玩家开启（开启且灰度比例为100）
+func (f Flag) Enabled() bool {
+	flag := f.state()
+	return flag.Enabled && flag.Rollout >= 100
+}
+
+// EnabledFor 开关是否对指定玩家开启。灰度发布时按开关和玩家ID稳定分桶，同一玩家的结果不随时间变化
+func (f Flag) EnabledFor(playerID int64) bool {
+	flag := f.state()
+	if !flag.Enabled || flag.Rollout <= 0 {
+		return false
+	}
+	if flag.Rollout >= 100 {
+		return true
+	}
+	return bucket(f, playerID) < flag.Rollout
+}
+
+// bucket 玩家在开关上的分桶(0-99)
+func bucket(f Flag, playerID int64) int {
+	h := fnv.New32a()
+	h.Write([]byte(f))
+	h.Write([]byte{':'})
+	h.Write([]byte(strconv.FormatInt(playerID, 10)))
+	return int(h.Sum32() % 100)
+}
+
+// state 开关的当前状态，未配置时使用声明的默认值
+func (f Flag) state() models.FeatureFlag {
+	if flags := snapshot.Load(); flags != nil {
+		if flag, ok := (*flags)[f]; ok {
+			return flag
+		}
+	}
+
+	definitionsMu.RLock()
+	def := definitions[f]
+	definitionsMu.RUnlock()
+	return models.FeatureFlag{
+		Key:         string(f),
+		Enabled:     def.defaultOn,
+		Rollout:     100,
+		Description: def.description,
+		Default:     def.defaultOn,
+	}
+}
+
+// List 列出所有已声明或已配置的开关
+func List() []models.FeatureFlag {
+	keys := make(map[Flag]bool)
+	definitionsMu.RLock()
+	for f := range definitions {
+		keys[f] = true
+	}
+	definitionsMu.RUnlock()
+	if flags := snapshot.Load(); flags != nil {
+		for f := range *flags {
+			keys[f] = true
+		}
+	}
+
+	result := make([]models.FeatureFlag, 0, len(keys))
+	for f := range keys {
+		result = append(result, f.state())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result
+}
+
+// Init 加载开关配置并启动定期刷新，Redis 可用时订阅其他实例的变更通知
+func Init() {
+	if db.DB == nil {
+		return
+	}
+	if err := Load(); err != nil {
+		log.Printf("加载功能开关失败，使用默认值: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := Load(); err != nil {
+				log.Printf("刷新功能开关失败: %v", err)
+			}
+		}
+	}()
+
+	if db.RedisClient != nil {
+		startInvalidationListener()
+	}
+}
+
+// Load 从数据库加载全部开关配置，成功后替换当前快照
+func Load() error {
+	rows, err := db.DB.Query(`SELECT key, enabled, rollout, description, updated_at FROM feature_flags`)
+	if err != nil {
+		return fmt.Errorf("查询功能开关失败: %w", err)
+	}
+	defer rows.Close()
+
+	flags := make(map[Flag]models.FeatureFlag)
+	for rows.Next() {
+		var flag models.FeatureFlag
+		if err := rows.Scan(&flag.Key, &flag.Enabled, &flag.Rollout, &flag.Description, &flag.UpdatedAt); err != nil {
+			return fmt.Errorf("扫描功能开关失败: %w", err)
+		}
+		flag.Configured = true
+		definitionsMu.RLock()
+		flag.Default = definitions[Flag(flag.Key)].defaultOn
+		definitionsMu.RUnlock()
+		flags[Flag(flag.Key)] = flag
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	snapshot.Store(&flags)
+	return nil
+}
+
+// Get 获取单个开关的当前配置
+func Get(key string) (models.FeatureFlag, error) {
+	if err := checkKey(key); err != nil {
+		return models.FeatureFlag{}, err
+	}
+	return Flag(key).state(), nil
+}
+
+// Set 更新开关配置，本实例立即生效并通知其他实例重新加载
+func Set(key string, req *models.UpdateFeatureFlagRequest) (models.FeatureFlag, error) {
+	if err := checkKey(key); err != nil {
+		return models.FeatureFlag{}, err
+	}
+	rollout := 100
+	if req.Rollout != nil {
+		rollout = *req.Rollout
+	}
+	if rollout < 0 || rollout > 100 {
+		return models.FeatureFlag{}, fmt.Errorf("%w: rollout 应在 0-100 之间", ErrInvalidFlag)
+	}
+	description := Flag(key).state().Description
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO feature_flags (key, enabled, rollout, description, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			enabled = EXCLUDED.enabled, rollout = EXCLUDED.rollout,
+			description = EXCLUDED.description, updated_at = NOW()
+	`, key, req.Enabled, rollout, description)
+	if err != nil {
+		return models.FeatureFlag{}, fmt.Errorf("更新功能开关失败: %w", err)
+	}
+
+	if err := reloadAndNotify(); err != nil {
+		return models.FeatureFlag{}, err
+	}
+	return Flag(key).state(), nil
+}
+
+// Reset 删除开关配置，恢复为代码中声明的默认值
+func Reset(key string) (models.FeatureFlag, error) {
+	if err := checkKey(key); err != nil {
+		return models.FeatureFlag{}, err
+	}
+	if _, err := db.DB.Exec(`DELETE FROM feature_flags WHERE key = $1`, key); err != nil {
+		return models.FeatureFlag{}, fmt.Errorf("删除功能开关失败: %w", err)
+	}
+	if err := reloadAndNotify(); err != nil {
+		return models.FeatureFlag{}, err
+	}
+	return Flag(key).state(), nil
+}
+
+// checkKey 只允许修改代码中声明的开关，避免拼写错误的键静默无效
+func checkKey(key string) error {
+	definitionsMu.RLock()
+	_, ok := definitions[Flag(key)]
+	definitionsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFlag, key)
+	}
+	return nil
+}
+
+// reloadAndNotify 重新加载本实例的开关并通知其他实例
+func reloadAndNotify() error {
+	if err := Load(); err != nil {
+		return err
+	}
+	if db.RedisClient == nil {
+		return nil
+	}
+	if err := db.RedisClient.Publish(context.Background(), InvalidateChannel, instanceID).Err(); err != nil {
+		log.Printf("发布功能开关变更通知失败: %v", err)
+	}
+	return nil
+}
+
+// startInvalidationListener 订阅变更通知，收到其他实例的通知后重新加载
+func startInvalidationListener() {
+	pubsub := db.RedisClient.Subscribe(context.Background(), InvalidateChannel)
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			if msg.Payload == instanceID {
+				continue
+			}
+			if err := Load(); err != nil {
+				log.Printf("收到变更通知后重新加载功能开关失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,92 @@
+// admin.go
+
+package game
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// adminKeyHeader 管理接口密钥请求头，与网关管理接口一致
+const adminKeyHeader = "X-Admin-Key"
+
+// RoomMetrics 获取所有房间的运行指标，按创建时间排序
+func (s *GameServer) RoomMetrics() []models.RoomMetrics {
+	rooms := s.ListRooms()
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.Before(rooms[j].CreatedAt)
+	})
+
+	result := make([]models.RoomMetrics, 0, len(rooms))
+	for _, room := range rooms {
+		result = append(result, room.Metrics())
+	}
+	return result
+}
+
+// RoomDetail 获取房间详情，包含房间内玩家及其得分
+func (s *GameServer) RoomDetail(roomID string) (*models.RoomDetail, bool) {
+	room, ok := s.GetRoom(roomID)
+	if !ok {
+		return nil, false
+	}
+	return &models.RoomDetail{Metrics: room.Metrics(), Room: room.State()}, true
+}
+
+// adminOnly 包装处理器，仅允许携带正确管理密钥的请求，未配置密钥时禁用
+func (s *GameServer) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := s.config.Admin.APIKey
+		if apiKey == "" {
+			http.Error(w, "管理接口未启用", http.StatusForbidden)
+			return
+		}
+
+		key := r.Header.Get(adminKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			log.Printf("管理接口认证失败: %s %s", r.Method, r.URL.Path)
+			http.Error(w, "管理员认证失败", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// handleAdminRooms 返回所有房间的运行指标
+func (s *GameServer) handleAdminRooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+		return
+	}
+	writeAdminJSON(w, s.RoomMetrics())
+}
+
+// handleAdminRoom 返回单个房间的详情
+func (s *GameServer) handleAdminRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+		return
+	}
+
+	detail, ok := s.RoomDetail(strings.TrimPrefix(r.URL.Path, "/admin/rooms/"))
+	if !ok {
+		http.Error(w, "房间不存在", http.StatusNotFound)
+		return
+	}
+	writeAdminJSON(w, detail)
+}
+
+// writeAdminJSON 写出管理接口的 JSON 响应
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("编码管理接口响应失败: %v", err)
+	}
+}
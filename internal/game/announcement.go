@@ -0,0 +1,53 @@
+// announcement.go
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+)
+
+// Announce 向本实例所有在线玩家广播全服公告，返回接收的连接数。
+// 公告按各连接的语言翻译，作为关键消息发送，断线重连恢复会话后会重发
+func (s *GameServer) Announce(a models.Announcement) int {
+	if a.MaintenanceAt != nil {
+		a.Countdown = int64(time.Until(*a.MaintenanceAt).Seconds())
+		if a.Countdown < 0 {
+			a.Countdown = 0
+		}
+	}
+
+	messages := make(map[i18n.Locale]Message)
+	count := 0
+
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+	for _, player := range s.connections {
+		msg, ok := messages[player.Locale]
+		if !ok {
+			localized := a
+			localized.Title = i18n.Translate(player.Locale, a.Title)
+			localized.Message = i18n.Translate(player.Locale, a.Message)
+			var err error
+			if msg, err = newMessage("announcement", localized); err != nil {
+				log.Printf("序列化全服公告失败: %v", err)
+				return count
+			}
+			msg.Kind = KindReliable
+			messages[player.Locale] = msg
+		}
+
+		if !player.trySend(msg) {
+			// 通道已满，关闭连接
+			go s.closeConnection(player)
+			continue
+		}
+		count++
+	}
+
+	log.Printf("广播全服公告 %s (%s) 给 %d 个连接", a.ID, a.Severity, count)
+	return count
+}
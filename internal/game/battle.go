@@ -3,7 +3,6 @@
 package game
 
 import (
-	"encoding/json"
 	"log"
 	"math"
 	"time"
@@ -43,6 +42,14 @@ func (r *Room) detectCollisions() {
 			entityA := entities[i]
 			entityB := entities[j]
 
+			// 实验性：不同玩家的投射物相遇时相互抵消
+			if r.ProjectileCollisions && entityA.GetType() == models.EntityProjectile && entityB.GetType() == models.EntityProjectile {
+				if collision, ok := clashProjectiles(entityA.(*models.ProjectileEntity), entityB.(*models.ProjectileEntity)); ok {
+					collisions = append(collisions, collision)
+				}
+				continue
+			}
+
 			// 检查是否是投射物和玩家
 			var projectile *models.ProjectileEntity
 			var player *models.PlayerEntity
@@ -60,7 +67,7 @@ func (r *Room) detectCollisions() {
 			}
 
 			// 如果是投射物和玩家，检查碰撞
-			if isCollision && player.IsAlive {
+			if isCollision && player.IsAlive && projectile.LifeTime > 0 && !player.UntargetableUntil.After(time.Now()) {
 				// 检查投射物是否已经击中该玩家
 				hasHit := false
 				for _, hitID := range projectile.HitEntities {
@@ -129,6 +136,36 @@ func (r *Room) detectCollisions() {
 	}
 }
 
+// clashProjectiles 检查两个投射物是否相遇，相遇时两者的生命周期归零，在下一帧更新时移除
+func clashProjectiles(a, b *models.ProjectileEntity) (models.CollisionInfo, bool) {
+	if a.OwnerID == b.OwnerID || a.LifeTime <= 0 || b.LifeTime <= 0 {
+		return models.CollisionInfo{}, false
+	}
+
+	posA := a.GetPosition()
+	posB := b.GetPosition()
+	dx := posA.X - posB.X
+	dy := posA.Y - posB.Y
+	distance := math.Sqrt(dx*dx + dy*dy)
+	if distance >= 2*projectileRadius {
+		return models.CollisionInfo{}, false
+	}
+
+	a.LifeTime = 0
+	b.LifeTime = 0
+	normal := models.Vector2D{}
+	if distance > 0 {
+		normal = models.Vector2D{X: dx / distance, Y: dy / distance}
+	}
+	return models.CollisionInfo{
+		EntityA:  a.ID,
+		EntityB:  b.ID,
+		Position: models.Vector2D{X: (posA.X + posB.X) / 2, Y: (posA.Y + posB.Y) / 2},
+		Normal:   normal,
+		Time:     time.Now(),
+	}, true
+}
+
 // handleCollision 处理碰撞
 func (r *Room) handleCollision(projectile *models.ProjectileEntity, player *models.PlayerEntity) {
 	// 将玩家添加到投射物的命中列表
@@ -137,46 +174,98 @@ func (r *Room) handleCollision(projectile *models.ProjectileEntity, player *mode
 	// 计算伤害
 	damage := projectile.Damage
 
+	// 获取投射物所有者，记录为最近攻击者
+	owner, _ := r.entities[projectile.OwnerID].(*models.PlayerEntity)
+	if owner != nil && owner.ID != player.ID {
+		player.LastAttackerID = owner.ID
+		player.LastAttackedAt = time.Now()
+		if player.Attackers == nil {
+			player.Attackers = make(map[string]time.Time)
+		}
+		player.Attackers[owner.ID] = player.LastAttackedAt
+	}
+
 	// 应用伤害
 	player.Health -= damage
 	if player.Health <= 0 {
-		player.Health = 0
-		player.IsAlive = false
-		player.RespawnTime = 5 // 5秒后重生
-
-		// 更新击杀统计
-		if projectile.OwnerID != "" {
-			// 获取投射物所有者
-			ownerEntity := r.entities[projectile.OwnerID]
-			if ownerEntity != nil && ownerEntity.GetType() == models.EntityPlayer {
-				ownerPlayer := ownerEntity.(*models.PlayerEntity)
-
-				// 更新玩家分数
-				r.playerMutex.Lock()
-				for _, ps := range r.players {
-					if ps.Entity.ID == ownerPlayer.ID {
-						ps.Entity.Kills++
-						r.scores[ownerPlayer.PlayerID]++
-						break
-					}
-				}
-				r.playerMutex.Unlock()
+		r.killPlayerLocked(player, owner, "")
+	}
+}
 
-				// 更新被击杀玩家的死亡次数
-				r.playerMutex.Lock()
-				for _, ps := range r.players {
-					if ps.Entity.ID == player.ID {
-						ps.Entity.Deaths++
-						break
-					}
-				}
-				r.playerMutex.Unlock()
+// killPlayerLocked 玩家阵亡并开始等待重生（调用方持有 entityMutex）。正式对局中击杀者和助攻者按模式得分，
+// 并记录击杀和死亡位置，热身期间的击杀不计分；killer 为nil时（如死于危险区域且没有最近攻击者）只记录死亡和助攻
+func (r *Room) killPlayerLocked(victim, killer *models.PlayerEntity, hazard models.HazardType) {
+	attackers := victim.Attackers
+	victim.Health = 0
+	victim.IsAlive = false
+	victim.LastAttackerID = ""
+	victim.Attackers = nil
+	delay := r.respawnDelay(time.Now())
+	victim.RespawnAt = time.Now().Add(delay)
+	victim.RespawnTime = int(math.Ceil(delay.Seconds()))
+
+	var killerID int64
+	if killer != nil {
+		killerID = killer.PlayerID
+	}
+	if r.phase == models.PhaseWarmup {
+		r.broadcastKill(killerID, victim.PlayerID, hazard)
+		return
+	}
 
-				// 广播击杀事件
-				r.broadcastKill(ownerPlayer.PlayerID, player.PlayerID)
-			}
+	// 更新击杀统计
+	r.playerMutex.Lock()
+	victim.Deaths++
+	if killer != nil {
+		killer.Kills++
+		r.scores[killer.PlayerID] += r.KillScore
+	}
+	for id, at := range attackers {
+		assistant, ok := r.entities[id].(*models.PlayerEntity)
+		if !ok || assistant == killer || time.Since(at) > assistWindow {
+			continue
 		}
+		assistant.Assists++
+		r.scores[assistant.PlayerID] += r.AssistScore
 	}
+	r.playerMutex.Unlock()
+
+	// 记录击杀与死亡位置
+	if killer != nil {
+		r.recordKillPositions(killer, victim)
+	}
+
+	// 广播击杀事件
+	r.broadcastKill(killerID, victim.PlayerID, hazard)
+}
+
+// recordKillPositions 记录一次击杀的击杀者与被击杀者位置
+func (r *Room) recordKillPositions(killer, victim *models.PlayerEntity) {
+	elapsed := time.Since(r.StartedAt).Seconds()
+
+	r.eventMutex.Lock()
+	defer r.eventMutex.Unlock()
+
+	r.positionEvents = append(r.positionEvents,
+		models.PositionEvent{
+			Type:          models.PositionEventKill,
+			PlayerID:      killer.PlayerID,
+			OtherPlayerID: victim.PlayerID,
+			CharacterID:   killer.CharacterID,
+			X:             killer.Position.X,
+			Y:             killer.Position.Y,
+			Elapsed:       elapsed,
+		},
+		models.PositionEvent{
+			Type:          models.PositionEventDeath,
+			PlayerID:      victim.PlayerID,
+			OtherPlayerID: killer.PlayerID,
+			CharacterID:   victim.CharacterID,
+			X:             victim.Position.X,
+			Y:             victim.Position.Y,
+			Elapsed:       elapsed,
+		},
+	)
 }
 
 // CreateProjectile 创建投射物
@@ -208,6 +297,11 @@ func (r *Room) CreateProjectile(owner *models.PlayerEntity, skillID int, directi
 
 // UseSkill 使用技能
 func (r *Room) UseSkill(player *models.PlayerEntity, skillID int, targetPos models.Vector2D) error {
+	// 角色等级不足时技能未解锁
+	if player.LockedSkills[skillID] {
+		return nil
+	}
+
 	// 检查技能冷却
 	if cooldown, ok := player.SkillCooldowns[skillID]; ok && cooldown > 0 {
 		return nil // 技能冷却中
@@ -227,22 +321,28 @@ func (r *Room) UseSkill(player *models.PlayerEntity, skillID int, targetPos mode
 
 	direction := models.Vector2D{X: dx, Y: dy}
 
-	// 根据技能ID创建不同的投射物
-	switch skillID {
-	case 1: // 普通射击
-		r.CreateProjectile(player, skillID, direction, 10, 500, 2.0)
-		player.SkillCooldowns[skillID] = 0.5 // 0.5秒冷却
-	case 2: // 散射
-		for i := -1; i <= 1; i++ {
-			angle := float64(i) * 15 * math.Pi / 180 // 每个投射物相差15度
-			rotatedDir := rotateVector(direction, angle)
-			r.CreateProjectile(player, skillID, rotatedDir, 8, 450, 1.5)
+	skill, ok := skillData(skillID)
+	if !ok {
+		return nil
+	}
+
+	// 根据技能类型执行
+	switch skill.Type {
+	case models.ProjectileSkill:
+		damage, cooldown := r.skillStats(skillID, skill.Damage, skill.CooldownTime)
+		r.fireProjectiles(player, skill, direction, damage)
+		player.SkillCooldowns[skillID] = cooldown
+	case models.MovementSkill:
+		_, cooldown := r.skillStats(skillID, 0, skill.CooldownTime)
+		r.dash(player, skill, direction)
+		player.SkillCooldowns[skillID] = cooldown
+	case models.BuffSkill:
+		// 伤害为负数的增益技能为治疗
+		if skill.Damage < 0 {
+			_, cooldown := r.skillStats(skillID, 0, skill.CooldownTime)
+			r.heal(player, -skill.Damage, skill.Range)
+			player.SkillCooldowns[skillID] = cooldown
 		}
-		player.SkillCooldowns[skillID] = 3.0 // 3秒冷却
-	case 3: // 穿透弹
-		projectile := r.CreateProjectile(player, skillID, direction, 15, 400, 3.0)
-		projectile.HitEntities = make([]string, 0) // 可以穿透多个目标
-		player.SkillCooldowns[skillID] = 5.0       // 5秒冷却
 	}
 
 	return nil
@@ -266,7 +366,7 @@ func (r *Room) broadcastCollisions(collisions []models.CollisionInfo) {
 		FrameId:       r.frameID,
 		Timestamp:     time.Now().UnixNano() / int64(time.Millisecond),
 		Collisions:    events,
-		RemainingTime: int32(r.TimeLimit - int(time.Since(r.StartedAt).Seconds())),
+		RemainingTime: int32(r.remainingTime(time.Now())),
 	}
 
 	// 将分数添加到帧
@@ -275,21 +375,21 @@ func (r *Room) broadcastCollisions(collisions []models.CollisionInfo) {
 		frame.Scores[playerID] = int32(score)
 	}
 
-	// 序列化
-	data, err := json.Marshal(frame)
-	if err != nil {
-		log.Printf("序列化碰撞事件失败: %v", err)
-		return
-	}
+	// 按各连接的编码序列化后广播给房间内所有玩家
+	encoder := newFrameEncoder(frame)
 
-	// 广播给房间内所有玩家
 	r.playerMutex.RLock()
 	defer r.playerMutex.RUnlock()
 
 	for _, player := range r.players {
 		if player.Connection != nil {
+			msg, err := encoder.encode(player.Connection.Encoding)
+			if err != nil {
+				log.Printf("序列化碰撞事件失败: %v", err)
+				return
+			}
 			select {
-			case player.Connection.Send <- data:
+			case player.Connection.Send <- msg:
 				// 消息已发送
 			default:
 				// 通道已满，跳过
@@ -298,9 +398,26 @@ func (r *Room) broadcastCollisions(collisions []models.CollisionInfo) {
 	}
 }
 
-// broadcastKill 广播击杀事件
-func (r *Room) broadcastKill(killerID, victimID int64) {
-	// TODO: 实现击杀事件广播
+// broadcastKill 广播击杀事件及击杀者的最新得分，并记入房间历史
+func (r *Room) broadcastKill(killerID, victimID int64, hazard models.HazardType) {
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	kill := models.KillEvent{KillerID: killerID, VictimID: victimID, KillerScore: r.scores[killerID], Hazard: hazard}
+	msg, err := newMessage("kill", kill)
+	if err != nil {
+		log.Printf("序列化击杀事件失败: %v", err)
+		return
+	}
+	r.history.add(models.RoomEvent{Type: models.RoomEventKill, Kill: &kill, At: time.Now()})
+
+	for _, ps := range r.players {
+		if ps.Connection == nil {
+			continue
+		}
+		// 通道已满时跳过
+		ps.Connection.trySend(msg)
+	}
 }
 
 // 辅助函数
@@ -312,6 +429,11 @@ func getDamageForCollision(collision models.CollisionInfo, entities map[string]m
 	entityA := entities[collision.EntityA]
 	entityB := entities[collision.EntityB]
 
+	// 投射物相互抵消不造成伤害
+	if entityA != nil && entityB != nil && entityA.GetType() == entityB.GetType() {
+		return 0
+	}
+
 	if entityA != nil && entityA.GetType() == models.EntityProjectile {
 		projectile = entityA.(*models.ProjectileEntity)
 	} else if entityB != nil && entityB.GetType() == models.EntityProjectile {
@@ -0,0 +1,85 @@
+// compression.go
+
+package game
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+var wsWriteBytesTotal = metrics.Default.NewCounterVec("pixelstorm_ws_write_bytes_total",
+	"WebSocket 发送的字节数，payload 为压缩前的消息体，wire 为实际写出的帧（含帧头）",
+	"compression", "stage")
+
+// countingConn 统计写出字节数的底层连接
+type countingConn struct {
+	net.Conn
+	written atomic.Int64
+}
+
+// Write 写出数据并累计字节数
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(int64(n))
+	return n, err
+}
+
+// countingHijacker 升级 WebSocket 时把被接管的连接包装为 countingConn
+type countingHijacker struct {
+	http.ResponseWriter
+	conn *countingConn
+}
+
+// Hijack 接管连接
+func (h *countingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(h.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.conn = &countingConn{Conn: conn}
+	return h.conn, rw, nil
+}
+
+// offersDeflate 客户端握手请求是否提供 permessage-deflate 扩展
+func offersDeflate(r *http.Request) bool {
+	for _, value := range r.Header.Values("Sec-WebSocket-Extensions") {
+		for _, ext := range strings.Split(value, ",") {
+			name, _, _ := strings.Cut(ext, ";")
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// frameWriter 写出 WebSocket 消息，已协商压缩时只压缩不小于阈值的消息，并统计压缩前后的字节数
+type frameWriter struct {
+	conn      *websocket.Conn
+	wire      *countingConn
+	deflate   bool // 已协商 permessage-deflate
+	threshold int
+}
+
+// write 写出一条消息
+func (fw *frameWriter) write(messageType int, data []byte) error {
+	compress := fw.deflate && len(data) >= fw.threshold
+	fw.conn.EnableWriteCompression(compress)
+
+	before := fw.wire.written.Load()
+	err := fw.conn.WriteMessage(messageType, data)
+
+	label := "none"
+	if compress {
+		label = "deflate"
+	}
+	wsWriteBytesTotal.Add(float64(len(data)), label, "payload")
+	wsWriteBytesTotal.Add(float64(fw.wire.written.Load()-before), label, "wire")
+	return err
+}
@@ -0,0 +1,138 @@
+// encoding.go
+
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/codec"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// EncodingJSON 所有消息以 JSON 文本帧发送（默认）
+	EncodingJSON = "json"
+	// EncodingProtobuf 实时消息以 protobuf 二进制帧发送，大厅消息仍为 JSON 文本帧
+	EncodingProtobuf = "protobuf"
+	// EncodingMsgpack 所有消息以 MessagePack 二进制帧收发，结构与 JSON 消息相同
+	EncodingMsgpack = "msgpack"
+)
+
+// parseEncoding 解析连接时 encoding 查询参数，未指定时使用 JSON
+func parseEncoding(value string) (string, error) {
+	switch value {
+	case "", EncodingJSON:
+		return EncodingJSON, nil
+	case EncodingProtobuf, EncodingMsgpack:
+		return value, nil
+	default:
+		return "", fmt.Errorf("不支持的编码: %s", value)
+	}
+}
+
+// OutboundMessage 待发送的 WebSocket 消息
+type OutboundMessage struct {
+	Binary bool // 二进制帧（protobuf 或 MessagePack 编码），否则为文本帧
+	Data   []byte
+}
+
+// encodeMessage 按连接编码转换已序列化为 JSON 的消息
+func (p *PlayerConnection) encodeMessage(data []byte) (OutboundMessage, error) {
+	if p.Encoding != EncodingMsgpack {
+		return OutboundMessage{Data: data}, nil
+	}
+	packed, err := codec.JSONToMsgpack(data)
+	if err != nil {
+		return OutboundMessage{}, err
+	}
+	return OutboundMessage{Binary: true, Data: packed}, nil
+}
+
+// trySend 为消息分配会话序号，按连接编码序列化后放入发送队列，队列已满时返回 false
+func (p *PlayerConnection) trySend(msg Message) bool {
+	if p.session != nil {
+		p.session.stamp(&msg)
+	}
+	return p.enqueue(msg)
+}
+
+// enqueue 按连接编码序列化消息后放入发送队列，队列已满时返回 false
+func (p *PlayerConnection) enqueue(msg Message) bool {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("序列化 %s 消息失败: %v", msg.Type, err)
+		return true
+	}
+	out, err := p.encodeMessage(data)
+	if err != nil {
+		log.Printf("转换消息编码失败: %v", err)
+		return true
+	}
+	select {
+	case p.Send <- out:
+		return true
+	default:
+		return false
+	}
+}
+
+// protoJSON 实时消息的 JSON 编码方式，字段名与 .proto 中保持一致
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true}
+
+// frameEncoder 按连接编码序列化同一个游戏帧，广播时每种编码只序列化一次
+type frameEncoder struct {
+	frame   *protocol.GameFrame
+	encoded map[string]OutboundMessage
+}
+
+// newFrameEncoder 创建游戏帧编码器
+func newFrameEncoder(frame *protocol.GameFrame) *frameEncoder {
+	return &frameEncoder{frame: frame, encoded: make(map[string]OutboundMessage, 2)}
+}
+
+// encode 按连接编码序列化游戏帧：protobuf 连接收到 ServerMessage 二进制帧，
+// 其他连接收到类型为 game_frame 的 Message
+func (e *frameEncoder) encode(encoding string) (OutboundMessage, error) {
+	if msg, ok := e.encoded[encoding]; ok {
+		return msg, nil
+	}
+
+	var msg OutboundMessage
+	switch encoding {
+	case EncodingProtobuf:
+		data, err := proto.Marshal(&protocol.ServerMessage{
+			Payload: &protocol.ServerMessage_GameFrame{GameFrame: e.frame},
+		})
+		if err != nil {
+			return OutboundMessage{}, err
+		}
+		msg = OutboundMessage{Binary: true, Data: data}
+	case EncodingMsgpack:
+		jsonMsg, err := e.encode(EncodingJSON)
+		if err != nil {
+			return OutboundMessage{}, err
+		}
+		data, err := codec.JSONToMsgpack(jsonMsg.Data)
+		if err != nil {
+			return OutboundMessage{}, err
+		}
+		msg = OutboundMessage{Binary: true, Data: data}
+	default:
+		payload, err := protoJSON.Marshal(e.frame)
+		if err != nil {
+			return OutboundMessage{}, err
+		}
+		data, err := json.Marshal(Message{Type: "game_frame", Payload: payload, Kind: KindRealtime})
+		if err != nil {
+			return OutboundMessage{}, err
+		}
+		msg = OutboundMessage{Data: data}
+	}
+
+	e.encoded[encoding] = msg
+	return msg, nil
+}
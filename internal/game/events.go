@@ -0,0 +1,59 @@
+// events.go
+
+package game
+
+import (
+	"log"
+	"sync"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/battlepass"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/quest"
+)
+
+// matchBattlePass 对局结束后发放通行证经验
+var matchBattlePass = battlepass.NewService(matchWallet)
+
+// matchQuests 对局结束后更新任务进度
+var matchQuests = quest.NewService(matchWallet, matchBattlePass)
+
+// subscribeOnce 保证同一进程只订阅一次对局事件
+var subscribeOnce sync.Once
+
+// subscribeMatchConsumers 订阅对局结束事件，异步发放通行证经验和更新任务进度。
+// 单个玩家处理失败只记录日志，不重试整个事件，避免其他玩家重复累计
+func subscribeMatchConsumers() {
+	subscribeOnce.Do(func() {
+		events.Subscribe("battlepass", func(e *events.Event) error {
+			var ended models.MatchEndedEvent
+			if err := e.Decode(&ended); err != nil {
+				return err
+			}
+			// 按对局结束时的活动加成计算，事件延迟处理时结果不变
+			boost := liveevent.BoostFor(ended.Match.GameMode, ended.Match.EndTime)
+			for i := range ended.Players {
+				p := &ended.Players[i]
+				if err := matchBattlePass.AddXP(p.PlayerID, liveevent.ApplyBonus(battlepass.MatchXP(p), boost.ExpBonus)); err != nil {
+					log.Printf("增加玩家 %d 通行证经验失败: %v", p.PlayerID, err)
+				}
+			}
+			return nil
+		}, events.MatchEnded)
+
+		events.Subscribe("quests", func(e *events.Event) error {
+			var ended models.MatchEndedEvent
+			if err := e.Decode(&ended); err != nil {
+				return err
+			}
+			for i := range ended.Players {
+				p := &ended.Players[i]
+				if err := matchQuests.RecordMatch(p, ended.Match.GameMode); err != nil {
+					log.Printf("更新玩家 %d 任务进度失败: %v", p.PlayerID, err)
+				}
+			}
+			return nil
+		}, events.MatchEnded)
+	})
+}
@@ -0,0 +1,148 @@
+// handshake.go
+
+package game
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+var handshakesTotal = metrics.Default.NewCounterVec("pixelstorm_ws_handshakes_total",
+	"WebSocket 协议握手次数，按客户端协议版本和结果统计", "client_version", "result")
+
+// HelloPayload 客户端握手消息，应为连接后发送的第一条消息
+type HelloPayload struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+	ClientVersion   string   `json:"client_version"` // 客户端版本号，仅用于日志
+	Locale          string   `json:"locale"`         // 客户端界面语言，如 en、zh-CN，优先于连接请求中的语言
+
+	// 断线重连时恢复之前的消息会话，消息信封的 ack 为断线前收到的最大序号
+	Resume bool `json:"resume"`
+}
+
+// WelcomePayload 握手成功响应
+type WelcomePayload struct {
+	ProtocolVersion int      `json:"protocol_version"` // 协商后的协议版本
+	Capabilities    []string `json:"capabilities"`     // 双方都支持的能力
+	Encoding        string   `json:"encoding"`         // 消息编码
+	Resumed         bool     `json:"resumed"`          // 是否恢复了之前的消息会话，未恢复时客户端应重新同步状态
+}
+
+// UpgradeRequiredPayload 客户端协议版本过低，连接随后关闭
+type UpgradeRequiredPayload struct {
+	Code               errcode.Code `json:"code"`
+	Message            string       `json:"message"`
+	MinProtocolVersion int          `json:"min_protocol_version"`
+	MaxProtocolVersion int          `json:"max_protocol_version"`
+}
+
+// minProtocolVersion 允许连接的最低协议版本
+func (s *GameServer) minProtocolVersion() int {
+	return min(max(s.config.Server.MinProtocolVersion, protocol.MinProtocolVersion), protocol.ProtocolVersion)
+}
+
+// handleHello 处理客户端握手，选择双方都支持的协议版本和能力，按需恢复之前的消息会话
+func (s *GameServer) handleHello(player *PlayerConnection, msg *Message, hello *HelloPayload) {
+	if player.ProtocolVersion != 0 {
+		log.Printf("玩家 %d 重复握手，已忽略", player.PlayerID)
+		return
+	}
+
+	if l, ok := i18n.Parse(hello.Locale); ok {
+		player.Locale = l
+	}
+
+	if !s.negotiate(player, hello.ProtocolVersion) {
+		log.Printf("玩家 %d 协议版本 %d 过低（客户端 %s），已要求升级",
+			player.PlayerID, hello.ProtocolVersion, hello.ClientVersion)
+		return
+	}
+
+	capabilities := []string{}
+	for _, capability := range protocol.Capabilities {
+		if slices.Contains(hello.Capabilities, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	encoding := player.Encoding
+	switch {
+	case slices.Contains(capabilities, protocol.CapabilityProtobuf):
+		encoding = EncodingProtobuf
+		capabilities = slices.DeleteFunc(capabilities, func(c string) bool { return c == protocol.CapabilityMsgpack })
+	case slices.Contains(capabilities, protocol.CapabilityMsgpack):
+		encoding = EncodingMsgpack
+	}
+	player.Capabilities = capabilities
+
+	var resend []Message
+	resumed := false
+	if hello.Resume {
+		player.session.receive(msg.Seq)
+		resend, resumed = player.session.resume(msg.Ack)
+	}
+	if !resumed {
+		player.session.reset()
+		player.session.receive(msg.Seq)
+	}
+
+	// welcome 仍按握手时的编码发送，之后的消息使用协商后的编码
+	s.sendPayload(player, "welcome", WelcomePayload{
+		ProtocolVersion: player.ProtocolVersion,
+		Capabilities:    capabilities,
+		Encoding:        encoding,
+		Resumed:         resumed,
+	})
+	player.Encoding = encoding
+
+	// 重发客户端未确认的关键消息，保留原序号供客户端去重
+	for _, m := range resend {
+		if !player.enqueue(m) {
+			log.Printf("玩家 %d 发送队列已满，剩余关键消息等待下次重连", player.PlayerID)
+			break
+		}
+	}
+	if len(resend) > 0 {
+		log.Printf("玩家 %d 恢复消息会话，重发 %d 条关键消息", player.PlayerID, len(resend))
+	}
+}
+
+// negotiate 按客户端协议版本确定连接使用的版本，版本过低时通知客户端升级并关闭连接
+func (s *GameServer) negotiate(player *PlayerConnection, clientVersion int) bool {
+	label := protocolVersionLabel(clientVersion)
+
+	if minVersion := s.minProtocolVersion(); clientVersion < minVersion {
+		handshakesTotal.Inc(label, "upgrade_required")
+		s.sendPayload(player, "upgrade_required", UpgradeRequiredPayload{
+			Code:               errcode.UpgradeRequired,
+			Message:            i18n.Translate(player.Locale, fmt.Sprintf("客户端协议版本过低，请升级到支持协议版本 %d 及以上的客户端", minVersion)),
+			MinProtocolVersion: minVersion,
+			MaxProtocolVersion: protocol.ProtocolVersion,
+		})
+		s.closeConnection(player)
+		return false
+	}
+
+	player.ProtocolVersion = min(clientVersion, protocol.ProtocolVersion)
+	handshakesTotal.Inc(label, "accepted")
+	return true
+}
+
+// protocolVersionLabel 客户端协议版本的指标标签，限制取值范围以免任意版本号产生过多序列
+func protocolVersionLabel(version int) string {
+	switch {
+	case version < 1:
+		return "invalid"
+	case version > protocol.ProtocolVersion:
+		return "newer"
+	default:
+		return strconv.Itoa(version)
+	}
+}
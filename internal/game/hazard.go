@@ -0,0 +1,97 @@
+// hazard.go
+
+package game
+
+import (
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// lastAttackerWindow 死于危险区域时，在此时间内命中过该玩家的攻击者获得击杀
+const lastAttackerWindow = 5 * time.Second
+
+// spawnAttempts 随机选择出生点的最多次数
+const spawnAttempts = 20
+
+// activeHazard 房间中的危险区域及下次造成伤害的时间
+type activeHazard struct {
+	models.MapHazard
+	nextTick time.Time
+}
+
+// loadHazards 从静态游戏数据加载地图的危险区域，数据不可用时没有危险区域
+func loadHazards(mapID int) []activeHazard {
+	catalog := gamedata.Current()
+	if catalog == nil {
+		return nil
+	}
+	m, ok := catalog.Map(mapID)
+	if !ok {
+		return nil
+	}
+
+	hazards := make([]activeHazard, 0, len(m.Hazards))
+	for _, h := range m.Hazards {
+		hazards = append(hazards, activeHazard{MapHazard: h})
+	}
+	return hazards
+}
+
+// applyHazards 致命区域内的存活玩家立即死亡，其他区域每隔一段时间对区域内的存活玩家造成伤害
+func (r *Room) applyHazards(now time.Time) {
+	if len(r.hazards) == 0 {
+		return
+	}
+
+	r.entityMutex.Lock()
+	defer r.entityMutex.Unlock()
+
+	for i := range r.hazards {
+		h := &r.hazards[i]
+		if !h.Lethal {
+			if now.Before(h.nextTick) {
+				continue
+			}
+			h.nextTick = now.Add(time.Duration(h.Interval * float64(time.Second)))
+		}
+
+		for _, entity := range r.entities {
+			player, ok := entity.(*models.PlayerEntity)
+			if !ok || !player.IsAlive || !h.Contains(player.Position) {
+				continue
+			}
+			if !h.Lethal {
+				player.Health -= h.Damage
+				if player.Health > 0 {
+					continue
+				}
+			}
+			r.killPlayerLocked(player, r.lastAttackerLocked(player, now), h.Type)
+		}
+	}
+}
+
+// lastAttackerLocked 玩家的最近攻击者，超过 lastAttackerWindow 或攻击者已离开房间时返回nil（调用方持有 entityMutex）
+func (r *Room) lastAttackerLocked(player *models.PlayerEntity, now time.Time) *models.PlayerEntity {
+	if player.LastAttackerID == "" || now.Sub(player.LastAttackedAt) > lastAttackerWindow {
+		return nil
+	}
+	attacker, _ := r.entities[player.LastAttackerID].(*models.PlayerEntity)
+	return attacker
+}
+
+// safeSpawn 出生点与所有致命或造成伤害的危险区域至少相距一个玩家半径
+func safeSpawn(pos models.Vector2D, hazards []activeHazard) bool {
+	for _, h := range hazards {
+		if !h.Lethal && h.Damage <= 0 {
+			continue
+		}
+		if pos.X >= h.X-playerRadius && pos.X <= h.X+h.Width+playerRadius &&
+			pos.Y >= h.Y-playerRadius && pos.Y <= h.Y+h.Height+playerRadius {
+			return false
+		}
+	}
+	return true
+}
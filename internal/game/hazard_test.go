@@ -0,0 +1,67 @@
+// hazard_test.go
+
+package game
+
+import (
+	"testing"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+func TestSpawnPosition(t *testing.T) {
+	lava := activeHazard{MapHazard: models.MapHazard{Type: models.HazardLava, X: 0, Y: 0, Width: 500, Height: 1000, Lethal: true}}
+	spikes := activeHazard{MapHazard: models.MapHazard{Type: models.HazardSpikes, X: 500, Y: 0, Width: 500, Height: 500, Damage: 10, Interval: 1}}
+	harmless := activeHazard{MapHazard: models.MapHazard{Type: models.HazardSpikes, X: 500, Y: 500, Width: 500, Height: 200}}
+
+	tests := []struct {
+		name    string
+		hazards []activeHazard
+		check   func(models.Vector2D) bool
+	}{
+		{
+			name:    "没有危险区域时在地图范围内",
+			hazards: []activeHazard{},
+			check: func(p models.Vector2D) bool {
+				return p.X >= playerRadius && p.X <= defaultMapSize-playerRadius &&
+					p.Y >= playerRadius && p.Y <= defaultMapSize-playerRadius
+			},
+		},
+		{
+			name:    "避开致命和造成伤害的区域",
+			hazards: []activeHazard{lava, spikes, harmless},
+			check: func(p models.Vector2D) bool {
+				return p.X >= 500+playerRadius && p.Y >= 500+playerRadius && p.Y <= defaultMapSize-playerRadius
+			},
+		},
+		{
+			name:    "没有安全位置时使用地图中心",
+			hazards: []activeHazard{{MapHazard: models.MapHazard{Width: defaultMapSize, Height: defaultMapSize, Lethal: true}}},
+			check: func(p models.Vector2D) bool {
+				return p == models.Vector2D{X: defaultMapSize / 2, Y: defaultMapSize / 2}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Room{hazards: tt.hazards}
+			for i := 0; i < 100; i++ {
+				if pos := r.spawnPosition(); !tt.check(pos) {
+					t.Fatalf("spawnPosition() = %+v", pos)
+				}
+			}
+		})
+	}
+}
+
+func TestSpawnPositionGridFallback(t *testing.T) {
+	// 只留下右下角一小块安全区域，随机选择大概率失败，需要按网格找到
+	r := &Room{hazards: []activeHazard{
+		{MapHazard: models.MapHazard{Width: defaultMapSize, Height: 900, Lethal: true}},
+		{MapHazard: models.MapHazard{Y: 900, Width: 900, Height: 100, Lethal: true}},
+	}}
+	for i := 0; i < 20; i++ {
+		if pos := r.spawnPosition(); !safeSpawn(pos, r.hazards) {
+			t.Fatalf("spawnPosition() = %+v, 位于危险区域附近", pos)
+		}
+	}
+}
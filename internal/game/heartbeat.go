@@ -0,0 +1,133 @@
+// heartbeat.go
+
+package game
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+const (
+	// heartbeatInterval 应用层心跳的发送间隔
+	heartbeatInterval = 5 * time.Second
+
+	// rttSmoothing 平滑往返延迟时新样本的权重
+	rttSmoothing = 0.125
+)
+
+var rttSeconds = metrics.Default.NewHistogramVec("pixelstorm_ws_rtt_seconds",
+	"应用层心跳测得的往返延迟(秒)，按所在房间的游戏模式统计，不在房间中为 lobby",
+	[]float64{0.01, 0.025, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.5, 1, 2}, "mode")
+
+// HeartbeatPayload 服务端心跳，客户端收到后应立即回复带相同 id 的 pong
+type HeartbeatPayload struct {
+	ID         uint64 `json:"id"`
+	ServerTime int64  `json:"server_time"` // 服务端发送时间(毫秒时间戳)
+	RTT        int64  `json:"rtt"`         // 服务端测得的平滑往返延迟(毫秒)，尚未测得时为0
+}
+
+// PongPayload 客户端对心跳的回复
+type PongPayload struct {
+	ID uint64 `json:"id"`
+}
+
+// validate 校验心跳回复
+func (p *PongPayload) validate() error {
+	if p.ID == 0 {
+		return errors.New("id 必须为正整数")
+	}
+	return nil
+}
+
+// playerLatency 玩家连接的往返延迟测量状态
+type playerLatency struct {
+	mu     sync.Mutex
+	pingID uint64        // 最近一次发出的心跳编号
+	sentAt time.Time     // 最近一次心跳的发送时间，收到回复后清零
+	rtt    time.Duration // 平滑往返延迟
+}
+
+// RTT 平滑后的往返延迟，尚未测得时为0
+func (p *PlayerConnection) RTT() time.Duration {
+	p.latency.mu.Lock()
+	defer p.latency.mu.Unlock()
+	return p.latency.rtt
+}
+
+// heartbeatLoop 定期向支持应用层心跳的连接发送心跳
+func (s *GameServer) heartbeatLoop() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.connMutex.RLock()
+			players := make([]*PlayerConnection, 0, len(s.connections))
+			for _, player := range s.connections {
+				if player.IsAlive && player.ProtocolVersion >= protocol.HeartbeatProtocolVersion {
+					players = append(players, player)
+				}
+			}
+			s.connMutex.RUnlock()
+
+			for _, player := range players {
+				s.sendHeartbeat(player)
+			}
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+// sendHeartbeat 发送一次心跳。上一次心跳未收到回复时以新心跳为准
+func (s *GameServer) sendHeartbeat(player *PlayerConnection) {
+	l := &player.latency
+	l.mu.Lock()
+	l.pingID++
+	l.sentAt = time.Now()
+	payload := HeartbeatPayload{
+		ID:         l.pingID,
+		ServerTime: l.sentAt.UnixMilli(),
+		RTT:        l.rtt.Milliseconds(),
+	}
+	l.mu.Unlock()
+
+	msg, err := newMessage("heartbeat", payload)
+	if err != nil {
+		log.Printf("序列化心跳消息失败: %v", err)
+		return
+	}
+	msg.Kind = KindRealtime
+	// 通道已满时跳过本次心跳
+	player.trySend(msg)
+}
+
+// handlePong 处理心跳回复，更新往返延迟。过期或重复的回复被忽略
+func (s *GameServer) handlePong(player *PlayerConnection, pong *PongPayload) {
+	l := &player.latency
+	l.mu.Lock()
+	if pong.ID != l.pingID || l.sentAt.IsZero() {
+		l.mu.Unlock()
+		return
+	}
+	sample := time.Since(l.sentAt)
+	l.sentAt = time.Time{}
+	if l.rtt == 0 {
+		l.rtt = sample
+	} else {
+		l.rtt += time.Duration(rttSmoothing * float64(sample-l.rtt))
+	}
+	l.mu.Unlock()
+
+	mode := "lobby"
+	if room := player.Room; room != nil {
+		mode = string(room.Mode)
+	}
+	rttSeconds.Observe(sample.Seconds(), mode)
+}
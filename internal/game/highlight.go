@@ -0,0 +1,60 @@
+// highlight.go
+
+package game
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// multiKillGap 连杀中相邻两次击杀的最大间隔(秒)
+const multiKillGap = 5.0
+
+// multiKillHighlights 从击杀事件中找出每名玩家的连续击杀，至少两次击杀才标记
+func multiKillHighlights(events []models.PositionEvent) []models.Highlight {
+	kills := make(map[int64][]float64)
+	var order []int64
+	for _, e := range events {
+		if e.Type != models.PositionEventKill {
+			continue
+		}
+		if _, ok := kills[e.PlayerID]; !ok {
+			order = append(order, e.PlayerID)
+		}
+		kills[e.PlayerID] = append(kills[e.PlayerID], e.Elapsed)
+	}
+
+	var highlights []models.Highlight
+	for _, playerID := range order {
+		times := kills[playerID]
+		slices.Sort(times)
+		start := 0
+		for i := 1; i <= len(times); i++ {
+			if i < len(times) && times[i]-times[i-1] <= multiKillGap {
+				continue
+			}
+			if count := i - start; count >= 2 {
+				highlights = append(highlights, models.Highlight{
+					Type:     models.HighlightMultiKill,
+					PlayerID: playerID,
+					Kills:    count,
+					Elapsed:  times[start],
+					Duration: times[i-1] - times[start],
+				})
+			}
+			start = i
+		}
+	}
+	return highlights
+}
+
+// matchHighlights 合并连杀和对局中记录的赛点标记，按时间排序
+func matchHighlights(events []models.PositionEvent, matchPoints []models.Highlight) []models.Highlight {
+	highlights := append(multiKillHighlights(events), matchPoints...)
+	slices.SortStableFunc(highlights, func(a, b models.Highlight) int {
+		return cmp.Compare(a.Elapsed, b.Elapsed)
+	})
+	return highlights
+}
@@ -0,0 +1,60 @@
+// highlight_test.go
+
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// kill 玩家在对局开始后 elapsed 秒的击杀事件
+func kill(playerID int64, elapsed float64) models.PositionEvent {
+	return models.PositionEvent{Type: models.PositionEventKill, PlayerID: playerID, Elapsed: elapsed}
+}
+
+func TestMultiKillHighlights(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []models.PositionEvent
+		want   []models.Highlight
+	}{
+		{name: "单次击杀不标记", events: []models.PositionEvent{kill(1, 10), kill(1, 30)}},
+		{
+			name:   "间隔内的两次击杀",
+			events: []models.PositionEvent{kill(1, 10), kill(1, 14)},
+			want:   []models.Highlight{{Type: models.HighlightMultiKill, PlayerID: 1, Kills: 2, Elapsed: 10, Duration: 4}},
+		},
+		{
+			name:   "相邻间隔不超过连杀间隔时持续累计",
+			events: []models.PositionEvent{kill(1, 10), kill(1, 15), kill(1, 20), kill(1, 40)},
+			want:   []models.Highlight{{Type: models.HighlightMultiKill, PlayerID: 1, Kills: 3, Elapsed: 10, Duration: 10}},
+		},
+		{
+			name:   "一名玩家的多次连杀",
+			events: []models.PositionEvent{kill(1, 10), kill(1, 12), kill(1, 60), kill(1, 61), kill(1, 62)},
+			want: []models.Highlight{
+				{Type: models.HighlightMultiKill, PlayerID: 1, Kills: 2, Elapsed: 10, Duration: 2},
+				{Type: models.HighlightMultiKill, PlayerID: 1, Kills: 3, Elapsed: 60, Duration: 2},
+			},
+		},
+		{
+			name: "不同玩家的击杀不连续计算，死亡事件忽略",
+			events: []models.PositionEvent{
+				kill(1, 10), kill(2, 11),
+				{Type: models.PositionEventDeath, PlayerID: 1, Elapsed: 12},
+				kill(2, 13),
+			},
+			want: []models.Highlight{{Type: models.HighlightMultiKill, PlayerID: 2, Kills: 2, Elapsed: 11, Duration: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := multiKillHighlights(tt.events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("multiKillHighlights() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
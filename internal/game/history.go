@@ -0,0 +1,72 @@
+// history.go
+
+package game
+
+import (
+	"log"
+	"sync"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+)
+
+// maxRoomHistory 房间保留的最近事件数
+const maxRoomHistory = 50
+
+// RoomHistoryPayload 加入房间后发送的最近事件，按时间先后排列
+type RoomHistoryPayload struct {
+	Events []models.RoomEvent `json:"events"`
+}
+
+// roomHistory 房间最近的聊天和击杀事件，中途加入或断线重新加入的玩家据此恢复上下文
+type roomHistory struct {
+	mu     sync.Mutex
+	events []models.RoomEvent
+}
+
+// add 记录事件，超过上限时丢弃最早的事件
+func (h *roomHistory) add(event models.RoomEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.events = append(h.events, event)
+	if len(h.events) > maxRoomHistory {
+		h.events = append(h.events[:0], h.events[len(h.events)-maxRoomHistory:]...)
+	}
+}
+
+// snapshot 获取事件副本
+func (h *roomHistory) snapshot() []models.RoomEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]models.RoomEvent(nil), h.events...)
+}
+
+// SendHistory 向刚加入的玩家发送房间最近的事件，跳过该玩家屏蔽的玩家的聊天消息，没有事件时不发送
+func (r *Room) SendHistory(conn *PlayerConnection) {
+	events := r.history.snapshot()
+	if len(events) == 0 {
+		return
+	}
+
+	blocked, err := social.Blocked(conn.PlayerID)
+	if err != nil {
+		log.Printf("加载玩家 %d 屏蔽列表失败: %v", conn.PlayerID, err)
+		blocked = map[int64]bool{}
+	}
+	visible := events[:0]
+	for _, event := range events {
+		if event.Chat != nil && blocked[event.Chat.PlayerID] {
+			continue
+		}
+		visible = append(visible, event)
+	}
+
+	msg, err := newMessage("room_history", RoomHistoryPayload{Events: visible})
+	if err != nil {
+		log.Printf("序列化房间历史失败: %v", err)
+		return
+	}
+	// 通道已满时跳过
+	conn.trySend(msg)
+}
@@ -0,0 +1,52 @@
+// input.go
+
+package game
+
+import (
+	"log"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// maxPendingSkills 每个房间在两帧之间最多缓存的技能操作数，超出的操作被丢弃
+const maxPendingSkills = 256
+
+// skillInput 玩家的技能操作，由游戏循环在下一帧执行
+type skillInput struct {
+	connID  string
+	skillID int
+	target  models.Vector2D
+}
+
+// QueueSkill 记录玩家的技能操作，由游戏循环在下一帧执行，避免与实体更新并发修改技能冷却
+func (r *Room) QueueSkill(connID string, skillID int, target models.Vector2D) {
+	r.inputMutex.Lock()
+	defer r.inputMutex.Unlock()
+	if len(r.pendingSkills) >= maxPendingSkills {
+		return
+	}
+	r.pendingSkills = append(r.pendingSkills, skillInput{connID: connID, skillID: skillID, target: target})
+}
+
+// applySkillInputs 执行上一帧以来存活玩家的技能操作，倒计时期间的操作被丢弃
+func (r *Room) applySkillInputs() {
+	r.inputMutex.Lock()
+	inputs := r.pendingSkills
+	r.pendingSkills = nil
+	r.inputMutex.Unlock()
+
+	if r.phase == models.PhaseCountdown {
+		return
+	}
+	for _, input := range inputs {
+		r.playerMutex.RLock()
+		state, ok := r.players[input.connID]
+		r.playerMutex.RUnlock()
+		if !ok || !state.Entity.IsAlive {
+			continue
+		}
+		if err := r.UseSkill(state.Entity, input.skillID, input.target); err != nil {
+			log.Printf("玩家 %d 使用技能 %d 失败: %v", state.Entity.PlayerID, input.skillID, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// maintenance.go
+
+package game
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// maintenanceTitle 维护公告标题
+const maintenanceTitle = "服务器维护"
+
+// watchMaintenance 按计划维护向本实例玩家发送倒计时公告，维护开始时结束所有房间
+func (s *GameServer) watchMaintenance() {
+	maintenance.Watch(s.shutdown, maintenance.Hooks{
+		Warn:  s.warnMaintenance,
+		Begin: s.beginMaintenance,
+		End: func(w models.MaintenanceWindow) {
+			s.announceMaintenance(w, "end", models.SeverityInfo, "服务器维护已结束，欢迎回来")
+		},
+		Cancel: func(w models.MaintenanceWindow) {
+			s.announceMaintenance(w, "cancel", models.SeverityInfo, "原定的服务器维护已取消")
+		},
+	})
+}
+
+// warnMaintenance 维护前警告玩家，最后一分钟使用紧急级别
+func (s *GameServer) warnMaintenance(w models.MaintenanceWindow, remaining time.Duration) {
+	severity := models.SeverityWarning
+	if remaining <= time.Minute {
+		severity = models.SeverityCritical
+	}
+	minutes := int(math.Ceil(remaining.Minutes()))
+	text := fmt.Sprintf("服务器将于 %d 分钟后开始维护，届时进行中的对局将按当前比分结算", minutes)
+	if w.Reason != "" {
+		text += "。" + w.Reason
+	}
+
+	startsAt := w.StartsAt
+	s.Announce(models.Announcement{
+		ID:            fmt.Sprintf("maintenance-%d-%d", w.ID, minutes),
+		Severity:      severity,
+		Title:         maintenanceTitle,
+		Message:       text,
+		MaintenanceAt: &startsAt,
+		CreatedAt:     time.Now(),
+	})
+}
+
+// beginMaintenance 维护开始：通知玩家并提前结束本实例的所有房间，进行中的对局保存结果
+func (s *GameServer) beginMaintenance(w models.MaintenanceWindow) {
+	s.announceMaintenance(w, "begin", models.SeverityCritical,
+		fmt.Sprintf("服务器维护中，预计 %s 恢复", w.EndsAt.Local().Format("15:04")))
+
+	rooms := s.ListRooms()
+	for _, room := range rooms {
+		room.ForceEnd()
+	}
+	log.Printf("维护开始，已结束 %d 个房间", len(rooms))
+}
+
+// announceMaintenance 向本实例玩家发送维护状态公告，stage 区分同一维护的不同公告
+func (s *GameServer) announceMaintenance(w models.MaintenanceWindow, stage string, severity models.AnnouncementSeverity, text string) {
+	s.Announce(models.Announcement{
+		ID:        fmt.Sprintf("maintenance-%d-%s", w.ID, stage),
+		Severity:  severity,
+		Title:     maintenanceTitle,
+		Message:   text,
+		CreatedAt: time.Now(),
+	})
+}
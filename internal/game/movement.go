@@ -0,0 +1,93 @@
+// movement.go
+
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// defaultMapSize 地图数据不可用时的地图边长，与出生点范围一致
+const defaultMapSize = 1000.0
+
+// dashStep 检查位移路径时的步长
+const dashStep = playerRadius / 2
+
+// dash 执行位移技能：沿瞄准方向移动技能射程的距离，碰到地图边界或（开启玩家碰撞时）其他存活玩家时停在碰撞前的位置。
+// 技能配置了效果时间时，位移后在该时间内无法被投射物选中
+func (r *Room) dash(player *models.PlayerEntity, skill models.Skill, direction models.Vector2D) {
+	if skill.Range <= 0 || (direction.X == 0 && direction.Y == 0) {
+		return
+	}
+	width, height := r.mapSize()
+
+	r.entityMutex.Lock()
+	defer r.entityMutex.Unlock()
+
+	start := player.GetPosition()
+	// 起点已与其他玩家重叠时允许离开，不因这些玩家被阻挡
+	overlapping := make(map[string]bool)
+	for id, entity := range r.entities {
+		if other, ok := entity.(*models.PlayerEntity); ok && bodyBlocks(player, other, start) {
+			overlapping[id] = true
+		}
+	}
+
+	end := start
+	for travelled := dashStep; ; travelled += dashStep {
+		travelled = math.Min(travelled, skill.Range)
+		next := models.Vector2D{X: start.X + direction.X*travelled, Y: start.Y + direction.Y*travelled}
+		if !r.dashClear(player, next, width, height, overlapping) {
+			break
+		}
+		end = next
+		if travelled >= skill.Range {
+			break
+		}
+	}
+
+	player.Position = end
+	if skill.EffectTime > 0 {
+		player.UntargetableUntil = time.Now().Add(time.Duration(skill.EffectTime * float64(time.Second)))
+	}
+}
+
+// dashClear 位移路径上的点是否在地图内，开启玩家碰撞时还需不与其他存活玩家重叠（调用方持有 entityMutex）
+func (r *Room) dashClear(player *models.PlayerEntity, pos models.Vector2D, width, height float64, overlapping map[string]bool) bool {
+	if pos.X < 0 || pos.Y < 0 || pos.X > width || pos.Y > height {
+		return false
+	}
+	if !r.BodyBlocking {
+		return true
+	}
+	for id, entity := range r.entities {
+		other, ok := entity.(*models.PlayerEntity)
+		if ok && !overlapping[id] && bodyBlocks(player, other, pos) {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyBlocks 玩家位于 pos 时是否与另一名存活玩家的身体重叠
+func bodyBlocks(player, other *models.PlayerEntity, pos models.Vector2D) bool {
+	if other.ID == player.ID || !other.IsAlive {
+		return false
+	}
+	dx := pos.X - other.Position.X
+	dy := pos.Y - other.Position.Y
+	return math.Sqrt(dx*dx+dy*dy) < 2*playerRadius
+}
+
+// mapSize 房间地图的宽高，地图数据不可用时使用默认大小
+func (r *Room) mapSize() (float64, float64) {
+	if catalog := gamedata.Current(); catalog != nil {
+		if m, ok := catalog.Map(r.MapID); ok && m.Width > 0 && m.Height > 0 {
+			return float64(m.Width), float64(m.Height)
+		}
+	}
+	return defaultMapSize, defaultMapSize
+}
@@ -0,0 +1,85 @@
+// overtime.go
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// startOvertime 对局时间到时领先者比分持平，按配置进入加时，返回是否已进入加时。
+// 不加时或加时为0秒的延长时间直接以平局结束
+func (r *Room) startOvertime() bool {
+	switch r.Overtime {
+	case models.OvertimeSuddenDeath:
+	case models.OvertimeExtraTime:
+		if r.OvertimeTime <= 0 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+
+	if !r.leaderTiedLocked() {
+		return false
+	}
+
+	log.Printf("房间 %s 比分持平，进入加时 (%s, %d 秒)", r.ID, r.Overtime, r.OvertimeTime)
+	r.enterPhaseLocked(models.PhaseOvertime, time.Duration(r.OvertimeTime)*time.Second)
+	return true
+}
+
+// checkOvertimeEnd 突然死亡在打破平局后结束，限时加时到期后结束，仍持平则为平局
+func (r *Room) checkOvertimeEnd() {
+	if r.OvertimeTime > 0 && !time.Now().Before(r.phaseEndsAt) {
+		r.endGame()
+		return
+	}
+	if r.Overtime != models.OvertimeSuddenDeath {
+		return
+	}
+
+	r.playerMutex.RLock()
+	tied := r.leaderTiedLocked()
+	r.playerMutex.RUnlock()
+	if !tied {
+		r.endGame()
+	}
+}
+
+// scoreTotalsLocked 决定胜负的得分：团队模式为队伍 -> 队伍总分，个人模式为玩家ID -> 个人得分（调用方持有 playerMutex）
+func (r *Room) scoreTotalsLocked() map[int64]int {
+	totals := make(map[int64]int)
+	teamMode := r.Mode.IsTeamMode()
+	for _, ps := range r.players {
+		if ps.Entity == nil {
+			continue
+		}
+		score := r.scores[ps.Entity.PlayerID]
+		if !teamMode {
+			totals[ps.Entity.PlayerID] = score
+		} else if ps.Entity.Team != models.TeamNone {
+			totals[int64(ps.Entity.Team)] += score
+		}
+	}
+	return totals
+}
+
+// leaderTiedLocked 最高分是否有多个队伍（团队模式）或玩家同分（调用方持有 playerMutex）
+func (r *Room) leaderTiedLocked() bool {
+	best, count := -1, 0
+	for _, score := range r.scoreTotalsLocked() {
+		switch {
+		case score > best:
+			best, count = score, 1
+		case score == best:
+			count++
+		}
+	}
+	return count > 1
+}
@@ -0,0 +1,27 @@
+// respawn.go
+
+package game
+
+import (
+	"time"
+)
+
+// respawnDelay 计算玩家阵亡后的重生等待时间。正式对局中重生时间随对局进行线性延长，
+// 时间到时达到最长重生时间；配置了重生波次时，等待满重生时间后在下一个波次与其他阵亡玩家一起重生
+func (r *Room) respawnDelay(now time.Time) time.Duration {
+	delay := time.Duration(r.RespawnTime) * time.Second
+
+	if r.phase.Scoring() && r.RespawnMaxTime > r.RespawnTime && r.TimeLimit > 0 {
+		progress := min(now.Sub(r.StartedAt).Seconds()/float64(r.TimeLimit), 1)
+		delay += time.Duration(progress * float64(r.RespawnMaxTime-r.RespawnTime) * float64(time.Second))
+	}
+
+	// 波次从对局（或热身）开始时计算
+	if r.RespawnWave > 0 {
+		wave := time.Duration(r.RespawnWave) * time.Second
+		earliest := now.Add(delay).Sub(r.StartedAt)
+		next := (earliest + wave - 1) / wave * wave
+		delay = r.StartedAt.Add(next).Sub(now)
+	}
+	return delay
+}
@@ -0,0 +1,50 @@
+// respawn_test.go
+
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+func TestRespawnDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		phase   models.MatchPhase
+		elapsed time.Duration // 距对局开始的时间
+		min     int           // RespawnTime
+		max     int           // RespawnMaxTime
+		wave    int           // RespawnWave
+		want    time.Duration
+	}{
+		{name: "固定重生时间", phase: models.PhaseLive, elapsed: 150 * time.Second, min: 5, want: 5 * time.Second},
+		{name: "对局开始时为最短时间", phase: models.PhaseLive, min: 5, max: 10, want: 5 * time.Second},
+		{name: "对局过半线性延长", phase: models.PhaseLive, elapsed: 150 * time.Second, min: 5, max: 10, want: 7500 * time.Millisecond},
+		{name: "加时超过时间限制时不超过最长时间", phase: models.PhaseOvertime, elapsed: 400 * time.Second, min: 5, max: 10, want: 10 * time.Second},
+		{name: "热身期间不延长", phase: models.PhaseWarmup, elapsed: 150 * time.Second, min: 5, max: 10, want: 5 * time.Second},
+		{name: "最长时间小于最短时间时不延长", phase: models.PhaseLive, elapsed: 150 * time.Second, min: 5, max: 3, want: 5 * time.Second},
+		{name: "等到下一个波次", phase: models.PhaseLive, elapsed: 12 * time.Second, min: 5, wave: 10, want: 8 * time.Second},
+		{name: "正好在波次上", phase: models.PhaseLive, elapsed: 5 * time.Second, min: 5, wave: 10, want: 5 * time.Second},
+		{name: "延长后再对齐波次", phase: models.PhaseLive, elapsed: 150 * time.Second, min: 5, max: 10, wave: 10, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Room{
+				TimeLimit:      300,
+				RespawnTime:    tt.min,
+				RespawnMaxTime: tt.max,
+				RespawnWave:    tt.wave,
+				StartedAt:      now.Add(-tt.elapsed),
+				phase:          tt.phase,
+			}
+			if got := r.respawnDelay(now); got != tt.want {
+				t.Errorf("respawnDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+// result.go
+
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 对局金币奖励
+const (
+	matchRewardBase    = 10 // 完成对局
+	matchRewardWin     = 20 // 获胜
+	matchRewardPerKill = 2  // 每次击杀
+	matchRewardMVP     = 10 // MVP
+)
+
+// matchWallet 对局奖励入账使用的钱包服务
+var matchWallet = wallet.NewService()
+
+// buildMatchResult 根据房间当前状态生成对局结果
+func (r *Room) buildMatchResult() *models.MatchResult {
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	result := &models.MatchResult{
+		Match: models.MatchRecord{
+			ID:        r.ID,
+			GameMode:  r.Mode,
+			StartTime: r.StartedAt,
+			EndTime:   r.EndedAt,
+			MapID:     r.MapID,
+			Duration:  int(r.EndedAt.Sub(r.StartedAt).Seconds()),
+		},
+		Players: make([]models.PlayerMatchRecord, 0, len(r.players)),
+	}
+
+	// 统计各队伍得分
+	teamScores := make(map[models.Team]int)
+	for _, ps := range r.players {
+		if ps.Entity == nil {
+			continue
+		}
+		score := r.scores[ps.Entity.PlayerID]
+		teamScores[ps.Entity.Team] += score
+
+		joinTime := ps.JoinedAt
+		if joinTime.Before(r.StartedAt) {
+			joinTime = r.StartedAt
+		}
+
+		result.Players = append(result.Players, models.PlayerMatchRecord{
+			MatchID:     r.ID,
+			PlayerID:    ps.Entity.PlayerID,
+			CharacterID: ps.Entity.CharacterID,
+			Team:        int(ps.Entity.Team),
+			Score:       score,
+			Kills:       ps.Entity.Kills,
+			Deaths:      ps.Entity.Deaths,
+			Assists:     ps.Entity.Assists,
+			PlayTime:    int(r.EndedAt.Sub(joinTime).Seconds()),
+			JoinTime:    joinTime,
+			LeaveTime:   r.EndedAt,
+		})
+	}
+
+	// 判定胜负：团队模式按队伍总分，个人模式按个人得分
+	if r.Mode.IsTeamMode() {
+		winningTeam, bestScore, tie := models.TeamNone, -1, false
+		for team, score := range teamScores {
+			if team == models.TeamNone {
+				continue
+			}
+			if score > bestScore {
+				winningTeam, bestScore, tie = team, score, false
+			} else if score == bestScore {
+				tie = true
+			}
+		}
+		if !tie {
+			result.Match.WinningTeam = int(winningTeam)
+			for i := range result.Players {
+				result.Players[i].Won = result.Players[i].Team == int(winningTeam)
+			}
+		}
+	} else if best := topScorer(result.Players, false); best >= 0 {
+		result.Players[best].Won = true
+	}
+
+	// MVP为全场得分最高者
+	if mvp := topScorer(result.Players, true); mvp >= 0 {
+		result.Players[mvp].MVP = true
+	}
+
+	// 计算金币奖励和角色经验，对局结束时进行中的限时活动提供加成
+	boost := liveevent.BoostFor(r.Mode, r.EndedAt)
+	for i := range result.Players {
+		result.Players[i].CoinsGained = liveevent.ApplyBonus(matchCoinReward(&result.Players[i]), boost.CoinBonus)
+		result.Players[i].ExpGained = liveevent.ApplyBonus(progression.MatchExp(&result.Players[i]), boost.ExpBonus)
+	}
+
+	// 附带击杀/死亡位置事件和回放的精彩片段标记
+	r.eventMutex.Lock()
+	result.PositionEvents = append([]models.PositionEvent(nil), r.positionEvents...)
+	r.eventMutex.Unlock()
+	result.Highlights = matchHighlights(result.PositionEvents, r.matchPointHighlights)
+
+	return result
+}
+
+// matchCoinReward 计算玩家本局获得的金币
+func matchCoinReward(p *models.PlayerMatchRecord) int {
+	reward := matchRewardBase + p.Kills*matchRewardPerKill
+	if p.Won {
+		reward += matchRewardWin
+	}
+	if p.MVP {
+		reward += matchRewardMVP
+	}
+	return reward
+}
+
+// topScorer 返回得分最高玩家的下标，并列时若allowTie为false则返回-1
+func topScorer(players []models.PlayerMatchRecord, allowTie bool) int {
+	best := -1
+	tie := false
+	for i, p := range players {
+		if best < 0 {
+			best = i
+			continue
+		}
+		b := players[best]
+		switch {
+		case p.Score > b.Score,
+			p.Score == b.Score && p.Kills > b.Kills,
+			p.Score == b.Score && p.Kills == b.Kills && p.Deaths < b.Deaths:
+			best, tie = i, false
+		case p.Score == b.Score && p.Kills == b.Kills && p.Deaths == b.Deaths:
+			tie = true
+		}
+	}
+	if tie && !allowTie {
+		return -1
+	}
+	return best
+}
+
+// saveMatchResult 持久化对局结果，按成长曲线发放角色经验。
+// 对局记录、战绩、角色经验和金币奖励在同一事务中写入，任一步失败都不会留下部分结算；
+// 已保存过的玩家记录会被跳过，重试保存不会重复累计。
+func saveMatchResult(result *models.MatchResult, curve *progression.Curve) error {
+	if db.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	match := result.Match
+	var saved []models.PlayerMatchRecord
+	levelUps := make(map[int64]*models.CharacterLevelUp)
+
+	err := db.WithTx(func(tx *sql.Tx) error {
+		// 写入对局记录
+		_, err := tx.Exec(`
+			INSERT INTO match_records (id, game_mode, map_id, start_time, end_time, status,
+			                           max_players, current_players, winning_team, duration)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (id) DO UPDATE SET
+				end_time = EXCLUDED.end_time, status = EXCLUDED.status,
+				current_players = EXCLUDED.current_players,
+				winning_team = EXCLUDED.winning_team, duration = EXCLUDED.duration
+		`, match.ID, string(match.GameMode), match.MapID, match.StartTime, match.EndTime,
+			string(models.RoomEnded), len(result.Players), len(result.Players), match.WinningTeam, match.Duration)
+		if err != nil {
+			return fmt.Errorf("写入对局记录失败: %w", err)
+		}
+
+		for _, p := range result.Players {
+			inserted, levelUp, err := savePlayerResult(tx, p, curve)
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				continue
+			}
+			saved = append(saved, p)
+			if levelUp != nil {
+				levelUps[p.PlayerID] = levelUp
+			}
+		}
+
+		// 写入位置事件和精彩片段标记，对局已完整保存过时不再重复写入
+		if len(saved) == 0 {
+			return nil
+		}
+		if err := savePositionEvents(tx, match.ID, result.PositionEvents); err != nil {
+			return err
+		}
+		return saveHighlights(tx, match.ID, result.Highlights)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range saved {
+		if levelUp := levelUps[p.PlayerID]; levelUp != nil {
+			log.Printf("玩家 %d 角色 %d 升级: %d -> %d", p.PlayerID, p.CharacterID, levelUp.OldLevel, levelUp.NewLevel)
+			events.Publish(events.PlayerLeveled, models.PlayerLeveledEvent{PlayerID: p.PlayerID, CharacterLevelUp: *levelUp})
+		}
+	}
+
+	// 通行证经验、任务进度和排行榜由事件消费者异步处理
+	if len(saved) > 0 {
+		events.Publish(events.MatchEnded, models.MatchEndedEvent{Match: match, Players: saved})
+	}
+
+	return nil
+}
+
+// savePlayerResult 在事务中写入单个玩家的对局记录并结算战绩、角色经验和金币，
+// 该玩家记录已存在时返回 false 且不做任何结算
+func savePlayerResult(tx *sql.Tx, p models.PlayerMatchRecord, curve *progression.Curve) (bool, *models.CharacterLevelUp, error) {
+	// 写入玩家对局记录
+	res, err := tx.Exec(`
+		INSERT INTO player_match_records (match_id, player_id, character_id, team, score,
+		                                  kills, deaths, assists, exp_gained, coins_gained,
+		                                  mvp, won, play_time, join_time, leave_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (match_id, player_id) DO NOTHING
+	`, p.MatchID, p.PlayerID, p.CharacterID, p.Team, p.Score,
+		p.Kills, p.Deaths, p.Assists, p.ExpGained, p.CoinsGained,
+		p.MVP, p.Won, p.PlayTime, p.JoinTime, p.LeaveTime)
+	if err != nil {
+		return false, nil, fmt.Errorf("写入玩家 %d 对局记录失败: %w", p.PlayerID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, nil, fmt.Errorf("写入玩家 %d 对局记录失败: %w", p.PlayerID, err)
+	}
+	if n == 0 {
+		// 重试保存时该玩家已结算过
+		return false, nil, nil
+	}
+
+	// 更新玩家总战绩
+	_, err = tx.Exec(`
+		UPDATE players SET
+			total_kills = total_kills + $2,
+			total_deaths = total_deaths + $3,
+			total_assists = total_assists + $4,
+			total_matches = total_matches + 1,
+			total_wins = total_wins + $5,
+			updated_at = NOW()
+		WHERE id = $1
+	`, p.PlayerID, p.Kills, p.Deaths, p.Assists, boolToInt(p.Won))
+	if err != nil {
+		return false, nil, fmt.Errorf("更新玩家 %d 战绩失败: %w", p.PlayerID, err)
+	}
+
+	// 更新玩家所用角色的统计
+	if err := updateCharacterStats(tx, p); err != nil {
+		return false, nil, err
+	}
+
+	// 增加角色经验
+	levelUp, err := curve.AddExpTx(tx, p.PlayerID, p.CharacterID, p.ExpGained)
+	if err != nil {
+		return false, nil, fmt.Errorf("增加玩家 %d 角色 %d 经验失败: %w", p.PlayerID, p.CharacterID, err)
+	}
+
+	// 发放对局金币奖励（幂等键保证重复保存时不会重复入账）
+	if p.CoinsGained > 0 {
+		_, err := matchWallet.ApplyTx(tx, models.TransactionRequest{
+			PlayerID:       p.PlayerID,
+			Currency:       models.CurrencyCoins,
+			Amount:         int64(p.CoinsGained),
+			Reason:         models.ReasonMatchReward,
+			ReferenceID:    p.MatchID,
+			IdempotencyKey: fmt.Sprintf("match:%s:%d", p.MatchID, p.PlayerID),
+		})
+		if err != nil {
+			return false, nil, fmt.Errorf("发放玩家 %d 对局奖励失败: %w", p.PlayerID, err)
+		}
+	}
+
+	return true, levelUp, nil
+}
+
+// savePositionEvents 在事务中批量写入对局位置事件
+func savePositionEvents(tx *sql.Tx, matchID string, events []models.PositionEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO match_position_events (match_id, event_type, player_id, other_player_id,
+		                                   character_id, pos_x, pos_y, elapsed)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`)
+	if err != nil {
+		return fmt.Errorf("准备位置事件写入失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, e := range events {
+		if _, err := stmt.Exec(matchID, string(e.Type), e.PlayerID, e.OtherPlayerID,
+			e.CharacterID, e.X, e.Y, e.Elapsed); err != nil {
+			return fmt.Errorf("写入位置事件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveHighlights 在事务中批量写入回放的精彩片段标记
+func saveHighlights(tx *sql.Tx, matchID string, highlights []models.Highlight) error {
+	if len(highlights) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO match_highlights (match_id, highlight_type, player_id, team, kills, elapsed, duration)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`)
+	if err != nil {
+		return fmt.Errorf("准备精彩片段标记写入失败: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, h := range highlights {
+		if _, err := stmt.Exec(matchID, string(h.Type), h.PlayerID, int(h.Team), h.Kills, h.Elapsed, h.Duration); err != nil {
+			return fmt.Errorf("写入精彩片段标记失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// updateCharacterStats 累加玩家本局所用角色的使用、胜利、击杀和死亡次数
+func updateCharacterStats(tx *sql.Tx, p models.PlayerMatchRecord) error {
+	_, err := tx.Exec(`
+		UPDATE player_characters SET
+			usage_count = usage_count + 1,
+			win_count = win_count + $3,
+			kill_count = kill_count + $4,
+			death_count = death_count + $5,
+			last_played_at = $6
+		WHERE player_id = $1 AND character_id = $2
+	`, p.PlayerID, p.CharacterID, boolToInt(p.Won), p.Kills, p.Deaths, p.LeaveTime)
+	if err != nil {
+		return fmt.Errorf("更新玩家 %d 角色 %d 统计失败: %w", p.PlayerID, p.CharacterID, err)
+	}
+	return nil
+}
+
+// persistMatchResult 生成并异步保存对局结果
+func (r *Room) persistMatchResult() {
+	result := r.buildMatchResult()
+	if len(result.Players) == 0 {
+		return
+	}
+
+	go func() {
+		start := time.Now()
+		if err := saveMatchResult(result, r.LevelCurve); err != nil {
+			log.Printf("保存房间 %s 对局结果失败: %v", r.ID, err)
+			report.Error(err, r.reportContext())
+			return
+		}
+		log.Printf("房间 %s 对局结果已保存 (%d 名玩家, 耗时 %v)", r.ID, len(result.Players), time.Since(start))
+	}()
+}
+
+// boolToInt 布尔值转整数
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
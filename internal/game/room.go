@@ -5,12 +5,48 @@ package game
 import (
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/avatar"
+	"github.com/jacl-coder/PixelStorm-Server/internal/balance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+	"github.com/jacl-coder/PixelStorm-Server/internal/title"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// defaultMaxHealth 角色配置不可用时的玩家生命值
+const defaultMaxHealth = 100
+
+var (
+	// ErrRoomFull 房间已满
+	ErrRoomFull = errcode.New(errcode.RoomFull, "房间已满")
+	// ErrRoomStarted 游戏已经开始，无法加入
+	ErrRoomStarted = errcode.New(errcode.RoomStarted, "游戏已经开始，无法加入")
+
+	// errRoomNotFound 加入的房间不存在或已关闭
+	errRoomNotFound = errcode.New(errcode.NotFound, "房间不存在")
+	// errAlreadyInRoom 玩家已在其他房间中
+	errAlreadyInRoom = errcode.New(errcode.Conflict, "已在房间中")
+	// errNotInRoom 玩家不在房间中
+	errNotInRoom = errcode.New(errcode.NotAllowed, "不在房间中")
+	// errCharacterLocked 对局开始后不能更换角色
+	errCharacterLocked = errcode.New(errcode.RoomStarted, "对局已经开始，无法更换角色")
+	// errCharacterNotOwned 玩家不能使用所选角色
+	errCharacterNotOwned = errcode.New(errcode.CharacterNotOwned, "未拥有该角色")
 )
 
 // Room 游戏房间
@@ -26,11 +62,42 @@ type Room struct {
 	MapID      int
 
 	// 房间设置
-	TimeLimit    int  // 时间限制(秒)
-	ScoreLimit   int  // 分数限制
-	FriendlyFire bool // 友军伤害
-	PrivateRoom  bool // 私人房间
-	Password     string
+	TimeLimit     int  // 时间限制(秒)
+	ScoreLimit    int  // 分数限制
+	RespawnTime   int  // 重生时间(秒)
+	WarmupTime    int  // 热身时间(秒)，为0时不热身
+	CountdownTime int  // 正式对局开始前的倒计时(秒)
+	FriendlyFire  bool // 友军伤害
+	BodyBlocking  bool // 玩家碰撞，开启后玩家之间不能互相穿过
+	PrivateRoom   bool // 私人房间
+	Password      string
+
+	// 时间到时比分持平的加时方式及加时时长(秒)
+	Overtime     models.OvertimeMode
+	OvertimeTime int
+
+	// 最长重生时间(秒)和重生波次间隔(秒)，见 respawnDelay
+	RespawnMaxTime int
+	RespawnWave    int
+
+	// 击杀、助攻、占点计分周期和夺旗的得分，每获得1分所需的治疗量(为0时治疗不得分)
+	KillScore        int
+	AssistScore      int
+	CaptureTickScore int
+	FlagCaptureScore int
+	HealingPerPoint  int
+
+	// 分队时尽量避免互相屏蔽的玩家同队
+	AvoidBlockedTeammates bool
+
+	// 技能数值覆盖，创建房间时从平衡配置加载
+	SkillBalance map[int]models.SkillBalance
+
+	// 实验性：投射物相互碰撞抵消，创建房间时按功能开关确定
+	ProjectileCollisions bool
+
+	// 角色等级成长曲线
+	LevelCurve *progression.Curve
 
 	// 玩家管理
 	players     map[string]*PlayerState
@@ -42,19 +109,59 @@ type Room struct {
 	frameID       int64
 	lastFrameTime time.Time
 	scores        map[int64]int // 玩家ID -> 分数
+	healing       map[int64]int // 玩家ID -> 累计治疗量，用于治疗得分
+
+	// 对局阶段及当前阶段的结束时间，只在游戏循环中修改
+	phase       models.MatchPhase
+	phaseEndsAt time.Time
+
+	// 地图危险区域，对局开始时加载，只在游戏循环中修改
+	hazards []activeHazard
+
+	// 玩家输入的技能操作，由游戏循环在下一帧执行
+	pendingSkills []skillInput
+	inputMutex    sync.Mutex
+
+	// 对局状态的发送时间和已通知赛点的队伍或玩家，只在游戏循环中修改
+	lastStatus  time.Time
+	matchPoints map[int64]bool
+
+	// 到达赛点的回放标记，对局结束时随结果持久化，只在游戏循环中修改
+	matchPointHighlights []models.Highlight
+
+	// 击杀/死亡位置事件（对局结束时随结果持久化，用于热力图）
+	positionEvents []models.PositionEvent
+	eventMutex     sync.Mutex
+
+	// 最近的聊天和击杀事件，发给中途加入的玩家
+	history roomHistory
 
 	// 控制通道
 	shutdown     chan struct{}
 	isRunning    bool
 	lastActivity time.Time
+
+	// 已请求提前结束（如计划维护），由游戏循环在下一帧处理
+	endRequested atomic.Bool
 }
 
 // PlayerState 玩家游戏状态
 type PlayerState struct {
 	Connection *PlayerConnection
 	Entity     *models.PlayerEntity
+	AvatarURL  string
+	Title      string
 	Ready      bool
 	LastInput  time.Time
+	JoinedAt   time.Time
+}
+
+// rtt 玩家连接的平滑往返延迟，没有连接时返回0
+func (ps *PlayerState) rtt() time.Duration {
+	if ps.Connection == nil {
+		return 0
+	}
+	return ps.Connection.RTT()
 }
 
 // NewRoom 创建新房间
@@ -62,7 +169,7 @@ func NewRoom(name string, mode models.GameMode, maxPlayers int, mapID int) *Room
 	roomID := uuid.New().String()
 	now := time.Now()
 
-	return &Room{
+	room := &Room{
 		ID:           roomID,
 		Name:         name,
 		Mode:         mode,
@@ -70,15 +177,65 @@ func NewRoom(name string, mode models.GameMode, maxPlayers int, mapID int) *Room
 		MaxPlayers:   maxPlayers,
 		CreatedAt:    now,
 		MapID:        mapID,
-		TimeLimit:    300, // 默认5分钟
-		ScoreLimit:   20,  // 默认20分
 		FriendlyFire: false,
+		LevelCurve:   progression.NewCurve(config.CharacterLevelConfig{}),
 		players:      make(map[string]*PlayerState),
 		entities:     make(map[string]models.Entity),
 		scores:       make(map[int64]int),
 		shutdown:     make(chan struct{}),
 		lastActivity: now,
 	}
+
+	// 时间限制、分数限制和重生时间按模式取自配置文件和管理员保存的平衡配置
+	room.ApplyBalance(balance.For(mode))
+	return room
+}
+
+// ApplyBalance 应用平衡配置，需在房间启动前调用，配置中为0的数值保持不变
+func (r *Room) ApplyBalance(cfg models.BalanceConfig) {
+	if cfg.TimeLimit > 0 {
+		r.TimeLimit = cfg.TimeLimit
+	}
+	if cfg.ScoreLimit > 0 {
+		r.ScoreLimit = cfg.ScoreLimit
+	}
+	if cfg.RespawnTime > 0 {
+		r.RespawnTime = cfg.RespawnTime
+	}
+	r.RespawnMaxTime = cfg.RespawnMaxTime
+	r.RespawnWave = cfg.RespawnWave
+	r.WarmupTime = cfg.WarmupTime
+	r.CountdownTime = cfg.CountdownTime
+	r.Overtime = cfg.Overtime
+	r.OvertimeTime = cfg.OvertimeTime
+	setScore(&r.KillScore, cfg.KillScore)
+	setScore(&r.AssistScore, cfg.AssistScore)
+	setScore(&r.CaptureTickScore, cfg.CaptureTickScore)
+	setScore(&r.FlagCaptureScore, cfg.FlagCaptureScore)
+	setScore(&r.HealingPerPoint, cfg.HealingPerPoint)
+	if cfg.BodyBlocking != nil {
+		r.BodyBlocking = *cfg.BodyBlocking
+	}
+	r.SkillBalance = cfg.Skills
+}
+
+// setScore 平衡配置中设置了得分规则时覆盖房间的值
+func setScore(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// skillStats 技能的伤害和冷却时间，平衡配置中没有覆盖时使用默认值
+func (r *Room) skillStats(skillID int, damage int, cooldown float64) (int, float64) {
+	override := r.SkillBalance[skillID]
+	if override.Damage > 0 {
+		damage = override.Damage
+	}
+	if override.Cooldown > 0 {
+		cooldown = override.Cooldown
+	}
+	return damage, cooldown
 }
 
 // Start 启动房间
@@ -113,15 +270,33 @@ func (r *Room) Stop() {
 
 // AddPlayer 添加玩家到房间
 func (r *Room) AddPlayer(conn *PlayerConnection, characterID int) error {
+	loadout := loadCharacterLoadout(conn.PlayerID, characterID)
+	avatarURL, err := avatar.URL(conn.PlayerID)
+	if err != nil {
+		log.Printf("加载玩家 %d 头像失败: %v", conn.PlayerID, err)
+	}
+	titleName, err := title.EquippedName(conn.PlayerID)
+	if err != nil {
+		log.Printf("加载玩家 %d 称号失败: %v", conn.PlayerID, err)
+	}
+
+	// 加载屏蔽关系用于分队（失败时按人数分队）
+	var blocked map[int64]bool
+	if r.AvoidBlockedTeammates && r.Mode.IsTeamMode() {
+		if blocked, err = social.Relations(conn.PlayerID); err != nil {
+			log.Printf("加载玩家 %d 屏蔽关系失败: %v", conn.PlayerID, err)
+		}
+	}
+
 	r.playerMutex.Lock()
 	defer r.playerMutex.Unlock()
 
 	if len(r.players) >= r.MaxPlayers {
-		return fmt.Errorf("房间已满")
+		return ErrRoomFull
 	}
 
 	if r.Status != models.RoomWaiting {
-		return fmt.Errorf("游戏已经开始，无法加入")
+		return ErrRoomStarted
 	}
 
 	// 创建玩家实体
@@ -129,26 +304,31 @@ func (r *Room) AddPlayer(conn *PlayerConnection, characterID int) error {
 		BaseEntity: models.BaseEntity{
 			ID:        uuid.New().String(),
 			Type:      models.EntityPlayer,
-			Position:  getRandomSpawnPosition(),
+			Position:  r.spawnPosition(),
 			Rotation:  0,
 			Velocity:  models.Vector2D{X: 0, Y: 0},
 			CreatedAt: time.Now(),
 		},
 		PlayerID:       conn.PlayerID,
 		CharacterID:    characterID,
-		Team:           assignTeam(r),
-		Health:         100,
-		MaxHealth:      100,
+		SkinID:         loadout.skinID,
+		Team:           assignTeamLocked(r, blocked),
+		Health:         loadout.maxHealth,
+		MaxHealth:      loadout.maxHealth,
 		IsAlive:        true,
 		SkillCooldowns: make(map[int]float64),
+		LockedSkills:   loadout.lockedSkills,
 	}
 
 	// 添加到房间
 	playerState := &PlayerState{
 		Connection: conn,
 		Entity:     playerEntity,
+		AvatarURL:  avatarURL,
+		Title:      titleName,
 		Ready:      false,
 		LastInput:  time.Now(),
+		JoinedAt:   time.Now(),
 	}
 
 	r.players[conn.ID] = playerState
@@ -161,9 +341,79 @@ func (r *Room) AddPlayer(conn *PlayerConnection, characterID int) error {
 	r.lastActivity = time.Now()
 	log.Printf("玩家 %d 加入房间 %s", conn.PlayerID, r.ID)
 
+	r.broadcastRoomStateLocked()
+
+	return nil
+}
+
+// ChangeCharacter 对局开始前更换玩家的角色，按新角色重新加载皮肤、生命值和技能解锁状态并广播房间状态。
+// 调用方负责检查玩家能否使用该角色
+func (r *Room) ChangeCharacter(connID string, characterID int) error {
+	r.playerMutex.RLock()
+	player, exists := r.players[connID]
+	r.playerMutex.RUnlock()
+	if !exists || player.Connection == nil {
+		return errNotInRoom
+	}
+
+	loadout := loadCharacterLoadout(player.Connection.PlayerID, characterID)
+
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+
+	// 加载期间玩家可能已离开或对局已开始
+	if r.players[connID] != player {
+		return errNotInRoom
+	}
+	if r.Status != models.RoomWaiting {
+		return errCharacterLocked
+	}
+
+	r.entityMutex.Lock()
+	entity := player.Entity
+	entity.CharacterID = characterID
+	entity.SkinID = loadout.skinID
+	entity.Health = loadout.maxHealth
+	entity.MaxHealth = loadout.maxHealth
+	entity.SkillCooldowns = make(map[int]float64)
+	entity.LockedSkills = loadout.lockedSkills
+	r.entityMutex.Unlock()
+
+	r.lastActivity = time.Now()
+	log.Printf("玩家 %d 在房间 %s 更换角色为 %d", player.Connection.PlayerID, r.ID, characterID)
+
+	r.broadcastRoomStateLocked()
 	return nil
 }
 
+// characterLoadout 玩家使用某个角色时的皮肤、生命值和技能解锁状态
+type characterLoadout struct {
+	skinID       int
+	maxHealth    int
+	lockedSkills map[int]bool
+}
+
+// loadCharacterLoadout 加载玩家使用该角色时的配置，加载失败只记录日志，不影响加入房间或更换角色
+func loadCharacterLoadout(playerID int64, characterID int) characterLoadout {
+	loadout := characterLoadout{maxHealth: defaultMaxHealth}
+
+	var err error
+	if loadout.skinID, err = inventory.EquippedSkinID(playerID, characterID); err != nil {
+		log.Printf("加载玩家 %d 角色 %d 皮肤失败: %v", playerID, characterID, err)
+	}
+	if loadout.lockedSkills, err = progression.LockedSkills(playerID, characterID); err != nil {
+		log.Printf("加载玩家 %d 角色 %d 技能解锁状态失败: %v", playerID, characterID, err)
+	}
+
+	// 角色生命值取自静态数据缓存，缓存不可用时使用默认值
+	if catalog := gamedata.Current(); catalog != nil {
+		if char, ok := catalog.Character(characterID); ok && char.MaxHP > 0 {
+			loadout.maxHealth = char.MaxHP
+		}
+	}
+	return loadout
+}
+
 // RemovePlayer 从房间移除玩家
 func (r *Room) RemovePlayer(connID string) {
 	r.playerMutex.Lock()
@@ -186,12 +436,28 @@ func (r *Room) RemovePlayer(connID string) {
 
 	log.Printf("玩家已离开房间 %s", r.ID)
 
+	r.broadcastRoomStateLocked()
+
 	// 如果房间为空，可以标记为可清理
 	if len(r.players) == 0 && r.Status != models.RoomEnded {
 		log.Printf("房间 %s 已空，等待清理", r.ID)
 	}
 }
 
+// SetReady 设置玩家的准备状态并广播房间状态，对局开始后不再改变
+func (r *Room) SetReady(connID string, ready bool) {
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+
+	player, exists := r.players[connID]
+	if !exists || r.Status != models.RoomWaiting || player.Ready == ready {
+		return
+	}
+	player.Ready = ready
+	r.lastActivity = time.Now()
+	r.broadcastRoomStateLocked()
+}
+
 // GetPlayerCount 获取玩家数量
 func (r *Room) GetPlayerCount() int {
 	r.playerMutex.RLock()
@@ -199,6 +465,72 @@ func (r *Room) GetPlayerCount() int {
 	return len(r.players)
 }
 
+// PlayerRTT 获取房间内玩家的平滑往返延迟，供延迟补偿使用。玩家不在房间或尚未测得时返回0
+func (r *Room) PlayerRTT(playerID int64) time.Duration {
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	for _, ps := range r.players {
+		if ps.Connection != nil && ps.Connection.PlayerID == playerID {
+			return ps.rtt()
+		}
+	}
+	return 0
+}
+
+// RoomLatency 房间内玩家往返延迟的汇总
+type RoomLatency struct {
+	Measured int           // 已测得延迟的玩家数
+	Avg      time.Duration // 平均往返延迟
+	Max      time.Duration // 最大往返延迟
+}
+
+// Latency 汇总房间内已测得延迟的玩家的往返延迟，用于选择区域和管理监控
+func (r *Room) Latency() RoomLatency {
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	var latency RoomLatency
+	var total time.Duration
+	for _, ps := range r.players {
+		rtt := ps.rtt()
+		if rtt == 0 {
+			continue
+		}
+		latency.Measured++
+		total += rtt
+		latency.Max = max(latency.Max, rtt)
+	}
+	if latency.Measured > 0 {
+		latency.Avg = total / time.Duration(latency.Measured)
+	}
+	return latency
+}
+
+// Metrics 房间运行指标
+func (r *Room) Metrics() models.RoomMetrics {
+	latency := r.Latency()
+	return models.RoomMetrics{
+		ID:              r.ID,
+		Name:            r.Name,
+		Mode:            r.Mode,
+		Status:          r.Status,
+		Players:         r.GetPlayerCount(),
+		MaxPlayers:      r.MaxPlayers,
+		CreatedAt:       r.CreatedAt,
+		MeasuredPlayers: latency.Measured,
+		AvgRTT:          latency.Avg.Milliseconds(),
+		MaxRTT:          latency.Max.Milliseconds(),
+	}
+}
+
+// State 房间大厅状态，包含玩家及其得分
+func (r *Room) State() *models.Room {
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+	return r.roomStateLocked()
+}
+
 // IsEmpty 检查房间是否为空
 func (r *Room) IsEmpty() bool {
 	return r.GetPlayerCount() == 0
@@ -219,14 +551,23 @@ func (r *Room) ShouldCleanup() bool {
 	return false
 }
 
+// roomPanicsTotal 游戏循环 panic 后被结束的房间数
+var roomPanicsTotal = metrics.Default.NewCounterVec("pixelstorm_room_panics_total",
+	"游戏循环 panic 后被结束的房间数", "mode")
+
 // gameLoop 游戏主循环
 func (r *Room) gameLoop() {
+	defer r.recoverLoop()
+
 	ticker := time.NewTicker(16 * time.Millisecond) // 约60FPS
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
+			if r.endRequested.Swap(false) {
+				r.forceEnd()
+			}
 			if r.Status == models.RoomPlaying {
 				r.update()
 			} else if r.Status == models.RoomWaiting {
@@ -238,6 +579,60 @@ func (r *Room) gameLoop() {
 	}
 }
 
+// recoverLoop 游戏循环 panic 时记录堆栈并结束房间，只影响本房间，服务器和其他房间继续运行
+func (r *Room) recoverLoop() {
+	p := recover()
+	if p == nil {
+		return
+	}
+	roomPanicsTotal.Inc(string(r.Mode))
+	log.Printf("房间 %s 游戏循环 panic: %v\n%s", r.ID, p, debug.Stack())
+	report.Panic(p, r.reportContext())
+	r.abort()
+}
+
+// reportContext 上报错误时附带的房间信息。panic 时玩家锁可能仍被持有，取不到锁时不附带玩家列表
+func (r *Room) reportContext() report.Context {
+	ctx := report.Context{
+		RoomID: r.ID,
+		Tags:   map[string]string{"game_mode": string(r.Mode)},
+		Extra:  map[string]interface{}{"status": r.Status, "frame_id": r.frameID, "map_id": r.MapID},
+	}
+	if r.playerMutex.TryRLock() {
+		players := make([]int64, 0, len(r.players))
+		for _, ps := range r.players {
+			if ps.Connection != nil {
+				players = append(players, ps.Connection.PlayerID)
+			}
+		}
+		r.playerMutex.RUnlock()
+		ctx.Extra["players"] = players
+	}
+	return ctx
+}
+
+// abort 异常结束房间：已开始的对局保存当前战绩并通知玩家对局结束。
+// 房间状态可能已不一致，保存或通知再次 panic 时放弃并记录日志
+func (r *Room) abort() {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("房间 %s 异常结束时保存对局失败: %v\n%s", r.ID, p, debug.Stack())
+			report.Panic(p, r.reportContext())
+		}
+	}()
+
+	playing := r.Status == models.RoomPlaying && r.phase.Scoring()
+	r.isRunning = false
+	r.Status = models.RoomEnded
+	r.EndedAt = time.Now()
+
+	if playing {
+		r.persistMatchResult()
+		r.broadcastGameEnd()
+	}
+	log.Printf("房间 %s 已异常结束", r.ID)
+}
+
 // update 更新游戏状态
 func (r *Room) update() {
 	now := time.Now()
@@ -245,17 +640,39 @@ func (r *Room) update() {
 	r.lastFrameTime = now
 	r.frameID++
 
-	// 更新实体
-	r.updateEntities(deltaTime)
+	// 热身和倒计时到期后进入下一阶段
+	r.advancePhase(now)
 
-	// 检测碰撞
-	r.detectCollisions()
+	// 执行玩家的技能操作
+	r.applySkillInputs()
 
-	// 检查游戏结束条件
-	r.checkGameEnd()
+	// 倒计时期间玩家不能行动
+	if r.phase != models.PhaseCountdown {
+		// 更新实体
+		r.updateEntities(deltaTime)
+
+		// 分开相互重叠的玩家
+		if r.BodyBlocking {
+			r.separatePlayers()
+		}
+
+		// 检测碰撞
+		r.detectCollisions()
+
+		// 危险区域伤害
+		r.applyHazards(now)
+	}
+
+	// 检查游戏结束条件，热身和倒计时不计入对局时间
+	if r.phase.Scoring() {
+		r.checkGameEnd()
+	}
 
 	// 发送游戏状态
 	r.broadcastGameState()
+	if r.Status == models.RoomPlaying {
+		r.syncMatchStatus(now)
+	}
 }
 
 // updateEntities 更新所有实体
@@ -288,11 +705,13 @@ func (r *Room) updateEntities(deltaTime float64) {
 				}
 			} else {
 				// 处理重生逻辑
-				e.RespawnTime -= int(deltaTime)
-				if e.RespawnTime <= 0 {
+				remaining := time.Until(e.RespawnAt)
+				e.RespawnTime = int(math.Ceil(remaining.Seconds()))
+				if remaining <= 0 {
 					e.IsAlive = true
+					e.RespawnTime = 0
 					e.Health = e.MaxHealth
-					e.Position = getRandomSpawnPosition()
+					e.Position = r.spawnPosition()
 					e.Velocity = models.Vector2D{X: 0, Y: 0}
 				}
 			}
@@ -337,33 +756,93 @@ func (r *Room) checkGameStart() {
 	}
 }
 
-// startGame 开始游戏
+// startGame 所有玩家准备后开始对局（调用方持有 playerMutex），依次经过热身和倒计时，未配置的阶段跳过
 func (r *Room) startGame() {
 	r.Status = models.RoomPlaying
 	r.StartedAt = time.Now()
 	r.lastFrameTime = time.Now()
 	r.frameID = 0
+	r.hazards = loadHazards(r.MapID)
+
+	switch {
+	case r.WarmupTime > 0:
+		log.Printf("房间 %s 开始热身 (%d 秒)", r.ID, r.WarmupTime)
+		r.enterPhaseLocked(models.PhaseWarmup, time.Duration(r.WarmupTime)*time.Second)
+	case r.CountdownTime > 0:
+		r.enterPhaseLocked(models.PhaseCountdown, time.Duration(r.CountdownTime)*time.Second)
+	default:
+		r.goLiveLocked()
+	}
+}
+
+// goLiveLocked 倒计时结束，开始正式对局（调用方持有 playerMutex）
+func (r *Room) goLiveLocked() {
+	r.StartedAt = time.Now()
+	r.enterPhaseLocked(models.PhaseLive, 0)
 
 	log.Printf("房间 %s 游戏开始", r.ID)
 
+	// 发布对局开始事件
+	started := models.MatchStartedEvent{
+		MatchID:   r.ID,
+		GameMode:  r.Mode,
+		MapID:     r.MapID,
+		PlayerIDs: make([]int64, 0, len(r.players)),
+		StartedAt: r.StartedAt,
+	}
+	for _, ps := range r.players {
+		if ps.Entity != nil {
+			started.PlayerIDs = append(started.PlayerIDs, ps.Entity.PlayerID)
+		}
+	}
+	go events.Publish(events.MatchStarted, started)
+
 	// 通知所有玩家游戏开始
 	r.broadcastGameStart()
 }
 
-// checkGameEnd 检查游戏是否结束
+// checkGameEnd 检查游戏是否结束，时间到时比分持平则按配置进入加时
 func (r *Room) checkGameEnd() {
-	// 检查时间限制
-	if time.Since(r.StartedAt).Seconds() >= float64(r.TimeLimit) {
+	// 检查分数限制，团队模式按队伍总分
+	r.playerMutex.RLock()
+	reached := false
+	for _, score := range r.scoreTotalsLocked() {
+		if score >= r.ScoreLimit {
+			reached = true
+			break
+		}
+	}
+	r.playerMutex.RUnlock()
+	if reached {
 		r.endGame()
 		return
 	}
 
-	// 检查分数限制
-	for _, score := range r.scores {
-		if score >= r.ScoreLimit {
-			r.endGame()
-			return
-		}
+	if r.phase == models.PhaseOvertime {
+		r.checkOvertimeEnd()
+		return
+	}
+
+	// 检查时间限制
+	if time.Since(r.StartedAt).Seconds() >= float64(r.TimeLimit) && !r.startOvertime() {
+		r.endGame()
+	}
+}
+
+// ForceEnd 请求提前结束房间，进行中的对局按当前战绩保存结果
+func (r *Room) ForceEnd() {
+	r.endRequested.Store(true)
+}
+
+// forceEnd 提前结束房间：进行中的对局正常结算，等待中或热身中的房间直接关闭
+func (r *Room) forceEnd() {
+	switch {
+	case r.Status == models.RoomPlaying && r.phase.Scoring():
+		log.Printf("房间 %s 对局被提前结束", r.ID)
+		r.endGame()
+	case r.Status == models.RoomWaiting || r.Status == models.RoomPlaying:
+		r.Status = models.RoomEnded
+		r.EndedAt = time.Now()
 	}
 }
 
@@ -374,10 +853,101 @@ func (r *Room) endGame() {
 
 	log.Printf("房间 %s 游戏结束", r.ID)
 
+	// 保存对局结果
+	r.persistMatchResult()
+
 	// 通知所有玩家游戏结束
 	r.broadcastGameEnd()
 }
 
+// roomStateLocked 生成房间大厅状态（调用方需持有 playerMutex）
+func (r *Room) roomStateLocked() *models.Room {
+	state := &models.Room{
+		ID:           r.ID,
+		Name:         r.Name,
+		Mode:         r.Mode,
+		Status:       r.Status,
+		MaxPlayers:   r.MaxPlayers,
+		CreatedAt:    r.CreatedAt,
+		Phase:        r.phase,
+		StartedAt:    r.StartedAt,
+		MapID:        r.MapID,
+		TimeLimit:    r.TimeLimit,
+		ScoreLimit:   r.ScoreLimit,
+		FriendlyFire: r.FriendlyFire,
+		BodyBlocking: r.BodyBlocking,
+		PrivateRoom:  r.PrivateRoom,
+		Players:      make([]models.RoomPlayer, 0, len(r.players)),
+	}
+
+	for _, ps := range r.players {
+		if ps.Entity == nil {
+			continue
+		}
+		state.Players = append(state.Players, models.RoomPlayer{
+			PlayerID:    ps.Entity.PlayerID,
+			AvatarURL:   ps.AvatarURL,
+			Title:       ps.Title,
+			CharacterID: ps.Entity.CharacterID,
+			SkinID:      ps.Entity.SkinID,
+			Team:        ps.Entity.Team,
+			Ready:       ps.Ready,
+			Ping:        ps.rtt().Milliseconds(),
+			Score:       r.scores[ps.Entity.PlayerID],
+			Kills:       ps.Entity.Kills,
+			Deaths:      ps.Entity.Deaths,
+			Assists:     ps.Entity.Assists,
+		})
+	}
+
+	return state
+}
+
+// broadcastRoomStateLocked 向房间内玩家广播大厅状态（调用方需持有 playerMutex）
+func (r *Room) broadcastRoomStateLocked() {
+	msg, err := newMessage("room_state", r.roomStateLocked())
+	if err != nil {
+		log.Printf("序列化房间状态失败: %v", err)
+		return
+	}
+
+	for _, ps := range r.players {
+		if ps.Connection == nil {
+			continue
+		}
+		// 通道已满时跳过
+		ps.Connection.trySend(msg)
+	}
+}
+
+// BroadcastChat 向房间内玩家广播聊天消息，屏蔽了发送者的玩家不会收到
+func (r *Room) BroadcastChat(senderID int64, text string) {
+	blockedBy, err := social.BlockedBy(senderID)
+	if err != nil {
+		log.Printf("加载玩家 %d 屏蔽关系失败: %v", senderID, err)
+		blockedBy = map[int64]bool{}
+	}
+
+	chat := models.ChatMessage{PlayerID: senderID, Text: text, SentAt: time.Now()}
+	msg, err := newMessage("chat", chat)
+	if err != nil {
+		log.Printf("序列化聊天消息失败: %v", err)
+		return
+	}
+	r.history.add(models.RoomEvent{Type: models.RoomEventChat, Chat: &chat, At: chat.SentAt})
+
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	for _, ps := range r.players {
+		if ps.Connection == nil || blockedBy[ps.Connection.PlayerID] {
+			continue
+		}
+		// 通道已满时跳过
+		ps.Connection.trySend(msg)
+	}
+}
+
 // broadcastGameState 广播游戏状态
 func (r *Room) broadcastGameState() {
 	// TODO: 实现游戏状态广播
@@ -388,46 +958,93 @@ func (r *Room) broadcastGameStart() {
 	// TODO: 实现游戏开始广播
 }
 
-// broadcastGameEnd 广播游戏结束
+// broadcastGameEnd 以关键消息广播对局结果，玩家短暂断线重连后仍会收到
 func (r *Room) broadcastGameEnd() {
-	// TODO: 实现游戏结束广播
+	result := r.buildMatchResult()
+	msg, err := newMessage("game_end", models.MatchResult{Match: result.Match, Players: result.Players})
+	if err != nil {
+		log.Printf("序列化对局结果失败: %v", err)
+		return
+	}
+	msg.Kind = KindReliable
+
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	for _, ps := range r.players {
+		if ps.Connection == nil {
+			continue
+		}
+		if !ps.Connection.trySend(msg) {
+			log.Printf("玩家 %d 发送队列已满，对局结果等待重连后重发", ps.Connection.PlayerID)
+		}
+	}
 }
 
 // 辅助函数
 
-// getRandomSpawnPosition 获取随机出生点
-func getRandomSpawnPosition() models.Vector2D {
-	// 临时实现，返回随机位置
-	return models.Vector2D{
-		X: rand.Float64() * 1000,
-		Y: rand.Float64() * 1000,
+// spawnPosition 在地图范围内随机选择不在危险区域附近的出生点，多次随机失败后按网格查找，仍找不到时使用地图中心
+func (r *Room) spawnPosition() models.Vector2D {
+	width, height := r.mapSize()
+	hazards := r.hazards
+	if hazards == nil {
+		// 对局开始前危险区域尚未加载
+		hazards = loadHazards(r.MapID)
 	}
-}
 
-// assignTeam 分配队伍
-func assignTeam(r *Room) models.Team {
-	if r.Mode != models.TeamDeathMatch && r.Mode != models.FlagCapture {
-		return models.TeamNone
+	for i := 0; i < spawnAttempts; i++ {
+		pos := models.Vector2D{
+			X: playerRadius + rand.Float64()*max(width-2*playerRadius, 0),
+			Y: playerRadius + rand.Float64()*max(height-2*playerRadius, 0),
+		}
+		if safeSpawn(pos, hazards) {
+			return pos
+		}
 	}
 
-	// 统计当前队伍人数
-	redCount := 0
-	blueCount := 0
+	step := 2 * playerRadius
+	for y := playerRadius; y <= height-playerRadius; y += step {
+		for x := playerRadius; x <= width-playerRadius; x += step {
+			if pos := (models.Vector2D{X: x, Y: y}); safeSpawn(pos, hazards) {
+				return pos
+			}
+		}
+	}
+	return models.Vector2D{X: width / 2, Y: height / 2}
+}
 
-	r.playerMutex.RLock()
-	defer r.playerMutex.RUnlock()
+// assignTeamLocked 分配队伍（调用方需持有 playerMutex）。
+// blocked 为与新玩家存在屏蔽关系的玩家，在不超过队伍人数上限的前提下优先分到屏蔽关系更少的队伍。
+func assignTeamLocked(r *Room, blocked map[int64]bool) models.Team {
+	if !r.Mode.IsTeamMode() {
+		return models.TeamNone
+	}
 
+	// 统计当前队伍人数与屏蔽关系数
+	counts := map[models.Team]int{}
+	conflicts := map[models.Team]int{}
 	for _, player := range r.players {
-		if player.Entity.Team == models.TeamRed {
-			redCount++
-		} else if player.Entity.Team == models.TeamBlue {
-			blueCount++
+		team := player.Entity.Team
+		counts[team]++
+		if blocked[player.Entity.PlayerID] {
+			conflicts[team]++
 		}
 	}
 
-	// 分配到人数较少的队伍
-	if redCount <= blueCount {
-		return models.TeamRed
+	teamSize := (r.MaxPlayers + 1) / 2
+	best := models.TeamRed
+	for _, team := range []models.Team{models.TeamRed, models.TeamBlue} {
+		if team == best {
+			continue
+		}
+		if counts[team] >= teamSize {
+			continue
+		}
+		if counts[best] >= teamSize ||
+			conflicts[team] < conflicts[best] ||
+			(conflicts[team] == conflicts[best] && counts[team] < counts[best]) {
+			best = team
+		}
 	}
-	return models.TeamBlue
+	return best
 }
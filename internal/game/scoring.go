@@ -0,0 +1,88 @@
+// scoring.go
+
+package game
+
+import (
+	"math"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// assistWindow 玩家阵亡前在此时间内命中过该玩家的其他攻击者获得助攻
+const assistWindow = 10 * time.Second
+
+// ScoreCaptureTick 据点每个计分周期为占领据点的玩家加分，由占点模式的据点逻辑调用
+func (r *Room) ScoreCaptureTick(playerIDs ...int64) {
+	r.addScores(r.CaptureTickScore, playerIDs...)
+}
+
+// ScoreFlagCapture 为成功夺旗的玩家加分，由夺旗模式的旗帜逻辑调用
+func (r *Room) ScoreFlagCapture(playerID int64) {
+	r.addScores(r.FlagCaptureScore, playerID)
+}
+
+// maxEventScore 单次得分事件的最高分值，至少为1
+func (r *Room) maxEventScore() int {
+	return max(r.KillScore, r.AssistScore, r.CaptureTickScore, r.FlagCaptureScore, 1)
+}
+
+// addScores 为玩家加分，热身期间和分值为0时忽略
+func (r *Room) addScores(points int, playerIDs ...int64) {
+	if points == 0 || r.phase == models.PhaseWarmup {
+		return
+	}
+
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+	for _, playerID := range playerIDs {
+		r.scores[playerID] += points
+	}
+}
+
+// heal 为施放者及范围内存活的队友恢复生命值，个人模式只恢复自己。正式对局中按实际恢复量计入治疗得分
+func (r *Room) heal(player *models.PlayerEntity, amount int, radius float64) {
+	r.entityMutex.Lock()
+	healed := 0
+	for _, entity := range r.entities {
+		target, ok := entity.(*models.PlayerEntity)
+		if !ok || !target.IsAlive {
+			continue
+		}
+		if target.ID != player.ID {
+			if player.Team == models.TeamNone || target.Team != player.Team {
+				continue
+			}
+			dx := target.Position.X - player.Position.X
+			dy := target.Position.Y - player.Position.Y
+			if math.Sqrt(dx*dx+dy*dy) > radius {
+				continue
+			}
+		}
+		restored := min(amount, target.MaxHealth-target.Health)
+		if restored > 0 {
+			target.Health += restored
+			healed += restored
+		}
+	}
+	r.entityMutex.Unlock()
+
+	r.addHealing(player.PlayerID, healed)
+}
+
+// addHealing 累计玩家的治疗量，每满 HealingPerPoint 得1分
+func (r *Room) addHealing(playerID int64, healed int) {
+	perPoint := r.HealingPerPoint
+	if healed <= 0 || perPoint <= 0 || r.phase == models.PhaseWarmup {
+		return
+	}
+
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+	if r.healing == nil {
+		r.healing = make(map[int64]int)
+	}
+	before := r.healing[playerID] / perPoint
+	r.healing[playerID] += healed
+	r.scores[playerID] += r.healing[playerID]/perPoint - before
+}
@@ -4,6 +4,7 @@ package game
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
@@ -12,23 +13,75 @@ import (
 	"time"
 
 	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/announce"
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
+	"github.com/jacl-coder/PixelStorm-Server/internal/health"
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/internal/registry"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
 )
 
 // GameServer 游戏服务器
 type GameServer struct {
 	config      *config.Config
+	levelCurve  *progression.Curve
+	trials      *trial.Service
 	rooms       map[string]*Room
 	roomsMutex  sync.RWMutex
 	httpServer  *http.Server
 	connections map[string]*PlayerConnection
 	connMutex   sync.RWMutex
 
+	// 玩家消息会话，断线后保留一段时间供重连恢复
+	sessions      map[int64]*playerSession
+	sessionsMutex sync.Mutex
+
+	// 向网关注册本实例并发送心跳，未配置服务注册时为nil
+	registrar *registry.Registrar
+
 	// 关闭信号
 	shutdown  chan struct{}
 	isRunning bool
 }
 
+// ErrServerFull 服务器容量已满
+var ErrServerFull = errcode.New(errcode.ServerFull, "服务器容量已满")
+
+// CapacityError 容量不足错误，调用方可据此选择其他实例或延迟重试
+type CapacityError struct {
+	Reason     string        // 不足原因
+	RetryAfter time.Duration // 建议的重试间隔
+}
+
+// Error 实现error接口
+func (e *CapacityError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrServerFull, e.Reason)
+}
+
+// Unwrap 支持errors.Is(err, ErrServerFull)
+func (e *CapacityError) Unwrap() error {
+	return ErrServerFull
+}
+
+// CapacityInfo 服务器容量信息
+type CapacityInfo struct {
+	Rooms         int  `json:"rooms"`
+	MaxRooms      int  `json:"max_rooms"`
+	ReservedSlots int  `json:"reserved_slots"` // 活跃房间占用的玩家名额
+	Connections   int  `json:"connections"`
+	MaxPlayers    int  `json:"max_players"`
+	Full          bool `json:"full"`
+}
+
+// 容量不足时建议的重试间隔
+const capacityRetryAfter = 5 * time.Second
+
 // PlayerConnection 玩家连接
 type PlayerConnection struct {
 	ID         string
@@ -37,9 +90,28 @@ type PlayerConnection struct {
 	LastActive time.Time
 
 	// 通信通道
-	Send    chan []byte
+	Send    chan OutboundMessage
 	Receive chan []byte
 
+	// 消息编码：json、protobuf 或 msgpack
+	Encoding string
+
+	// 握手协商的协议版本（握手前为0）和双方都支持的能力
+	ProtocolVersion int
+	Capabilities    []string
+
+	// 错误和通知使用的语言，取自连接请求，握手时客户端可另行指定
+	Locale i18n.Locale
+
+	// 消息会话，负责编号、确认和断线重发
+	session *playerSession
+
+	// 应用层心跳测得的往返延迟
+	latency playerLatency
+
+	// 已收到的无效消息数，达到上限后断开连接
+	violations int
+
 	// 连接状态
 	IsAlive bool
 	conn    net.Conn
@@ -49,8 +121,11 @@ type PlayerConnection struct {
 func NewGameServer(cfg *config.Config) *GameServer {
 	return &GameServer{
 		config:      cfg,
+		levelCurve:  progression.NewCurve(cfg.CharacterLevel),
+		trials:      trial.NewService(wallet.NewService(), cfg.Trial),
 		rooms:       make(map[string]*Room),
 		connections: make(map[string]*PlayerConnection),
+		sessions:    make(map[int64]*playerSession),
 		shutdown:    make(chan struct{}),
 	}
 }
@@ -78,16 +153,35 @@ func (s *GameServer) Start() error {
 	// 启动房间管理
 	go s.roomManager()
 
+	// 启动应用层心跳
+	go s.heartbeatLoop()
+
+	// 订阅对局事件
+	subscribeMatchConsumers()
+
+	// 接收全服公告并广播给本实例的玩家
+	announce.Listen(s.shutdown, func(a models.Announcement) { s.Announce(a) })
+
+	// 按计划维护警告玩家、结束对局
+	s.watchMaintenance()
+
+	// 向网关注册，心跳中上报当前负载
+	s.registrar = registry.New(s.config.Registry, "game", s.config.Server.GamePort, s.registryLoad)
+	s.registrar.Start()
+
 	s.isRunning = true
 	return nil
 }
 
 // Stop 停止游戏服务器
-func (s *GameServer) Stop() error {
+func (s *GameServer) Stop(ctx context.Context) error {
 	if !s.isRunning {
 		return nil
 	}
 
+	// 先从网关注销，不再接收新的连接转发
+	s.registrar.Stop()
+
 	// 发送关闭信号
 	close(s.shutdown)
 
@@ -98,21 +192,20 @@ func (s *GameServer) Stop() error {
 	}
 	s.roomsMutex.Unlock()
 
-	// 关闭所有连接
+	// 关闭所有连接，并从连接列表移除，避免读协程退出时再次关闭发送通道
 	s.connMutex.Lock()
-	for _, conn := range s.connections {
+	for id, conn := range s.connections {
 		close(conn.Send)
 		if conn.conn != nil {
 			conn.conn.Close()
 		}
+		delete(s.connections, id)
 	}
 	s.connMutex.Unlock()
 
-	// 关闭HTTP服务器
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+	// 关闭HTTP服务器，超时后强制关闭剩余连接
 	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.httpServer.Close()
 		return fmt.Errorf("HTTP服务器关闭错误: %w", err)
 	}
 
@@ -128,15 +221,78 @@ func (s *GameServer) createHandler() http.Handler {
 	// WebSocket 连接端点
 	mux.HandleFunc("/ws", s.handleWSConnection)
 
-	// 健康检查端点
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// 健康检查端点（附带容量信息）
+	mux.HandleFunc("/health", s.handleHealth)
+
+	// 区域延迟探测端点，客户端直连测量往返延迟
+	mux.HandleFunc("/ping", s.handlePing)
+
+	// 存活与就绪探针，容量已满时不再就绪
+	probes := health.NewChecker("game")
+	probes.Add("capacity", s.checkReadyCapacity)
+	probes.RegisterHandlers(mux)
+
+	// Prometheus 指标端点
+	mux.Handle("/metrics", metrics.Handler())
+
+	// 管理接口：房间运行指标（含玩家往返延迟）
+	mux.HandleFunc("/admin/rooms", s.adminOnly(s.handleAdminRooms))
+	mux.HandleFunc("/admin/rooms/", s.adminOnly(s.handleAdminRoom))
 
 	return mux
 }
 
+// handleHealth 处理健康检查请求
+func (s *GameServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		Status   string       `json:"status"`
+		Capacity CapacityInfo `json:"capacity"`
+	}{
+		Status:   "OK",
+		Capacity: s.Capacity(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码健康检查响应失败: %v", err)
+	}
+}
+
+// handlePing 处理延迟探测请求，只返回区域和服务器时间，不访问数据库
+func (s *GameServer) handlePing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resp := struct {
+		Region     string `json:"region"`
+		ServerTime int64  `json:"server_time"` // 毫秒时间戳
+	}{
+		Region:     s.config.Registry.Region,
+		ServerTime: time.Now().UnixMilli(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码延迟探测响应失败: %v", err)
+	}
+}
+
+// checkReadyCapacity 就绪检查：房间数和玩家名额未达上限
+func (s *GameServer) checkReadyCapacity(ctx context.Context) error {
+	info := s.Capacity()
+	if info.Full {
+		return fmt.Errorf("%w (房间 %d/%d, 连接 %d/%d)", ErrServerFull, info.Rooms, info.MaxRooms, info.Connections, info.MaxPlayers)
+	}
+	return nil
+}
+
 // roomManager 房间管理器
 func (s *GameServer) roomManager() {
 	ticker := time.NewTicker(10 * time.Second)
@@ -146,6 +302,7 @@ func (s *GameServer) roomManager() {
 		select {
 		case <-ticker.C:
 			s.cleanupRooms()
+			s.cleanupSessions()
 		case <-s.shutdown:
 			return
 		}
@@ -168,11 +325,23 @@ func (s *GameServer) cleanupRooms() {
 
 // CreateRoom 创建游戏房间
 func (s *GameServer) CreateRoom(name string, mode models.GameMode, maxPlayers int, mapID int) (*Room, error) {
-	room := NewRoom(name, mode, maxPlayers, mapID)
+	// 维护中不再创建房间
+	if maintenance.Active() {
+		return nil, maintenance.ErrInMaintenance
+	}
 
 	s.roomsMutex.Lock()
 	defer s.roomsMutex.Unlock()
 
+	// 检查容量限制
+	if err := s.checkCapacityLocked(maxPlayers); err != nil {
+		return nil, err
+	}
+
+	room := NewRoom(name, mode, maxPlayers, mapID)
+	room.AvoidBlockedTeammates = s.config.Match.AvoidBlockedTeammates
+	room.LevelCurve = s.levelCurve
+	room.ProjectileCollisions = feature.ProjectileCollisions.Enabled()
 	s.rooms[room.ID] = room
 
 	// 启动房间
@@ -203,3 +372,92 @@ func (s *GameServer) ListRooms() []*Room {
 
 	return rooms
 }
+
+// IsPlayerConnected 检查玩家是否有活跃的游戏连接
+func (s *GameServer) IsPlayerConnected(playerID int64) bool {
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+
+	for _, conn := range s.connections {
+		if conn.PlayerID == playerID && conn.IsAlive {
+			return true
+		}
+	}
+	return false
+}
+
+// Capacity 获取当前容量信息
+func (s *GameServer) Capacity() CapacityInfo {
+	s.roomsMutex.RLock()
+	rooms, reserved := s.activeRoomUsageLocked()
+	s.roomsMutex.RUnlock()
+
+	s.connMutex.RLock()
+	connections := len(s.connections)
+	s.connMutex.RUnlock()
+
+	info := CapacityInfo{
+		Rooms:         rooms,
+		MaxRooms:      s.config.Server.MaxRoomCount,
+		ReservedSlots: reserved,
+		Connections:   connections,
+		MaxPlayers:    s.config.Server.MaxPlayers,
+	}
+	info.Full = (info.MaxRooms > 0 && info.Rooms >= info.MaxRooms) ||
+		(info.MaxPlayers > 0 && (info.ReservedSlots >= info.MaxPlayers || info.Connections >= info.MaxPlayers))
+
+	return info
+}
+
+// registryLoad 注册心跳上报的容量和负载：玩家名额上限，以及连接数和房间占用名额中的较大者
+func (s *GameServer) registryLoad() (int, int) {
+	info := s.Capacity()
+	return info.MaxPlayers, max(info.Connections, info.ReservedSlots)
+}
+
+// activeRoomUsageLocked 统计未结束的房间数量及其占用的玩家名额（调用方需持有roomsMutex）
+func (s *GameServer) activeRoomUsageLocked() (int, int) {
+	rooms := 0
+	reserved := 0
+	for _, room := range s.rooms {
+		if room.Status == models.RoomEnded {
+			continue
+		}
+		rooms++
+		reserved += room.MaxPlayers
+	}
+	return rooms, reserved
+}
+
+// checkCapacityLocked 检查是否还能创建容纳maxPlayers人的房间（调用方需持有roomsMutex）
+func (s *GameServer) checkCapacityLocked(maxPlayers int) error {
+	rooms, reserved := s.activeRoomUsageLocked()
+
+	if limit := s.config.Server.MaxRoomCount; limit > 0 && rooms >= limit {
+		return &CapacityError{
+			Reason:     fmt.Sprintf("房间数已达上限 %d", limit),
+			RetryAfter: capacityRetryAfter,
+		}
+	}
+
+	if limit := s.config.Server.MaxPlayers; limit > 0 && reserved+maxPlayers > limit {
+		return &CapacityError{
+			Reason:     fmt.Sprintf("玩家名额不足 (已占用 %d/%d, 需要 %d)", reserved, limit, maxPlayers),
+			RetryAfter: capacityRetryAfter,
+		}
+	}
+
+	return nil
+}
+
+// acceptingConnections 检查是否还能接受新的玩家连接
+func (s *GameServer) acceptingConnections() bool {
+	limit := s.config.Server.MaxPlayers
+	if limit <= 0 {
+		return true
+	}
+
+	s.connMutex.RLock()
+	defer s.connMutex.RUnlock()
+	return len(s.connections) < limit
+}
@@ -0,0 +1,219 @@
+// session.go
+
+package game
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// 消息种类
+const (
+	// KindRealtime 实时消息（游戏帧），不编号，丢失后由后续帧覆盖
+	KindRealtime = "realtime"
+	// KindLobby 大厅消息，按会话编号，客户端可据序号发现漏收
+	KindLobby = "lobby"
+	// KindReliable 关键消息（匹配成功、对局结束），保留到客户端确认，断线重连恢复会话后重发
+	KindReliable = "reliable"
+)
+
+const (
+	// sessionResumeWindow 断线后保留消息会话的时长，期间重连可恢复会话
+	sessionResumeWindow = 2 * time.Minute
+
+	// maxPendingReliable 每个会话最多保留的未确认关键消息数
+	maxPendingReliable = 64
+)
+
+var (
+	missedMessagesTotal = metrics.Default.NewCounterVec("pixelstorm_ws_missed_messages_total",
+		"按序号发现的漏收消息数，inbound 为服务端漏收，outbound 为客户端重连时确认的漏收", "direction")
+	resentMessagesTotal = metrics.Default.NewCounterVec("pixelstorm_ws_resent_messages_total",
+		"断线重连恢复会话后重发的关键消息数", "type")
+)
+
+// playerSession 玩家的消息会话，跨越短暂断线保留，负责消息编号、确认和关键消息重发
+type playerSession struct {
+	mu             sync.Mutex
+	playerID       int64
+	nextSeq        uint64    // 最近一条发往客户端的消息序号
+	clientSeq      uint64    // 已收到的客户端消息最大序号
+	pending        []Message // 未确认的关键消息，按序号递增
+	disconnectedAt time.Time // 断线时间，连接中为零值
+}
+
+// stamp 为发往客户端的消息分配序号并附上确认号，关键消息保留到客户端确认
+func (ss *playerSession) stamp(msg *Message) {
+	if msg.Kind == "" {
+		msg.Kind = KindLobby
+	}
+	if msg.Kind == KindRealtime {
+		return
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.nextSeq++
+	msg.Seq = ss.nextSeq
+	msg.Ack = ss.clientSeq
+
+	if msg.Kind == KindReliable {
+		ss.pending = append(ss.pending, *msg)
+		if len(ss.pending) > maxPendingReliable {
+			log.Printf("玩家 %d 未确认的关键消息过多，丢弃序号 %d", ss.playerID, ss.pending[0].Seq)
+			ss.pending = ss.pending[1:]
+		}
+	}
+}
+
+// ack 客户端确认已收到序号不超过 seq 的消息
+func (ss *playerSession) ack(seq uint64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.ackLocked(seq)
+}
+
+func (ss *playerSession) ackLocked(seq uint64) {
+	n := 0
+	for n < len(ss.pending) && ss.pending[n].Seq <= seq {
+		n++
+	}
+	ss.pending = ss.pending[n:]
+}
+
+// receive 记录客户端消息序号，返回 false 表示重复消息（客户端重连后重发的已处理消息）
+func (ss *playerSession) receive(seq uint64) bool {
+	// 未编号的消息来自不支持消息信封的客户端
+	if seq == 0 {
+		return true
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if seq <= ss.clientSeq {
+		return false
+	}
+	if missed := seq - ss.clientSeq - 1; missed > 0 {
+		missedMessagesTotal.Add(float64(missed), "inbound")
+	}
+	ss.clientSeq = seq
+	return true
+}
+
+// resume 客户端重连并确认已收到 ack 及之前的消息，返回需要重发的关键消息。
+// ack 超出会话已发送的序号说明会话已不是客户端断线前的会话，返回 false
+func (ss *playerSession) resume(ack uint64) ([]Message, bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ack > ss.nextSeq {
+		return nil, false
+	}
+
+	ss.ackLocked(ack)
+	if missed := ss.nextSeq - ack; missed > 0 {
+		missedMessagesTotal.Add(float64(missed), "outbound")
+	}
+
+	resend := append([]Message(nil), ss.pending...)
+	for i := range resend {
+		resend[i].Ack = ss.clientSeq
+		resentMessagesTotal.Inc(resend[i].Type)
+	}
+	return resend, true
+}
+
+// reset 开始新的会话，丢弃之前的序号和未确认消息
+func (ss *playerSession) reset() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.nextSeq = 0
+	ss.clientSeq = 0
+	ss.pending = nil
+}
+
+// attachSession 连接建立时取得玩家的消息会话，断线不久的会话继续使用
+func (s *GameServer) attachSession(playerID int64) *playerSession {
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	ss, ok := s.sessions[playerID]
+	if !ok {
+		ss = &playerSession{playerID: playerID}
+		s.sessions[playerID] = ss
+	}
+
+	ss.mu.Lock()
+	ss.disconnectedAt = time.Time{}
+	ss.mu.Unlock()
+	return ss
+}
+
+// detachSession 连接关闭时标记会话断线，超过恢复时长后清理
+func (s *GameServer) detachSession(ss *playerSession) {
+	ss.mu.Lock()
+	ss.disconnectedAt = time.Now()
+	ss.mu.Unlock()
+}
+
+// cleanupSessions 清理断线超过恢复时长的会话
+func (s *GameServer) cleanupSessions() {
+	s.sessionsMutex.Lock()
+	defer s.sessionsMutex.Unlock()
+
+	for playerID, ss := range s.sessions {
+		ss.mu.Lock()
+		expired := !ss.disconnectedAt.IsZero() && time.Since(ss.disconnectedAt) > sessionResumeWindow
+		ss.mu.Unlock()
+		if expired {
+			delete(s.sessions, playerID)
+		}
+	}
+}
+
+// SendReliable 向玩家发送关键消息。玩家短暂断线时消息保留在会话中，重连恢复会话后重发。
+// 返回消息是否已放入在线连接的发送队列
+func (s *GameServer) SendReliable(playerID int64, msgType string, payload interface{}) bool {
+	msg, err := newMessage(msgType, payload)
+	if err != nil {
+		log.Printf("序列化 %s 消息失败: %v", msgType, err)
+		return false
+	}
+	msg.Kind = KindReliable
+
+	// 持有连接锁发送，避免连接在发送期间关闭
+	s.connMutex.RLock()
+	for _, conn := range s.connections {
+		if conn.PlayerID == playerID && conn.IsAlive {
+			defer s.connMutex.RUnlock()
+			return conn.trySend(msg)
+		}
+	}
+	s.connMutex.RUnlock()
+
+	s.sessionsMutex.Lock()
+	ss, ok := s.sessions[playerID]
+	s.sessionsMutex.Unlock()
+	if ok {
+		ss.stamp(&msg)
+	}
+	return false
+}
+
+// MatchFoundPayload 匹配成功消息
+type MatchFoundPayload struct {
+	RoomID string          `json:"room_id"`
+	Mode   models.GameMode `json:"mode"`
+}
+
+// NotifyMatchFound 通过游戏连接通知玩家匹配成功，返回玩家是否在线收到通知
+func (s *GameServer) NotifyMatchFound(playerID int64, roomID string, mode models.GameMode) bool {
+	return s.SendReliable(playerID, "match_found", MatchFoundPayload{RoomID: roomID, Mode: mode})
+}
@@ -0,0 +1,69 @@
+// skill.go
+
+package game
+
+import (
+	"math"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// defaultSpreadStep 技能数据未配置散射角度时，多发投射物之间的夹角(度)
+const defaultSpreadStep = 15.0
+
+// defaultProjectileLifetime 技能数据未配置射程时投射物的生命周期(秒)
+const defaultProjectileLifetime = 2.0
+
+// builtinSkills 静态游戏数据不可用时使用的技能数值
+var builtinSkills = map[int]models.Skill{
+	1: {ID: 1, Type: models.ProjectileSkill, Damage: 10, CooldownTime: 0.5, Range: 1000, ProjectileSpeed: 500, ProjectileCount: 1},
+	2: {ID: 2, Type: models.ProjectileSkill, Damage: 8, CooldownTime: 3.0, Range: 675, ProjectileSpeed: 450, ProjectileCount: 3, ProjectileSpread: 30},
+	3: {ID: 3, Type: models.ProjectileSkill, Damage: 15, CooldownTime: 5.0, Range: 1200, ProjectileSpeed: 400, ProjectileCount: 1},
+	4: {ID: 4, Type: models.BuffSkill, Damage: -20, CooldownTime: 8.0, Range: 200, EffectTime: 1.0},
+	5: {ID: 5, Type: models.MovementSkill, CooldownTime: 6.0, Range: 300, EffectTime: 0.5},
+}
+
+// skillData 获取技能配置，优先使用静态游戏数据缓存
+func skillData(skillID int) (models.Skill, bool) {
+	if catalog := gamedata.Current(); catalog != nil {
+		if skill, ok := catalog.Skill(skillID); ok {
+			return skill, true
+		}
+	}
+	skill, ok := builtinSkills[skillID]
+	return skill, ok
+}
+
+// fireProjectiles 按技能数据发射投射物，多发投射物以瞄准方向为中心均匀分布在扇形内。
+// 分布只由数量和角度决定，回放和延迟补偿时可以重新计算出相同的方向
+func (r *Room) fireProjectiles(player *models.PlayerEntity, skill models.Skill, direction models.Vector2D, damage int) {
+	lifetime := defaultProjectileLifetime
+	if skill.Range > 0 && skill.ProjectileSpeed > 0 {
+		lifetime = skill.Range / skill.ProjectileSpeed
+	}
+
+	for _, dir := range spreadDirections(direction, max(skill.ProjectileCount, 1), skill.ProjectileSpread) {
+		r.CreateProjectile(player, skill.ID, dir, damage, skill.ProjectileSpeed, lifetime)
+	}
+}
+
+// spreadDirections 计算 count 发投射物的方向，spread 为扇形总角度(度)，为0时每发相差 defaultSpreadStep 度
+func spreadDirections(direction models.Vector2D, count int, spread float64) []models.Vector2D {
+	if count == 1 {
+		return []models.Vector2D{direction}
+	}
+
+	step := defaultSpreadStep
+	if spread > 0 {
+		step = spread / float64(count-1)
+	}
+	start := -step * float64(count-1) / 2
+
+	dirs := make([]models.Vector2D, count)
+	for i := range dirs {
+		angle := (start + step*float64(i)) * math.Pi / 180
+		dirs[i] = rotateVector(direction, angle)
+	}
+	return dirs
+}
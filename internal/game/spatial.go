@@ -0,0 +1,91 @@
+// spatial.go
+
+package game
+
+import (
+	"math"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// gridCell 空间网格中的格子坐标
+type gridCell struct {
+	x, y int
+}
+
+// spatialGrid 按固定大小的格子划分玩家位置，格子边长不小于碰撞距离时，
+// 可能重叠的玩家一定位于相同或相邻的格子中，不需要两两比较
+type spatialGrid struct {
+	cellSize float64
+	cells    map[gridCell][]*models.PlayerEntity
+}
+
+// newSpatialGrid 创建空间网格
+func newSpatialGrid(cellSize float64) *spatialGrid {
+	return &spatialGrid{cellSize: cellSize, cells: make(map[gridCell][]*models.PlayerEntity)}
+}
+
+// cellOf 坐标所在的格子
+func (g *spatialGrid) cellOf(pos models.Vector2D) gridCell {
+	return gridCell{x: int(math.Floor(pos.X / g.cellSize)), y: int(math.Floor(pos.Y / g.cellSize))}
+}
+
+// insert 按玩家当前位置加入网格
+func (g *spatialGrid) insert(player *models.PlayerEntity) {
+	cell := g.cellOf(player.Position)
+	g.cells[cell] = append(g.cells[cell], player)
+}
+
+// nearby 遍历与坐标所在格子相同或相邻的格子中的玩家
+func (g *spatialGrid) nearby(pos models.Vector2D, fn func(*models.PlayerEntity)) {
+	center := g.cellOf(pos)
+	for dx := -1; dx <= 1; dx++ {
+		for dy := -1; dy <= 1; dy++ {
+			for _, player := range g.cells[gridCell{x: center.x + dx, y: center.y + dy}] {
+				fn(player)
+			}
+		}
+	}
+}
+
+// separatePlayers 开启玩家碰撞时，将相互重叠的存活玩家沿连线方向各推开重叠距离的一半
+func (r *Room) separatePlayers() {
+	r.entityMutex.Lock()
+	defer r.entityMutex.Unlock()
+
+	minDistance := 2 * playerRadius
+	grid := newSpatialGrid(minDistance)
+	players := make([]*models.PlayerEntity, 0, len(r.entities))
+	for _, entity := range r.entities {
+		if player, ok := entity.(*models.PlayerEntity); ok && player.IsAlive {
+			grid.insert(player)
+			players = append(players, player)
+		}
+	}
+
+	for _, player := range players {
+		grid.nearby(player.Position, func(other *models.PlayerEntity) {
+			// 每对玩家只处理一次
+			if other.ID <= player.ID {
+				return
+			}
+			dx := other.Position.X - player.Position.X
+			dy := other.Position.Y - player.Position.Y
+			distance := math.Sqrt(dx*dx + dy*dy)
+			if distance >= minDistance {
+				return
+			}
+
+			// 位置完全重合时按固定方向推开，保证结果可重现
+			normal := models.Vector2D{X: 1}
+			if distance > 0 {
+				normal = models.Vector2D{X: dx / distance, Y: dy / distance}
+			}
+			push := (minDistance - distance) / 2
+			player.Position.X -= normal.X * push
+			player.Position.Y -= normal.Y * push
+			other.Position.X += normal.X * push
+			other.Position.Y += normal.Y * push
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// spatial_test.go
+
+package game
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// newTestPlayer 创建位于指定位置的存活玩家
+func newTestPlayer(id string, x, y float64) *models.PlayerEntity {
+	return &models.PlayerEntity{
+		BaseEntity: models.BaseEntity{ID: id, Type: models.EntityPlayer, Position: models.Vector2D{X: x, Y: y}},
+		IsAlive:    true,
+	}
+}
+
+func TestSpatialGridNearby(t *testing.T) {
+	players := []*models.PlayerEntity{
+		newTestPlayer("a", 10, 10),   // 格子 (0,0)
+		newTestPlayer("b", 50, 10),   // 格子 (1,0)
+		newTestPlayer("c", 90, 50),   // 格子 (2,1)
+		newTestPlayer("d", -10, -10), // 格子 (-1,-1)
+	}
+	grid := newSpatialGrid(40)
+	for _, player := range players {
+		grid.insert(player)
+	}
+
+	tests := []struct {
+		name string
+		pos  models.Vector2D
+		want []string
+	}{
+		{name: "相同和相邻的格子", pos: models.Vector2D{X: 10, Y: 10}, want: []string{"a", "b", "d"}},
+		{name: "不包含隔一个格子的玩家", pos: models.Vector2D{X: 50, Y: 10}, want: []string{"a", "b", "c"}},
+		{name: "负坐标向下取整", pos: models.Vector2D{X: -30, Y: -30}, want: []string{"a", "d"}},
+		{name: "附近没有玩家", pos: models.Vector2D{X: 500, Y: 500}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			grid.nearby(tt.pos, func(player *models.PlayerEntity) {
+				got = append(got, player.ID)
+			})
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("nearby(%v) = %v, want %v", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeparatePlayers(t *testing.T) {
+	minDistance := 2 * playerRadius
+
+	tests := []struct {
+		name       string
+		a, b       models.Vector2D
+		bDead      bool
+		wantA      models.Vector2D
+		wantB      models.Vector2D
+		wantPushed bool // 推开后两人距离为碰撞距离
+	}{
+		{
+			name:  "没有重叠时不移动",
+			a:     models.Vector2D{X: 100, Y: 100},
+			b:     models.Vector2D{X: 100 + minDistance, Y: 100},
+			wantA: models.Vector2D{X: 100, Y: 100},
+			wantB: models.Vector2D{X: 100 + minDistance, Y: 100},
+		},
+		{
+			name:       "沿连线方向各推开一半",
+			a:          models.Vector2D{X: 100, Y: 100},
+			b:          models.Vector2D{X: 120, Y: 100},
+			wantA:      models.Vector2D{X: 90, Y: 100},
+			wantB:      models.Vector2D{X: 130, Y: 100},
+			wantPushed: true,
+		},
+		{
+			name:       "跨格子的重叠也会推开",
+			a:          models.Vector2D{X: 39, Y: 0},
+			b:          models.Vector2D{X: 41, Y: 0},
+			wantA:      models.Vector2D{X: 20, Y: 0},
+			wantB:      models.Vector2D{X: 60, Y: 0},
+			wantPushed: true,
+		},
+		{
+			name:       "位置重合时沿X轴推开",
+			a:          models.Vector2D{X: 100, Y: 100},
+			b:          models.Vector2D{X: 100, Y: 100},
+			wantA:      models.Vector2D{X: 100 - playerRadius, Y: 100},
+			wantB:      models.Vector2D{X: 100 + playerRadius, Y: 100},
+			wantPushed: true,
+		},
+		{
+			name:  "阵亡玩家不参与碰撞",
+			a:     models.Vector2D{X: 100, Y: 100},
+			b:     models.Vector2D{X: 110, Y: 100},
+			bDead: true,
+			wantA: models.Vector2D{X: 100, Y: 100},
+			wantB: models.Vector2D{X: 110, Y: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestPlayer("a", tt.a.X, tt.a.Y)
+			b := newTestPlayer("b", tt.b.X, tt.b.Y)
+			b.IsAlive = !tt.bDead
+			r := &Room{entities: map[string]models.Entity{a.ID: a, b.ID: b}}
+
+			r.separatePlayers()
+
+			if !nearlyEqual(a.Position, tt.wantA) || !nearlyEqual(b.Position, tt.wantB) {
+				t.Errorf("separatePlayers() a = %v, b = %v, want a = %v, b = %v", a.Position, b.Position, tt.wantA, tt.wantB)
+			}
+			if tt.wantPushed {
+				dx, dy := b.Position.X-a.Position.X, b.Position.Y-a.Position.Y
+				if distance := math.Sqrt(dx*dx + dy*dy); math.Abs(distance-minDistance) > 1e-9 {
+					t.Errorf("推开后距离 = %v, want %v", distance, minDistance)
+				}
+			}
+		})
+	}
+}
+
+// nearlyEqual 坐标在浮点误差范围内相等
+func nearlyEqual(a, b models.Vector2D) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
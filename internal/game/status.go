@@ -0,0 +1,112 @@
+// status.go
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// matchStatusInterval 对局状态的发送间隔
+const matchStatusInterval = time.Second
+
+// remainingTime 当前阶段的剩余时间(秒)：热身、倒计时和限时加时到阶段结束，正式对局到时间限制，
+// 不限时的突然死亡为0
+func (r *Room) remainingTime(now time.Time) int {
+	switch r.phase {
+	case models.PhaseLive:
+		return max(r.TimeLimit-int(now.Sub(r.StartedAt).Seconds()), 0)
+	case models.PhaseOvertime:
+		if r.OvertimeTime <= 0 {
+			return 0
+		}
+	}
+	return max(int(r.phaseEndsAt.Sub(now).Seconds()), 0)
+}
+
+// syncMatchStatus 每秒向房间内玩家发送对局状态，正式对局中队伍（团队模式）或玩家的得分首次进入赛点时发送赛点通知
+func (r *Room) syncMatchStatus(now time.Time) {
+	if now.Sub(r.lastStatus) < matchStatusInterval {
+		return
+	}
+	r.lastStatus = now
+
+	r.playerMutex.RLock()
+	defer r.playerMutex.RUnlock()
+
+	status := models.MatchStatus{
+		Phase:         r.phase,
+		RemainingTime: r.remainingTime(now),
+		ScoreLimit:    r.ScoreLimit,
+		Scores:        make(map[int64]int, len(r.players)),
+	}
+	teamMode := r.Mode.IsTeamMode()
+	if teamMode {
+		status.TeamScores = make(map[models.Team]int)
+	}
+
+	for _, ps := range r.players {
+		if ps.Entity == nil {
+			continue
+		}
+		score := r.scores[ps.Entity.PlayerID]
+		status.Scores[ps.Entity.PlayerID] = score
+		if teamMode && ps.Entity.Team != models.TeamNone {
+			status.TeamScores[ps.Entity.Team] += score
+		}
+	}
+
+	// 再有一次得分事件即可获胜时为赛点，即得分在 [ScoreLimit-maxEventScore, ScoreLimit) 内，每个队伍或玩家只通知一次
+	var points []models.MatchPoint
+	window := r.maxEventScore()
+	for key, score := range r.scoreTotalsLocked() {
+		if !r.phase.Scoring() || r.ScoreLimit <= window || score < r.ScoreLimit-window || score >= r.ScoreLimit || r.matchPoints[key] {
+			continue
+		}
+		if r.matchPoints == nil {
+			r.matchPoints = make(map[int64]bool)
+		}
+		r.matchPoints[key] = true
+		point := models.MatchPoint{Score: score}
+		if teamMode {
+			point.Team = models.Team(key)
+		} else {
+			point.PlayerID = key
+		}
+		points = append(points, point)
+		r.matchPointHighlights = append(r.matchPointHighlights, models.Highlight{
+			Type:     models.HighlightMatchPoint,
+			PlayerID: point.PlayerID,
+			Team:     point.Team,
+			Elapsed:  now.Sub(r.StartedAt).Seconds(),
+		})
+	}
+
+	r.broadcastLocked("match_status", status, false)
+	for _, point := range points {
+		log.Printf("房间 %s 队伍 %d 玩家 %d 到达赛点", r.ID, point.Team, point.PlayerID)
+		r.broadcastLocked("match_point", point, true)
+	}
+}
+
+// broadcastLocked 向房间内所有玩家发送消息，reliable 为 true 时以关键消息发送（调用方持有 playerMutex）
+func (r *Room) broadcastLocked(msgType string, payload interface{}, reliable bool) {
+	msg, err := newMessage(msgType, payload)
+	if err != nil {
+		log.Printf("序列化 %s 消息失败: %v", msgType, err)
+		return
+	}
+	if reliable {
+		msg.Kind = KindReliable
+	}
+
+	for _, ps := range r.players {
+		if ps.Connection == nil {
+			continue
+		}
+		// 通道已满时跳过
+		ps.Connection.trySend(msg)
+	}
+}
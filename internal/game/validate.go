@@ -0,0 +1,304 @@
+// validate.go
+
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxMessageViolations 单个连接允许的无效消息数，达到后断开连接
+const maxMessageViolations = 5
+
+var invalidMessagesTotal = metrics.Default.NewCounterVec("pixelstorm_ws_invalid_messages_total",
+	"校验失败的客户端消息数", "type", "code")
+
+// ErrorPayload 错误回复
+type ErrorPayload struct {
+	Code    errcode.Code `json:"code"`
+	Message string       `json:"message"`
+	Type    string       `json:"type,omitempty"` // 引发错误的消息类型
+	Seq     uint64       `json:"seq,omitempty"`  // 引发错误的消息序号
+}
+
+// payloadSchema 可校验取值的载荷结构
+type payloadSchema interface {
+	validate() error
+}
+
+// payloadDecoder 严格解析并校验载荷，返回该类型的载荷结构
+type payloadDecoder func(raw json.RawMessage) (interface{}, error)
+
+// messageSchemas 各类型客户端消息的载荷解析方式，值为 nil 的类型不接受载荷
+var messageSchemas = map[string]payloadDecoder{
+	"hello":        jsonPayload(func() payloadSchema { return &HelloPayload{} }),
+	"ack":          nil,
+	"pong":         jsonPayload(func() payloadSchema { return &PongPayload{} }),
+	"join_room":    jsonPayload(func() payloadSchema { return &JoinRoomPayload{} }),
+	"create_room":  jsonPayload(func() payloadSchema { return &CreateRoomPayload{} }),
+	"leave_room":   nil,
+	"ready":        nil,
+	"unready":      nil,
+	"player_input": decodePlayerInput,
+	"chat":         jsonPayload(func() payloadSchema { return &ChatPayload{} }),
+
+	"change_character": jsonPayload(func() payloadSchema { return &ChangeCharacterPayload{} }),
+}
+
+// decodeMessage 严格解析消息信封和载荷。解析失败时返回的消息包含已解析出的类型和序号，用于错误回复
+func decodeMessage(data []byte) (Message, interface{}, error) {
+	var msg Message
+	if err := decodeStrict(data, &msg); err != nil {
+		return msg, nil, errcode.New(errcode.MalformedMessage, err.Error())
+	}
+	if msg.Type == "" {
+		return msg, nil, errcode.New(errcode.MalformedMessage, "缺少消息类型")
+	}
+
+	decode, ok := messageSchemas[msg.Type]
+	if !ok {
+		return msg, nil, errcode.New(errcode.UnknownMessageType, fmt.Sprintf("未知的消息类型: %s", msg.Type))
+	}
+
+	if decode == nil {
+		if !emptyPayload(msg.Payload) {
+			return msg, nil, errcode.New(errcode.InvalidPayload, fmt.Sprintf("%s 消息不接受载荷", msg.Type))
+		}
+		return msg, nil, nil
+	}
+
+	payload, err := decode(msg.Payload)
+	if err != nil {
+		return msg, nil, errcode.New(errcode.InvalidPayload, err.Error())
+	}
+	return msg, payload, nil
+}
+
+// decodeStrict 解析单个 JSON 值，不允许未知字段和多余内容
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("JSON 之后存在多余内容")
+	}
+	return nil
+}
+
+// emptyPayload 载荷是否为空（缺省、null 或空对象）
+func emptyPayload(raw json.RawMessage) bool {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", "{}":
+		return true
+	}
+	return false
+}
+
+// jsonPayload 按 JSON 严格解析载荷并校验取值，缺省的载荷按空对象处理
+func jsonPayload(newSchema func() payloadSchema) payloadDecoder {
+	return func(raw json.RawMessage) (interface{}, error) {
+		if emptyPayload(raw) {
+			raw = json.RawMessage("{}")
+		}
+		v := newSchema()
+		if err := decodeStrict(raw, v); err != nil {
+			return nil, err
+		}
+		if err := v.validate(); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// rejectMessage 回复校验错误，无效消息过多时断开连接
+func (s *GameServer) rejectMessage(player *PlayerConnection, msg Message, err error) {
+	code := errcode.Of(err)
+	if code == "" {
+		code = errcode.MalformedMessage
+	}
+
+	msgType := msg.Type
+	if _, known := messageSchemas[msgType]; !known {
+		msgType = "unknown"
+	}
+	invalidMessagesTotal.Inc(msgType, string(code))
+
+	player.violations++
+	log.Printf("玩家 %d 发送的消息无效 (%d/%d): %s: %v", player.PlayerID, player.violations, maxMessageViolations, code, err)
+
+	s.sendPayload(player, "error", ErrorPayload{
+		Code:    code,
+		Message: i18n.Message(player.Locale, code, err.Error()),
+		Type:    msg.Type,
+		Seq:     msg.Seq,
+	})
+
+	if player.violations >= maxMessageViolations {
+		s.sendPayload(player, "error", ErrorPayload{
+			Code:    errcode.TooManyInvalidMessages,
+			Message: i18n.Translate(player.Locale, "无效消息过多，连接已关闭"),
+		})
+		s.closeConnection(player)
+	}
+}
+
+// validate 校验握手消息
+func (p *HelloPayload) validate() error {
+	if len(p.Capabilities) > 16 {
+		return errors.New("capabilities 最多 16 项")
+	}
+	if len(p.ClientVersion) > 64 {
+		return errors.New("client_version 最长 64 个字符")
+	}
+	if len(p.Locale) > 35 {
+		return errors.New("locale 最长 35 个字符")
+	}
+	return nil
+}
+
+// JoinRoomPayload 加入房间请求
+type JoinRoomPayload struct {
+	RoomID      string `json:"room_id"`
+	CharacterID int    `json:"character_id"`
+	Password    string `json:"password,omitempty"`
+}
+
+// validate 校验加入房间请求
+func (p *JoinRoomPayload) validate() error {
+	if p.RoomID == "" || len(p.RoomID) > 64 {
+		return errors.New("room_id 不能为空且最长 64 个字符")
+	}
+	if p.CharacterID <= 0 {
+		return errors.New("character_id 必须大于0")
+	}
+	if len(p.Password) > 64 {
+		return errors.New("password 最长 64 个字符")
+	}
+	return nil
+}
+
+// CreateRoomPayload 创建房间请求
+type CreateRoomPayload struct {
+	Name       string          `json:"name"`
+	Mode       models.GameMode `json:"mode"`
+	MaxPlayers int             `json:"max_players"`
+	MapID      int             `json:"map_id"`
+	Private    bool            `json:"private"`
+	Password   string          `json:"password,omitempty"`
+}
+
+// validate 校验创建房间请求
+func (p *CreateRoomPayload) validate() error {
+	if n := utf8.RuneCountInString(strings.TrimSpace(p.Name)); n == 0 || n > 32 {
+		return errors.New("name 不能为空且最长 32 个字符")
+	}
+	if !p.Mode.IsValid() {
+		return fmt.Errorf("不支持的游戏模式: %s", p.Mode)
+	}
+	if p.MaxPlayers < 2 || p.MaxPlayers > 16 {
+		return errors.New("max_players 应在 2-16 之间")
+	}
+	if p.MapID < 1 {
+		return errors.New("map_id 必须为正整数")
+	}
+	if len(p.Password) > 64 {
+		return errors.New("password 最长 64 个字符")
+	}
+	return nil
+}
+
+// ChangeCharacterPayload 对局开始前更换角色
+type ChangeCharacterPayload struct {
+	CharacterID int `json:"character_id"`
+}
+
+// validate 校验更换角色请求
+func (p *ChangeCharacterPayload) validate() error {
+	if p.CharacterID <= 0 {
+		return errors.New("character_id 必须大于0")
+	}
+	return nil
+}
+
+// ChatPayload 房间聊天消息
+type ChatPayload struct {
+	Text string `json:"text"`
+}
+
+// validate 校验聊天消息
+func (p *ChatPayload) validate() error {
+	p.Text = strings.TrimSpace(p.Text)
+	if p.Text == "" {
+		return errors.New("text 不能为空")
+	}
+	if utf8.RuneCountInString(p.Text) > maxChatLength {
+		return fmt.Errorf("text 最长 %d 个字符", maxChatLength)
+	}
+	return nil
+}
+
+// inputJSON 玩家输入的解析方式，不允许未知字段
+var inputJSON = protojson.UnmarshalOptions{}
+
+// decodePlayerInput 按 protocol.PlayerInput 解析玩家输入并校验取值
+func decodePlayerInput(raw json.RawMessage) (interface{}, error) {
+	var input protocol.PlayerInput
+	if err := inputJSON.Unmarshal(raw, &input); err != nil {
+		return nil, err
+	}
+
+	if input.Timestamp <= 0 {
+		return nil, errors.New("timestamp 必须为正数")
+	}
+	if move := input.Move; move != nil {
+		if d := move.Direction; d != nil {
+			if !finite(d.X, d.Y) || math.Hypot(float64(d.X), float64(d.Y)) > 1.01 {
+				return nil, errors.New("move.direction 必须为归一化向量")
+			}
+		}
+		if !finite(move.Speed) || move.Speed < 0 {
+			return nil, errors.New("move.speed 不能为负数")
+		}
+	}
+	if rotate := input.Rotate; rotate != nil {
+		if !finite(rotate.Rotation) || rotate.Rotation < 0 || rotate.Rotation > 360 {
+			return nil, errors.New("rotate.rotation 应在 0-360 之间")
+		}
+	}
+	if skill := input.Skill; skill != nil {
+		if skill.SkillId <= 0 {
+			return nil, errors.New("skill.skill_id 必须为正整数")
+		}
+		if t := skill.Target; t != nil && !finite(t.X, t.Y) {
+			return nil, errors.New("skill.target 坐标无效")
+		}
+	}
+	return &input, nil
+}
+
+// finite 数值是否均为有限值
+func finite(values ...float32) bool {
+	for _, v := range values {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+// warmup.go
+
+package game
+
+import (
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// advancePhase 热身结束后重置战绩并开始倒计时，倒计时结束后开始正式对局。
+// 等待中、正式对局和加时没有到期的阶段，直接返回，不加锁
+func (r *Room) advancePhase(now time.Time) {
+	if r.phase == "" || r.phaseEndsAt.IsZero() || r.phase.Scoring() || now.Before(r.phaseEndsAt) {
+		return
+	}
+
+	r.playerMutex.Lock()
+	defer r.playerMutex.Unlock()
+
+	switch r.phase {
+	case models.PhaseWarmup:
+		r.resetWarmupLocked()
+		log.Printf("房间 %s 热身结束，%d 秒后开始对局", r.ID, r.CountdownTime)
+		r.enterPhaseLocked(models.PhaseCountdown, time.Duration(r.CountdownTime)*time.Second)
+	case models.PhaseCountdown:
+		r.goLiveLocked()
+	}
+}
+
+// enterPhaseLocked 进入对局阶段并通知玩家（调用方持有 playerMutex）。
+// 阶段变化以关键消息发送，短暂断线的玩家重连后仍能得知对局已开始
+func (r *Room) enterPhaseLocked(phase models.MatchPhase, duration time.Duration) {
+	r.phase = phase
+	info := models.MatchPhaseInfo{Phase: phase}
+	if duration > 0 {
+		r.phaseEndsAt = time.Now().Add(duration)
+		info.EndsAt = r.phaseEndsAt
+	} else {
+		r.phaseEndsAt = time.Now()
+	}
+	if phase == models.PhaseOvertime {
+		info.Overtime = r.Overtime
+	}
+
+	msg, err := newMessage("match_phase", info)
+	if err != nil {
+		log.Printf("序列化对局阶段失败: %v", err)
+		return
+	}
+	msg.Kind = KindReliable
+
+	for _, ps := range r.players {
+		if ps.Connection == nil {
+			continue
+		}
+		// 通道已满时跳过
+		ps.Connection.trySend(msg)
+	}
+	r.broadcastRoomStateLocked()
+}
+
+// resetWarmupLocked 热身结束后清空得分和击杀统计，玩家满血复活到出生点，清除场上投射物（调用方持有 playerMutex）
+func (r *Room) resetWarmupLocked() {
+	r.scores = make(map[int64]int)
+	r.healing = nil
+	r.matchPoints = nil
+	r.matchPointHighlights = nil
+
+	r.entityMutex.Lock()
+	for id, entity := range r.entities {
+		switch e := entity.(type) {
+		case *models.PlayerEntity:
+			e.Kills, e.Deaths, e.Assists = 0, 0, 0
+			e.Attackers, e.LastAttackerID = nil, ""
+			e.IsAlive = true
+			e.Health = e.MaxHealth
+			e.RespawnTime = 0
+			e.Position = r.spawnPosition()
+			e.Velocity = models.Vector2D{}
+			e.SkillCooldowns = make(map[int]float64)
+		case *models.ProjectileEntity:
+			delete(r.entities, id)
+		}
+	}
+	r.entityMutex.Unlock()
+
+	r.eventMutex.Lock()
+	r.positionEvents = nil
+	r.eventMutex.Unlock()
+}
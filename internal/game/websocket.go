@@ -6,11 +6,17 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/codec"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
 )
 
 const (
@@ -25,6 +31,9 @@ const (
 
 	// 最大消息大小
 	maxMessageSize = 512 * 1024 // 512KB
+
+	// 聊天消息最大长度（字符）
+	maxChatLength = 200
 )
 
 var upgrader = websocket.Upgrader{
@@ -36,10 +45,23 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Message 消息结构
+// Message 消息信封。服务端发出的大厅消息和关键消息按玩家会话编号，
+// 双方在 ack 中携带已连续收到的对端最大序号
 type Message struct {
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload"`
+	Seq     uint64          `json:"seq,omitempty"`  // 消息序号，实时消息不编号
+	Ack     uint64          `json:"ack,omitempty"`  // 已收到的对端最大序号
+	Kind    string          `json:"kind,omitempty"` // 消息种类：realtime、lobby 或 reliable
+}
+
+// newMessage 创建指定类型的消息
+func newMessage(msgType string, payload interface{}) (Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Type: msgType, Payload: data}, nil
 }
 
 // handleWSConnection 处理WebSocket连接
@@ -47,29 +69,61 @@ func (s *GameServer) handleWSConnection(w http.ResponseWriter, r *http.Request)
 	// 获取认证信息
 	playerID := r.URL.Query().Get("player_id")
 	token := r.URL.Query().Get("token")
+	// 连接被拒绝时的提示和之后的错误消息使用请求语言
+	locale := i18n.FromRequest(r)
 
 	// 验证认证信息
 	// TODO: 实现真正的认证逻辑
 	if playerID == "" || token == "" {
-		http.Error(w, "未授权", http.StatusUnauthorized)
+		http.Error(w, i18n.Translate(locale, "未授权"), http.StatusUnauthorized)
 		return
 	}
 
-	// 升级HTTP连接为WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	// 连接数已满时拒绝新连接
+	if !s.acceptingConnections() {
+		w.Header().Set("Retry-After", strconv.Itoa(int(capacityRetryAfter.Seconds())))
+		http.Error(w, i18n.Translate(locale, "服务器已满"), http.StatusServiceUnavailable)
+		return
+	}
+
+	// 消息编码，客户端通过 encoding=protobuf 或 encoding=msgpack 选择二进制帧
+	encoding, err := parseEncoding(r.URL.Query().Get("encoding"))
+	if err != nil {
+		http.Error(w, i18n.Translate(locale, err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// 升级HTTP连接为WebSocket，包装底层连接以统计压缩后实际写出的字节数
+	wsCfg := s.config.WebSocket
+	up := upgrader
+	up.EnableCompression = wsCfg.Compression
+	hijacker := &countingHijacker{ResponseWriter: w}
+	conn, err := up.Upgrade(hijacker, r, nil)
 	if err != nil {
 		log.Printf("WebSocket升级失败: %v", err)
 		return
 	}
+	if wsCfg.CompressionLevel != 0 {
+		conn.SetCompressionLevel(wsCfg.CompressionLevel)
+	}
+	writer := &frameWriter{
+		conn:      conn,
+		wire:      hijacker.conn,
+		deflate:   wsCfg.Compression && offersDeflate(r),
+		threshold: wsCfg.CompressionThreshold,
+	}
 
 	// 创建玩家连接
 	playerConn := &PlayerConnection{
 		ID:         uuid.New().String(),
 		PlayerID:   parseInt64(playerID),
 		LastActive: time.Now(),
-		Send:       make(chan []byte, 256),
+		Send:       make(chan OutboundMessage, 256),
 		Receive:    make(chan []byte, 256),
 		IsAlive:    true,
+		Encoding:   encoding,
+		Locale:     locale,
+		session:    s.attachSession(parseInt64(playerID)),
 	}
 
 	// 添加到连接列表
@@ -81,7 +135,7 @@ func (s *GameServer) handleWSConnection(w http.ResponseWriter, r *http.Request)
 
 	// 启动读写协程
 	go s.readPump(conn, playerConn)
-	go s.writePump(conn, playerConn)
+	go s.writePump(conn, playerConn, writer)
 }
 
 // readPump 从WebSocket读取数据
@@ -100,7 +154,7 @@ func (s *GameServer) readPump(conn *websocket.Conn, player *PlayerConnection) {
 	})
 
 	for {
-		_, message, err := conn.ReadMessage()
+		messageType, message, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket错误: %v", err)
@@ -108,15 +162,28 @@ func (s *GameServer) readPump(conn *websocket.Conn, player *PlayerConnection) {
 			break
 		}
 
+		// 连接已关闭（如协议版本过低），等待写协程发送完剩余消息后断开
+		if !player.IsAlive {
+			continue
+		}
+
 		player.LastActive = time.Now()
 
+		// MessagePack 连接的二进制消息转换为 JSON 后按相同逻辑处理
+		if messageType == websocket.BinaryMessage && player.Encoding == EncodingMsgpack {
+			if message, err = codec.MsgpackToJSON(message); err != nil {
+				log.Printf("解析MessagePack消息失败: %v", err)
+				continue
+			}
+		}
+
 		// 处理接收到的消息
 		s.handleMessage(player, message)
 	}
 }
 
 // writePump 向WebSocket写入数据
-func (s *GameServer) writePump(conn *websocket.Conn, player *PlayerConnection) {
+func (s *GameServer) writePump(conn *websocket.Conn, player *PlayerConnection, writer *frameWriter) {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
@@ -133,22 +200,36 @@ func (s *GameServer) writePump(conn *websocket.Conn, player *PlayerConnection) {
 				return
 			}
 
-			w, err := conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
+			// 二进制帧每帧一条消息
+			if message.Binary {
+				if err := writer.write(websocket.BinaryMessage, message.Data); err != nil {
+					return
+				}
+				continue
 			}
-			w.Write(message)
 
-			// 添加队列中的其他消息
+			// 合并队列中的其他文本消息，遇到二进制消息时在文本帧之后单独发送。
+			// 消息体可能被多个连接共享，合并时复制
+			batch := slices.Clip(message.Data)
+			var pending *OutboundMessage
 			n := len(player.Send)
 			for i := 0; i < n; i++ {
-				w.Write([]byte("\n"))
-				w.Write(<-player.Send)
+				next := <-player.Send
+				if next.Binary {
+					pending = &next
+					break
+				}
+				batch = append(append(batch, '\n'), next.Data...)
 			}
 
-			if err := w.Close(); err != nil {
+			if err := writer.write(websocket.TextMessage, batch); err != nil {
 				return
 			}
+			if pending != nil {
+				if err := writer.write(websocket.BinaryMessage, pending.Data); err != nil {
+					return
+				}
+			}
 		case <-ticker.C:
 			conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -176,6 +257,8 @@ func (s *GameServer) closeConnection(player *PlayerConnection) {
 
 	// 关闭发送通道
 	close(player.Send)
+	player.IsAlive = false
+	s.detachSession(player.session)
 
 	// 从连接列表移除
 	delete(s.connections, player.ID)
@@ -185,17 +268,42 @@ func (s *GameServer) closeConnection(player *PlayerConnection) {
 
 // handleMessage 处理接收到的消息
 func (s *GameServer) handleMessage(player *PlayerConnection, data []byte) {
-	var msg Message
-	if err := json.Unmarshal(data, &msg); err != nil {
-		log.Printf("解析消息失败: %v", err)
+	msg, payload, err := decodeMessage(data)
+	if err != nil {
+		s.rejectMessage(player, msg, err)
+		return
+	}
+
+	// 握手消息可能重置会话，序号在握手处理中记录
+	if msg.Type == "hello" {
+		s.handleHello(player, &msg, payload.(*HelloPayload))
+		return
+	}
+
+	// 未握手直接发送其他消息的是引入握手之前的客户端，按协议版本 1 处理
+	if player.ProtocolVersion == 0 {
+		if !s.negotiate(player, 1) {
+			return
+		}
+	}
+
+	if msg.Ack > 0 {
+		player.session.ack(msg.Ack)
+	}
+	if !player.session.receive(msg.Seq) {
+		log.Printf("忽略玩家 %d 重复的消息 %d", player.PlayerID, msg.Seq)
 		return
 	}
 
 	switch msg.Type {
+	case "ack":
+		// 仅携带确认号
+	case "pong":
+		s.handlePong(player, payload.(*PongPayload))
 	case "join_room":
-		s.handleJoinRoom(player, msg.Payload)
+		s.handleJoinRoom(player, payload.(*JoinRoomPayload))
 	case "create_room":
-		s.handleCreateRoom(player, msg.Payload)
+		s.handleCreateRoom(player, payload.(*CreateRoomPayload))
 	case "leave_room":
 		s.handleLeaveRoom(player)
 	case "ready":
@@ -203,19 +311,40 @@ func (s *GameServer) handleMessage(player *PlayerConnection, data []byte) {
 	case "unready":
 		s.handlePlayerReady(player, false)
 	case "player_input":
-		s.handlePlayerInput(player, msg.Payload)
-	default:
-		log.Printf("未知消息类型: %s", msg.Type)
+		s.handlePlayerInput(player, payload.(*protocol.PlayerInput))
+	case "chat":
+		s.handleChat(player, payload.(*ChatPayload))
+	case "change_character":
+		s.handleChangeCharacter(player, payload.(*ChangeCharacterPayload))
 	}
 }
 
 // handleJoinRoom 处理加入房间请求
-func (s *GameServer) handleJoinRoom(player *PlayerConnection, payload json.RawMessage) {
-	// TODO: 实现加入房间逻辑
+func (s *GameServer) handleJoinRoom(player *PlayerConnection, req *JoinRoomPayload) {
+	if player.Room != nil {
+		s.replyError(player, "join_room", errAlreadyInRoom)
+		return
+	}
+
+	room, ok := s.GetRoom(req.RoomID)
+	if !ok {
+		s.replyError(player, "join_room", errRoomNotFound)
+		return
+	}
+
+	// 加入成功后房间会向所有成员广播 room_state
+	if err := room.AddPlayer(player, req.CharacterID); err != nil {
+		s.replyError(player, "join_room", err)
+		return
+	}
+	player.Room = room
+
+	// 补发加入前的聊天和击杀，重新加入的玩家不会看到空白的上下文
+	room.SendHistory(player)
 }
 
 // handleCreateRoom 处理创建房间请求
-func (s *GameServer) handleCreateRoom(player *PlayerConnection, payload json.RawMessage) {
+func (s *GameServer) handleCreateRoom(player *PlayerConnection, req *CreateRoomPayload) {
 	// TODO: 实现创建房间逻辑
 }
 
@@ -234,47 +363,103 @@ func (s *GameServer) handleLeaveRoom(player *PlayerConnection) {
 
 // handlePlayerReady 处理玩家准备/取消准备
 func (s *GameServer) handlePlayerReady(player *PlayerConnection, ready bool) {
-	// TODO: 实现玩家准备逻辑
+	if player.Room == nil {
+		return
+	}
+
+	// 所有玩家准备后由房间的游戏循环开始对局
+	player.Room.SetReady(player.ID, ready)
+}
+
+// handleChangeCharacter 处理对局开始前更换角色，成功后房间会向所有成员广播 room_state
+func (s *GameServer) handleChangeCharacter(player *PlayerConnection, req *ChangeCharacterPayload) {
+	if player.Room == nil {
+		s.replyError(player, "change_character", errNotInRoom)
+		return
+	}
+
+	// 与匹配入队相同：已拥有、试玩中或本周免费的角色可以使用
+	usable, err := s.trials.CanUse(player.PlayerID, req.CharacterID)
+	if err != nil {
+		log.Printf("检查玩家 %d 角色 %d 失败: %v", player.PlayerID, req.CharacterID, err)
+		s.replyError(player, "change_character", errcode.New(errcode.Internal, "检查角色失败"))
+		return
+	}
+	if !usable {
+		s.replyError(player, "change_character", errCharacterNotOwned)
+		return
+	}
+
+	if err := player.Room.ChangeCharacter(player.ID, req.CharacterID); err != nil {
+		s.replyError(player, "change_character", err)
+	}
 }
 
 // handlePlayerInput 处理玩家输入
-func (s *GameServer) handlePlayerInput(player *PlayerConnection, payload json.RawMessage) {
-	// TODO: 实现玩家输入处理逻辑
+func (s *GameServer) handlePlayerInput(player *PlayerConnection, input *protocol.PlayerInput) {
+	if player.Room == nil {
+		return
+	}
+
+	// 技能操作交给游戏循环执行
+	if skill := input.Skill; skill != nil {
+		var target models.Vector2D
+		if skill.Target != nil {
+			target = models.Vector2D{X: float64(skill.Target.X), Y: float64(skill.Target.Y)}
+		}
+		player.Room.QueueSkill(player.ID, int(skill.SkillId), target)
+	}
+
+	// TODO: 实现移动和转向输入处理逻辑
 }
 
-// sendMessage 向玩家发送消息
-func (s *GameServer) sendMessage(player *PlayerConnection, msg interface{}) {
-	data, err := json.Marshal(msg)
-	if err != nil {
-		log.Printf("序列化消息失败: %v", err)
+// handleChat 处理房间聊天消息
+func (s *GameServer) handleChat(player *PlayerConnection, req *ChatPayload) {
+	if player.Room == nil {
 		return
 	}
 
-	select {
-	case player.Send <- data:
-		// 消息已发送到通道
-	default:
+	player.Room.BroadcastChat(player.PlayerID, req.Text)
+}
+
+// sendMessage 向玩家发送消息
+func (s *GameServer) sendMessage(player *PlayerConnection, msg Message) {
+	if !player.trySend(msg) {
 		// 通道已满，关闭连接
 		s.closeConnection(player)
 	}
 }
 
-// broadcastMessage 向所有玩家广播消息
-func (s *GameServer) broadcastMessage(msg interface{}) {
-	data, err := json.Marshal(msg)
+// sendPayload 向玩家发送指定类型的消息
+func (s *GameServer) sendPayload(player *PlayerConnection, msgType string, payload interface{}) {
+	msg, err := newMessage(msgType, payload)
 	if err != nil {
-		log.Printf("序列化消息失败: %v", err)
+		log.Printf("序列化 %s 消息失败: %v", msgType, err)
 		return
 	}
+	s.sendMessage(player, msg)
+}
 
+// replyError 回复处理消息时的业务错误，如房间已满
+func (s *GameServer) replyError(player *PlayerConnection, msgType string, err error) {
+	code := errcode.Of(err)
+	if code == "" {
+		code = errcode.Internal
+	}
+	s.sendPayload(player, "error", ErrorPayload{
+		Code:    code,
+		Message: i18n.Message(player.Locale, code, err.Error()),
+		Type:    msgType,
+	})
+}
+
+// broadcastMessage 向所有玩家广播消息
+func (s *GameServer) broadcastMessage(msg Message) {
 	s.connMutex.RLock()
 	defer s.connMutex.RUnlock()
 
 	for _, player := range s.connections {
-		select {
-		case player.Send <- data:
-			// 消息已发送到通道
-		default:
+		if !player.trySend(msg) {
 			// 通道已满，关闭连接
 			go s.closeConnection(player)
 		}
@@ -0,0 +1,103 @@
+// detail.go
+
+package gamedata
+
+import (
+	"log"
+	"sync"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+)
+
+// detailCache 按角色ID缓存带技能的角色详情，快照未收录的角色回源查询后也会缓存，
+// 静态游戏数据每次重新加载时整体清空
+type detailCache struct {
+	mu      sync.RWMutex
+	entries map[int]*models.Character
+}
+
+// get 获取缓存的角色详情，返回副本
+func (d *detailCache) get(characterID int) (*models.Character, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	char, ok := d.entries[characterID]
+	if !ok {
+		return nil, false
+	}
+	return copyDetail(char), true
+}
+
+// put 缓存角色详情，返回副本
+func (d *detailCache) put(char *models.Character) *models.Character {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.entries == nil {
+		d.entries = make(map[int]*models.Character)
+	}
+	d.entries[char.ID] = char
+	return copyDetail(char)
+}
+
+// clear 清空全部角色详情
+func (d *detailCache) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = nil
+}
+
+// size 缓存的角色详情数
+func (d *detailCache) size() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.entries)
+}
+
+// CharacterDetail 获取带技能的角色详情，优先读取角色详情缓存，未命中时从当前快照或底层存取组装后缓存。
+// 缓存未初始化时直接查询底层存取；技能查询失败只记录日志，返回不带技能的角色且不缓存；
+// 角色不存在时返回 repository.ErrNotFound
+func CharacterDetail(inner repository.CharacterRepo, characterID int) (*models.Character, error) {
+	if Default == nil {
+		return loadDetail(inner, nil, characterID)
+	}
+	if detail, ok := Default.details.get(characterID); ok {
+		return detail, nil
+	}
+	return loadDetail(inner, Default, characterID)
+}
+
+// loadDetail 组装角色详情，c 不为nil且技能查询成功时写入其角色详情缓存
+func loadDetail(inner repository.CharacterRepo, c *Cache, characterID int) (*models.Character, error) {
+	if c != nil {
+		if catalog := c.Catalog(); catalog != nil {
+			if char, ok := catalog.Character(characterID); ok {
+				char.Skills = catalog.Skills(characterID)
+				return c.details.put(&char), nil
+			}
+		}
+	}
+
+	char, err := inner.GetByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	skills, err := inner.Skills(characterID)
+	if err != nil {
+		log.Printf("查询角色 %d 技能失败: %v", characterID, err)
+		return char, nil
+	}
+	char.Skills = skills
+	if c == nil {
+		return char, nil
+	}
+	return c.details.put(char), nil
+}
+
+// copyDetail 复制角色详情，调用方修改技能不影响缓存
+func copyDetail(char *models.Character) *models.Character {
+	detail := *char
+	detail.Skills = append([]models.Skill(nil), char.Skills...)
+	return &detail
+}
@@ -0,0 +1,200 @@
+// gamedata.go
+
+package gamedata
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// Catalog 一次加载得到的静态游戏数据快照，加载后只读
+type Catalog struct {
+	Characters []models.Character
+	Maps       []models.GameMap
+	LoadedAt   time.Time
+
+	characters map[int]*models.Character
+	skills     map[int][]models.Skill
+	maps       map[int]*models.GameMap
+}
+
+// Character 根据ID获取角色配置，返回副本
+func (c *Catalog) Character(characterID int) (models.Character, bool) {
+	char, ok := c.characters[characterID]
+	if !ok {
+		return models.Character{}, false
+	}
+	return *char, true
+}
+
+// Skills 获取角色技能，返回副本
+func (c *Catalog) Skills(characterID int) []models.Skill {
+	return append([]models.Skill(nil), c.skills[characterID]...)
+}
+
+// Skill 根据ID获取技能配置，返回副本
+func (c *Catalog) Skill(skillID int) (models.Skill, bool) {
+	for _, skills := range c.skills {
+		for _, skill := range skills {
+			if skill.ID == skillID {
+				return skill, true
+			}
+		}
+	}
+	return models.Skill{}, false
+}
+
+// Map 根据ID获取地图配置
+func (c *Catalog) Map(mapID int) (models.GameMap, bool) {
+	m, ok := c.maps[mapID]
+	if !ok {
+		return models.GameMap{}, false
+	}
+	return *m, true
+}
+
+// SkillCount 缓存的角色技能总数
+func (c *Catalog) SkillCount() int {
+	count := 0
+	for _, skills := range c.skills {
+		count += len(skills)
+	}
+	return count
+}
+
+// Cache 进程内静态游戏数据缓存。角色、技能和地图在启动时预热，
+// 之后只在管理员重新加载或收到其他实例的失效通知时整体替换
+type Cache struct {
+	characters repository.CharacterRepo
+	maps       repository.MapRepo
+
+	loadMu  sync.Mutex
+	catalog atomic.Pointer[Catalog]
+	loads   atomic.Uint64
+	details detailCache
+}
+
+// NewCache 创建静态游戏数据缓存
+func NewCache(characters repository.CharacterRepo, maps repository.MapRepo) *Cache {
+	return &Cache{characters: characters, maps: maps}
+}
+
+// Load 从数据库加载全部静态游戏数据，成功后替换当前快照并清空角色详情缓存
+func (c *Cache) Load() error {
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	characters, err := c.characters.All()
+	if err != nil {
+		return fmt.Errorf("加载角色配置失败: %w", err)
+	}
+
+	catalog := &Catalog{
+		Characters: characters,
+		LoadedAt:   time.Now(),
+		characters: make(map[int]*models.Character, len(characters)),
+		skills:     make(map[int][]models.Skill, len(characters)),
+	}
+	for i := range characters {
+		char := &characters[i]
+		skills, err := c.characters.Skills(char.ID)
+		if err != nil {
+			return fmt.Errorf("加载角色 %d 技能失败: %w", char.ID, err)
+		}
+		catalog.characters[char.ID] = char
+		catalog.skills[char.ID] = skills
+	}
+
+	catalog.Maps, err = c.maps.All()
+	if err != nil {
+		return fmt.Errorf("加载地图配置失败: %w", err)
+	}
+	catalog.maps = make(map[int]*models.GameMap, len(catalog.Maps))
+	for i := range catalog.Maps {
+		catalog.maps[catalog.Maps[i].ID] = &catalog.Maps[i]
+	}
+
+	c.catalog.Store(catalog)
+	c.details.clear()
+	c.loads.Add(1)
+	return nil
+}
+
+// Catalog 返回当前快照，尚未加载成功时尝试加载一次，仍失败返回nil
+func (c *Cache) Catalog() *Catalog {
+	if catalog := c.catalog.Load(); catalog != nil {
+		return catalog
+	}
+	if err := c.Load(); err != nil {
+		log.Printf("加载静态游戏数据失败: %v", err)
+		return nil
+	}
+	return c.catalog.Load()
+}
+
+// Default 全局静态游戏数据缓存，未初始化时为nil，调用方回退到直接查询数据库
+var Default *Cache
+
+// Init 创建全局缓存并预热，Redis 可用时订阅其他实例的失效通知
+func Init() {
+	Default = NewCache(repository.NewPostgresCharacterRepo(db.Cluster), repository.NewPostgresMapRepo(db.Cluster))
+	registerMetrics(Default)
+
+	if err := Default.Load(); err != nil {
+		log.Printf("预热静态游戏数据失败，首次访问时重试: %v", err)
+	} else {
+		catalog := Default.catalog.Load()
+		log.Printf("静态游戏数据已加载: %d 个角色, %d 个技能, %d 张地图",
+			len(catalog.Characters), catalog.SkillCount(), len(catalog.Maps))
+	}
+
+	if db.RedisClient != nil {
+		startInvalidationListener(Default)
+	}
+}
+
+// Current 返回全局缓存的当前快照，缓存不可用时返回nil
+func Current() *Catalog {
+	if Default == nil {
+		return nil
+	}
+	return Default.Catalog()
+}
+
+// Reload 重新加载本实例的静态游戏数据，并通知其他实例重新加载
+func Reload() (*Catalog, error) {
+	if Default == nil {
+		return nil, fmt.Errorf("静态游戏数据缓存未初始化")
+	}
+	if err := Default.Load(); err != nil {
+		return nil, err
+	}
+	publishInvalidation()
+	return Default.catalog.Load(), nil
+}
+
+// registerMetrics 注册缓存指标
+func registerMetrics(c *Cache) {
+	metrics.Default.CounterFunc("pixelstorm_gamedata_loads_total",
+		"静态游戏数据成功加载次数",
+		func() float64 { return float64(c.loads.Load()) })
+	metrics.Default.GaugeFunc("pixelstorm_gamedata_character_details",
+		"缓存的角色详情数",
+		func() float64 { return float64(c.details.size()) })
+	metrics.Default.GaugeFunc("pixelstorm_gamedata_loaded_timestamp_seconds",
+		"最近一次成功加载静态游戏数据的时间",
+		func() float64 {
+			if catalog := c.catalog.Load(); catalog != nil {
+				return float64(catalog.LoadedAt.Unix())
+			}
+			return 0
+		})
+}
@@ -0,0 +1,162 @@
+// gamedata_test.go
+
+package gamedata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+)
+
+// fakeCharacterRepo 内存中的角色存取，记录查询次数
+type fakeCharacterRepo struct {
+	repository.CharacterRepo
+
+	characters []models.Character
+	skills     map[int][]models.Skill
+	skillsErr  error
+	getCalls   int
+}
+
+func (f *fakeCharacterRepo) All() ([]models.Character, error) {
+	return append([]models.Character(nil), f.characters...), nil
+}
+
+func (f *fakeCharacterRepo) GetByID(characterID int) (*models.Character, error) {
+	f.getCalls++
+	for _, char := range f.characters {
+		if char.ID == characterID {
+			return &char, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeCharacterRepo) Skills(characterID int) ([]models.Skill, error) {
+	if f.skillsErr != nil {
+		return nil, f.skillsErr
+	}
+	return append([]models.Skill(nil), f.skills[characterID]...), nil
+}
+
+// fakeMapRepo 内存中的地图存取
+type fakeMapRepo struct {
+	maps []models.GameMap
+	err  error
+}
+
+func (f *fakeMapRepo) All() ([]models.GameMap, error) {
+	return f.maps, f.err
+}
+
+func newFakeCharacterRepo() *fakeCharacterRepo {
+	return &fakeCharacterRepo{
+		characters: []models.Character{{ID: 1, Name: "战士"}, {ID: 2, Name: "法师"}},
+		skills: map[int][]models.Skill{
+			1: {{ID: 11, Name: "冲锋"}},
+			2: {{ID: 21, Name: "火球"}, {ID: 22, Name: "闪现"}},
+		},
+	}
+}
+
+func TestCacheLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		skillsErr  error
+		mapsErr    error
+		wantErr    bool
+		wantSkills int
+	}{
+		{name: "加载成功", wantSkills: 3},
+		{name: "技能查询失败", skillsErr: errors.New("连接断开"), wantErr: true},
+		{name: "地图查询失败", mapsErr: errors.New("连接断开"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			characters := newFakeCharacterRepo()
+			characters.skillsErr = tt.skillsErr
+			c := NewCache(characters, &fakeMapRepo{maps: []models.GameMap{{ID: 1, Name: "训练场"}}, err: tt.mapsErr})
+
+			err := c.Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			catalog := c.catalog.Load()
+			if tt.wantErr {
+				if catalog != nil {
+					t.Errorf("加载失败时不应替换快照")
+				}
+				return
+			}
+			if got := catalog.SkillCount(); got != tt.wantSkills {
+				t.Errorf("SkillCount() = %d, want %d", got, tt.wantSkills)
+			}
+			if _, ok := catalog.Map(1); !ok {
+				t.Errorf("快照中缺少地图 1")
+			}
+		})
+	}
+}
+
+func TestLoadDetail(t *testing.T) {
+	tests := []struct {
+		name        string
+		withCache   bool
+		characterID int
+		skillsErr   error
+		wantErr     error
+		wantSkills  int
+		wantCached  bool
+	}{
+		{name: "无缓存时查询底层存取", characterID: 2, wantSkills: 2},
+		{name: "角色不存在", characterID: 9, wantErr: repository.ErrNotFound},
+		{name: "技能查询失败时返回不带技能的角色", characterID: 1, skillsErr: errors.New("连接断开")},
+		{name: "从快照组装并缓存", withCache: true, characterID: 2, wantSkills: 2, wantCached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			characters := newFakeCharacterRepo()
+			var c *Cache
+			if tt.withCache {
+				c = NewCache(characters, &fakeMapRepo{})
+				if err := c.Load(); err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+			}
+			characters.skillsErr = tt.skillsErr
+
+			char, err := loadDetail(characters, c, tt.characterID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadDetail() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if char.ID != tt.characterID {
+				t.Errorf("loadDetail() ID = %d, want %d", char.ID, tt.characterID)
+			}
+			if got := len(char.Skills); got != tt.wantSkills {
+				t.Errorf("len(Skills) = %d, want %d", got, tt.wantSkills)
+			}
+			if tt.withCache && characters.getCalls != 0 {
+				t.Errorf("快照命中时不应查询底层存取, GetByID 调用 %d 次", characters.getCalls)
+			}
+			if c == nil {
+				return
+			}
+			cached, ok := c.details.get(tt.characterID)
+			if ok != tt.wantCached {
+				t.Fatalf("角色详情已缓存 = %v, want %v", ok, tt.wantCached)
+			}
+			// 修改返回值不影响缓存
+			char.Skills[0].Name = "已修改"
+			if cached.Skills[0].Name == "已修改" {
+				t.Errorf("返回的角色详情与缓存共享技能切片")
+			}
+		})
+	}
+}
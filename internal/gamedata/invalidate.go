@@ -0,0 +1,46 @@
+// invalidate.go
+
+package gamedata
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// InvalidateChannel 静态游戏数据失效通知的 Redis 频道
+const InvalidateChannel = "gamedata:invalidate"
+
+// instanceID 本进程标识，收到自己发出的通知时不重复加载
+var instanceID = uuid.New().String()
+
+// publishInvalidation 通知其他实例重新加载静态游戏数据，失败只记录日志
+func publishInvalidation() {
+	if db.RedisClient == nil {
+		return
+	}
+	if err := db.RedisClient.Publish(context.Background(), InvalidateChannel, instanceID).Err(); err != nil {
+		log.Printf("发布静态游戏数据失效通知失败: %v", err)
+	}
+}
+
+// startInvalidationListener 订阅失效通知，收到其他实例的通知后重新加载
+func startInvalidationListener(c *Cache) {
+	pubsub := db.RedisClient.Subscribe(context.Background(), InvalidateChannel)
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			if msg.Payload == instanceID {
+				continue
+			}
+			if err := c.Load(); err != nil {
+				log.Printf("收到失效通知后重新加载静态游戏数据失败: %v", err)
+				continue
+			}
+			log.Printf("收到实例 %s 的失效通知，静态游戏数据已重新加载", msg.Payload)
+		}
+	}()
+}
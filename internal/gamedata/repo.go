@@ -0,0 +1,47 @@
+// repo.go
+
+package gamedata
+
+import (
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+)
+
+// cachedCharacterRepo 角色配置和技能从缓存读取，玩家角色的查询和写入仍走底层存取
+type cachedCharacterRepo struct {
+	repository.CharacterRepo
+}
+
+// CharacterRepo 包装角色存取，缓存不可用或未命中时回退到底层存取
+func CharacterRepo(inner repository.CharacterRepo) repository.CharacterRepo {
+	return &cachedCharacterRepo{CharacterRepo: inner}
+}
+
+// All 获取所有角色
+func (r *cachedCharacterRepo) All() ([]models.Character, error) {
+	catalog := Current()
+	if catalog == nil {
+		return r.CharacterRepo.All()
+	}
+	return append([]models.Character(nil), catalog.Characters...), nil
+}
+
+// GetByID 根据ID获取角色
+func (r *cachedCharacterRepo) GetByID(characterID int) (*models.Character, error) {
+	if catalog := Current(); catalog != nil {
+		if char, ok := catalog.Character(characterID); ok {
+			return &char, nil
+		}
+	}
+	return r.CharacterRepo.GetByID(characterID)
+}
+
+// Skills 获取角色技能
+func (r *cachedCharacterRepo) Skills(characterID int) ([]models.Skill, error) {
+	if catalog := Current(); catalog != nil {
+		if _, ok := catalog.characters[characterID]; ok {
+			return catalog.Skills(characterID), nil
+		}
+	}
+	return r.CharacterRepo.Skills(characterID)
+}
@@ -0,0 +1,55 @@
+// admin.go
+
+package gateway
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+)
+
+// AdminKeyHeader 管理接口密钥请求头
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminAuth 管理接口认证
+type AdminAuth struct {
+	apiKey string
+}
+
+// NewAdminAuth 创建管理接口认证
+func NewAdminAuth(cfg *config.AdminConfig) *AdminAuth {
+	return &AdminAuth{
+		apiKey: cfg.APIKey,
+	}
+}
+
+// Enabled 是否启用了管理接口
+func (a *AdminAuth) Enabled() bool {
+	return a.apiKey != ""
+}
+
+// Wrap 包装处理器，仅允许携带正确管理密钥的请求，修改类请求记录审计日志
+func (a *AdminAuth) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.Enabled() {
+			sendJSONError(w, "管理接口未启用", http.StatusForbidden)
+			return
+		}
+
+		key := r.Header.Get(AdminKeyHeader)
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(a.apiKey)) != 1 {
+			log.Printf("管理接口认证失败: %s %s", r.Method, r.URL.Path)
+			sendJSONError(w, "管理员认证失败", http.StatusUnauthorized)
+			return
+		}
+
+		// 修改类操作写入审计日志
+		if isMutation(r.Method) {
+			a.audit(w, r, next)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,59 @@
+// announcement.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/announce"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// AnnouncementHandler 全服公告管理处理器
+type AnnouncementHandler struct{}
+
+// NewAnnouncementHandler 创建全服公告管理处理器
+func NewAnnouncementHandler() *AnnouncementHandler {
+	return &AnnouncementHandler{}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *AnnouncementHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/announcements", admin.Wrap(h.handleAnnounce))
+}
+
+// handleAnnounce 向所有在线玩家广播公告: POST /admin/announcements
+func (h *AnnouncementHandler) handleAnnounce(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.AnnounceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	a, err := announce.Publish(&req)
+	if err != nil {
+		h.sendError(w, "发布公告失败", err)
+		return
+	}
+	log.Printf("管理员发布全服公告 %s (%s): %s", a.ID, a.Severity, a.Message)
+	sendJSONSuccess(w, "公告已发布", a)
+}
+
+// sendError 按错误类型返回公告错误
+func (h *AnnouncementHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, announce.ErrInvalidAnnouncement):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
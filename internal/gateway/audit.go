@@ -0,0 +1,209 @@
+// audit.go
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/audit"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// AdminActorHeader 管理操作者标识请求头，记录到审计日志，未设置时记为 admin
+const AdminActorHeader = "X-Admin-Actor"
+
+const (
+	// defaultAuditActor 未设置操作者时的审计标识
+	defaultAuditActor = "admin"
+
+	// maxAuditPayload 审计日志记录的请求体上限，超过时只记录类型和大小
+	maxAuditPayload = 64 << 10
+
+	// maxActorLength 操作者标识最大长度，与表结构一致
+	maxActorLength = 64
+
+	// defaultAuditRange 查询未指定开始时间时的时间范围
+	defaultAuditRange = 24 * time.Hour
+)
+
+// auditContextKey 请求上下文中审计记录的键
+type auditContextKey struct{}
+
+// isMutation 是否为修改类请求，只有这些请求写入审计日志
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// audit 执行修改类管理请求并写入审计日志，记录请求体、响应状态码和处理器提供的修改前状态
+func (a *AdminAuth) audit(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	entry := &models.AdminAuditEntry{
+		Actor:  r.Header.Get(AdminActorHeader),
+		IP:     clientIP(r),
+		Method: r.Method,
+		Target: r.URL.RequestURI(),
+	}
+	if entry.Actor == "" {
+		entry.Actor = defaultAuditActor
+	}
+	if len(entry.Actor) > maxActorLength {
+		entry.Actor = entry.Actor[:maxActorLength]
+	}
+	entry.Payload = capturePayload(r)
+
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	next(recorder, r.WithContext(context.WithValue(r.Context(), auditContextKey{}, entry)))
+	entry.Status = recorder.statusCode
+
+	if err := audit.Record(entry); err != nil {
+		log.Printf("记录管理操作 %s %s 失败: %v", entry.Method, entry.Target, err)
+	}
+}
+
+// capturePayload 读取请求体用于审计并还原给处理器。非 JSON 或过大的请求体只记录类型和大小
+func capturePayload(r *http.Request) json.RawMessage {
+	if r.Body == nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditPayload+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	if len(data) <= maxAuditPayload && json.Valid(data) {
+		return redactPayload(data)
+	}
+	summary, _ := json.Marshal(map[string]interface{}{
+		"content_type": r.Header.Get("Content-Type"),
+		"bytes":        r.ContentLength,
+	})
+	return summary
+}
+
+// redactedFields 审计日志中隐藏值的请求体字段
+var redactedFields = []string{"password"}
+
+// redactPayload 隐藏请求体顶层的密码等字段
+func redactPayload(data []byte) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return data
+	}
+	redacted := false
+	for _, key := range redactedFields {
+		if _, ok := fields[key]; ok {
+			fields[key] = json.RawMessage(`"[Filtered]"`)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return data
+	}
+	result, err := json.Marshal(fields)
+	if err != nil {
+		return nil
+	}
+	return result
+}
+
+// auditBefore 记录修改前的状态，审计日志据此计算变更的字段。不在审计中的请求忽略
+func auditBefore(r *http.Request, v interface{}) {
+	entry, ok := r.Context().Value(auditContextKey{}).(*models.AdminAuditEntry)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("序列化审计修改前状态失败: %v", err)
+		return
+	}
+	entry.Before = data
+}
+
+// AuditHandler 管理操作审计日志处理器
+type AuditHandler struct{}
+
+// NewAuditHandler 创建审计日志处理器
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *AuditHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/audit", admin.Wrap(h.handleQuery))
+}
+
+// handleQuery 按时间区间查询审计日志:
+// GET /admin/audit?from=&to=&actor=&target=&limit=，时间为 RFC3339 格式，默认最近24小时
+func (h *AuditHandler) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q, err := parseAuditQuery(r)
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	entries, err := audit.Query(q)
+	if err != nil {
+		log.Printf("查询审计日志失败: %v", err)
+		sendJSONError(w, "查询审计日志失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", entries)
+}
+
+// parseAuditQuery 解析审计日志查询参数
+func parseAuditQuery(r *http.Request) (models.AuditQuery, error) {
+	query := r.URL.Query()
+	q := models.AuditQuery{
+		To:     time.Now(),
+		Actor:  query.Get("actor"),
+		Target: query.Get("target"),
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return q, fmt.Errorf("无效的结束时间，格式应为 RFC3339")
+		}
+		q.To = to
+	}
+	q.From = q.To.Add(-defaultAuditRange)
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return q, fmt.Errorf("无效的开始时间，格式应为 RFC3339")
+		}
+		q.From = from
+	}
+	if q.From.After(q.To) {
+		return q, fmt.Errorf("开始时间不能晚于结束时间")
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > audit.MaxLimit {
+			return q, fmt.Errorf("limit 应在 1-%d 之间", audit.MaxLimit)
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
@@ -3,35 +3,62 @@
 package gateway
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/authsession"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
 	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	// 会话缓存，现在支持Redis
-	sessions    map[string]SessionInfo
+	players repository.PlayerRepo
+
+	// 会话缓存，现在支持Redis，Redis不可用时暂存在内存中
+	sessions    *localSessionStore
 	useRedis    bool
 	sessionTTL  time.Duration
+	sliding     bool
+	maxLifetime time.Duration
 }
 
 // SessionInfo 会话信息
 type SessionInfo struct {
 	PlayerID  int64
 	Username  string
+	IssuedAt  time.Time
 	ExpiresAt time.Time
 }
 
+// PlayerIDHeader 转发到游戏和匹配服务的请求附带的登录会话玩家ID，由网关设置，客户端传入的值会被覆盖
+const PlayerIDHeader = "X-Player-ID"
+
+// SessionExpiresHeader 已登录请求的响应头，值为会话的过期时间(RFC3339)，客户端可据此提前续期
+const SessionExpiresHeader = "X-Session-Expires-At"
+
+const (
+	// defaultSessionTTL 未配置时的会话有效期
+	defaultSessionTTL = 24 * time.Hour
+
+	// sessionRenewInterval 自动续期的最小延长时间，避免每个请求都写入会话存储
+	sessionRenewInterval = time.Minute
+
+	// sessionSweepInterval 清理内存中过期会话并尝试写回Redis的间隔
+	sessionSweepInterval = time.Minute
+)
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username"`
@@ -47,22 +74,35 @@ type RegisterRequest struct {
 
 // AuthResponse 认证响应
 type AuthResponse struct {
-	Success  bool   `json:"success"`
-	Message  string `json:"message"`
-	Token    string `json:"token,omitempty"`
-	PlayerID int64  `json:"player_id,omitempty"`
-	Username string `json:"username,omitempty"`
+	Success  bool         `json:"success"`
+	Code     errcode.Code `json:"code,omitempty"`
+	Message  string       `json:"message"`
+	Token    string       `json:"token,omitempty"`
+	PlayerID int64        `json:"player_id,omitempty"`
+	Username string       `json:"username,omitempty"`
+
+	// 会话过期时间和剩余秒数，客户端可在过期前调用 /auth/refresh 续期
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ExpiresIn int64      `json:"expires_in,omitempty"`
 }
 
 // NewAuthHandler 创建认证处理器
-func NewAuthHandler() *AuthHandler {
+func NewAuthHandler(players repository.PlayerRepo, cfg config.SessionConfig) *AuthHandler {
 	// 检查Redis是否可用
 	useRedis := db.RedisClient != nil
 
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+
 	return &AuthHandler{
-		sessions:   make(map[string]SessionInfo),
-		useRedis:   useRedis,
-		sessionTTL: 24 * time.Hour,
+		players:     players,
+		sessions:    newLocalSessionStore(cfg.LocalMaxEntries),
+		useRedis:    useRedis,
+		sessionTTL:  ttl,
+		sliding:     cfg.Sliding,
+		maxLifetime: cfg.MaxLifetime,
 	}
 }
 
@@ -71,29 +111,34 @@ func (h *AuthHandler) RegisterHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("/auth/login", h.handleLogin)
 	mux.HandleFunc("/auth/register", h.handleRegister)
 	mux.HandleFunc("/auth/validate", h.handleValidate)
+	mux.HandleFunc("/auth/refresh", h.handleRefresh)
 	mux.HandleFunc("/auth/logout", h.handleLogout)
 }
 
 // handleLogin 处理登录请求
 func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// 解析请求
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求格式", http.StatusBadRequest)
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
 		return
 	}
 
 	// 验证用户名和密码
-	playerID, err := h.validateCredentials(req.Username, req.Password)
+	playerID, err := h.players.FindByCredentials(req.Username, hashPassword(req.Password))
 	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("验证用户 %s 失败: %v", req.Username, err)
+		}
 		// 返回错误响应
 		resp := AuthResponse{
 			Success: false,
+			Code:    errcode.InvalidCredentials,
 			Message: "用户名或密码错误",
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -104,16 +149,12 @@ func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	// 生成会话令牌
 	token, err := h.generateToken()
 	if err != nil {
-		http.Error(w, "生成令牌失败", http.StatusInternalServerError)
+		sendJSONError(w, "生成令牌失败", http.StatusInternalServerError)
 		return
 	}
 
 	// 保存会话信息
-	sessionInfo := SessionInfo{
-		PlayerID:  playerID,
-		Username:  req.Username,
-		ExpiresAt: time.Now().Add(h.sessionTTL),
-	}
+	sessionInfo := h.newSession(playerID, req.Username)
 	h.setSession(token, sessionInfo)
 
 	// 返回成功响应
@@ -124,6 +165,7 @@ func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		PlayerID: playerID,
 		Username: req.Username,
 	}
+	resp.setExpiry(sessionInfo)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -131,29 +173,34 @@ func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 // handleRegister 处理注册请求
 func (h *AuthHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// 解析请求
 	var req RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求格式", http.StatusBadRequest)
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
 		return
 	}
 
 	// 验证请求
 	if req.Username == "" || req.Password == "" || req.Email == "" {
-		http.Error(w, "缺少必要参数", http.StatusBadRequest)
+		sendJSONError(w, "缺少必要参数", http.StatusBadRequest)
 		return
 	}
 
 	// 创建用户
-	playerID, err := h.createUser(req.Username, req.Password, req.Email)
+	playerID, err := h.players.Create(req.Username, hashPassword(req.Password), req.Email)
 	if err != nil {
 		// 返回错误响应
+		code := errcode.Of(err)
+		if code == "" {
+			code = errcode.Internal
+		}
 		resp := AuthResponse{
 			Success: false,
+			Code:    code,
 			Message: fmt.Sprintf("注册失败: %v", err),
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -164,16 +211,12 @@ func (h *AuthHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	// 生成会话令牌
 	token, err := h.generateToken()
 	if err != nil {
-		http.Error(w, "生成令牌失败", http.StatusInternalServerError)
+		sendJSONError(w, "生成令牌失败", http.StatusInternalServerError)
 		return
 	}
 
 	// 保存会话信息
-	sessionInfo := SessionInfo{
-		PlayerID:  playerID,
-		Username:  req.Username,
-		ExpiresAt: time.Now().Add(h.sessionTTL),
-	}
+	sessionInfo := h.newSession(playerID, req.Username)
 	h.setSession(token, sessionInfo)
 
 	// 返回成功响应
@@ -184,6 +227,7 @@ func (h *AuthHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		PlayerID: playerID,
 		Username: req.Username,
 	}
+	resp.setExpiry(sessionInfo)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
@@ -191,18 +235,15 @@ func (h *AuthHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
 // handleValidate 处理令牌验证请求
 func (h *AuthHandler) handleValidate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// 获取令牌
-	token := r.Header.Get("Authorization")
+	token := bearerToken(r)
 	if token == "" {
-		token = r.URL.Query().Get("token")
-		if token == "" {
-			http.Error(w, "未提供令牌", http.StatusBadRequest)
-			return
-		}
+		sendJSONError(w, "未提供令牌", http.StatusBadRequest)
+		return
 	}
 
 	// 验证令牌
@@ -213,9 +254,12 @@ func (h *AuthHandler) handleValidate(w http.ResponseWriter, r *http.Request) {
 			// 删除过期会话
 			h.deleteSession(token)
 		}
-		http.Error(w, "无效或已过期的令牌", http.StatusUnauthorized)
+		sendJSONErrorCode(w, errcode.AuthExpired, "无效或已过期的令牌", http.StatusUnauthorized)
 		return
 	}
+	if h.sliding {
+		session = h.renew(token, session, sessionRenewInterval)
+	}
 
 	// 返回成功响应
 	resp := AuthResponse{
@@ -224,92 +268,100 @@ func (h *AuthHandler) handleValidate(w http.ResponseWriter, r *http.Request) {
 		PlayerID: session.PlayerID,
 		Username: session.Username,
 	}
+	resp.setExpiry(session)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-// handleLogout 处理登出请求
-func (h *AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+// handleRefresh 续期会话，有效期从当前时间重新起算，不超过登录后的最长时间: POST /auth/refresh
+func (h *AuthHandler) handleRefresh(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// 获取令牌
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		token = r.URL.Query().Get("token")
-		if token == "" {
-			http.Error(w, "未提供令牌", http.StatusBadRequest)
-			return
+	token := bearerToken(r)
+	session, ok := h.getSession(token)
+	if token == "" || !ok || time.Now().After(session.ExpiresAt) {
+		if ok {
+			h.deleteSession(token)
 		}
+		h.sendAuthError(w, r)
+		return
 	}
+	session = h.renew(token, session, 0)
 
-	// 删除会话
-	h.deleteSession(token)
-
-	// 返回成功响应
 	resp := AuthResponse{
-		Success: true,
-		Message: "登出成功",
+		Success:  true,
+		Message:  "会话已续期",
+		PlayerID: session.PlayerID,
+		Username: session.Username,
 	}
+	resp.setExpiry(session)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-// validateCredentials 验证用户凭据
-func (h *AuthHandler) validateCredentials(username, password string) (int64, error) {
-	// 计算密码哈希
-	hashedPassword := hashPassword(password)
+// setExpiry 在响应中附带会话过期时间
+func (resp *AuthResponse) setExpiry(session SessionInfo) {
+	expiresAt := session.ExpiresAt
+	resp.ExpiresAt = &expiresAt
+	resp.ExpiresIn = int64(time.Until(expiresAt).Seconds())
+}
 
-	// 查询数据库
-	var playerID int64
-	err := db.DB.QueryRow("SELECT id FROM players WHERE username = $1 AND password = $2", username, hashedPassword).Scan(&playerID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, fmt.Errorf("用户名或密码错误")
-		}
-		return 0, fmt.Errorf("数据库查询错误: %w", err)
+// newSession 创建登录会话
+func (h *AuthHandler) newSession(playerID int64, username string) SessionInfo {
+	now := time.Now()
+	return SessionInfo{
+		PlayerID:  playerID,
+		Username:  username,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(h.sessionTTL),
 	}
-
-	return playerID, nil
 }
 
-// createUser 创建用户
-func (h *AuthHandler) createUser(username, password, email string) (int64, error) {
-	// 检查用户名是否已存在
-	var count int
-	err := db.DB.QueryRow("SELECT COUNT(*) FROM players WHERE username = $1", username).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("数据库查询错误: %w", err)
+// renew 将会话有效期从当前时间重新起算，不超过登录后的最长时间。
+// 延长的时间不足 minExtension 时不写入会话存储，返回原会话
+func (h *AuthHandler) renew(token string, session SessionInfo, minExtension time.Duration) SessionInfo {
+	expiresAt := time.Now().Add(h.sessionTTL)
+	if h.maxLifetime > 0 {
+		if limit := session.IssuedAt.Add(h.maxLifetime); expiresAt.After(limit) {
+			expiresAt = limit
+		}
 	}
-	if count > 0 {
-		return 0, fmt.Errorf("用户名已存在")
+	if !expiresAt.After(session.ExpiresAt) || expiresAt.Sub(session.ExpiresAt) < minExtension {
+		return session
 	}
 
-	// 检查邮箱是否已存在
-	err = db.DB.QueryRow("SELECT COUNT(*) FROM players WHERE email = $1", email).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("数据库查询错误: %w", err)
+	session.ExpiresAt = expiresAt
+	h.setSession(token, session)
+	return session
+}
+
+// handleLogout 处理登出请求
+func (h *AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
 	}
-	if count > 0 {
-		return 0, fmt.Errorf("邮箱已被使用")
+
+	// 获取令牌
+	token := bearerToken(r)
+	if token == "" {
+		sendJSONError(w, "未提供令牌", http.StatusBadRequest)
+		return
 	}
 
-	// 计算密码哈希
-	hashedPassword := hashPassword(password)
+	// 删除会话
+	h.deleteSession(token)
 
-	// 插入用户
-	var playerID int64
-	err = db.DB.QueryRow(
-		"INSERT INTO players (username, password, email, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id",
-		username, hashedPassword, email,
-	).Scan(&playerID)
-	if err != nil {
-		return 0, fmt.Errorf("创建用户失败: %w", err)
+	// 返回成功响应
+	resp := AuthResponse{
+		Success: true,
+		Message: "登出成功",
 	}
-
-	return playerID, nil
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 // generateToken 生成随机令牌
@@ -333,57 +385,121 @@ func hashPassword(password string) string {
 	return fmt.Sprintf("%x", hash)
 }
 
+// AuthenticatedHandlerFunc 需要登录的处理函数
+type AuthenticatedHandlerFunc func(w http.ResponseWriter, r *http.Request, session SessionInfo)
+
+// sessionContextKey 请求上下文中已校验的会话，限流中间件识别账号后保存，避免重复查询
+type sessionContextKey struct{}
+
+// withSession 在请求上下文中保存已校验的会话
+func withSession(r *http.Request, session SessionInfo) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session))
+}
+
+// Authenticate 从请求中解析令牌并校验会话
+func (h *AuthHandler) Authenticate(r *http.Request) (SessionInfo, bool) {
+	if session, ok := r.Context().Value(sessionContextKey{}).(SessionInfo); ok {
+		return session, true
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return SessionInfo{}, false
+	}
+
+	session, ok := h.getSession(token)
+	if !ok {
+		return SessionInfo{}, false
+	}
+	if time.Now().After(session.ExpiresAt) {
+		h.deleteSession(token)
+		return SessionInfo{}, false
+	}
+	if h.sliding {
+		session = h.renew(token, session, sessionRenewInterval)
+	}
+	return session, true
+}
+
+// RequireAuth 包装处理器，仅允许携带有效令牌的请求
+func (h *AuthHandler) RequireAuth(next AuthenticatedHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := h.Authenticate(r)
+		if !ok {
+			h.sendAuthError(w, r)
+			return
+		}
+		w.Header().Set(SessionExpiresHeader, session.ExpiresAt.UTC().Format(time.RFC3339))
+		next(w, r, session)
+	}
+}
+
+// sendAuthError 发送认证失败响应，未携带令牌时错误码为 UNAUTHORIZED，令牌无效或已过期时为 AUTH_EXPIRED
+func (h *AuthHandler) sendAuthError(w http.ResponseWriter, r *http.Request) {
+	code := errcode.AuthExpired
+	if bearerToken(r) == "" {
+		code = errcode.Unauthorized
+	}
+	sendJSONErrorCode(w, code, "未登录或登录已过期", http.StatusUnauthorized)
+}
+
+// bearerToken 从 Authorization 请求头或 token 查询参数中取出令牌
+func bearerToken(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return token
+}
+
 // setSession 设置会话信息
 func (h *AuthHandler) setSession(token string, session SessionInfo) {
 	if h.useRedis {
 		// 使用Redis存储
-		sessionKey := "session:" + token
-		sessionData := fmt.Sprintf("%d:%s:%d", session.PlayerID, session.Username, session.ExpiresAt.Unix())
-
-		err := db.RedisClient.Set(db.RedisClient.Context(), sessionKey, sessionData, h.sessionTTL).Err()
-		if err != nil {
-			// Redis失败时回退到内存存储
-			h.sessions[token] = session
+		if err := h.writeRedisSession(token, session); err != nil {
+			// Redis失败时回退到内存存储，恢复后由 maintainSessions 写回Redis
+			h.sessions.Set(token, session)
 		}
 	} else {
 		// 使用内存存储
-		h.sessions[token] = session
+		h.sessions.Set(token, session)
 	}
 }
 
+// writeRedisSession 将会话写入Redis，过期时间与会话一致。已过期的会话不写入
+func (h *AuthHandler) writeRedisSession(token string, session SessionInfo) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	sessionData := authsession.Encode(authsession.Record(session))
+	return db.RedisClient.Set(db.RedisClient.Context(), authsession.Key(token), sessionData, ttl).Err()
+}
+
 // getSession 获取会话信息
 func (h *AuthHandler) getSession(token string) (SessionInfo, bool) {
 	if h.useRedis {
 		// 从Redis获取
-		sessionKey := "session:" + token
-		sessionData, err := db.RedisClient.Get(db.RedisClient.Context(), sessionKey).Result()
+		sessionData, err := db.RedisClient.Get(db.RedisClient.Context(), authsession.Key(token)).Result()
 		if err != nil {
 			// Redis失败时尝试内存存储
-			session, ok := h.sessions[token]
-			return session, ok
+			return h.sessions.Get(token)
 		}
 
-		// 解析会话数据
-		parts := strings.Split(sessionData, ":")
-		if len(parts) != 3 {
+		// 解析会话数据，旧版本写入的会话没有登录时间
+		record, ok := authsession.Decode(sessionData)
+		if !ok {
 			return SessionInfo{}, false
 		}
-
-		playerID, _ := strconv.ParseInt(parts[0], 10, 64)
-		username := parts[1]
-		expiresAt, _ := strconv.ParseInt(parts[2], 10, 64)
-
-		session := SessionInfo{
-			PlayerID:  playerID,
-			Username:  username,
-			ExpiresAt: time.Unix(expiresAt, 0),
+		session := SessionInfo(record)
+		if session.IssuedAt.IsZero() {
+			session.IssuedAt = session.ExpiresAt.Add(-h.sessionTTL)
 		}
 
 		return session, true
 	} else {
 		// 从内存获取
-		session, ok := h.sessions[token]
-		return session, ok
+		return h.sessions.Get(token)
 	}
 }
 
@@ -391,12 +507,65 @@ func (h *AuthHandler) getSession(token string) (SessionInfo, bool) {
 func (h *AuthHandler) deleteSession(token string) {
 	if h.useRedis {
 		// 从Redis删除
-		sessionKey := "session:" + token
-		db.RedisClient.Del(db.RedisClient.Context(), sessionKey)
+		db.RedisClient.Del(db.RedisClient.Context(), authsession.Key(token))
 	}
 
 	// 同时从内存删除（如果存在）
-	delete(h.sessions, token)
+	h.sessions.Delete(token)
+}
+
+// maintainSessions 定期清理内存中过期的会话，Redis恢复后将暂存在内存中的会话写回Redis
+func (h *AuthHandler) maintainSessions(stop <-chan struct{}) {
+	ticker := time.NewTicker(sessionSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			expired, evicted := h.sessions.Sweep(time.Now())
+			if evicted > 0 {
+				log.Printf("内存会话超过上限，已淘汰 %d 个最久未使用的会话", evicted)
+			}
+			if expired > 0 {
+				log.Printf("清理内存中过期的会话 %d 个", expired)
+			}
+			if h.useRedis {
+				h.reconcileSessions()
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// reconcileSessions 将Redis不可用期间暂存在内存中的会话写回Redis，写回后从内存删除
+func (h *AuthHandler) reconcileSessions() {
+	pending := h.sessions.Snapshot()
+	if len(pending) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := db.RedisClient.Ping(ctx).Err(); err != nil {
+		return
+	}
+
+	moved := 0
+	for _, s := range pending {
+		if err := h.writeRedisSession(s.token, s.session); err != nil {
+			log.Printf("会话写回Redis失败，保留在内存中: %v", err)
+			break
+		}
+		// 写回期间被续期的会话保留到下一轮，已登出的会话同时从Redis删除
+		if h.sessions.DeleteIfUnchanged(s.token, s.session) {
+			moved++
+		} else if _, ok := h.sessions.Get(s.token); !ok {
+			db.RedisClient.Del(db.RedisClient.Context(), authsession.Key(s.token))
+		}
+	}
+	if moved > 0 {
+		log.Printf("Redis已恢复，%d 个内存会话已写回Redis", moved)
+	}
 }
 
 // ValidateToken 验证令牌（供其他模块使用）
@@ -0,0 +1,196 @@
+// avatar.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/avatar"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/storage"
+)
+
+// multipartOverhead 上传请求中除文件内容外允许的额外字节数
+const multipartOverhead = 16 * 1024
+
+// AvatarHandler 头像处理器
+type AvatarHandler struct {
+	avatars *avatar.Service
+	store   storage.Storage
+	auth    *AuthHandler
+}
+
+// NewAvatarHandler 创建头像处理器
+func NewAvatarHandler(auth *AuthHandler, store storage.Storage, svc *avatar.Service) *AvatarHandler {
+	return &AvatarHandler{
+		avatars: svc,
+		store:   store,
+		auth:    auth,
+	}
+}
+
+// SelectAvatarRequest 选择预设头像请求
+type SelectAvatarRequest struct {
+	AvatarID int `json:"avatar_id"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *AvatarHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/avatars", h.handleCatalog)
+
+	// 本地存储时由网关提供上传文件访问
+	if local, ok := h.store.(*storage.LocalStorage); ok {
+		mux.Handle(storage.LocalMountPath, local.Handler())
+	}
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *AvatarHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("avatar", h.handlePlayerAvatar)
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *AvatarHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/avatars", admin.Wrap(h.handleAdminAvatars))
+}
+
+// handleCatalog 查询可选的预设头像
+func (h *AvatarHandler) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	avatars, err := avatar.Catalog(true)
+	if err != nil {
+		log.Printf("查询头像目录失败: %v", err)
+		sendJSONError(w, "查询头像目录失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", avatars)
+}
+
+// handlePlayerAvatar GET 查询头像，PUT 选择预设头像，POST 上传自定义头像（仅限本人）
+func (h *AvatarHandler) handlePlayerAvatar(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method == http.MethodGet {
+		pa, err := avatar.Get(playerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 头像失败: %v", playerID, err)
+			sendJSONError(w, "查询头像失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", pa)
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持GET、PUT和POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+	if session.PlayerID != playerID {
+		sendJSONError(w, "只能修改自己的头像", http.StatusForbidden)
+		return
+	}
+
+	var pa *models.PlayerAvatar
+	var err error
+	if r.Method == http.MethodPut {
+		var req SelectAvatarRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AvatarID <= 0 {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		pa, err = h.avatars.Select(playerID, req.AvatarID)
+	} else {
+		data, ok := h.readUpload(w, r)
+		if !ok {
+			return
+		}
+		pa, err = h.avatars.Upload(playerID, data)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, avatar.ErrAvatarNotFound):
+			sendServiceError(w, err, http.StatusNotFound)
+		case errors.Is(err, avatar.ErrAvatarLocked):
+			sendServiceError(w, err, http.StatusForbidden)
+		case errors.Is(err, avatar.ErrImageTooLarge):
+			sendServiceError(w, err, http.StatusRequestEntityTooLarge)
+		case errors.Is(err, avatar.ErrInvalidImage):
+			sendServiceError(w, err, http.StatusUnsupportedMediaType)
+		default:
+			log.Printf("更新玩家 %d 头像失败: %v", playerID, err)
+			sendJSONError(w, "更新头像失败", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	sendJSONSuccess(w, "头像已更新", pa)
+}
+
+// readUpload 读取 multipart 表单中的 file 字段，超过大小上限时返回413
+func (h *AvatarHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	maxSize := h.avatars.MaxUploadSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+multipartOverhead)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			sendJSONError(w, avatar.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			sendJSONError(w, "请通过 file 字段上传图片", http.StatusBadRequest)
+		}
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		sendJSONError(w, "读取上传文件失败", http.StatusBadRequest)
+		return nil, false
+	}
+	return data, true
+}
+
+// handleAdminAvatars 管理预设头像: GET 全部, POST 添加
+func (h *AvatarHandler) handleAdminAvatars(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		avatars, err := avatar.Catalog(false)
+		if err != nil {
+			log.Printf("查询头像目录失败: %v", err)
+			sendJSONError(w, "查询头像目录失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", avatars)
+	case http.MethodPost:
+		var a models.Avatar
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := avatar.CreateAvatar(&a); err != nil {
+			if errors.Is(err, avatar.ErrInvalidAvatar) {
+				sendServiceError(w, err, http.StatusBadRequest)
+				return
+			}
+			log.Printf("添加头像失败: %v", err)
+			sendJSONError(w, "添加头像失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "添加成功", a)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
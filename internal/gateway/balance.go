@@ -0,0 +1,125 @@
+// balance.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/balance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// BalanceHandler 游戏平衡配置管理处理器
+type BalanceHandler struct{}
+
+// NewBalanceHandler 创建游戏平衡配置管理处理器
+func NewBalanceHandler() *BalanceHandler {
+	return &BalanceHandler{}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *BalanceHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/balance", admin.Wrap(h.handleStatus))
+	mux.HandleFunc("/admin/balance/push", admin.Wrap(h.handlePush))
+	mux.HandleFunc("/admin/balance/", admin.Wrap(h.handleMode))
+}
+
+// handleStatus 查看保存的和当前生效的平衡配置: GET /admin/balance
+func (h *BalanceHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	status, err := balance.Status()
+	if err != nil {
+		h.sendError(w, "获取平衡配置失败", err)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", status)
+}
+
+// handlePush 推送平衡配置，所有实例之后创建的房间立即使用新配置: POST /admin/balance/push
+func (h *BalanceHandler) handlePush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+	status, err := balance.Push()
+	if err != nil {
+		h.sendError(w, "推送平衡配置失败", err)
+		return
+	}
+	log.Printf("管理员推送平衡配置，共 %d 条配置", len(status.Saved))
+	sendJSONSuccess(w, "推送成功，新创建的房间将使用新配置", status)
+}
+
+// handleMode 保存模式的平衡配置: PUT /admin/balance/{mode}；删除: DELETE /admin/balance/{mode}。
+// mode 为 default 时对所有模式生效，修改在推送后生效
+func (h *BalanceHandler) handleMode(w http.ResponseWriter, r *http.Request) {
+	mode := strings.TrimPrefix(r.URL.Path, "/admin/balance/")
+	if mode == "" || strings.Contains(mode, "/") {
+		sendJSONError(w, "无效的游戏模式", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPut || r.Method == http.MethodDelete {
+		h.auditSaved(r, mode)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var cfg models.BalanceConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		cfg.Mode = mode
+		saved, err := balance.Save(&cfg)
+		if err != nil {
+			h.sendError(w, "保存平衡配置失败", err)
+			return
+		}
+		log.Printf("管理员保存模式 %s 的平衡配置", mode)
+		sendJSONSuccess(w, "保存成功，推送后生效", saved)
+	case http.MethodDelete:
+		if err := balance.Delete(mode); err != nil {
+			h.sendError(w, "删除平衡配置失败", err)
+			return
+		}
+		log.Printf("管理员删除模式 %s 的平衡配置", mode)
+		sendJSONSuccess(w, "删除成功，推送后生效", nil)
+	default:
+		sendJSONError(w, "仅支持PUT和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// auditSaved 记录模式修改前保存的平衡配置，供审计日志计算变更
+func (h *BalanceHandler) auditSaved(r *http.Request, mode string) {
+	status, err := balance.Status()
+	if err != nil {
+		return
+	}
+	for _, cfg := range status.Saved {
+		if cfg.Mode == mode {
+			auditBefore(r, cfg)
+			return
+		}
+	}
+}
+
+// sendError 按错误类型返回平衡配置错误
+func (h *BalanceHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, balance.ErrInvalidBalance):
+		sendServiceError(w, err, http.StatusBadRequest)
+	case errors.Is(err, balance.ErrBalanceNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,180 @@
+// battlepass.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/battlepass"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// BattlePassHandler 通行证处理器
+type BattlePassHandler struct {
+	battlePass *battlepass.Service
+	auth       *AuthHandler
+}
+
+// NewBattlePassHandler 创建通行证处理器
+func NewBattlePassHandler(auth *AuthHandler) *BattlePassHandler {
+	return &BattlePassHandler{
+		battlePass: battlepass.NewService(wallet.NewService()),
+		auth:       auth,
+	}
+}
+
+// ClaimRewardRequest 领取通行证奖励请求
+type ClaimRewardRequest struct {
+	Tier  int              `json:"tier"`
+	Track models.PassTrack `json:"track"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *BattlePassHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/battlepass/claim", h.auth.RequireAuth(h.handleClaim))
+	mux.HandleFunc("/battlepass/premium", h.auth.RequireAuth(h.handlePremium))
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *BattlePassHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("battlepass", h.handlePlayerBattlePass)
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *BattlePassHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/battlepass/seasons", admin.Wrap(h.handleAdminSeasons))
+	mux.HandleFunc("/admin/battlepass/rewards", admin.Wrap(h.handleAdminRewards))
+}
+
+// handlePlayerBattlePass 查询玩家当前赛季通行证进度
+func (h *BattlePassHandler) handlePlayerBattlePass(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err := h.battlePass.PlayerState(playerID)
+	if err != nil {
+		h.sendError(w, "查询通行证进度失败", err)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", state)
+}
+
+// handleClaim 领取通行证等级奖励
+func (h *BattlePassHandler) handleClaim(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ClaimRewardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if req.Tier <= 0 {
+		sendJSONError(w, "无效的等级", http.StatusBadRequest)
+		return
+	}
+	if req.Track == "" {
+		req.Track = models.PassTrackFree
+	}
+
+	reward, err := h.battlePass.Claim(session.PlayerID, req.Tier, req.Track)
+	if err != nil {
+		h.sendError(w, "领取奖励失败", err)
+		return
+	}
+
+	log.Printf("玩家 %d 领取通行证奖励: 赛季 %d 等级 %d (%s)", session.PlayerID, reward.SeasonID, reward.Tier, reward.Track)
+	sendJSONSuccess(w, "领取成功", reward)
+}
+
+// handlePremium 解锁当前赛季高级通行证
+func (h *BattlePassHandler) handlePremium(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err := h.battlePass.UnlockPremium(session.PlayerID)
+	if err != nil {
+		h.sendError(w, "解锁高级通行证失败", err)
+		return
+	}
+
+	log.Printf("玩家 %d 解锁赛季 %d 高级通行证", session.PlayerID, state.Season.ID)
+	sendJSONSuccess(w, "解锁成功", state)
+}
+
+// handleAdminSeasons 管理赛季: GET 列表, POST 创建
+func (h *BattlePassHandler) handleAdminSeasons(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		seasons, err := h.battlePass.ListSeasons()
+		if err != nil {
+			h.sendError(w, "查询赛季失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", seasons)
+	case http.MethodPost:
+		var season models.BattlePassSeason
+		if err := json.NewDecoder(r.Body).Decode(&season); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := h.battlePass.CreateSeason(&season); err != nil {
+			h.sendError(w, "创建赛季失败", err)
+			return
+		}
+		sendJSONSuccess(w, "创建成功", season)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminRewards 设置赛季等级奖励
+func (h *BattlePassHandler) handleAdminRewards(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var reward models.BattlePassReward
+	if err := json.NewDecoder(r.Body).Decode(&reward); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if err := h.battlePass.SetReward(&reward); err != nil {
+		h.sendError(w, "设置奖励失败", err)
+		return
+	}
+
+	sendJSONSuccess(w, "设置成功", reward)
+}
+
+// sendError 按错误类型返回通行证错误
+func (h *BattlePassHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, battlepass.ErrNoActiveSeason), errors.Is(err, battlepass.ErrRewardNotFound),
+		errors.Is(err, wallet.ErrPlayerNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, battlepass.ErrTierNotReached), errors.Is(err, battlepass.ErrPremiumRequired):
+		sendServiceError(w, err, http.StatusForbidden)
+	case errors.Is(err, battlepass.ErrAlreadyClaimed), errors.Is(err, battlepass.ErrAlreadyPremium),
+		errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, inventory.ErrAlreadyOwned):
+		sendServiceError(w, err, http.StatusConflict)
+	case errors.Is(err, battlepass.ErrInvalidSeason):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,91 @@
+// bookmark.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/bookmark"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// BookmarkHandler 对局收藏处理器
+type BookmarkHandler struct {
+	auth *AuthHandler
+}
+
+// NewBookmarkHandler 创建对局收藏处理器
+func NewBookmarkHandler(auth *AuthHandler) *BookmarkHandler {
+	return &BookmarkHandler{auth: auth}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *BookmarkHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/bookmarks", h.auth.RequireAuth(h.handleBookmarks))
+	mux.HandleFunc("/bookmarks/", h.auth.RequireAuth(h.handleRemove))
+}
+
+// handleBookmarks GET 查询收藏的对局，POST 收藏对局
+func (h *BookmarkHandler) handleBookmarks(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		bookmarks, err := bookmark.List(session.PlayerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 收藏的对局失败: %v", session.PlayerID, err)
+			sendJSONError(w, "查询收藏失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", bookmarks)
+	case http.MethodPost:
+		var req models.BookmarkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if req.MatchID == "" {
+			sendJSONError(w, "缺少对局ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := bookmark.Add(session.PlayerID, req.MatchID); err != nil {
+			switch {
+			case errors.Is(err, bookmark.ErrMatchNotFound):
+				sendServiceError(w, err, http.StatusNotFound)
+			case errors.Is(err, bookmark.ErrBookmarkLimit):
+				sendServiceError(w, err, http.StatusConflict)
+			default:
+				log.Printf("玩家 %d 收藏对局 %s 失败: %v", session.PlayerID, req.MatchID, err)
+				sendJSONError(w, "收藏失败", http.StatusInternalServerError)
+			}
+			return
+		}
+		sendJSONSuccess(w, "收藏成功", req)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRemove 取消收藏: DELETE /bookmarks/{match_id}
+func (h *BookmarkHandler) handleRemove(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, "仅支持DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	matchID := strings.TrimPrefix(r.URL.Path, "/bookmarks/")
+	if matchID == "" {
+		sendJSONError(w, "缺少对局ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := bookmark.Remove(session.PlayerID, matchID); err != nil {
+		log.Printf("玩家 %d 取消收藏对局 %s 失败: %v", session.PlayerID, matchID, err)
+		sendJSONError(w, "取消收藏失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "已取消收藏", nil)
+}
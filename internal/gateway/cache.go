@@ -8,7 +8,11 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
 )
 
 // CacheEntry 缓存条目
@@ -53,24 +57,59 @@ type CacheMiddleware struct {
 	CacheablePaths []string
 	// 缓存时间配置
 	CacheTTL map[string]time.Duration
+
+	// 命中统计
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// CacheStats 响应缓存的命中统计
+type CacheStats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // 命中率，没有请求时为0
+	Entries int     `json:"entries"`
+}
+
+// Stats 获取缓存命中统计
+func (cm *CacheMiddleware) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:    cm.hits.Load(),
+		Misses:  cm.misses.Load(),
+		Entries: cm.cache.Len(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
 }
 
 // NewCacheMiddleware 创建缓存中间件
 func NewCacheMiddleware() *CacheMiddleware {
-	return &CacheMiddleware{
+	cm := &CacheMiddleware{
 		cache: NewMemoryCache(),
 		CacheablePaths: []string{
 			"/characters",
 			"/stats/leaderboard",
+			"/stats/characters",
+			"/stats/maps",
 			"/players/characters/",
 			"/players/default-character/",
 		},
 		CacheTTL: map[string]time.Duration{
 			"/characters":        10 * time.Minute, // 角色信息缓存10分钟
 			"/stats/leaderboard": 2 * time.Minute,  // 排行榜缓存2分钟
+			"/stats/characters":  5 * time.Minute,  // 角色分析数据缓存5分钟
+			"/stats/maps":        5 * time.Minute,  // 地图分析数据缓存5分钟
 			"/players/":          1 * time.Minute,  // 玩家信息缓存1分钟
 		},
 	}
+
+	metrics.Default.CounterFunc("pixelstorm_gateway_cache_hits_total", "网关响应缓存命中次数",
+		func() float64 { return float64(cm.hits.Load()) })
+	metrics.Default.CounterFunc("pixelstorm_gateway_cache_misses_total", "网关响应缓存未命中次数",
+		func() float64 { return float64(cm.misses.Load()) })
+	return cm
 }
 
 // Middleware 缓存中间件
@@ -93,6 +132,7 @@ func (cm *CacheMiddleware) Middleware(next http.Handler) http.Handler {
 		
 		// 检查缓存
 		if entry := cm.cache.Get(cacheKey); entry != nil {
+			cm.hits.Add(1)
 			// 检查ETag
 			if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
 				if ifNoneMatch == entry.ETag {
@@ -106,6 +146,8 @@ func (cm *CacheMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 		
+		cm.misses.Add(1)
+
 		// 创建响应捕获器
 		recorder := &cacheResponseRecorder{
 			ResponseWriter: w,
@@ -155,7 +197,8 @@ func (cm *CacheMiddleware) generateCacheKey(r *http.Request) string {
 	if r.URL.RawQuery != "" {
 		key += "?" + r.URL.RawQuery
 	}
-	return key
+	// 不同语言的响应提示不同，分别缓存
+	return key + "#" + string(i18n.FromRequest(r))
 }
 
 // getTTL 获取缓存时间
@@ -214,6 +257,13 @@ func (mc *MemoryCache) Get(key string) *CacheEntry {
 	return entry
 }
 
+// Len 获取缓存条目数
+func (mc *MemoryCache) Len() int {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+	return len(mc.entries)
+}
+
 // Set 设置缓存条目
 func (mc *MemoryCache) Set(key string, entry *CacheEntry) {
 	mc.mutex.Lock()
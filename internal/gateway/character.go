@@ -3,24 +3,33 @@
 package gateway
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
-	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
 )
 
 // CharacterHandler 角色处理器
-type CharacterHandler struct{}
+type CharacterHandler struct {
+	characters repository.CharacterRepo
+	levelCurve *progression.Curve
+	trials     *trial.Service
+}
 
 // NewCharacterHandler 创建角色处理器
-func NewCharacterHandler() *CharacterHandler {
-	return &CharacterHandler{}
+func NewCharacterHandler(characters repository.CharacterRepo, levelCurve *progression.Curve, trials *trial.Service) *CharacterHandler {
+	return &CharacterHandler{characters: characters, levelCurve: levelCurve, trials: trials}
 }
 
 // RegisterHandlers 注册HTTP处理器
@@ -35,6 +44,7 @@ func (h *CharacterHandler) RegisterHandlers(mux *http.ServeMux) {
 // CharacterResponse 角色响应
 type CharacterResponse struct {
 	Success bool                `json:"success"`
+	Code    errcode.Code        `json:"code,omitempty"`
 	Message string              `json:"message"`
 	Data    interface{}         `json:"data"`
 }
@@ -59,7 +69,7 @@ func (h *CharacterHandler) handleCharacters(w http.ResponseWriter, r *http.Reque
 	}
 
 	// 查询所有角色
-	characters, err := h.getAllCharacters()
+	characters, err := h.characters.All()
 	if err != nil {
 		log.Printf("查询角色列表失败: %v", err)
 		h.sendErrorResponse(w, "查询角色列表失败", http.StatusInternalServerError)
@@ -85,10 +95,10 @@ func (h *CharacterHandler) handleCharacterDetail(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// 查询角色详情
-	character, err := h.getCharacterByID(characterID)
+	// 查询带技能的角色详情，技能查询失败不影响角色信息返回
+	character, err := gamedata.CharacterDetail(h.characters, characterID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNotFound) {
 			h.sendErrorResponse(w, "角色不存在", http.StatusNotFound)
 			return
 		}
@@ -97,15 +107,6 @@ func (h *CharacterHandler) handleCharacterDetail(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// 查询角色技能
-	skills, err := h.getCharacterSkills(characterID)
-	if err != nil {
-		log.Printf("查询角色技能失败: %v", err)
-		// 技能查询失败不影响角色信息返回，只记录日志
-	} else {
-		character.Skills = skills
-	}
-
 	// 返回成功响应
 	h.sendSuccessResponse(w, "查询成功", character)
 }
@@ -189,7 +190,7 @@ func (h *CharacterHandler) handlePlayerCharacters(w http.ResponseWriter, r *http
 // handleGetPlayerCharacters 处理获取玩家角色列表
 func (h *CharacterHandler) handleGetPlayerCharacters(w http.ResponseWriter, r *http.Request, playerID int64) {
 	// 查询玩家已解锁的角色
-	characters, err := h.getPlayerCharacters(playerID)
+	characters, err := h.playerCharacters(playerID)
 	if err != nil {
 		log.Printf("查询玩家角色失败: %v", err)
 		h.sendErrorResponse(w, "查询玩家角色失败", http.StatusInternalServerError)
@@ -215,22 +216,13 @@ func (h *CharacterHandler) handleSetDefaultCharacter(w http.ResponseWriter, r *h
 		return
 	}
 
-	// 检查玩家是否拥有该角色
-	hasCharacter, err := h.checkPlayerHasCharacter(playerID, req.CharacterID)
-	if err != nil {
-		log.Printf("检查玩家角色失败: %v", err)
-		h.sendErrorResponse(w, "检查玩家角色失败", http.StatusInternalServerError)
-		return
-	}
-
-	if !hasCharacter {
-		h.sendErrorResponse(w, "玩家未拥有该角色", http.StatusBadRequest)
-		return
-	}
-
 	// 设置默认角色
-	err = h.setPlayerDefaultCharacter(playerID, req.CharacterID)
+	err := h.characters.SetDefaultCharacter(playerID, req.CharacterID)
 	if err != nil {
+		if errors.Is(err, repository.ErrCharacterNotOwned) {
+			sendServiceError(w, err, http.StatusBadRequest)
+			return
+		}
 		log.Printf("设置默认角色失败: %v", err)
 		h.sendErrorResponse(w, "设置默认角色失败", http.StatusInternalServerError)
 		return
@@ -243,9 +235,9 @@ func (h *CharacterHandler) handleSetDefaultCharacter(w http.ResponseWriter, r *h
 // handleGetDefaultCharacter 处理获取默认角色
 func (h *CharacterHandler) handleGetDefaultCharacter(w http.ResponseWriter, r *http.Request, playerID int64) {
 	// 查询玩家默认角色
-	characterID, err := h.getPlayerDefaultCharacter(playerID)
+	characterID, err := h.characters.DefaultCharacter(playerID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNotFound) {
 			h.sendErrorResponse(w, "玩家未设置默认角色", http.StatusNotFound)
 			return
 		}
@@ -255,7 +247,7 @@ func (h *CharacterHandler) handleGetDefaultCharacter(w http.ResponseWriter, r *h
 	}
 
 	// 查询角色详情
-	character, err := h.getCharacterByID(characterID)
+	character, err := h.characters.GetByID(characterID)
 	if err != nil {
 		log.Printf("查询角色详情失败: %v", err)
 		h.sendErrorResponse(w, "查询角色详情失败", http.StatusInternalServerError)
@@ -270,7 +262,7 @@ func (h *CharacterHandler) handleGetDefaultCharacter(w http.ResponseWriter, r *h
 func (h *CharacterHandler) sendSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
 	resp := CharacterResponse{
 		Success: true,
-		Message: message,
+		Message: localize(w, "", message),
 		Data:    data,
 	}
 	
@@ -285,7 +277,8 @@ func (h *CharacterHandler) sendSuccessResponse(w http.ResponseWriter, message st
 func (h *CharacterHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	resp := CharacterResponse{
 		Success: false,
-		Message: message,
+		Code:    errcode.FromStatus(statusCode),
+		Message: localize(w, errcode.FromStatus(statusCode), message),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -295,214 +288,48 @@ func (h *CharacterHandler) sendErrorResponse(w http.ResponseWriter, message stri
 	}
 }
 
-// 数据库查询方法
-
-// getAllCharacters 获取所有角色
-func (h *CharacterHandler) getAllCharacters() ([]models.Character, error) {
-	query := `
-		SELECT id, name, description, max_hp, speed, base_attack, base_defense,
-		       special_ability, difficulty, role, unlockable, unlock_cost
-		FROM characters
-		ORDER BY id
-	`
-
-	rows, err := db.DB.Query(query)
+// playerCharacters 获取玩家已解锁的角色和试玩中的角色
+func (h *CharacterHandler) playerCharacters(playerID int64) ([]models.Character, error) {
+	owned, err := h.characters.PlayerCharacters(playerID)
 	if err != nil {
-		return nil, fmt.Errorf("查询角色失败: %w", err)
-	}
-	defer rows.Close()
-
-	var characters []models.Character
-	for rows.Next() {
-		var char models.Character
-		err := rows.Scan(
-			&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
-			&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
-			&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("扫描角色数据失败: %w", err)
-		}
-		characters = append(characters, char)
+		return nil, err
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历角色数据失败: %w", err)
+	characters := make([]models.Character, 0, len(owned))
+	for _, char := range owned {
+		char.Progress = h.levelCurve.Progress(char.Level, char.Exp)
+		characters = append(characters, char.Character)
 	}
 
-	return characters, nil
-}
-
-// getCharacterByID 根据ID获取角色
-func (h *CharacterHandler) getCharacterByID(characterID int) (*models.Character, error) {
-	query := `
-		SELECT id, name, description, max_hp, speed, base_attack, base_defense,
-		       special_ability, difficulty, role, unlockable, unlock_cost
-		FROM characters
-		WHERE id = $1
-	`
-
-	var char models.Character
-	err := db.DB.QueryRow(query, characterID).Scan(
-		&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
-		&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
-		&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
-	)
-
+	// 试玩中的角色附带到期时间，等级进度按初始值展示
+	trials, err := h.trials.Active(playerID)
 	if err != nil {
 		return nil, err
 	}
-
-	return &char, nil
-}
-
-// getCharacterSkills 获取角色技能
-func (h *CharacterHandler) getCharacterSkills(characterID int) ([]models.Skill, error) {
-	query := `
-		SELECT s.id, s.name, s.description, s.type, s.damage, s.cooldown_time,
-		       s.range, s.effect_time, s.projectile_speed, s.projectile_count,
-		       s.projectile_spread, s.animation_key, s.effect_key
-		FROM skills s
-		INNER JOIN character_skills cs ON s.id = cs.skill_id
-		WHERE cs.character_id = $1
-		ORDER BY cs.slot_index, s.id
-	`
-
-	rows, err := db.DB.Query(query, characterID)
-	if err != nil {
-		return nil, fmt.Errorf("查询角色技能失败: %w", err)
-	}
-	defer rows.Close()
-
-	var skills []models.Skill
-	for rows.Next() {
-		var skill models.Skill
-		var projectileSpeed, projectileSpread sql.NullFloat64
-		var projectileCount sql.NullInt64
-		var animationKey, effectKey sql.NullString
-
-		err := rows.Scan(
-			&skill.ID, &skill.Name, &skill.Description, &skill.Type, &skill.Damage,
-			&skill.CooldownTime, &skill.Range, &skill.EffectTime,
-			&projectileSpeed, &projectileCount, &projectileSpread,
-			&animationKey, &effectKey,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("扫描技能数据失败: %w", err)
-		}
-
-		// 处理可空字段
-		if projectileSpeed.Valid {
-			skill.ProjectileSpeed = projectileSpeed.Float64
+	for i := range trials {
+		char, err := h.characters.GetByID(trials[i].CharacterID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
 		}
-		if projectileCount.Valid {
-			skill.ProjectileCount = int(projectileCount.Int64)
-		}
-		if projectileSpread.Valid {
-			skill.ProjectileSpread = projectileSpread.Float64
-		}
-		if animationKey.Valid {
-			skill.AnimationKey = animationKey.String
-		}
-		if effectKey.Valid {
-			skill.EffectKey = effectKey.String
+		if err != nil {
+			return nil, fmt.Errorf("查询试玩角色失败: %w", err)
 		}
-
-		skills = append(skills, skill)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历技能数据失败: %w", err)
+		char.Progress = h.levelCurve.Progress(1, 0)
+		char.Trial = &trials[i]
+		characters = append(characters, *char)
 	}
 
-	return skills, nil
-}
-
-// getPlayerCharacters 获取玩家已解锁的角色
-func (h *CharacterHandler) getPlayerCharacters(playerID int64) ([]models.Character, error) {
-	query := `
-		SELECT c.id, c.name, c.description, c.max_hp, c.speed, c.base_attack,
-		       c.base_defense, c.special_ability, c.difficulty, c.role,
-		       c.unlockable, c.unlock_cost
-		FROM characters c
-		INNER JOIN player_characters pc ON c.id = pc.character_id
-		WHERE pc.player_id = $1
-		ORDER BY c.id
-	`
-
-	rows, err := db.DB.Query(query, playerID)
-	if err != nil {
-		return nil, fmt.Errorf("查询玩家角色失败: %w", err)
-	}
-	defer rows.Close()
-
-	var characters []models.Character
-	for rows.Next() {
-		var char models.Character
-		err := rows.Scan(
-			&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
-			&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
-			&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
-		)
+	// 补充等级不足尚未解锁的技能
+	for i := range characters {
+		locked, err := progression.LockedSkills(playerID, characters[i].ID)
 		if err != nil {
-			return nil, fmt.Errorf("扫描玩家角色数据失败: %w", err)
+			return nil, err
 		}
-		characters = append(characters, char)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历玩家角色数据失败: %w", err)
+		for skillID := range locked {
+			characters[i].Progress.LockedSkillIDs = append(characters[i].Progress.LockedSkillIDs, skillID)
+		}
+		sort.Ints(characters[i].Progress.LockedSkillIDs)
 	}
 
 	return characters, nil
 }
-
-// checkPlayerHasCharacter 检查玩家是否拥有指定角色
-func (h *CharacterHandler) checkPlayerHasCharacter(playerID int64, characterID int) (bool, error) {
-	query := `
-		SELECT COUNT(1) FROM player_characters
-		WHERE player_id = $1 AND character_id = $2
-	`
-
-	var count int
-	err := db.DB.QueryRow(query, playerID, characterID).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("检查玩家角色失败: %w", err)
-	}
-
-	return count > 0, nil
-}
-
-// getPlayerDefaultCharacter 获取玩家默认角色ID
-func (h *CharacterHandler) getPlayerDefaultCharacter(playerID int64) (int, error) {
-	query := `
-		SELECT character_id FROM player_default_characters
-		WHERE player_id = $1
-	`
-
-	var characterID int
-	err := db.DB.QueryRow(query, playerID).Scan(&characterID)
-	if err != nil {
-		return 0, err
-	}
-
-	return characterID, nil
-}
-
-// setPlayerDefaultCharacter 设置玩家默认角色
-func (h *CharacterHandler) setPlayerDefaultCharacter(playerID int64, characterID int) error {
-	// 使用 UPSERT 语法（PostgreSQL）
-	query := `
-		INSERT INTO player_default_characters (player_id, character_id)
-		VALUES ($1, $2)
-		ON CONFLICT (player_id)
-		DO UPDATE SET character_id = EXCLUDED.character_id
-	`
-
-	_, err := db.DB.Exec(query, playerID, characterID)
-	if err != nil {
-		return fmt.Errorf("设置默认角色失败: %w", err)
-	}
-
-	return nil
-}
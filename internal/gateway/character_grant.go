@@ -0,0 +1,396 @@
+// character_grant.go
+
+package gateway
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/audit"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 批量角色发放任务状态
+const (
+	GrantJobRunning   = "running"
+	GrantJobCompleted = "completed"
+	GrantJobFailed    = "failed"
+	GrantJobCancelled = "cancelled"
+)
+
+const (
+	// 每批处理的玩家数
+	grantBatchSize = 500
+	// 指定玩家时单次任务的最大玩家数，更多玩家使用 all_players
+	maxGrantPlayers = 100000
+	// 单次任务的最大角色数
+	maxGrantCharacters = 50
+	// 批次间隔，降低批量写入对数据库的压力
+	grantBatchPause = 100 * time.Millisecond
+
+	// grantJobAuditMethod 任务结束时写入审计日志的方法标识
+	grantJobAuditMethod = "JOB"
+)
+
+// CharacterGrantJob 批量发放（或收回）角色任务
+type CharacterGrantJob struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	Actor        string     `json:"actor"`
+	CharacterIDs []int      `json:"character_ids"`
+	Revoke       bool       `json:"revoke"`      // 收回角色而不是发放
+	AllPlayers   bool       `json:"all_players"` // 作用于全部玩家
+	TotalPlayers int        `json:"total_players"`
+	Processed    int        `json:"processed"` // 已处理的玩家数
+	Changed      int        `json:"changed"`   // 实际新增或删除的玩家角色记录数
+	Progress     float64    `json:"progress"`  // 进度百分比
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Error        string     `json:"error,omitempty"`
+
+	playerIDs []int64
+	cancel    chan struct{}
+}
+
+// CharacterGrantRequest 批量发放角色请求，PlayerIDs 与 AllPlayers 二选一
+type CharacterGrantRequest struct {
+	CharacterIDs []int   `json:"character_ids"`
+	PlayerIDs    []int64 `json:"player_ids,omitempty"`
+	AllPlayers   bool    `json:"all_players,omitempty"`
+	Revoke       bool    `json:"revoke,omitempty"`
+}
+
+// CharacterGrantHandler 批量角色发放管理处理器
+type CharacterGrantHandler struct {
+	characters repository.CharacterRepo
+
+	mutex sync.Mutex
+	job   *CharacterGrantJob
+}
+
+// NewCharacterGrantHandler 创建批量角色发放管理处理器
+func NewCharacterGrantHandler(characters repository.CharacterRepo) *CharacterGrantHandler {
+	return &CharacterGrantHandler{characters: characters}
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *CharacterGrantHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/characters/grants", admin.Wrap(h.handleGrants))
+}
+
+// handleGrants GET 查询当前（或最近一次）任务，POST 启动任务，DELETE 取消任务
+func (h *CharacterGrantHandler) handleGrants(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		job := h.snapshot()
+		if job == nil {
+			sendJSONError(w, "没有角色发放任务", http.StatusNotFound)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", job)
+	case http.MethodPost:
+		var req CharacterGrantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := h.validate(&req); err != nil {
+			sendJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		actor := r.Header.Get(AdminActorHeader)
+		if actor == "" {
+			actor = defaultAuditActor
+		}
+		job, err := h.start(req, actor)
+		if err != nil {
+			sendJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		sendJSONSuccess(w, "角色发放任务已启动", job)
+	case http.MethodDelete:
+		if !h.stop() {
+			sendJSONError(w, "没有正在运行的角色发放任务", http.StatusNotFound)
+			return
+		}
+		sendJSONSuccess(w, "角色发放任务已取消", h.snapshot())
+	default:
+		sendJSONError(w, "仅支持GET、POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// validate 检查请求参数和角色是否存在，并去重排序角色和玩家ID
+func (h *CharacterGrantHandler) validate(req *CharacterGrantRequest) error {
+	if len(req.CharacterIDs) == 0 || len(req.CharacterIDs) > maxGrantCharacters {
+		return fmt.Errorf("角色数量必须在 1 到 %d 之间", maxGrantCharacters)
+	}
+	if req.AllPlayers == (len(req.PlayerIDs) > 0) {
+		return errors.New("player_ids 和 all_players 必须且只能指定一个")
+	}
+	if len(req.PlayerIDs) > maxGrantPlayers {
+		return fmt.Errorf("单次最多指定 %d 名玩家", maxGrantPlayers)
+	}
+
+	req.CharacterIDs = uniqueSorted(req.CharacterIDs)
+	for _, id := range req.CharacterIDs {
+		if _, err := h.characters.GetByID(id); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return fmt.Errorf("角色 %d 不存在", id)
+			}
+			return fmt.Errorf("查询角色 %d 失败", id)
+		}
+	}
+	req.PlayerIDs = uniqueSorted(req.PlayerIDs)
+	return nil
+}
+
+// start 启动任务（同一时间只允许一个任务运行）
+func (h *CharacterGrantHandler) start(req CharacterGrantRequest, actor string) (*CharacterGrantJob, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.job != nil && h.job.Status == GrantJobRunning {
+		return nil, fmt.Errorf("已有角色发放任务正在运行: %s", h.job.ID)
+	}
+
+	job := &CharacterGrantJob{
+		ID:           uuid.New().String(),
+		Status:       GrantJobRunning,
+		Actor:        actor,
+		CharacterIDs: req.CharacterIDs,
+		Revoke:       req.Revoke,
+		AllPlayers:   req.AllPlayers,
+		StartedAt:    time.Now(),
+		playerIDs:    req.PlayerIDs,
+		cancel:       make(chan struct{}),
+	}
+	h.job = job
+
+	go h.run(job)
+
+	copied := *job
+	return &copied, nil
+}
+
+// stop 取消正在运行的任务
+func (h *CharacterGrantHandler) stop() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.job == nil || h.job.Status != GrantJobRunning {
+		return false
+	}
+
+	select {
+	case <-h.job.cancel:
+	default:
+		close(h.job.cancel)
+	}
+	return true
+}
+
+// snapshot 获取当前（或最近一次）任务的副本
+func (h *CharacterGrantHandler) snapshot() *CharacterGrantJob {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.job == nil {
+		return nil
+	}
+	copied := *h.job
+	return &copied
+}
+
+// update 在锁内更新任务状态
+func (h *CharacterGrantHandler) update(fn func(job *CharacterGrantJob)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	fn(h.job)
+}
+
+// run 分批发放或收回角色，结束时写入审计日志
+func (h *CharacterGrantHandler) run(job *CharacterGrantJob) {
+	finish := func(status string, err error) {
+		var result CharacterGrantJob
+		h.update(func(j *CharacterGrantJob) {
+			now := time.Now()
+			j.Status = status
+			j.FinishedAt = &now
+			if err != nil {
+				j.Error = err.Error()
+			}
+			result = *j
+		})
+		log.Printf("角色发放任务 %s 结束: %s (已处理 %d 名玩家, 变更 %d 条记录)",
+			job.ID, status, result.Processed, result.Changed)
+		auditGrantJob(&result)
+	}
+
+	total := len(job.playerIDs)
+	if job.AllPlayers {
+		if err := db.DB.QueryRow(`SELECT COUNT(*) FROM players`).Scan(&total); err != nil {
+			finish(GrantJobFailed, fmt.Errorf("统计玩家数失败: %w", err))
+			return
+		}
+	}
+	h.update(func(j *CharacterGrantJob) { j.TotalPlayers = total })
+	log.Printf("角色发放任务 %s 启动: 角色 %v, 共 %d 名玩家, 收回: %v", job.ID, job.CharacterIDs, total, job.Revoke)
+
+	var lastID int64
+	for offset := 0; ; {
+		select {
+		case <-job.cancel:
+			finish(GrantJobCancelled, nil)
+			return
+		default:
+		}
+
+		var batch []int64
+		if job.AllPlayers {
+			var err error
+			if batch, err = nextPlayerBatch(lastID); err != nil {
+				finish(GrantJobFailed, fmt.Errorf("查询玩家批次失败: %w", err))
+				return
+			}
+		} else {
+			end := min(offset+grantBatchSize, len(job.playerIDs))
+			batch = job.playerIDs[offset:end]
+			offset = end
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		changed, err := applyGrantBatch(batch, job.CharacterIDs, job.Revoke)
+		if err != nil {
+			finish(GrantJobFailed, fmt.Errorf("处理玩家 %d 到 %d 失败: %w", batch[0], batch[len(batch)-1], err))
+			return
+		}
+
+		lastID = batch[len(batch)-1]
+		h.update(func(j *CharacterGrantJob) {
+			j.Processed += len(batch)
+			j.Changed += changed
+			if j.TotalPlayers > 0 {
+				j.Progress = min(float64(j.Processed)*100.0/float64(j.TotalPlayers), 100)
+			}
+		})
+
+		time.Sleep(grantBatchPause)
+	}
+
+	h.update(func(j *CharacterGrantJob) { j.Progress = 100 })
+	finish(GrantJobCompleted, nil)
+}
+
+// nextPlayerBatch 按ID顺序获取 afterID 之后的一批玩家
+func nextPlayerBatch(afterID int64) ([]int64, error) {
+	rows, err := db.DB.Query(`SELECT id FROM players WHERE id > $1 ORDER BY id LIMIT $2`, afterID, grantBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// applyGrantBatch 在一个事务中为一批玩家发放或收回角色，返回变更的玩家角色记录数。
+// 发放时跳过不存在的玩家和已拥有的角色；收回时默认角色被收回的玩家改用编号最小的剩余角色，没有剩余角色时清除默认角色
+func applyGrantBatch(playerIDs []int64, characterIDs []int, revoke bool) (int, error) {
+	var changed int64
+	err := db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		var result sql.Result
+		var err error
+		if revoke {
+			result, err = tx.Exec(`
+				DELETE FROM player_characters WHERE player_id = ANY($1) AND character_id = ANY($2)
+			`, playerIDs, characterIDs)
+		} else {
+			result, err = tx.Exec(`
+				INSERT INTO player_characters (player_id, character_id, unlocked, unlocked_at)
+				SELECT p.id, c.id, true, NOW()
+				FROM players p CROSS JOIN characters c
+				WHERE p.id = ANY($1) AND c.id = ANY($2)
+				ON CONFLICT (player_id, character_id) DO NOTHING
+			`, playerIDs, characterIDs)
+		}
+		if err != nil {
+			return err
+		}
+		if changed, err = result.RowsAffected(); err != nil || !revoke {
+			return err
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE player_default_characters d SET character_id = (
+				SELECT MIN(pc.character_id) FROM player_characters pc WHERE pc.player_id = d.player_id
+			)
+			WHERE d.player_id = ANY($1) AND d.character_id = ANY($2)
+			  AND EXISTS (SELECT 1 FROM player_characters pc WHERE pc.player_id = d.player_id)
+		`, playerIDs, characterIDs); err != nil {
+			return fmt.Errorf("重新设置默认角色失败: %w", err)
+		}
+		if _, err := tx.Exec(`
+			DELETE FROM player_default_characters WHERE player_id = ANY($1) AND character_id = ANY($2)
+		`, playerIDs, characterIDs); err != nil {
+			return fmt.Errorf("清除默认角色失败: %w", err)
+		}
+		return nil
+	})
+	return int(changed), err
+}
+
+// auditGrantJob 将任务结果写入审计日志，与启动任务的请求记录对应
+func auditGrantJob(job *CharacterGrantJob) {
+	status := http.StatusOK
+	if job.Status == GrantJobFailed {
+		status = http.StatusInternalServerError
+	}
+	payload, err := json.Marshal(job)
+	if err != nil {
+		log.Printf("序列化角色发放任务 %s 失败: %v", job.ID, err)
+		return
+	}
+
+	entry := &models.AdminAuditEntry{
+		Actor:   job.Actor,
+		Method:  grantJobAuditMethod,
+		Target:  "/admin/characters/grants?job=" + job.ID,
+		Status:  status,
+		Payload: payload,
+	}
+	if err := audit.Record(entry); err != nil {
+		log.Printf("记录角色发放任务 %s 审计日志失败: %v", job.ID, err)
+	}
+}
+
+// uniqueSorted 去重并升序排列ID
+func uniqueSorted[T int | int64](ids []T) []T {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	unique := ids[:0]
+	for _, id := range ids {
+		if len(unique) == 0 || id != unique[len(unique)-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,103 @@
+// cosmetics.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+)
+
+// CosmeticsHandler 外观处理器
+type CosmeticsHandler struct {
+	auth *AuthHandler
+}
+
+// NewCosmeticsHandler 创建外观处理器
+func NewCosmeticsHandler(auth *AuthHandler) *CosmeticsHandler {
+	return &CosmeticsHandler{auth: auth}
+}
+
+// EquipRequest 装备请求
+type EquipRequest struct {
+	CharacterID int `json:"character_id"`
+	ItemID      int `json:"item_id"` // 0表示卸下当前皮肤
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *CosmeticsHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("cosmetics", h.handleCosmetics)
+	profile.RegisterSubResource("equip", h.handleEquip)
+}
+
+// handleCosmetics 查询玩家拥有的外观
+func (h *CosmeticsHandler) handleCosmetics(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cosmetics, err := inventory.OwnedCosmetics(playerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 外观失败: %v", playerID, err)
+		sendJSONError(w, "查询外观失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", cosmetics)
+}
+
+// handleEquip GET 查询各角色装备的皮肤，POST 装备或卸下皮肤（仅限本人）
+func (h *CosmeticsHandler) handleEquip(w http.ResponseWriter, r *http.Request, playerID int64) {
+	switch r.Method {
+	case http.MethodGet:
+		skins, err := inventory.EquippedSkins(playerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 装备皮肤失败: %v", playerID, err)
+			sendJSONError(w, "查询装备失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", skins)
+	case http.MethodPost:
+		session, ok := h.auth.Authenticate(r)
+		if !ok {
+			h.auth.sendAuthError(w, r)
+			return
+		}
+		if session.PlayerID != playerID {
+			sendJSONError(w, "只能修改自己的装备", http.StatusForbidden)
+			return
+		}
+
+		var req EquipRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if req.CharacterID <= 0 || req.ItemID < 0 {
+			sendJSONError(w, "无效的角色或道具ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := inventory.EquipSkin(playerID, req.CharacterID, req.ItemID); err != nil {
+			switch {
+			case errors.Is(err, inventory.ErrCharacterNotOwned), errors.Is(err, inventory.ErrItemNotOwned),
+				errors.Is(err, inventory.ErrCharacterLevelTooLow):
+				sendServiceError(w, err, http.StatusForbidden)
+			case errors.Is(err, inventory.ErrNotApplicable):
+				sendServiceError(w, err, http.StatusBadRequest)
+			default:
+				log.Printf("玩家 %d 装备皮肤失败: %v", playerID, err)
+				sendJSONError(w, "装备失败", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		sendJSONSuccess(w, "装备成功", req)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
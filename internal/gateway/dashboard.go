@@ -0,0 +1,249 @@
+// dashboard.go
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/notify"
+)
+
+// dashboardTimeout 管理面板向各服务查询的超时时间
+const dashboardTimeout = 3 * time.Second
+
+// errNoInstance 没有可用的服务实例
+var errNoInstance = errors.New("没有可用的服务实例")
+
+// DashboardHandler 运营管理面板处理器，汇总网关、游戏服务器和匹配服务的实时状态
+type DashboardHandler struct {
+	gateway *Gateway
+	client  *http.Client
+}
+
+// NewDashboardHandler 创建管理面板处理器
+func NewDashboardHandler(g *Gateway) *DashboardHandler {
+	return &DashboardHandler{
+		gateway: g,
+		client:  &http.Client{Timeout: dashboardTimeout},
+	}
+}
+
+// DashboardSummary 实时状态概览。某个服务查询失败时对应部分为空，原因记录在 errors 中
+type DashboardSummary struct {
+	GeneratedAt time.Time               `json:"generated_at"`
+	Players     DashboardPlayers        `json:"players"`
+	Rooms       DashboardRooms          `json:"rooms"`
+	Queues      map[models.GameMode]int `json:"queues"` // 各模式匹配队列中的玩家数
+	Cache       CacheStats              `json:"cache"`  // 本网关实例的响应缓存
+	Services    []DashboardService      `json:"services"`
+	Errors      map[ServiceType]string  `json:"errors,omitempty"`
+}
+
+// DashboardPlayers 在线玩家统计
+type DashboardPlayers struct {
+	Connected           int `json:"connected"`            // 游戏服务器上的连接数
+	MaxPlayers          int `json:"max_players"`          // 游戏服务器的玩家上限，0 表示不限
+	NotificationStreams int `json:"notification_streams"` // 本网关实例上的通知流连接数
+}
+
+// DashboardRooms 房间统计
+type DashboardRooms struct {
+	Total    int                       `json:"total"`
+	MaxRooms int                       `json:"max_rooms"` // 房间数上限，0 表示不限
+	ByMode   map[models.GameMode]int   `json:"by_mode"`
+	ByStatus map[models.RoomStatus]int `json:"by_status"`
+}
+
+// DashboardService 已注册的服务实例
+type DashboardService struct {
+	ID        string      `json:"id"`
+	Type      ServiceType `json:"type"`
+	URL       string      `json:"url"`
+	Region    string      `json:"region,omitempty"`
+	Load      int         `json:"load"`
+	Capacity  int         `json:"capacity"`
+	Healthy   bool        `json:"healthy"`
+	LastCheck time.Time   `json:"last_check"`
+}
+
+// gameHealth 游戏服务器健康检查响应中管理面板使用的部分
+type gameHealth struct {
+	Capacity struct {
+		Rooms       int `json:"rooms"`
+		MaxRooms    int `json:"max_rooms"`
+		Connections int `json:"connections"`
+		MaxPlayers  int `json:"max_players"`
+	} `json:"capacity"`
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *DashboardHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/dashboard", admin.Wrap(h.handleSummary))
+	mux.HandleFunc("/admin/dashboard/rooms", admin.Wrap(h.handleRooms))
+	mux.HandleFunc("/admin/dashboard/rooms/", admin.Wrap(h.handleRoom))
+}
+
+// handleSummary GET 实时状态概览
+func (h *DashboardHandler) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	summary := DashboardSummary{
+		GeneratedAt: time.Now(),
+		Players:     DashboardPlayers{NotificationStreams: notify.Default.Count()},
+		Rooms: DashboardRooms{
+			ByMode:   make(map[models.GameMode]int),
+			ByStatus: make(map[models.RoomStatus]int),
+		},
+		Queues:   make(map[models.GameMode]int),
+		Cache:    h.gateway.responseCache.Stats(),
+		Services: h.services(),
+		Errors:   make(map[ServiceType]string),
+	}
+
+	var health gameHealth
+	if _, err := h.fetch(r.Context(), ServiceGame, "/health", &health); err != nil {
+		summary.Errors[ServiceGame] = err.Error()
+	} else {
+		summary.Players.Connected = health.Capacity.Connections
+		summary.Players.MaxPlayers = health.Capacity.MaxPlayers
+		summary.Rooms.MaxRooms = health.Capacity.MaxRooms
+	}
+
+	var rooms []models.RoomMetrics
+	if _, err := h.fetch(r.Context(), ServiceGame, "/admin/rooms", &rooms); err != nil {
+		summary.Errors[ServiceGame] = err.Error()
+	}
+	summary.Rooms.Total = len(rooms)
+	for _, room := range rooms {
+		summary.Rooms.ByMode[room.Mode]++
+		summary.Rooms.ByStatus[room.Status]++
+	}
+
+	var queues struct {
+		Queues map[models.GameMode]int `json:"queues"`
+	}
+	if _, err := h.fetch(r.Context(), ServiceMatch, "/match/status", &queues); err != nil {
+		summary.Errors[ServiceMatch] = err.Error()
+	} else if queues.Queues != nil {
+		summary.Queues = queues.Queues
+	}
+
+	sendJSONSuccess(w, "获取成功", summary)
+}
+
+// handleRooms GET 房间列表，可按 mode 和 status 过滤
+func (h *DashboardHandler) handleRooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rooms []models.RoomMetrics
+	if _, err := h.fetch(r.Context(), ServiceGame, "/admin/rooms", &rooms); err != nil {
+		log.Printf("查询房间列表失败: %v", err)
+		sendJSONError(w, "查询游戏服务器失败", http.StatusBadGateway)
+		return
+	}
+
+	mode := models.GameMode(r.URL.Query().Get("mode"))
+	status := models.RoomStatus(r.URL.Query().Get("status"))
+	filtered := make([]models.RoomMetrics, 0, len(rooms))
+	for _, room := range rooms {
+		if (mode == "" || room.Mode == mode) && (status == "" || room.Status == status) {
+			filtered = append(filtered, room)
+		}
+	}
+	sendJSONSuccess(w, "获取成功", filtered)
+}
+
+// handleRoom GET 房间详情，包含玩家、得分和延迟
+func (h *DashboardHandler) handleRoom(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	roomID := strings.TrimPrefix(r.URL.Path, "/admin/dashboard/rooms/")
+	if roomID == "" || strings.Contains(roomID, "/") {
+		sendJSONError(w, "无效的房间ID", http.StatusBadRequest)
+		return
+	}
+
+	var detail models.RoomDetail
+	status, err := h.fetch(r.Context(), ServiceGame, "/admin/rooms/"+roomID, &detail)
+	if status == http.StatusNotFound {
+		sendJSONError(w, "房间不存在", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("查询房间 %s 详情失败: %v", roomID, err)
+		sendJSONError(w, "查询游戏服务器失败", http.StatusBadGateway)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", detail)
+}
+
+// services 已注册的服务实例及其健康状态
+func (h *DashboardHandler) services() []DashboardService {
+	g := h.gateway
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	result := []DashboardService{}
+	for _, serviceType := range []ServiceType{ServiceGame, ServiceMatch, ServiceAuth} {
+		for _, instance := range g.services[serviceType] {
+			result = append(result, DashboardService{
+				ID:        instance.ID,
+				Type:      instance.Type,
+				URL:       instance.URL.String(),
+				Region:    instance.Region,
+				Load:      instance.Load,
+				Capacity:  instance.Capacity,
+				Healthy:   instance.Health,
+				LastCheck: instance.LastCheck,
+			})
+		}
+	}
+	return result
+}
+
+// fetch 携带管理密钥向服务实例发送 GET 请求并解析 JSON 响应，返回响应状态码
+func (h *DashboardHandler) fetch(ctx context.Context, serviceType ServiceType, path string, out interface{}) (int, error) {
+	instance := h.gateway.getServiceInstance(serviceType)
+	if instance == nil {
+		return 0, errNoInstance
+	}
+
+	u := *instance.URL
+	u.Path = path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(AdminKeyHeader, h.gateway.config.Admin.APIKey)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("%s 返回状态码 %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("解析 %s 响应失败: %w", path, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,586 @@
+// debugcapture.go
+
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+const (
+	// captureRuleKey 当前抓包规则的 Redis 键，过期时间与规则一致
+	captureRuleKey = "debug:capture:rule"
+
+	// captureRecordsKey 抓包记录的 Redis 列表，最新的在前
+	captureRecordsKey = "debug:capture:records"
+
+	// captureRefresh 各网关实例重新加载抓包规则的间隔
+	captureRefresh = 5 * time.Second
+
+	// captureRecordsTTL 抓包记录在最后一次写入后保留的时间
+	captureRecordsTTL = 24 * time.Hour
+
+	// 未配置时的默认上限
+	defaultCaptureBodyBytes = 64 << 10
+	defaultCaptureRecords   = 500
+	defaultCaptureDuration  = time.Hour
+)
+
+var (
+	// errCaptureDisabled 配置中未启用调试抓包
+	errCaptureDisabled = errcode.New(errcode.FeatureDisabled, "未启用调试抓包")
+	// errInvalidCapture 抓包规则参数无效
+	errInvalidCapture = errcode.New(errcode.BadRequest, "无效的抓包规则")
+)
+
+// filteredHeaders 记录时隐藏值的请求头
+var filteredHeaders = []string{"Authorization", "Cookie", AdminKeyHeader}
+
+// uncapturedPaths 不记录的接口：管理接口，以及请求或响应中带密码、会话令牌和支付凭证的接口
+var uncapturedPaths = []string{"/admin/", "/auth/", "/payments/verify"}
+
+// filteredFields 记录时隐藏值的 JSON 字段
+var filteredFields = map[string]bool{
+	"password":       true,
+	"token":          true,
+	"refresh_token":  true,
+	"purchase_token": true,
+	"receipt":        true,
+}
+
+// CaptureRule 抓包规则：记录指定玩家的全部请求，或按比例抽样记录
+type CaptureRule struct {
+	PlayerID   int64     `json:"player_id,omitempty"`
+	SampleRate float64   `json:"sample_rate,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// matches 请求是否需要记录
+func (c *CaptureRule) matches(playerID int64) bool {
+	if c.PlayerID != 0 {
+		return c.PlayerID == playerID
+	}
+	return rand.Float64() < c.SampleRate
+}
+
+// StartCaptureRequest 开启抓包请求，player_id 和 sample_rate 二选一
+type StartCaptureRequest struct {
+	PlayerID   int64   `json:"player_id"`
+	SampleRate float64 `json:"sample_rate"`      // 抽样比例(0-1]
+	Duration   int     `json:"duration_seconds"` // 抓包时长(秒)，0 表示配置的最长时长
+}
+
+// CapturedExchange 一次请求和响应的完整记录
+type CapturedExchange struct {
+	ID             string            `json:"id"`
+	Time           time.Time         `json:"time"`
+	PlayerID       int64             `json:"player_id,omitempty"`
+	Method         string            `json:"method"`
+	URI            string            `json:"uri"`
+	RequestHeaders map[string]string `json:"request_headers"`
+	RequestBody    string            `json:"request_body,omitempty"`
+	Status         int               `json:"status"`
+	ResponseBody   string            `json:"response_body,omitempty"`
+	DurationMs     int64             `json:"duration_ms"`
+	Truncated      bool              `json:"truncated,omitempty"` // 请求体或响应体超过上限被截断
+}
+
+// DebugCapture 调试抓包。规则和记录保存在 Redis 中，在所有网关实例上生效；
+// 没有 Redis 时只在本实例生效
+type DebugCapture struct {
+	enabled     bool
+	maxBody     int
+	maxRecords  int
+	maxDuration time.Duration
+
+	mu      sync.RWMutex
+	rule    *CaptureRule
+	records []CapturedExchange // 没有 Redis 时的记录，最新的在前
+}
+
+// NewDebugCapture 按配置创建调试抓包
+func NewDebugCapture(cfg config.DebugCaptureConfig) *DebugCapture {
+	d := &DebugCapture{
+		enabled:     cfg.Enabled,
+		maxBody:     cfg.MaxBodyBytes,
+		maxRecords:  cfg.MaxRecords,
+		maxDuration: cfg.MaxDuration,
+	}
+	if d.maxBody <= 0 {
+		d.maxBody = defaultCaptureBodyBytes
+	}
+	if d.maxRecords <= 0 {
+		d.maxRecords = defaultCaptureRecords
+	}
+	if d.maxDuration <= 0 {
+		d.maxDuration = defaultCaptureDuration
+	}
+	return d
+}
+
+// Rule 当前生效的抓包规则，没有时返回nil
+func (d *DebugCapture) Rule() *CaptureRule {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.rule == nil || time.Now().After(d.rule.ExpiresAt) {
+		return nil
+	}
+	rule := *d.rule
+	return &rule
+}
+
+// Start 开启抓包，替换之前的规则
+func (d *DebugCapture) Start(req *StartCaptureRequest) (*CaptureRule, error) {
+	if !d.enabled {
+		return nil, errCaptureDisabled
+	}
+	if req.PlayerID < 0 || req.SampleRate < 0 || req.SampleRate > 1 || req.Duration < 0 {
+		return nil, fmt.Errorf("%w: player_id、sample_rate 和 duration_seconds 取值无效", errInvalidCapture)
+	}
+	if (req.PlayerID == 0) == (req.SampleRate == 0) {
+		return nil, fmt.Errorf("%w: 需要指定 player_id 或 sample_rate 其中之一", errInvalidCapture)
+	}
+
+	duration := time.Duration(req.Duration) * time.Second
+	if duration == 0 || duration > d.maxDuration {
+		duration = d.maxDuration
+	}
+	now := time.Now()
+	rule := &CaptureRule{
+		PlayerID:   req.PlayerID,
+		SampleRate: req.SampleRate,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(duration),
+	}
+
+	if db.RedisClient != nil {
+		data, err := json.Marshal(rule)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.RedisClient.Set(context.Background(), captureRuleKey, data, duration).Err(); err != nil {
+			return nil, fmt.Errorf("保存抓包规则失败: %w", err)
+		}
+	}
+	d.mu.Lock()
+	d.rule = rule
+	d.mu.Unlock()
+	return rule, nil
+}
+
+// Stop 停止抓包，已有的记录保留到过期或被清除
+func (d *DebugCapture) Stop() error {
+	if db.RedisClient != nil {
+		if err := db.RedisClient.Del(context.Background(), captureRuleKey).Err(); err != nil {
+			return fmt.Errorf("删除抓包规则失败: %w", err)
+		}
+	}
+	d.mu.Lock()
+	d.rule = nil
+	d.mu.Unlock()
+	return nil
+}
+
+// Records 最近的抓包记录，playerID 非0时只返回该玩家的记录
+func (d *DebugCapture) Records(playerID int64, limit int) ([]CapturedExchange, error) {
+	var records []CapturedExchange
+	if db.RedisClient != nil {
+		values, err := db.RedisClient.LRange(context.Background(), captureRecordsKey, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("读取抓包记录失败: %w", err)
+		}
+		for _, value := range values {
+			var record CapturedExchange
+			if err := json.Unmarshal([]byte(value), &record); err != nil {
+				continue
+			}
+			records = append(records, record)
+		}
+	} else {
+		d.mu.RLock()
+		records = append(records, d.records...)
+		d.mu.RUnlock()
+	}
+
+	result := make([]CapturedExchange, 0, min(limit, len(records)))
+	for _, record := range records {
+		if playerID != 0 && record.PlayerID != playerID {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+// Clear 清除全部抓包记录
+func (d *DebugCapture) Clear() error {
+	if db.RedisClient != nil {
+		if err := db.RedisClient.Del(context.Background(), captureRecordsKey).Err(); err != nil {
+			return fmt.Errorf("清除抓包记录失败: %w", err)
+		}
+	}
+	d.mu.Lock()
+	d.records = nil
+	d.mu.Unlock()
+	return nil
+}
+
+// load 从 Redis 重新加载抓包规则
+func (d *DebugCapture) load() error {
+	data, err := db.RedisClient.Get(context.Background(), captureRuleKey).Bytes()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	var rule *CaptureRule
+	if len(data) > 0 {
+		rule = &CaptureRule{}
+		if err := json.Unmarshal(data, rule); err != nil {
+			return err
+		}
+	}
+	d.mu.Lock()
+	d.rule = rule
+	d.mu.Unlock()
+	return nil
+}
+
+// Watch 定期从 Redis 加载其他实例开启或停止的抓包规则
+func (d *DebugCapture) Watch(stop <-chan struct{}) {
+	if !d.enabled || db.RedisClient == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(captureRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := d.load(); err != nil {
+					log.Printf("加载抓包规则失败: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// save 保存抓包记录，只保留最近的 maxRecords 条
+func (d *DebugCapture) save(record CapturedExchange) {
+	if db.RedisClient != nil {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return
+		}
+		ctx := context.Background()
+		pipe := db.RedisClient.TxPipeline()
+		pipe.LPush(ctx, captureRecordsKey, data)
+		pipe.LTrim(ctx, captureRecordsKey, 0, int64(d.maxRecords-1))
+		pipe.Expire(ctx, captureRecordsKey, captureRecordsTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.Printf("保存抓包记录失败: %v", err)
+		}
+		return
+	}
+
+	d.mu.Lock()
+	d.records = append([]CapturedExchange{record}, d.records...)
+	if len(d.records) > d.maxRecords {
+		d.records = d.records[:d.maxRecords]
+	}
+	d.mu.Unlock()
+}
+
+// Middleware 按抓包规则记录请求和响应。需放在限流中间件之内，以便按会话识别玩家；uncapturedPaths 中的接口不记录
+func (d *DebugCapture) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule := d.Rule()
+		if rule == nil || uncaptured(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var playerID int64
+		if session, ok := r.Context().Value(sessionContextKey{}).(SessionInfo); ok {
+			playerID = session.PlayerID
+		}
+		if !rule.matches(playerID) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		record := CapturedExchange{
+			ID:             uuid.New().String(),
+			Time:           start,
+			PlayerID:       playerID,
+			Method:         r.Method,
+			URI:            filteredURI(r),
+			RequestHeaders: filteredRequestHeaders(r),
+		}
+		requestBody, truncated := d.readRequestBody(r)
+		record.RequestBody = bodyText(filteredBody(requestBody))
+
+		recorder := &captureRecorder{ResponseWriter: w, statusCode: http.StatusOK, limit: d.maxBody}
+		next.ServeHTTP(recorder, r)
+
+		record.Status = recorder.statusCode
+		record.ResponseBody = bodyText(filteredBody(recorder.body.Bytes()))
+		record.Truncated = truncated || recorder.truncated
+		record.DurationMs = time.Since(start).Milliseconds()
+		d.save(record)
+	})
+}
+
+// readRequestBody 读取请求体用于记录并还原给处理器，超过上限的部分不记录
+func (d *DebugCapture) readRequestBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, false
+	}
+	data, _ := io.ReadAll(io.LimitReader(r.Body, int64(d.maxBody)+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if len(data) > d.maxBody {
+		return data[:d.maxBody], true
+	}
+	return data, false
+}
+
+// filteredURI 请求地址，隐藏查询参数中的令牌
+func filteredURI(r *http.Request) string {
+	query := r.URL.Query()
+	if !query.Has("token") {
+		return r.URL.RequestURI()
+	}
+	query.Set("token", "[Filtered]")
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// filteredRequestHeaders 请求头，隐藏认证相关的值
+func filteredRequestHeaders(r *http.Request) map[string]string {
+	headers := make(map[string]string, len(r.Header))
+	for key, values := range r.Header {
+		headers[key] = strings.Join(values, ", ")
+	}
+	for _, key := range filteredHeaders {
+		if _, ok := headers[http.CanonicalHeaderKey(key)]; ok {
+			headers[http.CanonicalHeaderKey(key)] = "[Filtered]"
+		}
+	}
+	return headers
+}
+
+// uncaptured 接口是否不记录
+func uncaptured(path string) bool {
+	for _, prefix := range uncapturedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredBody 隐藏 JSON 请求体或响应体中敏感字段的值，非 JSON 或没有敏感字段时原样返回
+func filteredBody(data []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || !filterFields(v) {
+		return data
+	}
+	filtered, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return filtered
+}
+
+// filterFields 递归替换敏感字段的值，返回是否有字段被替换
+func filterFields(v interface{}) bool {
+	filtered := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if filteredFields[strings.ToLower(key)] {
+				v[key] = "[Filtered]"
+				filtered = true
+				continue
+			}
+			filtered = filterFields(value) || filtered
+		}
+	case []interface{}:
+		for _, value := range v {
+			filtered = filterFields(value) || filtered
+		}
+	}
+	return filtered
+}
+
+// bodyText 可读的请求体或响应体，二进制内容只记录大小
+func bodyText(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	size := len(data)
+	// 截断处可能在多字节字符中间，去掉末尾不完整的字符
+	for i := 0; i < utf8.UTFMax && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("[二进制内容 %d 字节]", size)
+	}
+	return string(data)
+}
+
+// captureRecorder 记录状态码和不超过上限的响应体
+type captureRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	limit      int
+	body       bytes.Buffer
+	truncated  bool
+}
+
+// WriteHeader 记录状态码
+func (c *captureRecorder) WriteHeader(code int) {
+	c.statusCode = code
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write 记录响应体
+func (c *captureRecorder) Write(data []byte) (int, error) {
+	if room := c.limit - c.body.Len(); room > 0 {
+		c.body.Write(data[:min(room, len(data))])
+		c.truncated = c.truncated || len(data) > room
+	} else if len(data) > 0 {
+		c.truncated = true
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// Unwrap 供 http.ResponseController 刷新流式响应
+func (c *captureRecorder) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// DebugCaptureHandler 调试抓包管理处理器
+type DebugCaptureHandler struct {
+	capture *DebugCapture
+}
+
+// NewDebugCaptureHandler 创建调试抓包管理处理器
+func NewDebugCaptureHandler(capture *DebugCapture) *DebugCaptureHandler {
+	return &DebugCaptureHandler{capture: capture}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *DebugCaptureHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/debug/capture", admin.Wrap(h.handleCapture))
+	mux.HandleFunc("/admin/debug/captures", admin.Wrap(h.handleRecords))
+}
+
+// handleCapture 查看当前抓包规则: GET /admin/debug/capture；开启: POST；停止: DELETE
+func (h *DebugCaptureHandler) handleCapture(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendJSONSuccess(w, "获取成功", h.capture.Rule())
+	case http.MethodPost:
+		auditBefore(r, h.capture.Rule())
+		var req StartCaptureRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		rule, err := h.capture.Start(&req)
+		if err != nil {
+			h.sendError(w, "开启抓包失败", err)
+			return
+		}
+		log.Printf("管理员开启调试抓包: player_id=%d sample_rate=%.2f 到期 %s",
+			rule.PlayerID, rule.SampleRate, rule.ExpiresAt.Format(time.RFC3339))
+		sendJSONSuccess(w, "抓包已开启", rule)
+	case http.MethodDelete:
+		auditBefore(r, h.capture.Rule())
+		if err := h.capture.Stop(); err != nil {
+			h.sendError(w, "停止抓包失败", err)
+			return
+		}
+		log.Printf("管理员停止调试抓包")
+		sendJSONSuccess(w, "抓包已停止", nil)
+	default:
+		sendJSONError(w, "仅支持GET、POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRecords 查询抓包记录: GET /admin/debug/captures?player_id=&limit=；清除: DELETE
+func (h *DebugCaptureHandler) handleRecords(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		var playerID int64
+		if v := query.Get("player_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id <= 0 {
+				sendJSONError(w, "无效的玩家ID", http.StatusBadRequest)
+				return
+			}
+			playerID = id
+		}
+		limit := 100
+		if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+			limit = n
+		}
+
+		records, err := h.capture.Records(playerID, limit)
+		if err != nil {
+			h.sendError(w, "查询抓包记录失败", err)
+			return
+		}
+		sendJSONSuccess(w, "获取成功", records)
+	case http.MethodDelete:
+		if err := h.capture.Clear(); err != nil {
+			h.sendError(w, "清除抓包记录失败", err)
+			return
+		}
+		log.Printf("管理员清除调试抓包记录")
+		sendJSONSuccess(w, "已清除", nil)
+	default:
+		sendJSONError(w, "仅支持GET和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 按错误类型返回抓包管理错误
+func (h *DebugCaptureHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, errCaptureDisabled):
+		sendServiceError(w, err, http.StatusForbidden)
+	case errors.Is(err, errInvalidCapture):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,64 @@
+// debugcapture_test.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFilteredBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "非 JSON 原样返回", body: "plain text", want: "plain text"},
+		{name: "没有敏感字段原样返回", body: `{"player_id":9007199254740993,"name":"a"}`, want: `{"player_id":9007199254740993,"name":"a"}`},
+		{
+			name: "隐藏顶层字段",
+			body: `{"username":"a","password":"secret"}`,
+			want: `{"username":"a","password":"[Filtered]"}`,
+		},
+		{
+			name: "隐藏嵌套对象和数组中的字段",
+			body: `{"code":0,"data":{"token":"t","items":[{"receipt":"r","id":1}]}}`,
+			want: `{"code":0,"data":{"token":"[Filtered]","items":[{"receipt":"[Filtered]","id":1}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filteredBody([]byte(tt.body))
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("filteredBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUncaptured(t *testing.T) {
+	tests := map[string]bool{
+		"/auth/login":         true,
+		"/auth/refresh":       true,
+		"/payments/verify":    true,
+		"/admin/debug/record": true,
+		"/players/profile":    false,
+		"/shop/purchase":      false,
+	}
+	for path, want := range tests {
+		if got := uncaptured(path); got != want {
+			t.Errorf("uncaptured(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// jsonEqual 比较两个 JSON 是否等价，不是 JSON 时按字节比较
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(va, vb)
+}
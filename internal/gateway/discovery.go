@@ -0,0 +1,227 @@
+// discovery.go
+
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/alert"
+	"github.com/jacl-coder/PixelStorm-Server/internal/registry"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// maxInstanceIDLength 实例ID的最大长度
+const maxInstanceIDLength = 128
+
+// errInvalidRegistration 服务注册信息无效
+var errInvalidRegistration = errcode.New(errcode.BadRequest, "无效的服务注册信息")
+
+// DiscoveredService 服务发现接口返回的实例信息
+type DiscoveredService struct {
+	ID            string      `json:"id"`
+	Type          ServiceType `json:"type"`
+	URL           string      `json:"url"`
+	Region        string      `json:"region,omitempty"`
+	PublicURL     string      `json:"public_url,omitempty"`
+	Capacity      int         `json:"capacity"`
+	Load          int         `json:"load"`
+	Healthy       bool        `json:"healthy"`
+	Registered    bool        `json:"registered"` // 实例自行注册，否则为网关按配置添加的本机实例
+	LastHeartbeat time.Time   `json:"last_heartbeat,omitempty"`
+}
+
+// registryTTL 未收到心跳后移除实例的时间
+func (g *Gateway) registryTTL() time.Duration {
+	if ttl := g.config.Registry.TTL; ttl > 0 {
+		return ttl
+	}
+	interval := g.config.Registry.HeartbeatInterval
+	if interval <= 0 {
+		interval = registry.DefaultHeartbeatInterval
+	}
+	return 3 * interval
+}
+
+// selfRegistration 是否由游戏和匹配服务自行注册，否则网关转发到本机端口
+func (g *Gateway) selfRegistration() bool {
+	return g.config.Registry.Secret != ""
+}
+
+// registerInstance 注册实例，已注册的实例更新地址和负载并刷新心跳时间
+func (g *Gateway) registerInstance(reg *registry.Registration) error {
+	serviceType := ServiceType(reg.Type)
+	if serviceType != ServiceGame && serviceType != ServiceMatch {
+		return fmt.Errorf("%w: 不支持的服务类型 %q", errInvalidRegistration, reg.Type)
+	}
+	if reg.ID == "" || len(reg.ID) > maxInstanceIDLength {
+		return fmt.Errorf("%w: 实例ID为空或过长", errInvalidRegistration)
+	}
+	if reg.Capacity < 0 || reg.Load < 0 {
+		return fmt.Errorf("%w: 容量和负载不能为负数", errInvalidRegistration)
+	}
+	address, err := url.Parse(reg.Address)
+	if err != nil || address.Host == "" || (address.Scheme != "http" && address.Scheme != "https") {
+		return fmt.Errorf("%w: 无效的服务地址 %q", errInvalidRegistration, reg.Address)
+	}
+	if reg.PublicURL != "" {
+		public, err := url.Parse(reg.PublicURL)
+		if err != nil || public.Host == "" || (public.Scheme != "http" && public.Scheme != "https") {
+			return fmt.Errorf("%w: 无效的对外地址 %q", errInvalidRegistration, reg.PublicURL)
+		}
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := time.Now()
+	for _, instance := range g.services[serviceType] {
+		if instance.ID == reg.ID {
+			instance.URL = address
+			instance.Region = reg.Region
+			instance.PublicURL = reg.PublicURL
+			instance.Capacity = reg.Capacity
+			instance.Load = reg.Load
+			instance.LastHeartbeat = now
+			return nil
+		}
+	}
+
+	g.services[serviceType] = append(g.services[serviceType], &ServiceInstance{
+		ID:            reg.ID,
+		Type:          serviceType,
+		URL:           address,
+		Health:        true,
+		LastCheck:     now,
+		Region:        reg.Region,
+		Capacity:      reg.Capacity,
+		Load:          reg.Load,
+		Registered:    true,
+		LastHeartbeat: now,
+		PublicURL:     reg.PublicURL,
+	})
+	log.Printf("服务实例注册: %s, ID: %s, URL: %s, 区域: %q, 容量: %d", serviceType, reg.ID, address, reg.Region, reg.Capacity)
+	return nil
+}
+
+// expireInstances 移除超过心跳有效期未发送心跳的自注册实例
+func (g *Gateway) expireInstances() {
+	deadline := time.Now().Add(-g.registryTTL())
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for serviceType, instances := range g.services {
+		alive := instances[:0]
+		for _, instance := range instances {
+			if !instance.Registered || instance.LastHeartbeat.After(deadline) {
+				alive = append(alive, instance)
+				continue
+			}
+			log.Printf("服务实例心跳超时，已移除: %s, ID: %s", serviceType, instance.ID)
+			alert.Notify(alert.Alert{
+				Key:     "instance:" + instance.ID,
+				Level:   alert.LevelCritical,
+				Title:   fmt.Sprintf("%s 服务实例心跳超时", serviceType),
+				Message: fmt.Sprintf("实例 %s (%s) 最后一次心跳在 %s，已停止转发", instance.ID, instance.URL, instance.LastHeartbeat.Format(time.RFC3339)),
+			})
+		}
+		g.services[serviceType] = alive
+	}
+}
+
+// discoveredServices 已注册的实例，serviceType 和 region 为空时不过滤
+func (g *Gateway) discoveredServices(serviceType ServiceType, region string) []DiscoveredService {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	result := []DiscoveredService{}
+	for _, t := range []ServiceType{ServiceGame, ServiceMatch, ServiceAuth} {
+		if serviceType != "" && t != serviceType {
+			continue
+		}
+		for _, instance := range g.services[t] {
+			if region != "" && instance.Region != region {
+				continue
+			}
+			result = append(result, DiscoveredService{
+				ID:            instance.ID,
+				Type:          instance.Type,
+				URL:           instance.URL.String(),
+				Region:        instance.Region,
+				PublicURL:     instance.PublicURL,
+				Capacity:      instance.Capacity,
+				Load:          instance.Load,
+				Healthy:       instance.Health,
+				Registered:    instance.Registered,
+				LastHeartbeat: instance.LastHeartbeat,
+			})
+		}
+	}
+	return result
+}
+
+// checkRegistrySecret 校验注册接口密钥，失败时已发送错误响应
+func (g *Gateway) checkRegistrySecret(w http.ResponseWriter, r *http.Request) bool {
+	if !g.selfRegistration() {
+		sendJSONError(w, "未启用服务注册", http.StatusForbidden)
+		return false
+	}
+	key := r.Header.Get(registry.SecretHeader)
+	if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(g.config.Registry.Secret)) != 1 {
+		log.Printf("服务注册认证失败: %s %s", r.Method, r.URL.Path)
+		sendJSONError(w, "服务注册认证失败", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// handleServiceDiscovery 查询服务实例: GET /services?type=&region=
+func (g *Gateway) handleServiceDiscovery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	if !g.checkRegistrySecret(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	sendJSONSuccess(w, "获取成功", g.discoveredServices(ServiceType(query.Get("type")), query.Get("region")))
+}
+
+// handleServiceRegister 注册实例或发送心跳: POST /services/register；注销: DELETE /services/register?type=&id=
+func (g *Gateway) handleServiceRegister(w http.ResponseWriter, r *http.Request) {
+	if !g.checkRegistrySecret(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var reg registry.Registration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := g.registerInstance(&reg); err != nil {
+			sendServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+		sendJSONSuccess(w, "注册成功", map[string]interface{}{
+			"ttl_seconds": int(g.registryTTL().Seconds()),
+		})
+	case http.MethodDelete:
+		query := r.URL.Query()
+		if !g.UnregisterService(ServiceType(query.Get("type")), query.Get("id")) {
+			sendJSONError(w, "服务实例不存在", http.StatusNotFound)
+			return
+		}
+		sendJSONSuccess(w, "已注销", nil)
+	default:
+		sendJSONError(w, "仅支持POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
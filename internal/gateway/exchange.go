@@ -0,0 +1,76 @@
+// exchange.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/exchange"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// ExchangeHandler 货币兑换处理器
+type ExchangeHandler struct {
+	exchange *exchange.Service
+	auth     *AuthHandler
+}
+
+// NewExchangeHandler 创建货币兑换处理器
+func NewExchangeHandler(auth *AuthHandler, cfg config.ExchangeConfig) *ExchangeHandler {
+	return &ExchangeHandler{
+		exchange: exchange.NewService(wallet.NewService(), cfg),
+		auth:     auth,
+	}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *ExchangeHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/exchange", h.auth.RequireAuth(h.handleExchange))
+}
+
+// handleExchange GET 查询兑换比例和今日剩余额度，POST 宝石兑换金币
+func (h *ExchangeHandler) handleExchange(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		rates, err := h.exchange.Rates(session.PlayerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 兑换额度失败: %v", session.PlayerID, err)
+			sendJSONError(w, "查询兑换信息失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", rates)
+	case http.MethodPost:
+		var req models.ExchangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+
+		result, err := h.exchange.Exchange(session.PlayerID, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, exchange.ErrInvalidExchange):
+				sendServiceError(w, err, http.StatusBadRequest)
+			case errors.Is(err, exchange.ErrExchangeDisabled):
+				sendServiceError(w, err, http.StatusForbidden)
+			case errors.Is(err, wallet.ErrPlayerNotFound):
+				sendServiceError(w, err, http.StatusNotFound)
+			case errors.Is(err, exchange.ErrDailyLimit), errors.Is(err, wallet.ErrInsufficientFunds),
+				errors.Is(err, wallet.ErrIdempotencyConflict):
+				sendServiceError(w, err, http.StatusConflict)
+			default:
+				log.Printf("玩家 %d 兑换货币失败: %v", session.PlayerID, err)
+				sendJSONError(w, "兑换失败", http.StatusInternalServerError)
+			}
+			return
+		}
+		sendJSONSuccess(w, "兑换成功", result)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
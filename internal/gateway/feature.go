@@ -0,0 +1,104 @@
+// feature.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// errFeatureDisabled 接口已通过功能开关关闭或未对该玩家灰度开放
+var errFeatureDisabled = errcode.New(errcode.FeatureDisabled, "功能暂未开放")
+
+// requireFeature 包装处理器，功能开关未对当前玩家开启时返回 FEATURE_DISABLED
+func requireFeature(flag feature.Flag, next AuthenticatedHandlerFunc) AuthenticatedHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+		if !flag.EnabledFor(session.PlayerID) {
+			sendServiceError(w, errFeatureDisabled, http.StatusForbidden)
+			return
+		}
+		next(w, r, session)
+	}
+}
+
+// FeatureHandler 功能开关管理处理器
+type FeatureHandler struct{}
+
+// NewFeatureHandler 创建功能开关管理处理器
+func NewFeatureHandler() *FeatureHandler {
+	return &FeatureHandler{}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *FeatureHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/features", admin.Wrap(h.handleFeatures))
+	mux.HandleFunc("/admin/features/", admin.Wrap(h.handleFeature))
+}
+
+// handleFeatures 列出所有功能开关: GET /admin/features
+func (h *FeatureHandler) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", feature.List())
+}
+
+// handleFeature 更新功能开关或灰度比例: PUT /admin/features/{key}；恢复默认值: DELETE /admin/features/{key}
+func (h *FeatureHandler) handleFeature(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/admin/features/")
+	if key == "" {
+		sendJSONError(w, "缺少功能开关", http.StatusBadRequest)
+		return
+	}
+
+	if before, err := feature.Get(key); err == nil {
+		auditBefore(r, before)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req models.UpdateFeatureFlagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		flag, err := feature.Set(key, &req)
+		if err != nil {
+			h.sendError(w, "更新功能开关失败", err)
+			return
+		}
+		log.Printf("管理员更新功能开关 %s: enabled=%t rollout=%d", key, flag.Enabled, flag.Rollout)
+		sendJSONSuccess(w, "更新成功", flag)
+	case http.MethodDelete:
+		flag, err := feature.Reset(key)
+		if err != nil {
+			h.sendError(w, "恢复功能开关失败", err)
+			return
+		}
+		log.Printf("管理员恢复功能开关 %s 为默认值", key)
+		sendJSONSuccess(w, "已恢复默认值", flag)
+	default:
+		sendJSONError(w, "仅支持PUT和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 按错误类型返回功能开关错误
+func (h *FeatureHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, feature.ErrUnknownFlag):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, feature.ErrInvalidFlag):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,55 @@
+// gamedata.go
+
+package gateway
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+)
+
+// GameDataHandler 静态游戏数据管理处理器
+type GameDataHandler struct{}
+
+// NewGameDataHandler 创建静态游戏数据管理处理器
+func NewGameDataHandler() *GameDataHandler {
+	return &GameDataHandler{}
+}
+
+// GameDataSummary 静态游戏数据加载结果
+type GameDataSummary struct {
+	Characters int       `json:"characters"`
+	Skills     int       `json:"skills"`
+	Maps       int       `json:"maps"`
+	LoadedAt   time.Time `json:"loaded_at"`
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *GameDataHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/gamedata/reload", admin.Wrap(h.handleReload))
+}
+
+// handleReload 重新加载角色、技能和地图配置，并通知其他实例
+func (h *GameDataHandler) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	catalog, err := gamedata.Reload()
+	if err != nil {
+		log.Printf("重新加载静态游戏数据失败: %v", err)
+		sendJSONError(w, "重新加载游戏数据失败", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("管理员重新加载静态游戏数据: %d 个角色, %d 张地图", len(catalog.Characters), len(catalog.Maps))
+	sendJSONSuccess(w, "重新加载成功", GameDataSummary{
+		Characters: len(catalog.Characters),
+		Skills:     catalog.SkillCount(),
+		Maps:       len(catalog.Maps),
+		LoadedAt:   catalog.LoadedAt,
+	})
+}
@@ -3,15 +3,35 @@
 package gateway
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/alert"
+	"github.com/jacl-coder/PixelStorm-Server/internal/avatar"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/health"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/notify"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/internal/push"
+	"github.com/jacl-coder/PixelStorm-Server/internal/registry"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/internal/retention"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/storage"
 )
 
 // ServiceType 服务类型
@@ -33,6 +53,16 @@ type ServiceInstance struct {
 	URL       *url.URL
 	Health    bool
 	LastCheck time.Time
+
+	// 自行注册的实例通过心跳上报区域和负载，超过有效期未发送心跳时移除
+	Region        string
+	Capacity      int // 可承载的玩家数，0 表示不限
+	Load          int // 最近一次心跳时的玩家数
+	Registered    bool
+	LastHeartbeat time.Time
+
+	// 客户端直连本实例的地址，为空时不向客户端提供该实例的延迟探测地址
+	PublicURL string
 }
 
 // Gateway API网关
@@ -43,14 +73,40 @@ type Gateway struct {
 	httpServer *http.Server
 	isRunning  bool
 	shutdown   chan struct{}
+
+	// 管理接口认证
+	adminAuth *AdminAuth
+
+	// 响应缓存，管理面板读取其命中统计
+	responseCache *CacheMiddleware
+
+	// 请求频率限制，管理接口可查看状态、封禁客户端或调整上限
+	rateLimiter *RateLimiter
+
+	// 调试抓包，管理员开启后记录指定玩家或抽样请求的完整内容
+	debugCapture *DebugCapture
+
+	// 区域列表与玩家延迟，匹配请求优先转发到玩家延迟最低的区域
+	regions *RegionHandler
+
+	// 校验转发到游戏和匹配服务的请求的登录会话
+	auth *AuthHandler
+
+	// 排行榜定时刷新
+	leaderboardScheduler *models.LeaderboardScheduler
 }
 
 // NewGateway 创建新的网关
 func NewGateway(cfg *config.Config) *Gateway {
+	setTrustedProxies(cfg.Server.TrustedProxies)
 	return &Gateway{
-		config:   cfg,
-		services: make(map[ServiceType][]*ServiceInstance),
-		shutdown: make(chan struct{}),
+		config:        cfg,
+		services:      make(map[ServiceType][]*ServiceInstance),
+		shutdown:      make(chan struct{}),
+		adminAuth:     NewAdminAuth(&cfg.Admin),
+		responseCache: NewCacheMiddleware(),
+		rateLimiter:   NewRateLimiter(60, 10), // 每分钟60次请求，突发10次
+		debugCapture:  NewDebugCapture(cfg.DebugCapture),
 	}
 }
 
@@ -72,6 +128,37 @@ func (g *Gateway) Start() error {
 	// 启动健康检查
 	go g.healthCheck()
 
+	// 启动战绩汇总刷新任务
+	go g.statsRefresher()
+
+	// 启动对局记录归档任务
+	retention.NewService(g.config.Retention).Start(g.shutdown)
+
+	// 启动排行榜定时刷新
+	if db.RedisClient != nil {
+		g.leaderboardScheduler = models.NewLeaderboardScheduler(
+			models.NewRedisLeaderboard(),
+			g.config.Stats.LeaderboardRefreshInterval,
+			g.config.Stats.LeaderboardCheckInterval,
+		)
+		g.leaderboardScheduler.Start()
+
+		// 对局结果保存后标记排行榜待刷新
+		events.Subscribe("leaderboard", func(*events.Event) error {
+			models.MarkLeaderboardStale()
+			return nil
+		}, events.MatchEnded)
+	}
+
+	// 接收其他实例和服务发布的玩家通知
+	notify.Default.Listen(g.shutdown)
+
+	// 加载限流策略并接收其他实例的变更
+	g.rateLimiter.policies.Start(g.shutdown)
+
+	// 接收其他实例开启或停止的抓包规则
+	g.debugCapture.Watch(g.shutdown)
+
 	// 启动HTTP服务器
 	go func() {
 		log.Printf("API网关启动，监听端口: %d", g.config.Server.GatewayPort)
@@ -85,12 +172,22 @@ func (g *Gateway) Start() error {
 }
 
 // Stop 停止网关
-func (g *Gateway) Stop() error {
+func (g *Gateway) Stop(ctx context.Context) error {
 	if !g.isRunning {
 		return nil
 	}
 
 	close(g.shutdown)
+	if g.leaderboardScheduler != nil {
+		g.leaderboardScheduler.Stop()
+	}
+
+	// 停止接收新请求，等待进行中的请求完成，超时后强制关闭剩余连接
+	if err := g.httpServer.Shutdown(ctx); err != nil {
+		g.httpServer.Close()
+		return fmt.Errorf("HTTP服务器关闭错误: %w", err)
+	}
+
 	g.isRunning = false
 	log.Println("API网关已停止")
 	return nil
@@ -103,12 +200,15 @@ func (g *Gateway) RegisterService(serviceType ServiceType, serviceURL string) er
 		return fmt.Errorf("无效的服务URL: %w", err)
 	}
 
+	// 本机实例与网关部署在一起，使用网关配置的区域，客户端直连的地址与网关访问的地址相同
 	instance := &ServiceInstance{
 		ID:        fmt.Sprintf("%s-%d", serviceType, time.Now().UnixNano()),
 		Type:      serviceType,
 		URL:       parsedURL,
 		Health:    true,
 		LastCheck: time.Now(),
+		Region:    g.config.Registry.Region,
+		PublicURL: strings.TrimRight(serviceURL, "/"),
 	}
 
 	g.mutex.Lock()
@@ -149,10 +249,16 @@ func (g *Gateway) createHandler() http.Handler {
 	mux := http.NewServeMux()
 
 	// 创建各种处理器
-	authHandler := NewAuthHandler()
-	characterHandler := NewCharacterHandler()
-	profileHandler := NewProfileHandler()
-	statsHandler := NewStatsHandler()
+	players := repository.NewPostgresPlayerRepo(db.DB)
+	authHandler := NewAuthHandler(players, g.config.Session)
+	g.auth = authHandler
+	g.rateLimiter.identify = authHandler.Authenticate
+	go authHandler.maintainSessions(g.shutdown)
+	trials := trial.NewService(wallet.NewService(), g.config.Trial)
+	characterHandler := NewCharacterHandler(gamedata.CharacterRepo(repository.NewPostgresCharacterRepo(db.Cluster)),
+		progression.NewCurve(g.config.CharacterLevel), trials)
+	profileHandler := NewProfileHandler(players, authHandler)
+	statsHandler := NewStatsHandler(authHandler, repository.NewPostgresStatsRepo(db.Cluster))
 
 	// 注册认证相关路由
 	authHandler.RegisterHandlers(mux)
@@ -166,6 +272,144 @@ func (g *Gateway) createHandler() http.Handler {
 	// 注册战绩相关路由
 	statsHandler.RegisterHandlers(mux)
 
+	// 注册钱包相关路由
+	walletHandler := NewWalletHandler()
+	walletHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册外观相关路由
+	cosmeticsHandler := NewCosmeticsHandler(authHandler)
+	cosmeticsHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册玩家资产汇总路由
+	inventoryHandler := NewInventoryHandler(authHandler, players, characterHandler)
+	inventoryHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册商店相关路由
+	shopHandler := NewShopHandler(authHandler)
+	shopHandler.RegisterHandlers(mux)
+
+	// 注册限时活动路由
+	liveEventHandler := NewLiveEventHandler()
+	liveEventHandler.RegisterHandlers(mux)
+
+	// 注册通行证相关路由
+	battlePassHandler := NewBattlePassHandler(authHandler)
+	battlePassHandler.RegisterHandlers(mux)
+	battlePassHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册玩家设置路由
+	settingsHandler := NewSettingsHandler(authHandler)
+	settingsHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册赠礼相关路由
+	giftHandler := NewGiftHandler(authHandler, g.config.Gift)
+	giftHandler.RegisterHandlers(mux)
+	giftHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册称号相关路由
+	titleHandler := NewTitleHandler(authHandler)
+	titleHandler.RegisterHandlers(mux)
+	titleHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册隐私设置路由
+	privacyHandler := NewPrivacyHandler(authHandler)
+	privacyHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册货币兑换路由
+	exchangeHandler := NewExchangeHandler(authHandler, g.config.Exchange)
+	exchangeHandler.RegisterHandlers(mux)
+
+	// 注册应用内购买路由
+	paymentHandler := NewPaymentHandler(authHandler, g.config.Payments)
+	paymentHandler.RegisterHandlers(mux)
+
+	// 注册推送相关路由
+	pushService := push.NewService(g.config.Push)
+	pushHandler := NewPushHandler(authHandler)
+	pushHandler.RegisterHandlers(mux)
+
+	// 注册组队相关路由
+	partyHandler := NewPartyHandler(authHandler, g.config.Party, trials, g.matchServiceURL)
+	partyHandler.Service().SetNotifier(pushService)
+	partyHandler.RegisterHandlers(mux)
+	partyHandler.RegisterPlayerRoutes(profileHandler)
+
+	// 注册角色试玩路由
+	trialHandler := NewTrialHandler(authHandler, trials)
+	trialHandler.RegisterHandlers(mux)
+
+	// 注册屏蔽列表路由
+	socialHandler := NewSocialHandler(authHandler)
+	socialHandler.RegisterHandlers(mux)
+
+	// 注册对局收藏路由
+	bookmarkHandler := NewBookmarkHandler(authHandler)
+	bookmarkHandler.RegisterHandlers(mux)
+
+	// 注册对局回放路由
+	replayHandler := NewReplayHandler(authHandler)
+	replayHandler.RegisterHandlers(mux)
+
+	// 注册区域列表和玩家延迟路由
+	g.regions = NewRegionHandler(g, authHandler)
+	g.regions.RegisterHandlers(mux)
+	g.regions.RegisterPlayerRoutes(profileHandler)
+
+	// 注册头像相关路由
+	var avatarHandler *AvatarHandler
+	if store, err := storage.New(g.config.Storage); err != nil {
+		log.Printf("文件存储初始化失败，头像上传不可用: %v", err)
+	} else {
+		avatarHandler = NewAvatarHandler(authHandler, store, avatar.NewService(store, g.config.Avatar))
+		avatarHandler.RegisterHandlers(mux)
+		avatarHandler.RegisterPlayerRoutes(profileHandler)
+	}
+
+	// 注册邮件相关路由
+	mailHandler := NewMailHandler(authHandler, pushService)
+	mailHandler.RegisterHandlers(mux)
+
+	// 注册通知流路由
+	notificationHandler := NewNotificationHandler(authHandler, notify.Default, g.shutdown)
+	notificationHandler.RegisterHandlers(mux)
+
+	// 注册任务相关路由
+	questHandler := NewQuestHandler(authHandler)
+	questHandler.RegisterHandlers(mux)
+
+	// 注册GraphQL查询路由
+	graphQLHandler, err := NewGraphQLHandler(profileHandler, characterHandler, statsHandler)
+	if err != nil {
+		log.Printf("GraphQL接口初始化失败: %v", err)
+	} else {
+		graphQLHandler.RegisterHandlers(mux)
+	}
+
+	// 注册管理员路由
+	statsHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	walletHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	shopHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	battlePassHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	questHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	mailHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	titleHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	paymentHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	NewGameDataHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	NewCharacterGrantHandler(characterHandler.characters).RegisterAdminHandlers(mux, g.adminAuth)
+	NewDashboardHandler(g).RegisterAdminHandlers(mux, g.adminAuth)
+	NewFeatureHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	NewBalanceHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	NewRateLimitHandler(g.rateLimiter).RegisterAdminHandlers(mux, g.adminAuth)
+	NewAnnouncementHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	NewMaintenanceHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	liveEventHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	NewAuditHandler().RegisterAdminHandlers(mux, g.adminAuth)
+	NewDebugCaptureHandler(g.debugCapture).RegisterAdminHandlers(mux, g.adminAuth)
+	NewPlayerAdminHandler(players, g.rateLimiter.policies).RegisterAdminHandlers(mux, g.adminAuth)
+	if avatarHandler != nil {
+		avatarHandler.RegisterAdminHandlers(mux, g.adminAuth)
+	}
+
 	// 其他服务的API路由（转发到对应服务）
 	mux.HandleFunc("/game/", g.handleGameRequest)
 	mux.HandleFunc("/match/", g.handleMatchRequest)
@@ -176,8 +420,15 @@ func (g *Gateway) createHandler() http.Handler {
 		w.Write([]byte("OK"))
 	})
 
-	// 服务发现端点
+	// 存活与就绪探针
+	health.NewChecker("gateway").RegisterHandlers(mux)
+
+	// 服务发现与注册端点
 	mux.HandleFunc("/services", g.handleServiceDiscovery)
+	mux.HandleFunc(registry.RegisterPath, g.handleServiceRegister)
+
+	// Prometheus 指标端点
+	mux.Handle("/metrics", metrics.Handler())
 
 	// 应用中间件
 	handler := g.applyMiddleware(mux)
@@ -191,15 +442,23 @@ func (g *Gateway) applyMiddleware(handler http.Handler) http.Handler {
 	loggingMiddleware := NewLoggingMiddleware()
 	securityMiddleware := NewSecurityMiddleware()
 	corsMiddleware := NewCORSMiddleware()
-	rateLimiter := NewRateLimiter(60, 10) // 每分钟60次请求，突发10次
-	cacheMiddleware := NewCacheMiddleware()
+	msgpackMiddleware := NewMsgpackMiddleware()
 
 	// 按顺序应用中间件（从外到内）
+	// 最内层捕获处理函数的 panic 和 5xx 响应，此时请求上下文中已有限流中间件识别的会话
+	handler = recoveryMiddleware(handler)
+	handler = g.debugCapture.Middleware(handler)
 	handler = loggingMiddleware.Middleware(handler)
 	handler = securityMiddleware.Middleware(handler)
+	// 维护中拒绝玩家请求，管理接口和探针不受影响
+	handler = maintenanceMiddleware(handler)
 	handler = corsMiddleware.Middleware(handler)
-	handler = rateLimiter.Middleware(handler)
-	handler = cacheMiddleware.Middleware(handler)
+	handler = g.rateLimiter.Middleware(handler)
+	handler = g.responseCache.Middleware(handler)
+	// 在缓存之外转换编码，缓存中只保存 JSON 响应
+	handler = msgpackMiddleware.Middleware(handler)
+	// 最先协商响应语言，各层中间件的错误响应也按请求语言返回
+	handler = localeMiddleware(handler)
 
 	return handler
 }
@@ -211,22 +470,36 @@ func (g *Gateway) handleGameRequest(w http.ResponseWriter, r *http.Request) {
 
 // handleMatchRequest 处理匹配服务请求
 func (g *Gateway) handleMatchRequest(w http.ResponseWriter, r *http.Request) {
+	// 队伍匹配接口只供组队服务内部调用（已校验队长身份），不对客户端开放
+	if strings.HasPrefix(r.URL.Path, "/match/party/") {
+		sendJSONError(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
 	g.forwardRequest(w, r, ServiceMatch)
 }
 
 // forwardRequest 转发请求到指定服务
 func (g *Gateway) forwardRequest(w http.ResponseWriter, r *http.Request, serviceType ServiceType) {
 
-	// 验证认证
-	if !g.validateAuth(r) && serviceType != ServiceAuth {
-		http.Error(w, "未授权", http.StatusUnauthorized)
-		return
+	// 验证认证，下游服务通过请求头获知登录会话对应的玩家
+	r.Header.Del(PlayerIDHeader)
+	if serviceType != ServiceAuth {
+		session, ok := g.validateAuth(r)
+		if !ok {
+			sendJSONError(w, "未授权", http.StatusUnauthorized)
+			return
+		}
+		r.Header.Set(PlayerIDHeader, strconv.FormatInt(session.PlayerID, 10))
 	}
 
-	// 获取服务实例
-	instance := g.getServiceInstance(serviceType)
+	// 获取服务实例，匹配请求优先转发到玩家延迟最低的区域
+	var regions []string
+	if serviceType == ServiceMatch && g.regions != nil {
+		regions = g.regions.preferredRegions(r)
+	}
+	instance := g.getServiceInstanceIn(serviceType, regions)
 	if instance == nil {
-		http.Error(w, "服务不可用", http.StatusServiceUnavailable)
+		sendJSONError(w, "服务不可用", http.StatusServiceUnavailable)
 		return
 	}
 
@@ -244,31 +517,31 @@ func (g *Gateway) forwardRequest(w http.ResponseWriter, r *http.Request, service
 	proxy.ServeHTTP(w, r)
 }
 
-// handleServiceDiscovery 处理服务发现请求
-func (g *Gateway) handleServiceDiscovery(w http.ResponseWriter, r *http.Request) {
-	// TODO: 实现服务发现API
-	http.Error(w, "未实现", http.StatusNotImplemented)
+// ValidateSession 返回登录令牌对应的玩家，供同一进程中的匹配服务校验会话。网关尚未启动时返回 false
+func (g *Gateway) ValidateSession(token string) (int64, bool) {
+	if g.auth == nil {
+		return 0, false
+	}
+	playerID, _, ok := g.auth.ValidateToken(token)
+	return playerID, ok
 }
 
-// validateAuth 验证认证
-func (g *Gateway) validateAuth(r *http.Request) bool {
-	// 获取认证令牌
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		// 尝试从查询参数获取
-		token = r.URL.Query().Get("token")
-		if token == "" {
-			return false
-		}
+// validateAuth 验证请求携带的令牌（请求头或查询参数）对应有效的登录会话
+func (g *Gateway) validateAuth(r *http.Request) (SessionInfo, bool) {
+	if g.auth == nil {
+		return SessionInfo{}, false
 	}
-
-	// TODO: 实现真正的令牌验证
-	// 这里简单地检查令牌是否存在
-	return token != ""
+	return g.auth.Authenticate(r)
 }
 
 // getServiceInstance 获取服务实例
 func (g *Gateway) getServiceInstance(serviceType ServiceType) *ServiceInstance {
+	return g.getServiceInstanceIn(serviceType, nil)
+}
+
+// getServiceInstanceIn 按区域的优先顺序获取服务实例，靠前的区域没有可用实例时依次尝试后面的区域，
+// 都没有时在所有区域中选择
+func (g *Gateway) getServiceInstanceIn(serviceType ServiceType, regions []string) *ServiceInstance {
 	g.mutex.RLock()
 	defer g.mutex.RUnlock()
 
@@ -282,7 +555,8 @@ func (g *Gateway) getServiceInstance(serviceType ServiceType) *ServiceInstance {
 	// 例如考虑服务器负载、响应时间等
 	var healthyInstances []*ServiceInstance
 	for _, instance := range instances {
-		if instance.Health {
+		// 跳过心跳上报已满载的实例
+		if instance.Health && (instance.Capacity == 0 || instance.Load < instance.Capacity) {
 			healthyInstances = append(healthyInstances, instance)
 		}
 	}
@@ -290,14 +564,49 @@ func (g *Gateway) getServiceInstance(serviceType ServiceType) *ServiceInstance {
 	if len(healthyInstances) == 0 {
 		return nil
 	}
+	for _, region := range regions {
+		var inRegion []*ServiceInstance
+		for _, instance := range healthyInstances {
+			if regionOf(instance) == region {
+				inRegion = append(inRegion, instance)
+			}
+		}
+		if len(inRegion) > 0 {
+			healthyInstances = inRegion
+			break
+		}
+	}
 
 	// 使用时间戳作为简单的轮询机制
 	index := time.Now().UnixNano() % int64(len(healthyInstances))
 	return healthyInstances[index]
 }
 
-// registerInternalServices 注册内部服务
+// matchServiceURL 当前可用的匹配服务实例地址，供网关内部调用匹配服务
+func (g *Gateway) matchServiceURL() (string, error) {
+	instance := g.getServiceInstance(ServiceMatch)
+	if instance == nil {
+		return "", errNoInstance
+	}
+	return instance.URL.String(), nil
+}
+
+// registerInternalServices 注册内部服务。启用服务注册时游戏和匹配服务由各实例自行注册
 func (g *Gateway) registerInternalServices() {
+	if !g.selfRegistration() {
+		g.registerLocalServices()
+	}
+
+	// 注册认证服务 (内部实现)
+	authURL := fmt.Sprintf("http://localhost:%d", g.config.Server.GatewayPort)
+	if err := g.RegisterService(ServiceAuth, authURL); err != nil {
+		log.Printf("注册服务失败: %v", err)
+	}
+	log.Printf("注册服务: %s, URL: %s", ServiceAuth, authURL)
+}
+
+// registerLocalServices 未启用服务注册时，假定游戏和匹配服务运行在本机的配置端口上
+func (g *Gateway) registerLocalServices() {
 	// 注册游戏服务
 	gameURL := fmt.Sprintf("http://localhost:%d", g.config.Server.GamePort)
 	if err := g.RegisterService(ServiceGame, gameURL); err != nil {
@@ -311,13 +620,6 @@ func (g *Gateway) registerInternalServices() {
 		log.Printf("注册服务失败: %v", err)
 	}
 	log.Printf("注册服务: %s, URL: %s", ServiceMatch, matchURL)
-
-	// 注册认证服务 (内部实现)
-	authURL := fmt.Sprintf("http://localhost:%d", g.config.Server.GatewayPort)
-	if err := g.RegisterService(ServiceAuth, authURL); err != nil {
-		log.Printf("注册服务失败: %v", err)
-	}
-	log.Printf("注册服务: %s, URL: %s", ServiceAuth, authURL)
 }
 
 // healthCheck 健康检查
@@ -328,6 +630,7 @@ func (g *Gateway) healthCheck() {
 	for {
 		select {
 		case <-ticker.C:
+			g.expireInstances()
 			g.checkServicesHealth()
 		case <-g.shutdown:
 			return
@@ -344,7 +647,7 @@ func (g *Gateway) checkServicesHealth() {
 		for _, instance := range instances {
 			// 发送健康检查请求
 			healthURL := *instance.URL
-			healthURL.Path = "/health"
+			healthURL.Path = "/health/ready" // 未就绪（维护中、容量已满、依赖不可达）的实例不再分配流量
 
 			client := http.Client{
 				Timeout: 2 * time.Second,
@@ -355,14 +658,31 @@ func (g *Gateway) checkServicesHealth() {
 			// 更新健康状态
 			instance.LastCheck = time.Now()
 			if err != nil || resp.StatusCode != http.StatusOK {
+				reason := fmt.Sprintf("%v", err)
+				if err == nil {
+					reason = fmt.Sprintf("就绪检查返回状态码 %d", resp.StatusCode)
+					resp.Body.Close()
+				}
 				if instance.Health {
 					log.Printf("服务不健康: %s, ID: %s", serviceType, instance.ID)
 					instance.Health = false
+					alert.Notify(alert.Alert{
+						Key:     "instance:" + instance.ID,
+						Level:   alert.LevelCritical,
+						Title:   fmt.Sprintf("%s 服务实例不健康", serviceType),
+						Message: fmt.Sprintf("实例 %s (%s): %s", instance.ID, instance.URL, reason),
+					})
 				}
 			} else {
 				if !instance.Health {
 					log.Printf("服务恢复健康: %s, ID: %s", serviceType, instance.ID)
 					instance.Health = true
+					alert.Notify(alert.Alert{
+						Key:     "instance:" + instance.ID,
+						Level:   alert.LevelResolved,
+						Title:   fmt.Sprintf("%s 服务实例已恢复", serviceType),
+						Message: fmt.Sprintf("实例 %s (%s) 就绪检查通过", instance.ID, instance.URL),
+					})
 				}
 				resp.Body.Close()
 			}
@@ -0,0 +1,109 @@
+// gift.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gift"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/shop"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// GiftHandler 赠礼处理器
+type GiftHandler struct {
+	gifts *gift.Service
+	auth  *AuthHandler
+}
+
+// NewGiftHandler 创建赠礼处理器
+func NewGiftHandler(auth *AuthHandler, cfg config.GiftConfig) *GiftHandler {
+	w := wallet.NewService()
+	return &GiftHandler{
+		gifts: gift.NewService(w, shop.NewService(w), cfg),
+		auth:  auth,
+	}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *GiftHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/gifts", h.auth.RequireAuth(h.handleHistory))
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *GiftHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("gift", h.handleGift)
+}
+
+// handleGift 向指定玩家赠送宝石或商品
+func (h *GiftHandler) handleGift(w http.ResponseWriter, r *http.Request, recipientID int64) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+
+	var req models.SendGiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	g, err := h.gifts.Send(session.PlayerID, recipientID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, wallet.ErrPlayerNotFound), errors.Is(err, shop.ErrOfferNotFound):
+			sendServiceError(w, err, http.StatusNotFound)
+		case errors.Is(err, social.ErrBlocked), errors.Is(err, gift.ErrAccountTooNew):
+			sendServiceError(w, err, http.StatusForbidden)
+		case errors.Is(err, gift.ErrDailyLimit):
+			sendServiceError(w, err, http.StatusTooManyRequests)
+		case errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, inventory.ErrAlreadyOwned),
+			errors.Is(err, shop.ErrOfferUnavailable), errors.Is(err, gift.ErrNotGiftable):
+			sendServiceError(w, err, http.StatusConflict)
+		case errors.Is(err, gift.ErrInvalidGift), errors.Is(err, gift.ErrCannotGiftSelf):
+			sendServiceError(w, err, http.StatusBadRequest)
+		default:
+			log.Printf("玩家 %d 赠礼给玩家 %d 失败: %v", session.PlayerID, recipientID, err)
+			sendJSONError(w, "赠礼失败", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Printf("玩家 %d 赠礼给玩家 %d: %s %s %d", g.SenderID, g.RecipientID, g.Type, g.Currency, g.Amount)
+	sendJSONSuccess(w, "赠送成功", g)
+}
+
+// handleHistory 查询当前玩家发出和收到的赠礼
+func (h *GiftHandler) handleHistory(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+
+	gifts, err := gift.History(session.PlayerID, limit)
+	if err != nil {
+		log.Printf("查询玩家 %d 赠礼记录失败: %v", session.PlayerID, err)
+		sendJSONError(w, "查询赠礼记录失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", gifts)
+}
@@ -0,0 +1,361 @@
+// graphql.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 单次查询返回对局记录的最大条数
+const graphQLMaxMatches = 100
+
+// GraphQLHandler GraphQL查询处理器（复用REST处理器的数据查询）
+type GraphQLHandler struct {
+	schema     graphql.Schema
+	profile    *ProfileHandler
+	characters *CharacterHandler
+	stats      *StatsHandler
+}
+
+// GraphQLRequest GraphQL请求
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// NewGraphQLHandler 创建GraphQL处理器
+func NewGraphQLHandler(profile *ProfileHandler, characters *CharacterHandler, stats *StatsHandler) (*GraphQLHandler, error) {
+	h := &GraphQLHandler{
+		profile:    profile,
+		characters: characters,
+		stats:      stats,
+	}
+
+	schema, err := h.buildSchema()
+	if err != nil {
+		return nil, fmt.Errorf("构建GraphQL模式失败: %w", err)
+	}
+	h.schema = schema
+
+	return h, nil
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *GraphQLHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/graphql", h.handleGraphQL)
+}
+
+// handleGraphQL 处理GraphQL查询，支持 GET ?query= 和 POST JSON 两种方式
+func (h *GraphQLHandler) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req GraphQLRequest
+
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				h.sendErrorResponse(w, "无效的variables参数", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendErrorResponse(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+	default:
+		h.sendErrorResponse(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Query == "" {
+		h.sendErrorResponse(w, "查询语句不能为空", http.StatusBadRequest)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        h.stats.withViewer(r),
+	})
+	if result.HasErrors() {
+		log.Printf("GraphQL查询出错: %v", result.Errors)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		log.Printf("编码GraphQL响应失败: %v", err)
+	}
+}
+
+// sendErrorResponse 以GraphQL错误格式发送响应
+func (h *GraphQLHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	resp := map[string]interface{}{
+		"errors": []map[string]string{{"message": localize(w, errcode.FromStatus(statusCode), message)}},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码错误响应失败: %v", err)
+	}
+}
+
+// buildSchema 构建GraphQL模式
+func (h *GraphQLHandler) buildSchema() (graphql.Schema, error) {
+	skillType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Skill",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.Int},
+			"name":          &graphql.Field{Type: graphql.String},
+			"description":   &graphql.Field{Type: graphql.String},
+			"type":          &graphql.Field{Type: graphql.String},
+			"damage":        &graphql.Field{Type: graphql.Int},
+			"cooldown_time": &graphql.Field{Type: graphql.Float},
+			"range":         &graphql.Field{Type: graphql.Float},
+			"effect_time":   &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	characterType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Character",
+		Fields: graphql.Fields{
+			"id":              &graphql.Field{Type: graphql.Int},
+			"name":            &graphql.Field{Type: graphql.String},
+			"description":     &graphql.Field{Type: graphql.String},
+			"max_hp":          &graphql.Field{Type: graphql.Int},
+			"speed":           &graphql.Field{Type: graphql.Float},
+			"base_attack":     &graphql.Field{Type: graphql.Int},
+			"base_defense":    &graphql.Field{Type: graphql.Int},
+			"special_ability": &graphql.Field{Type: graphql.String},
+			"difficulty":      &graphql.Field{Type: graphql.Int},
+			"role":            &graphql.Field{Type: graphql.String},
+			"unlockable":      &graphql.Field{Type: graphql.Boolean},
+			"unlock_cost":     &graphql.Field{Type: graphql.Int},
+			"skills": &graphql.Field{
+				Type: graphql.NewList(skillType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					character := p.Source.(models.Character)
+					if character.Skills != nil {
+						return character.Skills, nil
+					}
+					return h.characters.characters.Skills(character.ID)
+				},
+			},
+		},
+	})
+
+	playerStatsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PlayerStats",
+		Fields: graphql.Fields{
+			"total_matches": &graphql.Field{Type: graphql.Int},
+			"total_wins":    &graphql.Field{Type: graphql.Int},
+			"losses":        &graphql.Field{Type: graphql.Int},
+			"win_rate":      &graphql.Field{Type: graphql.Float},
+			"total_kills":   &graphql.Field{Type: graphql.Int},
+			"total_deaths":  &graphql.Field{Type: graphql.Int},
+			"total_assists": &graphql.Field{Type: graphql.Int},
+			"kda":           &graphql.Field{Type: graphql.Float},
+			"average_score": &graphql.Field{Type: graphql.Float},
+			"total_mvp":     &graphql.Field{Type: graphql.Int},
+			"play_time":     &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	matchType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PlayerMatch",
+		Fields: graphql.Fields{
+			"match_id":     &graphql.Field{Type: graphql.String},
+			"character_id": &graphql.Field{Type: graphql.Int},
+			"team":         &graphql.Field{Type: graphql.Int},
+			"score":        &graphql.Field{Type: graphql.Int},
+			"kills":        &graphql.Field{Type: graphql.Int},
+			"deaths":       &graphql.Field{Type: graphql.Int},
+			"assists":      &graphql.Field{Type: graphql.Int},
+			"exp_gained":   &graphql.Field{Type: graphql.Int},
+			"coins_gained": &graphql.Field{Type: graphql.Int},
+			"mvp":          &graphql.Field{Type: graphql.Boolean},
+			"won":          &graphql.Field{Type: graphql.Boolean},
+			"play_time":    &graphql.Field{Type: graphql.Int},
+			"join_time":    &graphql.Field{Type: graphql.DateTime},
+			"leave_time":   &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	characterStatsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PlayerCharacterStats",
+		Fields: graphql.Fields{
+			"character_id":   &graphql.Field{Type: graphql.Int},
+			"character_name": &graphql.Field{Type: graphql.String},
+			"level":          &graphql.Field{Type: graphql.Int},
+			"games":          &graphql.Field{Type: graphql.Int},
+			"wins":           &graphql.Field{Type: graphql.Int},
+			"win_rate":       &graphql.Field{Type: graphql.Float},
+			"kills":          &graphql.Field{Type: graphql.Int},
+			"deaths":         &graphql.Field{Type: graphql.Int},
+			"assists":        &graphql.Field{Type: graphql.Int},
+			"kda":            &graphql.Field{Type: graphql.Float},
+			"play_time":      &graphql.Field{Type: graphql.Int},
+			"last_played_at": &graphql.Field{Type: graphql.DateTime},
+		},
+	})
+
+	playerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Player",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: graphql.Int},
+			"username":      &graphql.Field{Type: graphql.String},
+			"created_at":    &graphql.Field{Type: graphql.DateTime},
+			"level":         &graphql.Field{Type: graphql.Int},
+			"exp":           &graphql.Field{Type: graphql.Int},
+			"total_kills":   &graphql.Field{Type: graphql.Int},
+			"total_deaths":  &graphql.Field{Type: graphql.Int},
+			"total_assists": &graphql.Field{Type: graphql.Int},
+			"total_matches": &graphql.Field{Type: graphql.Int},
+			"total_wins":    &graphql.Field{Type: graphql.Int},
+			"stats": &graphql.Field{
+				Type: playerStatsType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					playerID := p.Source.(*models.Player).ID
+					if err := checkViewerPrivacy(p.Context, playerID, privacy.FieldStats); err != nil {
+						return nil, err
+					}
+					return h.stats.stats.PlayerStats(playerID)
+				},
+			},
+			"matches": &graphql.Field{
+				Type: graphql.NewList(matchType),
+				Args: graphql.FieldConfigArgument{
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					limit, _ := p.Args["limit"].(int)
+					offset, _ := p.Args["offset"].(int)
+					if limit <= 0 || limit > graphQLMaxMatches {
+						limit = graphQLMaxMatches
+					}
+					if offset < 0 {
+						offset = 0
+					}
+					playerID := p.Source.(*models.Player).ID
+					if err := checkViewerPrivacy(p.Context, playerID, privacy.FieldMatchHistory); err != nil {
+						return nil, err
+					}
+					matches, _, err := h.stats.stats.Matches(playerID, limit, offset)
+					return matches, err
+				},
+			},
+			"characters": &graphql.Field{
+				Type: graphql.NewList(characterStatsType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					playerID := p.Source.(*models.Player).ID
+					if err := checkViewerPrivacy(p.Context, playerID, privacy.FieldStats); err != nil {
+						return nil, err
+					}
+					return h.stats.stats.CharacterStats(playerID)
+				},
+			},
+		},
+	})
+
+	leaderboardEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LeaderboardEntry",
+		Fields: graphql.Fields{
+			"rank":        &graphql.Field{Type: graphql.Int},
+			"player_id":   &graphql.Field{Type: graphql.Int},
+			"username":    &graphql.Field{Type: graphql.String},
+			"avatar_url":  &graphql.Field{Type: graphql.String},
+			"level":       &graphql.Field{Type: graphql.Int},
+			"total_kills": &graphql.Field{Type: graphql.Int},
+			"total_wins":  &graphql.Field{Type: graphql.Int},
+			"win_rate":    &graphql.Field{Type: graphql.Float},
+			"kda":         &graphql.Field{Type: graphql.Float},
+			"score":       &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"player": &graphql.Field{
+				Type: playerType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					player, err := h.profile.players.GetByID(int64(p.Args["id"].(int)))
+					if errors.Is(err, repository.ErrNotFound) {
+						return nil, nil
+					}
+					return player, err
+				},
+			},
+			"characters": &graphql.Field{
+				Type: graphql.NewList(characterType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.characters.characters.All()
+				},
+			},
+			"character": &graphql.Field{
+				Type: characterType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					character, err := h.characters.characters.GetByID(p.Args["id"].(int))
+					if errors.Is(err, repository.ErrNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return *character, nil
+				},
+			},
+			"leaderboard": &graphql.Field{
+				Type: graphql.NewList(leaderboardEntryType),
+				Args: graphql.FieldConfigArgument{
+					"type":  &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: string(models.LeaderboardScore)},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					leaderboardType := models.LeaderboardType(p.Args["type"].(string))
+					if !isValidLeaderboardType(leaderboardType) {
+						return nil, fmt.Errorf("无效的排行榜类型: %s", leaderboardType)
+					}
+					limit, _ := p.Args["limit"].(int)
+					if limit <= 0 || limit > 100 {
+						limit = 10
+					}
+					return h.stats.getLeaderboard(leaderboardType, limit)
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// isValidLeaderboardType 检查排行榜类型是否有效
+func isValidLeaderboardType(t models.LeaderboardType) bool {
+	switch t {
+	case models.LeaderboardKills, models.LeaderboardWins, models.LeaderboardScore, models.LeaderboardKDA:
+		return true
+	}
+	return false
+}
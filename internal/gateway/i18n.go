@@ -0,0 +1,33 @@
+// i18n.go
+
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+)
+
+// localeMiddleware 协商响应语言，记录在 Content-Language 响应头中。
+// 各层中间件包装的 ResponseWriter 共用同一组响应头，发送响应时据此取得语言
+func localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", string(i18n.FromRequest(r)))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localeOf 响应使用的语言
+func localeOf(w http.ResponseWriter) i18n.Locale {
+	if l, ok := i18n.Parse(w.Header().Get("Content-Language")); ok {
+		return l
+	}
+	return i18n.Default
+}
+
+// localize 按响应语言翻译提示信息，无译文时错误响应退回错误码的通用说明
+func localize(w http.ResponseWriter, code errcode.Code, message string) string {
+	return i18n.Message(localeOf(w), code, message)
+}
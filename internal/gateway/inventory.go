@@ -0,0 +1,121 @@
+// inventory.go
+
+package gateway
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+)
+
+// InventoryHandler 玩家资产汇总处理器，客户端主界面一次请求获取角色、外观、消耗品和货币
+type InventoryHandler struct {
+	auth       *AuthHandler
+	players    repository.PlayerRepo
+	characters *CharacterHandler
+}
+
+// NewInventoryHandler 创建玩家资产汇总处理器
+func NewInventoryHandler(auth *AuthHandler, players repository.PlayerRepo, characters *CharacterHandler) *InventoryHandler {
+	return &InventoryHandler{auth: auth, players: players, characters: characters}
+}
+
+// InventoryCurrencies 玩家货币余额
+type InventoryCurrencies struct {
+	Coins int64 `json:"coins"`
+	Gems  int64 `json:"gems"`
+}
+
+// PlayerInventory 玩家资产汇总
+type PlayerInventory struct {
+	Characters  []models.Character         `json:"characters"` // 已解锁和试玩中的角色
+	Cosmetics   []inventory.CosmeticItem   `json:"cosmetics"`
+	Consumables []inventory.ConsumableItem `json:"consumables"`
+	Currencies  InventoryCurrencies        `json:"currencies"`
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *InventoryHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("inventory", h.handleInventory)
+}
+
+// handleInventory 查询玩家资产汇总（仅限本人），If-None-Match 与当前ETag一致时返回304
+func (h *InventoryHandler) handleInventory(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+	if session.PlayerID != playerID {
+		sendJSONError(w, "只能查看自己的资产", http.StatusForbidden)
+		return
+	}
+
+	inv, err := h.load(playerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			sendJSONError(w, "玩家不存在", http.StatusNotFound)
+			return
+		}
+		log.Printf("查询玩家 %d 资产失败: %v", playerID, err)
+		sendJSONError(w, "查询资产失败", http.StatusInternalServerError)
+		return
+	}
+
+	// ETag 只取决于资产数据，不受提示语言影响
+	data, err := json.Marshal(inv)
+	if err != nil {
+		log.Printf("编码玩家 %d 资产失败: %v", playerID, err)
+		sendJSONError(w, "查询资产失败", http.StatusInternalServerError)
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, md5.Sum(data))
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", json.RawMessage(data))
+}
+
+// load 汇总玩家的角色、外观、消耗品和货币
+func (h *InventoryHandler) load(playerID int64) (*PlayerInventory, error) {
+	player, err := h.players.GetByID(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	characters, err := h.characters.playerCharacters(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	cosmetics, err := inventory.OwnedCosmetics(playerID)
+	if err != nil {
+		return nil, err
+	}
+	consumables, err := inventory.OwnedConsumables(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PlayerInventory{
+		Characters:  characters,
+		Cosmetics:   cosmetics,
+		Consumables: consumables,
+		Currencies:  InventoryCurrencies{Coins: player.Coins, Gems: player.Gems},
+	}, nil
+}
@@ -0,0 +1,127 @@
+// liveevent.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// LiveEventHandler 限时活动处理器
+type LiveEventHandler struct{}
+
+// NewLiveEventHandler 创建限时活动处理器
+func NewLiveEventHandler() *LiveEventHandler {
+	return &LiveEventHandler{}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *LiveEventHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/events/active", h.handleActive)
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *LiveEventHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/events", admin.Wrap(h.handleAdminEvents))
+	mux.HandleFunc("/admin/events/", admin.Wrap(h.handleAdminEvent))
+}
+
+// handleActive 查询进行中的活动、活动模式和商店折扣: GET /events/active
+func (h *LiveEventHandler) handleActive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", liveevent.Current())
+}
+
+// handleAdminEvents 管理活动: GET 全部活动, POST 创建
+func (h *LiveEventHandler) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		events, err := liveevent.List()
+		if err != nil {
+			log.Printf("查询活动失败: %v", err)
+			sendJSONError(w, "查询活动失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", events)
+	case http.MethodPost:
+		var event models.LiveEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := liveevent.Create(&event); err != nil {
+			h.sendError(w, "创建活动失败", err)
+			return
+		}
+		log.Printf("管理员创建活动 #%d %s: %s - %s", event.ID, event.Name, event.StartsAt, event.EndsAt)
+		sendJSONSuccess(w, "创建成功", event)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminEvent 管理单个活动: GET 查询, PUT 更新, DELETE 停用
+func (h *LiveEventHandler) handleAdminEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/admin/events/"), 10, 64)
+	if err != nil {
+		sendJSONError(w, "无效的活动ID", http.StatusBadRequest)
+		return
+	}
+
+	before, err := liveevent.Get(eventID)
+	if err != nil {
+		h.sendError(w, "查询活动失败", err)
+		return
+	}
+	auditBefore(r, before)
+
+	switch r.Method {
+	case http.MethodGet:
+		sendJSONSuccess(w, "查询成功", before)
+	case http.MethodPut:
+		var event models.LiveEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		event.ID = eventID
+		if err := liveevent.Update(&event); err != nil {
+			h.sendError(w, "更新活动失败", err)
+			return
+		}
+		log.Printf("管理员更新活动 #%d %s: %s - %s", event.ID, event.Name, event.StartsAt, event.EndsAt)
+		sendJSONSuccess(w, "更新成功", event)
+	case http.MethodDelete:
+		if err := liveevent.Disable(eventID); err != nil {
+			h.sendError(w, "停用活动失败", err)
+			return
+		}
+		log.Printf("管理员停用活动 #%d", eventID)
+		sendJSONSuccess(w, "停用成功", nil)
+	default:
+		sendJSONError(w, "仅支持GET、PUT和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 按错误类型返回活动错误
+func (h *LiveEventHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, liveevent.ErrEventNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, liveevent.ErrInvalidEvent):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
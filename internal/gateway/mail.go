@@ -0,0 +1,189 @@
+// mail.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/mail"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// MailHandler 邮件处理器
+type MailHandler struct {
+	mail *mail.Service
+	auth *AuthHandler
+}
+
+// NewMailHandler 创建邮件处理器，新邮件通过 notifier 通知收件人
+func NewMailHandler(auth *AuthHandler, notifier mail.Notifier) *MailHandler {
+	return &MailHandler{
+		mail: mail.NewService(wallet.NewService(), notifier),
+		auth: auth,
+	}
+}
+
+// InboxData 收件箱响应数据
+type InboxData struct {
+	Mails  []models.Mail `json:"mails"`
+	Total  int           `json:"total"`
+	Unread int           `json:"unread"`
+	Page   int           `json:"page"`
+	Limit  int           `json:"limit"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *MailHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/mail", h.auth.RequireAuth(h.handleInbox))
+	mux.HandleFunc("/mail/claim-all", h.auth.RequireAuth(h.handleClaimAll))
+	mux.HandleFunc("/mail/", h.auth.RequireAuth(h.handleMail))
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *MailHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/mail", admin.Wrap(h.handleAdminSend))
+}
+
+// handleInbox 查询收件箱
+func (h *MailHandler) handleInbox(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 20
+	page := 1
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	mails, total, unread, err := h.mail.Inbox(session.PlayerID, limit, (page-1)*limit)
+	if err != nil {
+		log.Printf("查询玩家 %d 收件箱失败: %v", session.PlayerID, err)
+		sendJSONError(w, "查询邮件失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", InboxData{
+		Mails:  mails,
+		Total:  total,
+		Unread: unread,
+		Page:   page,
+		Limit:  limit,
+	})
+}
+
+// handleMail 处理单封邮件: GET /mail/{id} 查看, DELETE /mail/{id} 删除, POST /mail/{id}/claim 领取附件
+func (h *MailHandler) handleMail(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/mail/"), "/")
+	mailID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || mailID <= 0 {
+		sendJSONError(w, "无效的邮件ID", http.StatusBadRequest)
+		return
+	}
+
+	if len(parts) == 2 && parts[1] == "claim" {
+		if r.Method != http.MethodPost {
+			sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+			return
+		}
+		m, err := h.mail.Claim(session.PlayerID, mailID)
+		if err != nil {
+			h.sendError(w, "领取附件失败", err)
+			return
+		}
+		sendJSONSuccess(w, "领取成功", m)
+		return
+	}
+	if len(parts) != 1 {
+		sendJSONError(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		m, err := h.mail.Read(session.PlayerID, mailID)
+		if err != nil {
+			h.sendError(w, "查询邮件失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", m)
+	case http.MethodDelete:
+		if err := h.mail.Delete(session.PlayerID, mailID); err != nil {
+			h.sendError(w, "删除邮件失败", err)
+			return
+		}
+		sendJSONSuccess(w, "删除成功", nil)
+	default:
+		sendJSONError(w, "仅支持GET和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleClaimAll 一键领取全部附件
+func (h *MailHandler) handleClaimAll(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	claimed, err := h.mail.ClaimAll(session.PlayerID)
+	if err != nil {
+		h.sendError(w, "领取附件失败", err)
+		return
+	}
+	sendJSONSuccess(w, "领取成功", claimed)
+}
+
+// handleAdminSend 发送系统邮件（支持群发）
+func (h *MailHandler) handleAdminSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.SendMailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.mail.Send(&req)
+	if err != nil {
+		h.sendError(w, "发送邮件失败", err)
+		return
+	}
+
+	log.Printf("管理员发送邮件 %q (批次 %s) 给 %d 名玩家", req.Title, result.BatchID, result.Recipients)
+	sendJSONSuccess(w, "发送成功", result)
+}
+
+// sendError 按错误类型返回邮件错误
+func (h *MailHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, mail.ErrMailNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, mail.ErrAlreadyClaimed), errors.Is(err, mail.ErrNoAttachments),
+		errors.Is(err, mail.ErrUnclaimedAttachments):
+		sendServiceError(w, err, http.StatusConflict)
+	case errors.Is(err, mail.ErrInvalidMail), errors.Is(err, inventory.ErrItemNotFound):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
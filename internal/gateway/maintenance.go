@@ -0,0 +1,101 @@
+// maintenance.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// maintenanceExemptPrefixes 维护中仍可访问的路径：管理接口、健康检查、指标和服务发现
+var maintenanceExemptPrefixes = []string{"/admin/", "/health", "/metrics", "/services"}
+
+// maintenanceMiddleware 维护中拒绝玩家请求，返回 503 和预计恢复时间
+func maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !maintenance.Active() || maintenanceExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if retry := maintenance.RetryAfter(); retry > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())+1))
+		}
+		sendServiceError(w, maintenance.ErrInMaintenance, http.StatusServiceUnavailable)
+	})
+}
+
+// maintenanceExempt 路径是否不受维护模式限制
+func maintenanceExempt(path string) bool {
+	for _, prefix := range maintenanceExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceHandler 计划维护管理处理器
+type MaintenanceHandler struct{}
+
+// NewMaintenanceHandler 创建计划维护管理处理器
+func NewMaintenanceHandler() *MaintenanceHandler {
+	return &MaintenanceHandler{}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *MaintenanceHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/maintenance", admin.Wrap(h.handleMaintenance))
+}
+
+// handleMaintenance 查看维护状态: GET；计划维护: POST；取消维护: DELETE /admin/maintenance
+func (h *MaintenanceHandler) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sendJSONSuccess(w, "获取成功", maintenance.Status())
+	case http.MethodPost:
+		var req models.ScheduleMaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		window, err := maintenance.Schedule(&req)
+		if err != nil {
+			h.sendError(w, "计划维护失败", err)
+			return
+		}
+		log.Printf("管理员计划维护 #%d: %s - %s", window.ID, window.StartsAt, window.EndsAt)
+		sendJSONSuccess(w, "维护已计划", window)
+	case http.MethodDelete:
+		window, err := maintenance.Cancel()
+		if err != nil {
+			h.sendError(w, "取消维护失败", err)
+			return
+		}
+		log.Printf("管理员取消维护 #%d", window.ID)
+		sendJSONSuccess(w, "维护已取消", window)
+	default:
+		sendJSONError(w, "仅支持GET、POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 按错误类型返回维护计划错误
+func (h *MaintenanceHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, maintenance.ErrInvalidSchedule):
+		sendServiceError(w, err, http.StatusBadRequest)
+	case errors.Is(err, maintenance.ErrAlreadyScheduled):
+		sendServiceError(w, err, http.StatusConflict)
+	case errors.Is(err, maintenance.ErrNotScheduled):
+		sendServiceError(w, err, http.StatusNotFound)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
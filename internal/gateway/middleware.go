@@ -7,14 +7,26 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
 )
 
 // RateLimiter 请求频率限制器
 type RateLimiter struct {
 	clients map[string]*ClientInfo
 	mutex   sync.RWMutex
+
+	// 客户端封禁和请求上限调整
+	policies *ClientPolicies
+
+	// identify 识别请求所属账号，已登录的请求按账号计数，否则按IP计数
+	identify func(r *http.Request) (SessionInfo, bool)
 	
 	// 配置
 	RequestsPerMinute int
@@ -35,7 +47,9 @@ func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
 		RequestsPerMinute: requestsPerMinute,
 		BurstSize:         burstSize,
 		CleanupInterval:   5 * time.Minute,
+		policies:          NewClientPolicies(),
 	}
+	rl.policies.onClear = rl.Reset
 	
 	// 启动清理协程
 	go rl.cleanup()
@@ -46,12 +60,31 @@ func NewRateLimiter(requestsPerMinute, burstSize int) *RateLimiter {
 // Middleware 频率限制中间件
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 获取客户端IP
-		clientIP := rl.getClientIP(r)
-		
+		// 获取客户端IP，已登录的请求按账号计数
+		ipClient := "ip:" + rl.getClientIP(r)
+		client := ipClient
+		if rl.identify != nil {
+			if session, ok := rl.identify(r); ok {
+				client = fmt.Sprintf("player:%d", session.PlayerID)
+				r = withSession(r, session)
+			}
+		}
+
+		// 检查封禁和调整后的上限，账号策略优先于IP策略
+		limit := rl.RequestsPerMinute
+		if policy, ok := rl.policies.Lookup(client, ipClient); ok {
+			if policy.Banned {
+				sendServiceError(w, errClientBanned, http.StatusForbidden)
+				return
+			}
+			if policy.Limit > 0 {
+				limit = policy.Limit
+			}
+		}
+
 		// 检查频率限制
-		if !rl.allowRequest(clientIP) {
-			rl.sendRateLimitError(w)
+		if !rl.allowRequest(client, limit) {
+			rl.sendRateLimitError(w, limit)
 			return
 		}
 		
@@ -60,7 +93,7 @@ func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
 }
 
 // allowRequest 检查是否允许请求
-func (rl *RateLimiter) allowRequest(clientIP string) bool {
+func (rl *RateLimiter) allowRequest(clientIP string, limit int) bool {
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 	
@@ -90,7 +123,7 @@ func (rl *RateLimiter) allowRequest(clientIP string) bool {
 	client.Requests = validRequests
 	
 	// 检查是否超过限制
-	if len(client.Requests) >= rl.RequestsPerMinute {
+	if len(client.Requests) >= limit {
 		return false
 	}
 	
@@ -102,41 +135,123 @@ func (rl *RateLimiter) allowRequest(clientIP string) bool {
 
 // getClientIP 获取客户端IP
 func (rl *RateLimiter) getClientIP(r *http.Request) string {
-	// 检查X-Forwarded-For头
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		return xff
+	return clientIP(r)
+}
+
+// trustedProxies 网关前的受信任反向代理，启动时由 setTrustedProxies 设置，之后只读
+var trustedProxies []netip.Prefix
+
+// setTrustedProxies 设置受信任的反向代理，无法解析的地址已在配置校验时拒绝
+func setTrustedProxies(proxies []string) {
+	trustedProxies = trustedProxies[:0]
+	for _, proxy := range proxies {
+		if prefix, err := config.ParseTrustedProxy(proxy); err == nil {
+			trustedProxies = append(trustedProxies, prefix)
+		}
 	}
-	
-	// 检查X-Real-IP头
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		return xri
+}
+
+// trustedProxy 地址是否为受信任的反向代理
+func trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
 	}
-	
-	// 使用RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 获取请求的客户端IP，限流、封禁和管理操作审计使用。
+// 只有对端是受信任的代理时才读取 X-Forwarded-For 和 X-Real-IP，避免客户端伪造请求头绕过按IP的限制
+func clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		return r.RemoteAddr
+		remote = r.RemoteAddr
 	}
-	
-	return ip
+	if !trustedProxy(remote) {
+		return remote
+	}
+
+	// 从右往左跳过受信任的代理，第一个不受信任的地址为客户端，前面的地址可能由客户端伪造
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || !trustedProxy(hop) {
+				return hop
+			}
+		}
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		return xri
+	}
+	return remote
 }
 
 // sendRateLimitError 发送频率限制错误响应
-func (rl *RateLimiter) sendRateLimitError(w http.ResponseWriter) {
+func (rl *RateLimiter) sendRateLimitError(w http.ResponseWriter, limit int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusTooManyRequests)
 	
 	response := map[string]interface{}{
 		"success": false,
-		"message": fmt.Sprintf("请求过于频繁，每分钟最多允许 %d 次请求", rl.RequestsPerMinute),
-		"code":    "RATE_LIMIT_EXCEEDED",
+		"message": localize(w, errcode.RateLimited, fmt.Sprintf("请求过于频繁，每分钟最多允许 %d 次请求", limit)),
+		"code":    errcode.RateLimited,
 	}
 	
 	json.NewEncoder(w).Encode(response)
 }
 
+// Clients 本实例各客户端最近一分钟的请求数，prefix 非空时只返回以其开头的客户端
+func (rl *RateLimiter) Clients(prefix string) []RateLimitClient {
+	cutoff := time.Now().Add(-time.Minute)
+
+	rl.mutex.RLock()
+	result := make([]RateLimitClient, 0, len(rl.clients))
+	for key, client := range rl.clients {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		requests := 0
+		for _, reqTime := range client.Requests {
+			if reqTime.After(cutoff) {
+				requests++
+			}
+		}
+		result = append(result, RateLimitClient{
+			Client:   key,
+			Requests: requests,
+			Limit:    rl.RequestsPerMinute,
+			LastSeen: client.LastSeen,
+		})
+	}
+	rl.mutex.RUnlock()
+
+	for i := range result {
+		if policy, ok := rl.policies.Lookup(result[i].Client); ok {
+			result[i].Policy = &policy
+			if policy.Limit > 0 {
+				result[i].Limit = policy.Limit
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Requests > result[j].Requests })
+	return result
+}
+
+// Reset 清除客户端在本实例上的请求计数
+func (rl *RateLimiter) Reset(client string) {
+	rl.mutex.Lock()
+	delete(rl.clients, client)
+	rl.mutex.Unlock()
+}
+
 // cleanup 清理过期的客户端信息
 func (rl *RateLimiter) cleanup() {
 	ticker := time.NewTicker(rl.CleanupInterval)
@@ -205,6 +320,7 @@ func (cm *CORSMiddleware) Middleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", "*") // 生产环境应该更严格
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+		w.Header().Set("Access-Control-Expose-Headers", SessionExpiresHeader)
 		w.Header().Set("Access-Control-Max-Age", "86400")
 		
 		// 处理预检请求
@@ -262,3 +378,8 @@ func (rr *responseRecorder) WriteHeader(code int) {
 	rr.statusCode = code
 	rr.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap 供 http.ResponseController 刷新流式响应
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
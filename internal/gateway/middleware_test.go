@@ -0,0 +1,45 @@
+// middleware_test.go
+
+package gateway
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	setTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	defer setTrustedProxies(nil)
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		xri    string
+		want   string
+	}{
+		{name: "没有代理", remote: "203.0.113.5:4000", want: "203.0.113.5"},
+		{name: "不受信任的对端忽略转发头", remote: "203.0.113.5:4000", xff: "1.2.3.4", xri: "5.6.7.8", want: "203.0.113.5"},
+		{name: "受信任的代理", remote: "10.0.0.2:4000", xff: "198.51.100.7", want: "198.51.100.7"},
+		{name: "跳过多级受信任代理", remote: "10.0.0.2:4000", xff: "198.51.100.7, 192.168.1.1, 10.1.2.3", want: "198.51.100.7"},
+		{name: "不采用客户端伪造的前缀", remote: "10.0.0.2:4000", xff: "1.2.3.4, 198.51.100.7", want: "198.51.100.7"},
+		{name: "全部为受信任代理时取最左", remote: "10.0.0.2:4000", xff: "10.9.9.9, 10.1.1.1", want: "10.9.9.9"},
+		{name: "受信任的代理只设置 X-Real-IP", remote: "192.168.1.1:4000", xri: "198.51.100.8", want: "198.51.100.8"},
+		{name: "受信任的代理没有转发头", remote: "10.0.0.2:4000", want: "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if tt.xri != "" {
+				r.Header.Set("X-Real-IP", tt.xri)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
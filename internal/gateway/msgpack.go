@@ -0,0 +1,131 @@
+// msgpack.go
+
+package gateway
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/codec"
+)
+
+// MsgpackMiddleware MessagePack 内容协商中间件。Accept 中优先 MessagePack 的请求，
+// 其 JSON 响应转换为 MessagePack；Content-Type 为 MessagePack 的请求体转换为 JSON 交给处理器
+type MsgpackMiddleware struct{}
+
+// NewMsgpackMiddleware 创建 MessagePack 内容协商中间件
+func NewMsgpackMiddleware() *MsgpackMiddleware {
+	return &MsgpackMiddleware{}
+}
+
+// Middleware MessagePack 内容协商中间件
+func (mm *MsgpackMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		if codec.IsMsgpack(r.Header.Get("Content-Type")) {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				sendJSONError(w, "读取请求体失败", http.StatusBadRequest)
+				return
+			}
+			data, err := codec.MsgpackToJSON(body)
+			if err != nil {
+				sendJSONError(w, "无效的MessagePack请求体", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			r.ContentLength = int64(len(data))
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		if !codec.AcceptsMsgpack(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mw := &msgpackResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(mw, r)
+		mw.finish()
+	})
+}
+
+// msgpackResponseWriter 缓存 JSON 响应，处理结束后转换为 MessagePack 写出；其他类型的响应直接透传
+type msgpackResponseWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	buffering   bool
+	body        bytes.Buffer
+}
+
+// WriteHeader 按响应的 Content-Type 决定是否缓存
+func (w *msgpackResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.statusCode = statusCode
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if mediaType == "application/json" {
+		w.buffering = true
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write 写入响应体
+func (w *msgpackResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffering {
+		return w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush 透传流式响应的刷新
+func (w *msgpackResponseWriter) Flush() {
+	if w.buffering {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (w *msgpackResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish 将缓存的 JSON 响应转换为 MessagePack 写出，转换失败时按原 JSON 写出
+func (w *msgpackResponseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+
+	data := w.body.Bytes()
+	if w.body.Len() > 0 {
+		if converted, err := codec.JSONToMsgpack(data); err != nil {
+			log.Printf("转换MessagePack响应失败: %v", err)
+		} else {
+			data = converted
+			w.Header().Set("Content-Type", codec.ContentTypeMsgpack)
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	w.ResponseWriter.Write(data)
+}
@@ -0,0 +1,108 @@
+// notification.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/notify"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/i18n"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+const (
+	// sseKeepAlive SSE 连接的保活注释间隔，避免代理关闭空闲连接
+	sseKeepAlive = 25 * time.Second
+
+	// sseRetry 建议客户端断线后的重连间隔(毫秒)
+	sseRetry = 5000
+)
+
+// NotificationHandler 通知流处理器，供启动器、网页资料页等不维持游戏连接的轻量客户端
+// 通过 Server-Sent Events 接收组队邀请、新邮件、匹配成功等通知
+type NotificationHandler struct {
+	auth *AuthHandler
+	hub  *notify.Hub
+
+	// 网关关闭时结束所有通知流，否则 HTTP 服务器关闭时会一直等待这些长连接
+	shutdown <-chan struct{}
+}
+
+// NewNotificationHandler 创建通知流处理器
+func NewNotificationHandler(auth *AuthHandler, hub *notify.Hub, shutdown <-chan struct{}) *NotificationHandler {
+	metrics.Default.GaugeFunc("pixelstorm_sse_connections",
+		"当前连接的 SSE 通知流数", func() float64 { return float64(hub.Count()) })
+	return &NotificationHandler{auth: auth, hub: hub, shutdown: shutdown}
+}
+
+// RegisterHandlers 注册HTTP处理器。浏览器的 EventSource 无法设置请求头，可通过 token 查询参数认证
+func (h *NotificationHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/notifications/stream", h.auth.RequireAuth(requireFeature(feature.NotificationStream, h.handleStream)))
+}
+
+// handleStream GET 以 SSE 推送玩家通知，事件名为通知类别，数据为通知 JSON
+func (h *NotificationHandler) handleStream(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "方法不允许", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sub := h.hub.Subscribe(session.PlayerID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	if err := rc.Flush(); err != nil {
+		log.Printf("SSE 响应不支持刷新: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(sseKeepAlive)
+	defer ticker.Stop()
+
+	var id int64
+	for {
+		select {
+		case n := <-sub.C:
+			id++
+			if err := writeEvent(w, id, n); err != nil {
+				log.Printf("写入玩家 %d 的通知失败: %v", session.PlayerID, err)
+				continue
+			}
+		case <-ticker.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case <-r.Context().Done():
+			return
+		case <-h.shutdown:
+			return
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent 按连接语言写出一条 SSE 事件
+func writeEvent(w http.ResponseWriter, id int64, n *models.PushNotification) error {
+	localized := *n
+	localized.Title = i18n.Translate(localeOf(w), n.Title)
+	localized.Body = i18n.Translate(localeOf(w), n.Body)
+	data, err := json.Marshal(&localized)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, n.Category, data)
+	return err
+}
@@ -0,0 +1,358 @@
+// party.go
+
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/party"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+)
+
+// PartyHandler 组队处理器
+type PartyHandler struct {
+	party *party.Service
+	auth  *AuthHandler
+}
+
+// NewPartyHandler 创建组队处理器，matchURL 返回当前可用的匹配服务地址
+func NewPartyHandler(auth *AuthHandler, cfg config.PartyConfig, trials *trial.Service, matchURL func() (string, error)) *PartyHandler {
+	queuer := &matchQueuer{
+		baseURL: matchURL,
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+	return &PartyHandler{
+		party: party.NewService(cfg, queuer, trials),
+		auth:  auth,
+	}
+}
+
+// Service 返回组队服务，用于接入邀请通知
+func (h *PartyHandler) Service() *party.Service {
+	return h.party
+}
+
+// PartyInviteRequest 组队邀请请求
+type PartyInviteRequest struct {
+	PlayerID int64 `json:"player_id"`
+}
+
+// PartyCharacterRequest 选择角色请求
+type PartyCharacterRequest struct {
+	CharacterID int `json:"character_id"`
+}
+
+// PartyChatRequest 队伍聊天请求
+type PartyChatRequest struct {
+	Text string `json:"text"`
+}
+
+// PartyQueueRequest 队伍匹配请求
+type PartyQueueRequest struct {
+	GameMode models.GameMode `json:"game_mode"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *PartyHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/party", h.auth.RequireAuth(h.handleParty))
+	mux.HandleFunc("/party/invites", h.auth.RequireAuth(h.handleInvites))
+	mux.HandleFunc("/party/invites/", h.auth.RequireAuth(h.handleInviteAction))
+	mux.HandleFunc("/party/members/", h.auth.RequireAuth(h.handleKick))
+	mux.HandleFunc("/party/character", h.auth.RequireAuth(h.handleCharacter))
+	mux.HandleFunc("/party/chat", h.auth.RequireAuth(h.handleChat))
+	mux.HandleFunc("/party/queue", h.auth.RequireAuth(h.handleQueue))
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *PartyHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("presence", h.handlePresence)
+}
+
+// handleParty GET 查询当前队伍，POST 创建队伍，DELETE 离开队伍
+func (h *PartyHandler) handleParty(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		p, err := h.party.Get(session.PlayerID)
+		if err != nil {
+			h.sendError(w, "查询队伍失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", p)
+	case http.MethodPost:
+		p, err := h.party.Create(session.PlayerID)
+		if err != nil {
+			h.sendError(w, "创建队伍失败", err)
+			return
+		}
+		sendJSONSuccess(w, "创建成功", p)
+	case http.MethodDelete:
+		if err := h.party.Leave(session.PlayerID); err != nil {
+			h.sendError(w, "离开队伍失败", err)
+			return
+		}
+		sendJSONSuccess(w, "已离开队伍", nil)
+	default:
+		sendJSONError(w, "仅支持GET、POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleInvites GET 查询收到的邀请，POST 邀请玩家加入队伍
+func (h *PartyHandler) handleInvites(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		sendJSONSuccess(w, "查询成功", h.party.Invites(session.PlayerID))
+	case http.MethodPost:
+		var req PartyInviteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		invite, err := h.party.Invite(session.PlayerID, req.PlayerID)
+		if err != nil {
+			h.sendError(w, "邀请失败", err)
+			return
+		}
+		sendJSONSuccess(w, "邀请已发送", invite)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleInviteAction 处理邀请: POST /party/invites/{party_id}/accept 或 /decline
+func (h *PartyHandler) handleInviteAction(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/party/invites/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		sendJSONError(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
+
+	switch parts[1] {
+	case "accept":
+		p, err := h.party.Accept(session.PlayerID, parts[0])
+		if err != nil {
+			h.sendError(w, "加入队伍失败", err)
+			return
+		}
+		sendJSONSuccess(w, "已加入队伍", p)
+	case "decline":
+		if err := h.party.Decline(session.PlayerID, parts[0]); err != nil {
+			h.sendError(w, "拒绝邀请失败", err)
+			return
+		}
+		sendJSONSuccess(w, "已拒绝邀请", nil)
+	default:
+		sendJSONError(w, "无效的请求路径", http.StatusNotFound)
+	}
+}
+
+// handleKick 队长移出成员: DELETE /party/members/{player_id}
+func (h *PartyHandler) handleKick(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, "仅支持DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	memberID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/party/members/"), 10, 64)
+	if err != nil || memberID <= 0 {
+		sendJSONError(w, "无效的玩家ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.party.Kick(session.PlayerID, memberID); err != nil {
+		h.sendError(w, "移出成员失败", err)
+		return
+	}
+	sendJSONSuccess(w, "已移出成员", nil)
+}
+
+// handleCharacter PUT 选择本次匹配使用的角色
+func (h *PartyHandler) handleCharacter(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPut {
+		sendJSONError(w, "仅支持PUT方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req PartyCharacterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.party.SelectCharacter(session.PlayerID, req.CharacterID)
+	if err != nil {
+		h.sendError(w, "选择角色失败", err)
+		return
+	}
+	sendJSONSuccess(w, "选择成功", p)
+}
+
+// handleChat GET 查询队伍聊天（after 为已读取的最大序号），POST 发送消息
+func (h *PartyHandler) handleChat(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		var after int64
+		if afterStr := r.URL.Query().Get("after"); afterStr != "" {
+			a, err := strconv.ParseInt(afterStr, 10, 64)
+			if err != nil || a < 0 {
+				sendJSONError(w, "无效的消息序号", http.StatusBadRequest)
+				return
+			}
+			after = a
+		}
+		messages, err := h.party.Messages(session.PlayerID, after)
+		if err != nil {
+			h.sendError(w, "查询聊天失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", messages)
+	case http.MethodPost:
+		var req PartyChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		msg, err := h.party.SendMessage(session.PlayerID, req.Text)
+		if err != nil {
+			h.sendError(w, "发送消息失败", err)
+			return
+		}
+		sendJSONSuccess(w, "发送成功", msg)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleQueue POST 队长为整支队伍开始匹配，DELETE 取消匹配
+func (h *PartyHandler) handleQueue(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodPost:
+		var req PartyQueueRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		p, err := h.party.Queue(session.PlayerID, req.GameMode)
+		if err != nil {
+			h.sendError(w, "开始匹配失败", err)
+			return
+		}
+		sendJSONSuccess(w, "已开始匹配", p)
+	case http.MethodDelete:
+		p, err := h.party.CancelQueue(session.PlayerID)
+		if err != nil {
+			h.sendError(w, "取消匹配失败", err)
+			return
+		}
+		sendJSONSuccess(w, "已取消匹配", p)
+	default:
+		sendJSONError(w, "仅支持POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePresence GET 查询玩家状态，包含组队信息
+func (h *PartyHandler) handlePresence(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", models.Presence{
+		PlayerID: playerID,
+		Party:    h.party.Presence(playerID),
+	})
+}
+
+// sendError 根据组队错误类型返回对应状态码
+func (h *PartyHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, party.ErrNotInParty), errors.Is(err, party.ErrInviteNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, party.ErrNotLeader), errors.Is(err, social.ErrBlocked),
+		errors.Is(err, party.ErrCharacterNotOwned):
+		sendServiceError(w, err, http.StatusForbidden)
+	case errors.Is(err, party.ErrAlreadyInParty), errors.Is(err, party.ErrPartyFull),
+		errors.Is(err, party.ErrPartyQueued), errors.Is(err, party.ErrNotReady):
+		sendServiceError(w, err, http.StatusConflict)
+	case errors.Is(err, party.ErrInvalidRequest):
+		sendServiceError(w, err, http.StatusBadRequest)
+	case errors.Is(err, errNoInstance):
+		sendJSONError(w, "服务不可用", http.StatusServiceUnavailable)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
+
+// matchQueuer 通过HTTP将队伍提交到匹配服务
+type matchQueuer struct {
+	baseURL func() (string, error)
+	client  *http.Client
+}
+
+// QueueParty 将队伍加入匹配队列
+func (q *matchQueuer) QueueParty(req *models.PartyQueueRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("序列化匹配请求失败: %w", err)
+	}
+	baseURL, err := q.baseURL()
+	if err != nil {
+		return err
+	}
+	resp, err := q.client.Post(baseURL+"/match/party/join", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("请求匹配服务失败: %w", err)
+	}
+	return checkMatchResponse(resp)
+}
+
+// CancelParty 将队伍移出匹配队列
+func (q *matchQueuer) CancelParty(partyID string, mode models.GameMode) error {
+	baseURL, err := q.baseURL()
+	if err != nil {
+		return err
+	}
+	query := url.Values{"party_id": {partyID}, "game_mode": {string(mode)}}
+	req, err := http.NewRequest(http.MethodDelete, baseURL+"/match/party/leave?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求匹配服务失败: %w", err)
+	}
+	return checkMatchResponse(resp)
+}
+
+// checkMatchResponse 检查匹配服务响应，请求被拒绝时返回 ErrInvalidRequest
+func checkMatchResponse(resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	text := strings.TrimSpace(string(msg))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return fmt.Errorf("%w: %s", party.ErrInvalidRequest, text)
+	}
+	return fmt.Errorf("匹配服务返回 %d: %s", resp.StatusCode, text)
+}
@@ -0,0 +1,108 @@
+// payment.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/payment"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// PaymentHandler 应用内购买处理器
+type PaymentHandler struct {
+	payment *payment.Service
+	auth    *AuthHandler
+}
+
+// NewPaymentHandler 创建应用内购买处理器
+func NewPaymentHandler(auth *AuthHandler, cfg config.PaymentsConfig) *PaymentHandler {
+	return &PaymentHandler{
+		payment: payment.NewService(wallet.NewService(), cfg),
+		auth:    auth,
+	}
+}
+
+// RevenueData 收入统计响应数据
+type RevenueData struct {
+	From    string                `json:"from"`
+	To      string                `json:"to"`
+	Entries []models.RevenueEntry `json:"entries"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *PaymentHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/payments/verify", h.auth.RequireAuth(h.handleVerify))
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *PaymentHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/payments/revenue", admin.Wrap(h.handleRevenue))
+}
+
+// handleVerify POST 校验应用内购买并发放宝石
+func (h *PaymentHandler) handleVerify(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.VerifyPurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.payment.Verify(session.PlayerID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, payment.ErrStoreUnavailable), errors.Is(err, payment.ErrInvalidReceipt),
+			errors.Is(err, payment.ErrUnknownProduct), errors.Is(err, payment.ErrSandboxPurchase):
+			sendServiceError(w, err, http.StatusBadRequest)
+		case errors.Is(err, payment.ErrReceiptUsed):
+			sendServiceError(w, err, http.StatusConflict)
+		case errors.Is(err, payment.ErrPurchasePending):
+			sendServiceError(w, err, http.StatusAccepted)
+		case errors.Is(err, payment.ErrStoreRequest):
+			log.Printf("玩家 %d 校验 %s 购买失败: %v", session.PlayerID, req.Store, err)
+			sendJSONError(w, payment.ErrStoreRequest.Error(), http.StatusBadGateway)
+		default:
+			log.Printf("玩家 %d 校验 %s 购买失败: %v", session.PlayerID, req.Store, err)
+			sendJSONError(w, "校验购买失败", http.StatusInternalServerError)
+		}
+		return
+	}
+	sendJSONSuccess(w, "校验成功", result)
+}
+
+// handleRevenue GET 按天统计应用内购买收入，include_sandbox=true 时包含沙盒购买
+func (h *PaymentHandler) handleRevenue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseAnalyticsRange(r)
+	if err != nil {
+		sendServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := payment.Revenue(from, to, r.URL.Query().Get("include_sandbox") == "true")
+	if err != nil {
+		log.Printf("查询收入统计失败: %v", err)
+		sendJSONError(w, "查询收入统计失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", RevenueData{
+		From:    from.Format(analyticsDateLayout),
+		To:      to.AddDate(0, 0, -1).Format(analyticsDateLayout),
+		Entries: entries,
+	})
+}
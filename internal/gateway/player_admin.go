@@ -0,0 +1,153 @@
+// player_admin.go
+
+package gateway
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+)
+
+// minAdminPasswordLength 管理员设置的密码最少字符数
+const minAdminPasswordLength = 8
+
+// PlayerInspection 管理员查看的玩家信息
+type PlayerInspection struct {
+	Player     *models.Player           `json:"player"`
+	Statistics *models.PlayerStatistics `json:"statistics,omitempty"`
+	Ban        *ClientPolicy            `json:"ban,omitempty"` // 账号当前的封禁或限流策略
+}
+
+// ResetPasswordRequest 重置密码请求，password 为空时生成随机密码
+type ResetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// ResetPasswordResponse 重置密码响应，仅在生成随机密码时返回新密码
+type ResetPasswordResponse struct {
+	PlayerID int64  `json:"player_id"`
+	Password string `json:"password,omitempty"`
+}
+
+// PlayerAdminHandler 玩家账号管理处理器
+type PlayerAdminHandler struct {
+	players  repository.PlayerRepo
+	policies *ClientPolicies
+}
+
+// NewPlayerAdminHandler 创建玩家账号管理处理器，封禁状态取自限流策略
+func NewPlayerAdminHandler(players repository.PlayerRepo, policies *ClientPolicies) *PlayerAdminHandler {
+	return &PlayerAdminHandler{players: players, policies: policies}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *PlayerAdminHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/players/", admin.Wrap(h.handlePlayer))
+}
+
+// handlePlayer 查看玩家: GET /admin/players/{id}；重置密码: POST /admin/players/{id}/password
+func (h *PlayerAdminHandler) handlePlayer(w http.ResponseWriter, r *http.Request) {
+	idPart, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/admin/players/"), "/")
+	playerID, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || playerID <= 0 {
+		sendJSONError(w, "无效的玩家ID", http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleInspect(w, playerID)
+	case "password":
+		if r.Method != http.MethodPost {
+			sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+			return
+		}
+		h.handleResetPassword(w, r, playerID)
+	default:
+		sendJSONError(w, "接口不存在", http.StatusNotFound)
+	}
+}
+
+// handleInspect 玩家资料、统计和封禁状态
+func (h *PlayerAdminHandler) handleInspect(w http.ResponseWriter, playerID int64) {
+	player, err := h.players.GetByID(playerID)
+	if err != nil {
+		h.sendError(w, "查询玩家失败", err)
+		return
+	}
+	result := PlayerInspection{Player: player}
+	if stats, err := h.players.Statistics(playerID); err == nil {
+		result.Statistics = stats
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("查询玩家 %d 统计信息失败: %v", playerID, err)
+	}
+	if policy, ok := h.policies.Lookup(fmt.Sprintf("player:%d", playerID)); ok {
+		result.Ban = &policy
+	}
+	sendJSONSuccess(w, "获取成功", result)
+}
+
+// handleResetPassword 重置玩家密码，已登录的会话不受影响，需要时可同时封禁账号
+func (h *PlayerAdminHandler) handleResetPassword(w http.ResponseWriter, r *http.Request, playerID int64) {
+	var req ResetPasswordRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := ResetPasswordResponse{PlayerID: playerID}
+	password := req.Password
+	if password == "" {
+		generated, err := randomPassword()
+		if err != nil {
+			h.sendError(w, "生成密码失败", err)
+			return
+		}
+		password, resp.Password = generated, generated
+	} else if len([]rune(password)) < minAdminPasswordLength {
+		sendJSONError(w, fmt.Sprintf("密码至少需要 %d 个字符", minAdminPasswordLength), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.players.UpdatePassword(playerID, hashPassword(password)); err != nil {
+		h.sendError(w, "重置密码失败", err)
+		return
+	}
+	log.Printf("管理员重置玩家 %d 的密码", playerID)
+	sendJSONSuccess(w, "密码已重置", resp)
+}
+
+// randomPassword 生成12个字符的随机密码
+func randomPassword() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sendError 按错误类型返回玩家管理错误
+func (h *PlayerAdminHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		sendJSONError(w, "玩家不存在", http.StatusNotFound)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,113 @@
+// privacy.go
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+)
+
+// viewerContextKey GraphQL 请求上下文中查看者玩家ID的键
+type viewerContextKey struct{}
+
+// PrivacyHandler 隐私设置处理器
+type PrivacyHandler struct {
+	auth *AuthHandler
+}
+
+// NewPrivacyHandler 创建隐私设置处理器
+func NewPrivacyHandler(auth *AuthHandler) *PrivacyHandler {
+	return &PrivacyHandler{auth: auth}
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *PrivacyHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("privacy", h.handlePrivacy)
+}
+
+// handlePrivacy GET 查询隐私设置，PUT 修改隐私设置（仅限本人）
+func (h *PrivacyHandler) handlePrivacy(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		sendJSONError(w, "仅支持GET和PUT方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+	if session.PlayerID != playerID {
+		sendJSONError(w, "只能访问自己的隐私设置", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		settings, err := privacy.Get(playerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 隐私设置失败: %v", playerID, err)
+			sendJSONError(w, "查询隐私设置失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", settings)
+		return
+	}
+
+	var req models.PrivacySettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := privacy.Update(playerID, &req)
+	if err != nil {
+		if errors.Is(err, privacy.ErrInvalidVisibility) {
+			sendServiceError(w, err, http.StatusBadRequest)
+			return
+		}
+		log.Printf("更新玩家 %d 隐私设置失败: %v", playerID, err)
+		sendJSONError(w, "更新隐私设置失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "更新成功", settings)
+}
+
+// viewerID 返回请求的登录玩家ID，未登录时为0
+func (h *StatsHandler) viewerID(r *http.Request) int64 {
+	if session, ok := h.auth.Authenticate(r); ok {
+		return session.PlayerID
+	}
+	return 0
+}
+
+// checkPrivacy 检查请求者能否查看玩家资料，不可见时直接返回403
+func (h *StatsHandler) checkPrivacy(w http.ResponseWriter, r *http.Request, playerID int64, field privacy.Field) bool {
+	err := privacy.Check(h.viewerID(r), playerID, field)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, privacy.ErrHidden) {
+		sendServiceError(w, err, http.StatusForbidden)
+	} else {
+		log.Printf("检查玩家 %d 隐私设置失败: %v", playerID, err)
+		h.sendErrorResponse(w, "检查隐私设置失败", http.StatusInternalServerError)
+	}
+	return false
+}
+
+// withViewer 将请求者玩家ID写入上下文，供 GraphQL 解析时检查隐私设置
+func (h *StatsHandler) withViewer(r *http.Request) context.Context {
+	return context.WithValue(r.Context(), viewerContextKey{}, h.viewerID(r))
+}
+
+// checkViewerPrivacy 在 GraphQL 解析中检查上下文中的请求者能否查看玩家资料
+func checkViewerPrivacy(ctx context.Context, playerID int64, field privacy.Field) error {
+	viewerID, _ := ctx.Value(viewerContextKey{}).(int64)
+	return privacy.Check(viewerID, playerID, field)
+}
@@ -3,25 +3,49 @@
 package gateway
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/jacl-coder/PixelStorm-Server/internal/avatar"
+	"github.com/jacl-coder/PixelStorm-Server/internal/battlepass"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
-	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/internal/title"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
 )
 
 // ProfileHandler 玩家资料处理器
-type ProfileHandler struct{}
+type ProfileHandler struct {
+	players repository.PlayerRepo
+	auth    *AuthHandler
+
+	// 其他处理器注册的玩家子资源，如 /players/{id}/transactions
+	subResources map[string]PlayerSubResourceFunc
+	battlePass   *battlepass.Service
+}
+
+// PlayerSubResourceFunc 玩家子资源处理函数
+type PlayerSubResourceFunc func(w http.ResponseWriter, r *http.Request, playerID int64)
 
 // NewProfileHandler 创建玩家资料处理器
-func NewProfileHandler() *ProfileHandler {
-	return &ProfileHandler{}
+func NewProfileHandler(players repository.PlayerRepo, auth *AuthHandler) *ProfileHandler {
+	return &ProfileHandler{
+		players:      players,
+		auth:         auth,
+		subResources: make(map[string]PlayerSubResourceFunc),
+		battlePass:   battlepass.NewService(wallet.NewService()),
+	}
+}
+
+// RegisterSubResource 注册玩家子资源 /players/{id}/{name}
+func (h *ProfileHandler) RegisterSubResource(name string, handler PlayerSubResourceFunc) {
+	h.subResources[name] = handler
 }
 
 // RegisterHandlers 注册HTTP处理器
@@ -31,9 +55,10 @@ func (h *ProfileHandler) RegisterHandlers(mux *http.ServeMux) {
 
 // ProfileResponse 资料响应
 type ProfileResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Success bool         `json:"success"`
+	Code    errcode.Code `json:"code,omitempty"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data"`
 }
 
 // UpdateProfileRequest 更新资料请求
@@ -45,15 +70,11 @@ type UpdateProfileRequest struct {
 // PlayerProfileInfo 玩家资料信息
 type PlayerProfileInfo struct {
 	*models.Player
-	Statistics *PlayerStatistics `json:"statistics"`
-}
-
-// PlayerStatistics 玩家统计信息
-type PlayerStatistics struct {
-	WinRate     float64 `json:"win_rate"`     // 胜率
-	KDA         float64 `json:"kda"`          // KDA比率
-	AverageKill float64 `json:"average_kill"` // 平均击杀
-	PlayTime    int     `json:"play_time"`    // 总游戏时长(分钟)
+	AvatarURL  string                   `json:"avatar_url"`
+	Title      *models.Title            `json:"title,omitempty"` // 当前装备的称号
+	Badges     []models.Title           `json:"badges"`          // 当前展示的徽章
+	Statistics *models.PlayerStatistics `json:"statistics,omitempty"` // 玩家对查看者隐藏战绩时不返回
+	BattlePass *models.PlayerBattlePass `json:"battle_pass,omitempty"` // 当前赛季通行证进度
 }
 
 // handlePlayerProfile 处理玩家资料相关请求
@@ -74,7 +95,12 @@ func (h *ProfileHandler) handlePlayerProfile(w http.ResponseWriter, r *http.Requ
 	}
 
 	if parts[1] != "profile" {
-		h.sendErrorResponse(w, "未知的请求路径", http.StatusNotFound)
+		handler, ok := h.subResources[parts[1]]
+		if !ok {
+			h.sendErrorResponse(w, "未知的请求路径", http.StatusNotFound)
+			return
+		}
+		handler(w, r, playerID)
 		return
 	}
 
@@ -91,9 +117,9 @@ func (h *ProfileHandler) handlePlayerProfile(w http.ResponseWriter, r *http.Requ
 // handleGetPlayerProfile 处理获取玩家资料
 func (h *ProfileHandler) handleGetPlayerProfile(w http.ResponseWriter, r *http.Request, playerID int64) {
 	// 查询玩家基本信息
-	player, err := h.getPlayerByID(playerID)
+	player, err := h.players.GetByID(playerID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNotFound) {
 			h.sendErrorResponse(w, "玩家不存在", http.StatusNotFound)
 			return
 		}
@@ -102,24 +128,60 @@ func (h *ProfileHandler) handleGetPlayerProfile(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// 查询玩家统计信息
-	statistics, err := h.getPlayerStatistics(playerID)
-	if err != nil {
-		log.Printf("查询玩家统计信息失败: %v", err)
-		// 统计信息查询失败不影响基本信息返回
-		statistics = &PlayerStatistics{}
-	}
-
 	// 构建响应数据
 	profileInfo := &PlayerProfileInfo{
-		Player:     player,
-		Statistics: statistics,
+		Player: player,
+	}
+
+	// 查询玩家统计信息，玩家对查看者隐藏了战绩时不返回
+	if h.statsVisible(r, playerID) {
+		statistics, err := h.players.Statistics(playerID)
+		if err != nil {
+			log.Printf("查询玩家统计信息失败: %v", err)
+			// 统计信息查询失败不影响基本信息返回
+			statistics = &models.PlayerStatistics{}
+		}
+		profileInfo.Statistics = statistics
+	}
+
+	// 查询头像（失败不影响资料返回）
+	if profileInfo.AvatarURL, err = avatar.URL(playerID); err != nil {
+		log.Printf("查询玩家 %d 头像失败: %v", playerID, err)
+	}
+
+	// 查询装备的称号和徽章（失败不影响资料返回）
+	if titles, err := title.PlayerTitles(playerID); err == nil {
+		profileInfo.Title = titles.Title
+		profileInfo.Badges = titles.Badges
+	} else {
+		log.Printf("查询玩家 %d 称号失败: %v", playerID, err)
+	}
+
+	// 查询当前赛季通行证进度，没有进行中的赛季时不返回
+	battlePass, err := h.battlePass.PlayerState(playerID)
+	if err == nil {
+		profileInfo.BattlePass = battlePass
+	} else if err != battlepass.ErrNoActiveSeason {
+		log.Printf("查询玩家 %d 通行证进度失败: %v", playerID, err)
 	}
 
 	// 返回成功响应
 	h.sendSuccessResponse(w, "查询成功", profileInfo)
 }
 
+// statsVisible 请求者能否查看玩家的战绩统计，隐私设置查询失败时不返回
+func (h *ProfileHandler) statsVisible(r *http.Request, playerID int64) bool {
+	var viewerID int64
+	if session, ok := h.auth.Authenticate(r); ok {
+		viewerID = session.PlayerID
+	}
+	err := privacy.Check(viewerID, playerID, privacy.FieldStats)
+	if err != nil && !errors.Is(err, privacy.ErrHidden) {
+		log.Printf("检查玩家 %d 隐私设置失败: %v", playerID, err)
+	}
+	return err == nil
+}
+
 // handleUpdatePlayerProfile 处理更新玩家资料
 func (h *ProfileHandler) handleUpdatePlayerProfile(w http.ResponseWriter, r *http.Request, playerID int64) {
 	// 解析请求
@@ -136,7 +198,7 @@ func (h *ProfileHandler) handleUpdatePlayerProfile(w http.ResponseWriter, r *htt
 	}
 
 	// 检查玩家是否存在
-	exists, err := h.checkPlayerExists(playerID)
+	exists, err := h.players.Exists(playerID)
 	if err != nil {
 		log.Printf("检查玩家存在性失败: %v", err)
 		h.sendErrorResponse(w, "检查玩家信息失败", http.StatusInternalServerError)
@@ -149,21 +211,18 @@ func (h *ProfileHandler) handleUpdatePlayerProfile(w http.ResponseWriter, r *htt
 	}
 
 	// 更新玩家信息
-	err = h.updatePlayerProfile(playerID, &req)
+	err = h.players.UpdateProfile(playerID, req.Username, req.Email)
 	if err != nil {
-		log.Printf("更新玩家资料失败: %v", err)
 		// 检查是否是唯一约束冲突
-		if strings.Contains(err.Error(), "duplicate key") {
-			if strings.Contains(err.Error(), "username") {
-				h.sendErrorResponse(w, "用户名已存在", http.StatusConflict)
-			} else if strings.Contains(err.Error(), "email") {
-				h.sendErrorResponse(w, "邮箱已存在", http.StatusConflict)
-			} else {
-				h.sendErrorResponse(w, "数据冲突", http.StatusConflict)
-			}
-			return
+		switch {
+		case errors.Is(err, repository.ErrUsernameTaken):
+			h.sendErrorResponse(w, "用户名已存在", http.StatusConflict)
+		case errors.Is(err, repository.ErrEmailTaken):
+			h.sendErrorResponse(w, "邮箱已存在", http.StatusConflict)
+		default:
+			log.Printf("更新玩家资料失败: %v", err)
+			h.sendErrorResponse(w, "更新玩家资料失败", http.StatusInternalServerError)
 		}
-		h.sendErrorResponse(w, "更新玩家资料失败", http.StatusInternalServerError)
 		return
 	}
 
@@ -175,7 +234,7 @@ func (h *ProfileHandler) handleUpdatePlayerProfile(w http.ResponseWriter, r *htt
 func (h *ProfileHandler) sendSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
 	resp := ProfileResponse{
 		Success: true,
-		Message: message,
+		Message: localize(w, "", message),
 		Data:    data,
 	}
 	
@@ -190,7 +249,8 @@ func (h *ProfileHandler) sendSuccessResponse(w http.ResponseWriter, message stri
 func (h *ProfileHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	resp := ProfileResponse{
 		Success: false,
-		Message: message,
+		Code:    errcode.FromStatus(statusCode),
+		Message: localize(w, errcode.FromStatus(statusCode), message),
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -199,108 +259,3 @@ func (h *ProfileHandler) sendErrorResponse(w http.ResponseWriter, message string
 		log.Printf("编码错误响应失败: %v", err)
 	}
 }
-
-// 数据库查询方法
-
-// getPlayerByID 根据ID获取玩家信息
-func (h *ProfileHandler) getPlayerByID(playerID int64) (*models.Player, error) {
-	query := `
-		SELECT id, username, email, created_at, updated_at, level, exp, coins, gems,
-		       total_kills, total_deaths, total_assists, total_matches, total_wins
-		FROM players
-		WHERE id = $1
-	`
-
-	var player models.Player
-	err := db.DB.QueryRow(query, playerID).Scan(
-		&player.ID, &player.Username, &player.Email, &player.CreatedAt, &player.UpdatedAt,
-		&player.Level, &player.Exp, &player.Coins, &player.Gems,
-		&player.TotalKills, &player.TotalDeaths, &player.TotalAssists, &player.TotalMatches, &player.TotalWins,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &player, nil
-}
-
-// getPlayerStatistics 获取玩家统计信息
-func (h *ProfileHandler) getPlayerStatistics(playerID int64) (*PlayerStatistics, error) {
-	query := `
-		SELECT
-			CASE WHEN total_matches > 0 THEN (total_wins * 100.0 / total_matches) ELSE 0 END as win_rate,
-			CASE WHEN total_deaths > 0 THEN (total_kills * 1.0 / total_deaths) ELSE total_kills END as kda,
-			CASE WHEN total_matches > 0 THEN (total_kills * 1.0 / total_matches) ELSE 0 END as average_kill,
-			COALESCE(SUM(pmr.play_time), 0) / 60 as play_time_minutes
-		FROM players p
-		LEFT JOIN player_match_records pmr ON p.id = pmr.player_id
-		WHERE p.id = $1
-		GROUP BY p.id, p.total_matches, p.total_wins, p.total_kills, p.total_deaths
-	`
-	
-	var stats PlayerStatistics
-	err := db.DB.QueryRow(query, playerID).Scan(
-		&stats.WinRate, &stats.KDA, &stats.AverageKill, &stats.PlayTime,
-	)
-	
-	if err != nil {
-		return nil, fmt.Errorf("查询玩家统计信息失败: %w", err)
-	}
-
-	return &stats, nil
-}
-
-// checkPlayerExists 检查玩家是否存在
-func (h *ProfileHandler) checkPlayerExists(playerID int64) (bool, error) {
-	query := `SELECT COUNT(1) FROM players WHERE id = $1`
-
-	var count int
-	err := db.DB.QueryRow(query, playerID).Scan(&count)
-	if err != nil {
-		return false, fmt.Errorf("检查玩家存在性失败: %w", err)
-	}
-
-	return count > 0, nil
-}
-
-// updatePlayerProfile 更新玩家资料
-func (h *ProfileHandler) updatePlayerProfile(playerID int64, req *UpdateProfileRequest) error {
-	// 构建动态更新SQL
-	setParts := []string{}
-	args := []interface{}{}
-	argIndex := 1
-
-	if req.Username != "" {
-		setParts = append(setParts, fmt.Sprintf("username = $%d", argIndex))
-		args = append(args, req.Username)
-		argIndex++
-	}
-
-	if req.Email != "" {
-		setParts = append(setParts, fmt.Sprintf("email = $%d", argIndex))
-		args = append(args, req.Email)
-		argIndex++
-	}
-
-	// 添加更新时间
-	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
-	args = append(args, time.Now())
-	argIndex++
-
-	// 添加WHERE条件
-	args = append(args, playerID)
-
-	query := fmt.Sprintf(`
-		UPDATE players
-		SET %s
-		WHERE id = $%d
-	`, strings.Join(setParts, ", "), argIndex)
-
-	_, err := db.DB.Exec(query, args...)
-	if err != nil {
-		return fmt.Errorf("更新玩家资料失败: %w", err)
-	}
-
-	return nil
-}
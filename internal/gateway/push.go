@@ -0,0 +1,103 @@
+// push.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/push"
+)
+
+// PushHandler 推送设备与推送设置处理器
+type PushHandler struct {
+	auth *AuthHandler
+}
+
+// NewPushHandler 创建推送处理器
+func NewPushHandler(auth *AuthHandler) *PushHandler {
+	return &PushHandler{auth: auth}
+}
+
+// UnregisterDeviceRequest 注销推送设备请求
+type UnregisterDeviceRequest struct {
+	Token string `json:"token"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *PushHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/push/devices", h.auth.RequireAuth(h.handleDevices))
+	mux.HandleFunc("/push/preferences", h.auth.RequireAuth(h.handlePreferences))
+}
+
+// handleDevices POST 注册推送设备，DELETE 注销推送设备
+func (h *PushHandler) handleDevices(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodPost:
+		var req models.RegisterDeviceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		device, err := push.RegisterDevice(session.PlayerID, &req)
+		if err != nil {
+			h.sendError(w, "注册推送设备失败", err)
+			return
+		}
+		sendJSONSuccess(w, "注册成功", device)
+	case http.MethodDelete:
+		var req UnregisterDeviceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := push.UnregisterDevice(session.PlayerID, req.Token); err != nil {
+			h.sendError(w, "注销推送设备失败", err)
+			return
+		}
+		sendJSONSuccess(w, "注销成功", nil)
+	default:
+		sendJSONError(w, "仅支持POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePreferences GET 查询推送类别开关，PUT 修改推送类别开关
+func (h *PushHandler) handlePreferences(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		prefs, err := push.Preferences(session.PlayerID)
+		if err != nil {
+			h.sendError(w, "查询推送设置失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", prefs)
+	case http.MethodPut:
+		var req models.PushPreferences
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		prefs, err := push.UpdatePreferences(session.PlayerID, req)
+		if err != nil {
+			h.sendError(w, "更新推送设置失败", err)
+			return
+		}
+		sendJSONSuccess(w, "更新成功", prefs)
+	default:
+		sendJSONError(w, "仅支持GET和PUT方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 根据推送错误类型返回对应状态码
+func (h *PushHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, push.ErrInvalidDevice), errors.Is(err, push.ErrInvalidCategory):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,182 @@
+// quest.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/battlepass"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/quest"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// QuestHandler 任务处理器
+type QuestHandler struct {
+	quests *quest.Service
+	auth   *AuthHandler
+}
+
+// NewQuestHandler 创建任务处理器
+func NewQuestHandler(auth *AuthHandler) *QuestHandler {
+	w := wallet.NewService()
+	return &QuestHandler{
+		quests: quest.NewService(w, battlepass.NewService(w)),
+		auth:   auth,
+	}
+}
+
+// QuestActionRequest 任务操作请求（刷新、领取）
+type QuestActionRequest struct {
+	PlayerQuestID int64 `json:"player_quest_id"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *QuestHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/quests", h.auth.RequireAuth(h.handleQuests))
+	mux.HandleFunc("/quests/reroll", h.auth.RequireAuth(h.handleReroll))
+	mux.HandleFunc("/quests/claim", h.auth.RequireAuth(h.handleClaim))
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *QuestHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/quests", admin.Wrap(h.handleAdminQuests))
+	mux.HandleFunc("/admin/quests/", admin.Wrap(h.handleAdminQuest))
+}
+
+// handleQuests 查询当前玩家本周期的任务
+func (h *QuestHandler) handleQuests(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	quests, err := h.quests.PlayerQuests(session.PlayerID)
+	if err != nil {
+		h.sendError(w, "查询任务失败", err)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", quests)
+}
+
+// decodeAction 解析任务操作请求
+func (h *QuestHandler) decodeAction(w http.ResponseWriter, r *http.Request) (*QuestActionRequest, bool) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	var req QuestActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return nil, false
+	}
+	if req.PlayerQuestID <= 0 {
+		sendJSONError(w, "必须提供 player_quest_id", http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+// handleReroll 刷新一个未完成的任务
+func (h *QuestHandler) handleReroll(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	req, ok := h.decodeAction(w, r)
+	if !ok {
+		return
+	}
+
+	replacement, err := h.quests.Reroll(session.PlayerID, req.PlayerQuestID)
+	if err != nil {
+		h.sendError(w, "刷新任务失败", err)
+		return
+	}
+
+	sendJSONSuccess(w, "刷新成功", replacement)
+}
+
+// handleClaim 领取任务奖励
+func (h *QuestHandler) handleClaim(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	req, ok := h.decodeAction(w, r)
+	if !ok {
+		return
+	}
+
+	pq, err := h.quests.Claim(session.PlayerID, req.PlayerQuestID)
+	if err != nil {
+		h.sendError(w, "领取任务奖励失败", err)
+		return
+	}
+
+	log.Printf("玩家 %d 领取任务 %d 奖励: %s +%d, 通行证经验 +%d", session.PlayerID, pq.Quest.ID,
+		pq.Quest.RewardCurrency, pq.Quest.RewardAmount, pq.Quest.RewardPassXP)
+	sendJSONSuccess(w, "领取成功", pq)
+}
+
+// handleAdminQuests 管理任务定义: GET 列表, POST 创建
+func (h *QuestHandler) handleAdminQuests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		quests, err := h.quests.ListQuests()
+		if err != nil {
+			h.sendError(w, "查询任务定义失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", quests)
+	case http.MethodPost:
+		var q models.Quest
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := h.quests.CreateQuest(&q); err != nil {
+			h.sendError(w, "创建任务失败", err)
+			return
+		}
+		sendJSONSuccess(w, "创建成功", q)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminQuest 停用任务定义: DELETE /admin/quests/{id}
+func (h *QuestHandler) handleAdminQuest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, "仅支持DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	questID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/admin/quests/"))
+	if err != nil {
+		sendJSONError(w, "无效的任务ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.quests.DisableQuest(questID); err != nil {
+		h.sendError(w, "停用任务失败", err)
+		return
+	}
+	sendJSONSuccess(w, "停用成功", nil)
+}
+
+// sendError 按错误类型返回任务错误
+func (h *QuestHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, quest.ErrQuestNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, quest.ErrQuestNotCompleted), errors.Is(err, quest.ErrQuestCompleted),
+		errors.Is(err, quest.ErrQuestClaimed), errors.Is(err, quest.ErrRerollLimit),
+		errors.Is(err, quest.ErrNoReplacement):
+		sendServiceError(w, err, http.StatusConflict)
+	case errors.Is(err, quest.ErrInvalidQuest):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,415 @@
+// ratelimit.go
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+const (
+	// policiesKey 客户端限流策略的 Redis 哈希，字段为客户端标识
+	policiesKey = "ratelimit:policies"
+
+	// policiesChannel 限流策略变更和计数清除通知的 Redis 频道
+	policiesChannel = "ratelimit:policies"
+
+	// policiesRefresh 定期重新加载策略的间隔，错过通知或策略到期时以此兜底
+	policiesRefresh = 30 * time.Second
+)
+
+var (
+	// errInvalidClient 客户端标识格式无效
+	errInvalidClient = errcode.New(errcode.BadRequest, "客户端标识应为 ip:<地址> 或 player:<玩家ID>")
+	// errInvalidPolicy 限流策略参数无效
+	errInvalidPolicy = errcode.New(errcode.BadRequest, "无效的限流策略")
+	// errPolicyNotFound 该客户端没有限流策略
+	errPolicyNotFound = errcode.New(errcode.NotFound, "限流策略不存在")
+	// errClientBanned 客户端已被封禁
+	errClientBanned = errcode.New(errcode.ClientBanned, "该客户端已被暂时封禁")
+)
+
+// ClientPolicy 单个客户端的限流策略：封禁，或为受信任的合作方调整每分钟请求上限
+type ClientPolicy struct {
+	Client    string    `json:"client"` // ip:<地址> 或 player:<玩家ID>
+	Banned    bool      `json:"banned"`
+	Limit     int       `json:"limit,omitempty"` // 每分钟请求上限，0 表示使用默认值
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // 为空表示不过期
+}
+
+// expired 策略是否已过期
+func (p *ClientPolicy) expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && now.After(p.ExpiresAt)
+}
+
+// UpdatePolicyRequest 设置限流策略请求
+type UpdatePolicyRequest struct {
+	Banned   bool   `json:"banned"`
+	Limit    int    `json:"limit"`
+	Reason   string `json:"reason"`
+	Duration int    `json:"duration_seconds"` // 有效时长(秒)，0 表示不过期
+}
+
+// RateLimitClient 客户端在本网关实例上的限流状态
+type RateLimitClient struct {
+	Client   string        `json:"client"`
+	Requests int           `json:"requests"` // 最近一分钟的请求数
+	Limit    int           `json:"limit"`    // 当前生效的每分钟上限
+	LastSeen time.Time     `json:"last_seen"`
+	Policy   *ClientPolicy `json:"policy,omitempty"`
+}
+
+// policyMessage 实例间的策略通知
+type policyMessage struct {
+	Instance string `json:"instance"`
+	Clear    string `json:"clear,omitempty"` // 清除该客户端的请求计数，为空表示策略已变更
+}
+
+// ClientPolicies 客户端限流策略，保存在 Redis 中以便重启后保留并在网关实例间共享，
+// 请求路径只读取进程内快照
+type ClientPolicies struct {
+	instanceID string
+
+	mu       sync.RWMutex
+	policies map[string]ClientPolicy
+
+	// onClear 收到其他实例的清除通知时调用
+	onClear func(client string)
+}
+
+// NewClientPolicies 创建限流策略存储
+func NewClientPolicies() *ClientPolicies {
+	return &ClientPolicies{
+		instanceID: uuid.New().String(),
+		policies:   make(map[string]ClientPolicy),
+	}
+}
+
+// parseClient 规范化客户端标识
+func parseClient(client string) (string, error) {
+	kind, value, ok := strings.Cut(client, ":")
+	if !ok {
+		return "", errInvalidClient
+	}
+	switch kind {
+	case "ip":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", errInvalidClient
+		}
+		return "ip:" + ip.String(), nil
+	case "player":
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id <= 0 {
+			return "", errInvalidClient
+		}
+		return fmt.Sprintf("player:%d", id), nil
+	}
+	return "", errInvalidClient
+}
+
+// Lookup 按顺序查找第一个生效的策略，账号策略应排在 IP 策略之前
+func (p *ClientPolicies) Lookup(clients ...string) (ClientPolicy, bool) {
+	now := time.Now()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, client := range clients {
+		if policy, ok := p.policies[client]; ok && !policy.expired(now) {
+			return policy, true
+		}
+	}
+	return ClientPolicy{}, false
+}
+
+// List 列出所有生效的策略
+func (p *ClientPolicies) List() []ClientPolicy {
+	now := time.Now()
+	p.mu.RLock()
+	result := make([]ClientPolicy, 0, len(p.policies))
+	for _, policy := range p.policies {
+		if !policy.expired(now) {
+			result = append(result, policy)
+		}
+	}
+	p.mu.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Client < result[j].Client })
+	return result
+}
+
+// Set 设置客户端策略
+func (p *ClientPolicies) Set(client string, req *UpdatePolicyRequest) (*ClientPolicy, error) {
+	client, err := parseClient(client)
+	if err != nil {
+		return nil, err
+	}
+	if req.Limit < 0 || req.Duration < 0 {
+		return nil, fmt.Errorf("%w: limit 和 duration_seconds 不能为负数", errInvalidPolicy)
+	}
+	if !req.Banned && req.Limit == 0 {
+		return nil, fmt.Errorf("%w: 需要封禁或设置每分钟请求上限", errInvalidPolicy)
+	}
+
+	policy := ClientPolicy{
+		Client:    client,
+		Banned:    req.Banned,
+		Limit:     req.Limit,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+	if req.Duration > 0 {
+		policy.ExpiresAt = policy.CreatedAt.Add(time.Duration(req.Duration) * time.Second)
+	}
+
+	if db.RedisClient != nil {
+		data, err := json.Marshal(policy)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.RedisClient.HSet(context.Background(), policiesKey, client, data).Err(); err != nil {
+			return nil, fmt.Errorf("保存限流策略失败: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.policies[client] = policy
+	p.mu.Unlock()
+	p.publish(policyMessage{})
+	return &policy, nil
+}
+
+// Delete 删除客户端策略
+func (p *ClientPolicies) Delete(client string) error {
+	client, err := parseClient(client)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	_, ok := p.policies[client]
+	delete(p.policies, client)
+	p.mu.Unlock()
+
+	if db.RedisClient != nil {
+		removed, err := db.RedisClient.HDel(context.Background(), policiesKey, client).Result()
+		if err != nil {
+			return fmt.Errorf("删除限流策略失败: %w", err)
+		}
+		ok = ok || removed > 0
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", errPolicyNotFound, client)
+	}
+	p.publish(policyMessage{})
+	return nil
+}
+
+// Clear 通知其他网关实例清除客户端的请求计数
+func (p *ClientPolicies) Clear(client string) {
+	p.publish(policyMessage{Clear: client})
+}
+
+// Load 从 Redis 加载全部策略，删除已过期的策略
+func (p *ClientPolicies) Load() error {
+	if db.RedisClient == nil {
+		return nil
+	}
+	ctx := context.Background()
+	values, err := db.RedisClient.HGetAll(ctx, policiesKey).Result()
+	if err != nil {
+		return fmt.Errorf("加载限流策略失败: %w", err)
+	}
+
+	now := time.Now()
+	policies := make(map[string]ClientPolicy, len(values))
+	for client, data := range values {
+		var policy ClientPolicy
+		if err := json.Unmarshal([]byte(data), &policy); err != nil {
+			log.Printf("解析客户端 %s 的限流策略失败: %v", client, err)
+			continue
+		}
+		if policy.expired(now) {
+			db.RedisClient.HDel(ctx, policiesKey, client)
+			continue
+		}
+		policies[client] = policy
+	}
+
+	p.mu.Lock()
+	p.policies = policies
+	p.mu.Unlock()
+	return nil
+}
+
+// Start 加载策略并定期刷新，Redis 可用时接收其他实例的变更通知
+func (p *ClientPolicies) Start(stop <-chan struct{}) {
+	if db.RedisClient == nil {
+		return
+	}
+	if err := p.Load(); err != nil {
+		log.Printf("%v", err)
+	}
+
+	pubsub := db.RedisClient.Subscribe(context.Background(), policiesChannel)
+	go func() {
+		defer pubsub.Close()
+		ticker := time.NewTicker(policiesRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case msg, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+				var m policyMessage
+				if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Instance == p.instanceID {
+					continue
+				}
+				if m.Clear != "" {
+					if p.onClear != nil {
+						p.onClear(m.Clear)
+					}
+					continue
+				}
+				if err := p.Load(); err != nil {
+					log.Printf("收到变更通知后%v", err)
+				}
+			case <-ticker.C:
+				if err := p.Load(); err != nil {
+					log.Printf("刷新限流策略失败: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// publish 通知其他网关实例，失败只记录日志
+func (p *ClientPolicies) publish(m policyMessage) {
+	if db.RedisClient == nil {
+		return
+	}
+	m.Instance = p.instanceID
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	if err := db.RedisClient.Publish(context.Background(), policiesChannel, data).Err(); err != nil {
+		log.Printf("发布限流策略通知失败: %v", err)
+	}
+}
+
+// RateLimitHandler 限流和滥用管理处理器
+type RateLimitHandler struct {
+	limiter *RateLimiter
+}
+
+// NewRateLimitHandler 创建限流管理处理器
+func NewRateLimitHandler(limiter *RateLimiter) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter}
+}
+
+// RegisterAdminHandlers 注册管理员HTTP处理器
+func (h *RateLimitHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/ratelimit/clients", admin.Wrap(h.handleClients))
+	mux.HandleFunc("/admin/ratelimit/clients/", admin.Wrap(h.handleClient))
+	mux.HandleFunc("/admin/ratelimit/policies", admin.Wrap(h.handlePolicies))
+	mux.HandleFunc("/admin/ratelimit/policies/", admin.Wrap(h.handlePolicy))
+}
+
+// handleClients 查看本实例各客户端的限流状态，可按 client 前缀过滤: GET /admin/ratelimit/clients?client=ip:10.
+func (h *RateLimitHandler) handleClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", h.limiter.Clients(r.URL.Query().Get("client")))
+}
+
+// handleClient 清除客户端在所有网关实例上的请求计数: DELETE /admin/ratelimit/clients/{client}
+func (h *RateLimitHandler) handleClient(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, "仅支持DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+	client, err := parseClient(strings.TrimPrefix(r.URL.Path, "/admin/ratelimit/clients/"))
+	if err != nil {
+		h.sendError(w, "清除请求计数失败", err)
+		return
+	}
+	h.limiter.Reset(client)
+	h.limiter.policies.Clear(client)
+	log.Printf("管理员清除客户端 %s 的请求计数", client)
+	sendJSONSuccess(w, "已清除", nil)
+}
+
+// handlePolicies 列出所有生效的限流策略: GET /admin/ratelimit/policies
+func (h *RateLimitHandler) handlePolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", h.limiter.policies.List())
+}
+
+// handlePolicy 封禁客户端或调整其请求上限: PUT /admin/ratelimit/policies/{client}；解除: DELETE
+func (h *RateLimitHandler) handlePolicy(w http.ResponseWriter, r *http.Request) {
+	client := strings.TrimPrefix(r.URL.Path, "/admin/ratelimit/policies/")
+	if before, ok := h.limiter.policies.Lookup(client); ok {
+		auditBefore(r, before)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req UpdatePolicyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		policy, err := h.limiter.policies.Set(client, &req)
+		if err != nil {
+			h.sendError(w, "设置限流策略失败", err)
+			return
+		}
+		log.Printf("管理员设置客户端 %s 的限流策略: banned=%t limit=%d reason=%q", policy.Client, policy.Banned, policy.Limit, policy.Reason)
+		sendJSONSuccess(w, "设置成功", policy)
+	case http.MethodDelete:
+		if err := h.limiter.policies.Delete(client); err != nil {
+			h.sendError(w, "删除限流策略失败", err)
+			return
+		}
+		log.Printf("管理员删除客户端 %s 的限流策略", client)
+		sendJSONSuccess(w, "删除成功", nil)
+	default:
+		sendJSONError(w, "仅支持PUT和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendError 按错误类型返回限流管理错误
+func (h *RateLimitHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, errInvalidClient), errors.Is(err, errInvalidPolicy):
+		sendServiceError(w, err, http.StatusBadRequest)
+	case errors.Is(err, errPolicyNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,45 @@
+// recovery.go
+
+package gateway
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
+)
+
+// recoveryMiddleware 处理函数 panic 时记录堆栈、上报并返回 500，5xx 响应按采样比例上报
+func recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// 代理中断连接时的 panic 由 net/http 处理
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			log.Printf("处理请求 %s %s panic: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+			report.Panic(p, reportContext(r))
+			sendJSONError(recorder, "服务器内部错误", http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(recorder, r)
+		if recorder.statusCode >= http.StatusInternalServerError {
+			report.ServerError(r, recorder.statusCode, reportContext(r))
+		}
+	})
+}
+
+// reportContext 上报错误时附带的请求信息，限流中间件已识别账号时带上玩家ID
+func reportContext(r *http.Request) report.Context {
+	ctx := report.Context{Request: r}
+	if session, ok := r.Context().Value(sessionContextKey{}).(SessionInfo); ok {
+		ctx.PlayerID = session.PlayerID
+	}
+	return ctx
+}
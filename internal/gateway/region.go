@@ -0,0 +1,161 @@
+// region.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/latency"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// defaultRegion 未配置区域的实例所属的区域名
+const defaultRegion = "default"
+
+// maxLatencyRequestSize 上报延迟请求体的最大字节数
+const maxLatencyRequestSize = 4096
+
+// RegionHandler 区域列表与玩家延迟处理器
+type RegionHandler struct {
+	gateway *Gateway
+	auth    *AuthHandler
+}
+
+// NewRegionHandler 创建区域处理器
+func NewRegionHandler(gateway *Gateway, auth *AuthHandler) *RegionHandler {
+	return &RegionHandler{gateway: gateway, auth: auth}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *RegionHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/regions", h.handleRegions)
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *RegionHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("latencies", h.handleLatencies)
+}
+
+// regionOf 实例所属的区域名
+func regionOf(instance *ServiceInstance) string {
+	if instance.Region == "" {
+		return defaultRegion
+	}
+	return instance.Region
+}
+
+// handleRegions 列出有健康游戏服务器的区域及其延迟探测地址: GET /regions
+func (h *RegionHandler) handleRegions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", h.regions())
+}
+
+// regions 按区域汇总健康的游戏服务器实例，延迟探测地址取负载最低且配置了对外地址的实例
+func (h *RegionHandler) regions() []models.RegionInfo {
+	g := h.gateway
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	byName := make(map[string]*models.RegionInfo)
+	pingLoad := make(map[string]int)
+	unlimited := make(map[string]bool)
+	for _, instance := range g.services[ServiceGame] {
+		if !instance.Health {
+			continue
+		}
+		name := regionOf(instance)
+		info, ok := byName[name]
+		if !ok {
+			info = &models.RegionInfo{Name: name}
+			byName[name] = info
+		}
+		info.Instances++
+		info.Capacity += instance.Capacity
+		info.Load += instance.Load
+		if instance.Capacity == 0 {
+			unlimited[name] = true
+		}
+		if instance.Capacity == 0 || instance.Load < instance.Capacity {
+			info.Available = true
+		}
+		if instance.PublicURL != "" && (info.PingURL == "" || instance.Load < pingLoad[name]) {
+			info.PingURL = instance.PublicURL + "/ping"
+			pingLoad[name] = instance.Load
+		}
+	}
+
+	regions := make([]models.RegionInfo, 0, len(byName))
+	for name, info := range byName {
+		if unlimited[name] {
+			info.Capacity = 0
+		}
+		regions = append(regions, *info)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Name < regions[j].Name })
+	return regions
+}
+
+// preferredRegions 已登录玩家最近测量过的区域，按延迟从低到高排列；未登录或没有测量结果时返回nil
+func (h *RegionHandler) preferredRegions(r *http.Request) []string {
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		return nil
+	}
+	regions, err := latency.Ranked(session.PlayerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 区域延迟失败: %v", session.PlayerID, err)
+		return nil
+	}
+	return regions
+}
+
+// handleLatencies GET 查询玩家各区域的延迟，PUT 上报测得的延迟（仅限本人）
+func (h *RegionHandler) handleLatencies(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		sendJSONError(w, "仅支持GET和PUT方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+	if session.PlayerID != playerID {
+		sendJSONError(w, "只能访问自己的延迟数据", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		r.Body = http.MaxBytesReader(w, r.Body, maxLatencyRequestSize)
+		var req models.SaveLatenciesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := latency.Save(playerID, req.Latencies); err != nil {
+			if errors.Is(err, latency.ErrInvalidLatency) {
+				sendServiceError(w, err, http.StatusBadRequest)
+				return
+			}
+			log.Printf("保存玩家 %d 区域延迟失败: %v", playerID, err)
+			sendJSONError(w, "保存延迟失败", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	latencies, err := latency.List(playerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 区域延迟失败: %v", playerID, err)
+		sendJSONError(w, "查询延迟失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "获取成功", latencies)
+}
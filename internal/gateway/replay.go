@@ -0,0 +1,72 @@
+// replay.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/replay"
+)
+
+// ReplayHandler 对局回放处理器，回放链接可分享，未登录也能查看
+type ReplayHandler struct {
+	auth *AuthHandler
+}
+
+// NewReplayHandler 创建对局回放处理器
+func NewReplayHandler(auth *AuthHandler) *ReplayHandler {
+	return &ReplayHandler{auth: auth}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *ReplayHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/replays/", h.handleReplay)
+}
+
+// handleReplay 查询回放索引: GET /replays/{match_id}，导出回放索引为 JSON 文件: GET /replays/{match_id}/export。
+// 服务器只记录击杀/死亡事件，不保存逐帧数据，导出的内容与回放索引相同
+func (h *ReplayHandler) handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/replays/"), "/")
+	export := len(parts) == 2 && parts[1] == "export"
+	if parts[0] == "" || (len(parts) > 1 && !export) {
+		sendJSONError(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
+
+	// 登录的查看者能看到对其可见的玩家
+	var viewerID int64
+	if session, ok := h.auth.Authenticate(r); ok {
+		viewerID = session.PlayerID
+	}
+
+	rep, err := replay.Get(viewerID, parts[0])
+	if err != nil {
+		if errors.Is(err, replay.ErrReplayNotFound) {
+			sendServiceError(w, err, http.StatusNotFound)
+			return
+		}
+		log.Printf("查询对局 %s 回放失败: %v", parts[0], err)
+		sendJSONError(w, "查询回放失败", http.StatusInternalServerError)
+		return
+	}
+
+	if !export {
+		sendJSONSuccess(w, "查询成功", rep)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="replay-index-%s.json"`, rep.MatchID))
+	if err := json.NewEncoder(w).Encode(rep); err != nil {
+		log.Printf("编码对局 %s 回放失败: %v", rep.MatchID, err)
+	}
+}
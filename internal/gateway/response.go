@@ -0,0 +1,54 @@
+// response.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// sendJSONSuccess 发送成功响应
+func sendJSONSuccess(w http.ResponseWriter, message string, data interface{}) {
+	resp := map[string]interface{}{
+		"success": true,
+		"message": localize(w, "", message),
+		"data":    data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码响应失败: %v", err)
+	}
+}
+
+// sendJSONError 发送错误响应，错误码为 HTTP 状态码对应的通用错误码
+func sendJSONError(w http.ResponseWriter, message string, statusCode int) {
+	sendJSONErrorCode(w, errcode.FromStatus(statusCode), message, statusCode)
+}
+
+// sendServiceError 发送服务返回的错误，错误码取自错误链，没有错误码时使用 HTTP 状态码对应的通用错误码
+func sendServiceError(w http.ResponseWriter, err error, statusCode int) {
+	code := errcode.Of(err)
+	if code == "" {
+		code = errcode.FromStatus(statusCode)
+	}
+	sendJSONErrorCode(w, code, err.Error(), statusCode)
+}
+
+// sendJSONErrorCode 发送带错误码的错误响应
+func sendJSONErrorCode(w http.ResponseWriter, code errcode.Code, message string, statusCode int) {
+	resp := map[string]interface{}{
+		"success": false,
+		"code":    code,
+		"message": localize(w, code, message),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码错误响应失败: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// session_store.go
+
+package gateway
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultLocalSessions 未配置时内存会话存储的最大条目数
+const defaultLocalSessions = 10000
+
+// localSession 内存中保存的会话
+type localSession struct {
+	token   string
+	session SessionInfo
+}
+
+// localSessionStore Redis 不可用时的内存会话存储，超过上限时淘汰最久未使用的会话
+type localSessionStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // 最近使用的在前
+	evicted    int        // 上次统计以来因超过上限淘汰的会话数
+}
+
+// newLocalSessionStore 创建内存会话存储
+func newLocalSessionStore(maxEntries int) *localSessionStore {
+	if maxEntries <= 0 {
+		maxEntries = defaultLocalSessions
+	}
+	return &localSessionStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get 读取会话并标记为最近使用
+func (s *localSessionStore) Get(token string) (SessionInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[token]
+	if !ok {
+		return SessionInfo{}, false
+	}
+	s.order.MoveToFront(elem)
+	return elem.Value.(*localSession).session, true
+}
+
+// Set 保存会话，超过上限时淘汰最久未使用的会话
+func (s *localSessionStore) Set(token string, session SessionInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[token]; ok {
+		elem.Value.(*localSession).session = session
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[token] = s.order.PushFront(&localSession{token: token, session: session})
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+		s.evicted++
+	}
+}
+
+// Delete 删除会话
+func (s *localSessionStore) Delete(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[token]; ok {
+		s.remove(elem)
+	}
+}
+
+// DeleteIfUnchanged 会话在读取后没有被续期或重新写入时才删除，返回是否已删除
+func (s *localSessionStore) DeleteIfUnchanged(token string, session SessionInfo) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[token]
+	if !ok || !elem.Value.(*localSession).session.ExpiresAt.Equal(session.ExpiresAt) {
+		return false
+	}
+	s.remove(elem)
+	return true
+}
+
+// Len 当前保存的会话数
+func (s *localSessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// Sweep 删除已过期的会话，返回删除的过期会话数和上次统计以来因超过上限淘汰的会话数
+func (s *localSessionStore) Sweep(now time.Time) (expired, evicted int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for elem := s.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*localSession).session.ExpiresAt) {
+			s.remove(elem)
+			expired++
+		}
+		elem = prev
+	}
+	evicted, s.evicted = s.evicted, 0
+	return expired, evicted
+}
+
+// Snapshot 所有会话的副本，按最近使用排列
+func (s *localSessionStore) Snapshot() []localSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessions := make([]localSession, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		sessions = append(sessions, *elem.Value.(*localSession))
+	}
+	return sessions
+}
+
+// remove 删除链表节点和索引，调用方需持有锁
+func (s *localSessionStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.entries, elem.Value.(*localSession).token)
+}
@@ -0,0 +1,136 @@
+// session_store_test.go
+
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalSessionStoreEviction(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxEntries  int
+		set         []string // 按顺序写入的会话
+		get         []string // 写入后读取的会话，会标记为最近使用
+		setAfter    []string // 读取后再写入的会话
+		wantKept    []string
+		wantEvicted []string
+	}{
+		{name: "未超过上限", maxEntries: 3, set: []string{"a", "b", "c"}, wantKept: []string{"a", "b", "c"}},
+		{name: "淘汰最早写入的会话", maxEntries: 2, set: []string{"a", "b", "c"}, wantKept: []string{"b", "c"}, wantEvicted: []string{"a"}},
+		{name: "读取后不被淘汰", maxEntries: 2, set: []string{"a", "b"}, get: []string{"a"}, setAfter: []string{"c"}, wantKept: []string{"a", "c"}, wantEvicted: []string{"b"}},
+		{name: "重复写入不占用新条目", maxEntries: 2, set: []string{"a", "b", "a"}, setAfter: []string{"c"}, wantKept: []string{"a", "c"}, wantEvicted: []string{"b"}},
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLocalSessionStore(tt.maxEntries)
+			for _, token := range tt.set {
+				s.Set(token, SessionInfo{ExpiresAt: expiresAt})
+			}
+			for _, token := range tt.get {
+				if _, ok := s.Get(token); !ok {
+					t.Fatalf("Get(%q) 未找到会话", token)
+				}
+			}
+			for _, token := range tt.setAfter {
+				s.Set(token, SessionInfo{ExpiresAt: expiresAt})
+			}
+
+			if got := s.Len(); got != len(tt.wantKept) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.wantKept))
+			}
+			for _, token := range tt.wantKept {
+				if _, ok := s.Get(token); !ok {
+					t.Errorf("会话 %q 不应被淘汰", token)
+				}
+			}
+			for _, token := range tt.wantEvicted {
+				if _, ok := s.Get(token); ok {
+					t.Errorf("会话 %q 应被淘汰", token)
+				}
+			}
+			if _, evicted := s.Sweep(time.Now()); evicted != len(tt.wantEvicted) {
+				t.Errorf("Sweep() evicted = %d, want %d", evicted, len(tt.wantEvicted))
+			}
+		})
+	}
+}
+
+func TestLocalSessionStoreSweep(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		expiresAt   map[string]time.Time
+		wantExpired int
+		wantKept    []string
+	}{
+		{name: "空存储", expiresAt: map[string]time.Time{}},
+		{name: "全部未过期", expiresAt: map[string]time.Time{"a": now.Add(time.Minute), "b": now.Add(time.Hour)}, wantKept: []string{"a", "b"}},
+		{name: "删除已过期的会话", expiresAt: map[string]time.Time{"a": now.Add(-time.Minute), "b": now.Add(time.Hour), "c": now.Add(-time.Hour)}, wantExpired: 2, wantKept: []string{"b"}},
+		{name: "正好到期时保留", expiresAt: map[string]time.Time{"a": now}, wantKept: []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLocalSessionStore(0)
+			for token, expiresAt := range tt.expiresAt {
+				s.Set(token, SessionInfo{ExpiresAt: expiresAt})
+			}
+
+			expired, evicted := s.Sweep(now)
+			if expired != tt.wantExpired {
+				t.Errorf("Sweep() expired = %d, want %d", expired, tt.wantExpired)
+			}
+			if evicted != 0 {
+				t.Errorf("Sweep() evicted = %d, want 0", evicted)
+			}
+			if got := s.Len(); got != len(tt.wantKept) {
+				t.Errorf("Len() = %d, want %d", got, len(tt.wantKept))
+			}
+			for _, token := range tt.wantKept {
+				if _, ok := s.Get(token); !ok {
+					t.Errorf("会话 %q 不应被删除", token)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalSessionStoreDeleteIfUnchanged(t *testing.T) {
+	read := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		stored      *time.Time // nil 表示会话不存在
+		wantDeleted bool
+	}{
+		{name: "未变化时删除", stored: &read, wantDeleted: true},
+		{name: "已续期时保留", stored: ptrTime(read.Add(time.Hour))},
+		{name: "会话不存在", stored: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLocalSessionStore(0)
+			if tt.stored != nil {
+				s.Set("token", SessionInfo{PlayerID: 1, ExpiresAt: *tt.stored})
+			}
+
+			if got := s.DeleteIfUnchanged("token", SessionInfo{PlayerID: 1, ExpiresAt: read}); got != tt.wantDeleted {
+				t.Errorf("DeleteIfUnchanged() = %v, want %v", got, tt.wantDeleted)
+			}
+			_, exists := s.Get("token")
+			if wantExists := tt.stored != nil && !tt.wantDeleted; exists != wantExists {
+				t.Errorf("会话存在 = %v, want %v", exists, wantExists)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,90 @@
+// settings.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/settings"
+)
+
+// settingsRequestOverhead 请求体中除设置数据外允许的额外字节数
+const settingsRequestOverhead = 1024
+
+// SettingsHandler 玩家设置处理器
+type SettingsHandler struct {
+	auth *AuthHandler
+}
+
+// NewSettingsHandler 创建玩家设置处理器
+func NewSettingsHandler(auth *AuthHandler) *SettingsHandler {
+	return &SettingsHandler{auth: auth}
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *SettingsHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("settings", h.handleSettings)
+}
+
+// handleSettings GET 查询设置，PUT 保存设置（仅限本人）
+func (h *SettingsHandler) handleSettings(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		sendJSONError(w, "仅支持GET和PUT方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, ok := h.auth.Authenticate(r)
+	if !ok {
+		h.auth.sendAuthError(w, r)
+		return
+	}
+	if session.PlayerID != playerID {
+		sendJSONError(w, "只能访问自己的设置", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s, err := settings.Get(playerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 设置失败: %v", playerID, err)
+			sendJSONError(w, "查询设置失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", s)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, settings.MaxSettingsSize+settingsRequestOverhead)
+	var req models.SaveSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			sendJSONError(w, settings.ErrSettingsTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		}
+		return
+	}
+
+	s, err := settings.Save(playerID, req.Version, req.Settings)
+	if err != nil {
+		switch {
+		case errors.Is(err, settings.ErrVersionConflict):
+			sendServiceError(w, err, http.StatusConflict)
+		case errors.Is(err, settings.ErrSettingsTooLarge):
+			sendServiceError(w, err, http.StatusRequestEntityTooLarge)
+		case errors.Is(err, settings.ErrInvalidSettings):
+			sendServiceError(w, err, http.StatusBadRequest)
+		default:
+			log.Printf("保存玩家 %d 设置失败: %v", playerID, err)
+			sendJSONError(w, "保存设置失败", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	sendJSONSuccess(w, "保存成功", s)
+}
@@ -0,0 +1,212 @@
+// shop.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/shop"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// ShopHandler 商店处理器
+type ShopHandler struct {
+	shop *shop.Service
+	auth *AuthHandler
+}
+
+// NewShopHandler 创建商店处理器
+func NewShopHandler(auth *AuthHandler) *ShopHandler {
+	return &ShopHandler{
+		shop: shop.NewService(wallet.NewService()),
+		auth: auth,
+	}
+}
+
+// PurchaseRequest 购买请求
+type PurchaseRequest struct {
+	OfferID   int    `json:"offer_id"`
+	RequestID string `json:"request_id"` // 客户端生成的唯一ID，用于防止重复购买
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *ShopHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/shop", h.handleShop)
+	mux.HandleFunc("/shop/purchase", h.auth.RequireAuth(h.handlePurchase))
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *ShopHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/shop/items", admin.Wrap(h.handleAdminItems))
+	mux.HandleFunc("/admin/shop/offers", admin.Wrap(h.handleAdminOffers))
+	mux.HandleFunc("/admin/shop/offers/", admin.Wrap(h.handleAdminOffer))
+}
+
+// handleShop 查询当前可购买的商品
+func (h *ShopHandler) handleShop(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	offers, err := h.shop.ListOffers(true)
+	if err != nil {
+		log.Printf("查询商店商品失败: %v", err)
+		sendJSONError(w, "查询商店商品失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", offers)
+}
+
+// handlePurchase 处理购买请求
+func (h *ShopHandler) handlePurchase(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req PurchaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if req.OfferID <= 0 || req.RequestID == "" {
+		sendJSONError(w, "必须提供 offer_id 和 request_id", http.StatusBadRequest)
+		return
+	}
+
+	purchase, err := h.shop.Purchase(session.PlayerID, req.OfferID, req.RequestID)
+	if err != nil {
+		switch {
+		case errors.Is(err, shop.ErrOfferNotFound), errors.Is(err, wallet.ErrPlayerNotFound):
+			sendServiceError(w, err, http.StatusNotFound)
+		case errors.Is(err, shop.ErrOfferUnavailable), errors.Is(err, shop.ErrPurchaseLimit),
+			errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, inventory.ErrAlreadyOwned):
+			sendServiceError(w, err, http.StatusConflict)
+		case errors.Is(err, shop.ErrInvalidCatalog):
+			sendServiceError(w, err, http.StatusBadRequest)
+		default:
+			log.Printf("玩家 %d 购买商品 %d 失败: %v", session.PlayerID, req.OfferID, err)
+			sendJSONError(w, "购买失败", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Printf("玩家 %d 购买商品 %d: %s -%d, 余额 %d", purchase.PlayerID, purchase.OfferID,
+		purchase.Currency, purchase.Price, purchase.BalanceAfter)
+	sendJSONSuccess(w, "购买成功", purchase)
+}
+
+// handleAdminItems 管理道具定义: GET 列表, POST 创建
+func (h *ShopHandler) handleAdminItems(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		items, err := h.shop.ListItems()
+		if err != nil {
+			log.Printf("查询道具失败: %v", err)
+			sendJSONError(w, "查询道具失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", items)
+	case http.MethodPost:
+		var item models.Item
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := h.shop.CreateItem(&item); err != nil {
+			h.sendCatalogError(w, "创建道具失败", err)
+			return
+		}
+		sendJSONSuccess(w, "创建成功", item)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminOffers 管理商品: GET 全部商品, POST 创建
+func (h *ShopHandler) handleAdminOffers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		offers, err := h.shop.ListOffers(false)
+		if err != nil {
+			log.Printf("查询商品失败: %v", err)
+			sendJSONError(w, "查询商品失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", offers)
+	case http.MethodPost:
+		var offer models.ShopOffer
+		if err := json.NewDecoder(r.Body).Decode(&offer); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := h.shop.CreateOffer(&offer); err != nil {
+			h.sendCatalogError(w, "创建商品失败", err)
+			return
+		}
+		sendJSONSuccess(w, "创建成功", offer)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminOffer 管理单个商品: GET 查询, PUT 更新, DELETE 下架
+func (h *ShopHandler) handleAdminOffer(w http.ResponseWriter, r *http.Request) {
+	offerID, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/admin/shop/offers/"))
+	if err != nil {
+		sendJSONError(w, "无效的商品ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		offer, err := h.shop.GetOffer(offerID)
+		if err != nil {
+			h.sendCatalogError(w, "查询商品失败", err)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", offer)
+	case http.MethodPut:
+		var offer models.ShopOffer
+		if err := json.NewDecoder(r.Body).Decode(&offer); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		offer.ID = offerID
+		if err := h.shop.UpdateOffer(&offer); err != nil {
+			h.sendCatalogError(w, "更新商品失败", err)
+			return
+		}
+		sendJSONSuccess(w, "更新成功", offer)
+	case http.MethodDelete:
+		if err := h.shop.DisableOffer(offerID); err != nil {
+			h.sendCatalogError(w, "下架商品失败", err)
+			return
+		}
+		sendJSONSuccess(w, "下架成功", nil)
+	default:
+		sendJSONError(w, "仅支持GET、PUT和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// sendCatalogError 按错误类型返回商品管理错误
+func (h *ShopHandler) sendCatalogError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, shop.ErrOfferNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, shop.ErrInvalidCatalog):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+// social.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+)
+
+// SocialHandler 社交处理器（屏蔽列表）
+type SocialHandler struct {
+	auth *AuthHandler
+}
+
+// NewSocialHandler 创建社交处理器
+func NewSocialHandler(auth *AuthHandler) *SocialHandler {
+	return &SocialHandler{auth: auth}
+}
+
+// BlockRequest 屏蔽请求
+type BlockRequest struct {
+	PlayerID int64 `json:"player_id"`
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *SocialHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/blocks", h.auth.RequireAuth(h.handleBlocks))
+	mux.HandleFunc("/blocks/", h.auth.RequireAuth(h.handleUnblock))
+}
+
+// handleBlocks GET 查询屏蔽列表，POST 屏蔽玩家
+func (h *SocialHandler) handleBlocks(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		blocked, err := social.BlockedPlayers(session.PlayerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 屏蔽列表失败: %v", session.PlayerID, err)
+			sendJSONError(w, "查询屏蔽列表失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", blocked)
+	case http.MethodPost:
+		var req BlockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if req.PlayerID <= 0 {
+			sendJSONError(w, "无效的玩家ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := social.Block(session.PlayerID, req.PlayerID); err != nil {
+			switch {
+			case errors.Is(err, social.ErrCannotBlockSelf):
+				sendServiceError(w, err, http.StatusBadRequest)
+			case errors.Is(err, social.ErrPlayerNotFound):
+				sendServiceError(w, err, http.StatusNotFound)
+			default:
+				log.Printf("玩家 %d 屏蔽玩家 %d 失败: %v", session.PlayerID, req.PlayerID, err)
+				sendJSONError(w, "屏蔽失败", http.StatusInternalServerError)
+			}
+			return
+		}
+		sendJSONSuccess(w, "屏蔽成功", req)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleUnblock 取消屏蔽: DELETE /blocks/{player_id}
+func (h *SocialHandler) handleUnblock(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodDelete {
+		sendJSONError(w, "仅支持DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	blockedID, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/blocks/"), 10, 64)
+	if err != nil || blockedID <= 0 {
+		sendJSONError(w, "无效的玩家ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := social.Unblock(session.PlayerID, blockedID); err != nil {
+		log.Printf("玩家 %d 取消屏蔽玩家 %d 失败: %v", session.PlayerID, blockedID, err)
+		sendJSONError(w, "取消屏蔽失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "已取消屏蔽", nil)
+}
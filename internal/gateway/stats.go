@@ -3,26 +3,36 @@
 package gateway
 
 import (
-	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
 	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
 )
 
 // StatsHandler 战绩处理器
 type StatsHandler struct {
+	stats repository.StatsRepo
+
 	redisLeaderboard *models.RedisLeaderboard
 	useRedis         bool
+
+	// 战绩修复任务
+	repairer *statsRepairer
+
+	// 用于识别请求者以检查隐私设置
+	auth *AuthHandler
 }
 
 // NewStatsHandler 创建战绩处理器
-func NewStatsHandler() *StatsHandler {
+func NewStatsHandler(auth *AuthHandler, stats repository.StatsRepo) *StatsHandler {
 	useRedis := db.RedisClient != nil
 	var redisLeaderboard *models.RedisLeaderboard
 
@@ -31,8 +41,11 @@ func NewStatsHandler() *StatsHandler {
 	}
 
 	return &StatsHandler{
+		stats:            stats,
 		redisLeaderboard: redisLeaderboard,
 		useRedis:         useRedis,
+		repairer:         &statsRepairer{},
+		auth:             auth,
 	}
 }
 
@@ -41,14 +54,26 @@ func (h *StatsHandler) RegisterHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("/stats/player/", h.handlePlayerStats)
 	mux.HandleFunc("/stats/matches/", h.handlePlayerMatches)
 	mux.HandleFunc("/stats/leaderboard", h.handleLeaderboard)
-	mux.HandleFunc("/stats/leaderboard/refresh", h.handleRefreshLeaderboard)
+	mux.HandleFunc("/stats/characters", h.handleCharacterAnalytics)
+	mux.HandleFunc("/stats/maps", h.handleMapAnalytics)
+	mux.HandleFunc("/stats/match/", h.handleMatchStats)
+}
+
+// RegisterAdminHandlers 注册需要管理员权限的HTTP处理器
+func (h *StatsHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	// 排行榜由后台定时刷新，手动刷新仅供管理员使用
+	mux.HandleFunc("/stats/leaderboard/refresh", admin.Wrap(h.handleRefreshLeaderboard))
+
+	// 根据对局记录重新计算玩家战绩汇总
+	mux.HandleFunc("/admin/stats/recalculate", admin.Wrap(h.handleStatsRepair))
 }
 
 // StatsResponse 战绩响应
 type StatsResponse struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Success bool         `json:"success"`
+	Code    errcode.Code `json:"code,omitempty"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data"`
 }
 
 // PlayerMatchesResponse 玩家对局响应
@@ -82,16 +107,36 @@ func (h *StatsHandler) handlePlayerStats(w http.ResponseWriter, r *http.Request)
 
 	// 提取玩家ID
 	path := strings.TrimPrefix(r.URL.Path, "/stats/player/")
-	playerID, err := strconv.ParseInt(path, 10, 64)
+	parts := strings.Split(path, "/")
+	playerID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
 		h.sendErrorResponse(w, "无效的玩家ID", http.StatusBadRequest)
 		return
 	}
+	if !h.checkPrivacy(w, r, playerID, privacy.FieldStats) {
+		return
+	}
+
+	// 子资源: /stats/player/{id}/characters
+	if len(parts) > 1 {
+		if len(parts) == 2 && parts[1] == "characters" {
+			h.handlePlayerCharacterStats(w, playerID)
+			return
+		}
+		h.sendErrorResponse(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
+
+	// 指定时间窗口时返回窗口内的汇总战绩
+	if window := r.URL.Query().Get("window"); window != "" && window != "all" {
+		h.handlePlayerWindowStats(w, playerID, models.StatsWindow(window))
+		return
+	}
 
 	// 查询玩家战绩统计
-	stats, err := h.getPlayerStats(playerID)
+	stats, err := h.stats.PlayerStats(playerID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, repository.ErrNotFound) {
 			h.sendErrorResponse(w, "玩家不存在", http.StatusNotFound)
 			return
 		}
@@ -104,6 +149,43 @@ func (h *StatsHandler) handlePlayerStats(w http.ResponseWriter, r *http.Request)
 	h.sendSuccessResponse(w, "查询成功", stats)
 }
 
+// handlePlayerWindowStats 处理玩家时间窗口战绩查询
+func (h *StatsHandler) handlePlayerWindowStats(w http.ResponseWriter, playerID int64, window models.StatsWindow) {
+	if _, ok := models.StatsWindowTrunc[window]; !ok {
+		h.sendErrorResponse(w, "无效的时间窗口，可选值: daily, weekly, monthly, all", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := h.stats.WindowStats(playerID, window)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.sendErrorResponse(w, "玩家不存在", http.StatusNotFound)
+			return
+		}
+		log.Printf("查询玩家时间窗口战绩失败: %v", err)
+		h.sendErrorResponse(w, "查询玩家战绩失败", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, "查询成功", stats)
+}
+
+// handlePlayerCharacterStats 处理玩家各角色战绩查询
+func (h *StatsHandler) handlePlayerCharacterStats(w http.ResponseWriter, playerID int64) {
+	stats, err := h.stats.CharacterStats(playerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.sendErrorResponse(w, "玩家不存在", http.StatusNotFound)
+			return
+		}
+		log.Printf("查询玩家角色战绩失败: %v", err)
+		h.sendErrorResponse(w, "查询玩家角色战绩失败", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, "查询成功", stats)
+}
+
 // handlePlayerMatches 处理玩家对局历史查询
 func (h *StatsHandler) handlePlayerMatches(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -113,11 +195,25 @@ func (h *StatsHandler) handlePlayerMatches(w http.ResponseWriter, r *http.Reques
 
 	// 提取玩家ID
 	path := strings.TrimPrefix(r.URL.Path, "/stats/matches/")
-	playerID, err := strconv.ParseInt(path, 10, 64)
+	parts := strings.Split(path, "/")
+	playerID, err := strconv.ParseInt(parts[0], 10, 64)
 	if err != nil {
 		h.sendErrorResponse(w, "无效的玩家ID", http.StatusBadRequest)
 		return
 	}
+	if !h.checkPrivacy(w, r, playerID, privacy.FieldMatchHistory) {
+		return
+	}
+
+	// 子资源: /stats/matches/{id}/export
+	if len(parts) > 1 {
+		if len(parts) == 2 && parts[1] == "export" {
+			h.handleMatchExport(w, r, playerID)
+			return
+		}
+		h.sendErrorResponse(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
 
 	// 解析查询参数
 	query := r.URL.Query()
@@ -137,7 +233,7 @@ func (h *StatsHandler) handlePlayerMatches(w http.ResponseWriter, r *http.Reques
 	}
 
 	// 查询玩家对局历史
-	matches, total, err := h.getPlayerMatches(playerID, limit, offset)
+	matches, total, err := h.stats.Matches(playerID, limit, offset)
 	if err != nil {
 		log.Printf("查询玩家对局历史失败: %v", err)
 		h.sendErrorResponse(w, "查询对局历史失败", http.StatusInternalServerError)
@@ -231,7 +327,7 @@ func (h *StatsHandler) handleRefreshLeaderboard(w http.ResponseWriter, r *http.R
 func (h *StatsHandler) sendSuccessResponse(w http.ResponseWriter, message string, data interface{}) {
 	resp := StatsResponse{
 		Success: true,
-		Message: message,
+		Message: localize(w, "", message),
 		Data:    data,
 	}
 	
@@ -246,7 +342,7 @@ func (h *StatsHandler) sendSuccessResponse(w http.ResponseWriter, message string
 func (h *StatsHandler) sendMatchesResponse(w http.ResponseWriter, message string, data *PlayerMatchesData) {
 	resp := PlayerMatchesResponse{
 		Success: true,
-		Message: message,
+		Message: localize(w, "", message),
 		Data:    data,
 	}
 	
@@ -261,7 +357,7 @@ func (h *StatsHandler) sendMatchesResponse(w http.ResponseWriter, message string
 func (h *StatsHandler) sendLeaderboardResponse(w http.ResponseWriter, message string, data []models.LeaderboardEntry) {
 	resp := LeaderboardResponse{
 		Success: true,
-		Message: message,
+		Message: localize(w, "", message),
 		Data:    data,
 	}
 	
@@ -276,7 +372,8 @@ func (h *StatsHandler) sendLeaderboardResponse(w http.ResponseWriter, message st
 func (h *StatsHandler) sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
 	resp := StatsResponse{
 		Success: false,
-		Message: message,
+		Code:    errcode.FromStatus(statusCode),
+		Message: localize(w, errcode.FromStatus(statusCode), message),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -286,99 +383,7 @@ func (h *StatsHandler) sendErrorResponse(w http.ResponseWriter, message string,
 	}
 }
 
-// 数据库查询方法
-
-// getPlayerStats 获取玩家战绩统计
-func (h *StatsHandler) getPlayerStats(playerID int64) (*models.PlayerStats, error) {
-	query := `
-		SELECT
-			p.id as player_id,
-			p.total_matches,
-			p.total_wins,
-			(p.total_matches - p.total_wins) as losses,
-			CASE WHEN p.total_matches > 0 THEN (p.total_wins * 100.0 / p.total_matches) ELSE 0 END as win_rate,
-			p.total_kills,
-			p.total_deaths,
-			COALESCE(SUM(pmr.assists), 0) as total_assists,
-			CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + COALESCE(SUM(pmr.assists), 0)) * 1.0 / p.total_deaths)
-				 ELSE (p.total_kills + COALESCE(SUM(pmr.assists), 0)) END as kda,
-			CASE WHEN p.total_matches > 0 THEN (COALESCE(SUM(pmr.score), 0) * 1.0 / p.total_matches) ELSE 0 END as average_score,
-			COALESCE(SUM(CASE WHEN pmr.mvp = true THEN 1 ELSE 0 END), 0) as total_mvp,
-			COALESCE(SUM(pmr.play_time), 0) as play_time
-		FROM players p
-		LEFT JOIN player_match_records pmr ON p.id = pmr.player_id
-		WHERE p.id = $1
-		GROUP BY p.id, p.total_matches, p.total_wins, p.total_kills, p.total_deaths
-	`
-
-	var stats models.PlayerStats
-	err := db.DB.QueryRow(query, playerID).Scan(
-		&stats.PlayerID, &stats.TotalMatches, &stats.TotalWins, &stats.Losses,
-		&stats.WinRate, &stats.TotalKills, &stats.TotalDeaths, &stats.TotalAssists,
-		&stats.KDA, &stats.AverageScore, &stats.TotalMVP, &stats.PlayTime,
-	)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return &stats, nil
-}
-
-// getPlayerMatches 获取玩家对局历史
-func (h *StatsHandler) getPlayerMatches(playerID int64, limit, offset int) ([]models.PlayerMatchRecord, int, error) {
-	// 先查询总数
-	countQuery := `
-		SELECT COUNT(*) FROM player_match_records
-		WHERE player_id = $1
-	`
-
-	var total int
-	err := db.DB.QueryRow(countQuery, playerID).Scan(&total)
-	if err != nil {
-		return nil, 0, fmt.Errorf("查询对局总数失败: %w", err)
-	}
-
-	// 查询对局记录
-	query := `
-		SELECT pmr.match_id, pmr.player_id, pmr.character_id, pmr.team, pmr.score,
-		       pmr.kills, pmr.deaths, pmr.assists, pmr.exp_gained, pmr.coins_gained,
-		       pmr.mvp, pmr.play_time, pmr.join_time, pmr.leave_time
-		FROM player_match_records pmr
-		WHERE pmr.player_id = $1
-		ORDER BY pmr.join_time DESC
-		LIMIT $2 OFFSET $3
-	`
-
-	rows, err := db.DB.Query(query, playerID, limit, offset)
-	if err != nil {
-		return nil, 0, fmt.Errorf("查询对局记录失败: %w", err)
-	}
-	defer rows.Close()
-
-	var matches []models.PlayerMatchRecord
-	for rows.Next() {
-		var match models.PlayerMatchRecord
-		err := rows.Scan(
-			&match.MatchID, &match.PlayerID, &match.CharacterID, &match.Team,
-			&match.Score, &match.Kills, &match.Deaths, &match.Assists,
-			&match.ExpGained, &match.CoinsGained, &match.MVP,
-			&match.PlayTime, &match.JoinTime, &match.LeaveTime,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("扫描对局记录失败: %w", err)
-		}
-		matches = append(matches, match)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("遍历对局记录失败: %w", err)
-	}
-
-	return matches, total, nil
-}
-
-// getLeaderboard 获取排行榜
+// getLeaderboard 获取排行榜，优先读取Redis，不可用时回退到数据库
 func (h *StatsHandler) getLeaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
 	// 优先使用Redis
 	if h.useRedis {
@@ -399,65 +404,6 @@ func (h *StatsHandler) getLeaderboard(leaderboardType models.LeaderboardType, li
 	}
 
 	// 回退到数据库查询
-	return h.getLeaderboardFromDB(leaderboardType, limit)
+	return h.stats.Leaderboard(leaderboardType, limit)
 }
 
-// getLeaderboardFromDB 从数据库获取排行榜
-func (h *StatsHandler) getLeaderboardFromDB(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
-	var orderBy string
-
-	switch leaderboardType {
-	case models.LeaderboardKills:
-		orderBy = "p.total_kills DESC"
-	case models.LeaderboardWins:
-		orderBy = "p.total_wins DESC"
-	case models.LeaderboardKDA:
-		orderBy = "CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + p.total_assists) * 1.0 / p.total_deaths) ELSE (p.total_kills + p.total_assists) END DESC"
-	case models.LeaderboardScore:
-		orderBy = "(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) DESC"
-	default:
-		orderBy = "(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) DESC"
-	}
-
-	query := fmt.Sprintf(`
-		SELECT
-			p.id AS player_id,
-			p.username,
-			p.level,
-			p.total_kills,
-			p.total_wins,
-			CASE WHEN p.total_matches > 0 THEN (p.total_wins * 100.0 / p.total_matches) ELSE 0 END AS win_rate,
-			CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + p.total_assists) * 1.0 / p.total_deaths)
-				 ELSE (p.total_kills + p.total_assists) END AS kda,
-			(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) AS score,
-			ROW_NUMBER() OVER (ORDER BY %s) as rank
-		FROM players p
-		ORDER BY %s
-		LIMIT $1
-	`, orderBy, orderBy)
-
-	rows, err := db.DB.Query(query, limit)
-	if err != nil {
-		return nil, fmt.Errorf("查询排行榜失败: %w", err)
-	}
-	defer rows.Close()
-
-	var entries []models.LeaderboardEntry
-	for rows.Next() {
-		var entry models.LeaderboardEntry
-		err := rows.Scan(
-			&entry.PlayerID, &entry.Username, &entry.Level, &entry.TotalKills,
-			&entry.TotalWins, &entry.WinRate, &entry.KDA, &entry.Score, &entry.Rank,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("扫描排行榜数据失败: %w", err)
-		}
-		entries = append(entries, entry)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历排行榜数据失败: %w", err)
-	}
-
-	return entries, nil
-}
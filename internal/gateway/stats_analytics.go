@@ -0,0 +1,271 @@
+// stats_analytics.go
+
+package gateway
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 分析接口日期参数格式
+const analyticsDateLayout = "2006-01-02"
+
+// 未指定时间范围时默认统计的天数
+const defaultAnalyticsDays = 30
+
+// CharacterAnalyticsData 角色分析响应数据
+type CharacterAnalyticsData struct {
+	From       string                      `json:"from"`
+	To         string                      `json:"to"`
+	GameMode   string                      `json:"game_mode,omitempty"`
+	TotalPicks int                         `json:"total_picks"`
+	Characters []models.CharacterAnalytics `json:"characters"`
+}
+
+// refreshCharacterAnalytics 刷新角色每日数据物化视图
+func refreshCharacterAnalytics() error {
+	if db.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	// CONCURRENTLY 刷新期间不阻塞查询（依赖唯一索引 idx_character_daily_stats_key）
+	_, err := db.DB.Exec(`REFRESH MATERIALIZED VIEW CONCURRENTLY character_daily_stats`)
+	return err
+}
+
+// parseAnalyticsRange 解析 from/to 查询参数，返回 [from, to) 区间
+func parseAnalyticsRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to := today
+	if value := query.Get("to"); value != "" {
+		parsed, err := time.Parse(analyticsDateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("无效的结束日期，格式应为 YYYY-MM-DD")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -defaultAnalyticsDays+1)
+	if value := query.Get("from"); value != "" {
+		parsed, err := time.Parse(analyticsDateLayout, value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("无效的开始日期，格式应为 YYYY-MM-DD")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("开始日期不能晚于结束日期")
+	}
+
+	// 结束日期包含当天
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// handleCharacterAnalytics 处理角色选取率与胜率分析查询
+func (h *StatsHandler) handleCharacterAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseAnalyticsRange(r)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && !models.GameMode(mode).IsValid() {
+		h.sendErrorResponse(w, "无效的游戏模式", http.StatusBadRequest)
+		return
+	}
+
+	characters, totalPicks, err := h.getCharacterAnalytics(from, to, mode)
+	if err != nil {
+		log.Printf("查询角色分析数据失败: %v", err)
+		h.sendErrorResponse(w, "查询角色分析数据失败", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, "查询成功", &CharacterAnalyticsData{
+		From:       from.Format(analyticsDateLayout),
+		To:         to.AddDate(0, 0, -1).Format(analyticsDateLayout),
+		GameMode:   mode,
+		TotalPicks: totalPicks,
+		Characters: characters,
+	})
+}
+
+// getCharacterAnalytics 从物化视图汇总时间范围内各角色的数据
+func (h *StatsHandler) getCharacterAnalytics(from, to time.Time, mode string) ([]models.CharacterAnalytics, int, error) {
+	query := `
+		SELECT
+			c.id,
+			c.name,
+			COALESCE(SUM(s.picks), 0),
+			COALESCE(SUM(s.wins), 0),
+			COALESCE(SUM(s.kills), 0),
+			COALESCE(SUM(s.deaths), 0),
+			COALESCE(SUM(s.assists), 0),
+			COALESCE(SUM(s.score), 0)
+		FROM characters c
+		LEFT JOIN character_daily_stats s ON s.character_id = c.id
+			AND s.day >= $1 AND s.day < $2
+			AND ($3 = '' OR s.game_mode = $3)
+		GROUP BY c.id, c.name
+		ORDER BY COALESCE(SUM(s.picks), 0) DESC, c.id
+	`
+
+	rows, err := db.Reader().Query(query, from, to, mode)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var characters []models.CharacterAnalytics
+	totalPicks := 0
+	for rows.Next() {
+		var c models.CharacterAnalytics
+		var kills, deaths, assists, score int
+		if err := rows.Scan(&c.CharacterID, &c.CharacterName, &c.Picks, &c.Wins,
+			&kills, &deaths, &assists, &score); err != nil {
+			return nil, 0, err
+		}
+
+		if c.Picks > 0 {
+			picks := float64(c.Picks)
+			c.WinRate = float64(c.Wins) * 100.0 / picks
+			c.AverageKills = float64(kills) / picks
+			c.AverageDeaths = float64(deaths) / picks
+			c.AverageAssist = float64(assists) / picks
+			c.AverageScore = float64(score) / picks
+		}
+		if deaths > 0 {
+			c.AverageKDA = float64(kills+assists) / float64(deaths)
+		} else {
+			c.AverageKDA = float64(kills + assists)
+		}
+
+		totalPicks += c.Picks
+		characters = append(characters, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if totalPicks > 0 {
+		for i := range characters {
+			characters[i].PickRate = float64(characters[i].Picks) * 100.0 / float64(totalPicks)
+		}
+	}
+
+	return characters, totalPicks, nil
+}
+
+// MapAnalyticsData 地图分析响应数据
+type MapAnalyticsData struct {
+	From     string                `json:"from"`
+	To       string                `json:"to"`
+	GameMode string                `json:"game_mode,omitempty"`
+	Maps     []models.MapAnalytics `json:"maps"`
+}
+
+// handleMapAnalytics 处理地图对局数据分析查询
+func (h *StatsHandler) handleMapAnalytics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, to, err := parseAnalyticsRange(r)
+	if err != nil {
+		h.sendErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && !models.GameMode(mode).IsValid() {
+		h.sendErrorResponse(w, "无效的游戏模式", http.StatusBadRequest)
+		return
+	}
+
+	maps, err := h.getMapAnalytics(from, to, mode)
+	if err != nil {
+		log.Printf("查询地图分析数据失败: %v", err)
+		h.sendErrorResponse(w, "查询地图分析数据失败", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, "查询成功", &MapAnalyticsData{
+		From:     from.Format(analyticsDateLayout),
+		To:       to.AddDate(0, 0, -1).Format(analyticsDateLayout),
+		GameMode: mode,
+		Maps:     maps,
+	})
+}
+
+// getMapAnalytics 按地图和模式汇总已结束对局的场次、时长与胜负分布
+func (h *StatsHandler) getMapAnalytics(from, to time.Time, mode string) ([]models.MapAnalytics, error) {
+	query := `
+		SELECT
+			mr.map_id,
+			COALESCE(gm.name, ''),
+			mr.game_mode,
+			COUNT(*),
+			COALESCE(AVG(mr.duration), 0),
+			COALESCE(SUM(CASE WHEN mr.winning_team = $4 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN mr.winning_team = $5 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN mr.winning_team NOT IN ($4, $5) THEN 1 ELSE 0 END), 0)
+		FROM match_records mr
+		LEFT JOIN game_maps gm ON gm.id = mr.map_id
+		WHERE mr.status = $6
+			AND mr.map_id IS NOT NULL
+			AND mr.start_time >= $1 AND mr.start_time < $2
+			AND ($3 = '' OR mr.game_mode = $3)
+		GROUP BY mr.map_id, gm.name, mr.game_mode
+		ORDER BY mr.map_id, mr.game_mode
+	`
+
+	rows, err := db.Reader().Query(query, from, to, mode,
+		int(models.TeamRed), int(models.TeamBlue), string(models.RoomEnded))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	maps := make([]models.MapAnalytics, 0)
+	for rows.Next() {
+		var m models.MapAnalytics
+		var redWins, blueWins, undecided int
+		if err := rows.Scan(&m.MapID, &m.MapName, &m.GameMode, &m.Matches, &m.AverageDuration,
+			&redWins, &blueWins, &undecided); err != nil {
+			return nil, err
+		}
+
+		// 个人模式不记录获胜队伍，胜负分布只对团队模式有意义
+		if m.GameMode.IsTeamMode() && m.Matches > 0 {
+			matches := float64(m.Matches)
+			m.Draws = undecided
+			m.DrawRate = float64(undecided) * 100.0 / matches
+			m.Teams = []models.TeamWinStats{
+				{Team: models.TeamRed, Wins: redWins, WinRate: float64(redWins) * 100.0 / matches},
+				{Team: models.TeamBlue, Wins: blueWins, WinRate: float64(blueWins) * 100.0 / matches},
+			}
+		}
+
+		maps = append(maps, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return maps, nil
+}
@@ -0,0 +1,214 @@
+// stats_export.go
+
+package gateway
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 对局历史导出格式
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// MatchExportRecord 导出的对局记录
+type MatchExportRecord struct {
+	MatchID     string    `json:"match_id"`
+	GameMode    string    `json:"game_mode"`
+	MapID       int       `json:"map_id"`
+	CharacterID int       `json:"character_id"`
+	Team        int       `json:"team"`
+	Score       int       `json:"score"`
+	Kills       int       `json:"kills"`
+	Deaths      int       `json:"deaths"`
+	Assists     int       `json:"assists"`
+	ExpGained   int       `json:"exp_gained"`
+	CoinsGained int       `json:"coins_gained"`
+	MVP         bool      `json:"mvp"`
+	Won         bool      `json:"won"`
+	PlayTime    int       `json:"play_time"`
+	JoinTime    time.Time `json:"join_time"`
+	LeaveTime   time.Time `json:"leave_time"`
+}
+
+// matchExportCSVHeader CSV表头，与 csvRow 字段顺序一致
+var matchExportCSVHeader = []string{
+	"match_id", "game_mode", "map_id", "character_id", "team", "score",
+	"kills", "deaths", "assists", "exp_gained", "coins_gained",
+	"mvp", "won", "play_time", "join_time", "leave_time",
+}
+
+// csvRow 转换为CSV行
+func (m *MatchExportRecord) csvRow() []string {
+	return []string{
+		m.MatchID,
+		m.GameMode,
+		strconv.Itoa(m.MapID),
+		strconv.Itoa(m.CharacterID),
+		strconv.Itoa(m.Team),
+		strconv.Itoa(m.Score),
+		strconv.Itoa(m.Kills),
+		strconv.Itoa(m.Deaths),
+		strconv.Itoa(m.Assists),
+		strconv.Itoa(m.ExpGained),
+		strconv.Itoa(m.CoinsGained),
+		strconv.FormatBool(m.MVP),
+		strconv.FormatBool(m.Won),
+		strconv.Itoa(m.PlayTime),
+		m.JoinTime.UTC().Format(time.RFC3339),
+		m.LeaveTime.UTC().Format(time.RFC3339),
+	}
+}
+
+// handleMatchExport 导出玩家全部对局历史（流式输出，不受分页上限限制）
+func (h *StatsHandler) handleMatchExport(w http.ResponseWriter, r *http.Request, playerID int64) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatCSV
+	}
+	if format != ExportFormatCSV && format != ExportFormatJSON {
+		h.sendErrorResponse(w, "无效的导出格式，可选值: csv, json", http.StatusBadRequest)
+		return
+	}
+
+	// 确认玩家存在
+	var exists bool
+	if err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM players WHERE id = $1)`, playerID).Scan(&exists); err != nil {
+		log.Printf("检查玩家存在性失败: %v", err)
+		h.sendErrorResponse(w, "导出对局历史失败", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		h.sendErrorResponse(w, "玩家不存在", http.StatusNotFound)
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT pmr.match_id, COALESCE(mr.game_mode, ''), COALESCE(mr.map_id, 0),
+		       COALESCE(pmr.character_id, 0), COALESCE(pmr.team, 0), pmr.score,
+		       pmr.kills, pmr.deaths, pmr.assists, pmr.exp_gained, pmr.coins_gained,
+		       pmr.mvp, pmr.won, pmr.play_time, pmr.join_time, pmr.leave_time
+		FROM player_match_records pmr
+		LEFT JOIN match_records mr ON mr.id = pmr.match_id
+		WHERE pmr.player_id = $1
+		ORDER BY pmr.join_time DESC
+	`, playerID)
+	if err != nil {
+		log.Printf("查询对局历史失败: %v", err)
+		h.sendErrorResponse(w, "导出对局历史失败", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("matches_%d_%s.%s", playerID, time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var count int
+	if format == ExportFormatCSV {
+		count, err = writeMatchExportCSV(w, rows)
+	} else {
+		count, err = writeMatchExportJSON(w, rows)
+	}
+
+	// 响应头已发送，出错时只能中断输出并记录日志
+	if err != nil {
+		log.Printf("导出玩家 %d 对局历史中断 (已输出 %d 条): %v", playerID, count, err)
+		return
+	}
+	log.Printf("导出玩家 %d 对局历史: %d 条 (%s)", playerID, count, format)
+}
+
+// scanMatchExportRecord 扫描一条导出记录
+func scanMatchExportRecord(rows *sql.Rows) (*MatchExportRecord, error) {
+	var m MatchExportRecord
+	var leaveTime sql.NullTime
+	err := rows.Scan(
+		&m.MatchID, &m.GameMode, &m.MapID, &m.CharacterID, &m.Team, &m.Score,
+		&m.Kills, &m.Deaths, &m.Assists, &m.ExpGained, &m.CoinsGained,
+		&m.MVP, &m.Won, &m.PlayTime, &m.JoinTime, &leaveTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if leaveTime.Valid {
+		m.LeaveTime = leaveTime.Time
+	}
+	return &m, nil
+}
+
+// writeMatchExportCSV 以CSV格式逐行输出
+func writeMatchExportCSV(w http.ResponseWriter, rows *sql.Rows) (int, error) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(matchExportCSVHeader); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		m, err := scanMatchExportRecord(rows)
+		if err != nil {
+			return count, err
+		}
+		if err := writer.Write(m.csvRow()); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
+
+// writeMatchExportJSON 以JSON数组格式逐条输出
+func writeMatchExportJSON(w http.ResponseWriter, rows *sql.Rows) (int, error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte("[")); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		m, err := scanMatchExportRecord(rows)
+		if err != nil {
+			return count, err
+		}
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			return count, err
+		}
+		if count > 0 {
+			if _, err := w.Write([]byte(",\n")); err != nil {
+				return count, err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	_, err := w.Write([]byte("]\n"))
+	return count, err
+}
@@ -0,0 +1,127 @@
+// stats_heatmap.go
+
+package gateway
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// handleMatchStats 处理单场对局数据请求: /stats/match/{id}/heatmap
+func (h *StatsHandler) handleMatchStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.sendErrorResponse(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/stats/match/")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] != "heatmap" {
+		h.sendErrorResponse(w, "无效的请求路径", http.StatusNotFound)
+		return
+	}
+
+	eventType := models.PositionEventType(r.URL.Query().Get("type"))
+	if eventType != "" && eventType != models.PositionEventKill && eventType != models.PositionEventDeath {
+		h.sendErrorResponse(w, "无效的事件类型，可选值: kill, death", http.StatusBadRequest)
+		return
+	}
+
+	heatmap, err := h.getMatchHeatmap(parts[0], eventType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.sendErrorResponse(w, "对局不存在", http.StatusNotFound)
+			return
+		}
+		log.Printf("查询对局热力图失败: %v", err)
+		h.sendErrorResponse(w, "查询对局热力图失败", http.StatusInternalServerError)
+		return
+	}
+
+	// 隐藏了对局历史的玩家不出现在对局详情中
+	if err := h.filterHiddenEvents(r, heatmap); err != nil {
+		log.Printf("检查对局 %s 玩家隐私设置失败: %v", parts[0], err)
+		h.sendErrorResponse(w, "查询对局热力图失败", http.StatusInternalServerError)
+		return
+	}
+
+	h.sendSuccessResponse(w, "查询成功", heatmap)
+}
+
+// getMatchHeatmap 获取对局的击杀/死亡位置数据
+func (h *StatsHandler) getMatchHeatmap(matchID string, eventType models.PositionEventType) (*models.MatchHeatmap, error) {
+	heatmap := &models.MatchHeatmap{
+		MatchID: matchID,
+		Events:  make([]models.PositionEvent, 0),
+	}
+
+	// 对局及地图尺寸
+	err := db.DB.QueryRow(`
+		SELECT COALESCE(mr.map_id, 0), COALESCE(gm.width, 0), COALESCE(gm.height, 0)
+		FROM match_records mr
+		LEFT JOIN game_maps gm ON gm.id = mr.map_id
+		WHERE mr.id = $1
+	`, matchID).Scan(&heatmap.MapID, &heatmap.MapWidth, &heatmap.MapHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT event_type, player_id, COALESCE(other_player_id, 0), COALESCE(character_id, 0),
+		       pos_x, pos_y, COALESCE(elapsed, 0)
+		FROM match_position_events
+		WHERE match_id = $1 AND ($2 = '' OR event_type = $2)
+		ORDER BY id
+	`, matchID, string(eventType))
+	if err != nil {
+		return nil, fmt.Errorf("查询位置事件失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.PositionEvent
+		if err := rows.Scan(&e.Type, &e.PlayerID, &e.OtherPlayerID, &e.CharacterID,
+			&e.X, &e.Y, &e.Elapsed); err != nil {
+			return nil, fmt.Errorf("扫描位置事件失败: %w", err)
+		}
+		heatmap.Events = append(heatmap.Events, e)
+	}
+
+	return heatmap, rows.Err()
+}
+
+// filterHiddenEvents 移除对请求者隐藏了对局历史的玩家的位置事件，并隐去其作为对手的ID
+func (h *StatsHandler) filterHiddenEvents(r *http.Request, heatmap *models.MatchHeatmap) error {
+	playerIDs := make([]int64, 0, len(heatmap.Events))
+	for _, e := range heatmap.Events {
+		playerIDs = append(playerIDs, e.PlayerID)
+		if e.OtherPlayerID != 0 {
+			playerIDs = append(playerIDs, e.OtherPlayerID)
+		}
+	}
+
+	hidden, err := privacy.HiddenPlayers(h.viewerID(r), playerIDs, privacy.FieldMatchHistory)
+	if err != nil || len(hidden) == 0 {
+		return err
+	}
+
+	events := heatmap.Events[:0]
+	for _, e := range heatmap.Events {
+		if hidden[e.PlayerID] {
+			continue
+		}
+		if hidden[e.OtherPlayerID] {
+			e.OtherPlayerID = 0
+		}
+		events = append(events, e)
+	}
+	heatmap.Events = events
+	return nil
+}
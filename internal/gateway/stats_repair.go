@@ -0,0 +1,297 @@
+// stats_repair.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 战绩修复任务状态
+const (
+	RepairJobRunning   = "running"
+	RepairJobCompleted = "completed"
+	RepairJobFailed    = "failed"
+	RepairJobCancelled = "cancelled"
+)
+
+const (
+	// 默认每批处理的玩家数
+	defaultRepairBatchSize = 500
+	// 最大每批处理的玩家数
+	maxRepairBatchSize = 5000
+	// 批次间隔，降低在线修复对数据库的压力
+	repairBatchPause = 100 * time.Millisecond
+)
+
+// StatsRepairJob 战绩修复任务
+type StatsRepairJob struct {
+	ID           string     `json:"id"`
+	Status       string     `json:"status"`
+	DryRun       bool       `json:"dry_run"`    // 仅统计偏差，不写入
+	BatchSize    int        `json:"batch_size"` // 每批处理的玩家数
+	TotalPlayers int        `json:"total_players"`
+	Processed    int        `json:"processed"` // 已检查的玩家数
+	Repaired     int        `json:"repaired"`  // 数据有偏差并已修复（或将被修复）的玩家数
+	Progress     float64    `json:"progress"`  // 进度百分比
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Error        string     `json:"error,omitempty"`
+
+	cancel chan struct{}
+}
+
+// StatsRepairRequest 战绩修复请求
+type StatsRepairRequest struct {
+	BatchSize int  `json:"batch_size"`
+	DryRun    bool `json:"dry_run"`
+}
+
+// statsRepairer 战绩修复任务管理
+type statsRepairer struct {
+	mutex sync.Mutex
+	job   *StatsRepairJob
+}
+
+// handleStatsRepair 处理战绩修复任务请求
+func (h *StatsHandler) handleStatsRepair(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		job := h.repairer.snapshot()
+		if job == nil {
+			h.sendErrorResponse(w, "没有修复任务", http.StatusNotFound)
+			return
+		}
+		h.sendSuccessResponse(w, "查询成功", job)
+	case http.MethodPost:
+		var req StatsRepairRequest
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				h.sendErrorResponse(w, "无效的请求格式", http.StatusBadRequest)
+				return
+			}
+		}
+
+		job, err := h.repairer.start(req)
+		if err != nil {
+			h.sendErrorResponse(w, err.Error(), http.StatusConflict)
+			return
+		}
+		h.sendSuccessResponse(w, "修复任务已启动", job)
+	case http.MethodDelete:
+		if !h.repairer.stop() {
+			h.sendErrorResponse(w, "没有正在运行的修复任务", http.StatusNotFound)
+			return
+		}
+		h.sendSuccessResponse(w, "修复任务已取消", h.repairer.snapshot())
+	default:
+		h.sendErrorResponse(w, "仅支持GET、POST和DELETE方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// start 启动修复任务（同一时间只允许一个任务运行）
+func (sr *statsRepairer) start(req StatsRepairRequest) (*StatsRepairJob, error) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	if sr.job != nil && sr.job.Status == RepairJobRunning {
+		return nil, fmt.Errorf("已有修复任务正在运行: %s", sr.job.ID)
+	}
+
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRepairBatchSize
+	}
+	if batchSize > maxRepairBatchSize {
+		batchSize = maxRepairBatchSize
+	}
+
+	job := &StatsRepairJob{
+		ID:        uuid.New().String(),
+		Status:    RepairJobRunning,
+		DryRun:    req.DryRun,
+		BatchSize: batchSize,
+		StartedAt: time.Now(),
+		cancel:    make(chan struct{}),
+	}
+	sr.job = job
+
+	go sr.run(job)
+
+	copied := *job
+	return &copied, nil
+}
+
+// stop 取消正在运行的任务
+func (sr *statsRepairer) stop() bool {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	if sr.job == nil || sr.job.Status != RepairJobRunning {
+		return false
+	}
+
+	select {
+	case <-sr.job.cancel:
+	default:
+		close(sr.job.cancel)
+	}
+	return true
+}
+
+// snapshot 获取当前（或最近一次）任务的副本
+func (sr *statsRepairer) snapshot() *StatsRepairJob {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	if sr.job == nil {
+		return nil
+	}
+	copied := *sr.job
+	return &copied
+}
+
+// update 在锁内更新任务状态
+func (sr *statsRepairer) update(fn func(job *StatsRepairJob)) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+	fn(sr.job)
+}
+
+// run 按玩家ID分批修复
+func (sr *statsRepairer) run(job *StatsRepairJob) {
+	finish := func(status string, err error) {
+		var processed, repaired int
+		sr.update(func(j *StatsRepairJob) {
+			now := time.Now()
+			j.Status = status
+			j.FinishedAt = &now
+			if err != nil {
+				j.Error = err.Error()
+			}
+			processed, repaired = j.Processed, j.Repaired
+		})
+		log.Printf("战绩修复任务 %s 结束: %s (已检查 %d, 修复 %d)", job.ID, status, processed, repaired)
+
+		if status == RepairJobCompleted && !job.DryRun && repaired > 0 {
+			models.MarkLeaderboardStale()
+		}
+	}
+
+	var total int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM players`).Scan(&total); err != nil {
+		finish(RepairJobFailed, fmt.Errorf("统计玩家数失败: %w", err))
+		return
+	}
+	sr.update(func(j *StatsRepairJob) { j.TotalPlayers = total })
+	log.Printf("战绩修复任务 %s 启动: 共 %d 名玩家, 每批 %d, 试运行: %v", job.ID, total, job.BatchSize, job.DryRun)
+
+	var lastID int64
+	for {
+		select {
+		case <-job.cancel:
+			finish(RepairJobCancelled, nil)
+			return
+		default:
+		}
+
+		// 获取本批次的ID上界
+		var upperID int64
+		var count int
+		err := db.DB.QueryRow(`
+			SELECT COALESCE(MAX(id), 0), COUNT(*) FROM (
+				SELECT id FROM players WHERE id > $1 ORDER BY id LIMIT $2
+			) batch
+		`, lastID, job.BatchSize).Scan(&upperID, &count)
+		if err != nil {
+			finish(RepairJobFailed, fmt.Errorf("查询玩家批次失败: %w", err))
+			return
+		}
+		if count == 0 {
+			break
+		}
+
+		repaired, err := repairStatsBatch(lastID, upperID, job.DryRun)
+		if err != nil {
+			finish(RepairJobFailed, fmt.Errorf("修复玩家 (%d, %d] 失败: %w", lastID, upperID, err))
+			return
+		}
+
+		lastID = upperID
+		sr.update(func(j *StatsRepairJob) {
+			j.Processed += count
+			j.Repaired += repaired
+			if j.TotalPlayers > 0 {
+				j.Progress = float64(j.Processed) * 100.0 / float64(j.TotalPlayers)
+			}
+		})
+
+		time.Sleep(repairBatchPause)
+	}
+
+	sr.update(func(j *StatsRepairJob) { j.Progress = 100 })
+	finish(RepairJobCompleted, nil)
+}
+
+// statsRepairAggregateSQL 按对局记录（含归档）重新计算指定ID区间内玩家的战绩
+const statsRepairAggregateSQL = `
+	SELECT
+		pl.id AS player_id,
+		COALESCE(SUM(pmr.kills), 0) AS kills,
+		COALESCE(SUM(pmr.deaths), 0) AS deaths,
+		COALESCE(SUM(pmr.assists), 0) AS assists,
+		COUNT(pmr.match_id) AS matches,
+		COALESCE(SUM(CASE WHEN pmr.won THEN 1 ELSE 0 END), 0) AS wins
+	FROM players pl
+	LEFT JOIN player_match_records_all pmr ON pmr.player_id = pl.id
+	WHERE pl.id > $1 AND pl.id <= $2
+	GROUP BY pl.id
+`
+
+// statsRepairDriftCondition 判断汇总数据是否与对局记录不一致
+const statsRepairDriftCondition = `
+	p.id = agg.player_id AND
+	(p.total_kills, p.total_deaths, p.total_assists, p.total_matches, p.total_wins)
+		IS DISTINCT FROM (agg.kills, agg.deaths, agg.assists, agg.matches, agg.wins)
+`
+
+// repairStatsBatch 修复ID区间 (lowerID, upperID] 内的玩家战绩，返回有偏差的玩家数
+func repairStatsBatch(lowerID, upperID int64, dryRun bool) (int, error) {
+	var repaired int
+
+	if dryRun {
+		query := `SELECT COUNT(*) FROM players p, (` + statsRepairAggregateSQL + `) agg WHERE ` + statsRepairDriftCondition
+		err := db.DB.QueryRow(query, lowerID, upperID).Scan(&repaired)
+		return repaired, err
+	}
+
+	query := `
+		UPDATE players p SET
+			total_kills = agg.kills,
+			total_deaths = agg.deaths,
+			total_assists = agg.assists,
+			total_matches = agg.matches,
+			total_wins = agg.wins,
+			updated_at = NOW()
+		FROM (` + statsRepairAggregateSQL + `) agg
+		WHERE ` + statsRepairDriftCondition
+
+	result, err := db.DB.Exec(query, lowerID, upperID)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,101 @@
+// stats_window.go
+
+package gateway
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 战绩汇总数据刷新间隔
+const statsRefreshInterval = 5 * time.Minute
+
+// statsRefreshJobs 定期执行的战绩汇总刷新任务
+var statsRefreshJobs = map[string]func() error{
+	"时间窗口战绩": refreshStatsWindows,
+	"角色分析数据": refreshCharacterAnalytics,
+}
+
+// statsRefresher 定期刷新战绩汇总数据
+func (g *Gateway) statsRefresher() {
+	// 启动时先刷新一次
+	runStatsRefreshJobs()
+
+	ticker := time.NewTicker(statsRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			runStatsRefreshJobs()
+		case <-g.shutdown:
+			return
+		}
+	}
+}
+
+// runStatsRefreshJobs 依次执行所有刷新任务
+func runStatsRefreshJobs() {
+	for name, job := range statsRefreshJobs {
+		if err := job(); err != nil {
+			log.Printf("刷新%s失败: %v", name, err)
+		}
+	}
+}
+
+// refreshStatsWindows 从玩家对局记录重新计算所有时间窗口的汇总
+func refreshStatsWindows() error {
+	if db.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	for window, trunc := range models.StatsWindowTrunc {
+		if err := refreshStatsWindow(window, trunc); err != nil {
+			return fmt.Errorf("刷新 %s 窗口失败: %w", window, err)
+		}
+	}
+	return nil
+}
+
+// refreshStatsWindow 在事务中重建单个时间窗口的汇总，避免读到半刷新的数据
+func refreshStatsWindow(window models.StatsWindow, trunc string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM player_stats_windows WHERE stats_window = $1`, string(window)); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO player_stats_windows (player_id, stats_window, window_start, matches, wins,
+		                                  kills, deaths, assists, score, mvp, play_time, refreshed_at)
+		SELECT
+			pmr.player_id,
+			$1,
+			date_trunc($2, NOW()),
+			COUNT(*),
+			COALESCE(SUM(CASE WHEN pmr.won THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(pmr.kills), 0),
+			COALESCE(SUM(pmr.deaths), 0),
+			COALESCE(SUM(pmr.assists), 0),
+			COALESCE(SUM(pmr.score), 0),
+			COALESCE(SUM(CASE WHEN pmr.mvp THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(pmr.play_time), 0),
+			NOW()
+		FROM player_match_records pmr
+		WHERE pmr.join_time >= date_trunc($2, NOW())
+		GROUP BY pmr.player_id
+	`, string(window), trunc)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
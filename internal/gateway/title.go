@@ -0,0 +1,153 @@
+// title.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/title"
+)
+
+// TitleHandler 称号处理器
+type TitleHandler struct {
+	auth *AuthHandler
+}
+
+// NewTitleHandler 创建称号处理器
+func NewTitleHandler(auth *AuthHandler) *TitleHandler {
+	return &TitleHandler{auth: auth}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *TitleHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/titles", h.handleCatalog)
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *TitleHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("titles", h.handlePlayerTitles)
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *TitleHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/titles", admin.Wrap(h.handleAdminTitles))
+	mux.HandleFunc("/admin/titles/award", admin.Wrap(h.handleAdminAward))
+}
+
+// handleCatalog 查询全部称号和徽章
+func (h *TitleHandler) handleCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	titles, err := title.Catalog()
+	if err != nil {
+		log.Printf("查询称号失败: %v", err)
+		sendJSONError(w, "查询称号失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", titles)
+}
+
+// handlePlayerTitles GET 查询玩家称号，PUT 装备称号和徽章（仅限本人）
+func (h *TitleHandler) handlePlayerTitles(w http.ResponseWriter, r *http.Request, playerID int64) {
+	switch r.Method {
+	case http.MethodGet:
+		titles, err := title.PlayerTitles(playerID)
+		if err != nil {
+			log.Printf("查询玩家 %d 称号失败: %v", playerID, err)
+			sendJSONError(w, "查询称号失败", http.StatusInternalServerError)
+			return
+		}
+		sendJSONSuccess(w, "查询成功", titles)
+	case http.MethodPut:
+		session, ok := h.auth.Authenticate(r)
+		if !ok {
+			h.auth.sendAuthError(w, r)
+			return
+		}
+		if session.PlayerID != playerID {
+			sendJSONError(w, "只能修改自己的称号", http.StatusForbidden)
+			return
+		}
+
+		var req models.EquipTitlesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+
+		titles, err := title.Equip(playerID, &req)
+		if err != nil {
+			h.sendError(w, "装备称号失败", err)
+			return
+		}
+		sendJSONSuccess(w, "装备成功", titles)
+	default:
+		sendJSONError(w, "仅支持GET和PUT方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminTitles 管理称号定义: GET 全部, POST 添加
+func (h *TitleHandler) handleAdminTitles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.handleCatalog(w, r)
+	case http.MethodPost:
+		var t models.Title
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+			return
+		}
+		if err := title.Create(&t); err != nil {
+			h.sendError(w, "添加称号失败", err)
+			return
+		}
+		sendJSONSuccess(w, "添加成功", t)
+	default:
+		sendJSONError(w, "仅支持GET和POST方法", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleAdminAward 向玩家发放称号（赛事结算、运营活动等）
+func (h *TitleHandler) handleAdminAward(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.AwardTitleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	awarded, err := title.Award(req.TitleID, req.PlayerIDs, req.SourceRef)
+	if err != nil {
+		h.sendError(w, "发放称号失败", err)
+		return
+	}
+
+	log.Printf("管理员发放称号 %d 给 %d 名玩家（新获得 %d 名）", req.TitleID, len(req.PlayerIDs), awarded)
+	sendJSONSuccess(w, "发放成功", map[string]int{"awarded": awarded})
+}
+
+// sendError 按错误类型返回称号错误
+func (h *TitleHandler) sendError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, title.ErrTitleNotFound):
+		sendServiceError(w, err, http.StatusNotFound)
+	case errors.Is(err, title.ErrTitleNotOwned):
+		sendServiceError(w, err, http.StatusForbidden)
+	case errors.Is(err, title.ErrInvalidTitle):
+		sendServiceError(w, err, http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		sendJSONError(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,99 @@
+// trial.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// TrialHandler 角色试玩处理器
+type TrialHandler struct {
+	trials *trial.Service
+	auth   *AuthHandler
+}
+
+// NewTrialHandler 创建角色试玩处理器
+func NewTrialHandler(auth *AuthHandler, trials *trial.Service) *TrialHandler {
+	return &TrialHandler{trials: trials, auth: auth}
+}
+
+// RegisterHandlers 注册HTTP处理器
+func (h *TrialHandler) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/trials/rotation", h.handleRotation)
+	mux.HandleFunc("/trials", h.auth.RequireAuth(h.handleTrials))
+	mux.HandleFunc("/trials/rent", h.auth.RequireAuth(h.handleRent))
+}
+
+// handleRotation 查询本周免费轮换角色
+func (h *TrialHandler) handleRotation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rotation, err := h.trials.Rotation(time.Now())
+	if err != nil {
+		log.Printf("查询免费轮换角色失败: %v", err)
+		sendJSONError(w, "查询轮换角色失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", rotation)
+}
+
+// handleTrials 查询当前玩家可试玩的角色
+func (h *TrialHandler) handleTrials(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trials, err := h.trials.Active(session.PlayerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 试玩角色失败: %v", session.PlayerID, err)
+		sendJSONError(w, "查询试玩角色失败", http.StatusInternalServerError)
+		return
+	}
+	sendJSONSuccess(w, "查询成功", trials)
+}
+
+// handleRent 花费宝石租用角色
+func (h *TrialHandler) handleRent(w http.ResponseWriter, r *http.Request, session SessionInfo) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.RentCharacterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.trials.Rent(session.PlayerID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, trial.ErrInvalidRental), errors.Is(err, trial.ErrNotRentable):
+			sendServiceError(w, err, http.StatusBadRequest)
+		case errors.Is(err, trial.ErrRentalDisabled):
+			sendServiceError(w, err, http.StatusForbidden)
+		case errors.Is(err, trial.ErrCharacterNotFound), errors.Is(err, wallet.ErrPlayerNotFound):
+			sendServiceError(w, err, http.StatusNotFound)
+		case errors.Is(err, trial.ErrAlreadyOwned), errors.Is(err, wallet.ErrInsufficientFunds),
+			errors.Is(err, wallet.ErrIdempotencyConflict):
+			sendServiceError(w, err, http.StatusConflict)
+		default:
+			log.Printf("玩家 %d 租用角色 %d 失败: %v", session.PlayerID, req.CharacterID, err)
+			sendJSONError(w, "租用角色失败", http.StatusInternalServerError)
+		}
+		return
+	}
+	sendJSONSuccess(w, "租用成功", result)
+}
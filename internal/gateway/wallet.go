@@ -0,0 +1,122 @@
+// wallet.go
+
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+)
+
+// WalletHandler 钱包处理器
+type WalletHandler struct {
+	wallet *wallet.Service
+}
+
+// NewWalletHandler 创建钱包处理器
+func NewWalletHandler() *WalletHandler {
+	return &WalletHandler{
+		wallet: wallet.NewService(),
+	}
+}
+
+// TransactionsData 交易流水响应数据
+type TransactionsData struct {
+	Transactions []models.CurrencyTransaction `json:"transactions"`
+	Total        int                          `json:"total"`
+	Page         int                          `json:"page"`
+	Limit        int                          `json:"limit"`
+}
+
+// RegisterPlayerRoutes 注册玩家子资源路由
+func (h *WalletHandler) RegisterPlayerRoutes(profile *ProfileHandler) {
+	profile.RegisterSubResource("transactions", h.handlePlayerTransactions)
+}
+
+// RegisterAdminHandlers 注册管理员路由
+func (h *WalletHandler) RegisterAdminHandlers(mux *http.ServeMux, admin *AdminAuth) {
+	mux.HandleFunc("/admin/wallet/grant", admin.Wrap(h.handleGrant))
+}
+
+// handlePlayerTransactions 处理玩家交易流水查询
+func (h *WalletHandler) handlePlayerTransactions(w http.ResponseWriter, r *http.Request, playerID int64) {
+	if r.Method != http.MethodGet {
+		sendJSONError(w, "仅支持GET方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 20
+	offset := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	currency := models.Currency(query.Get("currency"))
+	if currency != "" && !currency.IsValid() {
+		sendJSONError(w, "无效的货币类型，可选值: coins, gems", http.StatusBadRequest)
+		return
+	}
+
+	transactions, total, err := h.wallet.Transactions(playerID, currency, limit, offset)
+	if err != nil {
+		log.Printf("查询玩家 %d 交易流水失败: %v", playerID, err)
+		sendJSONError(w, "查询交易流水失败", http.StatusInternalServerError)
+		return
+	}
+
+	sendJSONSuccess(w, "查询成功", &TransactionsData{
+		Transactions: transactions,
+		Total:        total,
+		Page:         offset/limit + 1,
+		Limit:        limit,
+	})
+}
+
+// handleGrant 处理管理员发放或扣除货币
+func (h *WalletHandler) handleGrant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		sendJSONError(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.TransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = models.ReasonGrant
+	}
+
+	txn, err := h.wallet.Apply(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, wallet.ErrPlayerNotFound):
+			sendServiceError(w, err, http.StatusNotFound)
+		case errors.Is(err, wallet.ErrInvalidTransaction):
+			sendServiceError(w, err, http.StatusBadRequest)
+		case errors.Is(err, wallet.ErrInsufficientFunds), errors.Is(err, wallet.ErrIdempotencyConflict):
+			sendServiceError(w, err, http.StatusConflict)
+		default:
+			log.Printf("发放货币失败: %v", err)
+			sendJSONError(w, "发放货币失败", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Printf("管理员发放货币: 玩家 %d, %s %+d, 余额 %d", txn.PlayerID, txn.Currency, txn.Amount, txn.BalanceAfter)
+	sendJSONSuccess(w, "发放成功", txn)
+}
@@ -0,0 +1,306 @@
+// gift.go
+
+package gift
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/shop"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// defaultMaxMessageLength 默认留言最大字符数
+const defaultMaxMessageLength = 100
+
+var (
+	// ErrInvalidGift 赠礼参数无效
+	ErrInvalidGift = errcode.New(errcode.BadRequest, "无效的赠礼")
+	// ErrCannotGiftSelf 不能赠送给自己
+	ErrCannotGiftSelf = errcode.New(errcode.BadRequest, "不能赠送给自己")
+	// ErrAccountTooNew 账号注册时间不足
+	ErrAccountTooNew = errcode.New(errcode.AccountTooNew, "账号注册时间不足，暂不能赠礼")
+	// ErrDailyLimit 超过每日赠礼限制
+	ErrDailyLimit = errcode.New(errcode.LimitReached, "已达到今日赠礼上限")
+	// ErrNotGiftable 商品不可赠送
+	ErrNotGiftable = errcode.New(errcode.NotAllowed, "该商品不可赠送")
+)
+
+// Service 赠礼服务
+type Service struct {
+	wallet *wallet.Service
+	shop   *shop.Service
+	cfg    config.GiftConfig
+}
+
+// NewService 创建赠礼服务
+func NewService(w *wallet.Service, s *shop.Service, cfg config.GiftConfig) *Service {
+	if cfg.MaxMessageLength <= 0 {
+		cfg.MaxMessageLength = defaultMaxMessageLength
+	}
+	return &Service{wallet: w, shop: s, cfg: cfg}
+}
+
+// Send 向其他玩家赠送宝石或购买商品赠送，货币变动记入双方流水
+func (s *Service) Send(senderID, recipientID int64, req *models.SendGiftRequest) (*models.Gift, error) {
+	if err := s.validate(senderID, recipientID, req); err != nil {
+		return nil, err
+	}
+	if err := social.CheckInvite(senderID, recipientID); err != nil {
+		return nil, err
+	}
+
+	// 商品赠礼先确认商品可购买
+	var offer *models.ShopOffer
+	if req.OfferID > 0 {
+		var err error
+		if offer, err = s.shop.GetOffer(req.OfferID); err != nil {
+			return nil, err
+		}
+		if !offer.IsAvailable(time.Now()) {
+			return nil, shop.ErrOfferUnavailable
+		}
+		// 限购商品不可赠送，避免绕过限购
+		if offer.PurchaseLimit > 0 {
+			return nil, ErrNotGiftable
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 按ID顺序锁定双方，避免互相赠送时死锁
+	createdAt, err := lockPlayers(tx, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重复请求直接返回已有记录
+	key := fmt.Sprintf("gift:%d:%s", senderID, req.RequestID)
+	if existing, err := getGiftByKey(tx, key); err != nil || existing != nil {
+		return existing, err
+	}
+
+	if time.Since(createdAt) < time.Duration(s.cfg.MinAccountAgeDays)*24*time.Hour {
+		return nil, ErrAccountTooNew
+	}
+	if err := s.checkDailyLimits(tx, senderID, req.Gems); err != nil {
+		return nil, err
+	}
+
+	g := &models.Gift{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Message:     req.Message,
+	}
+	if offer != nil {
+		g.Type = models.GiftItem
+		g.OfferID = offer.ID
+		g.ItemID = offer.ItemID
+		g.Quantity = offer.Quantity
+		g.Currency = offer.Currency
+		g.Amount = offer.Price
+	} else {
+		g.Type = models.GiftGems
+		g.Currency = models.CurrencyGems
+		g.Amount = req.Gems
+	}
+
+	var offerID, itemID interface{}
+	if offer != nil {
+		offerID, itemID = offer.ID, offer.ItemID
+	}
+	err = tx.QueryRow(`
+		INSERT INTO player_gifts (sender_id, recipient_id, gift_type, offer_id, item_id, quantity,
+		                          currency, amount, message, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at
+	`, senderID, recipientID, string(g.Type), offerID, itemID, g.Quantity,
+		string(g.Currency), g.Amount, g.Message, key).Scan(&g.ID, &g.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("记录赠礼失败: %w", err)
+	}
+
+	reference := fmt.Sprintf("gift:%d", g.ID)
+
+	// 扣除赠送方货币
+	_, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       senderID,
+		Currency:       g.Currency,
+		Amount:         -g.Amount,
+		Reason:         models.ReasonGiftSent,
+		ReferenceID:    reference,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 发放给接收方
+	if offer != nil {
+		if err := inventory.GrantTx(tx, recipientID, offer.Item, offer.Quantity); err != nil {
+			return nil, err
+		}
+	} else {
+		_, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+			PlayerID:       recipientID,
+			Currency:       models.CurrencyGems,
+			Amount:         g.Amount,
+			Reason:         models.ReasonGiftReceived,
+			ReferenceID:    reference,
+			IdempotencyKey: key + ":received",
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return g, nil
+}
+
+// validate 校验赠礼请求
+func (s *Service) validate(senderID, recipientID int64, req *models.SendGiftRequest) error {
+	if senderID == recipientID {
+		return ErrCannotGiftSelf
+	}
+	if req.RequestID == "" || len(req.RequestID) > 64 {
+		return fmt.Errorf("%w: 请求ID不能为空且不超过64个字符", ErrInvalidGift)
+	}
+	if (req.Gems > 0) == (req.OfferID > 0) || req.Gems < 0 || req.OfferID < 0 {
+		return fmt.Errorf("%w: 必须指定 gems 或 offer_id 其中之一", ErrInvalidGift)
+	}
+	if utf8.RuneCountInString(req.Message) > s.cfg.MaxMessageLength {
+		return fmt.Errorf("%w: 留言不能超过 %d 个字符", ErrInvalidGift, s.cfg.MaxMessageLength)
+	}
+	return nil
+}
+
+// checkDailyLimits 检查赠送方今日赠礼次数和宝石数量
+func (s *Service) checkDailyLimits(tx *sql.Tx, senderID int64, gems int64) error {
+	if s.cfg.DailyGiftLimit <= 0 && s.cfg.DailyGemCap <= 0 {
+		return nil
+	}
+
+	var count int
+	var gemsSent int64
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+	err := tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(amount) FILTER (WHERE gift_type = $3), 0)
+		FROM player_gifts
+		WHERE sender_id = $1 AND created_at >= $2
+	`, senderID, dayStart, string(models.GiftGems)).Scan(&count, &gemsSent)
+	if err != nil {
+		return fmt.Errorf("查询今日赠礼记录失败: %w", err)
+	}
+
+	if s.cfg.DailyGiftLimit > 0 && count >= s.cfg.DailyGiftLimit {
+		return fmt.Errorf("%w: 每日最多赠礼 %d 次", ErrDailyLimit, s.cfg.DailyGiftLimit)
+	}
+	if s.cfg.DailyGemCap > 0 && gemsSent+gems > s.cfg.DailyGemCap {
+		return fmt.Errorf("%w: 每日最多赠送 %d 宝石，今日已赠送 %d", ErrDailyLimit, s.cfg.DailyGemCap, gemsSent)
+	}
+	return nil
+}
+
+// lockPlayers 锁定赠送方和接收方，返回赠送方的注册时间
+func lockPlayers(tx *sql.Tx, senderID, recipientID int64) (time.Time, error) {
+	rows, err := tx.Query(`
+		SELECT id, created_at FROM players WHERE id IN ($1, $2) ORDER BY id FOR UPDATE
+	`, senderID, recipientID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("锁定玩家失败: %w", err)
+	}
+	defer rows.Close()
+
+	var senderCreatedAt time.Time
+	found := 0
+	for rows.Next() {
+		var id int64
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return time.Time{}, fmt.Errorf("扫描玩家失败: %w", err)
+		}
+		if id == senderID {
+			senderCreatedAt = createdAt
+		}
+		found++
+	}
+	if err := rows.Err(); err != nil {
+		return time.Time{}, fmt.Errorf("锁定玩家失败: %w", err)
+	}
+	if found < 2 {
+		return time.Time{}, wallet.ErrPlayerNotFound
+	}
+	return senderCreatedAt, nil
+}
+
+// getGiftByKey 按幂等键查询赠礼记录
+func getGiftByKey(tx *sql.Tx, key string) (*models.Gift, error) {
+	g, err := scanGift(tx.QueryRow(`SELECT `+giftColumns+` FROM player_gifts WHERE idempotency_key = $1`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询赠礼记录失败: %w", err)
+	}
+	return g, nil
+}
+
+// giftColumns 赠礼查询字段，与 scanGift 顺序一致
+const giftColumns = `
+	id, sender_id, recipient_id, gift_type, COALESCE(offer_id, 0), COALESCE(item_id, 0), quantity,
+	currency, amount, COALESCE(message, ''), created_at
+`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGift 扫描赠礼记录
+func scanGift(row rowScanner) (*models.Gift, error) {
+	var g models.Gift
+	err := row.Scan(&g.ID, &g.SenderID, &g.RecipientID, &g.Type, &g.OfferID, &g.ItemID, &g.Quantity,
+		&g.Currency, &g.Amount, &g.Message, &g.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// History 查询玩家发出和收到的赠礼记录
+func History(playerID int64, limit int) ([]models.Gift, error) {
+	rows, err := db.DB.Query(`SELECT `+giftColumns+`
+		FROM player_gifts
+		WHERE sender_id = $1 OR recipient_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`, playerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询赠礼记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	gifts := make([]models.Gift, 0)
+	for rows.Next() {
+		g, err := scanGift(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描赠礼记录失败: %w", err)
+		}
+		gifts = append(gifts, *g)
+	}
+	return gifts, rows.Err()
+}
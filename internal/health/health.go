@@ -0,0 +1,196 @@
+// health.go
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// checkTimeout 单个就绪检查项的超时时间
+const checkTimeout = 2 * time.Second
+
+const (
+	// StatusOK 检查通过
+	StatusOK = "ok"
+	// StatusUnavailable 检查未通过
+	StatusUnavailable = "unavailable"
+	// StatusSkipped 依赖未启用，不参与判定
+	StatusSkipped = "skipped"
+)
+
+// ErrSkipped 检查项返回此错误时视为未启用，不影响就绪状态
+var ErrSkipped = errors.New("未启用")
+
+// ErrMaintenance 服务处于维护模式
+var ErrMaintenance = errors.New("服务维护中")
+
+// Check 就绪检查项，返回nil表示通过
+type Check func(ctx context.Context) error
+
+// CheckResult 单个检查项的结果
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Report 探针响应
+type Report struct {
+	Service   string        `json:"service"`
+	Status    string        `json:"status"`
+	Uptime    int64         `json:"uptime_seconds"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker 服务的存活与就绪探针。存活只表示进程能处理请求；
+// 就绪要求数据库、Redis 可达，未处于维护模式，且通过服务自定义的检查（如容量）
+type Checker struct {
+	service   string
+	startedAt time.Time
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker 创建探针，默认包含数据库、Redis 和维护模式检查
+func NewChecker(service string) *Checker {
+	c := &Checker{service: service, startedAt: time.Now()}
+	c.Add("database", Database)
+	c.Add("redis", Redis)
+	c.Add("maintenance", Maintenance)
+	return c
+}
+
+// Add 添加就绪检查项
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// RegisterHandlers 注册探针端点: /health/live 和 /health/ready
+func (c *Checker) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/health/live", c.handleLive)
+	mux.HandleFunc("/health/ready", c.handleReady)
+}
+
+// Ready 并发执行所有就绪检查
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	report := c.report(StatusOK)
+	report.Checks = make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, nc)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusUnavailable {
+			report.Status = StatusUnavailable
+			break
+		}
+	}
+	return report
+}
+
+// run 在超时内执行单个检查项
+func run(ctx context.Context, nc namedCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := nc.check(ctx)
+	result := CheckResult{Name: nc.name, Status: StatusOK, Duration: time.Since(start).Milliseconds()}
+	switch {
+	case errors.Is(err, ErrSkipped):
+		result.Status = StatusSkipped
+	case err != nil:
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// report 创建不含检查项的响应
+func (c *Checker) report(status string) Report {
+	return Report{
+		Service:   c.service,
+		Status:    status,
+		Uptime:    int64(time.Since(c.startedAt).Seconds()),
+		CheckedAt: time.Now(),
+	}
+}
+
+// handleLive 存活探针，进程能处理请求即返回200
+func (c *Checker) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, c.report(StatusOK))
+}
+
+// handleReady 就绪探针，全部检查通过返回200，否则返回503
+func (c *Checker) handleReady(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+// writeReport 写出探针响应
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("编码探针响应失败: %v", err)
+	}
+}
+
+// Database 检查数据库主库是否可达
+func Database(ctx context.Context) error {
+	if db.DB == nil {
+		return errors.New("数据库未初始化")
+	}
+	return db.DB.PingContext(ctx)
+}
+
+// Redis 检查 Redis 是否可达，未配置 Redis 时跳过
+func Redis(ctx context.Context) error {
+	if db.RedisClient == nil {
+		return ErrSkipped
+	}
+	return db.RedisClient.Ping(ctx).Err()
+}
+
+// Maintenance 维护中（计划维护已开始或手动开启维护模式）时不再接收新流量
+func Maintenance(ctx context.Context) error {
+	if maintenance.Active() {
+		return ErrMaintenance
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// cosmetics.go
+
+package inventory
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrItemNotOwned 玩家未拥有该道具
+	ErrItemNotOwned = errcode.New(errcode.NotOwned, "未拥有该道具")
+	// ErrCharacterNotOwned 玩家未拥有该角色
+	ErrCharacterNotOwned = errcode.New(errcode.CharacterNotOwned, "未拥有该角色")
+	// ErrNotApplicable 道具不能装备到该角色
+	ErrNotApplicable = errcode.New(errcode.NotAllowed, "该外观不适用于此角色")
+	// ErrCharacterLevelTooLow 角色等级不足
+	ErrCharacterLevelTooLow = errcode.New(errcode.CharacterLevelTooLow, "角色等级不足")
+)
+
+// CosmeticItem 玩家拥有的外观
+type CosmeticItem struct {
+	models.Item
+	EquippedOn []int `json:"equipped_on"` // 已装备到的角色ID
+}
+
+// EquipSkin 为玩家的角色装备皮肤，itemID为0时卸下
+func EquipSkin(playerID int64, characterID, itemID int) error {
+	var characterLevel int
+	err := db.DB.QueryRow(`
+		SELECT COALESCE(level, 1) FROM player_characters WHERE player_id = $1 AND character_id = $2
+	`, playerID, characterID).Scan(&characterLevel)
+	if err == sql.ErrNoRows {
+		return ErrCharacterNotOwned
+	}
+	if err != nil {
+		return fmt.Errorf("检查角色归属失败: %w", err)
+	}
+
+	if itemID == 0 {
+		_, err := db.DB.Exec(`DELETE FROM player_character_skins WHERE player_id = $1 AND character_id = $2`,
+			playerID, characterID)
+		return err
+	}
+
+	// 检查道具归属和适用角色
+	var itemType models.ItemType
+	var refID, requiredLevel int
+	err = db.DB.QueryRow(`
+		SELECT i.item_type, COALESCE(i.ref_id, 0), COALESCE(i.required_character_level, 0)
+		FROM player_inventory pi
+		INNER JOIN items i ON i.id = pi.item_id
+		WHERE pi.player_id = $1 AND pi.item_id = $2 AND pi.quantity > 0
+	`, playerID, itemID).Scan(&itemType, &refID, &requiredLevel)
+	if err == sql.ErrNoRows {
+		return ErrItemNotOwned
+	}
+	if err != nil {
+		return fmt.Errorf("检查道具归属失败: %w", err)
+	}
+	if itemType != models.ItemCosmetic || (refID != 0 && refID != characterID) {
+		return ErrNotApplicable
+	}
+	if characterLevel < requiredLevel {
+		return fmt.Errorf("%w: 需要角色达到 %d 级", ErrCharacterLevelTooLow, requiredLevel)
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO player_character_skins (player_id, character_id, item_id, equipped_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (player_id, character_id) DO UPDATE SET
+			item_id = EXCLUDED.item_id, equipped_at = EXCLUDED.equipped_at
+	`, playerID, characterID, itemID)
+	if err != nil {
+		return fmt.Errorf("装备皮肤失败: %w", err)
+	}
+	return nil
+}
+
+// EquippedSkinID 获取玩家角色当前装备的皮肤道具ID，未装备时返回0
+func EquippedSkinID(playerID int64, characterID int) (int, error) {
+	if db.DB == nil {
+		return 0, nil
+	}
+
+	var itemID int
+	err := db.DB.QueryRow(`
+		SELECT item_id FROM player_character_skins WHERE player_id = $1 AND character_id = $2
+	`, playerID, characterID).Scan(&itemID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return itemID, err
+}
+
+// EquippedSkins 获取玩家所有角色的装备皮肤
+func EquippedSkins(playerID int64) ([]models.EquippedSkin, error) {
+	rows, err := db.DB.Query(`
+		SELECT s.character_id, s.item_id, s.equipped_at,
+		       i.id, i.name, COALESCE(i.description, ''), i.item_type, COALESCE(i.ref_id, 0), i.stackable, i.created_at
+		FROM player_character_skins s
+		INNER JOIN items i ON i.id = s.item_id
+		WHERE s.player_id = $1
+		ORDER BY s.character_id
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询装备皮肤失败: %w", err)
+	}
+	defer rows.Close()
+
+	skins := make([]models.EquippedSkin, 0)
+	for rows.Next() {
+		var skin models.EquippedSkin
+		var item models.Item
+		if err := rows.Scan(&skin.CharacterID, &skin.ItemID, &skin.EquippedAt,
+			&item.ID, &item.Name, &item.Description, &item.Type, &item.RefID, &item.Stackable, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描装备皮肤失败: %w", err)
+		}
+		skin.Item = &item
+		skins = append(skins, skin)
+	}
+	return skins, rows.Err()
+}
+
+// OwnedCosmetics 获取玩家拥有的全部外观及其装备情况
+func OwnedCosmetics(playerID int64) ([]CosmeticItem, error) {
+	rows, err := db.DB.Query(`
+		SELECT i.id, i.name, COALESCE(i.description, ''), i.item_type, COALESCE(i.ref_id, 0), i.stackable, i.created_at,
+		       COALESCE(array_to_string(ARRAY(
+		           SELECT s.character_id FROM player_character_skins s
+		           WHERE s.player_id = pi.player_id AND s.item_id = i.id
+		           ORDER BY s.character_id
+		       ), ','), '')
+		FROM player_inventory pi
+		INNER JOIN items i ON i.id = pi.item_id
+		WHERE pi.player_id = $1 AND pi.quantity > 0 AND i.item_type = $2
+		ORDER BY i.id
+	`, playerID, string(models.ItemCosmetic))
+	if err != nil {
+		return nil, fmt.Errorf("查询外观失败: %w", err)
+	}
+	defer rows.Close()
+
+	cosmetics := make([]CosmeticItem, 0)
+	for rows.Next() {
+		var c CosmeticItem
+		var equipped string
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.Type, &c.RefID, &c.Stackable, &c.CreatedAt,
+			&equipped); err != nil {
+			return nil, fmt.Errorf("扫描外观失败: %w", err)
+		}
+		c.EquippedOn = parseIDList(equipped)
+		cosmetics = append(cosmetics, c)
+	}
+	return cosmetics, rows.Err()
+}
+
+// parseIDList 解析逗号分隔的ID列表
+func parseIDList(s string) []int {
+	ids := make([]int, 0)
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
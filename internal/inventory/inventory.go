@@ -0,0 +1,135 @@
+// inventory.go
+
+package inventory
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrAlreadyOwned 不可叠加的道具已拥有
+	ErrAlreadyOwned = errcode.New(errcode.AlreadyOwned, "已拥有该道具")
+	// ErrInvalidQuantity 发放数量无效
+	ErrInvalidQuantity = errcode.New(errcode.BadRequest, "无效的道具数量")
+	// ErrItemNotFound 道具不存在
+	ErrItemNotFound = errcode.New(errcode.NotFound, "道具不存在")
+)
+
+// ConsumableItem 玩家持有的消耗品
+type ConsumableItem struct {
+	models.Item
+	Quantity int `json:"quantity"`
+}
+
+// GrantTx 在调用方事务中向玩家发放道具。
+// 角色道具解锁对应角色，其他道具写入背包；不可叠加的道具重复发放时返回 ErrAlreadyOwned。
+func GrantTx(tx *sql.Tx, playerID int64, item *models.Item, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	if item.Type == models.ItemCharacter {
+		return grantCharacterTx(tx, playerID, item.RefID)
+	}
+
+	if !item.Stackable {
+		result, err := tx.Exec(`
+			INSERT INTO player_inventory (player_id, item_id, quantity)
+			VALUES ($1, $2, 1)
+			ON CONFLICT (player_id, item_id) DO NOTHING
+		`, playerID, item.ID)
+		if err != nil {
+			return fmt.Errorf("发放道具 %d 失败: %w", item.ID, err)
+		}
+		return requireInserted(result)
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO player_inventory (player_id, item_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (player_id, item_id) DO UPDATE SET
+			quantity = player_inventory.quantity + EXCLUDED.quantity,
+			updated_at = NOW()
+	`, playerID, item.ID, quantity)
+	if err != nil {
+		return fmt.Errorf("发放道具 %d 失败: %w", item.ID, err)
+	}
+	return nil
+}
+
+// GrantItemTx 按道具ID在调用方事务中发放道具
+func GrantItemTx(tx *sql.Tx, playerID int64, itemID int, quantity int) (*models.Item, error) {
+	var item models.Item
+	err := tx.QueryRow(`
+		SELECT id, name, COALESCE(description, ''), item_type, COALESCE(ref_id, 0), stackable, created_at
+		FROM items WHERE id = $1
+	`, itemID).Scan(&item.ID, &item.Name, &item.Description, &item.Type, &item.RefID, &item.Stackable, &item.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询道具 %d 失败: %w", itemID, err)
+	}
+
+	if err := GrantTx(tx, playerID, &item, quantity); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// OwnedConsumables 获取玩家持有的全部消耗品及数量
+func OwnedConsumables(playerID int64) ([]ConsumableItem, error) {
+	rows, err := db.DB.Query(`
+		SELECT i.id, i.name, COALESCE(i.description, ''), i.item_type, COALESCE(i.ref_id, 0), i.stackable, i.created_at,
+		       pi.quantity
+		FROM player_inventory pi
+		INNER JOIN items i ON i.id = pi.item_id
+		WHERE pi.player_id = $1 AND pi.quantity > 0 AND i.item_type = $2
+		ORDER BY i.id
+	`, playerID, string(models.ItemConsumable))
+	if err != nil {
+		return nil, fmt.Errorf("查询消耗品失败: %w", err)
+	}
+	defer rows.Close()
+
+	consumables := make([]ConsumableItem, 0)
+	for rows.Next() {
+		var c ConsumableItem
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.Type, &c.RefID, &c.Stackable, &c.CreatedAt,
+			&c.Quantity); err != nil {
+			return nil, fmt.Errorf("扫描消耗品失败: %w", err)
+		}
+		consumables = append(consumables, c)
+	}
+	return consumables, rows.Err()
+}
+
+// grantCharacterTx 解锁角色
+func grantCharacterTx(tx *sql.Tx, playerID int64, characterID int) error {
+	result, err := tx.Exec(`
+		INSERT INTO player_characters (player_id, character_id, unlocked, unlocked_at)
+		VALUES ($1, $2, true, NOW())
+		ON CONFLICT (player_id, character_id) DO NOTHING
+	`, playerID, characterID)
+	if err != nil {
+		return fmt.Errorf("解锁角色 %d 失败: %w", characterID, err)
+	}
+	return requireInserted(result)
+}
+
+// requireInserted 检查是否实际插入了新记录
+func requireInserted(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAlreadyOwned
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// latency.go
+
+package latency
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+const (
+	// MaxRegions 一次最多上报的区域数
+	MaxRegions = 32
+	// MaxRTTMillis 往返延迟上限(毫秒)，超过时视为测量失败
+	MaxRTTMillis = 10000
+	// maxAge 超过该时间的测量结果不再用于选择区域
+	maxAge = 7 * 24 * time.Hour
+)
+
+// ErrInvalidLatency 上报的延迟无效
+var ErrInvalidLatency = errcode.New(errcode.BadRequest, "无效的延迟数据")
+
+// regionName 区域名：小写字母、数字和连字符
+var regionName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// Save 保存玩家测得的各区域延迟，覆盖这些区域之前的测量结果，未上报的区域保持不变
+func Save(playerID int64, latencies map[string]int) error {
+	if len(latencies) == 0 || len(latencies) > MaxRegions {
+		return fmt.Errorf("%w: 需上报1到%d个区域", ErrInvalidLatency, MaxRegions)
+	}
+	for region, rtt := range latencies {
+		if !regionName.MatchString(region) {
+			return fmt.Errorf("%w: 无效的区域名 %q", ErrInvalidLatency, region)
+		}
+		if rtt < 0 || rtt > MaxRTTMillis {
+			return fmt.Errorf("%w: 区域 %s 的延迟应在0到%d毫秒之间", ErrInvalidLatency, region, MaxRTTMillis)
+		}
+	}
+
+	return db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		for region, rtt := range latencies {
+			_, err := tx.Exec(`
+				INSERT INTO player_region_latencies (player_id, region, rtt_ms, measured_at)
+				VALUES ($1, $2, $3, NOW())
+				ON CONFLICT (player_id, region) DO UPDATE SET rtt_ms = EXCLUDED.rtt_ms, measured_at = EXCLUDED.measured_at
+			`, playerID, region, rtt)
+			if err != nil {
+				return fmt.Errorf("保存区域 %s 的延迟失败: %w", region, err)
+			}
+		}
+		return nil
+	})
+}
+
+// List 查询玩家各区域最近一次测得的延迟，按延迟从低到高排列
+func List(playerID int64) ([]models.RegionLatency, error) {
+	return query(`
+		SELECT region, rtt_ms, measured_at FROM player_region_latencies
+		WHERE player_id = $1 ORDER BY rtt_ms, region
+	`, playerID)
+}
+
+// Ranked 玩家最近测量过的区域，按延迟从低到高排列，用于选择匹配服务所在的区域
+func Ranked(playerID int64) ([]string, error) {
+	latencies, err := query(`
+		SELECT region, rtt_ms, measured_at FROM player_region_latencies
+		WHERE player_id = $1 AND measured_at > $2 ORDER BY rtt_ms, region
+	`, playerID, time.Now().Add(-maxAge))
+	if err != nil {
+		return nil, err
+	}
+	regions := make([]string, len(latencies))
+	for i, l := range latencies {
+		regions[i] = l.Region
+	}
+	return regions, nil
+}
+
+// query 查询延迟记录
+func query(q string, args ...interface{}) ([]models.RegionLatency, error) {
+	rows, err := db.DB.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询区域延迟失败: %w", err)
+	}
+	defer rows.Close()
+
+	latencies := []models.RegionLatency{}
+	for rows.Next() {
+		var l models.RegionLatency
+		if err := rows.Scan(&l.Region, &l.RTTMillis, &l.MeasuredAt); err != nil {
+			return nil, fmt.Errorf("扫描区域延迟失败: %w", err)
+		}
+		latencies = append(latencies, l)
+	}
+	return latencies, rows.Err()
+}
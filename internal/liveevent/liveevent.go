@@ -0,0 +1,360 @@
+// liveevent.go
+
+package liveevent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// InvalidateChannel 限时活动变更通知的 Redis 频道
+const InvalidateChannel = "liveevent:invalidate"
+
+const (
+	// refreshInterval 定期重新加载活动的间隔，未连接Redis或错过通知时以此兜底。
+	// 快照包含尚未开始的活动，活动按时间开始和结束不依赖刷新
+	refreshInterval = 30 * time.Second
+
+	// maxNameLength 活动名称最大字符数
+	maxNameLength = 50
+	// maxDescriptionLength 活动说明最大字符数
+	maxDescriptionLength = 500
+	// maxBonus 经验和金币加成百分比上限，即最多5倍
+	maxBonus = 400
+	// maxShopDiscount 商店折扣百分比上限
+	maxShopDiscount = 90
+)
+
+var (
+	// ErrInvalidEvent 活动参数无效
+	ErrInvalidEvent = errcode.New(errcode.BadRequest, "无效的活动")
+	// ErrEventNotFound 活动不存在
+	ErrEventNotFound = errcode.New(errcode.NotFound, "活动不存在")
+)
+
+// upcoming 最近加载的已启用且未结束的活动，按开始时间排列
+var upcoming atomic.Pointer[[]models.LiveEvent]
+
+// instanceID 本进程标识，收到自己发出的通知时不重复加载
+var instanceID = uuid.New().String()
+
+// eventColumns 活动查询字段，与 scanEvent 顺序一致
+const eventColumns = `id, name, description, starts_at, ends_at, exp_bonus, coin_bonus, shop_discount,
+	game_modes, enabled, created_at, updated_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEvent 扫描活动
+func scanEvent(row rowScanner) (*models.LiveEvent, error) {
+	var e models.LiveEvent
+	var modes []byte
+	err := row.Scan(&e.ID, &e.Name, &e.Description, &e.StartsAt, &e.EndsAt, &e.ExpBonus, &e.CoinBonus,
+		&e.ShopDiscount, &modes, &e.Enabled, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(modes, &e.GameModes); err != nil {
+		return nil, fmt.Errorf("解析活动 %d 的模式失败: %w", e.ID, err)
+	}
+	return &e, nil
+}
+
+// Init 加载活动并启动定期刷新，Redis 可用时订阅其他实例的变更通知
+func Init() {
+	if db.DB == nil {
+		return
+	}
+	if err := Load(); err != nil {
+		log.Printf("加载限时活动失败: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := Load(); err != nil {
+				log.Printf("刷新限时活动失败: %v", err)
+			}
+		}
+	}()
+
+	if db.RedisClient != nil {
+		startInvalidationListener()
+	}
+}
+
+// Load 从数据库加载已启用且未结束的活动，成功后替换当前快照
+func Load() error {
+	events, err := query(`SELECT ` + eventColumns + ` FROM live_events
+		WHERE enabled = true AND ends_at > NOW()
+		ORDER BY starts_at, id`)
+	if err != nil {
+		return err
+	}
+	upcoming.Store(&events)
+	return nil
+}
+
+// ActiveAt 指定时间进行中的活动
+func ActiveAt(now time.Time) []models.LiveEvent {
+	snapshot := upcoming.Load()
+	if snapshot == nil {
+		return nil
+	}
+	var active []models.LiveEvent
+	for _, e := range *snapshot {
+		if e.IsActive(now) {
+			active = append(active, e)
+		}
+	}
+	return active
+}
+
+// Current 当前进行中的活动、因活动开放的模式和生效的商店折扣
+func Current() models.ActiveEvents {
+	now := time.Now()
+	current := models.ActiveEvents{
+		Events:     ActiveAt(now),
+		GameModes:  []models.GameMode{},
+		ServerTime: now,
+	}
+	if current.Events == nil {
+		current.Events = []models.LiveEvent{}
+	}
+
+	seen := make(map[models.GameMode]bool)
+	for _, e := range current.Events {
+		current.ShopDiscount = max(current.ShopDiscount, e.ShopDiscount)
+		for _, mode := range e.GameModes {
+			if !seen[mode] {
+				seen[mode] = true
+				current.GameModes = append(current.GameModes, mode)
+			}
+		}
+	}
+	sort.Slice(current.GameModes, func(i, j int) bool { return current.GameModes[i] < current.GameModes[j] })
+	return current
+}
+
+// BoostFor 指定时间结束的该模式对局获得的经验和金币加成
+func BoostFor(mode models.GameMode, at time.Time) models.EventBoost {
+	var boost models.EventBoost
+	for _, e := range ActiveAt(at) {
+		if (e.ExpBonus == 0 && e.CoinBonus == 0) || !e.AppliesTo(mode) {
+			continue
+		}
+		boost.ExpBonus = max(boost.ExpBonus, e.ExpBonus)
+		boost.CoinBonus = max(boost.CoinBonus, e.CoinBonus)
+		boost.EventIDs = append(boost.EventIDs, e.ID)
+	}
+	return boost
+}
+
+// ApplyBonus 按加成百分比计算奖励
+func ApplyBonus(amount, bonus int) int {
+	if bonus <= 0 {
+		return amount
+	}
+	return amount * (100 + bonus) / 100
+}
+
+// ModeFeatured 该模式是否为进行中活动的活动模式，活动模式在活动期间开放匹配
+func ModeFeatured(mode models.GameMode, now time.Time) bool {
+	for _, e := range ActiveAt(now) {
+		for _, m := range e.GameModes {
+			if m == mode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ShopDiscount 指定时间生效的商店折扣百分比
+func ShopDiscount(now time.Time) int {
+	discount := 0
+	for _, e := range ActiveAt(now) {
+		discount = max(discount, e.ShopDiscount)
+	}
+	return discount
+}
+
+// List 查询全部活动，最近开始的在前
+func List() ([]models.LiveEvent, error) {
+	return query(`SELECT ` + eventColumns + ` FROM live_events ORDER BY starts_at DESC, id DESC`)
+}
+
+// Get 查询单个活动
+func Get(id int64) (*models.LiveEvent, error) {
+	e, err := scanEvent(db.DB.QueryRow(`SELECT `+eventColumns+` FROM live_events WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询活动失败: %w", err)
+	}
+	return e, nil
+}
+
+// Create 创建活动，本实例立即生效并通知其他实例重新加载
+func Create(e *models.LiveEvent) error {
+	modes, err := validate(e)
+	if err != nil {
+		return err
+	}
+
+	err = db.DB.QueryRow(`
+		INSERT INTO live_events (name, description, starts_at, ends_at, exp_bonus, coin_bonus,
+		                         shop_discount, game_modes, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`, e.Name, e.Description, e.StartsAt, e.EndsAt, e.ExpBonus, e.CoinBonus, e.ShopDiscount, modes, e.Enabled,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("保存活动失败: %w", err)
+	}
+	return reloadAndNotify()
+}
+
+// Update 更新活动，本实例立即生效并通知其他实例重新加载
+func Update(e *models.LiveEvent) error {
+	modes, err := validate(e)
+	if err != nil {
+		return err
+	}
+
+	err = db.DB.QueryRow(`
+		UPDATE live_events SET
+			name = $2, description = $3, starts_at = $4, ends_at = $5, exp_bonus = $6,
+			coin_bonus = $7, shop_discount = $8, game_modes = $9, enabled = $10, updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, e.ID, e.Name, e.Description, e.StartsAt, e.EndsAt, e.ExpBonus, e.CoinBonus, e.ShopDiscount, modes, e.Enabled,
+	).Scan(&e.CreatedAt, &e.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("更新活动失败: %w", err)
+	}
+	return reloadAndNotify()
+}
+
+// Disable 停用活动（保留记录以便追溯活动期间的奖励），进行中的活动立即结束
+func Disable(id int64) error {
+	result, err := db.DB.Exec(`UPDATE live_events SET enabled = false, updated_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("停用活动失败: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrEventNotFound
+	}
+	return reloadAndNotify()
+}
+
+// validate 校验活动参数，返回序列化后的活动模式
+func validate(e *models.LiveEvent) ([]byte, error) {
+	e.Name = strings.TrimSpace(e.Name)
+	e.Description = strings.TrimSpace(e.Description)
+	switch {
+	case e.Name == "" || utf8.RuneCountInString(e.Name) > maxNameLength:
+		return nil, fmt.Errorf("%w: 名称不能为空且不能超过%d个字符", ErrInvalidEvent, maxNameLength)
+	case utf8.RuneCountInString(e.Description) > maxDescriptionLength:
+		return nil, fmt.Errorf("%w: 说明不能超过%d个字符", ErrInvalidEvent, maxDescriptionLength)
+	case e.StartsAt.IsZero() || !e.StartsAt.Before(e.EndsAt):
+		return nil, fmt.Errorf("%w: 开始时间必须早于结束时间", ErrInvalidEvent)
+	case e.ExpBonus < 0 || e.ExpBonus > maxBonus || e.CoinBonus < 0 || e.CoinBonus > maxBonus:
+		return nil, fmt.Errorf("%w: 经验和金币加成应在 0-%d 之间", ErrInvalidEvent, maxBonus)
+	case e.ShopDiscount < 0 || e.ShopDiscount > maxShopDiscount:
+		return nil, fmt.Errorf("%w: 商店折扣应在 0-%d 之间", ErrInvalidEvent, maxShopDiscount)
+	}
+
+	seen := make(map[models.GameMode]bool)
+	modes := make([]models.GameMode, 0, len(e.GameModes))
+	for _, mode := range e.GameModes {
+		if !mode.IsValid() {
+			return nil, fmt.Errorf("%w: 未知的游戏模式 %q", ErrInvalidEvent, mode)
+		}
+		if !seen[mode] {
+			seen[mode] = true
+			modes = append(modes, mode)
+		}
+	}
+	e.GameModes = modes
+	if e.ExpBonus == 0 && e.CoinBonus == 0 && e.ShopDiscount == 0 && len(modes) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一项加成、折扣或活动模式", ErrInvalidEvent)
+	}
+
+	data, err := json.Marshal(modes)
+	if err != nil {
+		return nil, fmt.Errorf("序列化活动模式失败: %w", err)
+	}
+	return data, nil
+}
+
+// query 查询活动列表
+func query(q string, args ...interface{}) ([]models.LiveEvent, error) {
+	rows, err := db.DB.Query(q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询活动失败: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]models.LiveEvent, 0)
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描活动失败: %w", err)
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// reloadAndNotify 重新加载本实例的活动并通知其他实例
+func reloadAndNotify() error {
+	if err := Load(); err != nil {
+		return err
+	}
+	if db.RedisClient == nil {
+		return nil
+	}
+	if err := db.RedisClient.Publish(context.Background(), InvalidateChannel, instanceID).Err(); err != nil {
+		log.Printf("发布限时活动变更通知失败: %v", err)
+	}
+	return nil
+}
+
+// startInvalidationListener 订阅变更通知，收到其他实例的通知后重新加载
+func startInvalidationListener() {
+	pubsub := db.RedisClient.Subscribe(context.Background(), InvalidateChannel)
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			if msg.Payload == instanceID {
+				continue
+			}
+			if err := Load(); err != nil {
+				log.Printf("收到变更通知后重新加载限时活动失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,437 @@
+// mail.go
+
+package mail
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrMailNotFound 邮件不存在或已过期
+	ErrMailNotFound = errcode.New(errcode.NotFound, "邮件不存在或已过期")
+	// ErrNoAttachments 邮件没有附件
+	ErrNoAttachments = errcode.New(errcode.NotAllowed, "邮件没有附件")
+	// ErrAlreadyClaimed 附件已领取
+	ErrAlreadyClaimed = errcode.New(errcode.AlreadyClaimed, "附件已领取")
+	// ErrUnclaimedAttachments 附件未领取，不能删除
+	ErrUnclaimedAttachments = errcode.New(errcode.AttachmentsUnclaimed, "请先领取附件再删除邮件")
+	// ErrInvalidMail 邮件参数无效
+	ErrInvalidMail = errcode.New(errcode.BadRequest, "无效的邮件")
+)
+
+// Notifier 新邮件通知（如移动推送）
+type Notifier interface {
+	NotifyMail(playerID int64, title string)
+}
+
+// Service 邮件服务
+type Service struct {
+	wallet   *wallet.Service
+	notifier Notifier
+}
+
+// NewService 创建邮件服务，notifier 为nil时不发送新邮件通知
+func NewService(w *wallet.Service, notifier Notifier) *Service {
+	return &Service{wallet: w, notifier: notifier}
+}
+
+// SendResult 发送结果
+type SendResult struct {
+	BatchID    string `json:"batch_id"`
+	Recipients int    `json:"recipients"`
+}
+
+// validateAttachments 校验附件
+func validateAttachments(attachments []models.MailAttachment) error {
+	for _, a := range attachments {
+		if a.Amount <= 0 {
+			return fmt.Errorf("%w: 附件数量必须大于0", ErrInvalidMail)
+		}
+		switch a.Type {
+		case models.AttachmentCurrency:
+			if !a.Currency.IsValid() {
+				return fmt.Errorf("%w: 无效的货币类型 %q", ErrInvalidMail, a.Currency)
+			}
+		case models.AttachmentItem:
+			if a.ItemID <= 0 {
+				return fmt.Errorf("%w: 道具附件必须指定 item_id", ErrInvalidMail)
+			}
+		default:
+			return fmt.Errorf("%w: 附件类型必须为 currency 或 item", ErrInvalidMail)
+		}
+	}
+	return nil
+}
+
+// Send 发送邮件，可指定收件人或发送给全部玩家
+func (s *Service) Send(req *models.SendMailRequest) (*SendResult, error) {
+	if req.Category == "" {
+		req.Category = models.MailSystem
+	}
+	if !req.Category.IsValid() || req.Title == "" {
+		return nil, fmt.Errorf("%w: 类别和标题必须有效", ErrInvalidMail)
+	}
+	if !req.AllPlayers && len(req.PlayerIDs) == 0 {
+		return nil, fmt.Errorf("%w: 必须指定收件人", ErrInvalidMail)
+	}
+	if err := validateAttachments(req.Attachments); err != nil {
+		return nil, err
+	}
+
+	var expiresAt interface{}
+	if req.ExpiresInDays > 0 {
+		expiresAt = time.Now().AddDate(0, 0, req.ExpiresInDays)
+	}
+
+	result := &SendResult{BatchID: uuid.New().String()}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if req.AllPlayers {
+		res, err := tx.Exec(`
+			INSERT INTO mails (player_id, batch_id, category, title, body, expires_at)
+			SELECT id, $1, $2, $3, $4, $5 FROM players
+		`, result.BatchID, string(req.Category), req.Title, req.Body, expiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("写入邮件失败: %w", err)
+		}
+		affected, _ := res.RowsAffected()
+		result.Recipients = int(affected)
+	} else {
+		stmt, err := tx.Prepare(`
+			INSERT INTO mails (player_id, batch_id, category, title, body, expires_at)
+			SELECT id, $2, $3, $4, $5, $6 FROM players WHERE id = $1
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("准备邮件写入失败: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, playerID := range req.PlayerIDs {
+			res, err := stmt.Exec(playerID, result.BatchID, string(req.Category), req.Title, req.Body, expiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("写入玩家 %d 邮件失败: %w", playerID, err)
+			}
+			affected, _ := res.RowsAffected()
+			result.Recipients += int(affected)
+		}
+	}
+
+	// 附件按批次写入每封邮件
+	for _, a := range req.Attachments {
+		var currency, itemID interface{}
+		if a.Type == models.AttachmentCurrency {
+			currency = string(a.Currency)
+		} else {
+			itemID = a.ItemID
+		}
+		_, err := tx.Exec(`
+			INSERT INTO mail_attachments (mail_id, attachment_type, currency, item_id, amount)
+			SELECT id, $2, $3, $4, $5 FROM mails WHERE batch_id = $1
+		`, result.BatchID, string(a.Type), currency, itemID, a.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("写入邮件附件失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	// 全服邮件不逐个推送，避免瞬间大量推送
+	if s.notifier != nil && !req.AllPlayers {
+		for _, playerID := range req.PlayerIDs {
+			s.notifier.NotifyMail(playerID, req.Title)
+		}
+	}
+	return result, nil
+}
+
+// mailColumns 邮件查询字段
+const mailColumns = `id, player_id, category, title, COALESCE(body, ''), created_at, expires_at, read_at, claimed_at`
+
+// visibleCondition 未删除且未过期的邮件
+const visibleCondition = `deleted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+// scanMail 扫描邮件
+func scanMail(row interface{ Scan(...interface{}) error }) (*models.Mail, error) {
+	var m models.Mail
+	err := row.Scan(&m.ID, &m.PlayerID, &m.Category, &m.Title, &m.Body, &m.CreatedAt,
+		&m.ExpiresAt, &m.ReadAt, &m.ClaimedAt)
+	if err != nil {
+		return nil, err
+	}
+	m.Attachments = make([]models.MailAttachment, 0)
+	return &m, nil
+}
+
+// queryer 事务与数据库连接的公共查询接口
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadAttachments 加载同一玩家邮件的附件
+func loadAttachments(q queryer, playerID int64, mails ...*models.Mail) error {
+	if len(mails) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Mail, len(mails))
+	minID, maxID := mails[0].ID, mails[0].ID
+	for _, m := range mails {
+		byID[m.ID] = m
+		if m.ID < minID {
+			minID = m.ID
+		}
+		if m.ID > maxID {
+			maxID = m.ID
+		}
+	}
+
+	rows, err := q.Query(`
+		SELECT a.id, a.mail_id, a.attachment_type, COALESCE(a.currency, ''), COALESCE(a.item_id, 0), a.amount
+		FROM mail_attachments a
+		JOIN mails m ON m.id = a.mail_id
+		WHERE m.player_id = $1 AND a.mail_id BETWEEN $2 AND $3
+		ORDER BY a.id
+	`, playerID, minID, maxID)
+	if err != nil {
+		return fmt.Errorf("查询邮件附件失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.MailAttachment
+		var mailID int64
+		if err := rows.Scan(&a.ID, &mailID, &a.Type, &a.Currency, &a.ItemID, &a.Amount); err != nil {
+			return fmt.Errorf("扫描邮件附件失败: %w", err)
+		}
+		if m, ok := byID[mailID]; ok {
+			m.Attachments = append(m.Attachments, a)
+		}
+	}
+	return rows.Err()
+}
+
+// Inbox 分页查询玩家收件箱（按时间倒序），同时返回总数与未读数
+func (s *Service) Inbox(playerID int64, limit, offset int) ([]models.Mail, int, int, error) {
+	var total, unread int
+	err := db.DB.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE read_at IS NULL)
+		FROM mails WHERE player_id = $1 AND `+visibleCondition, playerID).Scan(&total, &unread)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("查询邮件数量失败: %w", err)
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+mailColumns+` FROM mails
+		WHERE player_id = $1 AND `+visibleCondition+`
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`, playerID, limit, offset)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("查询邮件失败: %w", err)
+	}
+
+	ptrs := make([]*models.Mail, 0)
+	for rows.Next() {
+		m, err := scanMail(rows)
+		if err != nil {
+			rows.Close()
+			return nil, 0, 0, fmt.Errorf("扫描邮件失败: %w", err)
+		}
+		ptrs = append(ptrs, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, 0, err
+	}
+
+	if err := loadAttachments(db.DB, playerID, ptrs...); err != nil {
+		return nil, 0, 0, err
+	}
+
+	mails := make([]models.Mail, 0, len(ptrs))
+	for _, m := range ptrs {
+		mails = append(mails, *m)
+	}
+	return mails, total, unread, nil
+}
+
+// Read 查看邮件并标记为已读
+func (s *Service) Read(playerID, mailID int64) (*models.Mail, error) {
+	m, err := scanMail(db.DB.QueryRow(`
+		UPDATE mails SET read_at = COALESCE(read_at, NOW())
+		WHERE id = $1 AND player_id = $2 AND `+visibleCondition+`
+		RETURNING `+mailColumns, mailID, playerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询邮件失败: %w", err)
+	}
+
+	if err := loadAttachments(db.DB, playerID, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Claim 领取邮件附件
+func (s *Service) Claim(playerID, mailID int64) (*models.Mail, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	m, err := s.claimTx(tx, playerID, mailID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return m, nil
+}
+
+// ClaimAll 领取收件箱中所有未领取的附件，返回成功领取的邮件
+func (s *Service) ClaimAll(playerID int64) ([]models.Mail, error) {
+	rows, err := db.DB.Query(`
+		SELECT m.id FROM mails m
+		WHERE m.player_id = $1 AND m.claimed_at IS NULL AND m.`+visibleCondition+`
+		  AND EXISTS (SELECT 1 FROM mail_attachments a WHERE a.mail_id = m.id)
+		ORDER BY m.id
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询未领取邮件失败: %w", err)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("扫描邮件失败: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 每封邮件独立事务，一封失败不影响其他邮件
+	claimed := make([]models.Mail, 0, len(ids))
+	for _, id := range ids {
+		m, err := s.Claim(playerID, id)
+		if errors.Is(err, ErrAlreadyClaimed) || errors.Is(err, ErrMailNotFound) {
+			continue
+		}
+		if err != nil {
+			return claimed, err
+		}
+		claimed = append(claimed, *m)
+	}
+	return claimed, nil
+}
+
+// claimTx 在事务中领取邮件附件
+func (s *Service) claimTx(tx *sql.Tx, playerID, mailID int64) (*models.Mail, error) {
+	m, err := scanMail(tx.QueryRow(`
+		SELECT `+mailColumns+` FROM mails
+		WHERE id = $1 AND player_id = $2 AND `+visibleCondition+`
+		FOR UPDATE
+	`, mailID, playerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrMailNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询邮件失败: %w", err)
+	}
+	if m.ClaimedAt != nil {
+		return nil, ErrAlreadyClaimed
+	}
+
+	if err := loadAttachments(tx, playerID, m); err != nil {
+		return nil, err
+	}
+	if len(m.Attachments) == 0 {
+		return nil, ErrNoAttachments
+	}
+
+	for _, a := range m.Attachments {
+		switch a.Type {
+		case models.AttachmentCurrency:
+			_, err := s.wallet.ApplyTx(tx, models.TransactionRequest{
+				PlayerID:       playerID,
+				Currency:       a.Currency,
+				Amount:         a.Amount,
+				Reason:         models.ReasonMailAttachment,
+				ReferenceID:    fmt.Sprintf("mail:%d", m.ID),
+				IdempotencyKey: fmt.Sprintf("mail:%d:%d", m.ID, a.ID),
+			})
+			if err != nil {
+				return nil, err
+			}
+		case models.AttachmentItem:
+			_, err := inventory.GrantItemTx(tx, playerID, a.ItemID, int(a.Amount))
+			if err != nil && err != inventory.ErrAlreadyOwned {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		UPDATE mails SET claimed_at = $2, read_at = COALESCE(read_at, $2) WHERE id = $1
+	`, m.ID, now); err != nil {
+		return nil, fmt.Errorf("更新邮件领取状态失败: %w", err)
+	}
+	m.ClaimedAt = &now
+	if m.ReadAt == nil {
+		m.ReadAt = &now
+	}
+	return m, nil
+}
+
+// Delete 删除邮件，有未领取且未过期的附件时拒绝删除
+func (s *Service) Delete(playerID, mailID int64) error {
+	var claimed, expired, hasAttachments bool
+	err := db.DB.QueryRow(`
+		SELECT m.claimed_at IS NOT NULL,
+		       m.expires_at IS NOT NULL AND m.expires_at <= NOW(),
+		       EXISTS (SELECT 1 FROM mail_attachments a WHERE a.mail_id = m.id)
+		FROM mails m
+		WHERE m.id = $1 AND m.player_id = $2 AND m.deleted_at IS NULL
+	`, mailID, playerID).Scan(&claimed, &expired, &hasAttachments)
+	if err == sql.ErrNoRows {
+		return ErrMailNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("查询邮件失败: %w", err)
+	}
+	if hasAttachments && !claimed && !expired {
+		return ErrUnclaimedAttachments
+	}
+
+	if _, err := db.DB.Exec(`UPDATE mails SET deleted_at = NOW() WHERE id = $1`, mailID); err != nil {
+		return fmt.Errorf("删除邮件失败: %w", err)
+	}
+	return nil
+}
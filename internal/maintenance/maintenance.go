@@ -0,0 +1,243 @@
+// maintenance.go
+
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// InvalidateChannel 计划维护变更通知的 Redis 频道
+const InvalidateChannel = "maintenance:invalidate"
+
+const (
+	// refreshInterval 定期重新加载计划的间隔，未连接Redis或错过通知时以此兜底
+	refreshInterval = 30 * time.Second
+
+	// maxDuration 单次维护的最长时间，避免误填导致服务长期不可用
+	maxDuration = 24 * time.Hour
+
+	// maxReasonLength 维护说明最大字符数
+	maxReasonLength = 200
+)
+
+var (
+	// ErrInvalidSchedule 维护计划参数无效
+	ErrInvalidSchedule = errcode.New(errcode.BadRequest, "无效的维护计划")
+	// ErrAlreadyScheduled 已有未结束的维护计划
+	ErrAlreadyScheduled = errcode.New(errcode.Conflict, "已有未结束的维护计划")
+	// ErrNotScheduled 没有未结束的维护计划
+	ErrNotScheduled = errcode.New(errcode.NotFound, "没有计划中的维护")
+	// ErrInMaintenance 服务器维护中，不接受新的对局或请求
+	ErrInMaintenance = errcode.New(errcode.Unavailable, "服务器维护中")
+)
+
+var (
+	settings config.MaintenanceConfig
+
+	// current 最近加载的未结束且未取消的维护计划，没有时为nil
+	current atomic.Pointer[models.MaintenanceWindow]
+)
+
+// instanceID 本进程标识，收到自己发出的通知时不重复加载
+var instanceID = uuid.New().String()
+
+// Init 保存维护配置，加载维护计划并启动定期刷新，Redis 可用时订阅其他实例的变更通知
+func Init(cfg config.MaintenanceConfig) {
+	settings = cfg
+	if db.DB == nil {
+		return
+	}
+	if err := Load(); err != nil {
+		log.Printf("加载维护计划失败: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := Load(); err != nil {
+				log.Printf("刷新维护计划失败: %v", err)
+			}
+		}
+	}()
+
+	if db.RedisClient != nil {
+		startInvalidationListener()
+	}
+}
+
+// Load 从数据库加载未结束且未取消的维护计划
+func Load() error {
+	var w models.MaintenanceWindow
+	err := db.DB.QueryRow(`
+		SELECT id, starts_at, ends_at, reason, created_at FROM maintenance_windows
+		WHERE cancelled_at IS NULL AND ends_at > NOW()
+		ORDER BY starts_at LIMIT 1
+	`).Scan(&w.ID, &w.StartsAt, &w.EndsAt, &w.Reason, &w.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		current.Store(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询维护计划失败: %w", err)
+	}
+	current.Store(&w)
+	return nil
+}
+
+// Current 获取未结束的维护计划，没有时返回nil
+func Current() *models.MaintenanceWindow {
+	w := current.Load()
+	if w == nil || !time.Now().Before(w.EndsAt) {
+		return nil
+	}
+	return w
+}
+
+// phaseAt 计算维护计划在指定时间所处的阶段
+func phaseAt(w *models.MaintenanceWindow, now time.Time) models.MaintenancePhase {
+	switch {
+	case w == nil || !now.Before(w.EndsAt):
+		return models.MaintenanceNone
+	case !now.Before(w.StartsAt):
+		return models.MaintenanceActive
+	case !now.Before(w.StartsAt.Add(-settings.DrainBefore)):
+		return models.MaintenanceDraining
+	default:
+		return models.MaintenanceScheduled
+	}
+}
+
+// Phase 当前的计划维护阶段
+func Phase() models.MaintenancePhase {
+	return phaseAt(current.Load(), time.Now())
+}
+
+// Active 是否处于维护中：计划维护已开始，或手动开启了维护模式功能开关
+func Active() bool {
+	return feature.Maintenance.Enabled() || Phase() == models.MaintenanceActive
+}
+
+// MatchmakingPaused 是否已停止匹配：维护中或即将开始计划维护
+func MatchmakingPaused() bool {
+	return Active() || Phase() == models.MaintenanceDraining
+}
+
+// RetryAfter 维护中时距计划维护结束的时间，手动维护或未在维护中时返回0
+func RetryAfter() time.Duration {
+	w := current.Load()
+	if phaseAt(w, time.Now()) != models.MaintenanceActive {
+		return 0
+	}
+	return time.Until(w.EndsAt)
+}
+
+// Status 当前维护状态
+func Status() models.MaintenanceStatus {
+	return models.MaintenanceStatus{
+		Phase:  Phase(),
+		Window: Current(),
+		Manual: feature.Maintenance.Enabled(),
+	}
+}
+
+// Schedule 计划维护，本实例立即生效并通知其他实例重新加载。同一时间只能有一个未结束的维护
+func Schedule(req *models.ScheduleMaintenanceRequest) (*models.MaintenanceWindow, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	duration := time.Duration(req.Duration) * time.Second
+	switch {
+	case !req.StartsAt.After(time.Now()):
+		return nil, fmt.Errorf("%w: 开始时间必须晚于当前时间", ErrInvalidSchedule)
+	case duration <= 0 || duration > maxDuration:
+		return nil, fmt.Errorf("%w: 维护时长应在 1 秒到 %v 之间", ErrInvalidSchedule, maxDuration)
+	case utf8.RuneCountInString(req.Reason) > maxReasonLength:
+		return nil, fmt.Errorf("%w: 说明不能超过%d个字符", ErrInvalidSchedule, maxReasonLength)
+	}
+
+	if err := Load(); err != nil {
+		return nil, err
+	}
+	if w := Current(); w != nil {
+		return nil, fmt.Errorf("%w: #%d 于 %s 开始", ErrAlreadyScheduled, w.ID, w.StartsAt.Format(time.RFC3339))
+	}
+
+	w := &models.MaintenanceWindow{StartsAt: req.StartsAt, EndsAt: req.StartsAt.Add(duration), Reason: req.Reason}
+	err := db.DB.QueryRow(`
+		INSERT INTO maintenance_windows (starts_at, ends_at, reason)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, w.StartsAt, w.EndsAt, w.Reason).Scan(&w.ID, &w.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("保存维护计划失败: %w", err)
+	}
+
+	if err := reloadAndNotify(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Cancel 取消未结束的维护计划，维护中时立即恢复服务
+func Cancel() (*models.MaintenanceWindow, error) {
+	if err := Load(); err != nil {
+		return nil, err
+	}
+	w := Current()
+	if w == nil {
+		return nil, ErrNotScheduled
+	}
+
+	if _, err := db.DB.Exec(`UPDATE maintenance_windows SET cancelled_at = NOW() WHERE id = $1`, w.ID); err != nil {
+		return nil, fmt.Errorf("取消维护计划失败: %w", err)
+	}
+	if err := reloadAndNotify(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// reloadAndNotify 重新加载本实例的维护计划并通知其他实例
+func reloadAndNotify() error {
+	if err := Load(); err != nil {
+		return err
+	}
+	if db.RedisClient == nil {
+		return nil
+	}
+	if err := db.RedisClient.Publish(context.Background(), InvalidateChannel, instanceID).Err(); err != nil {
+		log.Printf("发布维护计划变更通知失败: %v", err)
+	}
+	return nil
+}
+
+// startInvalidationListener 订阅变更通知，收到其他实例的通知后重新加载
+func startInvalidationListener() {
+	pubsub := db.RedisClient.Subscribe(context.Background(), InvalidateChannel)
+
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			if msg.Payload == instanceID {
+				continue
+			}
+			if err := Load(); err != nil {
+				log.Printf("收到变更通知后重新加载维护计划失败: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,118 @@
+// watch.go
+
+package maintenance
+
+import (
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// watchInterval 检查维护阶段变化的间隔
+const watchInterval = time.Second
+
+// Hooks 维护阶段变化时的回调，未设置的回调忽略。每个进程各自执行，只处理本实例的房间和连接
+type Hooks struct {
+	// Warn 到达配置的警告时间点，remaining 为距维护开始的时间
+	Warn func(w models.MaintenanceWindow, remaining time.Duration)
+	// Begin 维护开始
+	Begin func(w models.MaintenanceWindow)
+	// End 维护按计划结束，或维护中被取消
+	End func(w models.MaintenanceWindow)
+	// Cancel 维护开始前被取消
+	Cancel func(w models.MaintenanceWindow)
+}
+
+// watcher 跟踪本进程已处理的维护阶段，保证每个警告时间点和阶段变化只触发一次
+type watcher struct {
+	hooks  Hooks
+	window *models.MaintenanceWindow
+	phase  models.MaintenancePhase
+	warned map[time.Duration]bool
+}
+
+// Watch 按维护计划触发回调，直到 stop 关闭
+func Watch(stop <-chan struct{}, hooks Hooks) {
+	wt := &watcher{hooks: hooks, phase: models.MaintenanceNone}
+	go func() {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				wt.check(time.Now())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// check 比较当前维护计划与已处理的状态并触发相应回调
+func (wt *watcher) check(now time.Time) {
+	w := current.Load()
+
+	// 计划被取消或替换
+	if wt.window != nil && (w == nil || w.ID != wt.window.ID) {
+		prev := *wt.window
+		switch wt.phase {
+		case models.MaintenanceActive:
+			log.Printf("维护 #%d 已取消，恢复服务", prev.ID)
+			call(wt.hooks.End, prev)
+		case models.MaintenanceScheduled, models.MaintenanceDraining:
+			log.Printf("维护 #%d 已取消", prev.ID)
+			call(wt.hooks.Cancel, prev)
+		}
+		wt.window = nil
+		wt.phase = models.MaintenanceNone
+	}
+	if w == nil {
+		return
+	}
+	if wt.window == nil {
+		wt.window = w
+		wt.warned = make(map[time.Duration]bool)
+	}
+
+	phase := phaseAt(w, now)
+	switch phase {
+	case models.MaintenanceScheduled, models.MaintenanceDraining:
+		wt.warn(*w, w.StartsAt.Sub(now))
+		if phase == models.MaintenanceDraining && wt.phase != phase {
+			log.Printf("维护 #%d 将于 %s 开始，停止匹配", w.ID, w.StartsAt.Format(time.RFC3339))
+		}
+	case models.MaintenanceActive:
+		if wt.phase != phase {
+			log.Printf("维护 #%d 开始，预计 %s 结束", w.ID, w.EndsAt.Format(time.RFC3339))
+			call(wt.hooks.Begin, *w)
+		}
+	case models.MaintenanceNone:
+		if wt.phase == models.MaintenanceActive {
+			log.Printf("维护 #%d 结束，恢复服务", w.ID)
+			call(wt.hooks.End, *w)
+		}
+	}
+	wt.phase = phase
+}
+
+// warn 到达警告时间点时触发一次警告。计划时已越过多个时间点的只警告一次
+func (wt *watcher) warn(w models.MaintenanceWindow, remaining time.Duration) {
+	due := false
+	for _, before := range settings.Warnings {
+		if remaining <= before && !wt.warned[before] {
+			wt.warned[before] = true
+			due = true
+		}
+	}
+	if due && wt.hooks.Warn != nil {
+		wt.hooks.Warn(w, remaining)
+	}
+}
+
+// call 回调已设置时调用
+func call(fn func(models.MaintenanceWindow), w models.MaintenanceWindow) {
+	if fn != nil {
+		fn(w)
+	}
+}
@@ -4,11 +4,14 @@ package match
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
+	"github.com/jacl-coder/PixelStorm-Server/internal/health"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
 )
 
 // MatchHandler 匹配处理器
@@ -28,9 +31,18 @@ func (h *MatchHandler) RegisterHandlers(mux *http.ServeMux) {
 	// 健康检查端点
 	mux.HandleFunc("/health", h.handleHealth)
 
+	// 存活与就绪探针
+	health.NewChecker("match").RegisterHandlers(mux)
+
+	// Prometheus 指标端点
+	mux.Handle("/metrics", metrics.Handler())
+
 	// 匹配相关端点
 	mux.HandleFunc("/match/join", h.handleJoinQueue)
 	mux.HandleFunc("/match/leave", h.handleLeaveQueue)
+	// 队伍匹配端点只供网关的组队服务调用，网关不对客户端转发
+	mux.HandleFunc("/match/party/join", h.handleJoinPartyQueue)
+	mux.HandleFunc("/match/party/leave", h.handleLeavePartyQueue)
 	mux.HandleFunc("/match/status", h.handleMatchStatus)
 	mux.HandleFunc("/match/history/", h.handleMatchHistory)
 	mux.HandleFunc("/match/preferences/", h.handleMatchPreferences)
@@ -133,8 +145,11 @@ func (h *MatchHandler) handleJoinQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 添加到匹配队列
-	h.service.AddToQueue(req.PlayerID, req.CharacterID, req.GameMode, req.SessionID)
+	// 添加到匹配队列，只能为登录会话对应的玩家加入匹配
+	if err := h.service.AddToQueue(req.PlayerID, req.CharacterID, req.GameMode, req.SessionID, bearerToken(r)); err != nil {
+		sendQueueError(w, err)
+		return
+	}
 
 	// 返回成功响应
 	resp := matchResponse{
@@ -147,6 +162,28 @@ func (h *MatchHandler) handleJoinQueue(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// sendQueueError 按加入匹配队列失败的原因返回对应的状态码
+func sendQueueError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrInvalidParty), errors.Is(err, ErrPartyTooLarge):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrInvalidSession):
+		status = http.StatusUnauthorized
+	case errors.Is(err, ErrModeDisabled), errors.Is(err, ErrCharacterNotOwned):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrPartyAlreadyQueued):
+		status = http.StatusConflict
+	case errors.Is(err, ErrQueuePaused):
+		status = http.StatusServiceUnavailable
+	default:
+		log.Printf("加入匹配队列失败: %v", err)
+		http.Error(w, "加入匹配队列失败", status)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
 // handleLeaveQueue 处理离开匹配队列请求
 func (h *MatchHandler) handleLeaveQueue(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
@@ -169,6 +206,10 @@ func (h *MatchHandler) handleLeaveQueue(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "无效的玩家ID", http.StatusBadRequest)
 		return
 	}
+	if err := h.service.Authorize(bearerToken(r), playerID); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 
 	// 从队列移除
 	success := h.service.RemoveFromQueue(playerID, models.GameMode(gameModeStr))
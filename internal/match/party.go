@@ -0,0 +1,86 @@
+// party.go
+
+package match
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// handleJoinPartyQueue 处理队伍加入匹配队列请求
+func (h *MatchHandler) handleJoinPartyQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "仅支持POST方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.PartyQueueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "无效的请求格式", http.StatusBadRequest)
+		return
+	}
+	if req.PartyID == "" || req.GameMode == "" || len(req.Members) == 0 {
+		http.Error(w, "缺少必要参数", http.StatusBadRequest)
+		return
+	}
+
+	members := make([]*MatchRequest, 0, len(req.Members))
+	for _, m := range req.Members {
+		if m.PlayerID <= 0 || m.CharacterID <= 0 {
+			http.Error(w, "队伍成员参数无效", http.StatusBadRequest)
+			return
+		}
+		members = append(members, &MatchRequest{
+			PlayerID:    m.PlayerID,
+			CharacterID: m.CharacterID,
+			SessionID:   "party:" + req.PartyID,
+		})
+	}
+
+	if err := h.service.AddPartyToQueue(req.PartyID, members, req.GameMode); err != nil {
+		sendQueueError(w, err)
+		return
+	}
+
+	resp := matchResponse{
+		Success: true,
+		Message: "队伍已加入匹配队列",
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码响应失败: %v", err)
+	}
+}
+
+// handleLeavePartyQueue 处理队伍离开匹配队列请求
+func (h *MatchHandler) handleLeavePartyQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "仅支持POST或DELETE方法", http.StatusMethodNotAllowed)
+		return
+	}
+
+	partyID := r.URL.Query().Get("party_id")
+	gameMode := r.URL.Query().Get("game_mode")
+	if partyID == "" || gameMode == "" {
+		http.Error(w, "缺少必要参数", http.StatusBadRequest)
+		return
+	}
+
+	success := h.service.RemovePartyFromQueue(partyID, models.GameMode(gameMode))
+
+	resp := matchResponse{
+		Success: success,
+		Message: "队伍已离开匹配队列",
+	}
+	if !success {
+		resp.Message = "队伍不在匹配队列中"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("编码响应失败: %v", err)
+	}
+}
@@ -4,15 +4,44 @@ package match
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
 	"github.com/jacl-coder/PixelStorm-Server/internal/game"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
 	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/push"
+	"github.com/jacl-coder/PixelStorm-Server/internal/registry"
+	"github.com/jacl-coder/PixelStorm-Server/internal/report"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+var (
+	// ErrInvalidParty 组队匹配参数无效
+	ErrInvalidParty = errcode.New(errcode.BadRequest, "无效的队伍")
+	// ErrPartyTooLarge 队伍人数超过模式人数
+	ErrPartyTooLarge = errcode.New(errcode.PartyTooLarge, "队伍人数超过该模式的房间人数")
+	// ErrPartyAlreadyQueued 队伍已在匹配队列中
+	ErrPartyAlreadyQueued = errcode.New(errcode.AlreadyQueued, "队伍已在匹配队列中")
+	// ErrModeDisabled 该模式的匹配已通过功能开关关闭
+	ErrModeDisabled = errcode.New(errcode.FeatureDisabled, "该模式暂未开放匹配")
+	// ErrQueuePaused 服务器即将维护或维护中，暂停匹配
+	ErrQueuePaused = errcode.New(errcode.QueuePaused, "服务器即将维护，匹配已暂停")
+	// ErrCharacterNotOwned 未拥有所选角色且不在试玩期内
+	ErrCharacterNotOwned = errcode.New(errcode.CharacterNotOwned, "未拥有该角色或试玩已到期")
+	// ErrInvalidSession 登录会话无效，或与请求加入匹配的玩家不一致
+	ErrInvalidSession = errcode.New(errcode.AuthExpired, "登录会话无效或与玩家不一致")
 )
 
 // MatchRequest 匹配请求
@@ -22,6 +51,7 @@ type MatchRequest struct {
 	GameMode    models.GameMode
 	Timestamp   time.Time
 	SessionID   string
+	PartyID     string // 组队匹配时同一队伍的请求共享队伍ID，匹配时整队进入同一房间
 }
 
 // MatchService 匹配服务
@@ -33,6 +63,15 @@ type MatchService struct {
 	// 游戏服务器引用
 	gameServer *game.GameServer
 
+	// 匹配成功时推送通知不在游戏中的玩家
+	push *push.Service
+
+	// 检查玩家能否使用所选角色（已拥有或试玩中）
+	trials *trial.Service
+
+	// 校验加入和离开匹配队列请求的登录会话，默认读取网关写入Redis的会话
+	sessions SessionValidator
+
 	// 匹配配置
 	config *config.Config
 
@@ -40,6 +79,12 @@ type MatchService struct {
 	httpServer *http.Server
 	handler    *MatchHandler
 
+	// 游戏服务器容量不足时暂停匹配直到该时间
+	backoffUntil time.Time
+
+	// 向网关注册本实例并发送心跳，未配置服务注册时为nil
+	registrar *registry.Registrar
+
 	// 控制通道
 	shutdown  chan struct{}
 	isRunning bool
@@ -50,6 +95,9 @@ func NewMatchService(cfg *config.Config, gameServer *game.GameServer) *MatchServ
 	service := &MatchService{
 		queues:     make(map[models.GameMode][]*MatchRequest),
 		gameServer: gameServer,
+		push:       push.NewService(cfg.Push),
+		trials:     trial.NewService(wallet.NewService(), cfg.Trial),
+		sessions:   redisSession,
 		config:     cfg,
 		shutdown:   make(chan struct{}),
 	}
@@ -60,6 +108,12 @@ func NewMatchService(cfg *config.Config, gameServer *game.GameServer) *MatchServ
 	return service
 }
 
+// SetSessionValidator 替换登录会话的校验方式，须在 Start 之前调用。
+// 与网关在同一进程中运行时使用网关的会话存储，Redis 不可用时也能校验内存中的会话
+func (s *MatchService) SetSessionValidator(v SessionValidator) {
+	s.sessions = v
+}
+
 // Start 启动匹配服务
 func (s *MatchService) Start() error {
 	if s.isRunning {
@@ -89,30 +143,66 @@ func (s *MatchService) Start() error {
 	// 启动匹配循环
 	go s.matchLoop()
 
+	// 向网关注册，心跳中上报排队人数
+	s.registrar = registry.New(s.config.Registry, "match", s.config.Server.MatchPort, s.registryLoad)
+	s.registrar.Start()
+
 	return nil
 }
 
 // Stop 停止匹配服务
-func (s *MatchService) Stop() {
+func (s *MatchService) Stop(ctx context.Context) {
 	if !s.isRunning {
 		return
 	}
 
+	// 先从网关注销，不再接收新的匹配请求转发
+	s.registrar.Stop()
+
 	close(s.shutdown)
 	s.isRunning = false
 
-	// 关闭HTTP服务器
+	// 关闭HTTP服务器，超时后强制关闭剩余连接
 	if s.httpServer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		s.httpServer.Shutdown(ctx)
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			s.httpServer.Close()
+		}
 	}
 
 	log.Println("匹配服务已停止")
 }
 
-// AddToQueue 添加玩家到匹配队列
-func (s *MatchService) AddToQueue(playerID int64, characterID int, gameMode models.GameMode, sessionID string) {
+// ModeOpen 该模式是否对玩家开放匹配，模式可按功能开关关闭或灰度开放。
+// 进行中的限时活动的活动模式在活动期间对所有玩家开放，不受功能开关限制
+func (s *MatchService) ModeOpen(playerID int64, gameMode models.GameMode) bool {
+	return feature.MatchMode(gameMode).EnabledFor(playerID) || liveevent.ModeFeatured(gameMode, time.Now())
+}
+
+// Authorize 校验登录令牌对应的会话属于该玩家，会话无效、已过期或属于其他玩家时返回 ErrInvalidSession
+func (s *MatchService) Authorize(token string, playerID int64) error {
+	sessionPlayer, ok := s.sessions(token)
+	if !ok || sessionPlayer != playerID {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+// AddToQueue 使用登录令牌 token 将玩家添加到匹配队列。会话无效、匹配暂停、模式未开放或
+// 玩家不能使用所选角色时返回对应的错误
+func (s *MatchService) AddToQueue(playerID int64, characterID int, gameMode models.GameMode, sessionID, token string) error {
+	if err := s.Authorize(token, playerID); err != nil {
+		return err
+	}
+	if maintenance.MatchmakingPaused() {
+		return ErrQueuePaused
+	}
+	if !s.ModeOpen(playerID, gameMode) {
+		return fmt.Errorf("%w: %s", ErrModeDisabled, gameMode)
+	}
+	if err := s.checkCharacter(playerID, characterID); err != nil {
+		return err
+	}
+
 	s.queuesMutex.Lock()
 	defer s.queuesMutex.Unlock()
 
@@ -133,6 +223,80 @@ func (s *MatchService) AddToQueue(playerID int64, characterID int, gameMode mode
 	// 添加到队列
 	s.queues[gameMode] = append(s.queues[gameMode], request)
 	log.Printf("玩家 %d 加入 %s 模式的匹配队列", playerID, gameMode)
+	return nil
+}
+
+// checkCharacter 检查玩家能否使用所选角色：已拥有、试玩中或本周免费
+func (s *MatchService) checkCharacter(playerID int64, characterID int) error {
+	usable, err := s.trials.CanUse(playerID, characterID)
+	if err != nil {
+		return fmt.Errorf("检查玩家 %d 角色 %d 失败: %w", playerID, characterID, err)
+	}
+	if !usable {
+		return fmt.Errorf("%w: 玩家 %d 角色 %d", ErrCharacterNotOwned, playerID, characterID)
+	}
+	return nil
+}
+
+// AddPartyToQueue 将整支队伍加入匹配队列，队伍成员会被分配到同一房间
+func (s *MatchService) AddPartyToQueue(partyID string, members []*MatchRequest, gameMode models.GameMode) error {
+	if partyID == "" || len(members) == 0 {
+		return ErrInvalidParty
+	}
+	if maintenance.MatchmakingPaused() {
+		return ErrQueuePaused
+	}
+	for _, member := range members {
+		if !s.ModeOpen(member.PlayerID, gameMode) {
+			return fmt.Errorf("%w: %s", ErrModeDisabled, gameMode)
+		}
+		// 队伍选角后试玩可能已到期，入队时再检查一次
+		if err := s.checkCharacter(member.PlayerID, member.CharacterID); err != nil {
+			return err
+		}
+	}
+	if needed := getPlayersNeededForMode(gameMode); len(members) > needed {
+		return fmt.Errorf("%w: %s 模式最多 %d 人", ErrPartyTooLarge, gameMode, needed)
+	}
+
+	s.queuesMutex.Lock()
+	defer s.queuesMutex.Unlock()
+
+	for _, req := range s.queues[gameMode] {
+		if req.PartyID == partyID {
+			return ErrPartyAlreadyQueued
+		}
+	}
+
+	now := time.Now()
+	for _, member := range members {
+		member.GameMode = gameMode
+		member.Timestamp = now
+		member.PartyID = partyID
+		s.queues[gameMode] = append(s.queues[gameMode], member)
+	}
+	log.Printf("队伍 %s (%d 人) 加入 %s 模式的匹配队列", partyID, len(members), gameMode)
+	return nil
+}
+
+// RemovePartyFromQueue 从匹配队列移除整支队伍
+func (s *MatchService) RemovePartyFromQueue(partyID string, gameMode models.GameMode) bool {
+	s.queuesMutex.Lock()
+	defer s.queuesMutex.Unlock()
+
+	queue := s.queues[gameMode]
+	remaining := queue[:0]
+	for _, req := range queue {
+		if req.PartyID != partyID {
+			remaining = append(remaining, req)
+		}
+	}
+	removed := len(remaining) < len(queue)
+	s.queues[gameMode] = remaining
+	if removed {
+		log.Printf("队伍 %s 离开 %s 模式的匹配队列", partyID, gameMode)
+	}
+	return removed
 }
 
 // RemoveFromQueue 从匹配队列移除玩家
@@ -182,6 +346,15 @@ func (s *MatchService) GetAllQueueLengths() map[models.GameMode]int {
 	return result
 }
 
+// registryLoad 注册心跳上报的容量和负载：匹配服务不限容量，负载为各模式的排队人数
+func (s *MatchService) registryLoad() (int, int) {
+	queued := 0
+	for _, n := range s.GetAllQueueLengths() {
+		queued += n
+	}
+	return 0, queued
+}
+
 // matchLoop 匹配循环
 func (s *MatchService) matchLoop() {
 	ticker := time.NewTicker(1 * time.Second)
@@ -190,25 +363,52 @@ func (s *MatchService) matchLoop() {
 	for {
 		select {
 		case <-ticker.C:
-			s.processMatching()
+			s.runMatching()
 		case <-s.shutdown:
 			return
 		}
 	}
 }
 
+// matchPanicsTotal 匹配处理 panic 次数
+var matchPanicsTotal = metrics.Default.NewCounterVec("pixelstorm_match_panics_total",
+	"匹配处理 panic 次数")
+
+// runMatching 执行一轮匹配，panic 时记录堆栈并跳过本轮，匹配循环继续运行
+func (s *MatchService) runMatching() {
+	defer func() {
+		if p := recover(); p != nil {
+			matchPanicsTotal.Inc()
+			log.Printf("匹配处理 panic: %v\n%s", p, debug.Stack())
+			report.Panic(p, report.Context{Tags: map[string]string{"component": "matchmaking"}})
+		}
+	}()
+	s.processMatching()
+}
+
 // processMatching 处理匹配
 func (s *MatchService) processMatching() {
 	s.queuesMutex.Lock()
 	defer s.queuesMutex.Unlock()
 
+	// 游戏服务器已满，等待退避结束
+	if time.Now().Before(s.backoffUntil) {
+		return
+	}
+
+	// 即将维护时不再创建房间，队列中的玩家等待维护结束后继续匹配
+	if maintenance.MatchmakingPaused() {
+		return
+	}
+
 	// 为每种游戏模式进行匹配
 	for mode, queue := range s.queues {
 		// 根据游戏模式获取需要的玩家数量
 		playersNeeded := getPlayersNeededForMode(mode)
 
-		// 如果队列中的玩家不足，跳过
-		if len(queue) < playersNeeded {
+		// 按先进先出挑选玩家，同一队伍的成员不拆开；凑不齐时跳过
+		selected := selectPlayers(queue, playersNeeded)
+		if selected == nil {
 			continue
 		}
 
@@ -220,25 +420,80 @@ func (s *MatchService) processMatching() {
 		roomName := fmt.Sprintf("%s-%s", mode, time.Now().Format("150405"))
 		room, err := s.gameServer.CreateRoom(roomName, mode, playersNeeded, 1) // 使用默认地图ID 1
 		if err != nil {
+			var capErr *game.CapacityError
+			if errors.As(err, &capErr) {
+				// 服务器已满：玩家保留在队列中，延迟后重试
+				s.backoffUntil = time.Now().Add(capErr.RetryAfter)
+				log.Printf("游戏服务器已满，%v 后重试匹配: %v", capErr.RetryAfter, err)
+				return
+			}
 			log.Printf("创建房间失败: %v", err)
 			continue
 		}
 
-		// 将前N个玩家加入房间
-		matchedPlayers := queue[:playersNeeded]
-		s.queues[mode] = queue[playersNeeded:] // 更新队列
+		// 将选中的玩家加入房间并移出队列
+		matchedPlayers := make([]*MatchRequest, 0, playersNeeded)
+		remaining := make([]*MatchRequest, 0, len(queue)-playersNeeded)
+		for i, req := range queue {
+			if selected[i] {
+				matchedPlayers = append(matchedPlayers, req)
+			} else {
+				remaining = append(remaining, req)
+			}
+		}
+		s.queues[mode] = remaining // 更新队列
 
 		// 通知这些玩家已匹配成功
 		for _, player := range matchedPlayers {
-			// 在实际实现中，这里会通过WebSocket通知玩家
-			// 并提供房间信息让玩家加入
 			log.Printf("玩家 %d 匹配成功，房间ID: %s", player.PlayerID, room.ID)
 
-			// TODO: 通过会话ID找到玩家连接，并发送匹配成功消息
+			// 在线玩家通过游戏连接收到通知，短暂断线的玩家重连后重发；
+			// 没有游戏连接的玩家（应用在后台）通过推送通知
+			if !s.gameServer.NotifyMatchFound(player.PlayerID, room.ID, mode) {
+				s.push.NotifyMatchFound(player.PlayerID, room.ID, mode)
+			}
 		}
 	}
 }
 
+// selectPlayers 按加入顺序挑选恰好 needed 名玩家，队伍整体入选或整体跳过，
+// 返回选中的队列下标，人数凑不齐时返回nil
+func selectPlayers(queue []*MatchRequest, needed int) map[int]bool {
+	if len(queue) < needed {
+		return nil
+	}
+
+	// 按队伍分组，单人请求自成一组，组的顺序为首次出现的顺序
+	var groups [][]int
+	partyGroup := make(map[string]int)
+	for i, req := range queue {
+		if req.PartyID == "" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		if g, ok := partyGroup[req.PartyID]; ok {
+			groups[g] = append(groups[g], i)
+			continue
+		}
+		partyGroup[req.PartyID] = len(groups)
+		groups = append(groups, []int{i})
+	}
+
+	selected := make(map[int]bool, needed)
+	for _, group := range groups {
+		if len(selected)+len(group) > needed {
+			continue
+		}
+		for _, i := range group {
+			selected[i] = true
+		}
+		if len(selected) == needed {
+			return selected
+		}
+	}
+	return nil
+}
+
 // getPlayersNeededForMode 根据游戏模式获取需要的玩家数量
 func getPlayersNeededForMode(mode models.GameMode) int {
 	switch mode {
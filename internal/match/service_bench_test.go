@@ -0,0 +1,295 @@
+// service_bench_test.go
+
+package match
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/game"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/notify"
+)
+
+var (
+	benchRates    = flag.String("match.rates", "death_match=4,team_death_match=3,capture_point=2,flag_capture=2", "各模式每秒加入匹配的玩家数，格式为 模式=人数,...")
+	benchDuration = flag.Duration("match.duration", 10*time.Second, "每轮压测持续的时间")
+	benchTick     = flag.Duration("match.tick", time.Second, "匹配间隔，默认与匹配循环一致")
+)
+
+// benchWaiter 排队中的模拟玩家，匹配成功时经通知中心收到 match_found 推送
+type benchWaiter struct {
+	sub      *notify.Subscription
+	mode     models.GameMode
+	enqueued time.Time
+}
+
+// benchResult 压测的统计结果，多轮压测累加
+type benchResult struct {
+	arrived  map[models.GameMode]int
+	waits    map[models.GameMode][]time.Duration // 已匹配玩家的等待时间
+	starved  map[models.GameMode][]time.Duration // 结束时仍在排队的玩家已等待的时间
+	enqueues []time.Duration                     // AddToQueue 的耗时，主要是等待队列锁
+	rounds   []time.Duration                     // 每轮匹配持有队列锁的时间
+	elapsed  time.Duration
+}
+
+// benchRun 一轮压测
+type benchRun struct {
+	service *MatchService
+	nextID  *atomic.Int64
+
+	mu      sync.Mutex
+	pending map[int64]*benchWaiter
+	result  *benchResult
+}
+
+// BenchmarkMatchThroughput 按各模式的到达率持续向匹配服务加入玩家，统计匹配耗时、
+// 最长等待（队列公平性）和队列锁的争用，用于验证匹配队列的改造。玩家不连接游戏服务器，
+// 匹配成功的通知经推送服务投递到进程内的通知中心。用法：
+//
+//	go test ./internal/match/ -run '^$' -bench MatchThroughput -benchtime 1x \
+//		-match.rates=death_match=200,team_death_match=150,capture_point=80,flag_capture=80 \
+//		-match.duration=30s -mutexprofile mutex.out
+//
+// 到达率超过每轮每个模式能开出的房间时，backlog 和 wait-max-ms 随压测时间增长
+func BenchmarkMatchThroughput(b *testing.B) {
+	rates, err := parseRates(*benchRates)
+	if err != nil {
+		b.Fatalf("-match.rates 无效: %v", err)
+	}
+	if *benchDuration <= 0 || *benchTick <= 0 {
+		b.Fatal("-match.duration 和 -match.tick 必须大于0")
+	}
+
+	// 每个玩家和房间都会输出日志，压测期间关闭
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	result := &benchResult{
+		arrived: make(map[models.GameMode]int),
+		waits:   make(map[models.GameMode][]time.Duration),
+		starved: make(map[models.GameMode][]time.Duration),
+	}
+	var nextID atomic.Int64
+	for i := 0; i < b.N; i++ {
+		runMatchBench(rates, result, &nextID, int64(i*len(rates)))
+	}
+	result.report(b)
+}
+
+// runMatchBench 运行一轮压测：每个模式一个协程按泊松过程加入玩家，主协程按匹配间隔执行匹配
+func runMatchBench(rates map[models.GameMode]float64, result *benchResult, nextID *atomic.Int64, seed int64) {
+	// 不限制房间数和玩家名额，只测匹配本身
+	cfg := &config.Config{}
+	gameServer := game.NewGameServer(cfg)
+	run := &benchRun{
+		service: NewMatchService(cfg, gameServer),
+		nextID:  nextID,
+		pending: make(map[int64]*benchWaiter),
+		result:  result,
+	}
+	// 压测不经过网关登录，令牌即玩家ID
+	run.service.SetSessionValidator(func(token string) (int64, bool) {
+		id, err := strconv.ParseInt(token, 10, 64)
+		return id, err == nil
+	})
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for mode, rate := range rates {
+		seed++
+		wg.Add(1)
+		go func(mode models.GameMode, rate float64, rng *rand.Rand) {
+			defer wg.Done()
+			run.produce(mode, rate, rng, stop)
+		}(mode, rate, rand.New(rand.NewSource(seed)))
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(*benchTick)
+	deadline := time.After(*benchDuration)
+loop:
+	for {
+		select {
+		case <-ticker.C:
+			roundStart := time.Now()
+			run.service.runMatching()
+			result.rounds = append(result.rounds, time.Since(roundStart))
+			run.collect()
+		case <-deadline:
+			break loop
+		}
+	}
+	ticker.Stop()
+	close(stop)
+	wg.Wait()
+	result.elapsed += time.Since(start)
+
+	// 结束时仍在排队的玩家计入最长等待
+	now := time.Now()
+	run.mu.Lock()
+	for id, w := range run.pending {
+		result.starved[w.mode] = append(result.starved[w.mode], now.Sub(w.enqueued))
+		w.sub.Close()
+		delete(run.pending, id)
+	}
+	run.mu.Unlock()
+
+	// 匹配创建的房间没有玩家加入，直接停止游戏循环
+	for _, room := range gameServer.ListRooms() {
+		room.Stop()
+	}
+}
+
+// produce 按到达率加入玩家直到 stop 关闭，落后于计划时立即补上，保证实际到达率
+func (r *benchRun) produce(mode models.GameMode, rate float64, rng *rand.Rand, stop <-chan struct{}) {
+	next := time.Now()
+	for {
+		next = next.Add(time.Duration(rng.ExpFloat64() / rate * float64(time.Second)))
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		// 先订阅通知再加入队列，避免漏掉加入后立即发出的 match_found
+		id := r.nextID.Add(1)
+		w := &benchWaiter{sub: notify.Default.Subscribe(id), mode: mode, enqueued: time.Now()}
+		r.mu.Lock()
+		r.pending[id] = w
+		r.result.arrived[mode]++
+		r.mu.Unlock()
+
+		start := time.Now()
+		r.service.AddToQueue(id, 1, mode, "", strconv.FormatInt(id, 10))
+		elapsed := time.Since(start)
+
+		r.mu.Lock()
+		r.result.enqueues = append(r.result.enqueues, elapsed)
+		r.mu.Unlock()
+	}
+}
+
+// collect 收集本轮匹配成功的玩家。通知在匹配时同步发出，本轮结束时已在订阅的缓冲中
+func (r *benchRun) collect() {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, w := range r.pending {
+		select {
+		case <-w.sub.C:
+			r.result.waits[w.mode] = append(r.result.waits[w.mode], now.Sub(w.enqueued))
+			w.sub.Close()
+			delete(r.pending, id)
+		default:
+		}
+	}
+}
+
+// report 输出总体指标，各模式的明细写入压测日志
+func (res *benchResult) report(b *testing.B) {
+	var all, longest []time.Duration
+	matched, backlog := 0, 0
+	modes := make([]models.GameMode, 0, len(res.arrived))
+	for mode := range res.arrived {
+		modes = append(modes, mode)
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i] < modes[j] })
+
+	for _, mode := range modes {
+		waits, starved := res.waits[mode], res.starved[mode]
+		sortDurations(waits)
+		sortDurations(starved)
+		all = append(all, waits...)
+		longest = append(longest, waits...)
+		longest = append(longest, starved...)
+		matched += len(waits)
+		backlog += len(starved)
+
+		b.Logf("%-16s 到达 %6d  匹配 %6d  排队 %6d  p50 %8v  p99 %8v  最长 %8v",
+			mode, res.arrived[mode], len(waits), len(starved),
+			percentile(waits, 0.5), percentile(waits, 0.99),
+			max(percentile(waits, 1), percentile(starved, 1)))
+	}
+	sortDurations(all)
+	sortDurations(longest)
+	sortDurations(res.enqueues)
+	sortDurations(res.rounds)
+
+	b.ReportMetric(float64(matched)/res.elapsed.Seconds(), "matched/s")
+	b.ReportMetric(float64(backlog)/float64(b.N), "backlog")
+	b.ReportMetric(milliseconds(percentile(all, 0.5)), "wait-p50-ms")
+	b.ReportMetric(milliseconds(percentile(all, 0.99)), "wait-p99-ms")
+	b.ReportMetric(milliseconds(percentile(longest, 1)), "wait-max-ms")
+	b.ReportMetric(float64(percentile(res.enqueues, 0.99).Microseconds()), "enqueue-p99-us")
+	b.ReportMetric(float64(percentile(res.enqueues, 1).Microseconds()), "enqueue-max-us")
+	b.ReportMetric(milliseconds(percentile(res.rounds, 1)), "round-max-ms")
+}
+
+// parseRates 解析 模式=每秒人数 的列表
+func parseRates(s string) (map[models.GameMode]float64, error) {
+	rates := make(map[models.GameMode]float64)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q 应为 模式=人数", item)
+		}
+		mode := models.GameMode(strings.TrimSpace(name))
+		if !mode.IsValid() {
+			return nil, fmt.Errorf("未知的游戏模式 %q", mode)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("%s 的到达率 %q 无效", mode, value)
+		}
+		if rate > 0 {
+			rates[mode] = rate
+		}
+	}
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("至少需要一个到达率大于0的模式")
+	}
+	return rates, nil
+}
+
+// sortDurations 升序排序
+func sortDurations(d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+}
+
+// percentile 已排序数据的分位数，p 为1时取最大值，没有数据时返回0
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// milliseconds 转换为毫秒
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
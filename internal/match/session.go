@@ -0,0 +1,40 @@
+// session.go
+
+package match
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/authsession"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// SessionValidator 根据登录令牌返回会话对应的玩家，令牌无效或已过期时返回 false
+type SessionValidator func(token string) (int64, bool)
+
+// redisSession 从网关写入Redis的登录会话中读取玩家。Redis 不可用时无法确认会话，一律视为无效
+func redisSession(token string) (int64, bool) {
+	if db.RedisClient == nil || token == "" {
+		return 0, false
+	}
+	data, err := db.RedisClient.Get(db.RedisClient.Context(), authsession.Key(token)).Result()
+	if err != nil {
+		return 0, false
+	}
+	record, ok := authsession.Decode(data)
+	if !ok || time.Now().After(record.ExpiresAt) {
+		return 0, false
+	}
+	return record.PlayerID, true
+}
+
+// bearerToken 读取请求携带的登录令牌，与网关一致：优先 Authorization 请求头，其次 token 查询参数
+func bearerToken(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	return token
+}
@@ -0,0 +1,43 @@
+// announcement.go
+
+package models
+
+import (
+	"time"
+)
+
+// AnnouncementSeverity 公告级别，客户端据此选择展示样式
+type AnnouncementSeverity string
+
+const (
+	// SeverityInfo 普通通知，如活动预告
+	SeverityInfo AnnouncementSeverity = "info"
+	// SeverityWarning 需要玩家留意，如即将维护
+	SeverityWarning AnnouncementSeverity = "warning"
+	// SeverityCritical 紧急通知，如即将强制下线
+	SeverityCritical AnnouncementSeverity = "critical"
+)
+
+// IsValid 检查公告级别是否有效
+func (s AnnouncementSeverity) IsValid() bool {
+	return s == SeverityInfo || s == SeverityWarning || s == SeverityCritical
+}
+
+// Announcement 全服公告，通过 announcement 消息推送给所有在线玩家，大厅和对局内均会展示
+type Announcement struct {
+	ID            string               `json:"id"`
+	Severity      AnnouncementSeverity `json:"severity"`
+	Title         string               `json:"title,omitempty"`
+	Message       string               `json:"message"`
+	MaintenanceAt *time.Time           `json:"maintenance_at,omitempty"`    // 计划维护开始时间，客户端据此显示倒计时
+	Countdown     int64                `json:"countdown_seconds,omitempty"` // 发送时距维护开始的秒数，避免客户端时钟偏差
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+// AnnounceRequest 发布公告请求
+type AnnounceRequest struct {
+	Severity      AnnouncementSeverity `json:"severity"`
+	Title         string               `json:"title"`
+	Message       string               `json:"message"`
+	MaintenanceAt *time.Time           `json:"maintenance_at"`
+}
@@ -0,0 +1,37 @@
+// audit.go
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FieldChange 审计日志中单个字段的变更
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AdminAuditEntry 管理操作审计记录
+type AdminAuditEntry struct {
+	ID        int64                  `json:"id"`
+	Actor     string                 `json:"actor"`
+	IP        string                 `json:"ip,omitempty"`
+	Method    string                 `json:"method"`
+	Target    string                 `json:"target"`            // 请求路径及查询参数
+	Status    int                    `json:"status"`            // 响应状态码
+	Before    json.RawMessage        `json:"before,omitempty"`  // 修改前的状态，仅部分接口记录
+	Payload   json.RawMessage        `json:"payload,omitempty"` // 请求体
+	Diff      map[string]FieldChange `json:"diff,omitempty"`    // 请求体相对修改前状态变更的字段
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// AuditQuery 审计日志查询条件，时间区间为 [From, To)
+type AuditQuery struct {
+	From   time.Time
+	To     time.Time
+	Actor  string
+	Target string // 路径前缀
+	Limit  int
+}
@@ -0,0 +1,34 @@
+// avatar.go
+
+package models
+
+import (
+	"time"
+)
+
+// Avatar 头像目录中的预设头像
+type Avatar struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	ImageURL     string    `json:"image_url"`
+	UnlockItemID int       `json:"unlock_item_id,omitempty"` // 需要拥有的道具，0表示免费
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PlayerAvatar 玩家当前头像
+type PlayerAvatar struct {
+	PlayerID  int64     `json:"player_id"`
+	AvatarID  int       `json:"avatar_id,omitempty"` // 预设头像ID，上传头像时为0
+	URL       string    `json:"url"`
+	Uploaded  bool      `json:"uploaded"` // 是否为自定义上传
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// 查询玩家头像地址的SQL片段，需配合别名为 p 的 players 表使用
+const (
+	// AvatarJoinSQL 关联玩家头像
+	AvatarJoinSQL = `LEFT JOIN player_avatars pav ON pav.player_id = p.id LEFT JOIN avatars av ON av.id = pav.avatar_id`
+	// AvatarURLSQL 玩家头像地址（上传优先，其次预设头像）
+	AvatarURLSQL = `COALESCE(pav.upload_url, av.image_url, '')`
+)
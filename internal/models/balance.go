@@ -0,0 +1,77 @@
+// balance.go
+
+package models
+
+import (
+	"time"
+)
+
+// BalanceDefaultMode 对所有游戏模式生效的平衡配置
+const BalanceDefaultMode = "default"
+
+// SkillBalance 技能数值覆盖，字段为0时使用代码中的默认值
+type SkillBalance struct {
+	Damage   int     `json:"damage,omitempty"`   // 每个投射物的伤害
+	Cooldown float64 `json:"cooldown,omitempty"` // 冷却时间(秒)
+}
+
+// OvertimeMode 对局时间到时比分持平的处理方式
+type OvertimeMode string
+
+const (
+	// OvertimeNone 不加时，直接以平局结束
+	OvertimeNone OvertimeMode = "none"
+	// OvertimeSuddenDeath 突然死亡，打破平局后立即结束
+	OvertimeSuddenDeath OvertimeMode = "sudden_death"
+	// OvertimeExtraTime 延长对局时间，加时结束时仍持平则以平局结束
+	OvertimeExtraTime OvertimeMode = "extra_time"
+)
+
+// IsValid 检查加时方式是否有效
+func (m OvertimeMode) IsValid() bool {
+	switch m {
+	case OvertimeNone, OvertimeSuddenDeath, OvertimeExtraTime:
+		return true
+	default:
+		return false
+	}
+}
+
+// BalanceConfig 游戏平衡配置，数值字段为0、加时方式、得分规则和玩家碰撞为空时使用上一层的值
+type BalanceConfig struct {
+	Mode           string       `json:"mode"`             // 游戏模式或 default
+	RespawnTime    int          `json:"respawn_time"`     // 重生时间(秒)
+	RespawnMaxTime int          `json:"respawn_max_time"` // 时间到时的最长重生时间(秒)，重生时间随对局进行线性延长
+	RespawnWave    int          `json:"respawn_wave"`     // 重生波次间隔(秒)，为0时不分波次
+	TimeLimit      int          `json:"time_limit"`       // 时间限制(秒)
+	ScoreLimit     int          `json:"score_limit"`      // 分数限制
+	WarmupTime     int          `json:"warmup_time"`      // 热身时间(秒)，为0时不热身
+	CountdownTime  int          `json:"countdown_time"`   // 正式对局开始前的倒计时(秒)
+	Overtime       OvertimeMode `json:"overtime,omitempty"`
+	OvertimeTime   int          `json:"overtime_time"` // 加时时长(秒)，突然死亡为0时不限时
+
+	// 击杀、助攻、占点计分周期和夺旗的得分，每获得1分所需的治疗量，为空时使用上一层的值，可设为0
+	KillScore        *int `json:"kill_score,omitempty"`
+	AssistScore      *int `json:"assist_score,omitempty"`
+	CaptureTickScore *int `json:"capture_tick_score,omitempty"`
+	FlagCaptureScore *int `json:"flag_capture_score,omitempty"`
+	HealingPerPoint  *int `json:"healing_per_point,omitempty"`
+
+	// 玩家之间有碰撞体积，不能互相穿过，为空时使用上一层的值
+	BodyBlocking *bool `json:"body_blocking,omitempty"`
+
+	Skills    map[int]SkillBalance `json:"skills,omitempty"`
+	UpdatedAt time.Time            `json:"updated_at,omitempty"`
+}
+
+// Skill 获取技能数值覆盖
+func (c *BalanceConfig) Skill(skillID int) SkillBalance {
+	return c.Skills[skillID]
+}
+
+// BalanceStatus 平衡配置的保存与生效情况
+type BalanceStatus struct {
+	PushedAt  time.Time                  `json:"pushed_at"` // 最近一次加载到本实例的时间
+	Saved     []BalanceConfig            `json:"saved"`     // 数据库中保存的配置，推送后生效
+	Effective map[GameMode]BalanceConfig `json:"effective"` // 新建房间当前使用的配置
+}
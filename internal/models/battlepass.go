@@ -0,0 +1,79 @@
+// battlepass.go
+
+package models
+
+import (
+	"time"
+)
+
+// PassTrack 通行证奖励轨道
+type PassTrack string
+
+const (
+	// PassTrackFree 免费轨道
+	PassTrackFree PassTrack = "free"
+	// PassTrackPremium 高级轨道
+	PassTrackPremium PassTrack = "premium"
+)
+
+// IsValid 检查轨道是否有效
+func (t PassTrack) IsValid() bool {
+	return t == PassTrackFree || t == PassTrackPremium
+}
+
+// PassRewardType 通行证奖励类型
+type PassRewardType string
+
+const (
+	// PassRewardCurrency 货币奖励
+	PassRewardCurrency PassRewardType = "currency"
+	// PassRewardItem 道具奖励
+	PassRewardItem PassRewardType = "item"
+	// PassRewardTitle 称号奖励
+	PassRewardTitle PassRewardType = "title"
+)
+
+// BattlePassSeason 通行证赛季
+type BattlePassSeason struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	StartsAt        time.Time `json:"starts_at"`
+	EndsAt          time.Time `json:"ends_at"`
+	MaxTier         int       `json:"max_tier"`
+	XPPerTier       int       `json:"xp_per_tier"`      // 每级所需经验
+	PremiumCurrency Currency  `json:"premium_currency"` // 解锁高级轨道使用的货币
+	PremiumPrice    int64     `json:"premium_price"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// BattlePassReward 通行证等级奖励
+type BattlePassReward struct {
+	SeasonID   int            `json:"season_id"`
+	Tier       int            `json:"tier"`
+	Track      PassTrack      `json:"track"`
+	RewardType PassRewardType `json:"reward_type"`
+	Currency   Currency       `json:"currency,omitempty"`
+	ItemID     int            `json:"item_id,omitempty"`
+	TitleID    int            `json:"title_id,omitempty"`
+	Amount     int64          `json:"amount"` // 货币数量或道具数量
+}
+
+// PlayerBattlePass 玩家通行证进度
+type PlayerBattlePass struct {
+	Season     *BattlePassSeason   `json:"season"`
+	XP         int                 `json:"xp"`
+	Tier       int                 `json:"tier"`         // 当前等级
+	TierXP     int                 `json:"tier_xp"`      // 当前等级内已获得经验
+	NextTierXP int                 `json:"next_tier_xp"` // 升到下一级所需经验，满级为0
+	Premium    bool                `json:"premium"`      // 是否已解锁高级轨道
+	Rewards    []BattlePassReward  `json:"rewards"`      // 本赛季全部奖励
+	Claimed    []BattlePassClaimed `json:"claimed"`      // 已领取的奖励
+	Claimable  int                 `json:"claimable"`    // 可领取但未领取的奖励数
+}
+
+// BattlePassClaimed 已领取的奖励
+type BattlePassClaimed struct {
+	Tier      int       `json:"tier"`
+	Track     PassTrack `json:"track"`
+	ClaimedAt time.Time `json:"claimed_at"`
+}
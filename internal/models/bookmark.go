@@ -0,0 +1,30 @@
+// bookmark.go
+
+package models
+
+import (
+	"time"
+)
+
+// MatchBookmark 玩家收藏的对局及其在该对局中的表现
+type MatchBookmark struct {
+	MatchID      string    `json:"match_id"`
+	GameMode     GameMode  `json:"game_mode"`
+	MapID        int       `json:"map_id"`
+	StartTime    time.Time `json:"start_time"`
+	Duration     int       `json:"duration"` // 对局时长(秒)
+	CharacterID  int       `json:"character_id"`
+	Score        int       `json:"score"`
+	Kills        int       `json:"kills"`
+	Deaths       int       `json:"deaths"`
+	Assists      int       `json:"assists"`
+	Won          bool      `json:"won"`
+	MVP          bool      `json:"mvp"`
+	BookmarkedAt time.Time `json:"bookmarked_at"`
+	ReplayURL    string    `json:"replay_url"` // 可分享的回放链接
+}
+
+// BookmarkRequest 收藏对局请求
+type BookmarkRequest struct {
+	MatchID string `json:"match_id"`
+}
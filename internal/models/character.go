@@ -25,6 +25,12 @@ type Character struct {
 	Role       string `json:"role"`        // 角色定位，如"攻击手"、"辅助"等
 	Unlockable bool   `json:"unlockable"`  // 是否可解锁（有些角色可能是默认的）
 	UnlockCost int    `json:"unlock_cost"` // 解锁花费
+
+	// 玩家角色的等级进度（仅玩家角色列表返回）
+	Progress *CharacterProgress `json:"progress,omitempty"`
+
+	// 试玩状态（仅玩家角色列表中试玩中的角色返回）
+	Trial *CharacterTrial `json:"trial,omitempty"`
 }
 
 // PlayerCharacter 玩家拥有的角色
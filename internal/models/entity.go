@@ -83,16 +83,33 @@ type PlayerEntity struct {
 	BaseEntity
 	PlayerID       int64 `json:"player_id"`
 	CharacterID    int   `json:"character_id"`
+	SkinID         int   `json:"skin_id,omitempty"` // 装备的皮肤道具ID
 	Team           Team  `json:"team"`
 
 	// 战斗属性
 	Health      int  `json:"health"`
 	MaxHealth   int  `json:"max_health"`
 	IsAlive     bool `json:"is_alive"`
-	RespawnTime int  `json:"respawn_time,omitempty"`
+	RespawnTime int  `json:"respawn_time,omitempty"` // 距离重生的剩余秒数
+
+	// 阵亡后的重生时间点
+	RespawnAt time.Time `json:"-"`
+
+	// 位移技能的无法选中时间，在此之前不会被投射物命中
+	UntargetableUntil time.Time `json:"-"`
+
+	// 最近一次被其他玩家命中的攻击者实体ID和时间，死于危险区域时用于判定击杀者
+	LastAttackerID string    `json:"-"`
+	LastAttackedAt time.Time `json:"-"`
+
+	// 本次存活期间命中过该玩家的攻击者实体ID -> 最近命中时间，阵亡时用于判定助攻
+	Attackers map[string]time.Time `json:"-"`
 
 	// 技能冷却
 	SkillCooldowns map[int]float64 `json:"skill_cooldowns,omitempty"`
+
+	// 角色等级不足、尚未解锁的技能
+	LockedSkills map[int]bool `json:"-"`
 	
 	// 战斗统计
 	Kills   int `json:"kills"`
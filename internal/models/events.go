@@ -0,0 +1,47 @@
+// events.go
+
+package models
+
+import "time"
+
+// MatchStartedEvent 对局开始事件
+type MatchStartedEvent struct {
+	MatchID   string    `json:"match_id"`
+	GameMode  GameMode  `json:"game_mode"`
+	MapID     int       `json:"map_id"`
+	PlayerIDs []int64   `json:"player_ids"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// MatchEndedEvent 对局结果保存完成事件，只包含本次新结算的玩家
+type MatchEndedEvent struct {
+	Match   MatchRecord         `json:"match"`
+	Players []PlayerMatchRecord `json:"players"`
+}
+
+// PlayerLeveledEvent 玩家角色升级事件
+type PlayerLeveledEvent struct {
+	PlayerID int64 `json:"player_id"`
+	CharacterLevelUp
+}
+
+// PurchaseSource 购买来源
+type PurchaseSource string
+
+const (
+	// PurchaseSourceShop 游戏内商店，使用金币或宝石
+	PurchaseSourceShop PurchaseSource = "shop"
+	// PurchaseSourceIAP 应用内购买，使用法币购买宝石
+	PurchaseSourceIAP PurchaseSource = "iap"
+)
+
+// PurchaseMadeEvent 购买完成事件
+type PurchaseMadeEvent struct {
+	PlayerID    int64          `json:"player_id"`
+	Source      PurchaseSource `json:"source"`
+	ProductID   string         `json:"product_id"` // 商店为报价ID，应用内购买为商品ID
+	Quantity    int            `json:"quantity"`
+	Currency    string         `json:"currency"` // 商店为 coins/gems，应用内购买为法币代码
+	Amount      int64          `json:"amount"`   // 商店为货币数量，应用内购买为分
+	PurchasedAt time.Time      `json:"purchased_at"`
+}
@@ -0,0 +1,25 @@
+// feature.go
+
+package models
+
+import (
+	"time"
+)
+
+// FeatureFlag 功能开关
+type FeatureFlag struct {
+	Key         string    `json:"key"`
+	Enabled     bool      `json:"enabled"`
+	Rollout     int       `json:"rollout"` // 开启时生效的玩家百分比(0-100)，按玩家ID稳定分桶
+	Description string    `json:"description"`
+	Default     bool      `json:"default"`    // 未配置时的默认值
+	Configured  bool      `json:"configured"` // 是否已在数据库中配置，未配置时使用默认值
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// UpdateFeatureFlagRequest 更新功能开关请求
+type UpdateFeatureFlagRequest struct {
+	Enabled     bool    `json:"enabled"`
+	Rollout     *int    `json:"rollout,omitempty"` // 缺省时为100
+	Description *string `json:"description,omitempty"`
+}
@@ -0,0 +1,40 @@
+// gift.go
+
+package models
+
+import (
+	"time"
+)
+
+// GiftType 礼物类型
+type GiftType string
+
+const (
+	// GiftGems 赠送宝石
+	GiftGems GiftType = "gems"
+	// GiftItem 购买商品赠送
+	GiftItem GiftType = "item"
+)
+
+// Gift 玩家之间的赠礼记录
+type Gift struct {
+	ID          int64     `json:"id"`
+	SenderID    int64     `json:"sender_id"`
+	RecipientID int64     `json:"recipient_id"`
+	Type        GiftType  `json:"type"`
+	OfferID     int       `json:"offer_id,omitempty"`
+	ItemID      int       `json:"item_id,omitempty"`
+	Quantity    int       `json:"quantity,omitempty"`
+	Currency    Currency  `json:"currency"`
+	Amount      int64     `json:"amount"` // 赠送的宝石数，或购买商品花费的货币
+	Message     string    `json:"message,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SendGiftRequest 赠礼请求，gems 与 offer_id 二选一
+type SendGiftRequest struct {
+	RequestID string `json:"request_id"` // 客户端生成的请求ID，重复提交不会重复赠送
+	Gems      int64  `json:"gems,omitempty"`
+	OfferID   int    `json:"offer_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
@@ -15,7 +15,7 @@ import (
 
 // RedisLeaderboard Redis排行榜管理器
 type RedisLeaderboard struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ctx    context.Context
 }
 
@@ -27,20 +27,32 @@ func NewRedisLeaderboard() *RedisLeaderboard {
 	}
 }
 
-// 排行榜Redis键名
+// 排行榜Redis键名，使用相同的哈希标签保证 Redis Cluster 下位于同一槽位，
+// 刷新时才能在一个事务中原子替换
 const (
-	LeaderboardKillsKey = "leaderboard:kills"
-	LeaderboardWinsKey  = "leaderboard:wins"
-	LeaderboardScoreKey = "leaderboard:score"
-	LeaderboardKDAKey   = "leaderboard:kda"
+	LeaderboardKillsKey = "{leaderboard}:kills"
+	LeaderboardWinsKey  = "{leaderboard}:wins"
+	LeaderboardScoreKey = "{leaderboard}:score"
+	LeaderboardKDAKey   = "{leaderboard}:kda"
 	
 	// 玩家详细信息键前缀
 	PlayerInfoPrefix = "player:info:"
 	
 	// 排行榜缓存时间
 	LeaderboardCacheTTL = 5 * time.Minute
+
+	// 刷新排行榜时每个管道批次写入的玩家数
+	leaderboardRefreshChunkSize = 500
 )
 
+// leaderboardTypes 所有排行榜类型
+var leaderboardTypes = []LeaderboardType{
+	LeaderboardKills,
+	LeaderboardWins,
+	LeaderboardScore,
+	LeaderboardKDA,
+}
+
 // UpdatePlayerScore 更新玩家分数
 func (rl *RedisLeaderboard) UpdatePlayerScore(playerID int64, scoreType LeaderboardType, score float64) error {
 	key := rl.getLeaderboardKey(scoreType)
@@ -117,12 +129,16 @@ func (rl *RedisLeaderboard) GetPlayerRank(playerID int64, scoreType LeaderboardT
 }
 
 // RefreshLeaderboard 刷新排行榜（从数据库重新加载）
+//
+// 新数据先分批通过管道写入临时键，全部写完后在一个MULTI事务中RENAME覆盖正式键，
+// 刷新过程中读取方始终能看到完整的旧榜单。
 func (rl *RedisLeaderboard) RefreshLeaderboard() error {
 	// 查询数据库获取最新数据
 	query := `
 		SELECT
 			p.id AS player_id,
 			p.username,
+			` + AvatarURLSQL + ` AS avatar_url,
 			p.level,
 			p.total_kills,
 			p.total_wins,
@@ -131,54 +147,117 @@ func (rl *RedisLeaderboard) RefreshLeaderboard() error {
 				 ELSE (p.total_kills + p.total_assists) END AS kda,
 			(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) AS score
 		FROM players p
+		` + AvatarJoinSQL + `
 		WHERE 1=1
 		ORDER BY score DESC
 		LIMIT 1000
 	`
-	
-	rows, err := db.DB.Query(query)
+
+	// 全量刷新是只读查询，优先走从库
+	rows, err := db.Reader().Query(query)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
-	
-	// 清空现有排行榜
-	keys := []string{
-		LeaderboardKillsKey,
-		LeaderboardWinsKey,
-		LeaderboardScoreKey,
-		LeaderboardKDAKey,
-	}
-	
-	for _, key := range keys {
-		rl.client.Del(rl.ctx, key)
-	}
-	
-	// 重新填充排行榜
+
+	var entries []LeaderboardEntry
 	for rows.Next() {
 		var entry LeaderboardEntry
 		err := rows.Scan(
-			&entry.PlayerID, &entry.Username, &entry.Level,
+			&entry.PlayerID, &entry.Username, &entry.AvatarURL, &entry.Level,
 			&entry.TotalKills, &entry.TotalWins, &entry.WinRate,
 			&entry.KDA, &entry.Score,
 		)
 		if err != nil {
 			continue
 		}
-		
-		// 更新各种排行榜
-		rl.UpdatePlayerScore(entry.PlayerID, LeaderboardKills, float64(entry.TotalKills))
-		rl.UpdatePlayerScore(entry.PlayerID, LeaderboardWins, float64(entry.TotalWins))
-		rl.UpdatePlayerScore(entry.PlayerID, LeaderboardScore, entry.Score)
-		rl.UpdatePlayerScore(entry.PlayerID, LeaderboardKDA, entry.KDA)
-		
-		// 缓存玩家信息
-		rl.UpdatePlayerInfo(&entry)
+		entries = append(entries, entry)
 	}
-	
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// 临时键 -> 正式键
+	suffix := fmt.Sprintf(":tmp:%d", time.Now().UnixNano())
+	tmpKeys := make(map[LeaderboardType]string, len(leaderboardTypes))
+	for _, t := range leaderboardTypes {
+		tmpKeys[t] = rl.getLeaderboardKey(t) + suffix
+	}
+
+	// 分批写入临时键
+	for start := 0; start < len(entries); start += leaderboardRefreshChunkSize {
+		end := start + leaderboardRefreshChunkSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		if err := rl.writeChunk(tmpKeys, entries[start:end]); err != nil {
+			rl.discardTempKeys(tmpKeys)
+			return fmt.Errorf("写入排行榜临时数据失败: %w", err)
+		}
+	}
+
+	// 原子替换正式键
+	_, err = rl.client.TxPipelined(rl.ctx, func(pipe redis.Pipeliner) error {
+		for t, tmpKey := range tmpKeys {
+			if len(entries) == 0 {
+				pipe.Del(rl.ctx, rl.getLeaderboardKey(t))
+				continue
+			}
+			pipe.Rename(rl.ctx, tmpKey, rl.getLeaderboardKey(t))
+		}
+		return nil
+	})
+	if err != nil {
+		rl.discardTempKeys(tmpKeys)
+		return fmt.Errorf("替换排行榜失败: %w", err)
+	}
+
 	return nil
 }
 
+// writeChunk 通过一次管道往返写入一批玩家的分数和信息
+func (rl *RedisLeaderboard) writeChunk(tmpKeys map[LeaderboardType]string, chunk []LeaderboardEntry) error {
+	members := make(map[LeaderboardType][]*redis.Z, len(tmpKeys))
+	for _, entry := range chunk {
+		scores := map[LeaderboardType]float64{
+			LeaderboardKills: float64(entry.TotalKills),
+			LeaderboardWins:  float64(entry.TotalWins),
+			LeaderboardScore: entry.Score,
+			LeaderboardKDA:   entry.KDA,
+		}
+		for t, score := range scores {
+			members[t] = append(members[t], &redis.Z{Score: score, Member: entry.PlayerID})
+		}
+	}
+
+	pipe := rl.client.Pipeline()
+	for t, zs := range members {
+		pipe.ZAdd(rl.ctx, tmpKeys[t], zs...)
+	}
+
+	// 缓存玩家信息
+	for i := range chunk {
+		data, err := json.Marshal(&chunk[i])
+		if err != nil {
+			continue
+		}
+		pipe.Set(rl.ctx, fmt.Sprintf("%s%d", PlayerInfoPrefix, chunk[i].PlayerID), data, LeaderboardCacheTTL)
+	}
+
+	_, err := pipe.Exec(rl.ctx)
+	return err
+}
+
+// discardTempKeys 删除刷新失败时残留的临时键
+func (rl *RedisLeaderboard) discardTempKeys(tmpKeys map[LeaderboardType]string) {
+	keys := make([]string, 0, len(tmpKeys))
+	for _, key := range tmpKeys {
+		keys = append(keys, key)
+	}
+	rl.client.Del(rl.ctx, keys...)
+}
+
 // getLeaderboardKey 获取排行榜键名
 func (rl *RedisLeaderboard) getLeaderboardKey(scoreType LeaderboardType) string {
 	switch scoreType {
@@ -219,6 +298,7 @@ func (rl *RedisLeaderboard) getPlayerInfoFromDB(playerID int64) (*LeaderboardEnt
 		SELECT
 			p.id AS player_id,
 			p.username,
+			` + AvatarURLSQL + ` AS avatar_url,
 			p.level,
 			p.total_kills,
 			p.total_wins,
@@ -227,12 +307,13 @@ func (rl *RedisLeaderboard) getPlayerInfoFromDB(playerID int64) (*LeaderboardEnt
 				 ELSE (p.total_kills + p.total_assists) END AS kda,
 			(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) AS score
 		FROM players p
+		` + AvatarJoinSQL + `
 		WHERE p.id = $1
 	`
 	
 	var entry LeaderboardEntry
 	err := db.DB.QueryRow(query, playerID).Scan(
-		&entry.PlayerID, &entry.Username, &entry.Level,
+		&entry.PlayerID, &entry.Username, &entry.AvatarURL, &entry.Level,
 		&entry.TotalKills, &entry.TotalWins, &entry.WinRate,
 		&entry.KDA, &entry.Score,
 	)
@@ -0,0 +1,162 @@
+// leaderboard_scheduler.go
+
+package models
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 排行榜调度相关Redis键名，与排行榜键使用相同的哈希标签
+const (
+	// LeaderboardRefreshedAtKey 上次刷新时间（Unix秒）
+	LeaderboardRefreshedAtKey = "{leaderboard}:refreshed_at"
+	// LeaderboardStaleKey 排行榜数据已过期标记
+	LeaderboardStaleKey = "{leaderboard}:stale"
+	// LeaderboardRefreshLockKey 刷新锁，避免多个实例同时刷新
+	LeaderboardRefreshLockKey = "{leaderboard}:refresh_lock"
+
+	// 刷新锁的持有时间
+	leaderboardRefreshLockTTL = time.Minute
+)
+
+// MarkLeaderboardStale 标记排行榜数据已过期（例如对局结果写入后）
+func MarkLeaderboardStale() {
+	if db.RedisClient == nil {
+		return
+	}
+	if err := db.RedisClient.Set(db.Ctx, LeaderboardStaleKey, 1, 0).Err(); err != nil {
+		log.Printf("标记排行榜过期失败: %v", err)
+	}
+}
+
+// LeaderboardScheduler 排行榜定时刷新调度器
+type LeaderboardScheduler struct {
+	leaderboard     *RedisLeaderboard
+	refreshInterval time.Duration // 定时刷新间隔
+	checkInterval   time.Duration // 过期检查间隔
+
+	shutdown  chan struct{}
+	stopOnce  sync.Once
+	isRunning bool
+}
+
+// NewLeaderboardScheduler 创建排行榜调度器
+func NewLeaderboardScheduler(leaderboard *RedisLeaderboard, refreshInterval, checkInterval time.Duration) *LeaderboardScheduler {
+	if refreshInterval <= 0 {
+		refreshInterval = 5 * time.Minute
+	}
+	if checkInterval <= 0 {
+		checkInterval = 30 * time.Second
+	}
+
+	return &LeaderboardScheduler{
+		leaderboard:     leaderboard,
+		refreshInterval: refreshInterval,
+		checkInterval:   checkInterval,
+		shutdown:        make(chan struct{}),
+	}
+}
+
+// Start 启动调度器
+func (s *LeaderboardScheduler) Start() {
+	if s.isRunning {
+		return
+	}
+	s.isRunning = true
+
+	go s.run()
+	log.Printf("排行榜调度器启动，刷新间隔: %v，检查间隔: %v", s.refreshInterval, s.checkInterval)
+}
+
+// Stop 停止调度器
+func (s *LeaderboardScheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.shutdown)
+		s.isRunning = false
+	})
+}
+
+// run 调度循环
+func (s *LeaderboardScheduler) run() {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	// 启动时检查一次
+	s.refreshIfNeeded()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.refreshIfNeeded()
+		case <-s.shutdown:
+			return
+		}
+	}
+}
+
+// refreshIfNeeded 当排行榜到期、被标记过期或数据缺失时刷新
+func (s *LeaderboardScheduler) refreshIfNeeded() {
+	stale, reason := s.isStale()
+	if !stale {
+		return
+	}
+
+	// 获取刷新锁
+	ok, err := s.leaderboard.client.SetNX(s.leaderboard.ctx, LeaderboardRefreshLockKey, 1, leaderboardRefreshLockTTL).Result()
+	if err != nil {
+		log.Printf("获取排行榜刷新锁失败: %v", err)
+		return
+	}
+	if !ok {
+		return // 其他实例正在刷新
+	}
+	defer s.leaderboard.client.Del(s.leaderboard.ctx, LeaderboardRefreshLockKey)
+
+	start := time.Now()
+	if err := s.leaderboard.RefreshLeaderboard(); err != nil {
+		log.Printf("定时刷新排行榜失败: %v", err)
+		return
+	}
+
+	pipe := s.leaderboard.client.TxPipeline()
+	pipe.Set(s.leaderboard.ctx, LeaderboardRefreshedAtKey, time.Now().Unix(), 0)
+	pipe.Del(s.leaderboard.ctx, LeaderboardStaleKey)
+	if _, err := pipe.Exec(s.leaderboard.ctx); err != nil {
+		log.Printf("记录排行榜刷新时间失败: %v", err)
+	}
+
+	log.Printf("排行榜已刷新 (原因: %s, 耗时 %v)", reason, time.Since(start))
+}
+
+// isStale 检查排行榜是否需要刷新，只根据过期标记和上次刷新时间判断，
+// 没有玩家时排行榜为空是正常状态，不会因此反复刷新
+func (s *LeaderboardScheduler) isStale() (bool, string) {
+	ctx := s.leaderboard.ctx
+	client := s.leaderboard.client
+
+	if n, err := client.Exists(ctx, LeaderboardStaleKey).Result(); err == nil && n > 0 {
+		return true, "数据已更新"
+	}
+
+	value, err := client.Get(ctx, LeaderboardRefreshedAtKey).Result()
+	if err == redis.Nil {
+		return true, "从未刷新"
+	}
+	if err != nil {
+		log.Printf("读取排行榜刷新时间失败: %v", err)
+		return false, ""
+	}
+
+	refreshedAt, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || time.Since(time.Unix(refreshedAt, 0)) >= s.refreshInterval {
+		return true, "定时刷新"
+	}
+
+	return false, ""
+}
@@ -0,0 +1,56 @@
+// live_event.go
+
+package models
+
+import (
+	"time"
+)
+
+// LiveEvent 限时活动，如双倍经验周末、活动模式和金币掉落加成
+type LiveEvent struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	ExpBonus     int        `json:"exp_bonus"`            // 对局角色经验和通行证经验加成百分比，100 为双倍
+	CoinBonus    int        `json:"coin_bonus"`           // 对局金币掉落加成百分比
+	ShopDiscount int        `json:"shop_discount"`        // 商店折扣百分比
+	GameModes    []GameMode `json:"game_modes,omitempty"` // 活动模式：活动期间开放匹配，非空时经验和金币加成只对这些模式生效
+	Enabled      bool       `json:"enabled"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsActive 活动在指定时间是否进行中
+func (e *LiveEvent) IsActive(now time.Time) bool {
+	return e.Enabled && !now.Before(e.StartsAt) && now.Before(e.EndsAt)
+}
+
+// AppliesTo 活动的对局加成是否对该模式生效
+func (e *LiveEvent) AppliesTo(mode GameMode) bool {
+	if len(e.GameModes) == 0 {
+		return true
+	}
+	for _, m := range e.GameModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// EventBoost 进行中的活动对某一模式的对局合计生效的加成，多个活动的同项加成取最大值
+type EventBoost struct {
+	ExpBonus  int     `json:"exp_bonus"`
+	CoinBonus int     `json:"coin_bonus"`
+	EventIDs  []int64 `json:"event_ids,omitempty"` // 提供加成的活动
+}
+
+// ActiveEvents 当前进行中的活动，供客户端展示活动横幅和活动模式
+type ActiveEvents struct {
+	Events       []LiveEvent `json:"events"`
+	GameModes    []GameMode  `json:"game_modes"`    // 因活动开放匹配的模式
+	ShopDiscount int         `json:"shop_discount"` // 当前生效的商店折扣百分比
+	ServerTime   time.Time   `json:"server_time"`
+}
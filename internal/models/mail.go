@@ -0,0 +1,77 @@
+// mail.go
+
+package models
+
+import (
+	"time"
+)
+
+// MailCategory 邮件类别
+type MailCategory string
+
+const (
+	// MailSystem 系统通知
+	MailSystem MailCategory = "system"
+	// MailCompensation 补偿
+	MailCompensation MailCategory = "compensation"
+	// MailSeasonReward 赛季奖励
+	MailSeasonReward MailCategory = "season_reward"
+)
+
+// IsValid 检查邮件类别是否有效
+func (c MailCategory) IsValid() bool {
+	switch c {
+	case MailSystem, MailCompensation, MailSeasonReward:
+		return true
+	}
+	return false
+}
+
+// AttachmentType 邮件附件类型
+type AttachmentType string
+
+const (
+	// AttachmentCurrency 货币
+	AttachmentCurrency AttachmentType = "currency"
+	// AttachmentItem 道具
+	AttachmentItem AttachmentType = "item"
+)
+
+// MailAttachment 邮件附件
+type MailAttachment struct {
+	ID       int64          `json:"id"`
+	Type     AttachmentType `json:"type"`
+	Currency Currency       `json:"currency,omitempty"`
+	ItemID   int            `json:"item_id,omitempty"`
+	Amount   int64          `json:"amount"` // 货币数量或道具数量
+}
+
+// Mail 玩家邮件
+type Mail struct {
+	ID          int64            `json:"id"`
+	PlayerID    int64            `json:"player_id"`
+	Category    MailCategory     `json:"category"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	Attachments []MailAttachment `json:"attachments"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	ReadAt      *time.Time       `json:"read_at,omitempty"`
+	ClaimedAt   *time.Time       `json:"claimed_at,omitempty"` // 附件领取时间
+}
+
+// HasUnclaimedAttachments 是否有未领取的附件
+func (m *Mail) HasUnclaimedAttachments() bool {
+	return len(m.Attachments) > 0 && m.ClaimedAt == nil
+}
+
+// SendMailRequest 发送邮件请求（管理员群发）
+type SendMailRequest struct {
+	PlayerIDs     []int64          `json:"player_ids"`  // 收件人，AllPlayers为true时忽略
+	AllPlayers    bool             `json:"all_players"` // 发送给全部玩家
+	Category      MailCategory     `json:"category"`
+	Title         string           `json:"title"`
+	Body          string           `json:"body"`
+	Attachments   []MailAttachment `json:"attachments"`
+	ExpiresInDays int              `json:"expires_in_days"` // 有效天数，0表示永久
+}
@@ -0,0 +1,44 @@
+// maintenance.go
+
+package models
+
+import (
+	"time"
+)
+
+// MaintenancePhase 计划维护的阶段
+type MaintenancePhase string
+
+const (
+	// MaintenanceNone 没有计划中的维护
+	MaintenanceNone MaintenancePhase = "none"
+	// MaintenanceScheduled 已计划维护，服务正常，按配置的时间点向在线玩家发送警告
+	MaintenanceScheduled MaintenancePhase = "scheduled"
+	// MaintenanceDraining 即将维护，已停止匹配，进行中的对局继续
+	MaintenanceDraining MaintenancePhase = "draining"
+	// MaintenanceActive 维护中，进行中的对局已结束，网关拒绝玩家请求
+	MaintenanceActive MaintenancePhase = "active"
+)
+
+// MaintenanceWindow 计划维护时间段
+type MaintenanceWindow struct {
+	ID        int64     `json:"id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScheduleMaintenanceRequest 计划维护请求
+type ScheduleMaintenanceRequest struct {
+	StartsAt time.Time `json:"starts_at"`
+	Duration int       `json:"duration_seconds"` // 维护时长(秒)，到期后自动恢复服务
+	Reason   string    `json:"reason"`
+}
+
+// MaintenanceStatus 当前维护状态
+type MaintenanceStatus struct {
+	Phase  MaintenancePhase   `json:"phase"`
+	Window *MaintenanceWindow `json:"window,omitempty"`
+	Manual bool               `json:"manual"` // 维护模式功能开关已手动开启，不随计划维护自动解除
+}
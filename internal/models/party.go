@@ -0,0 +1,75 @@
+// party.go
+
+package models
+
+import (
+	"time"
+)
+
+// PartyStatus 队伍状态
+type PartyStatus string
+
+const (
+	// PartyIdle 空闲
+	PartyIdle PartyStatus = "idle"
+	// PartyQueued 匹配中
+	PartyQueued PartyStatus = "queued"
+)
+
+// Party 对局外的组队
+type Party struct {
+	ID        string        `json:"id"`
+	LeaderID  int64         `json:"leader_id"`
+	Members   []PartyMember `json:"members"`
+	MaxSize   int           `json:"max_size"`
+	Status    PartyStatus   `json:"status"`
+	GameMode  GameMode      `json:"game_mode,omitempty"` // 匹配中的游戏模式
+	QueuedAt  *time.Time    `json:"queued_at,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// PartyMember 队伍成员
+type PartyMember struct {
+	PlayerID    int64     `json:"player_id"`
+	CharacterID int       `json:"character_id,omitempty"` // 选择的角色，队长开始匹配前所有成员都需选择
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// PartyInvite 组队邀请
+type PartyInvite struct {
+	PartyID   string    `json:"party_id"`
+	FromID    int64     `json:"from_id"`
+	ToID      int64     `json:"to_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PartyMessage 队伍聊天消息
+type PartyMessage struct {
+	Seq      int64     `json:"seq"`
+	PlayerID int64     `json:"player_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// PartyPresence 玩家状态中展示的组队信息
+type PartyPresence struct {
+	Size     int         `json:"size"`
+	MaxSize  int         `json:"max_size"`
+	IsLeader bool        `json:"is_leader"`
+	Status   PartyStatus `json:"status"`
+	GameMode GameMode    `json:"game_mode,omitempty"`
+}
+
+// Presence 玩家状态
+type Presence struct {
+	PlayerID int64          `json:"player_id"`
+	Party    *PartyPresence `json:"party,omitempty"`
+}
+
+// PartyQueueRequest 队长为整支队伍发起匹配，由网关发送给匹配服务
+type PartyQueueRequest struct {
+	PartyID  string        `json:"party_id"`
+	GameMode GameMode      `json:"game_mode"`
+	Members  []PartyMember `json:"members"`
+}
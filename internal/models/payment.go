@@ -0,0 +1,58 @@
+// payment.go
+
+package models
+
+import (
+	"time"
+)
+
+// PaymentStore 应用商店
+type PaymentStore string
+
+const (
+	// StoreApple App Store
+	StoreApple PaymentStore = "apple"
+	// StoreGoogle Google Play
+	StoreGoogle PaymentStore = "google"
+)
+
+// VerifyPurchaseRequest 应用内购买校验请求
+type VerifyPurchaseRequest struct {
+	Store         PaymentStore `json:"store"`
+	Receipt       string       `json:"receipt,omitempty"`        // App Store 收据（base64）
+	ProductID     string       `json:"product_id,omitempty"`     // Google Play 商品ID
+	PurchaseToken string       `json:"purchase_token,omitempty"` // Google Play 购买令牌
+}
+
+// Purchase 已校验并发放的应用内购买记录
+type Purchase struct {
+	ID            int64        `json:"id"`
+	PlayerID      int64        `json:"player_id"`
+	Store         PaymentStore `json:"store"`
+	ProductID     string       `json:"product_id"`
+	TransactionID string       `json:"transaction_id"`
+	Quantity      int          `json:"quantity"`
+	Gems          int64        `json:"gems"`
+	PriceCents    int64        `json:"price_cents"` // 按商品标价记录，不含商店分成和税费
+	Currency      string       `json:"currency"`
+	Sandbox       bool         `json:"sandbox"`
+	PurchasedAt   time.Time    `json:"purchased_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// VerifyPurchaseResult 校验结果
+type VerifyPurchaseResult struct {
+	Purchases   []Purchase `json:"purchases"`    // 本次请求涉及的购买，包括此前已发放的
+	GemsGranted int64      `json:"gems_granted"` // 本次新发放的宝石
+}
+
+// RevenueEntry 收入统计条目
+type RevenueEntry struct {
+	Day        string       `json:"day"`
+	Store      PaymentStore `json:"store"`
+	Currency   string       `json:"currency"`
+	Purchases  int          `json:"purchases"`
+	Buyers     int          `json:"buyers"`
+	Gems       int64        `json:"gems"`
+	PriceCents int64        `json:"price_cents"`
+}
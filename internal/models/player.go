@@ -29,6 +29,14 @@ type Player struct {
 	TotalWins    int `json:"total_wins"`
 }
 
+// PlayerStatistics 玩家资料页展示的统计信息
+type PlayerStatistics struct {
+	WinRate     float64 `json:"win_rate"`     // 胜率
+	KDA         float64 `json:"kda"`          // KDA比率
+	AverageKill float64 `json:"average_kill"` // 平均击杀
+	PlayTime    int     `json:"play_time"`    // 总游戏时长(分钟)
+}
+
 // PlayerSession 玩家会话信息
 type PlayerSession struct {
 	PlayerID  int64  `json:"player_id"`
@@ -0,0 +1,32 @@
+// privacy.go
+
+package models
+
+import (
+	"time"
+)
+
+// Visibility 资料可见范围
+type Visibility string
+
+const (
+	// VisibilityPublic 所有人可见
+	VisibilityPublic Visibility = "public"
+	// VisibilityFriends 仅好友可见
+	VisibilityFriends Visibility = "friends"
+	// VisibilityPrivate 仅自己可见
+	VisibilityPrivate Visibility = "private"
+)
+
+// IsValid 检查可见范围是否有效
+func (v Visibility) IsValid() bool {
+	return v == VisibilityPublic || v == VisibilityFriends || v == VisibilityPrivate
+}
+
+// PrivacySettings 玩家隐私设置
+type PrivacySettings struct {
+	PlayerID     int64      `json:"player_id"`
+	MatchHistory Visibility `json:"match_history"` // 对局历史及对局详情
+	Stats        Visibility `json:"stats"`         // 战绩统计
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+// progression.go
+
+package models
+
+// CharacterProgress 玩家角色的等级成长进度
+type CharacterProgress struct {
+	Level          int   `json:"level"`
+	Exp            int   `json:"exp"`         // 当前等级已获得的经验
+	ExpToNext      int   `json:"exp_to_next"` // 升到下一级所需经验，满级时为0
+	MaxLevel       int   `json:"max_level"`
+	LockedSkillIDs []int `json:"locked_skill_ids"` // 等级不足尚未解锁的技能
+}
+
+// CharacterLevelUp 角色升级结果
+type CharacterLevelUp struct {
+	CharacterID int `json:"character_id"`
+	OldLevel    int `json:"old_level"`
+	NewLevel    int `json:"new_level"`
+}
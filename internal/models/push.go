@@ -0,0 +1,76 @@
+// push.go
+
+package models
+
+import (
+	"time"
+)
+
+// DevicePlatform 推送设备平台
+type DevicePlatform string
+
+const (
+	// PlatformIOS iOS设备，通过APNs推送
+	PlatformIOS DevicePlatform = "ios"
+	// PlatformAndroid 安卓设备，通过FCM推送
+	PlatformAndroid DevicePlatform = "android"
+)
+
+// IsValid 检查平台是否有效
+func (p DevicePlatform) IsValid() bool {
+	return p == PlatformIOS || p == PlatformAndroid
+}
+
+// PushCategory 推送类别，玩家可按类别关闭
+type PushCategory string
+
+const (
+	// PushMatchFound 匹配成功（应用在后台时）
+	PushMatchFound PushCategory = "match_found"
+	// PushFriendRequest 好友申请
+	PushFriendRequest PushCategory = "friend_request"
+	// PushPartyInvite 组队邀请
+	PushPartyInvite PushCategory = "party_invite"
+	// PushMail 新邮件
+	PushMail PushCategory = "mail"
+)
+
+// PushCategories 全部推送类别
+var PushCategories = []PushCategory{PushMatchFound, PushFriendRequest, PushPartyInvite, PushMail}
+
+// IsValid 检查推送类别是否有效
+func (c PushCategory) IsValid() bool {
+	for _, category := range PushCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// PushDevice 玩家注册的推送设备
+type PushDevice struct {
+	ID        int64          `json:"id"`
+	PlayerID  int64          `json:"player_id"`
+	Platform  DevicePlatform `json:"platform"`
+	Token     string         `json:"token"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// RegisterDeviceRequest 注册推送设备请求
+type RegisterDeviceRequest struct {
+	Platform DevicePlatform `json:"platform"`
+	Token    string         `json:"token"`
+}
+
+// PushPreferences 各推送类别是否开启，未设置的类别默认开启
+type PushPreferences map[PushCategory]bool
+
+// PushNotification 推送内容
+type PushNotification struct {
+	Category PushCategory      `json:"category"`
+	Title    string            `json:"title"`
+	Body     string            `json:"body"`
+	Data     map[string]string `json:"data,omitempty"` // 客户端跳转使用的附加数据
+}
@@ -0,0 +1,88 @@
+// quest.go
+
+package models
+
+import (
+	"time"
+)
+
+// QuestPeriod 任务周期
+type QuestPeriod string
+
+const (
+	// QuestDaily 每日任务
+	QuestDaily QuestPeriod = "daily"
+	// QuestWeekly 每周任务
+	QuestWeekly QuestPeriod = "weekly"
+)
+
+// IsValid 检查任务周期是否有效
+func (p QuestPeriod) IsValid() bool {
+	return p == QuestDaily || p == QuestWeekly
+}
+
+// QuestObjective 任务目标类型
+type QuestObjective string
+
+const (
+	// ObjectivePlay 完成对局
+	ObjectivePlay QuestObjective = "play"
+	// ObjectiveWin 赢得对局
+	ObjectiveWin QuestObjective = "win"
+	// ObjectiveKill 击杀
+	ObjectiveKill QuestObjective = "kill"
+	// ObjectiveAssist 助攻
+	ObjectiveAssist QuestObjective = "assist"
+	// ObjectiveMVP 获得MVP
+	ObjectiveMVP QuestObjective = "mvp"
+)
+
+// IsValid 检查任务目标是否有效
+func (o QuestObjective) IsValid() bool {
+	switch o {
+	case ObjectivePlay, ObjectiveWin, ObjectiveKill, ObjectiveAssist, ObjectiveMVP:
+		return true
+	}
+	return false
+}
+
+// Quest 任务定义
+type Quest struct {
+	ID             int            `json:"id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	Period         QuestPeriod    `json:"period"`
+	Objective      QuestObjective `json:"objective"`
+	Target         int            `json:"target"`                   // 目标数量
+	CharacterID    int            `json:"character_id,omitempty"`   // 限定角色，0表示不限
+	CharacterRole  string         `json:"character_role,omitempty"` // 限定角色定位，如"狙击手"
+	GameMode       GameMode       `json:"game_mode,omitempty"`      // 限定游戏模式
+	RewardCurrency Currency       `json:"reward_currency"`          // 奖励货币
+	RewardAmount   int64          `json:"reward_amount"`            // 奖励数量
+	RewardPassXP   int            `json:"reward_pass_xp"`           // 奖励通行证经验
+	Active         bool           `json:"active"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// PlayerQuest 玩家当前周期的任务
+type PlayerQuest struct {
+	ID          int64      `json:"id"`
+	PlayerID    int64      `json:"player_id"`
+	Quest       Quest      `json:"quest"`
+	PeriodStart time.Time  `json:"period_start"` // 周期开始日期
+	PeriodEnd   time.Time  `json:"period_end"`   // 周期结束时间
+	Progress    int        `json:"progress"`
+	Rerolled    bool       `json:"rerolled"` // 是否由刷新获得
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+}
+
+// Completed 是否已完成
+func (q *PlayerQuest) Completed() bool {
+	return q.CompletedAt != nil
+}
+
+// Claimed 是否已领取奖励
+func (q *PlayerQuest) Claimed() bool {
+	return q.ClaimedAt != nil
+}
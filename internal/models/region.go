@@ -0,0 +1,29 @@
+// region.go
+
+package models
+
+import (
+	"time"
+)
+
+// RegionInfo 可供客户端选择的游戏服务器区域
+type RegionInfo struct {
+	Name      string `json:"name"`
+	PingURL   string `json:"ping_url,omitempty"` // 客户端直连测量往返延迟的地址，实例未配置对外地址时为空
+	Instances int    `json:"instances"`          // 健康的游戏服务器实例数
+	Capacity  int    `json:"capacity"`           // 可承载的玩家数，0 表示不限
+	Load      int    `json:"load"`               // 当前的玩家数
+	Available bool   `json:"available"`          // 是否还有未满载的实例
+}
+
+// RegionLatency 玩家最近一次测得的到某区域的往返延迟
+type RegionLatency struct {
+	Region     string    `json:"region"`
+	RTTMillis  int       `json:"rtt_ms"`
+	MeasuredAt time.Time `json:"measured_at"`
+}
+
+// SaveLatenciesRequest 上报延迟请求，键为区域名，值为往返延迟(毫秒)
+type SaveLatenciesRequest struct {
+	Latencies map[string]int `json:"latencies"`
+}
@@ -0,0 +1,54 @@
+// replay.go
+
+package models
+
+import (
+	"time"
+)
+
+// HighlightType 回放精彩片段标记类型
+type HighlightType string
+
+const (
+	// HighlightMultiKill 连续击杀，相邻两次击杀的间隔不超过连杀间隔
+	HighlightMultiKill HighlightType = "multi_kill"
+	// HighlightMatchPoint 队伍或玩家到达赛点
+	HighlightMatchPoint HighlightType = "match_point"
+)
+
+// Highlight 回放索引中的精彩片段标记
+type Highlight struct {
+	Type     HighlightType `json:"type"`
+	PlayerID int64         `json:"player_id,omitempty"`
+	Team     Team          `json:"team,omitempty"`     // 团队模式的赛点为队伍
+	Kills    int           `json:"kills,omitempty"`    // 连杀数
+	Elapsed  float64       `json:"elapsed"`            // 片段开始距对局开始的秒数
+	Duration float64       `json:"duration,omitempty"` // 片段时长(秒)
+}
+
+// ReplayPlayer 回放中的玩家及其战绩
+type ReplayPlayer struct {
+	PlayerID    int64  `json:"player_id"`
+	Username    string `json:"username"`
+	CharacterID int    `json:"character_id"`
+	Team        int    `json:"team"`
+	Score       int    `json:"score"`
+	Kills       int    `json:"kills"`
+	Deaths      int    `json:"deaths"`
+	Assists     int    `json:"assists"`
+	Won         bool   `json:"won"`
+	MVP         bool   `json:"mvp"`
+}
+
+// Replay 对局回放索引：对局信息、玩家战绩、击杀/死亡事件时间线和精彩片段标记
+type Replay struct {
+	MatchID     string          `json:"match_id"`
+	GameMode    GameMode        `json:"game_mode"`
+	MapID       int             `json:"map_id"`
+	StartTime   time.Time       `json:"start_time"`
+	Duration    int             `json:"duration"` // 对局时长(秒)
+	WinningTeam int             `json:"winning_team"`
+	Players     []ReplayPlayer  `json:"players"`
+	Events      []PositionEvent `json:"events"`
+	Highlights  []Highlight     `json:"highlights"`
+}
@@ -20,6 +20,20 @@ const (
 	FlagCapture GameMode = "flag_capture"
 )
 
+// IsValid 检查是否为支持的游戏模式
+func (m GameMode) IsValid() bool {
+	switch m {
+	case DeathMatch, TeamDeathMatch, CapturePoint, FlagCapture:
+		return true
+	}
+	return false
+}
+
+// IsTeamMode 检查是否为团队模式
+func (m GameMode) IsTeamMode() bool {
+	return m == TeamDeathMatch || m == FlagCapture || m == CapturePoint
+}
+
 // RoomStatus 房间状态
 type RoomStatus string
 
@@ -32,6 +46,48 @@ const (
 	RoomEnded RoomStatus = "ended"
 )
 
+// MatchPhase 对局进行中的阶段
+type MatchPhase string
+
+const (
+	// PhaseWarmup 热身，可以移动和攻击但击杀不计分
+	PhaseWarmup MatchPhase = "warmup"
+	// PhaseCountdown 热身结束后重置战绩并倒计时，玩家不能行动
+	PhaseCountdown MatchPhase = "countdown"
+	// PhaseLive 正式对局
+	PhaseLive MatchPhase = "live"
+	// PhaseOvertime 对局时间到时比分持平，进入加时
+	PhaseOvertime MatchPhase = "overtime"
+)
+
+// Scoring 该阶段的战绩是否计入对局结果
+func (p MatchPhase) Scoring() bool {
+	return p == PhaseLive || p == PhaseOvertime
+}
+
+// MatchPhaseInfo 对局阶段变化通知
+type MatchPhaseInfo struct {
+	Phase    MatchPhase   `json:"phase"`
+	EndsAt   time.Time    `json:"ends_at,omitempty"`  // 热身、倒计时或限时加时的结束时间，正式对局为空
+	Overtime OvertimeMode `json:"overtime,omitempty"` // 加时方式，只在进入加时时设置
+}
+
+// MatchStatus 对局进行中定期发送的比分和剩余时间，客户端据此渲染HUD，无需解析完整的游戏帧
+type MatchStatus struct {
+	Phase         MatchPhase    `json:"phase"`
+	RemainingTime int           `json:"remaining_time"` // 当前阶段剩余时间(秒)，不限时的突然死亡为0
+	ScoreLimit    int           `json:"score_limit"`
+	TeamScores    map[Team]int  `json:"team_scores,omitempty"` // 团队模式各队伍总分
+	Scores        map[int64]int `json:"scores"`                // 玩家ID -> 分数
+}
+
+// MatchPoint 赛点通知，队伍（团队模式）或玩家（个人模式）再得1分即达到分数限制
+type MatchPoint struct {
+	Team     Team  `json:"team,omitempty"`
+	PlayerID int64 `json:"player_id,omitempty"`
+	Score    int   `json:"score"` // 队伍总分或个人得分
+}
+
 // Team 队伍
 type Team int
 
@@ -50,6 +106,7 @@ type Room struct {
 	Name       string     `json:"name"`
 	Mode       GameMode   `json:"mode"`
 	Status     RoomStatus `json:"status"`
+	Phase      MatchPhase `json:"phase,omitempty"` // 对局进行中的阶段，等待中为空
 	MaxPlayers int        `json:"max_players"`
 	CreatedAt  time.Time  `json:"created_at"`
 	StartedAt  time.Time  `json:"started_at,omitempty"`
@@ -60,6 +117,7 @@ type Room struct {
 	TimeLimit    int    `json:"time_limit"`    // 时间限制(秒)
 	ScoreLimit   int    `json:"score_limit"`   // 分数限制
 	FriendlyFire bool   `json:"friendly_fire"` // 友军伤害
+	BodyBlocking bool   `json:"body_blocking"` // 玩家碰撞
 	PrivateRoom  bool   `json:"private_room"`  // 私人房间
 	Password     string `json:"-"`             // 房间密码
 
@@ -71,9 +129,13 @@ type Room struct {
 type RoomPlayer struct {
 	PlayerID    int64  `json:"player_id"`
 	Username    string `json:"username"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	Title       string `json:"title,omitempty"` // 装备的称号名称
 	CharacterID int    `json:"character_id"`
+	SkinID      int    `json:"skin_id,omitempty"` // 装备的皮肤道具ID
 	Team        Team   `json:"team"`
 	Ready       bool   `json:"ready"`
+	Ping        int64  `json:"ping,omitempty"` // 往返延迟(毫秒)，尚未测得时省略
 
 	// 游戏中数据
 	Score   int `json:"score"`
@@ -82,6 +144,54 @@ type RoomPlayer struct {
 	Assists int `json:"assists"`
 }
 
+// RoomEventType 房间历史事件类型
+type RoomEventType string
+
+const (
+	// RoomEventChat 聊天消息
+	RoomEventChat RoomEventType = "chat"
+	// RoomEventKill 击杀及击杀后的得分
+	RoomEventKill RoomEventType = "kill"
+)
+
+// KillEvent 击杀事件
+type KillEvent struct {
+	KillerID    int64      `json:"killer_id"` // 死于地图危险区域且没有最近攻击者时为0
+	VictimID    int64      `json:"victim_id"`
+	KillerScore int        `json:"killer_score"`     // 击杀后击杀者的得分
+	Hazard      HazardType `json:"hazard,omitempty"` // 死于地图危险区域时的区域类型
+}
+
+// RoomEvent 房间历史事件，按类型只有一个字段有值
+type RoomEvent struct {
+	Type RoomEventType `json:"type"`
+	Chat *ChatMessage  `json:"chat,omitempty"`
+	Kill *KillEvent    `json:"kill,omitempty"`
+	At   time.Time     `json:"at"`
+}
+
+// RoomMetrics 房间运行指标（管理接口）
+type RoomMetrics struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Mode       GameMode   `json:"mode"`
+	Status     RoomStatus `json:"status"`
+	Players    int        `json:"players"`
+	MaxPlayers int        `json:"max_players"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// 往返延迟(毫秒)，仅统计已测得延迟的玩家
+	MeasuredPlayers int   `json:"measured_players"`
+	AvgRTT          int64 `json:"avg_rtt_ms"`
+	MaxRTT          int64 `json:"max_rtt_ms"`
+}
+
+// RoomDetail 房间详情（管理接口），包含房间内玩家及其得分
+type RoomDetail struct {
+	Metrics RoomMetrics `json:"metrics"`
+	Room    *Room       `json:"room"`
+}
+
 // GameMap 游戏地图
 type GameMap struct {
 	ID          int    `json:"id"`
@@ -94,6 +204,47 @@ type GameMap struct {
 	Height         int        `json:"height"`
 	MaxPlayers     int        `json:"max_players"`
 	SupportedModes []GameMode `json:"supported_modes"`
+
+	// 危险区域
+	Hazards []MapHazard `json:"hazards,omitempty"`
+}
+
+// HazardType 地图危险区域类型
+type HazardType string
+
+const (
+	// HazardLava 熔岩，持续造成伤害
+	HazardLava HazardType = "lava"
+	// HazardSpikes 尖刺，持续造成伤害
+	HazardSpikes HazardType = "spikes"
+	// HazardOutOfBounds 场外区域，进入即死亡
+	HazardOutOfBounds HazardType = "out_of_bounds"
+)
+
+// IsValid 检查危险区域类型是否有效
+func (t HazardType) IsValid() bool {
+	switch t {
+	case HazardLava, HazardSpikes, HazardOutOfBounds:
+		return true
+	}
+	return false
+}
+
+// MapHazard 地图上的矩形危险区域，Lethal 为 true 时进入即死亡，否则每隔 Interval 秒对区域内的玩家造成 Damage 点伤害
+type MapHazard struct {
+	Type     HazardType `json:"type"`
+	X        float64    `json:"x"` // 左上角坐标
+	Y        float64    `json:"y"`
+	Width    float64    `json:"width"`
+	Height   float64    `json:"height"`
+	Damage   int        `json:"damage,omitempty"`
+	Interval float64    `json:"interval,omitempty"`
+	Lethal   bool       `json:"lethal,omitempty"`
+}
+
+// Contains 检查坐标是否在危险区域内
+func (h MapHazard) Contains(pos Vector2D) bool {
+	return pos.X >= h.X && pos.X <= h.X+h.Width && pos.Y >= h.Y && pos.Y <= h.Y+h.Height
 }
 
 // 注意：表结构定义已移至 pkg/db/schema.go 统一管理
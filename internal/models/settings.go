@@ -0,0 +1,22 @@
+// settings.go
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PlayerSettings 玩家云端设置（灵敏度、键位、界面选项等）
+type PlayerSettings struct {
+	PlayerID  int64           `json:"player_id"`
+	Settings  json.RawMessage `json:"settings"`
+	Version   int             `json:"version"` // 每次保存递增，0表示尚未保存过
+	UpdatedAt *time.Time      `json:"updated_at,omitempty"`
+}
+
+// SaveSettingsRequest 保存设置请求
+type SaveSettingsRequest struct {
+	Version  int             `json:"version"` // 客户端持有的版本号，与服务端不一致时拒绝保存
+	Settings json.RawMessage `json:"settings"`
+}
@@ -0,0 +1,111 @@
+// shop.go
+
+package models
+
+import (
+	"time"
+)
+
+// ItemType 道具类型
+type ItemType string
+
+const (
+	// ItemCharacter 角色（发放到 player_characters）
+	ItemCharacter ItemType = "character"
+	// ItemCosmetic 外观
+	ItemCosmetic ItemType = "cosmetic"
+	// ItemConsumable 消耗品
+	ItemConsumable ItemType = "consumable"
+)
+
+// IsValid 检查道具类型是否有效
+func (t ItemType) IsValid() bool {
+	return t == ItemCharacter || t == ItemCosmetic || t == ItemConsumable
+}
+
+// Item 道具定义
+type Item struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Type        ItemType  `json:"type"`
+	RefID       int       `json:"ref_id,omitempty"` // 关联角色ID：角色道具为解锁的角色，外观为适用的角色（0表示通用）
+	Stackable   bool      `json:"stackable"`        // 是否可叠加持有
+	CreatedAt   time.Time `json:"created_at"`
+
+	// 外观需要的角色等级，0表示不限制
+	RequiredCharacterLevel int `json:"required_character_level,omitempty"`
+}
+
+// ShopOffer 商店上架条目
+type ShopOffer struct {
+	ID             int        `json:"id"`
+	ItemID         int        `json:"item_id"`
+	Item           *Item      `json:"item,omitempty"`
+	Quantity       int        `json:"quantity"` // 每次购买获得的数量
+	Currency       Currency   `json:"currency"`
+	Price          int64      `json:"price"`
+	OriginalPrice  int64      `json:"original_price,omitempty"` // 限时活动折扣前的价格，没有折扣时为空
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+	PurchaseLimit  int        `json:"purchase_limit"` // 每位玩家限购次数，0为不限
+	SortOrder      int        `json:"sort_order"`
+	Enabled        bool       `json:"enabled"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ApplyDiscount 按折扣百分比调整价格并保留原价，折扣后至少为1
+func (o *ShopOffer) ApplyDiscount(discount int) {
+	if discount <= 0 {
+		return
+	}
+	o.OriginalPrice = o.Price
+	o.Price = max(o.Price*int64(100-discount)/100, 1)
+}
+
+// IsAvailable 检查条目在指定时间是否可购买
+func (o *ShopOffer) IsAvailable(now time.Time) bool {
+	if !o.Enabled {
+		return false
+	}
+	if o.AvailableFrom != nil && now.Before(*o.AvailableFrom) {
+		return false
+	}
+	if o.AvailableUntil != nil && !now.Before(*o.AvailableUntil) {
+		return false
+	}
+	return true
+}
+
+// InventoryItem 玩家背包中的道具
+type InventoryItem struct {
+	PlayerID   int64     `json:"player_id"`
+	ItemID     int       `json:"item_id"`
+	Item       *Item     `json:"item,omitempty"`
+	Quantity   int       `json:"quantity"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ShopPurchase 商店购买记录
+type ShopPurchase struct {
+	ID            int64     `json:"id"`
+	PlayerID      int64     `json:"player_id"`
+	OfferID       int       `json:"offer_id"`
+	ItemID        int       `json:"item_id"`
+	Quantity      int       `json:"quantity"`
+	Currency      Currency  `json:"currency"`
+	Price         int64     `json:"price"`
+	TransactionID int64     `json:"transaction_id"`
+	BalanceAfter  int64     `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// EquippedSkin 角色当前装备的皮肤
+type EquippedSkin struct {
+	CharacterID int       `json:"character_id"`
+	ItemID      int       `json:"item_id"`
+	Item        *Item     `json:"item,omitempty"`
+	EquippedAt  time.Time `json:"equipped_at"`
+}
@@ -31,23 +31,27 @@ type Skill struct {
 	Damage       int     `json:"damage"`
 	CooldownTime float64 `json:"cooldown_time"` // 冷却时间(秒)
 	Range        float64 `json:"range"`         // 射程/范围
-	EffectTime   float64 `json:"effect_time"`   // 效果持续时间(秒)
+	EffectTime   float64 `json:"effect_time"`   // 效果持续时间(秒)，位移技能为位移后无法选中的时间
 
 	// 投射物属性
 	ProjectileSpeed  float64 `json:"projectile_speed,omitempty"`
 	ProjectileCount  int     `json:"projectile_count,omitempty"`
-	ProjectileSpread float64 `json:"projectile_spread,omitempty"` // 散射角度
+	ProjectileSpread float64 `json:"projectile_spread,omitempty"` // 多发投射物的扇形总角度(度)
 
 	// 视觉效果
 	AnimationKey string `json:"animation_key"`
 	EffectKey    string `json:"effect_key"`
+
+	// 解锁该技能槽所需的角色等级
+	RequiredLevel int `json:"required_level"`
 }
 
 // CharacterSkill 角色技能关联
 type CharacterSkill struct {
-	CharacterID int `json:"character_id"`
-	SkillID     int `json:"skill_id"`
-	SlotIndex   int `json:"slot_index"` // 技能槽位置
+	CharacterID   int `json:"character_id"`
+	SkillID       int `json:"skill_id"`
+	SlotIndex     int `json:"slot_index"`     // 技能槽位置
+	RequiredLevel int `json:"required_level"` // 解锁所需角色等级
 }
 
 // 注意：表结构定义已移至 pkg/db/schema.go 统一管理
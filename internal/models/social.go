@@ -0,0 +1,21 @@
+// social.go
+
+package models
+
+import (
+	"time"
+)
+
+// BlockedPlayer 被屏蔽的玩家
+type BlockedPlayer struct {
+	PlayerID  int64     `json:"player_id"`
+	Username  string    `json:"username"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// ChatMessage 房间聊天消息
+type ChatMessage struct {
+	PlayerID int64     `json:"player_id"`
+	Text     string    `json:"text"`
+	SentAt   time.Time `json:"sent_at"`
+}
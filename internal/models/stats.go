@@ -30,11 +30,50 @@ type PlayerMatchRecord struct {
 	ExpGained   int       `json:"exp_gained"`
 	CoinsGained int       `json:"coins_gained"`
 	MVP         bool      `json:"mvp"`        // 是否为MVP
+	Won         bool      `json:"won"`        // 是否获胜
 	PlayTime    int       `json:"play_time"`  // 游戏时长(秒)
 	JoinTime    time.Time `json:"join_time"`  // 加入时间
 	LeaveTime   time.Time `json:"leave_time"` // 离开时间
 }
 
+// MatchResult 对局结果（对局结束时由游戏房间生成）
+type MatchResult struct {
+	Match          MatchRecord         `json:"match"`
+	Players        []PlayerMatchRecord `json:"players"`
+	PositionEvents []PositionEvent     `json:"position_events,omitempty"`
+	Highlights     []Highlight         `json:"highlights,omitempty"`
+}
+
+// PositionEventType 位置事件类型
+type PositionEventType string
+
+const (
+	// PositionEventKill 击杀（击杀者所在位置）
+	PositionEventKill PositionEventType = "kill"
+	// PositionEventDeath 死亡（被击杀者所在位置）
+	PositionEventDeath PositionEventType = "death"
+)
+
+// PositionEvent 对局中的位置事件，用于热力图
+type PositionEvent struct {
+	Type          PositionEventType `json:"type"`
+	PlayerID      int64             `json:"player_id"`
+	OtherPlayerID int64             `json:"other_player_id"` // 击杀事件为被击杀者，死亡事件为击杀者
+	CharacterID   int               `json:"character_id"`
+	X             float64           `json:"x"`
+	Y             float64           `json:"y"`
+	Elapsed       float64           `json:"elapsed"` // 距对局开始的秒数
+}
+
+// MatchHeatmap 对局热力图数据
+type MatchHeatmap struct {
+	MatchID   string          `json:"match_id"`
+	MapID     int             `json:"map_id"`
+	MapWidth  int             `json:"map_width"`
+	MapHeight int             `json:"map_height"`
+	Events    []PositionEvent `json:"events"`
+}
+
 // PlayerStats 玩家战绩统计
 type PlayerStats struct {
 	PlayerID     int64   `json:"player_id"`
@@ -51,10 +90,98 @@ type PlayerStats struct {
 	PlayTime     int     `json:"play_time"`     // 总游戏时长(秒)
 }
 
+// PlayerCharacterStats 玩家单个角色的战绩
+type PlayerCharacterStats struct {
+	CharacterID   int        `json:"character_id"`
+	CharacterName string     `json:"character_name"`
+	Level         int        `json:"level"`
+	Games         int        `json:"games"`
+	Wins          int        `json:"wins"`
+	WinRate       float64    `json:"win_rate"`
+	Kills         int        `json:"kills"`
+	Deaths        int        `json:"deaths"`
+	Assists       int        `json:"assists"`
+	KDA           float64    `json:"kda"`       // (击杀+助攻)/死亡
+	PlayTime      int        `json:"play_time"` // 游戏时长(秒)
+	LastPlayedAt  *time.Time `json:"last_played_at,omitempty"`
+}
+
+// StatsWindow 战绩统计时间窗口
+type StatsWindow string
+
+const (
+	// StatsWindowDaily 今日
+	StatsWindowDaily StatsWindow = "daily"
+	// StatsWindowWeekly 本周
+	StatsWindowWeekly StatsWindow = "weekly"
+	// StatsWindowMonthly 本月
+	StatsWindowMonthly StatsWindow = "monthly"
+)
+
+// StatsWindowTrunc 时间窗口对应的 date_trunc 精度
+var StatsWindowTrunc = map[StatsWindow]string{
+	StatsWindowDaily:   "day",
+	StatsWindowWeekly:  "week",
+	StatsWindowMonthly: "month",
+}
+
+// PlayerWindowStats 玩家时间窗口战绩
+type PlayerWindowStats struct {
+	PlayerID     int64       `json:"player_id"`
+	Window       StatsWindow `json:"window"`
+	WindowStart  time.Time   `json:"window_start"`
+	TotalMatches int         `json:"total_matches"`
+	TotalWins    int         `json:"total_wins"`
+	WinRate      float64     `json:"win_rate"`
+	TotalKills   int         `json:"total_kills"`
+	TotalDeaths  int         `json:"total_deaths"`
+	TotalAssists int         `json:"total_assists"`
+	KDA          float64     `json:"kda"`
+	TotalScore   int         `json:"total_score"`
+	TotalMVP     int         `json:"total_mvp"`
+	PlayTime     int         `json:"play_time"`    // 游戏时长(秒)
+	RefreshedAt  time.Time   `json:"refreshed_at"` // 汇总数据刷新时间
+}
+
+// CharacterAnalytics 角色使用与胜率分析
+type CharacterAnalytics struct {
+	CharacterID   int     `json:"character_id"`
+	CharacterName string  `json:"character_name"`
+	Picks         int     `json:"picks"`     // 被选用次数
+	PickRate      float64 `json:"pick_rate"` // 选取率(百分比)
+	Wins          int     `json:"wins"`
+	WinRate       float64 `json:"win_rate"` // 胜率(百分比)
+	AverageKills  float64 `json:"average_kills"`
+	AverageDeaths float64 `json:"average_deaths"`
+	AverageAssist float64 `json:"average_assists"`
+	AverageKDA    float64 `json:"average_kda"` // (击杀+助攻)/死亡
+	AverageScore  float64 `json:"average_score"`
+}
+
+// MapAnalytics 地图对局数据分析（按地图和模式汇总）
+type MapAnalytics struct {
+	MapID           int            `json:"map_id"`
+	MapName         string         `json:"map_name"`
+	GameMode        GameMode       `json:"game_mode"`
+	Matches         int            `json:"matches"`
+	AverageDuration float64        `json:"average_duration"` // 平均对局时长(秒)
+	Draws           int            `json:"draws"`            // 平局或无胜方的对局数
+	DrawRate        float64        `json:"draw_rate"`        // 平局率(百分比)
+	Teams           []TeamWinStats `json:"teams,omitempty"`  // 团队模式下各队伍胜率
+}
+
+// TeamWinStats 队伍胜场统计
+type TeamWinStats struct {
+	Team    Team    `json:"team"`
+	Wins    int     `json:"wins"`
+	WinRate float64 `json:"win_rate"` // 胜率(百分比)
+}
+
 // LeaderboardEntry 排行榜条目
 type LeaderboardEntry struct {
 	PlayerID   int64   `json:"player_id"`
 	Username   string  `json:"username"`
+	AvatarURL  string  `json:"avatar_url"`
 	Level      int     `json:"level"`
 	TotalKills int     `json:"total_kills"`
 	TotalWins  int     `json:"total_wins"`
@@ -0,0 +1,84 @@
+// title.go
+
+package models
+
+import (
+	"time"
+)
+
+// TitleKind 称号类型
+type TitleKind string
+
+const (
+	// TitleKindTitle 称号，显示在名字旁，同时只能装备一个
+	TitleKindTitle TitleKind = "title"
+	// TitleKindBadge 徽章，可同时展示多个
+	TitleKindBadge TitleKind = "badge"
+)
+
+// IsValid 检查称号类型是否有效
+func (k TitleKind) IsValid() bool {
+	return k == TitleKindTitle || k == TitleKindBadge
+}
+
+// TitleSource 称号获取途径
+type TitleSource string
+
+const (
+	// TitleSourceAchievement 成就
+	TitleSourceAchievement TitleSource = "achievement"
+	// TitleSourceSeason 赛季奖励
+	TitleSourceSeason TitleSource = "season"
+	// TitleSourceTournament 赛事奖励
+	TitleSourceTournament TitleSource = "tournament"
+	// TitleSourceEvent 运营活动
+	TitleSourceEvent TitleSource = "event"
+)
+
+// IsValid 检查获取途径是否有效
+func (s TitleSource) IsValid() bool {
+	switch s {
+	case TitleSourceAchievement, TitleSourceSeason, TitleSourceTournament, TitleSourceEvent:
+		return true
+	}
+	return false
+}
+
+// Title 称号或徽章定义
+type Title struct {
+	ID          int         `json:"id"`
+	Code        string      `json:"code"` // 唯一标识，供其他系统发放时引用
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Kind        TitleKind   `json:"kind"`
+	Source      TitleSource `json:"source"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// PlayerTitle 玩家获得的称号
+type PlayerTitle struct {
+	Title
+	SourceRef string    `json:"source_ref,omitempty"` // 获取来源，如赛季ID、赛事ID
+	Equipped  bool      `json:"equipped"`
+	EarnedAt  time.Time `json:"earned_at"`
+}
+
+// PlayerTitles 玩家称号列表及当前装备
+type PlayerTitles struct {
+	Owned  []PlayerTitle `json:"owned"`
+	Title  *Title        `json:"title,omitempty"` // 当前装备的称号
+	Badges []Title       `json:"badges"`          // 当前展示的徽章
+}
+
+// EquipTitlesRequest 装备称号和徽章请求
+type EquipTitlesRequest struct {
+	TitleID  int   `json:"title_id"`  // 0表示卸下称号
+	BadgeIDs []int `json:"badge_ids"` // 展示的徽章，按顺序
+}
+
+// AwardTitleRequest 管理员发放称号请求
+type AwardTitleRequest struct {
+	TitleID   int     `json:"title_id"`
+	PlayerIDs []int64 `json:"player_ids"`
+	SourceRef string  `json:"source_ref,omitempty"`
+}
@@ -0,0 +1,37 @@
+// trial.go
+
+package models
+
+import (
+	"time"
+)
+
+// TrialSource 角色试玩来源
+type TrialSource string
+
+const (
+	// TrialRotation 每周免费轮换
+	TrialRotation TrialSource = "rotation"
+	// TrialRental 宝石租用
+	TrialRental TrialSource = "rental"
+)
+
+// CharacterTrial 玩家可限时使用的未解锁角色
+type CharacterTrial struct {
+	CharacterID int         `json:"character_id"`
+	Source      TrialSource `json:"source"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+}
+
+// CharacterRotation 本周免费轮换角色
+type CharacterRotation struct {
+	CharacterIDs []int     `json:"character_ids"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+}
+
+// RentCharacterRequest 租用角色请求
+type RentCharacterRequest struct {
+	RequestID   string `json:"request_id"` // 客户端生成的请求ID，重复提交只扣费一次
+	CharacterID int    `json:"character_id"`
+}
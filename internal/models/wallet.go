@@ -0,0 +1,97 @@
+// wallet.go
+
+package models
+
+import (
+	"time"
+)
+
+// Currency 货币类型
+type Currency string
+
+const (
+	// CurrencyCoins 金币
+	CurrencyCoins Currency = "coins"
+	// CurrencyGems 宝石
+	CurrencyGems Currency = "gems"
+)
+
+// IsValid 检查货币类型是否有效
+func (c Currency) IsValid() bool {
+	return c == CurrencyCoins || c == CurrencyGems
+}
+
+// TransactionReason 货币变动原因
+type TransactionReason string
+
+const (
+	// ReasonMatchReward 对局奖励
+	ReasonMatchReward TransactionReason = "match_reward"
+	// ReasonPurchase 购买消费
+	ReasonPurchase TransactionReason = "purchase"
+	// ReasonGrant 管理员发放
+	ReasonGrant TransactionReason = "grant"
+	// ReasonRefund 退款
+	ReasonRefund TransactionReason = "refund"
+	// ReasonBattlePass 通行证奖励
+	ReasonBattlePass TransactionReason = "battle_pass"
+	// ReasonQuestReward 任务奖励
+	ReasonQuestReward TransactionReason = "quest_reward"
+	// ReasonMailAttachment 邮件附件
+	ReasonMailAttachment TransactionReason = "mail_attachment"
+	// ReasonGiftSent 赠送礼物
+	ReasonGiftSent TransactionReason = "gift_sent"
+	// ReasonGiftReceived 收到礼物
+	ReasonGiftReceived TransactionReason = "gift_received"
+	// ReasonIAPPurchase 应用内购买
+	ReasonIAPPurchase TransactionReason = "iap_purchase"
+	// ReasonExchange 货币兑换
+	ReasonExchange TransactionReason = "exchange"
+	// ReasonCharacterRental 租用角色
+	ReasonCharacterRental TransactionReason = "character_rental"
+)
+
+// CurrencyTransaction 货币交易流水
+type CurrencyTransaction struct {
+	ID             int64             `json:"id"`
+	PlayerID       int64             `json:"player_id"`
+	Currency       Currency          `json:"currency"`
+	Amount         int64             `json:"amount"`        // 正数为收入，负数为支出
+	BalanceAfter   int64             `json:"balance_after"` // 交易后余额
+	Reason         TransactionReason `json:"reason"`
+	ReferenceID    string            `json:"reference_id,omitempty"` // 关联业务ID，如对局ID、订单ID
+	IdempotencyKey string            `json:"idempotency_key"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// TransactionRequest 货币变动请求
+type TransactionRequest struct {
+	PlayerID       int64             `json:"player_id"`
+	Currency       Currency          `json:"currency"`
+	Amount         int64             `json:"amount"`
+	Reason         TransactionReason `json:"reason"`
+	ReferenceID    string            `json:"reference_id,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key"` // 相同键的请求只会执行一次
+}
+
+// ExchangeRequest 宝石兑换金币请求
+type ExchangeRequest struct {
+	RequestID string `json:"request_id"` // 客户端生成的请求ID，重复提交只兑换一次
+	Gems      int64  `json:"gems"`
+}
+
+// ExchangeRates 兑换比例和今日剩余额度
+type ExchangeRates struct {
+	CoinsPerGem    int64 `json:"coins_per_gem"`
+	MinGems        int64 `json:"min_gems"`
+	DailyGemLimit  int64 `json:"daily_gem_limit,omitempty"` // 0为不限
+	DailyRemaining int64 `json:"daily_remaining,omitempty"` // 今日还可兑换的宝石数，不限时省略
+}
+
+// ExchangeResult 兑换结果，包含双方货币的流水
+type ExchangeResult struct {
+	Gems             int64                `json:"gems"`
+	Coins            int64                `json:"coins"`
+	GemsTransaction  *CurrencyTransaction `json:"gems_transaction"`
+	CoinsTransaction *CurrencyTransaction `json:"coins_transaction"`
+}
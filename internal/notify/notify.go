@@ -0,0 +1,158 @@
+// notify.go
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// Channel 玩家通知的 Redis 频道，各网关实例订阅后投递给本实例上连接的玩家
+const Channel = "notify:player"
+
+// subscriptionBuffer 每个订阅缓冲的通知数，客户端读取过慢时丢弃新通知
+const subscriptionBuffer = 16
+
+var deliveredTotal = metrics.Default.NewCounterVec("pixelstorm_notifications_delivered_total",
+	"投递给在线订阅的玩家通知数", "category", "status")
+
+// message 通过 Redis 频道转发的通知
+type message struct {
+	PlayerID     int64                    `json:"player_id"`
+	Notification *models.PushNotification `json:"notification"`
+}
+
+// Hub 在线通知中心，按玩家管理订阅（如 SSE 连接）。
+// 连接Redis时通知经频道转发到所有实例，否则只投递给本进程内的订阅
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[int64]map[*Subscription]struct{}
+}
+
+// Subscription 玩家的一个通知订阅
+type Subscription struct {
+	C <-chan *models.PushNotification
+
+	hub      *Hub
+	playerID int64
+	ch       chan *models.PushNotification
+	once     sync.Once
+}
+
+// Default 全局通知中心
+var Default = NewHub()
+
+// NewHub 创建通知中心
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int64]map[*Subscription]struct{})}
+}
+
+// Publish 向全局通知中心发布通知
+func Publish(playerID int64, n *models.PushNotification) {
+	Default.Publish(playerID, n)
+}
+
+// Subscribe 订阅玩家的通知，不再需要时应调用 Close
+func (h *Hub) Subscribe(playerID int64) *Subscription {
+	ch := make(chan *models.PushNotification, subscriptionBuffer)
+	sub := &Subscription{C: ch, hub: h, playerID: playerID, ch: ch}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[playerID] == nil {
+		h.subs[playerID] = make(map[*Subscription]struct{})
+	}
+	h.subs[playerID][sub] = struct{}{}
+	return sub
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		h := s.hub
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subs[s.playerID], s)
+		if len(h.subs[s.playerID]) == 0 {
+			delete(h.subs, s.playerID)
+		}
+	})
+}
+
+// Count 当前的订阅数
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	n := 0
+	for _, subs := range h.subs {
+		n += len(subs)
+	}
+	return n
+}
+
+// Publish 发布通知。连接Redis时经频道转发，由订阅了频道的实例投递，失败只记录日志
+func (h *Hub) Publish(playerID int64, n *models.PushNotification) {
+	if db.RedisClient == nil {
+		h.deliver(playerID, n)
+		return
+	}
+
+	data, err := json.Marshal(message{PlayerID: playerID, Notification: n})
+	if err != nil {
+		log.Printf("序列化玩家 %d 的通知失败: %v", playerID, err)
+		return
+	}
+	if err := db.RedisClient.Publish(context.Background(), Channel, data).Err(); err != nil {
+		log.Printf("发布玩家 %d 的通知失败: %v", playerID, err)
+	}
+}
+
+// Listen 订阅 Redis 频道并投递给本实例的订阅，直到 stop 关闭。未连接Redis时不做任何事
+func (h *Hub) Listen(stop <-chan struct{}) {
+	if db.RedisClient == nil {
+		return
+	}
+	pubsub := db.RedisClient.Subscribe(context.Background(), Channel)
+
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var m message
+				if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Notification == nil {
+					log.Printf("解析玩家通知失败: %v", err)
+					continue
+				}
+				h.deliver(m.PlayerID, m.Notification)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// deliver 投递给本进程内玩家的所有订阅，订阅缓冲已满时丢弃
+func (h *Hub) deliver(playerID int64, n *models.PushNotification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs[playerID] {
+		select {
+		case sub.ch <- n:
+			deliveredTotal.Inc(string(n.Category), "ok")
+		default:
+			deliveredTotal.Inc(string(n.Category), "dropped")
+		}
+	}
+}
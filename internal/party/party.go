@@ -0,0 +1,569 @@
+// party.go
+
+package party
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/social"
+	"github.com/jacl-coder/PixelStorm-Server/internal/trial"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 默认组队配置
+const (
+	defaultMaxSize     = 4
+	defaultInviteTTL   = 5 * time.Minute
+	defaultChatHistory = 50
+	// maxChatLength 聊天消息最大字符数，与房间聊天一致
+	maxChatLength = 200
+)
+
+var (
+	// ErrNotInParty 玩家不在队伍中
+	ErrNotInParty = errcode.New(errcode.NotInParty, "不在队伍中")
+	// ErrAlreadyInParty 玩家已在队伍中
+	ErrAlreadyInParty = errcode.New(errcode.AlreadyInParty, "已在队伍中，请先离开当前队伍")
+	// ErrNotLeader 只有队长可以执行该操作
+	ErrNotLeader = errcode.New(errcode.NotPartyLeader, "只有队长可以执行该操作")
+	// ErrPartyFull 队伍已满
+	ErrPartyFull = errcode.New(errcode.PartyFull, "队伍已满")
+	// ErrInviteNotFound 邀请不存在或已过期
+	ErrInviteNotFound = errcode.New(errcode.NotFound, "邀请不存在或已过期")
+	// ErrPartyQueued 队伍匹配中
+	ErrPartyQueued = errcode.New(errcode.AlreadyQueued, "队伍正在匹配中，请先取消匹配")
+	// ErrNotReady 有成员未选择角色
+	ErrNotReady = errcode.New(errcode.PartyNotReady, "有队伍成员尚未选择角色")
+	// ErrInvalidRequest 请求参数无效
+	ErrInvalidRequest = errcode.New(errcode.BadRequest, "无效的组队请求")
+	// ErrCharacterNotOwned 未拥有该角色且不在试玩期内
+	ErrCharacterNotOwned = errcode.New(errcode.CharacterNotOwned, "未拥有该角色")
+)
+
+// Queuer 将队伍提交到匹配服务
+type Queuer interface {
+	QueueParty(req *models.PartyQueueRequest) error
+	CancelParty(partyID string, mode models.GameMode) error
+}
+
+// Notifier 向离线或不在界面上的玩家发送组队通知（如推送）
+type Notifier interface {
+	NotifyPartyInvite(invite *models.PartyInvite)
+}
+
+// party 队伍内部状态
+type party struct {
+	models.Party
+	messages []models.PartyMessage
+	nextSeq  int64
+}
+
+// Service 组队服务，队伍只在对局外存在，保存在内存中
+type Service struct {
+	mu      sync.Mutex
+	parties map[string]*party
+	members map[int64]string                // 玩家ID -> 队伍ID
+	invites map[int64][]*models.PartyInvite // 被邀请玩家ID -> 邀请
+
+	cfg      config.PartyConfig
+	queuer   Queuer
+	trials   *trial.Service
+	notifier Notifier
+}
+
+// NewService 创建组队服务，未配置的参数使用默认值，trials 用于检查成员能否使用所选角色
+func NewService(cfg config.PartyConfig, queuer Queuer, trials *trial.Service) *Service {
+	if cfg.MaxSize <= 1 {
+		cfg.MaxSize = defaultMaxSize
+	}
+	if cfg.InviteTTL <= 0 {
+		cfg.InviteTTL = defaultInviteTTL
+	}
+	if cfg.ChatHistory <= 0 {
+		cfg.ChatHistory = defaultChatHistory
+	}
+	return &Service{
+		parties: make(map[string]*party),
+		members: make(map[int64]string),
+		invites: make(map[int64][]*models.PartyInvite),
+		cfg:     cfg,
+		queuer:  queuer,
+		trials:  trials,
+	}
+}
+
+// SetNotifier 设置组队邀请通知方式
+func (s *Service) SetNotifier(n Notifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notifier = n
+}
+
+// Create 创建队伍，创建者成为队长
+func (s *Service) Create(leaderID int64) (*models.Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[leaderID]; ok {
+		return nil, ErrAlreadyInParty
+	}
+
+	now := time.Now()
+	p := &party{Party: models.Party{
+		ID:        uuid.New().String(),
+		LeaderID:  leaderID,
+		Members:   []models.PartyMember{{PlayerID: leaderID, JoinedAt: now}},
+		MaxSize:   s.cfg.MaxSize,
+		Status:    models.PartyIdle,
+		CreatedAt: now,
+	}}
+	s.parties[p.ID] = p
+	s.members[leaderID] = p.ID
+	return p.snapshot(), nil
+}
+
+// Get 查询玩家所在的队伍
+func (s *Service) Get(playerID int64) (*models.Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil, err
+	}
+	return p.snapshot(), nil
+}
+
+// Invite 邀请玩家加入队伍，任一成员都可以邀请
+func (s *Service) Invite(fromID, toID int64) (*models.PartyInvite, error) {
+	if fromID == toID || toID <= 0 {
+		return nil, fmt.Errorf("%w: 不能邀请自己", ErrInvalidRequest)
+	}
+	if err := social.CheckInvite(fromID, toID); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	p, err := s.partyOf(fromID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if p.hasMember(toID) {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: 玩家已在队伍中", ErrInvalidRequest)
+	}
+	if len(p.Members) >= p.MaxSize {
+		s.mu.Unlock()
+		return nil, ErrPartyFull
+	}
+
+	now := time.Now()
+	invite := &models.PartyInvite{
+		PartyID:   p.ID,
+		FromID:    fromID,
+		ToID:      toID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.InviteTTL),
+	}
+	// 同一队伍的重复邀请只保留最新一条
+	pending := s.pendingInvites(toID)
+	kept := pending[:0]
+	for _, inv := range pending {
+		if inv.PartyID != p.ID {
+			kept = append(kept, inv)
+		}
+	}
+	s.invites[toID] = append(kept, invite)
+	notifier := s.notifier
+	s.mu.Unlock()
+
+	if notifier != nil {
+		notifier.NotifyPartyInvite(invite)
+	}
+	return invite, nil
+}
+
+// Invites 查询玩家收到的未过期邀请
+func (s *Service) Invites(playerID int64) []models.PartyInvite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := s.pendingInvites(playerID)
+	result := make([]models.PartyInvite, 0, len(pending))
+	for _, inv := range pending {
+		result = append(result, *inv)
+	}
+	return result
+}
+
+// Accept 接受邀请加入队伍
+func (s *Service) Accept(playerID int64, partyID string) (*models.Party, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[playerID]; ok {
+		return nil, ErrAlreadyInParty
+	}
+	if !s.takeInvite(playerID, partyID) {
+		return nil, ErrInviteNotFound
+	}
+	p, ok := s.parties[partyID]
+	if !ok {
+		return nil, ErrInviteNotFound
+	}
+	if p.Status == models.PartyQueued {
+		return nil, ErrPartyQueued
+	}
+	if len(p.Members) >= p.MaxSize {
+		return nil, ErrPartyFull
+	}
+
+	p.Members = append(p.Members, models.PartyMember{PlayerID: playerID, JoinedAt: time.Now()})
+	s.members[playerID] = p.ID
+	return p.snapshot(), nil
+}
+
+// Decline 拒绝邀请
+func (s *Service) Decline(playerID int64, partyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.takeInvite(playerID, partyID) {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// Leave 离开队伍，队长离开时由最早加入的成员接任，最后一人离开时解散队伍。
+// 匹配中的队伍会取消匹配
+func (s *Service) Leave(playerID int64) error {
+	s.mu.Lock()
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	mode := s.removeMember(p, playerID)
+	s.mu.Unlock()
+
+	s.cancelQueue(p.ID, mode)
+	return nil
+}
+
+// Kick 队长将成员移出队伍
+func (s *Service) Kick(leaderID, memberID int64) error {
+	if leaderID == memberID {
+		return fmt.Errorf("%w: 不能移出自己", ErrInvalidRequest)
+	}
+
+	s.mu.Lock()
+	p, err := s.leaderParty(leaderID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if !p.hasMember(memberID) {
+		s.mu.Unlock()
+		return ErrNotInParty
+	}
+	mode := s.removeMember(p, memberID)
+	s.mu.Unlock()
+
+	s.cancelQueue(p.ID, mode)
+	return nil
+}
+
+// SelectCharacter 成员选择本次匹配使用的角色
+func (s *Service) SelectCharacter(playerID int64, characterID int) (*models.Party, error) {
+	if characterID <= 0 {
+		return nil, fmt.Errorf("%w: 无效的角色ID", ErrInvalidRequest)
+	}
+	usable, err := s.trials.CanUse(playerID, characterID)
+	if err != nil {
+		return nil, err
+	}
+	if !usable {
+		return nil, ErrCharacterNotOwned
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status == models.PartyQueued {
+		return nil, ErrPartyQueued
+	}
+	for i := range p.Members {
+		if p.Members[i].PlayerID == playerID {
+			p.Members[i].CharacterID = characterID
+		}
+	}
+	return p.snapshot(), nil
+}
+
+// Queue 队长为整支队伍发起匹配，所有成员需已选择角色
+func (s *Service) Queue(leaderID int64, mode models.GameMode) (*models.Party, error) {
+	if mode == "" {
+		return nil, fmt.Errorf("%w: 必须指定游戏模式", ErrInvalidRequest)
+	}
+
+	s.mu.Lock()
+	p, err := s.leaderParty(leaderID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if p.Status == models.PartyQueued {
+		s.mu.Unlock()
+		return nil, ErrPartyQueued
+	}
+	for _, m := range p.Members {
+		if m.CharacterID == 0 {
+			s.mu.Unlock()
+			return nil, ErrNotReady
+		}
+	}
+
+	// 先标记为匹配中，避免提交期间成员变动
+	now := time.Now()
+	p.Status = models.PartyQueued
+	p.GameMode = mode
+	p.QueuedAt = &now
+	req := &models.PartyQueueRequest{
+		PartyID:  p.ID,
+		GameMode: mode,
+		Members:  append([]models.PartyMember(nil), p.Members...),
+	}
+	s.mu.Unlock()
+
+	if err := s.queuer.QueueParty(req); err != nil {
+		s.mu.Lock()
+		p.resetQueue()
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.snapshot(), nil
+}
+
+// CancelQueue 队长取消队伍匹配
+func (s *Service) CancelQueue(leaderID int64) (*models.Party, error) {
+	s.mu.Lock()
+	p, err := s.leaderParty(leaderID)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	mode := p.GameMode
+	wasQueued := p.Status == models.PartyQueued
+	p.resetQueue()
+	s.mu.Unlock()
+
+	if wasQueued {
+		if err := s.queuer.CancelParty(p.ID, mode); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return p.snapshot(), nil
+}
+
+// SendMessage 发送队伍聊天消息
+func (s *Service) SendMessage(playerID int64, text string) (*models.PartyMessage, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: 消息不能为空", ErrInvalidRequest)
+	}
+	if runes := []rune(text); len(runes) > maxChatLength {
+		text = string(runes[:maxChatLength])
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil, err
+	}
+	p.nextSeq++
+	msg := models.PartyMessage{Seq: p.nextSeq, PlayerID: playerID, Text: text, SentAt: time.Now()}
+	p.messages = append(p.messages, msg)
+	if over := len(p.messages) - s.cfg.ChatHistory; over > 0 {
+		p.messages = append(p.messages[:0:0], p.messages[over:]...)
+	}
+	return &msg, nil
+}
+
+// Messages 查询队伍中序号大于 afterSeq 的聊天消息，过滤与查看者存在屏蔽关系的玩家
+func (s *Service) Messages(playerID int64, afterSeq int64) ([]models.PartyMessage, error) {
+	relations, err := social.Relations(playerID)
+	if err != nil {
+		log.Printf("加载玩家 %d 屏蔽关系失败: %v", playerID, err)
+		relations = map[int64]bool{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]models.PartyMessage, 0)
+	for _, msg := range p.messages {
+		if msg.Seq > afterSeq && !relations[msg.PlayerID] {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// Presence 查询玩家状态中的组队信息，不在队伍中时返回nil
+func (s *Service) Presence(playerID int64) *models.PartyPresence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil
+	}
+	return &models.PartyPresence{
+		Size:     len(p.Members),
+		MaxSize:  p.MaxSize,
+		IsLeader: p.LeaderID == playerID,
+		Status:   p.Status,
+		GameMode: p.GameMode,
+	}
+}
+
+// partyOf 查询玩家所在队伍，调用方需持有锁
+func (s *Service) partyOf(playerID int64) (*party, error) {
+	id, ok := s.members[playerID]
+	if !ok {
+		return nil, ErrNotInParty
+	}
+	p, ok := s.parties[id]
+	if !ok {
+		delete(s.members, playerID)
+		return nil, ErrNotInParty
+	}
+	return p, nil
+}
+
+// leaderParty 查询玩家作为队长的队伍，调用方需持有锁
+func (s *Service) leaderParty(playerID int64) (*party, error) {
+	p, err := s.partyOf(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if p.LeaderID != playerID {
+		return nil, ErrNotLeader
+	}
+	return p, nil
+}
+
+// removeMember 将成员移出队伍并重置匹配状态，返回移出前的匹配模式（未匹配时为空）。
+// 调用方需持有锁
+func (s *Service) removeMember(p *party, playerID int64) models.GameMode {
+	var mode models.GameMode
+	if p.Status == models.PartyQueued {
+		mode = p.GameMode
+	}
+	p.resetQueue()
+
+	for i, m := range p.Members {
+		if m.PlayerID == playerID {
+			p.Members = append(p.Members[:i], p.Members[i+1:]...)
+			break
+		}
+	}
+	delete(s.members, playerID)
+
+	if len(p.Members) == 0 {
+		delete(s.parties, p.ID)
+		return mode
+	}
+	if p.LeaderID == playerID {
+		// 成员按加入顺序保存，第一位即最早加入者
+		p.LeaderID = p.Members[0].PlayerID
+	}
+	return mode
+}
+
+// cancelQueue 队伍成员变动后取消匹配，mode 为空时不处理
+func (s *Service) cancelQueue(partyID string, mode models.GameMode) {
+	if mode == "" {
+		return
+	}
+	if err := s.queuer.CancelParty(partyID, mode); err != nil {
+		log.Printf("取消队伍 %s 匹配失败: %v", partyID, err)
+	}
+}
+
+// pendingInvites 清理并返回玩家未过期的邀请，调用方需持有锁
+func (s *Service) pendingInvites(playerID int64) []*models.PartyInvite {
+	now := time.Now()
+	pending := s.invites[playerID][:0]
+	for _, inv := range s.invites[playerID] {
+		if _, ok := s.parties[inv.PartyID]; ok && now.Before(inv.ExpiresAt) {
+			pending = append(pending, inv)
+		}
+	}
+	if len(pending) == 0 {
+		delete(s.invites, playerID)
+		return nil
+	}
+	s.invites[playerID] = pending
+	return pending
+}
+
+// takeInvite 取出玩家来自指定队伍的邀请，调用方需持有锁
+func (s *Service) takeInvite(playerID int64, partyID string) bool {
+	pending := s.pendingInvites(playerID)
+	for i, inv := range pending {
+		if inv.PartyID == partyID {
+			s.invites[playerID] = append(pending[:i], pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot 复制队伍状态，避免调用方持有内部切片
+func (p *party) snapshot() *models.Party {
+	copied := p.Party
+	copied.Members = append([]models.PartyMember(nil), p.Members...)
+	return &copied
+}
+
+// hasMember 检查玩家是否在队伍中
+func (p *party) hasMember(playerID int64) bool {
+	for _, m := range p.Members {
+		if m.PlayerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// resetQueue 重置为空闲状态
+func (p *party) resetQueue() {
+	p.Status = models.PartyIdle
+	p.GameMode = ""
+	p.QueuedAt = nil
+}
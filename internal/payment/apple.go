@@ -0,0 +1,121 @@
+// apple.go
+
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// App Store 收据校验地址
+const (
+	appleProductionURL = "https://buy.itunes.apple.com/verifyReceipt"
+	appleSandboxURL    = "https://sandbox.itunes.apple.com/verifyReceipt"
+	// appleStatusSandboxReceipt 沙盒收据发到了生产环境，需改用沙盒地址
+	appleStatusSandboxReceipt = 21007
+)
+
+// appleVerifier 通过 verifyReceipt 接口校验 App Store 收据
+type appleVerifier struct {
+	cfg    config.AppleIAPConfig
+	client *http.Client
+}
+
+// newAppleVerifier 创建 App Store 收据校验
+func newAppleVerifier(cfg config.AppleIAPConfig) *appleVerifier {
+	return &appleVerifier{cfg: cfg, client: &http.Client{Timeout: verifyTimeout}}
+}
+
+// appleReceiptResponse verifyReceipt 响应中需要的字段
+type appleReceiptResponse struct {
+	Status      int    `json:"status"`
+	Environment string `json:"environment"`
+	Receipt     struct {
+		BundleID string `json:"bundle_id"`
+		InApp    []struct {
+			ProductID      string `json:"product_id"`
+			TransactionID  string `json:"transaction_id"`
+			Quantity       string `json:"quantity"`
+			PurchaseDateMS string `json:"purchase_date_ms"`
+		} `json:"in_app"`
+	} `json:"receipt"`
+}
+
+// Verify 校验收据，先请求生产环境，沙盒收据再请求沙盒环境
+func (a *appleVerifier) Verify(ctx context.Context, req *models.VerifyPurchaseRequest) ([]storePurchase, error) {
+	if req.Receipt == "" {
+		return nil, fmt.Errorf("%w: 缺少收据", ErrInvalidReceipt)
+	}
+
+	resp, err := a.request(ctx, appleProductionURL, req.Receipt)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == appleStatusSandboxReceipt {
+		if resp, err = a.request(ctx, appleSandboxURL, req.Receipt); err != nil {
+			return nil, err
+		}
+	}
+	if resp.Status != 0 {
+		return nil, fmt.Errorf("%w: App Store 返回状态 %d", ErrInvalidReceipt, resp.Status)
+	}
+	if resp.Receipt.BundleID != a.cfg.BundleID {
+		return nil, fmt.Errorf("%w: 收据不属于本应用", ErrInvalidReceipt)
+	}
+
+	sandbox := resp.Environment == "Sandbox"
+	purchases := make([]storePurchase, 0, len(resp.Receipt.InApp))
+	for _, item := range resp.Receipt.InApp {
+		quantity, _ := strconv.Atoi(item.Quantity)
+		ms, _ := strconv.ParseInt(item.PurchaseDateMS, 10, 64)
+		purchases = append(purchases, storePurchase{
+			ProductID:     item.ProductID,
+			TransactionID: item.TransactionID,
+			Quantity:      quantity,
+			PurchasedAt:   time.UnixMilli(ms),
+			Sandbox:       sandbox,
+		})
+	}
+	return purchases, nil
+}
+
+// request 向指定地址提交收据
+func (a *appleVerifier) request(ctx context.Context, url, receipt string) (*appleReceiptResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"receipt-data":             receipt,
+		"password":                 a.cfg.SharedSecret,
+		"exclude-old-transactions": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStoreRequest, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: App Store 返回 %d", ErrStoreRequest, resp.StatusCode)
+	}
+	var result appleReceiptResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: 解析 App Store 响应失败: %v", ErrStoreRequest, err)
+	}
+	return &result, nil
+}
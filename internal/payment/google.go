@@ -0,0 +1,145 @@
+// google.go
+
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/googleauth"
+)
+
+// Google Play Developer API
+const (
+	androidPublisherScope = "https://www.googleapis.com/auth/androidpublisher"
+	googlePurchaseURL     = "https://androidpublisher.googleapis.com/androidpublisher/v3/applications/%s/purchases/products/%s/tokens/%s"
+)
+
+// Google Play 购买状态
+const (
+	googlePurchased = 0
+	googlePending   = 2
+	// googlePurchaseTest 许可测试账号的购买
+	googlePurchaseTest = 0
+)
+
+// googleVerifier 通过 Android Publisher 接口校验 Google Play 购买
+type googleVerifier struct {
+	packageName string
+	tokens      *googleauth.TokenSource
+	client      *http.Client
+}
+
+// newGoogleVerifier 创建 Google Play 购买校验
+func newGoogleVerifier(cfg config.GoogleIAPConfig) (*googleVerifier, error) {
+	tokens, err := googleauth.NewTokenSource(cfg.CredentialsFile, androidPublisherScope)
+	if err != nil {
+		return nil, err
+	}
+	return &googleVerifier{
+		packageName: cfg.PackageName,
+		tokens:      tokens,
+		client:      &http.Client{Timeout: verifyTimeout},
+	}, nil
+}
+
+// googleProductPurchase purchases.products 响应中需要的字段
+type googleProductPurchase struct {
+	PurchaseState      int    `json:"purchaseState"`
+	OrderID            string `json:"orderId"`
+	PurchaseTimeMillis string `json:"purchaseTimeMillis"`
+	PurchaseType       *int   `json:"purchaseType"`
+	Quantity           int    `json:"quantity"`
+}
+
+// Verify 查询购买状态，只有已付款的购买才会返回
+func (g *googleVerifier) Verify(ctx context.Context, req *models.VerifyPurchaseRequest) ([]storePurchase, error) {
+	if req.ProductID == "" || req.PurchaseToken == "" {
+		return nil, fmt.Errorf("%w: 缺少商品ID或购买令牌", ErrInvalidReceipt)
+	}
+
+	var purchase googleProductPurchase
+	if err := g.call(ctx, http.MethodGet, g.purchaseURL(req), &purchase); err != nil {
+		return nil, err
+	}
+
+	switch purchase.PurchaseState {
+	case googlePurchased:
+	case googlePending:
+		return nil, ErrPurchasePending
+	default:
+		return nil, fmt.Errorf("%w: 购买已取消", ErrInvalidReceipt)
+	}
+
+	// 测试购买可能没有订单号，使用购买令牌作为交易ID
+	transactionID := purchase.OrderID
+	if transactionID == "" {
+		transactionID = req.PurchaseToken
+	}
+	ms, _ := strconv.ParseInt(purchase.PurchaseTimeMillis, 10, 64)
+	return []storePurchase{{
+		ProductID:     req.ProductID,
+		TransactionID: transactionID,
+		Quantity:      purchase.Quantity,
+		PurchasedAt:   time.UnixMilli(ms),
+		Sandbox:       purchase.PurchaseType != nil && *purchase.PurchaseType == googlePurchaseTest,
+	}}, nil
+}
+
+// Finish 消耗商品，玩家才能再次购买，未消耗的购买三天后会被自动退款
+func (g *googleVerifier) Finish(ctx context.Context, req *models.VerifyPurchaseRequest) error {
+	return g.call(ctx, http.MethodPost, g.purchaseURL(req)+":consume", nil)
+}
+
+// purchaseURL 购买查询地址
+func (g *googleVerifier) purchaseURL(req *models.VerifyPurchaseRequest) string {
+	return fmt.Sprintf(googlePurchaseURL, url.PathEscape(g.packageName),
+		url.PathEscape(req.ProductID), url.PathEscape(req.PurchaseToken))
+}
+
+// call 调用接口，result 不为nil时解析响应
+func (g *googleVerifier) call(ctx context.Context, method, endpoint string, result interface{}) error {
+	accessToken, err := g.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStoreRequest, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStoreRequest, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent:
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound ||
+		resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w: Google Play 返回 %d", ErrInvalidReceipt, resp.StatusCode)
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%w: Google Play 返回 %d: %s", ErrStoreRequest, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("%w: 解析 Google Play 响应失败: %v", ErrStoreRequest, err)
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// payment.go
+
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// verifyTimeout 向商店校验购买的超时时间
+const verifyTimeout = 15 * time.Second
+
+var (
+	// ErrStoreUnavailable 未配置该商店
+	ErrStoreUnavailable = errcode.New(errcode.BadRequest, "不支持该商店的购买")
+	// ErrInvalidReceipt 收据或购买令牌无效
+	ErrInvalidReceipt = errcode.New(errcode.InvalidReceipt, "无效的购买凭证")
+	// ErrPurchasePending 购买尚未完成付款
+	ErrPurchasePending = errcode.New(errcode.InvalidReceipt, "购买尚未完成付款")
+	// ErrSandboxPurchase 不接受沙盒购买
+	ErrSandboxPurchase = errcode.New(errcode.InvalidReceipt, "不接受测试环境的购买")
+	// ErrUnknownProduct 商品未配置
+	ErrUnknownProduct = errcode.New(errcode.NotFound, "未知的商品")
+	// ErrReceiptUsed 购买已被其他玩家使用
+	ErrReceiptUsed = errcode.New(errcode.ReceiptUsed, "该购买已被其他账号使用")
+	// ErrStoreRequest 请求商店接口失败
+	ErrStoreRequest = errcode.New(errcode.UpstreamError, "商店校验服务暂不可用")
+)
+
+// storePurchase 商店返回的已付款购买
+type storePurchase struct {
+	ProductID     string
+	TransactionID string
+	Quantity      int
+	PurchasedAt   time.Time
+	Sandbox       bool
+}
+
+// verifier 向商店校验购买凭证
+type verifier interface {
+	Verify(ctx context.Context, req *models.VerifyPurchaseRequest) ([]storePurchase, error)
+}
+
+// finisher 发放完成后需要通知商店的校验器（如 Google Play 消耗商品）
+type finisher interface {
+	Finish(ctx context.Context, req *models.VerifyPurchaseRequest) error
+}
+
+// Service 应用内购买服务
+type Service struct {
+	wallet       *wallet.Service
+	verifiers    map[models.PaymentStore]verifier
+	products     map[string]config.IAPProduct
+	allowSandbox bool
+}
+
+// NewService 根据配置创建应用内购买服务，未配置的商店不可用
+func NewService(w *wallet.Service, cfg config.PaymentsConfig) *Service {
+	s := &Service{
+		wallet:       w,
+		verifiers:    make(map[models.PaymentStore]verifier),
+		products:     make(map[string]config.IAPProduct, len(cfg.Products)),
+		allowSandbox: cfg.AllowSandbox,
+	}
+	for _, p := range cfg.Products {
+		if p.ProductID != "" && p.Gems > 0 {
+			s.products[p.ProductID] = p
+		}
+	}
+
+	if cfg.Apple.BundleID != "" {
+		s.verifiers[models.StoreApple] = newAppleVerifier(cfg.Apple)
+	}
+	if cfg.Google.PackageName != "" {
+		v, err := newGoogleVerifier(cfg.Google)
+		if err != nil {
+			log.Printf("Google Play 购买校验初始化失败: %v", err)
+		} else {
+			s.verifiers[models.StoreGoogle] = v
+		}
+	}
+	return s
+}
+
+// Verify 校验购买凭证并发放宝石，同一笔交易只发放一次
+func (s *Service) Verify(playerID int64, req *models.VerifyPurchaseRequest) (*models.VerifyPurchaseResult, error) {
+	v, ok := s.verifiers[req.Store]
+	if !ok {
+		return nil, ErrStoreUnavailable
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+
+	purchases, err := v.Verify(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 过滤沙盒购买和非宝石商品（App Store 收据可能包含其他商品）
+	valid := make([]storePurchase, 0, len(purchases))
+	sandboxOnly := len(purchases) > 0
+	for _, p := range purchases {
+		if p.Sandbox && !s.allowSandbox {
+			continue
+		}
+		sandboxOnly = false
+		if _, ok := s.products[p.ProductID]; ok {
+			valid = append(valid, p)
+		}
+	}
+	if sandboxOnly {
+		return nil, ErrSandboxPurchase
+	}
+	if len(valid) == 0 {
+		return nil, ErrUnknownProduct
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.VerifyPurchaseResult{Purchases: make([]models.Purchase, 0, len(valid))}
+	var granted []*models.Purchase
+	for _, p := range valid {
+		purchase, isNew, err := s.grantTx(tx, playerID, req.Store, p)
+		if err != nil {
+			return nil, err
+		}
+		result.Purchases = append(result.Purchases, *purchase)
+		if isNew {
+			result.GemsGranted += purchase.Gems
+			granted = append(granted, purchase)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	for _, purchase := range granted {
+		events.Publish(events.PurchaseMade, models.PurchaseMadeEvent{
+			PlayerID:    playerID,
+			Source:      models.PurchaseSourceIAP,
+			ProductID:   purchase.ProductID,
+			Quantity:    purchase.Quantity,
+			Currency:    purchase.Currency,
+			Amount:      purchase.PriceCents,
+			PurchasedAt: purchase.PurchasedAt,
+		})
+	}
+
+	// 宝石已发放，通知商店失败只记录日志，下次校验时会重试
+	if f, ok := v.(finisher); ok {
+		if err := f.Finish(ctx, req); err != nil {
+			log.Printf("玩家 %d 的 %s 购买完成通知失败: %v", playerID, req.Store, err)
+		}
+	}
+	return result, nil
+}
+
+// grantTx 记录购买并发放宝石，已记录的交易不重复发放，返回是否为本次新发放
+func (s *Service) grantTx(tx *sql.Tx, playerID int64, store models.PaymentStore, p storePurchase) (*models.Purchase, bool, error) {
+	product := s.products[p.ProductID]
+	quantity := max(p.Quantity, 1)
+	purchase := &models.Purchase{
+		PlayerID:      playerID,
+		Store:         store,
+		ProductID:     p.ProductID,
+		TransactionID: p.TransactionID,
+		Quantity:      quantity,
+		Gems:          product.Gems * int64(quantity),
+		PriceCents:    product.PriceCents * int64(quantity),
+		Currency:      product.Currency,
+		Sandbox:       p.Sandbox,
+		PurchasedAt:   p.PurchasedAt,
+	}
+
+	err := tx.QueryRow(`
+		INSERT INTO iap_purchases (player_id, store, product_id, transaction_id, quantity, gems,
+		                           price_cents, currency, sandbox, purchased_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (store, transaction_id) DO NOTHING
+		RETURNING id, created_at
+	`, playerID, string(store), p.ProductID, p.TransactionID, quantity, purchase.Gems,
+		purchase.PriceCents, purchase.Currency, p.Sandbox, p.PurchasedAt).Scan(&purchase.ID, &purchase.CreatedAt)
+	if err == sql.ErrNoRows {
+		// 已记录的交易：同一玩家重复校验直接返回，其他玩家使用同一凭证则拒绝
+		existing, err := getPurchase(tx, store, p.TransactionID)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing.PlayerID != playerID {
+			return nil, false, ErrReceiptUsed
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("记录购买失败: %w", err)
+	}
+
+	_, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       playerID,
+		Currency:       models.CurrencyGems,
+		Amount:         purchase.Gems,
+		Reason:         models.ReasonIAPPurchase,
+		ReferenceID:    fmt.Sprintf("iap:%d", purchase.ID),
+		IdempotencyKey: fmt.Sprintf("iap:%s:%s", store, p.TransactionID),
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return purchase, true, nil
+}
+
+// purchaseColumns 购买记录查询字段，与 getPurchase 扫描顺序一致
+const purchaseColumns = `
+	id, COALESCE(player_id, 0), store, product_id, transaction_id, quantity, gems,
+	price_cents, COALESCE(currency, ''), sandbox, purchased_at, created_at
+`
+
+// getPurchase 按商店交易ID查询购买记录
+func getPurchase(tx *sql.Tx, store models.PaymentStore, transactionID string) (*models.Purchase, error) {
+	var p models.Purchase
+	err := tx.QueryRow(`SELECT `+purchaseColumns+` FROM iap_purchases WHERE store = $1 AND transaction_id = $2`,
+		string(store), transactionID).Scan(&p.ID, &p.PlayerID, &p.Store, &p.ProductID, &p.TransactionID,
+		&p.Quantity, &p.Gems, &p.PriceCents, &p.Currency, &p.Sandbox, &p.PurchasedAt, &p.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("查询购买记录失败: %w", err)
+	}
+	return &p, nil
+}
+
+// Revenue 按天、商店和币种统计收入，默认不含沙盒购买
+func Revenue(from, to time.Time, includeSandbox bool) ([]models.RevenueEntry, error) {
+	rows, err := db.DB.Query(`
+		SELECT to_char(date_trunc('day', purchased_at), 'YYYY-MM-DD') AS day, store, COALESCE(currency, ''),
+		       COUNT(*), COUNT(DISTINCT player_id), SUM(gems), SUM(price_cents)
+		FROM iap_purchases
+		WHERE purchased_at >= $1 AND purchased_at < $2 AND ($3 OR NOT sandbox)
+		GROUP BY 1, 2, 3
+		ORDER BY 1, 2, 3
+	`, from, to, includeSandbox)
+	if err != nil {
+		return nil, fmt.Errorf("查询收入统计失败: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.RevenueEntry, 0)
+	for rows.Next() {
+		var e models.RevenueEntry
+		if err := rows.Scan(&e.Day, &e.Store, &e.Currency, &e.Purchases, &e.Buyers, &e.Gems, &e.PriceCents); err != nil {
+			return nil, fmt.Errorf("扫描收入统计失败: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,141 @@
+// privacy.go
+
+package privacy
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrHidden 玩家已隐藏该资料
+	ErrHidden = errcode.New(errcode.ProfileHidden, "该玩家已隐藏此资料")
+	// ErrInvalidVisibility 可见范围无效
+	ErrInvalidVisibility = errcode.New(errcode.BadRequest, "可见范围必须为 public、friends 或 private")
+)
+
+// Field 受隐私设置控制的资料
+type Field int
+
+const (
+	// FieldMatchHistory 对局历史
+	FieldMatchHistory Field = iota
+	// FieldStats 战绩统计
+	FieldStats
+)
+
+// Get 查询玩家隐私设置，未设置时默认公开
+func Get(playerID int64) (*models.PrivacySettings, error) {
+	s := &models.PrivacySettings{
+		PlayerID:     playerID,
+		MatchHistory: models.VisibilityPublic,
+		Stats:        models.VisibilityPublic,
+	}
+	if db.DB == nil {
+		return s, nil
+	}
+
+	var updatedAt time.Time
+	err := db.DB.QueryRow(`
+		SELECT match_history, stats, updated_at FROM player_privacy WHERE player_id = $1
+	`, playerID).Scan(&s.MatchHistory, &s.Stats, &updatedAt)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询隐私设置失败: %w", err)
+	}
+	s.UpdatedAt = &updatedAt
+	return s, nil
+}
+
+// Update 更新玩家隐私设置，未指定的字段保持不变
+func Update(playerID int64, req *models.PrivacySettings) (*models.PrivacySettings, error) {
+	current, err := Get(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if req.MatchHistory != "" {
+		current.MatchHistory = req.MatchHistory
+	}
+	if req.Stats != "" {
+		current.Stats = req.Stats
+	}
+	if !current.MatchHistory.IsValid() || !current.Stats.IsValid() {
+		return nil, ErrInvalidVisibility
+	}
+
+	var updatedAt time.Time
+	err = db.DB.QueryRow(`
+		INSERT INTO player_privacy (player_id, match_history, stats, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (player_id) DO UPDATE SET
+			match_history = EXCLUDED.match_history, stats = EXCLUDED.stats, updated_at = NOW()
+		RETURNING updated_at
+	`, playerID, string(current.MatchHistory), string(current.Stats)).Scan(&updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("更新隐私设置失败: %w", err)
+	}
+	current.UpdatedAt = &updatedAt
+	return current, nil
+}
+
+// Check 检查查看者能否查看玩家的指定资料，不可见时返回 ErrHidden。
+// viewerID 为0表示未登录。
+func Check(viewerID, ownerID int64, field Field) error {
+	if viewerID == ownerID {
+		return nil
+	}
+
+	s, err := Get(ownerID)
+	if err != nil {
+		return err
+	}
+	if !allowed(viewerID, ownerID, visibilityOf(s, field)) {
+		return ErrHidden
+	}
+	return nil
+}
+
+// HiddenPlayers 返回 playerIDs 中对查看者隐藏了指定资料的玩家
+func HiddenPlayers(viewerID int64, playerIDs []int64, field Field) (map[int64]bool, error) {
+	hidden := make(map[int64]bool)
+	for _, id := range playerIDs {
+		if id == viewerID || hidden[id] {
+			continue
+		}
+		if err := Check(viewerID, id, field); err != nil {
+			if !errors.Is(err, ErrHidden) {
+				return nil, err
+			}
+			hidden[id] = true
+		}
+	}
+	return hidden, nil
+}
+
+// visibilityOf 取出设置中指定资料的可见范围
+func visibilityOf(s *models.PrivacySettings, field Field) models.Visibility {
+	if field == FieldStats {
+		return s.Stats
+	}
+	return s.MatchHistory
+}
+
+// allowed 判断可见范围是否允许查看者访问。
+// 目前还没有好友关系，仅好友可见的资料除本人外均不可见。
+func allowed(viewerID, ownerID int64, v models.Visibility) bool {
+	switch v {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityFriends, models.VisibilityPrivate:
+		return viewerID != 0 && viewerID == ownerID
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+// progression.go
+
+package progression
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 默认成长曲线
+const (
+	defaultMaxLevel = 30
+	defaultBaseExp  = 200
+	defaultGrowth   = 1.15
+)
+
+// 对局角色经验
+const (
+	matchExpBase      = 50 // 完成对局
+	matchExpWin       = 30 // 获胜
+	matchExpPerKill   = 5  // 每次击杀
+	matchExpPerAssist = 2  // 每次助攻
+	matchExpMVP       = 25 // MVP
+)
+
+// Curve 角色等级成长曲线
+type Curve struct {
+	// expTable[i] 为 i+1 级升到 i+2 级所需经验
+	expTable []int
+}
+
+// NewCurve 根据配置创建成长曲线，未配置的参数使用默认值
+func NewCurve(cfg config.CharacterLevelConfig) *Curve {
+	if len(cfg.ExpTable) > 0 {
+		table := make([]int, len(cfg.ExpTable))
+		for i, exp := range cfg.ExpTable {
+			table[i] = max(exp, 1)
+		}
+		return &Curve{expTable: table}
+	}
+
+	maxLevel := cfg.MaxLevel
+	if maxLevel <= 0 {
+		maxLevel = defaultMaxLevel
+	}
+	baseExp := cfg.BaseExp
+	if baseExp <= 0 {
+		baseExp = defaultBaseExp
+	}
+
+	table := make([]int, maxLevel-1)
+	for i := range table {
+		var exp float64
+		switch cfg.Curve {
+		case "linear":
+			exp = float64(baseExp) + cfg.Growth*float64(i)
+		default:
+			growth := cfg.Growth
+			if growth <= 0 {
+				growth = defaultGrowth
+			}
+			exp = float64(baseExp) * math.Pow(growth, float64(i))
+		}
+		table[i] = max(int(math.Round(exp)), 1)
+	}
+	return &Curve{expTable: table}
+}
+
+// MaxLevel 等级上限
+func (c *Curve) MaxLevel() int {
+	return len(c.expTable) + 1
+}
+
+// ExpToNext 从指定等级升到下一级所需经验，满级时返回0
+func (c *Curve) ExpToNext(level int) int {
+	if level < 1 || level >= c.MaxLevel() {
+		return 0
+	}
+	return c.expTable[level-1]
+}
+
+// Apply 在当前等级和经验上增加经验，返回新的等级和经验，满级后经验不再累积
+func (c *Curve) Apply(level, exp, gained int) (int, int) {
+	level = max(level, 1)
+	exp += gained
+	for level < c.MaxLevel() && exp >= c.ExpToNext(level) {
+		exp -= c.ExpToNext(level)
+		level++
+	}
+	if level >= c.MaxLevel() {
+		// 调低等级上限后不降低已有等级
+		return max(level, c.MaxLevel()), 0
+	}
+	return level, exp
+}
+
+// Progress 生成角色等级进度
+func (c *Curve) Progress(level, exp int) *models.CharacterProgress {
+	return &models.CharacterProgress{
+		Level:          level,
+		Exp:            exp,
+		ExpToNext:      c.ExpToNext(level),
+		MaxLevel:       c.MaxLevel(),
+		LockedSkillIDs: make([]int, 0),
+	}
+}
+
+// MatchExp 计算玩家本局获得的角色经验
+func MatchExp(p *models.PlayerMatchRecord) int {
+	exp := matchExpBase + p.Kills*matchExpPerKill + p.Assists*matchExpPerAssist
+	if p.Won {
+		exp += matchExpWin
+	}
+	if p.MVP {
+		exp += matchExpMVP
+	}
+	return exp
+}
+
+// AddExp 为玩家角色增加经验并按曲线升级，升级时返回升级结果
+func (c *Curve) AddExp(playerID int64, characterID, amount int) (*models.CharacterLevelUp, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	var levelUp *models.CharacterLevelUp
+	err := db.WithTx(func(tx *sql.Tx) error {
+		var err error
+		levelUp, err = c.AddExpTx(tx, playerID, characterID, amount)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return levelUp, nil
+}
+
+// AddExpTx 在调用方事务中为玩家角色增加经验，便于与对局结算等操作保持原子性
+func (c *Curve) AddExpTx(tx *sql.Tx, playerID int64, characterID, amount int) (*models.CharacterLevelUp, error) {
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	var level, exp int
+	err := tx.QueryRow(`
+		SELECT COALESCE(level, 1), COALESCE(exp, 0) FROM player_characters
+		WHERE player_id = $1 AND character_id = $2
+		FOR UPDATE
+	`, playerID, characterID).Scan(&level, &exp)
+	if err == sql.ErrNoRows {
+		// 未拥有的角色（如试玩）不累积经验
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询角色等级失败: %w", err)
+	}
+
+	newLevel, newExp := c.Apply(level, exp, amount)
+	_, err = tx.Exec(`
+		UPDATE player_characters SET level = $3, exp = $4
+		WHERE player_id = $1 AND character_id = $2
+	`, playerID, characterID, newLevel, newExp)
+	if err != nil {
+		return nil, fmt.Errorf("更新角色等级失败: %w", err)
+	}
+
+	if newLevel == level {
+		return nil, nil
+	}
+	return &models.CharacterLevelUp{CharacterID: characterID, OldLevel: level, NewLevel: newLevel}, nil
+}
+
+// LockedSkills 查询玩家角色因等级不足尚未解锁的技能，数据库未初始化时返回空
+func LockedSkills(playerID int64, characterID int) (map[int]bool, error) {
+	locked := make(map[int]bool)
+	if db.DB == nil {
+		return locked, nil
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT cs.skill_id
+		FROM character_skills cs
+		LEFT JOIN player_characters pc ON pc.character_id = cs.character_id AND pc.player_id = $1
+		WHERE cs.character_id = $2 AND COALESCE(cs.required_level, 1) > COALESCE(pc.level, 1)
+	`, playerID, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("查询未解锁技能失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var skillID int
+		if err := rows.Scan(&skillID); err != nil {
+			return nil, fmt.Errorf("扫描未解锁技能失败: %w", err)
+		}
+		locked[skillID] = true
+	}
+	return locked, rows.Err()
+}
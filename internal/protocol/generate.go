@@ -0,0 +1,10 @@
+// generate.go
+
+package protocol
+
+// 修改 message.proto 后在仓库根目录执行 go generate ./internal/protocol 重新生成 message.pb.go。
+// 需要 protoc 和 protoc-gen-go v1.36.6：
+//
+//	go install google.golang.org/protobuf/cmd/protoc-gen-go@v1.36.6
+//
+//go:generate protoc --proto_path=../.. --go_out=../.. --go_opt=paths=source_relative internal/protocol/message.proto
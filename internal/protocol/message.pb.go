@@ -1,3 +1,5 @@
+// message.proto
+
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
 // 	protoc-gen-go v1.36.6
@@ -1576,6 +1578,73 @@ func (x *CollisionEvent) GetDamage() int32 {
 	return 0
 }
 
+// 服务端实时消息。选择 protobuf 编码的连接以二进制帧接收，每帧一条
+type ServerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ServerMessage_GameFrame
+	Payload       isServerMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerMessage) Reset() {
+	*x = ServerMessage{}
+	mi := &file_internal_protocol_message_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerMessage) ProtoMessage() {}
+
+func (x *ServerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_protocol_message_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerMessage.ProtoReflect.Descriptor instead.
+func (*ServerMessage) Descriptor() ([]byte, []int) {
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ServerMessage) GetPayload() isServerMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ServerMessage) GetGameFrame() *GameFrame {
+	if x != nil {
+		if x, ok := x.Payload.(*ServerMessage_GameFrame); ok {
+			return x.GameFrame
+		}
+	}
+	return nil
+}
+
+type isServerMessage_Payload interface {
+	isServerMessage_Payload()
+}
+
+type ServerMessage_GameFrame struct {
+	GameFrame *GameFrame `protobuf:"bytes,1,opt,name=game_frame,json=gameFrame,proto3,oneof"`
+}
+
+func (*ServerMessage_GameFrame) isServerMessage_Payload() {}
+
 // 游戏结果
 type GameResult struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -1590,7 +1659,7 @@ type GameResult struct {
 
 func (x *GameResult) Reset() {
 	*x = GameResult{}
-	mi := &file_internal_protocol_message_proto_msgTypes[20]
+	mi := &file_internal_protocol_message_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1602,7 +1671,7 @@ func (x *GameResult) String() string {
 func (*GameResult) ProtoMessage() {}
 
 func (x *GameResult) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[20]
+	mi := &file_internal_protocol_message_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1615,7 +1684,7 @@ func (x *GameResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameResult.ProtoReflect.Descriptor instead.
 func (*GameResult) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{20}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GameResult) GetRoomId() string {
@@ -1673,7 +1742,7 @@ type PlayerResult struct {
 
 func (x *PlayerResult) Reset() {
 	*x = PlayerResult{}
-	mi := &file_internal_protocol_message_proto_msgTypes[21]
+	mi := &file_internal_protocol_message_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1685,7 +1754,7 @@ func (x *PlayerResult) String() string {
 func (*PlayerResult) ProtoMessage() {}
 
 func (x *PlayerResult) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[21]
+	mi := &file_internal_protocol_message_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1698,7 +1767,7 @@ func (x *PlayerResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerResult.ProtoReflect.Descriptor instead.
 func (*PlayerResult) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{21}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *PlayerResult) GetPlayerId() int64 {
@@ -1800,7 +1869,7 @@ type SkillInfo struct {
 
 func (x *SkillInfo) Reset() {
 	*x = SkillInfo{}
-	mi := &file_internal_protocol_message_proto_msgTypes[22]
+	mi := &file_internal_protocol_message_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1812,7 +1881,7 @@ func (x *SkillInfo) String() string {
 func (*SkillInfo) ProtoMessage() {}
 
 func (x *SkillInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[22]
+	mi := &file_internal_protocol_message_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1825,7 +1894,7 @@ func (x *SkillInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SkillInfo.ProtoReflect.Descriptor instead.
 func (*SkillInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{22}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *SkillInfo) GetId() int32 {
@@ -1941,7 +2010,7 @@ type CharacterInfo struct {
 
 func (x *CharacterInfo) Reset() {
 	*x = CharacterInfo{}
-	mi := &file_internal_protocol_message_proto_msgTypes[23]
+	mi := &file_internal_protocol_message_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1953,7 +2022,7 @@ func (x *CharacterInfo) String() string {
 func (*CharacterInfo) ProtoMessage() {}
 
 func (x *CharacterInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[23]
+	mi := &file_internal_protocol_message_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1966,7 +2035,7 @@ func (x *CharacterInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CharacterInfo.ProtoReflect.Descriptor instead.
 func (*CharacterInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{23}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *CharacterInfo) GetId() int32 {
@@ -2078,7 +2147,7 @@ type PlayerCharacterInfo struct {
 
 func (x *PlayerCharacterInfo) Reset() {
 	*x = PlayerCharacterInfo{}
-	mi := &file_internal_protocol_message_proto_msgTypes[24]
+	mi := &file_internal_protocol_message_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2090,7 +2159,7 @@ func (x *PlayerCharacterInfo) String() string {
 func (*PlayerCharacterInfo) ProtoMessage() {}
 
 func (x *PlayerCharacterInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[24]
+	mi := &file_internal_protocol_message_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2103,7 +2172,7 @@ func (x *PlayerCharacterInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerCharacterInfo.ProtoReflect.Descriptor instead.
 func (*PlayerCharacterInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{24}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *PlayerCharacterInfo) GetPlayerId() int64 {
@@ -2183,7 +2252,7 @@ type CharacterUnlockRequirement struct {
 
 func (x *CharacterUnlockRequirement) Reset() {
 	*x = CharacterUnlockRequirement{}
-	mi := &file_internal_protocol_message_proto_msgTypes[25]
+	mi := &file_internal_protocol_message_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2195,7 +2264,7 @@ func (x *CharacterUnlockRequirement) String() string {
 func (*CharacterUnlockRequirement) ProtoMessage() {}
 
 func (x *CharacterUnlockRequirement) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[25]
+	mi := &file_internal_protocol_message_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2208,7 +2277,7 @@ func (x *CharacterUnlockRequirement) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CharacterUnlockRequirement.ProtoReflect.Descriptor instead.
 func (*CharacterUnlockRequirement) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{25}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *CharacterUnlockRequirement) GetCharacterId() int32 {
@@ -2271,7 +2340,7 @@ type PlayerProfile struct {
 
 func (x *PlayerProfile) Reset() {
 	*x = PlayerProfile{}
-	mi := &file_internal_protocol_message_proto_msgTypes[26]
+	mi := &file_internal_protocol_message_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2283,7 +2352,7 @@ func (x *PlayerProfile) String() string {
 func (*PlayerProfile) ProtoMessage() {}
 
 func (x *PlayerProfile) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[26]
+	mi := &file_internal_protocol_message_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2296,7 +2365,7 @@ func (x *PlayerProfile) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerProfile.ProtoReflect.Descriptor instead.
 func (*PlayerProfile) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{26}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *PlayerProfile) GetId() int64 {
@@ -2427,7 +2496,7 @@ type MatchRecord struct {
 
 func (x *MatchRecord) Reset() {
 	*x = MatchRecord{}
-	mi := &file_internal_protocol_message_proto_msgTypes[27]
+	mi := &file_internal_protocol_message_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2439,7 +2508,7 @@ func (x *MatchRecord) String() string {
 func (*MatchRecord) ProtoMessage() {}
 
 func (x *MatchRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[27]
+	mi := &file_internal_protocol_message_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2452,7 +2521,7 @@ func (x *MatchRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchRecord.ProtoReflect.Descriptor instead.
 func (*MatchRecord) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{27}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *MatchRecord) GetId() string {
@@ -2527,7 +2596,7 @@ type PlayerMatchRecord struct {
 
 func (x *PlayerMatchRecord) Reset() {
 	*x = PlayerMatchRecord{}
-	mi := &file_internal_protocol_message_proto_msgTypes[28]
+	mi := &file_internal_protocol_message_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2539,7 +2608,7 @@ func (x *PlayerMatchRecord) String() string {
 func (*PlayerMatchRecord) ProtoMessage() {}
 
 func (x *PlayerMatchRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[28]
+	mi := &file_internal_protocol_message_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2552,7 +2621,7 @@ func (x *PlayerMatchRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerMatchRecord.ProtoReflect.Descriptor instead.
 func (*PlayerMatchRecord) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{28}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *PlayerMatchRecord) GetMatchId() string {
@@ -2674,7 +2743,7 @@ type PlayerStats struct {
 
 func (x *PlayerStats) Reset() {
 	*x = PlayerStats{}
-	mi := &file_internal_protocol_message_proto_msgTypes[29]
+	mi := &file_internal_protocol_message_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2686,7 +2755,7 @@ func (x *PlayerStats) String() string {
 func (*PlayerStats) ProtoMessage() {}
 
 func (x *PlayerStats) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[29]
+	mi := &file_internal_protocol_message_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2699,7 +2768,7 @@ func (x *PlayerStats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerStats.ProtoReflect.Descriptor instead.
 func (*PlayerStats) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{29}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *PlayerStats) GetPlayerId() int64 {
@@ -2804,7 +2873,7 @@ type LeaderboardEntry struct {
 
 func (x *LeaderboardEntry) Reset() {
 	*x = LeaderboardEntry{}
-	mi := &file_internal_protocol_message_proto_msgTypes[30]
+	mi := &file_internal_protocol_message_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2816,7 +2885,7 @@ func (x *LeaderboardEntry) String() string {
 func (*LeaderboardEntry) ProtoMessage() {}
 
 func (x *LeaderboardEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[30]
+	mi := &file_internal_protocol_message_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2829,7 +2898,7 @@ func (x *LeaderboardEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeaderboardEntry.ProtoReflect.Descriptor instead.
 func (*LeaderboardEntry) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{30}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *LeaderboardEntry) GetPlayerId() int64 {
@@ -2912,7 +2981,7 @@ type GameMapInfo struct {
 
 func (x *GameMapInfo) Reset() {
 	*x = GameMapInfo{}
-	mi := &file_internal_protocol_message_proto_msgTypes[31]
+	mi := &file_internal_protocol_message_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2924,7 +2993,7 @@ func (x *GameMapInfo) String() string {
 func (*GameMapInfo) ProtoMessage() {}
 
 func (x *GameMapInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[31]
+	mi := &file_internal_protocol_message_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2937,7 +3006,7 @@ func (x *GameMapInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GameMapInfo.ProtoReflect.Descriptor instead.
 func (*GameMapInfo) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{31}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *GameMapInfo) GetId() int32 {
@@ -3011,7 +3080,7 @@ type MatchPreferences struct {
 
 func (x *MatchPreferences) Reset() {
 	*x = MatchPreferences{}
-	mi := &file_internal_protocol_message_proto_msgTypes[32]
+	mi := &file_internal_protocol_message_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3023,7 +3092,7 @@ func (x *MatchPreferences) String() string {
 func (*MatchPreferences) ProtoMessage() {}
 
 func (x *MatchPreferences) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[32]
+	mi := &file_internal_protocol_message_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3036,7 +3105,7 @@ func (x *MatchPreferences) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchPreferences.ProtoReflect.Descriptor instead.
 func (*MatchPreferences) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{32}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *MatchPreferences) GetPlayerId() int64 {
@@ -3097,7 +3166,7 @@ type MatchHistory struct {
 
 func (x *MatchHistory) Reset() {
 	*x = MatchHistory{}
-	mi := &file_internal_protocol_message_proto_msgTypes[33]
+	mi := &file_internal_protocol_message_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3109,7 +3178,7 @@ func (x *MatchHistory) String() string {
 func (*MatchHistory) ProtoMessage() {}
 
 func (x *MatchHistory) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[33]
+	mi := &file_internal_protocol_message_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3122,7 +3191,7 @@ func (x *MatchHistory) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchHistory.ProtoReflect.Descriptor instead.
 func (*MatchHistory) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{33}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *MatchHistory) GetId() string {
@@ -3186,7 +3255,7 @@ type CharacterListResponse struct {
 
 func (x *CharacterListResponse) Reset() {
 	*x = CharacterListResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[34]
+	mi := &file_internal_protocol_message_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3198,7 +3267,7 @@ func (x *CharacterListResponse) String() string {
 func (*CharacterListResponse) ProtoMessage() {}
 
 func (x *CharacterListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[34]
+	mi := &file_internal_protocol_message_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3211,7 +3280,7 @@ func (x *CharacterListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CharacterListResponse.ProtoReflect.Descriptor instead.
 func (*CharacterListResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{34}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *CharacterListResponse) GetSuccess() bool {
@@ -3247,7 +3316,7 @@ type CharacterDetailResponse struct {
 
 func (x *CharacterDetailResponse) Reset() {
 	*x = CharacterDetailResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[35]
+	mi := &file_internal_protocol_message_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3259,7 +3328,7 @@ func (x *CharacterDetailResponse) String() string {
 func (*CharacterDetailResponse) ProtoMessage() {}
 
 func (x *CharacterDetailResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[35]
+	mi := &file_internal_protocol_message_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3272,7 +3341,7 @@ func (x *CharacterDetailResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CharacterDetailResponse.ProtoReflect.Descriptor instead.
 func (*CharacterDetailResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{35}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *CharacterDetailResponse) GetSuccess() bool {
@@ -3308,7 +3377,7 @@ type PlayerCharactersResponse struct {
 
 func (x *PlayerCharactersResponse) Reset() {
 	*x = PlayerCharactersResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[36]
+	mi := &file_internal_protocol_message_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3320,7 +3389,7 @@ func (x *PlayerCharactersResponse) String() string {
 func (*PlayerCharactersResponse) ProtoMessage() {}
 
 func (x *PlayerCharactersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[36]
+	mi := &file_internal_protocol_message_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3333,7 +3402,7 @@ func (x *PlayerCharactersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerCharactersResponse.ProtoReflect.Descriptor instead.
 func (*PlayerCharactersResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{36}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *PlayerCharactersResponse) GetSuccess() bool {
@@ -3368,7 +3437,7 @@ type SetDefaultCharacterRequest struct {
 
 func (x *SetDefaultCharacterRequest) Reset() {
 	*x = SetDefaultCharacterRequest{}
-	mi := &file_internal_protocol_message_proto_msgTypes[37]
+	mi := &file_internal_protocol_message_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3380,7 +3449,7 @@ func (x *SetDefaultCharacterRequest) String() string {
 func (*SetDefaultCharacterRequest) ProtoMessage() {}
 
 func (x *SetDefaultCharacterRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[37]
+	mi := &file_internal_protocol_message_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3393,7 +3462,7 @@ func (x *SetDefaultCharacterRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetDefaultCharacterRequest.ProtoReflect.Descriptor instead.
 func (*SetDefaultCharacterRequest) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{37}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *SetDefaultCharacterRequest) GetPlayerId() int64 {
@@ -3422,7 +3491,7 @@ type PlayerProfileResponse struct {
 
 func (x *PlayerProfileResponse) Reset() {
 	*x = PlayerProfileResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[38]
+	mi := &file_internal_protocol_message_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3434,7 +3503,7 @@ func (x *PlayerProfileResponse) String() string {
 func (*PlayerProfileResponse) ProtoMessage() {}
 
 func (x *PlayerProfileResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[38]
+	mi := &file_internal_protocol_message_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3447,7 +3516,7 @@ func (x *PlayerProfileResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerProfileResponse.ProtoReflect.Descriptor instead.
 func (*PlayerProfileResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{38}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *PlayerProfileResponse) GetSuccess() bool {
@@ -3483,7 +3552,7 @@ type UpdatePlayerProfileRequest struct {
 
 func (x *UpdatePlayerProfileRequest) Reset() {
 	*x = UpdatePlayerProfileRequest{}
-	mi := &file_internal_protocol_message_proto_msgTypes[39]
+	mi := &file_internal_protocol_message_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3495,7 +3564,7 @@ func (x *UpdatePlayerProfileRequest) String() string {
 func (*UpdatePlayerProfileRequest) ProtoMessage() {}
 
 func (x *UpdatePlayerProfileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[39]
+	mi := &file_internal_protocol_message_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3508,7 +3577,7 @@ func (x *UpdatePlayerProfileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdatePlayerProfileRequest.ProtoReflect.Descriptor instead.
 func (*UpdatePlayerProfileRequest) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{39}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *UpdatePlayerProfileRequest) GetPlayerId() int64 {
@@ -3544,7 +3613,7 @@ type PlayerStatsResponse struct {
 
 func (x *PlayerStatsResponse) Reset() {
 	*x = PlayerStatsResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[40]
+	mi := &file_internal_protocol_message_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3556,7 +3625,7 @@ func (x *PlayerStatsResponse) String() string {
 func (*PlayerStatsResponse) ProtoMessage() {}
 
 func (x *PlayerStatsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[40]
+	mi := &file_internal_protocol_message_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3569,7 +3638,7 @@ func (x *PlayerStatsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PlayerStatsResponse.ProtoReflect.Descriptor instead.
 func (*PlayerStatsResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{40}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *PlayerStatsResponse) GetSuccess() bool {
@@ -3608,7 +3677,7 @@ type MatchHistoryResponse struct {
 
 func (x *MatchHistoryResponse) Reset() {
 	*x = MatchHistoryResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[41]
+	mi := &file_internal_protocol_message_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3620,7 +3689,7 @@ func (x *MatchHistoryResponse) String() string {
 func (*MatchHistoryResponse) ProtoMessage() {}
 
 func (x *MatchHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[41]
+	mi := &file_internal_protocol_message_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3633,7 +3702,7 @@ func (x *MatchHistoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchHistoryResponse.ProtoReflect.Descriptor instead.
 func (*MatchHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{41}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *MatchHistoryResponse) GetSuccess() bool {
@@ -3691,7 +3760,7 @@ type LeaderboardResponse struct {
 
 func (x *LeaderboardResponse) Reset() {
 	*x = LeaderboardResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[42]
+	mi := &file_internal_protocol_message_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3703,7 +3772,7 @@ func (x *LeaderboardResponse) String() string {
 func (*LeaderboardResponse) ProtoMessage() {}
 
 func (x *LeaderboardResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[42]
+	mi := &file_internal_protocol_message_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3716,7 +3785,7 @@ func (x *LeaderboardResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeaderboardResponse.ProtoReflect.Descriptor instead.
 func (*LeaderboardResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{42}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *LeaderboardResponse) GetSuccess() bool {
@@ -3758,7 +3827,7 @@ type SetMatchPreferencesRequest struct {
 
 func (x *SetMatchPreferencesRequest) Reset() {
 	*x = SetMatchPreferencesRequest{}
-	mi := &file_internal_protocol_message_proto_msgTypes[43]
+	mi := &file_internal_protocol_message_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3770,7 +3839,7 @@ func (x *SetMatchPreferencesRequest) String() string {
 func (*SetMatchPreferencesRequest) ProtoMessage() {}
 
 func (x *SetMatchPreferencesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[43]
+	mi := &file_internal_protocol_message_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3783,7 +3852,7 @@ func (x *SetMatchPreferencesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetMatchPreferencesRequest.ProtoReflect.Descriptor instead.
 func (*SetMatchPreferencesRequest) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{43}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *SetMatchPreferencesRequest) GetPlayerId() int64 {
@@ -3812,7 +3881,7 @@ type MatchPreferencesResponse struct {
 
 func (x *MatchPreferencesResponse) Reset() {
 	*x = MatchPreferencesResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[44]
+	mi := &file_internal_protocol_message_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3824,7 +3893,7 @@ func (x *MatchPreferencesResponse) String() string {
 func (*MatchPreferencesResponse) ProtoMessage() {}
 
 func (x *MatchPreferencesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[44]
+	mi := &file_internal_protocol_message_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3837,7 +3906,7 @@ func (x *MatchPreferencesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchPreferencesResponse.ProtoReflect.Descriptor instead.
 func (*MatchPreferencesResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{44}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *MatchPreferencesResponse) GetSuccess() bool {
@@ -3876,7 +3945,7 @@ type MatchHistoryListResponse struct {
 
 func (x *MatchHistoryListResponse) Reset() {
 	*x = MatchHistoryListResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[45]
+	mi := &file_internal_protocol_message_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3888,7 +3957,7 @@ func (x *MatchHistoryListResponse) String() string {
 func (*MatchHistoryListResponse) ProtoMessage() {}
 
 func (x *MatchHistoryListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[45]
+	mi := &file_internal_protocol_message_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3901,7 +3970,7 @@ func (x *MatchHistoryListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MatchHistoryListResponse.ProtoReflect.Descriptor instead.
 func (*MatchHistoryListResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{45}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *MatchHistoryListResponse) GetSuccess() bool {
@@ -3957,7 +4026,7 @@ type SuccessResponse struct {
 
 func (x *SuccessResponse) Reset() {
 	*x = SuccessResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[46]
+	mi := &file_internal_protocol_message_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3969,7 +4038,7 @@ func (x *SuccessResponse) String() string {
 func (*SuccessResponse) ProtoMessage() {}
 
 func (x *SuccessResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[46]
+	mi := &file_internal_protocol_message_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3982,7 +4051,7 @@ func (x *SuccessResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SuccessResponse.ProtoReflect.Descriptor instead.
 func (*SuccessResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{46}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *SuccessResponse) GetSuccess() bool {
@@ -4011,7 +4080,7 @@ type ErrorResponse struct {
 
 func (x *ErrorResponse) Reset() {
 	*x = ErrorResponse{}
-	mi := &file_internal_protocol_message_proto_msgTypes[47]
+	mi := &file_internal_protocol_message_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4023,7 +4092,7 @@ func (x *ErrorResponse) String() string {
 func (*ErrorResponse) ProtoMessage() {}
 
 func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_internal_protocol_message_proto_msgTypes[47]
+	mi := &file_internal_protocol_message_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4036,7 +4105,7 @@ func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ErrorResponse.ProtoReflect.Descriptor instead.
 func (*ErrorResponse) Descriptor() ([]byte, []int) {
-	return file_internal_protocol_message_proto_rawDescGZIP(), []int{47}
+	return file_internal_protocol_message_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *ErrorResponse) GetSuccess() bool {
@@ -4182,7 +4251,11 @@ const file_internal_protocol_message_proto_rawDesc = "" +
 	"\bentity_a\x18\x01 \x01(\tR\aentityA\x12\x19\n" +
 	"\bentity_b\x18\x02 \x01(\tR\aentityB\x12.\n" +
 	"\bposition\x18\x03 \x01(\v2\x12.protocol.Vector2DR\bposition\x12\x16\n" +
-	"\x06damage\x18\x04 \x01(\x05R\x06damage\"\xb4\x01\n" +
+	"\x06damage\x18\x04 \x01(\x05R\x06damage\"P\n" +
+	"\rServerMessage\x124\n" +
+	"\n" +
+	"game_frame\x18\x01 \x01(\v2\x13.protocol.GameFrameH\x00R\tgameFrameB\t\n" +
+	"\apayload\"\xb4\x01\n" +
 	"\n" +
 	"GameResult\x12\x17\n" +
 	"\aroom_id\x18\x01 \x01(\tR\x06roomId\x12\x1d\n" +
@@ -4465,7 +4538,7 @@ func file_internal_protocol_message_proto_rawDescGZIP() []byte {
 }
 
 var file_internal_protocol_message_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_internal_protocol_message_proto_msgTypes = make([]protoimpl.MessageInfo, 50)
+var file_internal_protocol_message_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
 var file_internal_protocol_message_proto_goTypes = []any{
 	(EntityType)(0),                    // 0: protocol.EntityType
 	(SkillType)(0),                     // 1: protocol.SkillType
@@ -4490,36 +4563,37 @@ var file_internal_protocol_message_proto_goTypes = []any{
 	(*PlayerInput)(nil),                // 20: protocol.PlayerInput
 	(*GameFrame)(nil),                  // 21: protocol.GameFrame
 	(*CollisionEvent)(nil),             // 22: protocol.CollisionEvent
-	(*GameResult)(nil),                 // 23: protocol.GameResult
-	(*PlayerResult)(nil),               // 24: protocol.PlayerResult
-	(*SkillInfo)(nil),                  // 25: protocol.SkillInfo
-	(*CharacterInfo)(nil),              // 26: protocol.CharacterInfo
-	(*PlayerCharacterInfo)(nil),        // 27: protocol.PlayerCharacterInfo
-	(*CharacterUnlockRequirement)(nil), // 28: protocol.CharacterUnlockRequirement
-	(*PlayerProfile)(nil),              // 29: protocol.PlayerProfile
-	(*MatchRecord)(nil),                // 30: protocol.MatchRecord
-	(*PlayerMatchRecord)(nil),          // 31: protocol.PlayerMatchRecord
-	(*PlayerStats)(nil),                // 32: protocol.PlayerStats
-	(*LeaderboardEntry)(nil),           // 33: protocol.LeaderboardEntry
-	(*GameMapInfo)(nil),                // 34: protocol.GameMapInfo
-	(*MatchPreferences)(nil),           // 35: protocol.MatchPreferences
-	(*MatchHistory)(nil),               // 36: protocol.MatchHistory
-	(*CharacterListResponse)(nil),      // 37: protocol.CharacterListResponse
-	(*CharacterDetailResponse)(nil),    // 38: protocol.CharacterDetailResponse
-	(*PlayerCharactersResponse)(nil),   // 39: protocol.PlayerCharactersResponse
-	(*SetDefaultCharacterRequest)(nil), // 40: protocol.SetDefaultCharacterRequest
-	(*PlayerProfileResponse)(nil),      // 41: protocol.PlayerProfileResponse
-	(*UpdatePlayerProfileRequest)(nil), // 42: protocol.UpdatePlayerProfileRequest
-	(*PlayerStatsResponse)(nil),        // 43: protocol.PlayerStatsResponse
-	(*MatchHistoryResponse)(nil),       // 44: protocol.MatchHistoryResponse
-	(*LeaderboardResponse)(nil),        // 45: protocol.LeaderboardResponse
-	(*SetMatchPreferencesRequest)(nil), // 46: protocol.SetMatchPreferencesRequest
-	(*MatchPreferencesResponse)(nil),   // 47: protocol.MatchPreferencesResponse
-	(*MatchHistoryListResponse)(nil),   // 48: protocol.MatchHistoryListResponse
-	(*SuccessResponse)(nil),            // 49: protocol.SuccessResponse
-	(*ErrorResponse)(nil),              // 50: protocol.ErrorResponse
-	nil,                                // 51: protocol.PlayerEntityInfo.SkillCooldownsEntry
-	nil,                                // 52: protocol.GameFrame.ScoresEntry
+	(*ServerMessage)(nil),              // 23: protocol.ServerMessage
+	(*GameResult)(nil),                 // 24: protocol.GameResult
+	(*PlayerResult)(nil),               // 25: protocol.PlayerResult
+	(*SkillInfo)(nil),                  // 26: protocol.SkillInfo
+	(*CharacterInfo)(nil),              // 27: protocol.CharacterInfo
+	(*PlayerCharacterInfo)(nil),        // 28: protocol.PlayerCharacterInfo
+	(*CharacterUnlockRequirement)(nil), // 29: protocol.CharacterUnlockRequirement
+	(*PlayerProfile)(nil),              // 30: protocol.PlayerProfile
+	(*MatchRecord)(nil),                // 31: protocol.MatchRecord
+	(*PlayerMatchRecord)(nil),          // 32: protocol.PlayerMatchRecord
+	(*PlayerStats)(nil),                // 33: protocol.PlayerStats
+	(*LeaderboardEntry)(nil),           // 34: protocol.LeaderboardEntry
+	(*GameMapInfo)(nil),                // 35: protocol.GameMapInfo
+	(*MatchPreferences)(nil),           // 36: protocol.MatchPreferences
+	(*MatchHistory)(nil),               // 37: protocol.MatchHistory
+	(*CharacterListResponse)(nil),      // 38: protocol.CharacterListResponse
+	(*CharacterDetailResponse)(nil),    // 39: protocol.CharacterDetailResponse
+	(*PlayerCharactersResponse)(nil),   // 40: protocol.PlayerCharactersResponse
+	(*SetDefaultCharacterRequest)(nil), // 41: protocol.SetDefaultCharacterRequest
+	(*PlayerProfileResponse)(nil),      // 42: protocol.PlayerProfileResponse
+	(*UpdatePlayerProfileRequest)(nil), // 43: protocol.UpdatePlayerProfileRequest
+	(*PlayerStatsResponse)(nil),        // 44: protocol.PlayerStatsResponse
+	(*MatchHistoryResponse)(nil),       // 45: protocol.MatchHistoryResponse
+	(*LeaderboardResponse)(nil),        // 46: protocol.LeaderboardResponse
+	(*SetMatchPreferencesRequest)(nil), // 47: protocol.SetMatchPreferencesRequest
+	(*MatchPreferencesResponse)(nil),   // 48: protocol.MatchPreferencesResponse
+	(*MatchHistoryListResponse)(nil),   // 49: protocol.MatchHistoryListResponse
+	(*SuccessResponse)(nil),            // 50: protocol.SuccessResponse
+	(*ErrorResponse)(nil),              // 51: protocol.ErrorResponse
+	nil,                                // 52: protocol.PlayerEntityInfo.SkillCooldownsEntry
+	nil,                                // 53: protocol.GameFrame.ScoresEntry
 }
 var file_internal_protocol_message_proto_depIdxs = []int32{
 	2,  // 0: protocol.MatchUpdate.status:type_name -> protocol.MatchUpdate.Status
@@ -4528,7 +4602,7 @@ var file_internal_protocol_message_proto_depIdxs = []int32{
 	4,  // 3: protocol.EntityInfo.position:type_name -> protocol.Vector2D
 	4,  // 4: protocol.EntityInfo.velocity:type_name -> protocol.Vector2D
 	14, // 5: protocol.PlayerEntityInfo.base:type_name -> protocol.EntityInfo
-	51, // 6: protocol.PlayerEntityInfo.skill_cooldowns:type_name -> protocol.PlayerEntityInfo.SkillCooldownsEntry
+	52, // 6: protocol.PlayerEntityInfo.skill_cooldowns:type_name -> protocol.PlayerEntityInfo.SkillCooldownsEntry
 	14, // 7: protocol.ProjectileEntityInfo.base:type_name -> protocol.EntityInfo
 	4,  // 8: protocol.MoveOperation.direction:type_name -> protocol.Vector2D
 	4,  // 9: protocol.SkillOperation.target:type_name -> protocol.Vector2D
@@ -4537,28 +4611,29 @@ var file_internal_protocol_message_proto_depIdxs = []int32{
 	19, // 12: protocol.PlayerInput.skill:type_name -> protocol.SkillOperation
 	14, // 13: protocol.GameFrame.entities:type_name -> protocol.EntityInfo
 	22, // 14: protocol.GameFrame.collisions:type_name -> protocol.CollisionEvent
-	52, // 15: protocol.GameFrame.scores:type_name -> protocol.GameFrame.ScoresEntry
+	53, // 15: protocol.GameFrame.scores:type_name -> protocol.GameFrame.ScoresEntry
 	4,  // 16: protocol.CollisionEvent.position:type_name -> protocol.Vector2D
-	24, // 17: protocol.GameResult.players:type_name -> protocol.PlayerResult
-	1,  // 18: protocol.SkillInfo.type:type_name -> protocol.SkillType
-	25, // 19: protocol.CharacterInfo.skills:type_name -> protocol.SkillInfo
-	27, // 20: protocol.PlayerProfile.characters:type_name -> protocol.PlayerCharacterInfo
-	26, // 21: protocol.PlayerProfile.default_character:type_name -> protocol.CharacterInfo
-	26, // 22: protocol.CharacterListResponse.data:type_name -> protocol.CharacterInfo
-	26, // 23: protocol.CharacterDetailResponse.data:type_name -> protocol.CharacterInfo
-	27, // 24: protocol.PlayerCharactersResponse.data:type_name -> protocol.PlayerCharacterInfo
-	29, // 25: protocol.PlayerProfileResponse.data:type_name -> protocol.PlayerProfile
-	32, // 26: protocol.PlayerStatsResponse.data:type_name -> protocol.PlayerStats
-	31, // 27: protocol.MatchHistoryResponse.data:type_name -> protocol.PlayerMatchRecord
-	33, // 28: protocol.LeaderboardResponse.data:type_name -> protocol.LeaderboardEntry
-	35, // 29: protocol.SetMatchPreferencesRequest.preferences:type_name -> protocol.MatchPreferences
-	35, // 30: protocol.MatchPreferencesResponse.data:type_name -> protocol.MatchPreferences
-	36, // 31: protocol.MatchHistoryListResponse.data:type_name -> protocol.MatchHistory
-	32, // [32:32] is the sub-list for method output_type
-	32, // [32:32] is the sub-list for method input_type
-	32, // [32:32] is the sub-list for extension type_name
-	32, // [32:32] is the sub-list for extension extendee
-	0,  // [0:32] is the sub-list for field type_name
+	21, // 17: protocol.ServerMessage.game_frame:type_name -> protocol.GameFrame
+	25, // 18: protocol.GameResult.players:type_name -> protocol.PlayerResult
+	1,  // 19: protocol.SkillInfo.type:type_name -> protocol.SkillType
+	26, // 20: protocol.CharacterInfo.skills:type_name -> protocol.SkillInfo
+	28, // 21: protocol.PlayerProfile.characters:type_name -> protocol.PlayerCharacterInfo
+	27, // 22: protocol.PlayerProfile.default_character:type_name -> protocol.CharacterInfo
+	27, // 23: protocol.CharacterListResponse.data:type_name -> protocol.CharacterInfo
+	27, // 24: protocol.CharacterDetailResponse.data:type_name -> protocol.CharacterInfo
+	28, // 25: protocol.PlayerCharactersResponse.data:type_name -> protocol.PlayerCharacterInfo
+	30, // 26: protocol.PlayerProfileResponse.data:type_name -> protocol.PlayerProfile
+	33, // 27: protocol.PlayerStatsResponse.data:type_name -> protocol.PlayerStats
+	32, // 28: protocol.MatchHistoryResponse.data:type_name -> protocol.PlayerMatchRecord
+	34, // 29: protocol.LeaderboardResponse.data:type_name -> protocol.LeaderboardEntry
+	36, // 30: protocol.SetMatchPreferencesRequest.preferences:type_name -> protocol.MatchPreferences
+	36, // 31: protocol.MatchPreferencesResponse.data:type_name -> protocol.MatchPreferences
+	37, // 32: protocol.MatchHistoryListResponse.data:type_name -> protocol.MatchHistory
+	33, // [33:33] is the sub-list for method output_type
+	33, // [33:33] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
 }
 
 func init() { file_internal_protocol_message_proto_init() }
@@ -4566,13 +4641,16 @@ func file_internal_protocol_message_proto_init() {
 	if File_internal_protocol_message_proto != nil {
 		return
 	}
+	file_internal_protocol_message_proto_msgTypes[20].OneofWrappers = []any{
+		(*ServerMessage_GameFrame)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_internal_protocol_message_proto_rawDesc), len(file_internal_protocol_message_proto_rawDesc)),
 			NumEnums:      3,
-			NumMessages:   50,
+			NumMessages:   51,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,26 @@
+// version.go
+
+package protocol
+
+const (
+	// ProtocolVersion 服务端实现的最新协议版本。版本 2 引入握手，版本 3 引入带序号和确认的消息信封及断线恢复，
+	// 版本 4 引入应用层心跳
+	ProtocolVersion = 4
+	// MinProtocolVersion 服务端仍兼容的最低协议版本。版本 1 为引入握手之前的客户端，
+	// 连接后不发送 hello 直接发送其他消息
+	MinProtocolVersion = 1
+	// HeartbeatProtocolVersion 服务端发送应用层心跳的最低协议版本，客户端需回复 pong
+	HeartbeatProtocolVersion = 4
+)
+
+// 客户端在 hello 中声明、服务端在 welcome 中确认的可选能力
+const (
+	// CapabilityProtobuf 实时消息使用 protobuf 二进制帧
+	CapabilityProtobuf = "protobuf"
+	// CapabilityMsgpack 所有消息使用 MessagePack 二进制帧，供无法使用 protobuf 的客户端减小消息体积。
+	// 与 protobuf 同时声明时选择 protobuf
+	CapabilityMsgpack = "msgpack"
+)
+
+// Capabilities 服务端支持的全部可选能力
+var Capabilities = []string{CapabilityProtobuf, CapabilityMsgpack}
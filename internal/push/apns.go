@@ -0,0 +1,166 @@
+// apns.go
+
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/jws"
+)
+
+// APNs接口地址
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+	// apnsTokenTTL 认证令牌有效期，苹果要求20到60分钟之间刷新
+	apnsTokenTTL = 50 * time.Minute
+)
+
+// APNsSender 通过APNs令牌认证推送iOS设备
+type APNsSender struct {
+	baseURL string
+	keyID   string
+	teamID  string
+	topic   string
+	key     *ecdsa.PrivateKey
+	client  *http.Client
+
+	// 认证令牌缓存
+	mu       sync.Mutex
+	jwt      string
+	issuedAt time.Time
+}
+
+// NewAPNsSender 读取.p8私钥创建APNs推送
+func NewAPNsSender(cfg config.APNsConfig) (*APNsSender, error) {
+	if cfg.KeyID == "" || cfg.TeamID == "" || cfg.Topic == "" {
+		return nil, errors.New("APNs需要配置 key_id、team_id 和 topic")
+	}
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取APNs私钥失败: %w", err)
+	}
+	key, err := jws.ParsePKCS8Key(data)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("APNs私钥不是ECDSA密钥")
+	}
+
+	baseURL := apnsProductionURL
+	if cfg.Sandbox {
+		baseURL = apnsSandboxURL
+	}
+	return &APNsSender{
+		baseURL: baseURL,
+		keyID:   cfg.KeyID,
+		teamID:  cfg.TeamID,
+		topic:   cfg.Topic,
+		key:     ecKey,
+		// APNs 要求 HTTP/2，默认传输层在TLS下会自动协商
+		client: &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+// Send 发送推送，设备令牌无效或已注销时返回 ErrInvalidToken
+func (a *APNsSender) Send(ctx context.Context, token string, n *models.PushNotification) error {
+	authToken, err := a.token()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{"title": n.Title, "body": n.Body},
+			"sound": "default",
+		},
+		"category": string(n.Category),
+	}
+	for k, v := range n.Data {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/3/device/"+token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求APNs失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var result struct {
+		Reason string `json:"reason"`
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(msg, &result)
+	if resp.StatusCode == http.StatusGone || result.Reason == "BadDeviceToken" || result.Reason == "Unregistered" {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("APNs返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+// token 获取认证令牌，超过有效期后重新签发
+func (a *APNsSender) token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.jwt != "" && time.Since(a.issuedAt) < apnsTokenTTL {
+		return a.jwt, nil
+	}
+
+	now := time.Now()
+	jwt, err := jws.Sign(
+		map[string]string{"alg": "ES256", "kid": a.keyID},
+		map[string]interface{}{"iss": a.teamID, "iat": now.Unix()},
+		func(input []byte) ([]byte, error) {
+			hash := sha256.Sum256(input)
+			r, s, err := ecdsa.Sign(rand.Reader, a.key, hash[:])
+			if err != nil {
+				return nil, err
+			}
+			// JWS 要求 r 和 s 各32字节定长拼接
+			sig := make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+			return sig, nil
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	a.jwt = jwt
+	a.issuedAt = now
+	return a.jwt, nil
+}
@@ -0,0 +1,98 @@
+// fcm.go
+
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/googleauth"
+)
+
+// FCM接口地址
+const (
+	fcmScope   = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmSendURL = "https://fcm.googleapis.com/v1/projects/%s/messages:send"
+)
+
+// FCMSender 通过FCM HTTP v1接口推送安卓设备
+type FCMSender struct {
+	projectID string
+	tokens    *googleauth.TokenSource
+	client    *http.Client
+}
+
+// NewFCMSender 读取服务账号文件创建FCM推送
+func NewFCMSender(cfg config.FCMConfig) (*FCMSender, error) {
+	tokens, err := googleauth.NewTokenSource(cfg.CredentialsFile, fcmScope)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID := cfg.ProjectID
+	if projectID == "" {
+		projectID = tokens.ProjectID()
+	}
+	if projectID == "" {
+		return nil, errors.New("未配置FCM项目ID")
+	}
+
+	return &FCMSender{
+		projectID: projectID,
+		tokens:    tokens,
+		client:    &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+// Send 发送推送，设备未注册时返回 ErrInvalidToken
+func (f *FCMSender) Send(ctx context.Context, token string, n *models.PushNotification) error {
+	accessToken, err := f.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{"category": string(n.Category)}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token":        token,
+			"notification": map[string]string{"title": n.Title, "body": n.Body},
+			"data":         data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(fcmSendURL, f.projectID), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求FCM失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusNotFound || strings.Contains(string(msg), "UNREGISTERED") {
+		return ErrInvalidToken
+	}
+	return fmt.Errorf("FCM返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+}
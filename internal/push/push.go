@@ -0,0 +1,292 @@
+// push.go
+
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/notify"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 推送限制
+const (
+	// maxTokenLength 设备令牌最大长度
+	maxTokenLength = 512
+	// maxDevicesPerPlayer 每名玩家最多注册的设备数，超出时移除最早的设备
+	maxDevicesPerPlayer = 10
+	// sendTimeout 单次推送发送超时
+	sendTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidDevice 设备参数无效
+	ErrInvalidDevice = errcode.New(errcode.BadRequest, "无效的推送设备")
+	// ErrInvalidCategory 推送类别无效
+	ErrInvalidCategory = errcode.New(errcode.BadRequest, "无效的推送类别")
+	// ErrInvalidToken 设备令牌已失效，发送方返回该错误时删除设备
+	ErrInvalidToken = errors.New("设备令牌已失效")
+)
+
+// Sender 向单个设备发送推送
+type Sender interface {
+	Send(ctx context.Context, token string, n *models.PushNotification) error
+}
+
+// Service 推送服务，通知异步发送，失败只记录日志
+type Service struct {
+	enabled bool
+	senders map[models.DevicePlatform]Sender
+}
+
+// NewService 根据配置创建推送服务，未配置凭据的平台不发送推送
+func NewService(cfg config.PushConfig) *Service {
+	s := &Service{
+		enabled: cfg.Enabled,
+		senders: make(map[models.DevicePlatform]Sender),
+	}
+	if !cfg.Enabled {
+		return s
+	}
+
+	if cfg.FCM.CredentialsFile != "" {
+		sender, err := NewFCMSender(cfg.FCM)
+		if err != nil {
+			log.Printf("FCM推送初始化失败，安卓设备不推送: %v", err)
+		} else {
+			s.senders[models.PlatformAndroid] = sender
+		}
+	}
+	if cfg.APNs.KeyFile != "" {
+		sender, err := NewAPNsSender(cfg.APNs)
+		if err != nil {
+			log.Printf("APNs推送初始化失败，iOS设备不推送: %v", err)
+		} else {
+			s.senders[models.PlatformIOS] = sender
+		}
+	}
+	return s
+}
+
+// RegisterDevice 注册推送设备，令牌已被其他玩家注册时转移到当前玩家
+func RegisterDevice(playerID int64, req *models.RegisterDeviceRequest) (*models.PushDevice, error) {
+	if !req.Platform.IsValid() {
+		return nil, fmt.Errorf("%w: 平台必须为 ios 或 android", ErrInvalidDevice)
+	}
+	if req.Token == "" || len(req.Token) > maxTokenLength {
+		return nil, fmt.Errorf("%w: 令牌不能为空且不超过 %d 个字符", ErrInvalidDevice, maxTokenLength)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	d := &models.PushDevice{PlayerID: playerID, Platform: req.Platform, Token: req.Token}
+	err = tx.QueryRow(`
+		INSERT INTO push_devices (player_id, platform, token)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token) DO UPDATE SET
+			player_id = EXCLUDED.player_id, platform = EXCLUDED.platform, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`, playerID, string(req.Platform), req.Token).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("注册推送设备失败: %w", err)
+	}
+
+	// 只保留最近使用的设备
+	_, err = tx.Exec(`
+		DELETE FROM push_devices WHERE player_id = $1 AND id NOT IN (
+			SELECT id FROM push_devices WHERE player_id = $1 ORDER BY updated_at DESC LIMIT $2
+		)
+	`, playerID, maxDevicesPerPlayer)
+	if err != nil {
+		return nil, fmt.Errorf("清理推送设备失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return d, nil
+}
+
+// UnregisterDevice 注销推送设备（如退出登录）
+func UnregisterDevice(playerID int64, token string) error {
+	_, err := db.DB.Exec(`DELETE FROM push_devices WHERE player_id = $1 AND token = $2`, playerID, token)
+	if err != nil {
+		return fmt.Errorf("注销推送设备失败: %w", err)
+	}
+	return nil
+}
+
+// Preferences 查询玩家各推送类别的开关，未设置的类别默认开启
+func Preferences(playerID int64) (models.PushPreferences, error) {
+	prefs := make(models.PushPreferences, len(models.PushCategories))
+	for _, category := range models.PushCategories {
+		prefs[category] = true
+	}
+
+	rows, err := db.DB.Query(`SELECT category, enabled FROM push_preferences WHERE player_id = $1`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询推送设置失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category models.PushCategory
+		var enabled bool
+		if err := rows.Scan(&category, &enabled); err != nil {
+			return nil, fmt.Errorf("扫描推送设置失败: %w", err)
+		}
+		if category.IsValid() {
+			prefs[category] = enabled
+		}
+	}
+	return prefs, rows.Err()
+}
+
+// UpdatePreferences 更新推送类别开关，未指定的类别保持不变
+func UpdatePreferences(playerID int64, update models.PushPreferences) (models.PushPreferences, error) {
+	for category := range update {
+		if !category.IsValid() {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCategory, category)
+		}
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	for category, enabled := range update {
+		_, err := tx.Exec(`
+			INSERT INTO push_preferences (player_id, category, enabled)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (player_id, category) DO UPDATE SET enabled = EXCLUDED.enabled
+		`, playerID, string(category), enabled)
+		if err != nil {
+			return nil, fmt.Errorf("更新推送设置失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return Preferences(playerID)
+}
+
+// Notify 投递给玩家的在线通知订阅（如 SSE），并异步向玩家的所有设备发送推送，
+// 玩家关闭了该类别时不发送推送
+func (s *Service) Notify(playerID int64, n *models.PushNotification) {
+	if s == nil {
+		return
+	}
+	notify.Publish(playerID, n)
+
+	if !s.enabled || len(s.senders) == 0 || db.DB == nil {
+		return
+	}
+	go s.send(playerID, n)
+}
+
+// NotifyMatchFound 通知玩家匹配成功
+func (s *Service) NotifyMatchFound(playerID int64, roomID string, mode models.GameMode) {
+	s.Notify(playerID, &models.PushNotification{
+		Category: models.PushMatchFound,
+		Title:    "匹配成功",
+		Body:     "已为你找到对局，请尽快返回游戏",
+		Data:     map[string]string{"room_id": roomID, "game_mode": string(mode)},
+	})
+}
+
+// NotifyPartyInvite 通知玩家收到组队邀请
+func (s *Service) NotifyPartyInvite(invite *models.PartyInvite) {
+	s.Notify(invite.ToID, &models.PushNotification{
+		Category: models.PushPartyInvite,
+		Title:    "组队邀请",
+		Body:     "有玩家邀请你加入队伍",
+		Data: map[string]string{
+			"party_id": invite.PartyID,
+			"from_id":  strconv.FormatInt(invite.FromID, 10),
+		},
+	})
+}
+
+// NotifyMail 通知玩家收到新邮件
+func (s *Service) NotifyMail(playerID int64, title string) {
+	s.Notify(playerID, &models.PushNotification{
+		Category: models.PushMail,
+		Title:    "新邮件",
+		Body:     title,
+	})
+}
+
+// send 检查玩家推送设置后逐个设备发送，删除已失效的设备
+func (s *Service) send(playerID int64, n *models.PushNotification) {
+	prefs, err := Preferences(playerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 推送设置失败: %v", playerID, err)
+		return
+	}
+	if !prefs[n.Category] {
+		return
+	}
+
+	devices, err := devices(playerID)
+	if err != nil {
+		log.Printf("查询玩家 %d 推送设备失败: %v", playerID, err)
+		return
+	}
+
+	for _, d := range devices {
+		sender, ok := s.senders[d.Platform]
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err := sender.Send(ctx, d.Token, n)
+		cancel()
+		if errors.Is(err, ErrInvalidToken) {
+			if _, err := db.DB.Exec(`DELETE FROM push_devices WHERE id = $1`, d.ID); err != nil {
+				log.Printf("删除失效推送设备 %d 失败: %v", d.ID, err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("向玩家 %d 的 %s 设备推送失败: %v", playerID, d.Platform, err)
+		}
+	}
+}
+
+// devices 查询玩家的推送设备
+func devices(playerID int64) ([]models.PushDevice, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, player_id, platform, token, created_at, updated_at
+		FROM push_devices WHERE player_id = $1
+	`, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []models.PushDevice
+	for rows.Next() {
+		var d models.PushDevice
+		if err := rows.Scan(&d.ID, &d.PlayerID, &d.Platform, &d.Token, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
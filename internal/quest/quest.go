@@ -0,0 +1,469 @@
+// quest.go
+
+package quest
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/battlepass"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 每个周期分配的任务数量与可刷新次数
+const (
+	dailyQuestCount  = 3
+	weeklyQuestCount = 2
+	rerollsPerPeriod = 1
+)
+
+var (
+	// ErrQuestNotFound 任务不存在
+	ErrQuestNotFound = errcode.New(errcode.NotFound, "任务不存在")
+	// ErrQuestNotCompleted 任务未完成
+	ErrQuestNotCompleted = errcode.New(errcode.NotCompleted, "任务未完成")
+	// ErrQuestCompleted 任务已完成，不能刷新
+	ErrQuestCompleted = errcode.New(errcode.Conflict, "任务已完成")
+	// ErrQuestClaimed 奖励已领取
+	ErrQuestClaimed = errcode.New(errcode.AlreadyClaimed, "任务奖励已领取")
+	// ErrRerollLimit 刷新次数已用完
+	ErrRerollLimit = errcode.New(errcode.LimitReached, "本周期刷新次数已用完")
+	// ErrNoReplacement 没有可替换的任务
+	ErrNoReplacement = errcode.New(errcode.NotAllowed, "没有可替换的任务")
+	// ErrInvalidQuest 任务定义无效
+	ErrInvalidQuest = errcode.New(errcode.BadRequest, "无效的任务定义")
+)
+
+// Service 任务服务
+type Service struct {
+	wallet     *wallet.Service
+	battlePass *battlepass.Service
+}
+
+// NewService 创建任务服务
+func NewService(w *wallet.Service, bp *battlepass.Service) *Service {
+	return &Service{wallet: w, battlePass: bp}
+}
+
+// PeriodStart 返回时间所在周期的开始时间（UTC，每周从周一开始）
+func PeriodStart(period models.QuestPeriod, now time.Time) time.Time {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == models.QuestWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// PeriodEnd 返回周期的结束时间
+func PeriodEnd(period models.QuestPeriod, start time.Time) time.Time {
+	if period == models.QuestWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// dateKey 将周期开始时间格式化为DATE参数，避免受数据库会话时区影响
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// questCount 周期内分配的任务数量
+func questCount(period models.QuestPeriod) int {
+	if period == models.QuestWeekly {
+		return weeklyQuestCount
+	}
+	return dailyQuestCount
+}
+
+// questColumns 任务定义查询字段
+const questColumns = `q.id, q.name, COALESCE(q.description, ''), q.period, q.objective, q.target,
+	COALESCE(q.character_id, 0), COALESCE(q.character_role, ''), COALESCE(q.game_mode, ''),
+	q.reward_currency, q.reward_amount, q.reward_pass_xp, q.active, q.created_at`
+
+// scanQuestArgs 返回任务定义的扫描目标
+func scanQuestArgs(q *models.Quest) []interface{} {
+	return []interface{}{&q.ID, &q.Name, &q.Description, &q.Period, &q.Objective, &q.Target,
+		&q.CharacterID, &q.CharacterRole, &q.GameMode,
+		&q.RewardCurrency, &q.RewardAmount, &q.RewardPassXP, &q.Active, &q.CreatedAt}
+}
+
+// scanPlayerQuest 扫描玩家任务
+func scanPlayerQuest(row interface{ Scan(...interface{}) error }) (*models.PlayerQuest, error) {
+	var pq models.PlayerQuest
+	args := []interface{}{&pq.ID, &pq.PlayerID, &pq.PeriodStart, &pq.Progress, &pq.Rerolled,
+		&pq.CompletedAt, &pq.ClaimedAt}
+	if err := row.Scan(append(args, scanQuestArgs(&pq.Quest)...)...); err != nil {
+		return nil, err
+	}
+	pq.PeriodEnd = PeriodEnd(pq.Quest.Period, pq.PeriodStart)
+	return &pq, nil
+}
+
+// playerQuestQuery 玩家任务查询语句前缀
+const playerQuestQuery = `
+	SELECT pq.id, pq.player_id, pq.period_start, pq.progress, pq.rerolled,
+	       pq.completed_at, pq.claimed_at, ` + questColumns + `
+	FROM player_quests pq
+	JOIN quests q ON q.id = pq.quest_id
+`
+
+// ensureAssigned 为玩家补齐当前周期的任务
+func (s *Service) ensureAssigned(playerID int64, now time.Time) error {
+	for _, period := range []models.QuestPeriod{models.QuestDaily, models.QuestWeekly} {
+		start := dateKey(PeriodStart(period, now))
+
+		var assigned int
+		err := db.DB.QueryRow(`
+			SELECT COUNT(*) FROM player_quests pq
+			JOIN quests q ON q.id = pq.quest_id
+			WHERE pq.player_id = $1 AND pq.period_start = $2 AND q.period = $3
+		`, playerID, start, string(period)).Scan(&assigned)
+		if err != nil {
+			return fmt.Errorf("查询已分配任务失败: %w", err)
+		}
+
+		missing := questCount(period) - assigned
+		if missing <= 0 {
+			continue
+		}
+
+		_, err = db.DB.Exec(`
+			INSERT INTO player_quests (player_id, quest_id, period_start)
+			SELECT $1, q.id, $2 FROM quests q
+			WHERE q.active AND q.period = $3
+			  AND q.id NOT IN (SELECT quest_id FROM player_quests WHERE player_id = $1 AND period_start = $2)
+			ORDER BY RANDOM()
+			LIMIT $4
+			ON CONFLICT DO NOTHING
+		`, playerID, start, string(period), missing)
+		if err != nil {
+			return fmt.Errorf("分配任务失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// PlayerQuests 获取玩家当前周期的任务，首次查询时自动分配
+func (s *Service) PlayerQuests(playerID int64) ([]models.PlayerQuest, error) {
+	now := time.Now()
+	if err := s.ensureAssigned(playerID, now); err != nil {
+		return nil, err
+	}
+	return s.currentQuests(playerID, now)
+}
+
+// currentQuests 查询玩家当前周期的任务
+func (s *Service) currentQuests(playerID int64, now time.Time) ([]models.PlayerQuest, error) {
+	rows, err := db.DB.Query(playerQuestQuery+`
+		WHERE pq.player_id = $1
+		  AND ((q.period = 'daily' AND pq.period_start = $2) OR (q.period = 'weekly' AND pq.period_start = $3))
+		ORDER BY q.period, pq.id
+	`, playerID, dateKey(PeriodStart(models.QuestDaily, now)), dateKey(PeriodStart(models.QuestWeekly, now)))
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家任务失败: %w", err)
+	}
+	defer rows.Close()
+
+	quests := make([]models.PlayerQuest, 0)
+	for rows.Next() {
+		pq, err := scanPlayerQuest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描玩家任务失败: %w", err)
+		}
+		quests = append(quests, *pq)
+	}
+	return quests, rows.Err()
+}
+
+// questIncrement 计算一局对局对任务的进度贡献
+func questIncrement(q *models.Quest, p *models.PlayerMatchRecord, mode models.GameMode, role string) int {
+	if q.CharacterID != 0 && q.CharacterID != p.CharacterID {
+		return 0
+	}
+	if q.CharacterRole != "" && q.CharacterRole != role {
+		return 0
+	}
+	if q.GameMode != "" && q.GameMode != mode {
+		return 0
+	}
+
+	switch q.Objective {
+	case models.ObjectivePlay:
+		return 1
+	case models.ObjectiveWin:
+		if p.Won {
+			return 1
+		}
+	case models.ObjectiveKill:
+		return p.Kills
+	case models.ObjectiveAssist:
+		return p.Assists
+	case models.ObjectiveMVP:
+		if p.MVP {
+			return 1
+		}
+	}
+	return 0
+}
+
+// RecordMatch 根据对局结果更新玩家任务进度
+func (s *Service) RecordMatch(p *models.PlayerMatchRecord, mode models.GameMode) error {
+	now := time.Now()
+	if err := s.ensureAssigned(p.PlayerID, now); err != nil {
+		return err
+	}
+
+	quests, err := s.currentQuests(p.PlayerID, now)
+	if err != nil {
+		return err
+	}
+
+	role, err := characterRole(p.CharacterID)
+	if err != nil {
+		return err
+	}
+
+	for _, pq := range quests {
+		if pq.Completed() {
+			continue
+		}
+		inc := questIncrement(&pq.Quest, p, mode, role)
+		if inc <= 0 {
+			continue
+		}
+
+		_, err := db.DB.Exec(`
+			UPDATE player_quests SET
+				progress = LEAST(progress + $2, $3),
+				completed_at = CASE WHEN progress + $2 >= $3 THEN NOW() ELSE NULL END
+			WHERE id = $1 AND completed_at IS NULL
+		`, pq.ID, inc, pq.Quest.Target)
+		if err != nil {
+			return fmt.Errorf("更新任务 %d 进度失败: %w", pq.ID, err)
+		}
+	}
+	return nil
+}
+
+// getPlayerQuestTx 在事务中锁定并查询玩家任务
+func getPlayerQuestTx(tx *sql.Tx, playerID, playerQuestID int64) (*models.PlayerQuest, error) {
+	pq, err := scanPlayerQuest(tx.QueryRow(playerQuestQuery+`
+		WHERE pq.id = $1 AND pq.player_id = $2
+		FOR UPDATE OF pq
+	`, playerQuestID, playerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrQuestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家任务失败: %w", err)
+	}
+	return pq, nil
+}
+
+// Reroll 将一个未完成的任务替换为同周期的其他任务
+func (s *Service) Reroll(playerID, playerQuestID int64) (*models.PlayerQuest, error) {
+	now := time.Now()
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	pq, err := getPlayerQuestTx(tx, playerID, playerQuestID)
+	if err != nil {
+		return nil, err
+	}
+	period := pq.Quest.Period
+	if dateKey(pq.PeriodStart) != dateKey(PeriodStart(period, now)) {
+		return nil, ErrQuestNotFound
+	}
+	if pq.Completed() {
+		return nil, ErrQuestCompleted
+	}
+
+	var rerolls int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM player_quests pq
+		JOIN quests q ON q.id = pq.quest_id
+		WHERE pq.player_id = $1 AND pq.period_start = $2 AND q.period = $3 AND pq.rerolled
+	`, playerID, dateKey(pq.PeriodStart), string(period)).Scan(&rerolls)
+	if err != nil {
+		return nil, fmt.Errorf("查询刷新次数失败: %w", err)
+	}
+	if rerolls >= rerollsPerPeriod {
+		return nil, ErrRerollLimit
+	}
+
+	var newID int64
+	err = tx.QueryRow(`
+		INSERT INTO player_quests (player_id, quest_id, period_start, rerolled)
+		SELECT $1, q.id, $2, true FROM quests q
+		WHERE q.active AND q.period = $3
+		  AND q.id NOT IN (SELECT quest_id FROM player_quests WHERE player_id = $1 AND period_start = $2)
+		ORDER BY RANDOM()
+		LIMIT 1
+		RETURNING id
+	`, playerID, dateKey(pq.PeriodStart), string(period)).Scan(&newID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoReplacement
+	}
+	if err != nil {
+		return nil, fmt.Errorf("分配替换任务失败: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM player_quests WHERE id = $1`, pq.ID); err != nil {
+		return nil, fmt.Errorf("移除原任务失败: %w", err)
+	}
+
+	replacement, err := getPlayerQuestTx(tx, playerID, newID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return replacement, nil
+}
+
+// Claim 领取已完成任务的奖励
+func (s *Service) Claim(playerID, playerQuestID int64) (*models.PlayerQuest, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	pq, err := getPlayerQuestTx(tx, playerID, playerQuestID)
+	if err != nil {
+		return nil, err
+	}
+	if !pq.Completed() {
+		return nil, ErrQuestNotCompleted
+	}
+	if pq.Claimed() {
+		return nil, ErrQuestClaimed
+	}
+
+	claimedAt := time.Now()
+	if _, err := tx.Exec(`UPDATE player_quests SET claimed_at = $2 WHERE id = $1`, pq.ID, claimedAt); err != nil {
+		return nil, fmt.Errorf("更新领取状态失败: %w", err)
+	}
+	pq.ClaimedAt = &claimedAt
+
+	if pq.Quest.RewardAmount > 0 {
+		_, err := s.wallet.ApplyTx(tx, models.TransactionRequest{
+			PlayerID:       playerID,
+			Currency:       pq.Quest.RewardCurrency,
+			Amount:         pq.Quest.RewardAmount,
+			Reason:         models.ReasonQuestReward,
+			ReferenceID:    fmt.Sprintf("quest:%d", pq.Quest.ID),
+			IdempotencyKey: fmt.Sprintf("quest:%d", pq.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	// 通行证经验在事务外发放，失败不影响已领取的货币奖励
+	if err := s.battlePass.AddXP(playerID, pq.Quest.RewardPassXP); err != nil {
+		log.Printf("发放任务 %d 通行证经验失败: %v", pq.ID, err)
+	}
+
+	return pq, nil
+}
+
+// ListQuests 查询全部任务定义
+func (s *Service) ListQuests() ([]models.Quest, error) {
+	rows, err := db.DB.Query(`SELECT ` + questColumns + ` FROM quests q ORDER BY q.period, q.id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务定义失败: %w", err)
+	}
+	defer rows.Close()
+
+	quests := make([]models.Quest, 0)
+	for rows.Next() {
+		var q models.Quest
+		if err := rows.Scan(scanQuestArgs(&q)...); err != nil {
+			return nil, fmt.Errorf("扫描任务定义失败: %w", err)
+		}
+		quests = append(quests, q)
+	}
+	return quests, rows.Err()
+}
+
+// CreateQuest 创建任务定义
+func (s *Service) CreateQuest(q *models.Quest) error {
+	if q.Name == "" || !q.Period.IsValid() || !q.Objective.IsValid() || q.Target <= 0 {
+		return fmt.Errorf("%w: 名称、周期、目标类型和目标数量必须有效", ErrInvalidQuest)
+	}
+	if q.RewardCurrency == "" {
+		q.RewardCurrency = models.CurrencyCoins
+	}
+	if !q.RewardCurrency.IsValid() || q.RewardAmount < 0 || q.RewardPassXP < 0 {
+		return fmt.Errorf("%w: 无效的任务奖励", ErrInvalidQuest)
+	}
+	if q.GameMode != "" && !q.GameMode.IsValid() {
+		return fmt.Errorf("%w: 无效的游戏模式 %q", ErrInvalidQuest, q.GameMode)
+	}
+
+	var characterID, role, mode interface{}
+	if q.CharacterID > 0 {
+		characterID = q.CharacterID
+	}
+	if q.CharacterRole != "" {
+		role = q.CharacterRole
+	}
+	if q.GameMode != "" {
+		mode = string(q.GameMode)
+	}
+
+	q.Active = true
+	return db.DB.QueryRow(`
+		INSERT INTO quests (name, description, period, objective, target, character_id, character_role,
+		                    game_mode, reward_currency, reward_amount, reward_pass_xp)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, q.Name, q.Description, string(q.Period), string(q.Objective), q.Target, characterID, role,
+		mode, string(q.RewardCurrency), q.RewardAmount, q.RewardPassXP).Scan(&q.ID, &q.CreatedAt)
+}
+
+// DisableQuest 停用任务定义，已分配的任务不受影响
+func (s *Service) DisableQuest(questID int) error {
+	result, err := db.DB.Exec(`UPDATE quests SET active = false WHERE id = $1`, questID)
+	if err != nil {
+		return fmt.Errorf("停用任务失败: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrQuestNotFound
+	}
+	return nil
+}
+
+// characterRole 查询角色定位，优先使用静态数据缓存
+func characterRole(characterID int) (string, error) {
+	if catalog := gamedata.Current(); catalog != nil {
+		if char, ok := catalog.Character(characterID); ok {
+			return char.Role, nil
+		}
+	}
+
+	var role string
+	if err := db.DB.QueryRow(`SELECT COALESCE(role, '') FROM characters WHERE id = $1`, characterID).Scan(&role); err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("查询角色定位失败: %w", err)
+	}
+	return role, nil
+}
@@ -0,0 +1,186 @@
+// registry.go
+
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+)
+
+const (
+	// SecretHeader 注册接口密钥请求头
+	SecretHeader = "X-Registry-Key"
+
+	// RegisterPath 网关的服务注册接口，POST 注册或发送心跳，DELETE 注销
+	RegisterPath = "/services/register"
+
+	// DefaultHeartbeatInterval 未配置时的心跳间隔
+	DefaultHeartbeatInterval = 10 * time.Second
+
+	// requestTimeout 单次注册请求超时
+	requestTimeout = 5 * time.Second
+)
+
+// Registration 服务实例的注册信息，每次心跳重新发送以更新负载
+type Registration struct {
+	ID       string `json:"id"`               // 实例ID，由服务类型、主机名和端口组成，重启后不变
+	Type     string `json:"type"`             // 服务类型：game 或 match
+	Address  string `json:"address"`          // 网关访问本实例的地址，如 http://10.0.0.5:8081
+	Region   string `json:"region,omitempty"` // 实例所在区域
+	Capacity int    `json:"capacity"`         // 可承载的玩家数，0 表示不限
+	Load     int    `json:"load"`             // 当前的玩家数
+
+	// 客户端直连本实例的地址，网关据此向客户端提供区域延迟探测地址
+	PublicURL string `json:"public_url,omitempty"`
+}
+
+// LoadFunc 返回实例的容量和当前负载
+type LoadFunc func() (capacity, load int)
+
+// Registrar 向网关注册服务实例并定期发送心跳
+type Registrar struct {
+	endpoint string
+	secret   string
+	interval time.Duration
+	reg      Registration
+	load     LoadFunc
+	client   *http.Client
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New 创建注册器，未配置网关地址或密钥时返回nil，此时各方法均不执行任何操作
+func New(cfg config.RegistryConfig, serviceType string, port int, load LoadFunc) *Registrar {
+	if cfg.GatewayURL == "" || cfg.Secret == "" {
+		return nil
+	}
+	host := cfg.AdvertiseHost
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	interval := cfg.HeartbeatInterval
+	if interval <= 0 {
+		interval = DefaultHeartbeatInterval
+	}
+
+	return &Registrar{
+		endpoint: strings.TrimRight(cfg.GatewayURL, "/") + RegisterPath,
+		secret:   cfg.Secret,
+		interval: interval,
+		reg: Registration{
+			ID:      fmt.Sprintf("%s-%s-%d", serviceType, host, port),
+			Type:    serviceType,
+			Address: "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+			Region:  cfg.Region,
+
+			PublicURL: strings.TrimRight(cfg.PublicURL, "/"),
+		},
+		load:   load,
+		client: &http.Client{Timeout: requestTimeout},
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start 立即注册并开始定期发送心跳。网关未启动时注册失败不影响服务，下一次心跳时重试
+func (r *Registrar) Start() {
+	if r == nil {
+		return
+	}
+	go r.run()
+}
+
+// Stop 停止心跳并从网关注销，网关随即不再转发请求到本实例
+func (r *Registrar) Stop() {
+	if r == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+
+	if err := r.send(http.MethodDelete, nil); err != nil {
+		log.Printf("从网关注销 %s 失败: %v", r.reg.ID, err)
+		return
+	}
+	log.Printf("已从网关注销 %s", r.reg.ID)
+}
+
+// run 心跳循环，只在注册成功与失败之间切换时记录日志，网关不可用期间不重复记录
+func (r *Registrar) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var registered, failed bool
+	for {
+		if err := r.heartbeat(); err != nil {
+			if !failed {
+				log.Printf("向网关注册 %s 失败，每 %s 重试: %v", r.reg.ID, r.interval, err)
+			}
+			registered, failed = false, true
+		} else if !registered {
+			log.Printf("已向网关注册 %s (%s, 区域 %q)", r.reg.ID, r.reg.Address, r.reg.Region)
+			registered, failed = true, false
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// heartbeat 发送包含当前负载的注册信息
+func (r *Registrar) heartbeat() error {
+	reg := r.reg
+	if r.load != nil {
+		reg.Capacity, reg.Load = r.load()
+	}
+	body, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return r.send(http.MethodPost, body)
+}
+
+// send 发送注册或注销请求，注销时实例通过查询参数指定
+func (r *Registrar) send(method string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	endpoint := r.endpoint
+	if method == http.MethodDelete {
+		endpoint += "?" + url.Values{"type": {r.reg.Type}, "id": {r.reg.ID}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SecretHeader, r.secret)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("网关返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
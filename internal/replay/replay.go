@@ -0,0 +1,167 @@
+// replay.go
+
+package replay
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/privacy"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// ErrReplayNotFound 对局不存在或已归档
+var ErrReplayNotFound = errcode.New(errcode.NotFound, "回放不存在或已归档")
+
+// Get 查询对局的回放索引。链接可分享给任何人，对查看者隐藏了对局历史的玩家不出现在回放中，
+// 其作为对手出现的事件隐去ID
+func Get(viewerID int64, matchID string) (*models.Replay, error) {
+	r := &models.Replay{
+		MatchID:    matchID,
+		Players:    make([]models.ReplayPlayer, 0),
+		Events:     make([]models.PositionEvent, 0),
+		Highlights: make([]models.Highlight, 0),
+	}
+	err := db.DB.QueryRow(`
+		SELECT game_mode, COALESCE(map_id, 0), start_time, COALESCE(duration, 0), COALESCE(winning_team, 0)
+		FROM match_records WHERE id = $1
+	`, matchID).Scan(&r.GameMode, &r.MapID, &r.StartTime, &r.Duration, &r.WinningTeam)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReplayNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询对局记录失败: %w", err)
+	}
+
+	if err := loadPlayers(r); err != nil {
+		return nil, err
+	}
+	if err := loadEvents(r); err != nil {
+		return nil, err
+	}
+	if err := loadHighlights(r); err != nil {
+		return nil, err
+	}
+	if err := hidePlayers(viewerID, r); err != nil {
+		return nil, fmt.Errorf("检查对局 %s 玩家隐私设置失败: %w", matchID, err)
+	}
+	return r, nil
+}
+
+// loadPlayers 查询参与对局的玩家及其战绩，得分高的在前
+func loadPlayers(r *models.Replay) error {
+	rows, err := db.DB.Query(`
+		SELECT pmr.player_id, COALESCE(p.username, ''), pmr.character_id, COALESCE(pmr.team, 0),
+		       pmr.score, pmr.kills, pmr.deaths, pmr.assists, pmr.won, pmr.mvp
+		FROM player_match_records pmr
+		LEFT JOIN players p ON p.id = pmr.player_id
+		WHERE pmr.match_id = $1
+		ORDER BY pmr.score DESC, pmr.player_id
+	`, r.MatchID)
+	if err != nil {
+		return fmt.Errorf("查询对局玩家失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.ReplayPlayer
+		if err := rows.Scan(&p.PlayerID, &p.Username, &p.CharacterID, &p.Team,
+			&p.Score, &p.Kills, &p.Deaths, &p.Assists, &p.Won, &p.MVP); err != nil {
+			return fmt.Errorf("扫描对局玩家失败: %w", err)
+		}
+		r.Players = append(r.Players, p)
+	}
+	return rows.Err()
+}
+
+// loadEvents 按发生顺序查询对局的击杀/死亡事件
+func loadEvents(r *models.Replay) error {
+	rows, err := db.DB.Query(`
+		SELECT event_type, player_id, COALESCE(other_player_id, 0), COALESCE(character_id, 0),
+		       pos_x, pos_y, COALESCE(elapsed, 0)
+		FROM match_position_events
+		WHERE match_id = $1
+		ORDER BY id
+	`, r.MatchID)
+	if err != nil {
+		return fmt.Errorf("查询对局事件失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.PositionEvent
+		if err := rows.Scan(&e.Type, &e.PlayerID, &e.OtherPlayerID, &e.CharacterID,
+			&e.X, &e.Y, &e.Elapsed); err != nil {
+			return fmt.Errorf("扫描对局事件失败: %w", err)
+		}
+		r.Events = append(r.Events, e)
+	}
+	return rows.Err()
+}
+
+// loadHighlights 按时间查询对局的精彩片段标记
+func loadHighlights(r *models.Replay) error {
+	rows, err := db.DB.Query(`
+		SELECT highlight_type, player_id, team, kills, elapsed, duration
+		FROM match_highlights
+		WHERE match_id = $1
+		ORDER BY elapsed, id
+	`, r.MatchID)
+	if err != nil {
+		return fmt.Errorf("查询精彩片段标记失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h models.Highlight
+		if err := rows.Scan(&h.Type, &h.PlayerID, &h.Team, &h.Kills, &h.Elapsed, &h.Duration); err != nil {
+			return fmt.Errorf("扫描精彩片段标记失败: %w", err)
+		}
+		r.Highlights = append(r.Highlights, h)
+	}
+	return rows.Err()
+}
+
+// hidePlayers 移除对查看者隐藏了对局历史的玩家及其事件和标记，并隐去其作为对手的ID
+func hidePlayers(viewerID int64, r *models.Replay) error {
+	playerIDs := make([]int64, 0, len(r.Players))
+	for _, p := range r.Players {
+		playerIDs = append(playerIDs, p.PlayerID)
+	}
+	hidden, err := privacy.HiddenPlayers(viewerID, playerIDs, privacy.FieldMatchHistory)
+	if err != nil || len(hidden) == 0 {
+		return err
+	}
+
+	players := r.Players[:0]
+	for _, p := range r.Players {
+		if !hidden[p.PlayerID] {
+			players = append(players, p)
+		}
+	}
+	r.Players = players
+
+	events := r.Events[:0]
+	for _, e := range r.Events {
+		if hidden[e.PlayerID] {
+			continue
+		}
+		if hidden[e.OtherPlayerID] {
+			e.OtherPlayerID = 0
+		}
+		events = append(events, e)
+	}
+	r.Events = events
+
+	highlights := r.Highlights[:0]
+	for _, h := range r.Highlights {
+		if !hidden[h.PlayerID] {
+			highlights = append(highlights, h)
+		}
+	}
+	r.Highlights = highlights
+	return nil
+}
@@ -0,0 +1,209 @@
+// event.go
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// maxFrames 堆栈最多保留的帧数
+const maxFrames = 64
+
+// event Sentry 事件
+type event struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Level       Level                  `json:"level"`
+	Platform    string                 `json:"platform"`
+	ServerName  string                 `json:"server_name,omitempty"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	Tags        map[string]string      `json:"tags,omitempty"`
+	Extra       map[string]interface{} `json:"extra,omitempty"`
+	User        *eventUser             `json:"user,omitempty"`
+	Request     *eventRequest          `json:"request,omitempty"`
+	Exception   exceptions             `json:"exception"`
+}
+
+// exceptions 事件中的异常列表
+type exceptions struct {
+	Values []exception `json:"values"`
+}
+
+// exception 异常及其堆栈
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+// stacktrace 堆栈，帧按调用顺序从外到内排列
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+// frame 堆栈帧
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// eventUser 相关玩家
+type eventUser struct {
+	ID string `json:"id"`
+}
+
+// eventRequest 相关 HTTP 请求，不包含请求体和认证信息
+type eventRequest struct {
+	URL         string            `json:"url"`
+	Method      string            `json:"method"`
+	QueryString string            `json:"query_string,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// reportedHeaders 随事件上报的请求头，Authorization、Cookie 等敏感头不上报
+var reportedHeaders = []string{"User-Agent", "Content-Type", "Accept-Language", "X-Request-ID"}
+
+// filteredParams 值会被隐藏的查询参数
+var filteredParams = []string{"token", "password"}
+
+// newEventRequest 提取请求中可以上报的信息
+func newEventRequest(r *http.Request) *eventRequest {
+	req := &eventRequest{
+		URL:     r.URL.Path,
+		Method:  r.Method,
+		Headers: make(map[string]string),
+	}
+	if r.Host != "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		req.URL = scheme + "://" + r.Host + r.URL.Path
+	}
+
+	query := r.URL.Query()
+	for _, key := range filteredParams {
+		if query.Has(key) {
+			query.Set(key, "[Filtered]")
+		}
+	}
+	req.QueryString = query.Encode()
+
+	for _, key := range reportedHeaders {
+		if v := r.Header.Get(key); v != "" {
+			req.Headers[key] = v
+		}
+	}
+	return req
+}
+
+// modulePath 本项目的模块路径，用于区分项目代码和依赖库代码
+var modulePath = func() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Path != "" {
+		return info.Main.Path
+	}
+	return "github.com/jacl-coder/PixelStorm-Server"
+}()
+
+// reportPackage 本包的包路径，其中的帧不计入堆栈
+var reportPackage = modulePath + "/internal/report"
+
+// callers 当前调用栈，跳过 runtime 和本包的帧
+func callers() *stacktrace {
+	pcs := make([]uintptr, maxFrames)
+	n := runtime.Callers(1, pcs)
+	it := runtime.CallersFrames(pcs[:n])
+
+	var frames []frame
+	for {
+		f, more := it.Next()
+		module, function := splitFunction(f.Function)
+		if module != "runtime" && module != reportPackage && function != "" {
+			frames = append(frames, frame{
+				Function: function,
+				Module:   module,
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+				InApp:    strings.HasPrefix(module, modulePath),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+
+	// Sentry 要求最外层的调用在前
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return &stacktrace{Frames: frames}
+}
+
+// splitFunction 把 github.com/a/b/pkg.(*T).Method 拆分为包路径和函数名
+func splitFunction(name string) (module, function string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	return name[:slash+1+dot], name[slash+1+dot+1:]
+}
+
+// release 发布版本，未配置时依次使用模块版本和提交号
+func release(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return "pixelstorm-server@" + v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return "pixelstorm-server@" + s.Value
+		}
+	}
+	return ""
+}
+
+// envelope 把事件编码为 envelope：头部、条目头部和事件各占一行
+func envelope(ev *event) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(map[string]interface{}{
+		"event_id": ev.EventID,
+		"sent_at":  time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	item, err := json.Marshal(map[string]interface{}{
+		"type":   "event",
+		"length": len(payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	for _, line := range [][]byte{header, item, payload} {
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,290 @@
+// report.go
+
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+const (
+	// sendTimeout 单次上报超时
+	sendTimeout = 10 * time.Second
+
+	// queueSize 待上报事件队列长度，队列已满时丢弃新事件，避免错误风暴拖慢服务
+	queueSize = 100
+
+	// clientName 上报时使用的客户端标识
+	clientName = "pixelstorm-server/1.0"
+)
+
+// Level 事件级别
+type Level string
+
+const (
+	// LevelFatal panic
+	LevelFatal Level = "fatal"
+	// LevelError 错误
+	LevelError Level = "error"
+)
+
+// Context 错误发生时的上下文，随事件一起上报
+type Context struct {
+	PlayerID int64                  // 相关玩家，0 表示无
+	RoomID   string                 // 相关房间
+	Request  *http.Request          // 相关 HTTP 请求
+	Tags     map[string]string      // 可用于检索的标签
+	Extra    map[string]interface{} // 附加数据
+}
+
+// reportsTotal 错误上报结果
+var reportsTotal = metrics.Default.NewCounterVec("pixelstorm_error_reports_total",
+	"错误上报数，status 为 sent、dropped 或 failed", "level", "status")
+
+// Reporter 错误上报器，按 Sentry 协议异步上报事件
+type Reporter struct {
+	endpoint    string
+	publicKey   string
+	release     string
+	environment string
+	service     string
+	serverName  string
+	sampleRate  float64
+	client      *http.Client
+
+	queue   chan *event
+	pending sync.WaitGroup
+}
+
+// NewReporter 按配置创建错误上报器，并启动上报协程
+func NewReporter(cfg config.ErrorReportingConfig, service string) (*Reporter, error) {
+	endpoint, publicKey, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	sampleRate := cfg.SampleRate
+	if sampleRate <= 0 {
+		sampleRate = 1
+	}
+	host, _ := os.Hostname()
+
+	r := &Reporter{
+		endpoint:    endpoint,
+		publicKey:   publicKey,
+		release:     release(cfg.Release),
+		environment: cfg.Environment,
+		service:     service,
+		serverName:  host,
+		sampleRate:  sampleRate,
+		client:      &http.Client{Timeout: sendTimeout},
+		queue:       make(chan *event, queueSize),
+	}
+	go r.run()
+	return r, nil
+}
+
+// parseDSN 解析 DSN，返回事件上报地址和公钥
+func parseDSN(dsn string) (endpoint, publicKey string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", fmt.Errorf("解析错误上报 DSN 失败: %w", err)
+	}
+	path := strings.Trim(u.Path, "/")
+	publicKey = u.User.Username()
+	if u.Host == "" || publicKey == "" || path == "" {
+		return "", "", fmt.Errorf("错误上报 DSN 格式无效，应为 https://<公钥>@<主机>/<项目ID>")
+	}
+
+	// 项目ID为路径最后一段，之前的部分是服务部署的路径前缀
+	prefix, project := "", path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		prefix, project = "/"+path[:i], path[i+1:]
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project), publicKey, nil
+}
+
+// Panic 上报 panic，需在 recover 所在的 defer 中调用，堆栈从 panic 发生处开始
+func (r *Reporter) Panic(p interface{}, ctx Context) {
+	if r == nil {
+		return
+	}
+	r.capture(LevelFatal, exception{
+		Type:       "panic",
+		Value:      fmt.Sprint(p),
+		Stacktrace: callers(),
+	}, ctx)
+}
+
+// Error 上报错误，类型取自错误码，没有错误码时为 error
+func (r *Reporter) Error(err error, ctx Context) {
+	if r == nil || err == nil {
+		return
+	}
+	kind := string(errcode.Of(err))
+	if kind == "" {
+		kind = "error"
+	}
+	r.capture(LevelError, exception{
+		Type:       kind,
+		Value:      err.Error(),
+		Stacktrace: callers(),
+	}, ctx)
+}
+
+// ServerError 按采样比例上报 5xx 响应，同一接口的事件按方法和路径归为一组
+func (r *Reporter) ServerError(req *http.Request, status int, ctx Context) {
+	if r == nil || rand.Float64() >= r.sampleRate {
+		return
+	}
+	ctx.Request = req
+	r.capture(LevelError, exception{
+		Type:  "HTTP " + strconv.Itoa(status),
+		Value: req.Method + " " + req.URL.Path,
+	}, ctx)
+}
+
+// capture 补充公共字段后放入上报队列，队列已满时丢弃
+func (r *Reporter) capture(level Level, exc exception, ctx Context) {
+	ev := &event{
+		EventID:     strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Timestamp:   time.Now().UTC(),
+		Level:       level,
+		Platform:    "go",
+		ServerName:  r.serverName,
+		Release:     r.release,
+		Environment: r.environment,
+		Tags:        map[string]string{"service": r.service},
+		Extra:       ctx.Extra,
+		Exception:   exceptions{Values: []exception{exc}},
+	}
+	for k, v := range ctx.Tags {
+		ev.Tags[k] = v
+	}
+	if ctx.RoomID != "" {
+		ev.Tags["room_id"] = ctx.RoomID
+	}
+	if ctx.PlayerID != 0 {
+		ev.User = &eventUser{ID: strconv.FormatInt(ctx.PlayerID, 10)}
+	}
+	if ctx.Request != nil {
+		ev.Request = newEventRequest(ctx.Request)
+	}
+
+	r.pending.Add(1)
+	select {
+	case r.queue <- ev:
+	default:
+		r.pending.Done()
+		reportsTotal.Inc(string(level), "dropped")
+	}
+}
+
+// run 依次发送队列中的事件，失败只记录日志
+func (r *Reporter) run() {
+	for ev := range r.queue {
+		if err := r.send(ev); err != nil {
+			reportsTotal.Inc(string(ev.Level), "failed")
+			log.Printf("上报错误事件 %s 失败: %v", ev.EventID, err)
+		} else {
+			reportsTotal.Inc(string(ev.Level), "sent")
+		}
+		r.pending.Done()
+	}
+}
+
+// send 以 envelope 格式发送单个事件
+func (r *Reporter) send(ev *event) error {
+	body, err := envelope(ev)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, r.publicKey))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("上报服务返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Flush 等待队列中的事件发送完成，超时返回 false。退出前调用，避免丢失最后的事件
+func (r *Reporter) Flush(timeout time.Duration) bool {
+	if r == nil {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Default 全局错误上报器，未配置 DSN 时为nil
+var Default *Reporter
+
+// Init 按配置创建全局错误上报器
+func Init(cfg config.ErrorReportingConfig, service string) {
+	if cfg.DSN == "" {
+		return
+	}
+	r, err := NewReporter(cfg, service)
+	if err != nil {
+		log.Printf("%v，不上报错误", err)
+		return
+	}
+	Default = r
+	log.Printf("已启用错误上报 (release %s, environment %s)", r.release, r.environment)
+}
+
+// Panic 通过全局错误上报器上报 panic，需在 recover 所在的 defer 中调用
+func Panic(p interface{}, ctx Context) {
+	Default.Panic(p, ctx)
+}
+
+// Error 通过全局错误上报器上报错误
+func Error(err error, ctx Context) {
+	Default.Error(err, ctx)
+}
+
+// ServerError 通过全局错误上报器上报 5xx 响应
+func ServerError(req *http.Request, status int, ctx Context) {
+	Default.ServerError(req, status, ctx)
+}
+
+// Flush 等待全局错误上报器发送完队列中的事件
+func Flush(timeout time.Duration) bool {
+	return Default.Flush(timeout)
+}
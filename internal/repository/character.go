@@ -0,0 +1,258 @@
+// character.go
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// PostgresCharacterRepo 基于 PostgreSQL 的角色存取，角色配置和角色列表走从库，
+// 玩家拥有和默认角色的检查与写入走主库
+type PostgresCharacterRepo struct {
+	cluster *db.ReplicaCluster
+}
+
+// NewPostgresCharacterRepo 创建基于 PostgreSQL 的角色存取
+func NewPostgresCharacterRepo(cluster *db.ReplicaCluster) *PostgresCharacterRepo {
+	return &PostgresCharacterRepo{cluster: cluster}
+}
+
+// All 获取所有角色
+func (r *PostgresCharacterRepo) All() ([]models.Character, error) {
+	query := `
+		-- name: characters_all
+		SELECT id, name, description, max_hp, speed, base_attack, base_defense,
+		       special_ability, difficulty, role, unlockable, unlock_cost
+		FROM characters
+		ORDER BY id
+	`
+
+	rows, err := r.cluster.Reader().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	defer rows.Close()
+
+	var characters []models.Character
+	for rows.Next() {
+		var char models.Character
+		err := rows.Scan(
+			&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
+			&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
+			&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描角色数据失败: %w", err)
+		}
+		characters = append(characters, char)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历角色数据失败: %w", err)
+	}
+
+	return characters, nil
+}
+
+// GetByID 根据ID获取角色
+func (r *PostgresCharacterRepo) GetByID(characterID int) (*models.Character, error) {
+	query := `
+		-- name: character_by_id
+		SELECT id, name, description, max_hp, speed, base_attack, base_defense,
+		       special_ability, difficulty, role, unlockable, unlock_cost
+		FROM characters
+		WHERE id = $1
+	`
+
+	var char models.Character
+	err := r.cluster.Reader().QueryRow(query, characterID).Scan(
+		&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
+		&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
+		&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+
+	return &char, nil
+}
+
+// Skills 获取角色技能
+func (r *PostgresCharacterRepo) Skills(characterID int) ([]models.Skill, error) {
+	query := `
+		-- name: character_skills
+		SELECT s.id, s.name, s.description, s.type, s.damage, s.cooldown_time,
+		       s.range, s.effect_time, s.projectile_speed, s.projectile_count,
+		       s.projectile_spread, s.animation_key, s.effect_key,
+		       COALESCE(cs.required_level, 1)
+		FROM skills s
+		INNER JOIN character_skills cs ON s.id = cs.skill_id
+		WHERE cs.character_id = $1
+		ORDER BY cs.slot_index, s.id
+	`
+
+	rows, err := r.cluster.Reader().Query(query, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色技能失败: %w", err)
+	}
+	defer rows.Close()
+
+	var skills []models.Skill
+	for rows.Next() {
+		var skill models.Skill
+		var projectileSpeed, projectileSpread sql.NullFloat64
+		var projectileCount sql.NullInt64
+		var animationKey, effectKey sql.NullString
+
+		err := rows.Scan(
+			&skill.ID, &skill.Name, &skill.Description, &skill.Type, &skill.Damage,
+			&skill.CooldownTime, &skill.Range, &skill.EffectTime,
+			&projectileSpeed, &projectileCount, &projectileSpread,
+			&animationKey, &effectKey, &skill.RequiredLevel,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描技能数据失败: %w", err)
+		}
+
+		// 处理可空字段
+		if projectileSpeed.Valid {
+			skill.ProjectileSpeed = projectileSpeed.Float64
+		}
+		if projectileCount.Valid {
+			skill.ProjectileCount = int(projectileCount.Int64)
+		}
+		if projectileSpread.Valid {
+			skill.ProjectileSpread = projectileSpread.Float64
+		}
+		if animationKey.Valid {
+			skill.AnimationKey = animationKey.String
+		}
+		if effectKey.Valid {
+			skill.EffectKey = effectKey.String
+		}
+
+		skills = append(skills, skill)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历技能数据失败: %w", err)
+	}
+
+	return skills, nil
+}
+
+// PlayerCharacters 获取玩家已解锁的角色及等级
+func (r *PostgresCharacterRepo) PlayerCharacters(playerID int64) ([]OwnedCharacter, error) {
+	query := `
+		-- name: player_characters
+		SELECT c.id, c.name, c.description, c.max_hp, c.speed, c.base_attack,
+		       c.base_defense, c.special_ability, c.difficulty, c.role,
+		       c.unlockable, c.unlock_cost, COALESCE(pc.level, 1), COALESCE(pc.exp, 0)
+		FROM characters c
+		INNER JOIN player_characters pc ON c.id = pc.character_id
+		WHERE pc.player_id = $1
+		ORDER BY c.id
+	`
+
+	rows, err := r.cluster.Reader().Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家角色失败: %w", err)
+	}
+	defer rows.Close()
+
+	var characters []OwnedCharacter
+	for rows.Next() {
+		var char OwnedCharacter
+		err := rows.Scan(
+			&char.ID, &char.Name, &char.Description, &char.MaxHP, &char.Speed,
+			&char.BaseAttack, &char.BaseDefense, &char.SpecialAbility,
+			&char.Difficulty, &char.Role, &char.Unlockable, &char.UnlockCost,
+			&char.Level, &char.Exp,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描玩家角色数据失败: %w", err)
+		}
+		characters = append(characters, char)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历玩家角色数据失败: %w", err)
+	}
+
+	return characters, nil
+}
+
+// HasCharacter 检查玩家是否拥有指定角色
+func (r *PostgresCharacterRepo) HasCharacter(playerID int64, characterID int) (bool, error) {
+	query := `
+		-- name: player_has_character
+		SELECT COUNT(1) FROM player_characters
+		WHERE player_id = $1 AND character_id = $2
+	`
+
+	var count int
+	err := r.cluster.Primary().QueryRow(query, playerID, characterID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("检查玩家角色失败: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// DefaultCharacter 获取玩家默认角色ID
+func (r *PostgresCharacterRepo) DefaultCharacter(playerID int64) (int, error) {
+	query := `
+		-- name: player_default_character
+		SELECT character_id FROM player_default_characters
+		WHERE player_id = $1
+	`
+
+	var characterID int
+	err := r.cluster.Primary().QueryRow(query, playerID).Scan(&characterID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询默认角色失败: %w", err)
+	}
+
+	return characterID, nil
+}
+
+// SetDefaultCharacter 设置玩家默认角色，拥有检查和写入在同一事务中完成
+func (r *PostgresCharacterRepo) SetDefaultCharacter(playerID int64, characterID int) error {
+	return db.RunInTx(r.cluster.Primary(), func(tx *sql.Tx) error {
+		// 锁定拥有记录，避免写入期间角色被移除
+		var owned bool
+		err := tx.QueryRow(`
+			SELECT true FROM player_characters
+			WHERE player_id = $1 AND character_id = $2
+			FOR SHARE
+		`, playerID, characterID).Scan(&owned)
+		if err == sql.ErrNoRows {
+			return ErrCharacterNotOwned
+		}
+		if err != nil {
+			return fmt.Errorf("检查玩家角色失败: %w", err)
+		}
+
+		// 使用 UPSERT 语法（PostgreSQL）
+		_, err = tx.Exec(`
+			INSERT INTO player_default_characters (player_id, character_id)
+			VALUES ($1, $2)
+			ON CONFLICT (player_id)
+			DO UPDATE SET character_id = EXCLUDED.character_id
+		`, playerID, characterID)
+		if err != nil {
+			return fmt.Errorf("设置默认角色失败: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+// map.go
+
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// PostgresMapRepo 基于 PostgreSQL 的地图配置存取，只读查询走从库
+type PostgresMapRepo struct {
+	cluster *db.ReplicaCluster
+}
+
+// NewPostgresMapRepo 创建基于 PostgreSQL 的地图配置存取
+func NewPostgresMapRepo(cluster *db.ReplicaCluster) *PostgresMapRepo {
+	return &PostgresMapRepo{cluster: cluster}
+}
+
+// All 获取所有地图及其支持的游戏模式和危险区域
+func (r *PostgresMapRepo) All() ([]models.GameMap, error) {
+	query := `
+		-- name: game_maps_all
+		SELECT gm.id, gm.name, COALESCE(gm.description, ''), COALESCE(gm.image_path, ''),
+		       gm.width, gm.height, gm.max_players, gm.hazards, mm.mode
+		FROM game_maps gm
+		LEFT JOIN map_modes mm ON mm.map_id = gm.id
+		ORDER BY gm.id, mm.mode
+	`
+
+	rows, err := r.cluster.Reader().Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询地图失败: %w", err)
+	}
+	defer rows.Close()
+
+	var maps []models.GameMap
+	for rows.Next() {
+		var m models.GameMap
+		var mode sql.NullString
+		var hazards []byte
+		err := rows.Scan(&m.ID, &m.Name, &m.Description, &m.ImagePath,
+			&m.Width, &m.Height, &m.MaxPlayers, &hazards, &mode)
+		if err != nil {
+			return nil, fmt.Errorf("扫描地图数据失败: %w", err)
+		}
+		if err := json.Unmarshal(hazards, &m.Hazards); err != nil {
+			return nil, fmt.Errorf("解析地图 %d 危险区域失败: %w", m.ID, err)
+		}
+
+		// 同一地图的多个模式按行返回，合并到同一条记录
+		if n := len(maps); n == 0 || maps[n-1].ID != m.ID {
+			maps = append(maps, m)
+		}
+		if mode.Valid {
+			last := &maps[len(maps)-1]
+			last.SupportedModes = append(last.SupportedModes, models.GameMode(mode.String))
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历地图数据失败: %w", err)
+	}
+
+	return maps, nil
+}
@@ -0,0 +1,257 @@
+// player.go
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// PostgresPlayerRepo 基于 PostgreSQL 的玩家存取
+type PostgresPlayerRepo struct {
+	db *sql.DB
+}
+
+// NewPostgresPlayerRepo 创建基于 PostgreSQL 的玩家存取
+func NewPostgresPlayerRepo(db *sql.DB) *PostgresPlayerRepo {
+	return &PostgresPlayerRepo{db: db}
+}
+
+// GetByID 根据ID获取玩家信息
+func (r *PostgresPlayerRepo) GetByID(playerID int64) (*models.Player, error) {
+	query := `
+		-- name: player_by_id
+		SELECT id, username, email, created_at, updated_at, level, exp, coins, gems,
+		       total_kills, total_deaths, total_assists, total_matches, total_wins
+		FROM players
+		WHERE id = $1
+	`
+
+	var player models.Player
+	err := r.db.QueryRow(query, playerID).Scan(
+		&player.ID, &player.Username, &player.Email, &player.CreatedAt, &player.UpdatedAt,
+		&player.Level, &player.Exp, &player.Coins, &player.Gems,
+		&player.TotalKills, &player.TotalDeaths, &player.TotalAssists, &player.TotalMatches, &player.TotalWins,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家信息失败: %w", err)
+	}
+
+	return &player, nil
+}
+
+// Exists 检查玩家是否存在
+func (r *PostgresPlayerRepo) Exists(playerID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM players WHERE id = $1)`, playerID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("检查玩家存在性失败: %w", err)
+	}
+	return exists, nil
+}
+
+// Statistics 获取玩家统计信息
+func (r *PostgresPlayerRepo) Statistics(playerID int64) (*models.PlayerStatistics, error) {
+	query := `
+		-- name: player_statistics
+		SELECT
+			CASE WHEN total_matches > 0 THEN (total_wins * 100.0 / total_matches) ELSE 0 END as win_rate,
+			CASE WHEN total_deaths > 0 THEN (total_kills * 1.0 / total_deaths) ELSE total_kills END as kda,
+			CASE WHEN total_matches > 0 THEN (total_kills * 1.0 / total_matches) ELSE 0 END as average_kill,
+			COALESCE(SUM(pmr.play_time), 0) / 60 as play_time_minutes
+		FROM players p
+		LEFT JOIN player_match_records_all pmr ON p.id = pmr.player_id
+		WHERE p.id = $1
+		GROUP BY p.id, p.total_matches, p.total_wins, p.total_kills, p.total_deaths
+	`
+
+	var stats models.PlayerStatistics
+	err := r.db.QueryRow(query, playerID).Scan(
+		&stats.WinRate, &stats.KDA, &stats.AverageKill, &stats.PlayTime,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家统计信息失败: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// UpdateProfile 更新玩家资料
+func (r *PostgresPlayerRepo) UpdateProfile(playerID int64, username, email string) error {
+	// 构建动态更新SQL
+	setParts := []string{}
+	args := []interface{}{}
+	argIndex := 1
+
+	if username != "" {
+		setParts = append(setParts, fmt.Sprintf("username = $%d", argIndex))
+		args = append(args, username)
+		argIndex++
+	}
+
+	if email != "" {
+		setParts = append(setParts, fmt.Sprintf("email = $%d", argIndex))
+		args = append(args, email)
+		argIndex++
+	}
+
+	// 添加更新时间
+	setParts = append(setParts, fmt.Sprintf("updated_at = $%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
+
+	// 添加WHERE条件
+	args = append(args, playerID)
+
+	query := fmt.Sprintf(`
+		UPDATE players
+		SET %s
+		WHERE id = $%d
+	`, strings.Join(setParts, ", "), argIndex)
+
+	if _, err := r.db.Exec(query, args...); err != nil {
+		if conflict := uniqueConflict(err); conflict != nil {
+			return conflict
+		}
+		return fmt.Errorf("更新玩家资料失败: %w", err)
+	}
+
+	return nil
+}
+
+// FindByCredentials 按用户名和密码哈希查找玩家
+func (r *PostgresPlayerRepo) FindByCredentials(username, passwordHash string) (int64, error) {
+	var playerID int64
+	err := r.db.QueryRow("SELECT id FROM players WHERE username = $1 AND password = $2", username, passwordHash).Scan(&playerID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("数据库查询错误: %w", err)
+	}
+
+	return playerID, nil
+}
+
+// Create 创建玩家账号，查重和插入在同一事务中完成
+func (r *PostgresPlayerRepo) Create(username, passwordHash, email string) (int64, error) {
+	var playerID int64
+	err := db.RunInTx(r.db, func(tx *sql.Tx) error {
+		// 检查用户名是否已存在
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM players WHERE username = $1", username).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("数据库查询错误: %w", err)
+		}
+		if count > 0 {
+			return ErrUsernameTaken
+		}
+
+		// 检查邮箱是否已存在
+		err = tx.QueryRow("SELECT COUNT(*) FROM players WHERE email = $1", email).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("数据库查询错误: %w", err)
+		}
+		if count > 0 {
+			return ErrEmailTaken
+		}
+
+		// 插入用户，并发注册时仍可能触发唯一约束
+		err = tx.QueryRow(
+			"INSERT INTO players (username, password, email, created_at, updated_at) VALUES ($1, $2, $3, NOW(), NOW()) RETURNING id",
+			username, passwordHash, email,
+		).Scan(&playerID)
+		if err != nil {
+			if conflict := uniqueConflict(err); conflict != nil {
+				return conflict
+			}
+			return fmt.Errorf("创建用户失败: %w", err)
+		}
+
+		// 新账号拥有初始角色和默认角色，首次匹配无需先解锁角色
+		return grantStarterCharacters(tx, playerID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return playerID, nil
+}
+
+// grantStarterCharacters 授予所有无需解锁的初始角色，并将编号最小的设为默认角色
+func grantStarterCharacters(tx *sql.Tx, playerID int64) error {
+	rows, err := tx.Query("SELECT id FROM characters WHERE unlockable = false ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("查询初始角色失败: %w", err)
+	}
+	var characterIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("扫描初始角色失败: %w", err)
+		}
+		characterIDs = append(characterIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("查询初始角色失败: %w", err)
+	}
+	if len(characterIDs) == 0 {
+		return nil
+	}
+
+	for _, id := range characterIDs {
+		if _, err := tx.Exec(
+			"INSERT INTO player_characters (player_id, character_id, unlocked, unlocked_at) VALUES ($1, $2, true, NOW())",
+			playerID, id,
+		); err != nil {
+			return fmt.Errorf("授予初始角色 %d 失败: %w", id, err)
+		}
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO player_default_characters (player_id, character_id) VALUES ($1, $2)",
+		playerID, characterIDs[0],
+	); err != nil {
+		return fmt.Errorf("设置默认角色失败: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword 修改玩家密码哈希
+func (r *PostgresPlayerRepo) UpdatePassword(playerID int64, passwordHash string) error {
+	result, err := r.db.Exec("UPDATE players SET password = $1, updated_at = NOW() WHERE id = $2", passwordHash, playerID)
+	if err != nil {
+		return fmt.Errorf("修改密码失败: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// uniqueConflict 将 players 表的唯一约束冲突转换为对应的错误，其他错误返回nil
+func uniqueConflict(err error) error {
+	constraint, ok := db.UniqueViolation(err)
+	if !ok {
+		return nil
+	}
+	switch {
+	case strings.Contains(constraint, "username"):
+		return ErrUsernameTaken
+	case strings.Contains(constraint, "email"):
+		return ErrEmailTaken
+	}
+	return fmt.Errorf("数据冲突: %w", err)
+}
@@ -0,0 +1,82 @@
+// repository.go
+
+package repository
+
+import (
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrNotFound 记录不存在
+	ErrNotFound = errcode.New(errcode.NotFound, "记录不存在")
+	// ErrUsernameTaken 用户名已被使用
+	ErrUsernameTaken = errcode.New(errcode.UsernameTaken, "用户名已存在")
+	// ErrEmailTaken 邮箱已被使用
+	ErrEmailTaken = errcode.New(errcode.EmailTaken, "邮箱已被使用")
+	// ErrCharacterNotOwned 玩家未拥有该角色
+	ErrCharacterNotOwned = errcode.New(errcode.CharacterNotOwned, "玩家未拥有该角色")
+)
+
+// PlayerRepo 玩家账号和资料的存取
+type PlayerRepo interface {
+	// GetByID 根据ID获取玩家信息，不存在时返回 ErrNotFound
+	GetByID(playerID int64) (*models.Player, error)
+	// Exists 检查玩家是否存在
+	Exists(playerID int64) (bool, error)
+	// Statistics 获取玩家资料页的统计信息
+	Statistics(playerID int64) (*models.PlayerStatistics, error)
+	// UpdateProfile 更新玩家资料，空字段不修改，冲突时返回 ErrUsernameTaken 或 ErrEmailTaken
+	UpdateProfile(playerID int64, username, email string) error
+	// FindByCredentials 按用户名和密码哈希查找玩家，不匹配时返回 ErrNotFound
+	FindByCredentials(username, passwordHash string) (int64, error)
+	// Create 创建玩家账号，冲突时返回 ErrUsernameTaken 或 ErrEmailTaken
+	Create(username, passwordHash, email string) (int64, error)
+	// UpdatePassword 修改玩家密码哈希，玩家不存在时返回 ErrNotFound
+	UpdatePassword(playerID int64, passwordHash string) error
+}
+
+// CharacterRepo 角色配置和玩家角色的存取
+type CharacterRepo interface {
+	// All 获取所有角色
+	All() ([]models.Character, error)
+	// GetByID 根据ID获取角色，不存在时返回 ErrNotFound
+	GetByID(characterID int) (*models.Character, error)
+	// Skills 获取角色技能
+	Skills(characterID int) ([]models.Skill, error)
+	// PlayerCharacters 获取玩家已解锁的角色及等级
+	PlayerCharacters(playerID int64) ([]OwnedCharacter, error)
+	// HasCharacter 检查玩家是否拥有指定角色
+	HasCharacter(playerID int64, characterID int) (bool, error)
+	// DefaultCharacter 获取玩家默认角色ID，未设置时返回 ErrNotFound
+	DefaultCharacter(playerID int64) (int, error)
+	// SetDefaultCharacter 设置玩家默认角色，未拥有时返回 ErrCharacterNotOwned
+	SetDefaultCharacter(playerID int64, characterID int) error
+}
+
+// MapRepo 地图配置的查询
+type MapRepo interface {
+	// All 获取所有地图及其支持的游戏模式
+	All() ([]models.GameMap, error)
+}
+
+// StatsRepo 玩家战绩和排行榜的查询
+type StatsRepo interface {
+	// PlayerStats 获取玩家战绩统计，玩家不存在时返回 ErrNotFound
+	PlayerStats(playerID int64) (*models.PlayerStats, error)
+	// WindowStats 获取玩家时间窗口战绩，玩家不存在时返回 ErrNotFound
+	WindowStats(playerID int64, window models.StatsWindow) (*models.PlayerWindowStats, error)
+	// CharacterStats 获取玩家各角色战绩，玩家不存在时返回 ErrNotFound
+	CharacterStats(playerID int64) ([]models.PlayerCharacterStats, error)
+	// Matches 分页获取玩家对局历史和总数
+	Matches(playerID int64, limit, offset int) ([]models.PlayerMatchRecord, int, error)
+	// Leaderboard 获取排行榜
+	Leaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error)
+}
+
+// OwnedCharacter 玩家拥有的角色及其等级
+type OwnedCharacter struct {
+	models.Character
+	Level int
+	Exp   int
+}
@@ -0,0 +1,320 @@
+// stats.go
+
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// PostgresStatsRepo 基于 PostgreSQL 的战绩查询，全部为只读查询，优先走从库
+type PostgresStatsRepo struct {
+	cluster *db.ReplicaCluster
+}
+
+// NewPostgresStatsRepo 创建基于 PostgreSQL 的战绩查询
+func NewPostgresStatsRepo(cluster *db.ReplicaCluster) *PostgresStatsRepo {
+	return &PostgresStatsRepo{cluster: cluster}
+}
+
+// PlayerStats 获取玩家战绩统计
+func (r *PostgresStatsRepo) PlayerStats(playerID int64) (*models.PlayerStats, error) {
+	query := `
+		-- name: player_stats
+		SELECT
+			p.id as player_id,
+			p.total_matches,
+			p.total_wins,
+			(p.total_matches - p.total_wins) as losses,
+			CASE WHEN p.total_matches > 0 THEN (p.total_wins * 100.0 / p.total_matches) ELSE 0 END as win_rate,
+			p.total_kills,
+			p.total_deaths,
+			COALESCE(SUM(pmr.assists), 0) as total_assists,
+			CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + COALESCE(SUM(pmr.assists), 0)) * 1.0 / p.total_deaths)
+				 ELSE (p.total_kills + COALESCE(SUM(pmr.assists), 0)) END as kda,
+			CASE WHEN p.total_matches > 0 THEN (COALESCE(SUM(pmr.score), 0) * 1.0 / p.total_matches) ELSE 0 END as average_score,
+			COALESCE(SUM(CASE WHEN pmr.mvp = true THEN 1 ELSE 0 END), 0) as total_mvp,
+			COALESCE(SUM(pmr.play_time), 0) as play_time
+		FROM players p
+		LEFT JOIN player_match_records_all pmr ON p.id = pmr.player_id
+		WHERE p.id = $1
+		GROUP BY p.id, p.total_matches, p.total_wins, p.total_kills, p.total_deaths
+	`
+
+	var stats models.PlayerStats
+	err := r.cluster.Reader().QueryRow(query, playerID).Scan(
+		&stats.PlayerID, &stats.TotalMatches, &stats.TotalWins, &stats.Losses,
+		&stats.WinRate, &stats.TotalKills, &stats.TotalDeaths, &stats.TotalAssists,
+		&stats.KDA, &stats.AverageScore, &stats.TotalMVP, &stats.PlayTime,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家战绩失败: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// CharacterStats 获取玩家各角色战绩（按使用次数排序）
+func (r *PostgresStatsRepo) CharacterStats(playerID int64) ([]models.PlayerCharacterStats, error) {
+	conn := r.cluster.Reader()
+
+	// 确认玩家存在
+	var exists bool
+	if err := conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM players WHERE id = $1)`, playerID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("检查玩家存在性失败: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	// 场次、胜场、击杀和死亡取自 player_characters 的累计值，助攻和时长从对局记录汇总
+	query := `
+		-- name: character_stats
+		SELECT
+			pc.character_id,
+			c.name,
+			pc.level,
+			pc.usage_count,
+			pc.win_count,
+			pc.kill_count,
+			pc.death_count,
+			COALESCE(pmr.assists, 0),
+			COALESCE(pmr.play_time, 0),
+			pc.last_played_at
+		FROM player_characters pc
+		INNER JOIN characters c ON c.id = pc.character_id
+		LEFT JOIN (
+			SELECT character_id, SUM(assists) AS assists, SUM(play_time) AS play_time
+			FROM player_match_records_all
+			WHERE player_id = $1
+			GROUP BY character_id
+		) pmr ON pmr.character_id = pc.character_id
+		WHERE pc.player_id = $1
+		ORDER BY pc.usage_count DESC, pc.last_played_at DESC NULLS LAST, pc.character_id
+	`
+
+	rows, err := conn.Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家角色战绩失败: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make([]models.PlayerCharacterStats, 0)
+	for rows.Next() {
+		var s models.PlayerCharacterStats
+		var lastPlayedAt sql.NullTime
+		if err := rows.Scan(&s.CharacterID, &s.CharacterName, &s.Level, &s.Games, &s.Wins,
+			&s.Kills, &s.Deaths, &s.Assists, &s.PlayTime, &lastPlayedAt); err != nil {
+			return nil, fmt.Errorf("扫描玩家角色战绩失败: %w", err)
+		}
+
+		if lastPlayedAt.Valid {
+			s.LastPlayedAt = &lastPlayedAt.Time
+		}
+		if s.Games > 0 {
+			s.WinRate = float64(s.Wins) * 100.0 / float64(s.Games)
+		}
+		if s.Deaths > 0 {
+			s.KDA = float64(s.Kills+s.Assists) / float64(s.Deaths)
+		} else {
+			s.KDA = float64(s.Kills + s.Assists)
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, rows.Err()
+}
+
+// Matches 获取玩家对局历史
+func (r *PostgresStatsRepo) Matches(playerID int64, limit, offset int) ([]models.PlayerMatchRecord, int, error) {
+	conn := r.cluster.Reader()
+
+	// 先查询总数
+	countQuery := `
+		-- name: player_match_count
+		SELECT COUNT(*) FROM player_match_records
+		WHERE player_id = $1
+	`
+
+	var total int
+	err := conn.QueryRow(countQuery, playerID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询对局总数失败: %w", err)
+	}
+
+	// 查询对局记录
+	query := `
+		-- name: player_matches
+		SELECT pmr.match_id, pmr.player_id, pmr.character_id, pmr.team, pmr.score,
+		       pmr.kills, pmr.deaths, pmr.assists, pmr.exp_gained, pmr.coins_gained,
+		       pmr.mvp, pmr.won, pmr.play_time, pmr.join_time, pmr.leave_time
+		FROM player_match_records pmr
+		WHERE pmr.player_id = $1
+		ORDER BY pmr.join_time DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := conn.Query(query, playerID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询对局记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []models.PlayerMatchRecord
+	for rows.Next() {
+		var match models.PlayerMatchRecord
+		err := rows.Scan(
+			&match.MatchID, &match.PlayerID, &match.CharacterID, &match.Team,
+			&match.Score, &match.Kills, &match.Deaths, &match.Assists,
+			&match.ExpGained, &match.CoinsGained, &match.MVP, &match.Won,
+			&match.PlayTime, &match.JoinTime, &match.LeaveTime,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("扫描对局记录失败: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("遍历对局记录失败: %w", err)
+	}
+
+	return matches, total, nil
+}
+
+// Leaderboard 获取排行榜
+func (r *PostgresStatsRepo) Leaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
+	var orderBy string
+
+	switch leaderboardType {
+	case models.LeaderboardKills:
+		orderBy = "p.total_kills DESC"
+	case models.LeaderboardWins:
+		orderBy = "p.total_wins DESC"
+	case models.LeaderboardKDA:
+		orderBy = "CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + p.total_assists) * 1.0 / p.total_deaths) ELSE (p.total_kills + p.total_assists) END DESC"
+	case models.LeaderboardScore:
+		orderBy = "(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) DESC"
+	default:
+		orderBy = "(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) DESC"
+	}
+
+	query := fmt.Sprintf(`
+		-- name: leaderboard
+		SELECT
+			p.id AS player_id,
+			p.username,
+			`+models.AvatarURLSQL+` AS avatar_url,
+			p.level,
+			p.total_kills,
+			p.total_wins,
+			CASE WHEN p.total_matches > 0 THEN (p.total_wins * 100.0 / p.total_matches) ELSE 0 END AS win_rate,
+			CASE WHEN p.total_deaths > 0 THEN ((p.total_kills + p.total_assists) * 1.0 / p.total_deaths)
+				 ELSE (p.total_kills + p.total_assists) END AS kda,
+			(p.total_wins * 10 + p.total_kills + p.total_assists * 0.5 - p.total_deaths * 0.5) AS score,
+			ROW_NUMBER() OVER (ORDER BY %s) as rank
+		FROM players p
+		`+models.AvatarJoinSQL+`
+		ORDER BY %s
+		LIMIT $1
+	`, orderBy, orderBy)
+
+	rows, err := r.cluster.Reader().Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询排行榜失败: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LeaderboardEntry
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		err := rows.Scan(
+			&entry.PlayerID, &entry.Username, &entry.AvatarURL, &entry.Level, &entry.TotalKills,
+			&entry.TotalWins, &entry.WinRate, &entry.KDA, &entry.Score, &entry.Rank,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("扫描排行榜数据失败: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历排行榜数据失败: %w", err)
+	}
+
+	return entries, nil
+}
+
+// WindowStats 获取玩家时间窗口战绩
+func (r *PostgresStatsRepo) WindowStats(playerID int64, window models.StatsWindow) (*models.PlayerWindowStats, error) {
+	conn := r.cluster.Reader()
+
+	// 确认玩家存在
+	var exists bool
+	if err := conn.QueryRow(`SELECT EXISTS(SELECT 1 FROM players WHERE id = $1)`, playerID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("检查玩家存在性失败: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	stats := &models.PlayerWindowStats{
+		PlayerID: playerID,
+		Window:   window,
+	}
+
+	query := `
+		-- name: player_window_stats
+		SELECT window_start, matches, wins, kills, deaths, assists, score, mvp, play_time, refreshed_at
+		FROM player_stats_windows
+		WHERE player_id = $1 AND stats_window = $2
+	`
+	err := conn.QueryRow(query, playerID, string(window)).Scan(
+		&stats.WindowStart, &stats.TotalMatches, &stats.TotalWins, &stats.TotalKills,
+		&stats.TotalDeaths, &stats.TotalAssists, &stats.TotalScore, &stats.TotalMVP,
+		&stats.PlayTime, &stats.RefreshedAt,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询时间窗口战绩失败: %w", err)
+	}
+
+	// 本窗口内没有对局，返回空汇总
+	if err == sql.ErrNoRows {
+		if stats.WindowStart, err = windowStart(conn, window); err != nil {
+			return nil, fmt.Errorf("计算窗口起始时间失败: %w", err)
+		}
+		return stats, nil
+	}
+
+	if stats.TotalMatches > 0 {
+		stats.WinRate = float64(stats.TotalWins) * 100.0 / float64(stats.TotalMatches)
+	}
+	if stats.TotalDeaths > 0 {
+		stats.KDA = float64(stats.TotalKills+stats.TotalAssists) / float64(stats.TotalDeaths)
+	} else {
+		stats.KDA = float64(stats.TotalKills + stats.TotalAssists)
+	}
+
+	return stats, nil
+}
+
+// windowStart 计算当前时间窗口的起始时间。PostgreSQL 按数据库时区截断；
+// SQLite 的函数返回值不带列类型，无法扫描为时间，在进程内截断
+func windowStart(conn *sql.DB, window models.StatsWindow) (time.Time, error) {
+	if db.IsSQLite() {
+		return db.TruncTime(models.StatsWindowTrunc[window], time.Now())
+	}
+
+	var start time.Time
+	err := conn.QueryRow(`SELECT date_trunc($1, NOW())`, models.StatsWindowTrunc[window]).Scan(&start)
+	return start, err
+}
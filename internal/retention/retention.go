@@ -0,0 +1,262 @@
+// retention.go
+
+package retention
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// 默认保留策略
+const (
+	defaultMatchMonths = 6
+	defaultInterval    = 24 * time.Hour
+	defaultBatchSize   = 500
+)
+
+// archiveLockKey 归档任务的事务级咨询锁，多个网关实例同时只有一个在归档
+const archiveLockKey = 0x7069786c_61726368
+
+// 批次之间的间隔，避免长时间占用数据库
+const batchPause = 200 * time.Millisecond
+
+var archivedMatches = metrics.Default.NewCounterVec("pixelstorm_retention_archived_matches_total",
+	"归档的对局数", "month")
+
+// archivedTables 需要创建月分区的归档表
+var archivedTables = []string{
+	"match_records_archive",
+	"player_match_records_archive",
+	"match_position_events_archive",
+}
+
+// Service 对局数据归档服务，将超过保留期的对局及其玩家记录、位置事件移入按月分区的归档表
+type Service struct {
+	cfg config.RetentionConfig
+}
+
+// NewService 创建归档服务，未配置的参数使用默认值
+func NewService(cfg config.RetentionConfig) *Service {
+	if cfg.MatchMonths <= 0 {
+		cfg.MatchMonths = defaultMatchMonths
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	return &Service{cfg: cfg}
+}
+
+// Start 按配置的间隔定期归档，stop 关闭时退出
+func (s *Service) Start(stop <-chan struct{}) {
+	if !s.cfg.Enabled {
+		return
+	}
+	if db.IsSQLite() {
+		log.Println("SQLite 不支持分区表，对局归档任务不启动")
+		return
+	}
+	log.Printf("对局归档任务启动，保留最近 %d 个月，执行间隔: %v", s.cfg.MatchMonths, s.cfg.Interval)
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.Archive(time.Now(), stop); err != nil {
+				log.Printf("归档对局记录失败: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Cutoff 返回保留期的起点，早于该时间开始的对局会被归档。按整月计算，保证每次归档完整的月份
+func (s *Service) Cutoff(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -s.cfg.MatchMonths, 0)
+}
+
+// Archive 分批归档保留期之前的对局，返回归档的对局数，玩家收藏的对局不归档。其他实例正在归档时直接返回
+func (s *Service) Archive(now time.Time, stop <-chan struct{}) (int, error) {
+	if db.DB == nil {
+		return 0, fmt.Errorf("数据库未初始化")
+	}
+
+	cutoff := s.Cutoff(now)
+	total := 0
+	for {
+		select {
+		case <-stop:
+			return total, nil
+		default:
+		}
+
+		n, err := s.archiveBatch(cutoff)
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+		total += n
+		time.Sleep(batchPause)
+	}
+
+	if total > 0 {
+		log.Printf("已归档 %d 场 %s 之前的对局", total, cutoff.Format("2006-01-02"))
+	}
+	return total, nil
+}
+
+// archiveBatch 在一个事务中归档一批对局，返回归档的对局数，没有待归档的对局或未获得锁时返回0
+func (s *Service) archiveBatch(cutoff time.Time) (int, error) {
+	var archived int
+	err := db.WithTx(func(tx *sql.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, int64(archiveLockKey)).Scan(&locked); err != nil {
+			return fmt.Errorf("获取归档锁失败: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		rows, err := tx.Query(`
+			SELECT id, start_time FROM match_records m
+			WHERE start_time < $1
+				AND NOT EXISTS (SELECT 1 FROM player_match_bookmarks b WHERE b.match_id = m.id)
+			ORDER BY start_time
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		`, cutoff, s.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("查询待归档对局失败: %w", err)
+		}
+		var ids []string
+		months := make(map[time.Time]int)
+		for rows.Next() {
+			var id string
+			var startTime time.Time
+			if err := rows.Scan(&id, &startTime); err != nil {
+				rows.Close()
+				return fmt.Errorf("扫描待归档对局失败: %w", err)
+			}
+			ids = append(ids, id)
+			months[monthStart(startTime)]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("遍历待归档对局失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for month := range months {
+			if err := ensurePartitions(tx, month); err != nil {
+				return err
+			}
+		}
+
+		if err := moveMatches(tx, ids); err != nil {
+			return err
+		}
+
+		archived = len(ids)
+		for month, n := range months {
+			archivedMatches.Add(float64(n), month.Format("2006-01"))
+		}
+		return nil
+	})
+	return archived, err
+}
+
+// moveMatches 复制对局、玩家记录和位置事件到归档表后删除在线数据（玩家记录和位置事件随对局级联删除）
+func moveMatches(tx *sql.Tx, ids []string) error {
+	_, err := tx.Exec(`
+		INSERT INTO match_records_archive (id, game_mode, map_id, start_time, end_time, status,
+		                                   max_players, current_players, winning_team, duration)
+		SELECT id, game_mode, map_id, start_time, end_time, status,
+		       max_players, current_players, winning_team, duration
+		FROM match_records WHERE id = ANY($1)
+		ON CONFLICT DO NOTHING
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("归档对局记录失败: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO player_match_records_archive (match_id, player_id, character_id, team, score,
+		                                          kills, deaths, assists, exp_gained, coins_gained,
+		                                          mvp, won, play_time, join_time, leave_time, match_start_time)
+		SELECT pmr.match_id, pmr.player_id, pmr.character_id, pmr.team, pmr.score,
+		       pmr.kills, pmr.deaths, pmr.assists, pmr.exp_gained, pmr.coins_gained,
+		       pmr.mvp, pmr.won, pmr.play_time, pmr.join_time, pmr.leave_time, mr.start_time
+		FROM player_match_records pmr
+		INNER JOIN match_records mr ON mr.id = pmr.match_id
+		WHERE pmr.match_id = ANY($1)
+		ON CONFLICT DO NOTHING
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("归档玩家对局记录失败: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO match_position_events_archive (id, match_id, event_type, player_id, other_player_id,
+		                                           character_id, pos_x, pos_y, elapsed, match_start_time)
+		SELECT e.id, e.match_id, e.event_type, e.player_id, e.other_player_id,
+		       e.character_id, e.pos_x, e.pos_y, e.elapsed, mr.start_time
+		FROM match_position_events e
+		INNER JOIN match_records mr ON mr.id = e.match_id
+		WHERE e.match_id = ANY($1)
+		ON CONFLICT DO NOTHING
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("归档位置事件失败: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM match_records WHERE id = ANY($1)`, ids); err != nil {
+		return fmt.Errorf("删除已归档对局失败: %w", err)
+	}
+	return nil
+}
+
+// ensurePartitions 创建指定月份的归档分区
+func ensurePartitions(tx *sql.Tx, month time.Time) error {
+	const layout = "2006-01-02 15:04:05-07"
+	from := month.Format(layout)
+	to := month.AddDate(0, 1, 0).Format(layout)
+	for _, table := range archivedTables {
+		partition := PartitionName(table, month)
+		_, err := tx.Exec(fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')`,
+			partition, table, from, to))
+		if err != nil {
+			return fmt.Errorf("创建归档分区 %s 失败: %w", partition, err)
+		}
+	}
+	return nil
+}
+
+// PartitionName 返回归档表指定月份的分区名，如 match_records_archive_y2026m01
+func PartitionName(table string, month time.Time) string {
+	return fmt.Sprintf("%s_y%04dm%02d", table, month.Year(), int(month.Month()))
+}
+
+// monthStart 返回所在月份第一天 00:00 UTC
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,261 @@
+// apply.go
+
+package seed
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// Mode 写入模式
+type Mode string
+
+const (
+	// ModeInsert 只插入数据库中不存在的记录，已有记录保持不变
+	ModeInsert Mode = "insert"
+	// ModeUpsert 插入不存在的记录并用种子数据覆盖已有记录
+	ModeUpsert Mode = "upsert"
+)
+
+// Result 单类数据的写入结果
+type Result struct {
+	Inserted int `json:"inserted"`
+	Updated  int `json:"updated"`
+	Skipped  int `json:"skipped"`
+}
+
+// Apply 在一个事务中写入一类种子数据，记录按名称（账号按用户名）匹配
+func Apply(conn *sql.DB, data *Data, kind Kind, mode Mode) (Result, error) {
+	var result Result
+	if mode != ModeInsert && mode != ModeUpsert {
+		return result, fmt.Errorf("未知的写入模式: %s", mode)
+	}
+
+	err := db.RunInTx(conn, func(tx *sql.Tx) error {
+		result = Result{}
+		w := &writer{tx: tx, mode: mode, result: &result}
+		switch kind {
+		case KindSkills:
+			return w.skills(data.Skills)
+		case KindCharacters:
+			return w.characters(data.Characters)
+		case KindMaps:
+			return w.maps(data.Maps)
+		case KindAccounts:
+			return w.accounts(data.Accounts)
+		default:
+			return fmt.Errorf("未知的数据类型: %s", kind)
+		}
+	})
+	return result, err
+}
+
+// writer 在事务中写入种子数据并累计结果
+type writer struct {
+	tx     *sql.Tx
+	mode   Mode
+	result *Result
+}
+
+// column 写入的列和值
+type column struct {
+	name  string
+	value interface{}
+}
+
+// save 按 key 列查找记录，不存在时插入，upsert 模式下更新已有记录。返回记录ID和记录是否被写入
+func (w *writer) save(table string, key column, columns []column) (int64, bool, error) {
+	var id int64
+	err := w.tx.QueryRow(fmt.Sprintf("SELECT id FROM %s WHERE %s = $1 ORDER BY id LIMIT 1", table, key.name), key.value).Scan(&id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		all := append([]column{key}, columns...)
+		names := make([]string, len(all))
+		placeholders := make([]string, len(all))
+		values := make([]interface{}, len(all))
+		for i, c := range all {
+			names[i], placeholders[i], values[i] = c.name, fmt.Sprintf("$%d", i+1), c.value
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING id", table, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+		if err := w.tx.QueryRow(query, values...).Scan(&id); err != nil {
+			return 0, false, fmt.Errorf("插入 %s %v 失败: %w", table, key.value, err)
+		}
+		w.result.Inserted++
+		return id, true, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("查询 %s %v 失败: %w", table, key.value, err)
+	case w.mode == ModeInsert:
+		w.result.Skipped++
+		return id, false, nil
+	}
+
+	assignments := make([]string, len(columns))
+	values := make([]interface{}, len(columns)+1)
+	for i, c := range columns {
+		assignments[i], values[i] = fmt.Sprintf("%s = $%d", c.name, i+1), c.value
+	}
+	values[len(columns)] = id
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", table, strings.Join(assignments, ", "), len(columns)+1)
+	if _, err := w.tx.Exec(query, values...); err != nil {
+		return 0, false, fmt.Errorf("更新 %s %v 失败: %w", table, key.value, err)
+	}
+	w.result.Updated++
+	return id, true, nil
+}
+
+// lookup 按名称查询记录ID
+func (w *writer) lookup(table, name string) (int64, error) {
+	var id int64
+	err := w.tx.QueryRow(fmt.Sprintf("SELECT id FROM %s WHERE name = $1 ORDER BY id LIMIT 1", table), name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s %q 不存在，请先写入", table, name)
+	}
+	return id, err
+}
+
+// skills 写入技能
+func (w *writer) skills(skills []Skill) error {
+	for _, s := range skills {
+		_, _, err := w.save("skills", column{"name", s.Name}, []column{
+			{"description", s.Description},
+			{"type", string(s.Type)},
+			{"damage", s.Damage},
+			{"cooldown_time", s.CooldownTime},
+			{"range", s.Range},
+			{"effect_time", s.EffectTime},
+			{"projectile_speed", s.ProjectileSpeed},
+			{"projectile_count", s.ProjectileCount},
+			{"projectile_spread", s.ProjectileSpread},
+			{"animation_key", s.AnimationKey},
+			{"effect_key", s.EffectKey},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// characters 写入角色，角色被写入时按种子数据重建技能槽
+func (w *writer) characters(characters []Character) error {
+	for _, c := range characters {
+		id, written, err := w.save("characters", column{"name", c.Name}, []column{
+			{"description", c.Description},
+			{"max_hp", c.MaxHP},
+			{"speed", c.Speed},
+			{"base_attack", c.BaseAttack},
+			{"base_defense", c.BaseDefense},
+			{"special_ability", c.SpecialAbility},
+			{"difficulty", c.Difficulty},
+			{"role", c.Role},
+			{"unlockable", c.Unlockable},
+			{"unlock_cost", c.UnlockCost},
+		})
+		if err != nil {
+			return err
+		}
+		if !written {
+			continue
+		}
+
+		if _, err := w.tx.Exec("DELETE FROM character_skills WHERE character_id = $1", id); err != nil {
+			return fmt.Errorf("清除角色 %s 的技能失败: %w", c.Name, err)
+		}
+		for slot, name := range c.Skills {
+			skillID, err := w.lookup("skills", name)
+			if err != nil {
+				return err
+			}
+			if _, err := w.tx.Exec("INSERT INTO character_skills (character_id, skill_id, slot_index) VALUES ($1, $2, $3)",
+				id, skillID, slot); err != nil {
+				return fmt.Errorf("关联角色 %s 的技能 %s 失败: %w", c.Name, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// maps 写入地图，地图被写入时按种子数据重建支持的模式
+func (w *writer) maps(maps []Map) error {
+	for _, m := range maps {
+		hazards, err := json.Marshal(append([]Hazard{}, m.Hazards...))
+		if err != nil {
+			return fmt.Errorf("序列化地图 %s 的危险区域失败: %w", m.Name, err)
+		}
+		id, written, err := w.save("game_maps", column{"name", m.Name}, []column{
+			{"description", m.Description},
+			{"image_path", m.ImagePath},
+			{"width", m.Width},
+			{"height", m.Height},
+			{"max_players", m.MaxPlayers},
+			{"hazards", hazards},
+		})
+		if err != nil {
+			return err
+		}
+		if !written {
+			continue
+		}
+
+		if _, err := w.tx.Exec("DELETE FROM map_modes WHERE map_id = $1", id); err != nil {
+			return fmt.Errorf("清除地图 %s 的模式失败: %w", m.Name, err)
+		}
+		for _, mode := range m.Modes {
+			if _, err := w.tx.Exec("INSERT INTO map_modes (map_id, mode) VALUES ($1, $2)", id, string(mode)); err != nil {
+				return fmt.Errorf("写入地图 %s 的模式 %s 失败: %w", m.Name, mode, err)
+			}
+		}
+	}
+	return nil
+}
+
+// accounts 写入测试账号，账号被写入时解锁默认角色并设为默认角色
+func (w *writer) accounts(accounts []Account) error {
+	for _, a := range accounts {
+		id, written, err := w.save("players", column{"username", a.Username}, []column{
+			{"password", hashPassword(a.Password)},
+			{"email", a.Email},
+			{"level", a.Level},
+			{"exp", a.Exp},
+			{"coins", a.Coins},
+			{"gems", a.Gems},
+		})
+		if err != nil {
+			return err
+		}
+		if !written || a.DefaultCharacter == "" {
+			continue
+		}
+
+		characterID, err := w.lookup("characters", a.DefaultCharacter)
+		if err != nil {
+			return err
+		}
+		if _, err := w.tx.Exec(`
+			INSERT INTO player_characters (player_id, character_id, unlocked, unlocked_at)
+			VALUES ($1, $2, true, NOW())
+			ON CONFLICT (player_id, character_id) DO UPDATE SET unlocked = true
+		`, id, characterID); err != nil {
+			return fmt.Errorf("为账号 %s 解锁角色失败: %w", a.Username, err)
+		}
+		if _, err := w.tx.Exec(`
+			INSERT INTO player_default_characters (player_id, character_id)
+			VALUES ($1, $2)
+			ON CONFLICT (player_id) DO UPDATE SET character_id = EXCLUDED.character_id
+		`, id, characterID); err != nil {
+			return fmt.Errorf("设置账号 %s 的默认角色失败: %w", a.Username, err)
+		}
+	}
+	return nil
+}
+
+// hashPassword 与网关登录校验一致的密码哈希
+func hashPassword(password string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(password)))
+}
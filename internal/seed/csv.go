@@ -0,0 +1,254 @@
+// csv.go
+
+package seed
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sheet 策划维护的表格类型，列名与 YAML 种子文件的字段名一致
+type Sheet string
+
+const (
+	// SheetSkills 技能表，按 name 列匹配
+	SheetSkills Sheet = "skills"
+	// SheetCharacters 角色表，按 name 列匹配，技能槽在 SheetSlots 中维护
+	SheetCharacters Sheet = "characters"
+	// SheetSlots 技能槽表，列为 character、slot、skill，出现的角色按表中内容重建技能槽
+	SheetSlots Sheet = "slots"
+)
+
+// MergeCSV 把表格中的行合并到 data：按名称覆盖已有记录中表格包含的列，表格中没有的列保持原值，
+// 名称不存在时新增记录。表格中未出现的记录保持不变
+func MergeCSV(data *Data, sheet Sheet, r io.Reader) error {
+	header, rows, err := readCSV(r)
+	if err != nil {
+		return err
+	}
+
+	switch sheet {
+	case SheetSkills:
+		return mergeRecords(&data.Skills, header, rows, func(s *Skill) string { return s.Name })
+	case SheetCharacters:
+		if index(header, "skills") >= 0 {
+			return fmt.Errorf("角色表不能包含 skills 列，技能槽请在 %s 表中维护", SheetSlots)
+		}
+		return mergeRecords(&data.Characters, header, rows, func(c *Character) string { return c.Name })
+	case SheetSlots:
+		return mergeSlots(data, header, rows)
+	default:
+		return fmt.Errorf("未知的表格类型: %s", sheet)
+	}
+}
+
+// csvRow 表格中的一行及其行号
+type csvRow struct {
+	line   int
+	values []string
+}
+
+// readCSV 读取表头和数据行，去掉表格软件导出的 BOM 和单元格两端的空白，跳过空行
+func readCSV(r io.Reader) ([]string, []csvRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取表头失败: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	seen := make(map[string]bool)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if column == "" || seen[column] {
+			return nil, nil, fmt.Errorf("表头第 %d 列为空或重复", i+1)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+
+	var rows []csvRow
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		empty := true
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+			empty = empty && values[i] == ""
+		}
+		if !empty {
+			rows = append(rows, csvRow{line: line, values: values})
+		}
+	}
+	return header, rows, nil
+}
+
+// index 列在表头中的位置，不存在时为 -1
+func index(header []string, column string) int {
+	for i, c := range header {
+		if c == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeRecords 按名称合并技能或角色记录，列通过 yaml 标签对应到字段
+func mergeRecords[T any](records *[]T, header []string, rows []csvRow, name func(*T) string) error {
+	if index(header, "name") < 0 {
+		return errors.New("表头缺少 name 列")
+	}
+	fields := fieldIndexes(reflect.TypeOf((*T)(nil)).Elem())
+	for _, column := range header {
+		if _, ok := fields[column]; !ok {
+			return fmt.Errorf("未知的列: %s", column)
+		}
+	}
+
+	positions := make(map[string]int, len(*records))
+	for i := range *records {
+		positions[name(&(*records)[i])] = i
+	}
+
+	for _, row := range rows {
+		var record T
+		pos, exists := positions[row.values[index(header, "name")]]
+		if exists {
+			record = (*records)[pos]
+		}
+		value := reflect.ValueOf(&record).Elem()
+		for i, column := range header {
+			if err := setField(value.Field(fields[column]), row.values[i]); err != nil {
+				return fmt.Errorf("第 %d 行 %s 列: %w", row.line, column, err)
+			}
+		}
+
+		if exists {
+			(*records)[pos] = record
+		} else {
+			positions[name(&record)] = len(*records)
+			*records = append(*records, record)
+		}
+	}
+	return nil
+}
+
+// fieldIndexes yaml 标签到字段序号的映射
+func fieldIndexes(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ","); tag != "" {
+			fields[tag] = i
+		}
+	}
+	return fields
+}
+
+// setField 把单元格的值解析到字段，数值列为空时为0，布尔列为空时为 false
+func setField(field reflect.Value, cell string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int, reflect.Int64:
+		if cell == "" {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return fmt.Errorf("无效的整数 %q", cell)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		if cell == "" {
+			field.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return fmt.Errorf("无效的数值 %q", cell)
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		if cell == "" {
+			field.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return fmt.Errorf("无效的布尔值 %q，应为 true 或 false", cell)
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("不支持的字段类型 %s", field.Type())
+	}
+	return nil
+}
+
+// mergeSlots 按技能槽表重建表中出现的角色的技能列表，槽位按序号排列
+func mergeSlots(data *Data, header []string, rows []csvRow) error {
+	characterCol, slotCol, skillCol := index(header, "character"), index(header, "slot"), index(header, "skill")
+	if characterCol < 0 || slotCol < 0 || skillCol < 0 || len(header) != 3 {
+		return errors.New("技能槽表的表头应为 character、slot、skill")
+	}
+
+	type slot struct {
+		index int
+		skill string
+	}
+	slots := make(map[string][]slot)
+	var order []string
+	for _, row := range rows {
+		character := row.values[characterCol]
+		n, err := strconv.Atoi(row.values[slotCol])
+		if err != nil || n < 0 {
+			return fmt.Errorf("第 %d 行: 无效的槽位 %q", row.line, row.values[slotCol])
+		}
+		for _, s := range slots[character] {
+			if s.index == n {
+				return fmt.Errorf("第 %d 行: 角色 %s 的槽位 %d 重复", row.line, character, n)
+			}
+		}
+		if _, ok := slots[character]; !ok {
+			order = append(order, character)
+		}
+		slots[character] = append(slots[character], slot{index: n, skill: row.values[skillCol]})
+	}
+
+	for _, name := range order {
+		character := findCharacter(data, name)
+		if character == nil {
+			return fmt.Errorf("技能槽表中的角色 %s 不存在", name)
+		}
+		list := slots[name]
+		sort.Slice(list, func(i, j int) bool { return list[i].index < list[j].index })
+		character.Skills = make([]string, len(list))
+		for i, s := range list {
+			character.Skills[i] = s.skill
+		}
+	}
+	return nil
+}
+
+// findCharacter 按名称查找角色
+func findCharacter(data *Data, name string) *Character {
+	for i := range data.Characters {
+		if data.Characters[i].Name == name {
+			return &data.Characters[i]
+		}
+	}
+	return nil
+}
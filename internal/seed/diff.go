@@ -0,0 +1,220 @@
+// diff.go
+
+package seed
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Action 记录的变化类型
+type Action string
+
+const (
+	// ActionAdd 新增记录
+	ActionAdd Action = "add"
+	// ActionUpdate 修改已有记录
+	ActionUpdate Action = "update"
+)
+
+// FieldChange 字段的新旧值
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new"`
+}
+
+// Change 一条记录的变化
+type Change struct {
+	Kind   Kind          `json:"kind"`
+	Name   string        `json:"name"`
+	Action Action        `json:"action"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// String 单行文本形式，如 "~ characters 突击兵: max_hp 100 → 110"
+func (c Change) String() string {
+	parts := make([]string, len(c.Fields))
+	for i, f := range c.Fields {
+		if c.Action == ActionAdd {
+			parts[i] = fmt.Sprintf("%s=%s", f.Field, formatValue(f.New))
+		} else {
+			parts[i] = fmt.Sprintf("%s %s → %s", f.Field, formatValue(f.Old), formatValue(f.New))
+		}
+	}
+	prefix := "~"
+	if c.Action == ActionAdd {
+		prefix = "+"
+	}
+	return fmt.Sprintf("%s %s %s: %s", prefix, c.Kind, c.Name, strings.Join(parts, ", "))
+}
+
+// formatValue 字段值的显示形式，技能列表以逗号分隔
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case []string:
+		return "[" + strings.Join(v, ", ") + "]"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Current 读取数据库中的技能和角色，角色的技能按槽位排列
+func Current(conn *sql.DB) (*Data, error) {
+	data := &Data{}
+
+	rows, err := conn.Query(`
+		SELECT name, COALESCE(description, ''), type, COALESCE(damage, 0), COALESCE(cooldown_time, 0),
+		       COALESCE(range, 0), COALESCE(effect_time, 0), COALESCE(projectile_speed, 0),
+		       COALESCE(projectile_count, 0), COALESCE(projectile_spread, 0),
+		       COALESCE(animation_key, ''), COALESCE(effect_key, '')
+		FROM skills ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询技能失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Skill
+		if err := rows.Scan(&s.Name, &s.Description, &s.Type, &s.Damage, &s.CooldownTime, &s.Range, &s.EffectTime,
+			&s.ProjectileSpeed, &s.ProjectileCount, &s.ProjectileSpread, &s.AnimationKey, &s.EffectKey); err != nil {
+			return nil, err
+		}
+		data.Skills = append(data.Skills, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	charRows, err := conn.Query(`
+		SELECT id, name, COALESCE(description, ''), max_hp, speed, base_attack, base_defense,
+		       COALESCE(special_ability, ''), COALESCE(difficulty, 1), COALESCE(role, ''),
+		       COALESCE(unlockable, true), COALESCE(unlock_cost, 0)
+		FROM characters ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	defer charRows.Close()
+	positions := make(map[int64]int)
+	for charRows.Next() {
+		var id int64
+		var c Character
+		if err := charRows.Scan(&id, &c.Name, &c.Description, &c.MaxHP, &c.Speed, &c.BaseAttack, &c.BaseDefense,
+			&c.SpecialAbility, &c.Difficulty, &c.Role, &c.Unlockable, &c.UnlockCost); err != nil {
+			return nil, err
+		}
+		positions[id] = len(data.Characters)
+		data.Characters = append(data.Characters, c)
+	}
+	if err := charRows.Err(); err != nil {
+		return nil, err
+	}
+
+	slotRows, err := conn.Query(`
+		SELECT cs.character_id, s.name
+		FROM character_skills cs JOIN skills s ON s.id = cs.skill_id
+		ORDER BY cs.character_id, cs.slot_index
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询角色技能失败: %w", err)
+	}
+	defer slotRows.Close()
+	for slotRows.Next() {
+		var characterID int64
+		var skill string
+		if err := slotRows.Scan(&characterID, &skill); err != nil {
+			return nil, err
+		}
+		if i, ok := positions[characterID]; ok {
+			data.Characters[i].Skills = append(data.Characters[i].Skills, skill)
+		}
+	}
+	return data, slotRows.Err()
+}
+
+// Diff 比较两份数据中的技能和角色，返回 after 相对 before 新增和修改的记录，以及只包含这些记录的数据。
+// after 中没有的记录不视为删除
+func Diff(before, after *Data) ([]Change, *Data) {
+	changed := &Data{}
+	var changes []Change
+
+	skills := make(map[string]Skill, len(before.Skills))
+	for _, s := range before.Skills {
+		skills[s.Name] = s
+	}
+	for _, s := range after.Skills {
+		old, exists := skills[s.Name]
+		if change, ok := diffRecord(KindSkills, s.Name, old, s, exists); ok {
+			changes = append(changes, change)
+			changed.Skills = append(changed.Skills, s)
+		}
+	}
+
+	characters := make(map[string]Character, len(before.Characters))
+	for _, c := range before.Characters {
+		characters[c.Name] = c
+	}
+	for _, c := range after.Characters {
+		old, exists := characters[c.Name]
+		if change, ok := diffRecord(KindCharacters, c.Name, old, c, exists); ok {
+			changes = append(changes, change)
+			changed.Characters = append(changed.Characters, c)
+		}
+	}
+	return changes, changed
+}
+
+// diffRecord 按 yaml 标签逐个比较字段，新增记录列出全部非零字段
+func diffRecord(kind Kind, name string, before, after interface{}, exists bool) (Change, bool) {
+	change := Change{Kind: kind, Name: name, Action: ActionUpdate}
+	if !exists {
+		change.Action = ActionAdd
+	}
+
+	oldValue, newValue := reflect.ValueOf(before), reflect.ValueOf(after)
+	t := newValue.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if field == "name" {
+			continue
+		}
+		o, n := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if !exists {
+			if !newValue.Field(i).IsZero() {
+				change.Fields = append(change.Fields, FieldChange{Field: field, New: n})
+			}
+			continue
+		}
+		if !equalValue(o, n) {
+			change.Fields = append(change.Fields, FieldChange{Field: field, Old: o, New: n})
+		}
+	}
+	return change, !exists || len(change.Fields) > 0
+}
+
+// equalValue 比较字段值，空列表与 nil 相同，小数按数据库的两位精度比较
+func equalValue(a, b interface{}) bool {
+	switch a := a.(type) {
+	case []string:
+		bs := b.([]string)
+		if len(a) != len(bs) {
+			return false
+		}
+		for i := range a {
+			if a[i] != bs[i] {
+				return false
+			}
+		}
+		return true
+	case float64:
+		d := a - b.(float64)
+		return d < 0.005 && d > -0.005
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,269 @@
+// seed.go
+
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Version 当前支持的种子文件格式版本，字段有不兼容的变化时递增
+const Version = 1
+
+// Kind 种子数据类型
+type Kind string
+
+const (
+	// KindSkills 技能
+	KindSkills Kind = "skills"
+	// KindCharacters 角色及其技能
+	KindCharacters Kind = "characters"
+	// KindMaps 地图及其支持的模式
+	KindMaps Kind = "maps"
+	// KindAccounts 测试账号
+	KindAccounts Kind = "accounts"
+)
+
+// Kinds 按写入顺序排列的种子数据类型，后面的类型引用前面的数据
+var Kinds = []Kind{KindSkills, KindCharacters, KindMaps, KindAccounts}
+
+// Skill 技能
+type Skill struct {
+	Name             string           `yaml:"name" json:"name"`
+	Description      string           `yaml:"description" json:"description"`
+	Type             models.SkillType `yaml:"type" json:"type"`
+	Damage           int              `yaml:"damage" json:"damage"` // 负数表示治疗
+	CooldownTime     float64          `yaml:"cooldown_time" json:"cooldown_time"`
+	Range            float64          `yaml:"range" json:"range"`
+	EffectTime       float64          `yaml:"effect_time" json:"effect_time"`
+	ProjectileSpeed  float64          `yaml:"projectile_speed" json:"projectile_speed"`
+	ProjectileCount  int              `yaml:"projectile_count" json:"projectile_count"`
+	ProjectileSpread float64          `yaml:"projectile_spread" json:"projectile_spread"`
+	AnimationKey     string           `yaml:"animation_key" json:"animation_key"`
+	EffectKey        string           `yaml:"effect_key" json:"effect_key"`
+}
+
+// Character 角色，Skills 按顺序占用技能槽
+type Character struct {
+	Name           string   `yaml:"name" json:"name"`
+	Description    string   `yaml:"description" json:"description"`
+	MaxHP          int      `yaml:"max_hp" json:"max_hp"`
+	Speed          float64  `yaml:"speed" json:"speed"`
+	BaseAttack     int      `yaml:"base_attack" json:"base_attack"`
+	BaseDefense    int      `yaml:"base_defense" json:"base_defense"`
+	SpecialAbility string   `yaml:"special_ability" json:"special_ability"`
+	Difficulty     int      `yaml:"difficulty" json:"difficulty"`
+	Role           string   `yaml:"role" json:"role"`
+	Unlockable     bool     `yaml:"unlockable" json:"unlockable"`
+	UnlockCost     int      `yaml:"unlock_cost" json:"unlock_cost"`
+	Skills         []string `yaml:"skills" json:"skills"`
+}
+
+// Map 地图
+type Map struct {
+	Name        string            `yaml:"name" json:"name"`
+	Description string            `yaml:"description" json:"description"`
+	ImagePath   string            `yaml:"image_path" json:"image_path"`
+	Width       int               `yaml:"width" json:"width"`
+	Height      int               `yaml:"height" json:"height"`
+	MaxPlayers  int               `yaml:"max_players" json:"max_players"`
+	Modes       []models.GameMode `yaml:"modes" json:"modes"`
+	Hazards     []Hazard          `yaml:"hazards" json:"hazards"`
+}
+
+// Hazard 地图危险区域，字段含义见 models.MapHazard
+type Hazard struct {
+	Type     models.HazardType `yaml:"type" json:"type"`
+	X        float64           `yaml:"x" json:"x"`
+	Y        float64           `yaml:"y" json:"y"`
+	Width    float64           `yaml:"width" json:"width"`
+	Height   float64           `yaml:"height" json:"height"`
+	Damage   int               `yaml:"damage" json:"damage,omitempty"`
+	Interval float64           `yaml:"interval" json:"interval,omitempty"`
+	Lethal   bool              `yaml:"lethal" json:"lethal,omitempty"`
+}
+
+// Account 测试账号，解锁默认角色并设为默认角色
+type Account struct {
+	Username         string `yaml:"username" json:"username"`
+	Password         string `yaml:"password" json:"password"`
+	Email            string `yaml:"email" json:"email"`
+	Level            int    `yaml:"level" json:"level"`
+	Exp              int64  `yaml:"exp" json:"exp"`
+	Coins            int64  `yaml:"coins" json:"coins"`
+	Gems             int64  `yaml:"gems" json:"gems"`
+	DefaultCharacter string `yaml:"default_character" json:"default_character"`
+}
+
+// file 单个种子文件，一个文件可以包含多种数据
+type file struct {
+	Version    int         `yaml:"version" json:"version"`
+	Skills     []Skill     `yaml:"skills" json:"skills"`
+	Characters []Character `yaml:"characters" json:"characters"`
+	Maps       []Map       `yaml:"maps" json:"maps"`
+	Accounts   []Account   `yaml:"accounts" json:"accounts"`
+}
+
+// Data 目录中全部种子文件合并后的数据
+type Data struct {
+	Skills     []Skill
+	Characters []Character
+	Maps       []Map
+	Accounts   []Account
+}
+
+// Load 读取目录中的 .yaml、.yml 和 .json 种子文件并校验，未知字段和不支持的版本视为错误
+func Load(dir string) (*Data, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子数据目录失败: %w", err)
+	}
+
+	data := &Data{}
+	loaded := 0
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		f, err := readFile(filepath.Join(dir, entry.Name()), ext)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		data.Skills = append(data.Skills, f.Skills...)
+		data.Characters = append(data.Characters, f.Characters...)
+		data.Maps = append(data.Maps, f.Maps...)
+		data.Accounts = append(data.Accounts, f.Accounts...)
+		loaded++
+	}
+	if loaded == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有种子文件", dir)
+	}
+
+	if err := data.Validate(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// readFile 按扩展名解析种子文件
+func readFile(path, ext string) (*file, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if ext == ".json" {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&f)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析失败: %w", err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("不支持的版本 %d，当前版本为 %d", f.Version, Version)
+	}
+	return &f, nil
+}
+
+// skillTypes 可用的技能类型
+var skillTypes = map[models.SkillType]bool{
+	models.ProjectileSkill: true,
+	models.AOESkill:        true,
+	models.BuffSkill:       true,
+	models.DebuffSkill:     true,
+	models.MovementSkill:   true,
+	models.UtilitySkill:    true,
+}
+
+// maxNameLength 名称的最大长度，与表结构一致
+const maxNameLength = 50
+
+// Validate 校验字段取值、名称唯一性和引用关系，返回全部错误
+func (d *Data) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	checkName := func(kind Kind, i int, name string, seen map[string]bool) {
+		check(name != "" && len([]rune(name)) <= maxNameLength, "%s[%d].name 不能为空且不超过 %d 个字符", kind, i, maxNameLength)
+		check(!seen[name], "%s[%d].name %q 重复", kind, i, name)
+		seen[name] = true
+	}
+
+	skills := make(map[string]bool)
+	for i, s := range d.Skills {
+		checkName(KindSkills, i, s.Name, skills)
+		check(skillTypes[s.Type], "skills[%d].type 的值 %q 无效", i, s.Type)
+		check(s.CooldownTime >= 0 && s.Range >= 0 && s.EffectTime >= 0 && s.ProjectileSpeed >= 0 &&
+			s.ProjectileCount >= 0 && s.ProjectileSpread >= 0, "skills[%d] 除 damage 外的数值不能为负数", i)
+		check(s.Type != models.ProjectileSkill || (s.ProjectileCount > 0 && s.ProjectileSpeed > 0),
+			"skills[%d] 投射物技能的 projectile_count 和 projectile_speed 必须大于0", i)
+		check(s.ProjectileSpread <= 360, "skills[%d].projectile_spread 不能超过360度", i)
+	}
+
+	characters := make(map[string]bool)
+	for i, c := range d.Characters {
+		checkName(KindCharacters, i, c.Name, characters)
+		check(c.MaxHP > 0 && c.Speed > 0, "characters[%d].max_hp 和 speed 必须大于0", i)
+		check(c.BaseAttack >= 0 && c.BaseDefense >= 0, "characters[%d].base_attack 和 base_defense 不能为负数", i)
+		check(c.Difficulty >= 1 && c.Difficulty <= 5, "characters[%d].difficulty 应在 1-5 之间", i)
+		check(c.UnlockCost >= 0, "characters[%d].unlock_cost 不能为负数", i)
+		check(len(c.Skills) > 0, "characters[%d].skills 不能为空", i)
+		used := make(map[string]bool)
+		for _, skill := range c.Skills {
+			check(skills[skill], "characters[%d] 引用的技能 %q 不存在", i, skill)
+			check(!used[skill], "characters[%d] 的技能 %q 重复", i, skill)
+			used[skill] = true
+		}
+	}
+
+	maps := make(map[string]bool)
+	for i, m := range d.Maps {
+		checkName(KindMaps, i, m.Name, maps)
+		check(m.Width > 0 && m.Height > 0 && m.MaxPlayers > 0, "maps[%d].width、height 和 max_players 必须大于0", i)
+		check(len(m.Modes) > 0, "maps[%d].modes 不能为空", i)
+		used := make(map[models.GameMode]bool)
+		for _, mode := range m.Modes {
+			check(mode.IsValid(), "maps[%d].modes 中的 %q 不是有效的游戏模式", i, mode)
+			check(!used[mode], "maps[%d].modes 中的 %q 重复", i, mode)
+			used[mode] = true
+		}
+		for j, h := range m.Hazards {
+			check(h.Type.IsValid(), "maps[%d].hazards[%d].type 的值 %q 无效", i, j, h.Type)
+			check(h.Width > 0 && h.Height > 0, "maps[%d].hazards[%d].width 和 height 必须大于0", i, j)
+			check(h.Lethal || (h.Damage > 0 && h.Interval > 0),
+				"maps[%d].hazards[%d] 非致命区域的 damage 和 interval 必须大于0", i, j)
+		}
+	}
+
+	accounts := make(map[string]bool)
+	emails := make(map[string]bool)
+	for i, a := range d.Accounts {
+		checkName(KindAccounts, i, a.Username, accounts)
+		check(a.Password != "", "accounts[%d].password 不能为空", i)
+		check(strings.Contains(a.Email, "@"), "accounts[%d].email %q 格式无效", i, a.Email)
+		check(!emails[a.Email], "accounts[%d].email %q 重复", i, a.Email)
+		emails[a.Email] = true
+		check(a.Level >= 1 && a.Exp >= 0 && a.Coins >= 0 && a.Gems >= 0, "accounts[%d] 的等级至少为1，经验和货币不能为负数", i)
+		check(a.DefaultCharacter == "" || characters[a.DefaultCharacter], "accounts[%d] 引用的角色 %q 不存在", i, a.DefaultCharacter)
+	}
+
+	return errors.Join(errs...)
+}
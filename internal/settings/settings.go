@@ -0,0 +1,186 @@
+// settings.go
+
+package settings
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// 设置数据限制
+const (
+	// MaxSettingsSize 设置数据最大字节数
+	MaxSettingsSize = 16 * 1024
+	// maxSectionEntries 每个分区最多的条目数
+	maxSectionEntries = 200
+	// maxKeyLength 条目名称最大长度
+	maxKeyLength = 64
+	// maxStringLength 字符串值最大长度
+	maxStringLength = 256
+	// minSensitivity 灵敏度下限
+	minSensitivity = 0.01
+	// maxSensitivity 灵敏度上限
+	maxSensitivity = 100
+)
+
+var (
+	// ErrInvalidSettings 设置格式无效
+	ErrInvalidSettings = errcode.New(errcode.BadRequest, "无效的设置数据")
+	// ErrSettingsTooLarge 设置数据过大
+	ErrSettingsTooLarge = errcode.New(errcode.PayloadTooLarge, "设置数据过大")
+	// ErrVersionConflict 版本冲突，设置已在其他设备上修改
+	ErrVersionConflict = errcode.New(errcode.VersionConflict, "设置已在其他设备上修改，请重新获取后再保存")
+)
+
+// sectionValidators 允许的顶层分区及其校验函数
+var sectionValidators = map[string]func(map[string]interface{}) error{
+	"sensitivity": validateSensitivity,
+	"keybinds":    validateKeybinds,
+	"ui":          validateScalars,
+	"audio":       validateScalars,
+	"graphics":    validateScalars,
+}
+
+// Get 查询玩家设置，未保存过时返回空设置和版本0
+func Get(playerID int64) (*models.PlayerSettings, error) {
+	s := &models.PlayerSettings{PlayerID: playerID}
+	var data []byte
+	var updatedAt time.Time
+	err := db.DB.QueryRow(`
+		SELECT data, version, updated_at FROM player_settings WHERE player_id = $1
+	`, playerID).Scan(&data, &s.Version, &updatedAt)
+	if err == sql.ErrNoRows {
+		s.Settings = json.RawMessage(`{}`)
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家设置失败: %w", err)
+	}
+
+	s.Settings = json.RawMessage(data)
+	s.UpdatedAt = &updatedAt
+	return s, nil
+}
+
+// Save 保存玩家设置，version 必须与当前版本一致，成功后版本号加1
+func Save(playerID int64, version int, data json.RawMessage) (*models.PlayerSettings, error) {
+	if version < 0 {
+		return nil, fmt.Errorf("%w: 版本号不能为负数", ErrInvalidSettings)
+	}
+	compact, err := Validate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &models.PlayerSettings{PlayerID: playerID, Settings: compact}
+	var updatedAt time.Time
+	if version == 0 {
+		// 首次保存，已有记录说明其他设备先保存过
+		err = db.DB.QueryRow(`
+			INSERT INTO player_settings (player_id, data, version, updated_at)
+			VALUES ($1, $2, 1, NOW())
+			ON CONFLICT (player_id) DO NOTHING
+			RETURNING version, updated_at
+		`, playerID, string(compact)).Scan(&s.Version, &updatedAt)
+	} else {
+		err = db.DB.QueryRow(`
+			UPDATE player_settings SET data = $2, version = version + 1, updated_at = NOW()
+			WHERE player_id = $1 AND version = $3
+			RETURNING version, updated_at
+		`, playerID, string(compact), version).Scan(&s.Version, &updatedAt)
+	}
+	if err == sql.ErrNoRows {
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("保存玩家设置失败: %w", err)
+	}
+
+	s.UpdatedAt = &updatedAt
+	return s, nil
+}
+
+// Validate 校验设置数据的大小和结构，返回压缩后的JSON
+func Validate(data json.RawMessage) (json.RawMessage, error) {
+	if len(data) > MaxSettingsSize {
+		return nil, fmt.Errorf("%w: 最大 %d 字节", ErrSettingsTooLarge, MaxSettingsSize)
+	}
+
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil || sections == nil {
+		return nil, fmt.Errorf("%w: 设置必须是JSON对象", ErrInvalidSettings)
+	}
+
+	for name, raw := range sections {
+		validate, ok := sectionValidators[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: 未知的设置分区 %q", ErrInvalidSettings, name)
+		}
+		var entries map[string]interface{}
+		if err := json.Unmarshal(raw, &entries); err != nil || entries == nil {
+			return nil, fmt.Errorf("%w: 分区 %q 必须是JSON对象", ErrInvalidSettings, name)
+		}
+		if len(entries) > maxSectionEntries {
+			return nil, fmt.Errorf("%w: 分区 %q 条目过多", ErrInvalidSettings, name)
+		}
+		for key := range entries {
+			if key == "" || len(key) > maxKeyLength {
+				return nil, fmt.Errorf("%w: 分区 %q 中的条目名称无效", ErrInvalidSettings, name)
+			}
+		}
+		if err := validate(entries); err != nil {
+			return nil, fmt.Errorf("%w: 分区 %q %v", ErrInvalidSettings, name, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// validateSensitivity 灵敏度必须是有效范围内的数值
+func validateSensitivity(entries map[string]interface{}) error {
+	for key, value := range entries {
+		v, ok := value.(float64)
+		if !ok || v < minSensitivity || v > maxSensitivity {
+			return fmt.Errorf("中的 %q 必须是 %g 到 %g 之间的数值", key, minSensitivity, float64(maxSensitivity))
+		}
+	}
+	return nil
+}
+
+// validateKeybinds 键位绑定必须是字符串，空字符串表示未绑定
+func validateKeybinds(entries map[string]interface{}) error {
+	for key, value := range entries {
+		v, ok := value.(string)
+		if !ok || len(v) > maxKeyLength {
+			return fmt.Errorf("中的 %q 必须是长度不超过 %d 的字符串", key, maxKeyLength)
+		}
+	}
+	return nil
+}
+
+// validateScalars 普通选项只允许字符串、数值和布尔值
+func validateScalars(entries map[string]interface{}) error {
+	for key, value := range entries {
+		switch v := value.(type) {
+		case bool, float64:
+		case string:
+			if len(v) > maxStringLength {
+				return fmt.Errorf("中的 %q 超过 %d 个字符", key, maxStringLength)
+			}
+		default:
+			return fmt.Errorf("中的 %q 只能是字符串、数值或布尔值", key)
+		}
+	}
+	return nil
+}
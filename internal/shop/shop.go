@@ -0,0 +1,382 @@
+// shop.go
+
+package shop
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/inventory"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrOfferNotFound 商品不存在
+	ErrOfferNotFound = errcode.New(errcode.NotFound, "商品不存在")
+	// ErrOfferUnavailable 商品当前不可购买
+	ErrOfferUnavailable = errcode.New(errcode.OfferUnavailable, "商品当前不可购买")
+	// ErrPurchaseLimit 已达到限购次数
+	ErrPurchaseLimit = errcode.New(errcode.LimitReached, "已达到限购次数")
+	// ErrInvalidCatalog 商品目录参数无效
+	ErrInvalidCatalog = errcode.New(errcode.BadRequest, "无效的商品参数")
+)
+
+// Service 商店服务
+type Service struct {
+	wallet *wallet.Service
+}
+
+// NewService 创建商店服务
+func NewService(w *wallet.Service) *Service {
+	return &Service{wallet: w}
+}
+
+// offerColumns 商品查询字段，与 scanOffer 顺序一致
+const offerColumns = `
+	o.id, o.item_id, o.quantity, o.currency, o.price, o.available_from, o.available_until,
+	o.purchase_limit, o.sort_order, o.enabled, o.created_at, o.updated_at,
+	i.id, i.name, COALESCE(i.description, ''), i.item_type, COALESCE(i.ref_id, 0), i.stackable, i.created_at
+`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOffer 扫描商品及其道具
+func scanOffer(row rowScanner) (*models.ShopOffer, error) {
+	var offer models.ShopOffer
+	var item models.Item
+	var from, until sql.NullTime
+
+	err := row.Scan(
+		&offer.ID, &offer.ItemID, &offer.Quantity, &offer.Currency, &offer.Price, &from, &until,
+		&offer.PurchaseLimit, &offer.SortOrder, &offer.Enabled, &offer.CreatedAt, &offer.UpdatedAt,
+		&item.ID, &item.Name, &item.Description, &item.Type, &item.RefID, &item.Stackable, &item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.Valid {
+		offer.AvailableFrom = &from.Time
+	}
+	if until.Valid {
+		offer.AvailableUntil = &until.Time
+	}
+	offer.Item = &item
+	return &offer, nil
+}
+
+// ListOffers 查询商品列表，availableOnly为true时只返回当前可购买的商品，价格按进行中的限时活动折扣
+func (s *Service) ListOffers(availableOnly bool) ([]models.ShopOffer, error) {
+	query := `SELECT ` + offerColumns + `
+		FROM shop_offers o
+		INNER JOIN items i ON i.id = o.item_id`
+	if availableOnly {
+		query += `
+		WHERE o.enabled = true
+			AND (o.available_from IS NULL OR o.available_from <= NOW())
+			AND (o.available_until IS NULL OR o.available_until > NOW())`
+	}
+	query += `
+		ORDER BY o.sort_order, o.id`
+
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	defer rows.Close()
+
+	discount := 0
+	if availableOnly {
+		discount = liveevent.ShopDiscount(time.Now())
+	}
+
+	offers := make([]models.ShopOffer, 0)
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描商品失败: %w", err)
+		}
+		offer.ApplyDiscount(discount)
+		offers = append(offers, *offer)
+	}
+	return offers, rows.Err()
+}
+
+// GetOffer 查询单个商品
+func (s *Service) GetOffer(offerID int) (*models.ShopOffer, error) {
+	row := db.DB.QueryRow(`SELECT `+offerColumns+`
+		FROM shop_offers o
+		INNER JOIN items i ON i.id = o.item_id
+		WHERE o.id = $1`, offerID)
+
+	offer, err := scanOffer(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	return offer, err
+}
+
+// Purchase 购买商品：在同一事务中扣除货币、发放道具并记录购买。
+// requestID 由客户端生成，重复提交同一请求时返回首次购买的结果。
+func (s *Service) Purchase(playerID int64, offerID int, requestID string) (*models.ShopPurchase, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("%w: 请求ID不能为空", ErrInvalidCatalog)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 锁定玩家行，串行化同一玩家的购买
+	var locked int64
+	err = tx.QueryRow(`SELECT id FROM players WHERE id = $1 FOR UPDATE`, playerID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, wallet.ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("锁定玩家失败: %w", err)
+	}
+
+	// 重复请求直接返回已有购买记录
+	idempotencyKey := fmt.Sprintf("shop:%d:%s", playerID, requestID)
+	if existing, err := getPurchaseByKey(tx, idempotencyKey); err != nil || existing != nil {
+		return existing, err
+	}
+
+	offer, err := scanOffer(tx.QueryRow(`SELECT `+offerColumns+`
+		FROM shop_offers o
+		INNER JOIN items i ON i.id = o.item_id
+		WHERE o.id = $1`, offerID))
+	if err == sql.ErrNoRows {
+		return nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询商品失败: %w", err)
+	}
+	now := time.Now()
+	if !offer.IsAvailable(now) {
+		return nil, ErrOfferUnavailable
+	}
+	offer.ApplyDiscount(liveevent.ShopDiscount(now))
+
+	// 限购检查
+	if offer.PurchaseLimit > 0 {
+		var bought int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM shop_purchases WHERE player_id = $1 AND offer_id = $2`,
+			playerID, offerID).Scan(&bought)
+		if err != nil {
+			return nil, fmt.Errorf("查询购买次数失败: %w", err)
+		}
+		if bought >= offer.PurchaseLimit {
+			return nil, ErrPurchaseLimit
+		}
+	}
+
+	// 发放道具
+	if err := inventory.GrantTx(tx, playerID, offer.Item, offer.Quantity); err != nil {
+		return nil, err
+	}
+
+	// 扣除货币
+	txn, err := s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       playerID,
+		Currency:       offer.Currency,
+		Amount:         -offer.Price,
+		Reason:         models.ReasonPurchase,
+		ReferenceID:    fmt.Sprintf("offer:%d", offer.ID),
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	purchase := &models.ShopPurchase{
+		PlayerID:      playerID,
+		OfferID:       offer.ID,
+		ItemID:        offer.ItemID,
+		Quantity:      offer.Quantity,
+		Currency:      offer.Currency,
+		Price:         offer.Price,
+		TransactionID: txn.ID,
+		BalanceAfter:  txn.BalanceAfter,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO shop_purchases (player_id, offer_id, item_id, quantity, currency, price, transaction_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, purchase.PlayerID, purchase.OfferID, purchase.ItemID, purchase.Quantity,
+		string(purchase.Currency), purchase.Price, purchase.TransactionID).Scan(&purchase.ID, &purchase.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("写入购买记录失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+
+	events.Publish(events.PurchaseMade, models.PurchaseMadeEvent{
+		PlayerID:    playerID,
+		Source:      models.PurchaseSourceShop,
+		ProductID:   strconv.Itoa(purchase.OfferID),
+		Quantity:    purchase.Quantity,
+		Currency:    string(purchase.Currency),
+		Amount:      purchase.Price,
+		PurchasedAt: purchase.CreatedAt,
+	})
+	return purchase, nil
+}
+
+// getPurchaseByKey 按交易幂等键查询购买记录，不存在时返回nil
+func getPurchaseByKey(tx *sql.Tx, key string) (*models.ShopPurchase, error) {
+	var p models.ShopPurchase
+	var offerID, itemID sql.NullInt64
+	err := tx.QueryRow(`
+		SELECT sp.id, sp.player_id, sp.offer_id, sp.item_id, sp.quantity, sp.currency, sp.price,
+		       sp.transaction_id, ct.balance_after, sp.created_at
+		FROM shop_purchases sp
+		INNER JOIN currency_transactions ct ON ct.id = sp.transaction_id
+		WHERE ct.idempotency_key = $1
+	`, key).Scan(&p.ID, &p.PlayerID, &offerID, &itemID, &p.Quantity, &p.Currency, &p.Price,
+		&p.TransactionID, &p.BalanceAfter, &p.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询购买记录失败: %w", err)
+	}
+	p.OfferID = int(offerID.Int64)
+	p.ItemID = int(itemID.Int64)
+	return &p, nil
+}
+
+// ListItems 查询全部道具定义
+func (s *Service) ListItems() ([]models.Item, error) {
+	rows, err := db.DB.Query(`
+		SELECT id, name, COALESCE(description, ''), item_type, COALESCE(ref_id, 0), stackable, created_at
+		FROM items
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("查询道具失败: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.Item, 0)
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(&item.ID, &item.Name, &item.Description, &item.Type,
+			&item.RefID, &item.Stackable, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("扫描道具失败: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// CreateItem 创建道具定义
+func (s *Service) CreateItem(item *models.Item) error {
+	if item.Name == "" || !item.Type.IsValid() {
+		return fmt.Errorf("%w: 名称不能为空且类型必须为 character、cosmetic 或 consumable", ErrInvalidCatalog)
+	}
+	if item.Type == models.ItemCharacter {
+		if item.RefID <= 0 {
+			return fmt.Errorf("%w: 角色道具必须指定 ref_id", ErrInvalidCatalog)
+		}
+		item.Stackable = false
+	}
+	if item.RequiredCharacterLevel < 0 {
+		return fmt.Errorf("%w: 角色等级要求不能为负数", ErrInvalidCatalog)
+	}
+
+	return db.DB.QueryRow(`
+		INSERT INTO items (name, description, item_type, ref_id, stackable, required_character_level)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, item.Name, item.Description, string(item.Type), item.RefID, item.Stackable,
+		item.RequiredCharacterLevel).Scan(&item.ID, &item.CreatedAt)
+}
+
+// validateOffer 校验商品参数
+func validateOffer(offer *models.ShopOffer) error {
+	if offer.ItemID <= 0 {
+		return fmt.Errorf("%w: 必须指定 item_id", ErrInvalidCatalog)
+	}
+	if !offer.Currency.IsValid() {
+		return fmt.Errorf("%w: 无效的货币类型 %q", ErrInvalidCatalog, offer.Currency)
+	}
+	if offer.Price <= 0 {
+		return fmt.Errorf("%w: 价格必须大于0", ErrInvalidCatalog)
+	}
+	if offer.Quantity <= 0 {
+		offer.Quantity = 1
+	}
+	if offer.PurchaseLimit < 0 {
+		return fmt.Errorf("%w: 限购次数不能为负数", ErrInvalidCatalog)
+	}
+	if offer.AvailableFrom != nil && offer.AvailableUntil != nil && !offer.AvailableFrom.Before(*offer.AvailableUntil) {
+		return fmt.Errorf("%w: 上架时间必须早于下架时间", ErrInvalidCatalog)
+	}
+	return nil
+}
+
+// CreateOffer 创建商品
+func (s *Service) CreateOffer(offer *models.ShopOffer) error {
+	if err := validateOffer(offer); err != nil {
+		return err
+	}
+
+	return db.DB.QueryRow(`
+		INSERT INTO shop_offers (item_id, quantity, currency, price, available_from, available_until,
+		                         purchase_limit, sort_order, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`, offer.ItemID, offer.Quantity, string(offer.Currency), offer.Price, offer.AvailableFrom,
+		offer.AvailableUntil, offer.PurchaseLimit, offer.SortOrder, offer.Enabled,
+	).Scan(&offer.ID, &offer.CreatedAt, &offer.UpdatedAt)
+}
+
+// UpdateOffer 更新商品
+func (s *Service) UpdateOffer(offer *models.ShopOffer) error {
+	if err := validateOffer(offer); err != nil {
+		return err
+	}
+
+	err := db.DB.QueryRow(`
+		UPDATE shop_offers SET
+			item_id = $2, quantity = $3, currency = $4, price = $5, available_from = $6,
+			available_until = $7, purchase_limit = $8, sort_order = $9, enabled = $10,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING created_at, updated_at
+	`, offer.ID, offer.ItemID, offer.Quantity, string(offer.Currency), offer.Price, offer.AvailableFrom,
+		offer.AvailableUntil, offer.PurchaseLimit, offer.SortOrder, offer.Enabled,
+	).Scan(&offer.CreatedAt, &offer.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrOfferNotFound
+	}
+	return err
+}
+
+// DisableOffer 下架商品（保留记录以便追溯购买历史）
+func (s *Service) DisableOffer(offerID int) error {
+	result, err := db.DB.Exec(`UPDATE shop_offers SET enabled = false, updated_at = NOW() WHERE id = $1`, offerID)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrOfferNotFound
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// block.go
+
+package social
+
+import (
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrCannotBlockSelf 不能屏蔽自己
+	ErrCannotBlockSelf = errcode.New(errcode.BadRequest, "不能屏蔽自己")
+	// ErrPlayerNotFound 玩家不存在
+	ErrPlayerNotFound = errcode.New(errcode.NotFound, "玩家不存在")
+	// ErrBlocked 双方存在屏蔽关系
+	ErrBlocked = errcode.New(errcode.Blocked, "对方已屏蔽你或你已屏蔽对方")
+)
+
+// Block 屏蔽玩家，重复屏蔽不报错
+func Block(playerID, blockedID int64) error {
+	if playerID == blockedID {
+		return ErrCannotBlockSelf
+	}
+
+	var exists bool
+	if err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM players WHERE id = $1)`, blockedID).Scan(&exists); err != nil {
+		return fmt.Errorf("查询玩家失败: %w", err)
+	}
+	if !exists {
+		return ErrPlayerNotFound
+	}
+
+	_, err := db.DB.Exec(`
+		INSERT INTO player_blocks (player_id, blocked_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, playerID, blockedID)
+	if err != nil {
+		return fmt.Errorf("屏蔽玩家失败: %w", err)
+	}
+	return nil
+}
+
+// Unblock 取消屏蔽
+func Unblock(playerID, blockedID int64) error {
+	if _, err := db.DB.Exec(`DELETE FROM player_blocks WHERE player_id = $1 AND blocked_id = $2`, playerID, blockedID); err != nil {
+		return fmt.Errorf("取消屏蔽失败: %w", err)
+	}
+	return nil
+}
+
+// BlockedPlayers 查询玩家屏蔽的所有玩家
+func BlockedPlayers(playerID int64) ([]models.BlockedPlayer, error) {
+	rows, err := db.DB.Query(`
+		SELECT p.id, p.username, b.created_at
+		FROM player_blocks b
+		JOIN players p ON p.id = b.blocked_id
+		WHERE b.player_id = $1
+		ORDER BY b.created_at DESC
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询屏蔽列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	blocked := make([]models.BlockedPlayer, 0)
+	for rows.Next() {
+		var b models.BlockedPlayer
+		if err := rows.Scan(&b.PlayerID, &b.Username, &b.BlockedAt); err != nil {
+			return nil, fmt.Errorf("扫描屏蔽列表失败: %w", err)
+		}
+		blocked = append(blocked, b)
+	}
+	return blocked, rows.Err()
+}
+
+// IsBlocked 检查两名玩家之间是否存在任一方向的屏蔽
+func IsBlocked(a, b int64) (bool, error) {
+	if db.DB == nil {
+		return false, nil
+	}
+
+	var blocked bool
+	err := db.DB.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM player_blocks
+			WHERE (player_id = $1 AND blocked_id = $2) OR (player_id = $2 AND blocked_id = $1)
+		)
+	`, a, b).Scan(&blocked)
+	if err != nil {
+		return false, fmt.Errorf("查询屏蔽关系失败: %w", err)
+	}
+	return blocked, nil
+}
+
+// CheckInvite 检查邀请是否允许发送（好友、组队邀请），被屏蔽时返回 ErrBlocked
+func CheckInvite(fromID, toID int64) error {
+	blocked, err := IsBlocked(fromID, toID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// Blocked 返回该玩家屏蔽的玩家ID集合（用于过滤房间聊天记录）
+func Blocked(playerID int64) (map[int64]bool, error) {
+	return queryIDSet(`SELECT blocked_id FROM player_blocks WHERE player_id = $1`, playerID)
+}
+
+// BlockedBy 返回屏蔽了该玩家的玩家ID集合（用于过滤聊天消息接收者）
+func BlockedBy(playerID int64) (map[int64]bool, error) {
+	return queryIDSet(`SELECT player_id FROM player_blocks WHERE blocked_id = $1`, playerID)
+}
+
+// Relations 返回与该玩家存在任一方向屏蔽关系的玩家ID集合
+func Relations(playerID int64) (map[int64]bool, error) {
+	return queryIDSet(`
+		SELECT blocked_id FROM player_blocks WHERE player_id = $1
+		UNION
+		SELECT player_id FROM player_blocks WHERE blocked_id = $1
+	`, playerID)
+}
+
+// queryIDSet 查询玩家ID集合，数据库未初始化时返回空集合
+func queryIDSet(query string, playerID int64) (map[int64]bool, error) {
+	ids := make(map[int64]bool)
+	if db.DB == nil {
+		return ids, nil
+	}
+
+	rows, err := db.DB.Query(query, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询屏蔽关系失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描屏蔽关系失败: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,299 @@
+// title.go
+
+package title
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// MaxBadges 同时展示的徽章数量上限
+const MaxBadges = 3
+
+var (
+	// ErrTitleNotFound 称号不存在
+	ErrTitleNotFound = errcode.New(errcode.NotFound, "称号不存在")
+	// ErrTitleNotOwned 未获得该称号
+	ErrTitleNotOwned = errcode.New(errcode.NotOwned, "未获得该称号")
+	// ErrInvalidTitle 称号参数无效
+	ErrInvalidTitle = errcode.New(errcode.BadRequest, "无效的称号")
+)
+
+// titleColumns 称号查询字段，与 scanTitle 顺序一致
+const titleColumns = `t.id, t.code, t.name, COALESCE(t.description, ''), t.kind, t.source, t.created_at`
+
+// rowScanner 兼容 *sql.Row 和 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTitle 扫描称号，extra 为追加在称号字段之后的列
+func scanTitle(row rowScanner, extra ...interface{}) (*models.Title, error) {
+	var t models.Title
+	dest := append([]interface{}{&t.ID, &t.Code, &t.Name, &t.Description, &t.Kind, &t.Source, &t.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Catalog 查询全部称号定义
+func Catalog() ([]models.Title, error) {
+	rows, err := db.DB.Query(`SELECT ` + titleColumns + ` FROM titles t ORDER BY t.kind, t.id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询称号失败: %w", err)
+	}
+	defer rows.Close()
+
+	titles := make([]models.Title, 0)
+	for rows.Next() {
+		t, err := scanTitle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描称号失败: %w", err)
+		}
+		titles = append(titles, *t)
+	}
+	return titles, rows.Err()
+}
+
+// Create 添加称号定义
+func Create(t *models.Title) error {
+	if t.Code == "" || t.Name == "" {
+		return fmt.Errorf("%w: 标识和名称不能为空", ErrInvalidTitle)
+	}
+	if !t.Kind.IsValid() {
+		return fmt.Errorf("%w: 类型必须为 title 或 badge", ErrInvalidTitle)
+	}
+	if !t.Source.IsValid() {
+		return fmt.Errorf("%w: 无效的获取途径 %q", ErrInvalidTitle, t.Source)
+	}
+
+	return db.DB.QueryRow(`
+		INSERT INTO titles (code, name, description, kind, source)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, t.Code, t.Name, t.Description, string(t.Kind), string(t.Source)).Scan(&t.ID, &t.CreatedAt)
+}
+
+// IDByCode 按标识查询称号ID，供成就、赛事等系统发放称号
+func IDByCode(code string) (int, error) {
+	var id int
+	err := db.DB.QueryRow(`SELECT id FROM titles WHERE code = $1`, code).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrTitleNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询称号失败: %w", err)
+	}
+	return id, nil
+}
+
+// AwardTx 在调用方事务中发放称号，已拥有时不重复发放，返回是否为新获得
+func AwardTx(tx *sql.Tx, playerID int64, titleID int, sourceRef string) (bool, error) {
+	var ref interface{}
+	if sourceRef != "" {
+		ref = sourceRef
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO player_titles (player_id, title_id, source_ref)
+		SELECT $1, id, $3 FROM titles WHERE id = $2
+		ON CONFLICT DO NOTHING
+	`, playerID, titleID, ref)
+	if err != nil {
+		return false, fmt.Errorf("发放称号 %d 失败: %w", titleID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM titles WHERE id = $1)`, titleID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("查询称号失败: %w", err)
+	}
+	if !exists {
+		return false, ErrTitleNotFound
+	}
+	return false, nil
+}
+
+// Award 向多名玩家发放称号，返回新获得的人数
+func Award(titleID int, playerIDs []int64, sourceRef string) (int, error) {
+	if len(playerIDs) == 0 {
+		return 0, fmt.Errorf("%w: 必须指定玩家", ErrInvalidTitle)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	awarded := 0
+	for _, playerID := range playerIDs {
+		added, err := AwardTx(tx, playerID, titleID, sourceRef)
+		if err != nil {
+			return 0, err
+		}
+		if added {
+			awarded++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return awarded, nil
+}
+
+// PlayerTitles 查询玩家获得的全部称号及当前装备
+func PlayerTitles(playerID int64) (*models.PlayerTitles, error) {
+	rows, err := db.DB.Query(`
+		SELECT `+titleColumns+`, COALESCE(pt.source_ref, ''), pt.equipped, pt.earned_at
+		FROM player_titles pt
+		INNER JOIN titles t ON t.id = pt.title_id
+		WHERE pt.player_id = $1
+		ORDER BY pt.equipped DESC, pt.display_order, pt.earned_at DESC
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家称号失败: %w", err)
+	}
+	defer rows.Close()
+
+	result := &models.PlayerTitles{
+		Owned:  make([]models.PlayerTitle, 0),
+		Badges: make([]models.Title, 0),
+	}
+	for rows.Next() {
+		var pt models.PlayerTitle
+		t, err := scanTitle(rows, &pt.SourceRef, &pt.Equipped, &pt.EarnedAt)
+		if err != nil {
+			return nil, fmt.Errorf("扫描玩家称号失败: %w", err)
+		}
+		pt.Title = *t
+		result.Owned = append(result.Owned, pt)
+
+		if pt.Equipped {
+			if t.Kind == models.TitleKindTitle {
+				result.Title = t
+			} else {
+				result.Badges = append(result.Badges, *t)
+			}
+		}
+	}
+	return result, rows.Err()
+}
+
+// Equip 装备称号并设置展示的徽章，只能使用已获得的称号
+func Equip(playerID int64, req *models.EquipTitlesRequest) (*models.PlayerTitles, error) {
+	if len(req.BadgeIDs) > MaxBadges {
+		return nil, fmt.Errorf("%w: 最多展示 %d 个徽章", ErrInvalidTitle, MaxBadges)
+	}
+
+	owned, err := ownedKinds(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if req.TitleID != 0 {
+		if kind, ok := owned[req.TitleID]; !ok {
+			return nil, ErrTitleNotOwned
+		} else if kind != models.TitleKindTitle {
+			return nil, fmt.Errorf("%w: %d 不是称号", ErrInvalidTitle, req.TitleID)
+		}
+	}
+	seen := make(map[int]bool, len(req.BadgeIDs))
+	for _, id := range req.BadgeIDs {
+		kind, ok := owned[id]
+		if !ok {
+			return nil, ErrTitleNotOwned
+		}
+		if kind != models.TitleKindBadge || seen[id] {
+			return nil, fmt.Errorf("%w: %d 不是徽章或重复", ErrInvalidTitle, id)
+		}
+		seen[id] = true
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE player_titles SET equipped = false, display_order = 0 WHERE player_id = $1 AND equipped
+	`, playerID); err != nil {
+		return nil, fmt.Errorf("卸下称号失败: %w", err)
+	}
+	if req.TitleID != 0 {
+		if _, err := tx.Exec(`
+			UPDATE player_titles SET equipped = true WHERE player_id = $1 AND title_id = $2
+		`, playerID, req.TitleID); err != nil {
+			return nil, fmt.Errorf("装备称号失败: %w", err)
+		}
+	}
+	for i, id := range req.BadgeIDs {
+		if _, err := tx.Exec(`
+			UPDATE player_titles SET equipped = true, display_order = $3 WHERE player_id = $1 AND title_id = $2
+		`, playerID, id, i+1); err != nil {
+			return nil, fmt.Errorf("展示徽章失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return PlayerTitles(playerID)
+}
+
+// ownedKinds 查询玩家拥有的称号ID及其类型
+func ownedKinds(playerID int64) (map[int]models.TitleKind, error) {
+	rows, err := db.DB.Query(`
+		SELECT t.id, t.kind FROM player_titles pt
+		INNER JOIN titles t ON t.id = pt.title_id
+		WHERE pt.player_id = $1
+	`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家称号失败: %w", err)
+	}
+	defer rows.Close()
+
+	owned := make(map[int]models.TitleKind)
+	for rows.Next() {
+		var id int
+		var kind models.TitleKind
+		if err := rows.Scan(&id, &kind); err != nil {
+			return nil, fmt.Errorf("扫描玩家称号失败: %w", err)
+		}
+		owned[id] = kind
+	}
+	return owned, rows.Err()
+}
+
+// EquippedName 查询玩家当前装备的称号名称，未装备或数据库未初始化时返回空字符串
+func EquippedName(playerID int64) (string, error) {
+	if db.DB == nil {
+		return "", nil
+	}
+
+	var name string
+	err := db.DB.QueryRow(`
+		SELECT t.name FROM player_titles pt
+		INNER JOIN titles t ON t.id = pt.title_id
+		WHERE pt.player_id = $1 AND pt.equipped AND t.kind = $2
+	`, playerID, string(models.TitleKindTitle)).Scan(&name)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("查询玩家称号失败: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,318 @@
+// trial.go
+
+package trial
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/wallet"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// rotationEpoch 轮换周期起点，周一 00:00 UTC
+var rotationEpoch = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// rotationPeriod 轮换周期
+const rotationPeriod = 7 * 24 * time.Hour
+
+var (
+	// ErrRentalDisabled 未开放角色租用
+	ErrRentalDisabled = errcode.New(errcode.FeatureDisabled, "暂未开放角色租用")
+	// ErrInvalidRental 租用参数无效
+	ErrInvalidRental = errcode.New(errcode.BadRequest, "无效的租用请求")
+	// ErrCharacterNotFound 角色不存在
+	ErrCharacterNotFound = errcode.New(errcode.NotFound, "角色不存在")
+	// ErrNotRentable 角色无需解锁，不能租用
+	ErrNotRentable = errcode.New(errcode.NotAllowed, "该角色不能租用")
+	// ErrAlreadyOwned 已拥有该角色
+	ErrAlreadyOwned = errcode.New(errcode.AlreadyOwned, "已拥有该角色")
+)
+
+// Service 角色试玩服务，包括每周免费轮换和宝石限时租用
+type Service struct {
+	wallet *wallet.Service
+	cfg    config.TrialConfig
+}
+
+// NewService 创建角色试玩服务
+func NewService(w *wallet.Service, cfg config.TrialConfig) *Service {
+	if cfg.RentalHours <= 0 {
+		cfg.RentalHours = 24
+	}
+	return &Service{wallet: w, cfg: cfg}
+}
+
+// Rotation 查询指定时间所在周的免费轮换角色，按周序号在可解锁角色中依次轮换
+func (s *Service) Rotation(now time.Time) (*models.CharacterRotation, error) {
+	week := int(now.UTC().Sub(rotationEpoch) / rotationPeriod)
+	startsAt := rotationEpoch.Add(time.Duration(week) * rotationPeriod)
+	rotation := &models.CharacterRotation{
+		CharacterIDs: make([]int, 0, max(s.cfg.RotationSize, 0)),
+		StartsAt:     startsAt,
+		EndsAt:       startsAt.Add(rotationPeriod),
+	}
+	if s.cfg.RotationSize <= 0 {
+		return rotation, nil
+	}
+
+	ids, err := unlockableCharacters()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return rotation, nil
+	}
+
+	size := min(s.cfg.RotationSize, len(ids))
+	offset := week * size % len(ids)
+	for i := 0; i < size; i++ {
+		rotation.CharacterIDs = append(rotation.CharacterIDs, ids[(offset+i)%len(ids)])
+	}
+	return rotation, nil
+}
+
+// unlockableCharacters 按ID顺序返回可解锁角色，优先使用静态数据缓存
+func unlockableCharacters() ([]int, error) {
+	var ids []int
+	if catalog := gamedata.Current(); catalog != nil {
+		for _, char := range catalog.Characters {
+			if char.Unlockable {
+				ids = append(ids, char.ID)
+			}
+		}
+		return ids, nil
+	}
+
+	rows, err := db.DB.Query(`SELECT id FROM characters WHERE unlockable ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询可解锁角色失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描角色失败: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历角色失败: %w", err)
+	}
+	return ids, nil
+}
+
+// Active 查询玩家当前可试玩的未拥有角色，同一角色同时处于轮换和租用时取较晚的到期时间
+func (s *Service) Active(playerID int64) ([]models.CharacterTrial, error) {
+	now := time.Now()
+	rotation, err := s.Rotation(now)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT character_id, MAX(expires_at) FROM character_trials
+		WHERE player_id = $1 AND expires_at > $2
+		GROUP BY character_id
+	`, playerID, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询租用角色失败: %w", err)
+	}
+	defer rows.Close()
+
+	rentals := make(map[int]time.Time)
+	for rows.Next() {
+		var characterID int
+		var expiresAt time.Time
+		if err := rows.Scan(&characterID, &expiresAt); err != nil {
+			return nil, fmt.Errorf("扫描租用角色失败: %w", err)
+		}
+		rentals[characterID] = expiresAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历租用角色失败: %w", err)
+	}
+	rows.Close()
+
+	owned, err := ownedCharacters(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	trials := make([]models.CharacterTrial, 0, len(rentals)+len(rotation.CharacterIDs))
+	for _, id := range rotation.CharacterIDs {
+		if owned[id] {
+			continue
+		}
+		trial := models.CharacterTrial{CharacterID: id, Source: models.TrialRotation, ExpiresAt: rotation.EndsAt}
+		if expiresAt, ok := rentals[id]; ok && expiresAt.After(rotation.EndsAt) {
+			trial.Source = models.TrialRental
+			trial.ExpiresAt = expiresAt
+		}
+		delete(rentals, id)
+		trials = append(trials, trial)
+	}
+	for id, expiresAt := range rentals {
+		if owned[id] {
+			continue
+		}
+		trials = append(trials, models.CharacterTrial{CharacterID: id, Source: models.TrialRental, ExpiresAt: expiresAt})
+	}
+	sort.Slice(trials, func(i, j int) bool { return trials[i].CharacterID < trials[j].CharacterID })
+	return trials, nil
+}
+
+// CanUse 检查玩家是否可以使用角色（已拥有、租用中或本周免费），数据库未初始化时不做检查
+func (s *Service) CanUse(playerID int64, characterID int) (bool, error) {
+	if db.DB == nil {
+		return true, nil
+	}
+
+	var usable bool
+	err := db.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM player_characters WHERE player_id = $1 AND character_id = $2)
+		    OR EXISTS(SELECT 1 FROM character_trials
+		              WHERE player_id = $1 AND character_id = $2 AND expires_at > CURRENT_TIMESTAMP)
+	`, playerID, characterID).Scan(&usable)
+	if err != nil {
+		return false, fmt.Errorf("查询玩家角色失败: %w", err)
+	}
+	if usable {
+		return true, nil
+	}
+
+	rotation, err := s.Rotation(time.Now())
+	if err != nil {
+		return false, err
+	}
+	for _, id := range rotation.CharacterIDs {
+		if id == characterID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Rent 花费宝石租用角色，已租用时在原到期时间上顺延
+func (s *Service) Rent(playerID int64, req *models.RentCharacterRequest) (*models.CharacterTrial, error) {
+	if s.cfg.RentalGemCost <= 0 {
+		return nil, ErrRentalDisabled
+	}
+	if req.RequestID == "" || len(req.RequestID) > 64 {
+		return nil, fmt.Errorf("%w: 请求ID不能为空且不超过64个字符", ErrInvalidRental)
+	}
+	if req.CharacterID <= 0 {
+		return nil, fmt.Errorf("%w: 无效的角色ID", ErrInvalidRental)
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 锁定玩家，串行化同一玩家的租用
+	var exists bool
+	err = tx.QueryRow(`SELECT true FROM players WHERE id = $1 FOR UPDATE`, playerID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, wallet.ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("锁定玩家失败: %w", err)
+	}
+
+	// 重复请求直接返回已有租用
+	key := fmt.Sprintf("rental:%d:%s", playerID, req.RequestID)
+	trial := &models.CharacterTrial{Source: models.TrialRental}
+	err = tx.QueryRow(`SELECT character_id, expires_at FROM character_trials WHERE idempotency_key = $1`,
+		key).Scan(&trial.CharacterID, &trial.ExpiresAt)
+	if err == nil {
+		return trial, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("查询租用记录失败: %w", err)
+	}
+
+	var unlockable, owned bool
+	err = tx.QueryRow(`
+		SELECT c.unlockable, EXISTS(SELECT 1 FROM player_characters WHERE player_id = $1 AND character_id = c.id)
+		FROM characters c WHERE c.id = $2
+	`, playerID, req.CharacterID).Scan(&unlockable, &owned)
+	if err == sql.ErrNoRows {
+		return nil, ErrCharacterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	if !unlockable {
+		return nil, ErrNotRentable
+	}
+	if owned {
+		return nil, ErrAlreadyOwned
+	}
+
+	// 未到期的租用从原到期时间开始顺延
+	startsAt := time.Now()
+	var latest sql.NullTime
+	err = tx.QueryRow(`SELECT MAX(expires_at) FROM character_trials WHERE player_id = $1 AND character_id = $2`,
+		playerID, req.CharacterID).Scan(&latest)
+	if err != nil {
+		return nil, fmt.Errorf("查询租用记录失败: %w", err)
+	}
+	if latest.Valid && latest.Time.After(startsAt) {
+		startsAt = latest.Time
+	}
+	trial.CharacterID = req.CharacterID
+	trial.ExpiresAt = startsAt.Add(time.Duration(s.cfg.RentalHours) * time.Hour)
+
+	_, err = s.wallet.ApplyTx(tx, models.TransactionRequest{
+		PlayerID:       playerID,
+		Currency:       models.CurrencyGems,
+		Amount:         -s.cfg.RentalGemCost,
+		Reason:         models.ReasonCharacterRental,
+		ReferenceID:    fmt.Sprintf("character:%d", req.CharacterID),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO character_trials (player_id, character_id, source, starts_at, expires_at, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, playerID, req.CharacterID, string(models.TrialRental), startsAt, trial.ExpiresAt, key)
+	if err != nil {
+		return nil, fmt.Errorf("记录租用失败: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return trial, nil
+}
+
+// ownedCharacters 查询玩家已拥有的角色
+func ownedCharacters(playerID int64) (map[int]bool, error) {
+	rows, err := db.DB.Query(`SELECT character_id FROM player_characters WHERE player_id = $1`, playerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询玩家角色失败: %w", err)
+	}
+	defer rows.Close()
+
+	owned := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("扫描玩家角色失败: %w", err)
+		}
+		owned[id] = true
+	}
+	return owned, rows.Err()
+}
@@ -0,0 +1,194 @@
+// wallet.go
+
+package wallet
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+var (
+	// ErrInsufficientFunds 余额不足
+	ErrInsufficientFunds = errcode.New(errcode.InsufficientFunds, "余额不足")
+	// ErrPlayerNotFound 玩家不存在
+	ErrPlayerNotFound = errcode.New(errcode.NotFound, "玩家不存在")
+	// ErrIdempotencyConflict 幂等键已被其他玩家或不同参数的交易使用
+	ErrIdempotencyConflict = errcode.New(errcode.IdempotencyConflict, "幂等键冲突")
+	// ErrInvalidTransaction 交易参数无效
+	ErrInvalidTransaction = errcode.New(errcode.BadRequest, "无效的交易")
+)
+
+// Service 钱包服务，所有金币/宝石变动都通过它记录流水
+type Service struct{}
+
+// NewService 创建钱包服务
+func NewService() *Service {
+	return &Service{}
+}
+
+// validate 校验交易请求
+func validate(req *models.TransactionRequest) error {
+	if !req.Currency.IsValid() {
+		return fmt.Errorf("%w: 无效的货币类型 %q", ErrInvalidTransaction, req.Currency)
+	}
+	if req.Amount == 0 {
+		return fmt.Errorf("%w: 交易金额不能为0", ErrInvalidTransaction)
+	}
+	if req.Reason == "" {
+		return fmt.Errorf("%w: 交易原因不能为空", ErrInvalidTransaction)
+	}
+	if req.IdempotencyKey == "" {
+		return fmt.Errorf("%w: 幂等键不能为空", ErrInvalidTransaction)
+	}
+	return nil
+}
+
+// Apply 在独立事务中执行一笔货币变动
+func (s *Service) Apply(req models.TransactionRequest) (*models.CurrencyTransaction, error) {
+	if db.DB == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("开启事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	txn, err := s.ApplyTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return txn, nil
+}
+
+// ApplyTx 在调用方事务中执行一笔货币变动，便于与发放道具等操作保持原子性。
+// 相同幂等键的请求重复提交时直接返回已有流水，不会重复扣款或入账。
+func (s *Service) ApplyTx(tx *sql.Tx, req models.TransactionRequest) (*models.CurrencyTransaction, error) {
+	if err := validate(&req); err != nil {
+		return nil, err
+	}
+
+	// 锁定玩家行，串行化同一玩家的余额变动
+	column := string(req.Currency)
+	var balance int64
+	err := tx.QueryRow(`SELECT `+column+` FROM players WHERE id = $1 FOR UPDATE`, req.PlayerID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return nil, ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询余额失败: %w", err)
+	}
+
+	// 幂等检查
+	existing, err := getTransactionByKey(tx, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.PlayerID != req.PlayerID || existing.Currency != req.Currency || existing.Amount != req.Amount {
+			return nil, ErrIdempotencyConflict
+		}
+		return existing, nil
+	}
+
+	newBalance := balance + req.Amount
+	if newBalance < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	if _, err := tx.Exec(`UPDATE players SET `+column+` = $2, updated_at = NOW() WHERE id = $1`, req.PlayerID, newBalance); err != nil {
+		return nil, fmt.Errorf("更新余额失败: %w", err)
+	}
+
+	txn := &models.CurrencyTransaction{
+		PlayerID:       req.PlayerID,
+		Currency:       req.Currency,
+		Amount:         req.Amount,
+		BalanceAfter:   newBalance,
+		Reason:         req.Reason,
+		ReferenceID:    req.ReferenceID,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO currency_transactions (player_id, currency, amount, balance_after, reason,
+		                                   reference_id, idempotency_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, txn.PlayerID, string(txn.Currency), txn.Amount, txn.BalanceAfter, string(txn.Reason),
+		txn.ReferenceID, txn.IdempotencyKey).Scan(&txn.ID, &txn.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("写入交易流水失败: %w", err)
+	}
+
+	return txn, nil
+}
+
+// TransactionByKeyTx 在调用方事务中按幂等键查询流水，不存在时返回nil
+func TransactionByKeyTx(tx *sql.Tx, key string) (*models.CurrencyTransaction, error) {
+	return getTransactionByKey(tx, key)
+}
+
+// getTransactionByKey 按幂等键查询流水，不存在时返回nil
+func getTransactionByKey(tx *sql.Tx, key string) (*models.CurrencyTransaction, error) {
+	var txn models.CurrencyTransaction
+	err := tx.QueryRow(`
+		SELECT id, player_id, currency, amount, balance_after, reason,
+		       COALESCE(reference_id, ''), idempotency_key, created_at
+		FROM currency_transactions
+		WHERE idempotency_key = $1
+	`, key).Scan(&txn.ID, &txn.PlayerID, &txn.Currency, &txn.Amount, &txn.BalanceAfter,
+		&txn.Reason, &txn.ReferenceID, &txn.IdempotencyKey, &txn.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询交易流水失败: %w", err)
+	}
+	return &txn, nil
+}
+
+// Transactions 分页查询玩家交易流水（按时间倒序），currency为空时返回全部货币
+func (s *Service) Transactions(playerID int64, currency models.Currency, limit, offset int) ([]models.CurrencyTransaction, int, error) {
+	var total int
+	err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM currency_transactions
+		WHERE player_id = $1 AND ($2 = '' OR currency = $2)
+	`, playerID, string(currency)).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询交易总数失败: %w", err)
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT id, player_id, currency, amount, balance_after, reason,
+		       COALESCE(reference_id, ''), idempotency_key, created_at
+		FROM currency_transactions
+		WHERE player_id = $1 AND ($2 = '' OR currency = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`, playerID, string(currency), limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询交易流水失败: %w", err)
+	}
+	defer rows.Close()
+
+	transactions := make([]models.CurrencyTransaction, 0)
+	for rows.Next() {
+		var txn models.CurrencyTransaction
+		if err := rows.Scan(&txn.ID, &txn.PlayerID, &txn.Currency, &txn.Amount, &txn.BalanceAfter,
+			&txn.Reason, &txn.ReferenceID, &txn.IdempotencyKey, &txn.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("扫描交易流水失败: %w", err)
+		}
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, total, rows.Err()
+}
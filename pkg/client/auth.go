@@ -0,0 +1,99 @@
+// auth.go
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Session 登录会话
+type Session struct {
+	Token    string `json:"token"`
+	PlayerID int64  `json:"player_id"`
+	Username string `json:"username"`
+
+	ExpiresAt time.Time `json:"expires_at"` // 会话过期时间，开启续期时每次有效请求都会向后推迟
+	ExpiresIn int64     `json:"expires_in"` // 响应时会话的剩余秒数
+}
+
+// authResponse 认证接口的响应，会话信息位于顶层而不是 data 中
+type authResponse struct {
+	envelope
+	Session
+}
+
+// Login 登录，成功后后续请求自动携带会话令牌
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	return c.authenticate(ctx, "/auth/login", map[string]string{
+		"username": username,
+		"password": password,
+	})
+}
+
+// Register 注册新账号，成功后自动登录
+func (c *Client) Register(ctx context.Context, username, password, email string) (*Session, error) {
+	return c.authenticate(ctx, "/auth/register", map[string]string{
+		"username": username,
+		"password": password,
+		"email":    email,
+	})
+}
+
+// authenticate 调用登录或注册接口并保存会话
+func (c *Client) authenticate(ctx context.Context, path string, body interface{}) (*Session, error) {
+	resp, err := c.authRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	c.SetToken(resp.Token, resp.PlayerID)
+	return &resp.Session, nil
+}
+
+// Validate 校验当前会话令牌，返回会话对应的玩家
+func (c *Client) Validate(ctx context.Context) (*Session, error) {
+	resp, err := c.authRequest(ctx, http.MethodGet, "/auth/validate", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Token = c.Token()
+	return &resp.Session, nil
+}
+
+// Refresh 续期当前会话，返回新的过期时间。会话达到服务端设置的最长时间后无法续期，需重新登录
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	resp, err := c.authRequest(ctx, http.MethodPost, "/auth/refresh", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Token = c.Token()
+	return &resp.Session, nil
+}
+
+// Logout 登出并清除本地会话
+func (c *Client) Logout(ctx context.Context) error {
+	if _, err := c.authRequest(ctx, http.MethodPost, "/auth/logout", nil); err != nil {
+		return err
+	}
+	c.SetToken("", 0)
+	return nil
+}
+
+// authRequest 发送认证请求并解析响应
+func (c *Client) authRequest(ctx context.Context, method, path string, body interface{}) (*authResponse, error) {
+	raw, err := c.send(ctx, method, path, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp authResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !resp.Success {
+		return nil, envelopeError(resp.envelope)
+	}
+	return &resp, nil
+}
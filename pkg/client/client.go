@@ -0,0 +1,206 @@
+// client.go
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// defaultTimeout 默认的 HTTP 请求超时时间
+const defaultTimeout = 10 * time.Second
+
+// Client 网关 HTTP API 客户端，登录后自动携带会话令牌，可被多个协程共用
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	sessionID  string // 加入匹配队列时标识本客户端
+
+	mu       sync.RWMutex
+	token    string
+	playerID int64
+	language string
+}
+
+// New 创建网关客户端，baseURL 形如 http://localhost:8080
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		sessionID:  uuid.New().String(),
+	}
+}
+
+// SetHTTPClient 替换底层 HTTP 客户端
+func (c *Client) SetHTTPClient(httpClient *http.Client) {
+	c.httpClient = httpClient
+}
+
+// SetToken 使用已有的会话令牌，不经过登录
+func (c *Client) SetToken(token string, playerID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+	c.playerID = playerID
+}
+
+// SetLanguage 设置提示信息的语言，如 en、zh-CN，作为 Accept-Language 请求头发送
+func (c *Client) SetLanguage(language string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.language = language
+}
+
+// Language 提示信息的语言，未设置时为空，由服务端使用默认语言
+func (c *Client) Language() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.language
+}
+
+// Token 当前会话令牌，未登录时为空
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// PlayerID 当前登录的玩家ID，未登录时为0
+func (c *Client) PlayerID() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.playerID
+}
+
+// APIError 网关返回的错误响应，可通过 errcode.Of 取得错误码
+type APIError struct {
+	StatusCode int
+	Code       errcode.Code
+	Message    string
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+// Unwrap 支持 errcode.Of 取得错误码
+func (e *APIError) Unwrap() error {
+	return &errcode.Error{Code: e.Code, Message: e.Message}
+}
+
+// envelope 网关的通用响应结构
+type envelope struct {
+	Success bool            `json:"success"`
+	Code    errcode.Code    `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// do 发送请求并解析通用响应结构，响应失败时返回 *APIError，成功时把 data 解析到 out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	raw, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !env.Success {
+		return envelopeError(env)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return fmt.Errorf("解析响应数据失败: %w", err)
+	}
+	return nil
+}
+
+// send 发送请求并返回响应体，非 2xx 响应转换为 *APIError
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("序列化请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if language := c.Language(); language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newAPIError(resp.StatusCode, raw)
+	}
+	return raw, nil
+}
+
+// envelopeError 状态码为 200 但 success 为 false 的响应
+func envelopeError(env envelope) *APIError {
+	code := env.Code
+	if code == "" {
+		code = errcode.BadRequest
+	}
+	return &APIError{StatusCode: http.StatusOK, Code: code, Message: env.Message}
+}
+
+// newAPIError 解析错误响应，部分服务以纯文本返回错误，此时按状态码取通用错误码
+func newAPIError(statusCode int, raw []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode}
+	var env envelope
+	if json.Unmarshal(raw, &env) == nil {
+		apiErr.Code = env.Code
+		apiErr.Message = env.Message
+	} else {
+		apiErr.Message = strings.TrimSpace(string(raw))
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = errcode.FromStatus(statusCode)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,639 @@
+// game.go
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/protocol"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/codec"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// 握手等待 welcome 的超时时间
+	handshakeTimeout = 10 * time.Second
+
+	// 读取超时时间，服务端定期发送 ping，收到 ping 或消息时延长
+	readTimeout = 60 * time.Second
+
+	// 写入超时时间
+	writeTimeout = 10 * time.Second
+
+	// 默认的重连等待时间
+	defaultReconnectDelay    = time.Second
+	defaultMaxReconnectDelay = 30 * time.Second
+)
+
+// 消息编码，与服务端 welcome 消息中的 encoding 一致
+const (
+	encodingJSON    = "json"
+	encodingMsgpack = "msgpack"
+)
+
+// 消息种类
+const (
+	KindRealtime = "realtime"
+	KindLobby    = "lobby"
+	KindReliable = "reliable"
+)
+
+var (
+	// ErrNotConnected 游戏连接已断开，正在重连或已关闭
+	ErrNotConnected = errors.New("游戏连接已断开")
+	// ErrClosed 游戏客户端已关闭
+	ErrClosed = errors.New("游戏客户端已关闭")
+)
+
+// GameConfig 游戏连接配置
+type GameConfig struct {
+	URL           string   // 游戏服务器 WebSocket 地址，形如 ws://localhost:8081/ws
+	PlayerID      int64    // 玩家ID
+	Token         string   // 会话令牌
+	Capabilities  []string // 请求的协议能力，如 protocol.CapabilityMsgpack，为空时所有消息使用 JSON
+	ClientVersion string   // 客户端版本号，仅用于服务端日志
+	Locale        string   // 错误和公告的语言，如 en、zh-CN，为空时使用服务端默认语言
+
+	ReconnectDelay       time.Duration // 首次重连前的等待时间，之后每次翻倍
+	MaxReconnectDelay    time.Duration // 重连等待时间上限
+	MaxReconnectAttempts int           // 连续重连失败的最大次数，0 表示不限
+}
+
+// GameConfig 按当前登录的会话生成游戏连接配置
+func (c *Client) GameConfig(wsURL string) GameConfig {
+	return GameConfig{
+		URL:      wsURL,
+		PlayerID: c.PlayerID(),
+		Token:    c.Token(),
+		Locale:   c.Language(),
+	}
+}
+
+// Message 游戏消息信封
+type Message struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	Seq     uint64          `json:"seq,omitempty"`
+	Ack     uint64          `json:"ack,omitempty"`
+	Kind    string          `json:"kind,omitempty"`
+}
+
+// Decode 把消息载荷解析到 v
+func (m *Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// GameFrame 解析 game_frame 消息的载荷
+func (m *Message) GameFrame() (*protocol.GameFrame, error) {
+	var frame protocol.GameFrame
+	if err := protojson.Unmarshal(m.Payload, &frame); err != nil {
+		return nil, err
+	}
+	return &frame, nil
+}
+
+// ErrorPayload 服务端的错误消息
+type ErrorPayload struct {
+	Code    errcode.Code `json:"code"`
+	Message string       `json:"message"`
+	Type    string       `json:"type,omitempty"`
+	Seq     uint64       `json:"seq,omitempty"`
+}
+
+// Welcome 握手结果
+type Welcome struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+	Encoding        string   `json:"encoding"`
+	Resumed         bool     `json:"resumed"`
+}
+
+// heartbeat 服务端应用层心跳载荷
+type heartbeat struct {
+	ID  uint64 `json:"id"`
+	RTT int64  `json:"rtt"` // 服务端测得的平滑往返延迟(毫秒)
+}
+
+// hello 握手消息载荷
+type hello struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Capabilities    []string `json:"capabilities"`
+	ClientVersion   string   `json:"client_version,omitempty"`
+	Locale          string   `json:"locale,omitempty"`
+	Resume          bool     `json:"resume,omitempty"`
+}
+
+// upgradeRequired 协议版本过低的通知载荷
+type upgradeRequired struct {
+	Code    errcode.Code `json:"code"`
+	Message string       `json:"message"`
+}
+
+// Handler 消息处理函数，在接收协程中按收到的顺序依次调用，处理函数中不能调用 Close
+type Handler func(msg *Message)
+
+// GameClient 游戏服务器 WebSocket 客户端。连接后完成协议握手，按消息类型分发收到的消息，
+// 自动确认关键消息，断线后按退避间隔重连并恢复消息会话，重发的关键消息按序号去重
+type GameClient struct {
+	cfg    GameConfig
+	dialer *websocket.Dialer
+
+	handlersMu  sync.RWMutex
+	handlers    map[string][]Handler
+	onReconnect []func(Welcome)
+
+	// 写锁保护连接、编码和客户端消息序号
+	writeMu   sync.Mutex
+	conn      *websocket.Conn
+	encoding  string
+	clientSeq uint64
+	welcome   Welcome
+	running   bool // 接收协程已启动
+
+	// 已收到的服务端消息最大序号
+	serverSeq atomic.Uint64
+
+	// 服务端心跳中报告的往返延迟(毫秒)
+	rtt atomic.Int64
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// NewGameClient 创建游戏客户端
+func NewGameClient(cfg GameConfig) *GameClient {
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = defaultReconnectDelay
+	}
+	if cfg.MaxReconnectDelay < cfg.ReconnectDelay {
+		cfg.MaxReconnectDelay = max(defaultMaxReconnectDelay, cfg.ReconnectDelay)
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.EnableCompression = true
+
+	return &GameClient{
+		cfg:      cfg,
+		dialer:   &dialer,
+		handlers: make(map[string][]Handler),
+		encoding: encodingJSON,
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// On 注册指定类型消息的处理函数，应在 Connect 之前注册
+func (g *GameClient) On(msgType string, handler Handler) {
+	g.handlersMu.Lock()
+	defer g.handlersMu.Unlock()
+	g.handlers[msgType] = append(g.handlers[msgType], handler)
+}
+
+// OnAnnouncement 注册全服公告的处理函数，大厅和对局内都会收到。
+// 公告带有维护时间时，Countdown 为服务端发送时距维护开始的秒数
+func (g *GameClient) OnAnnouncement(fn func(models.Announcement)) {
+	g.On("announcement", func(msg *Message) {
+		var a models.Announcement
+		if err := msg.Decode(&a); err != nil {
+			log.Printf("解析全服公告失败: %v", err)
+			return
+		}
+		fn(a)
+	})
+}
+
+// OnReconnect 注册重连成功后的回调，Welcome.Resumed 为 false 时之前的会话已失效，应重新同步状态
+func (g *GameClient) OnReconnect(fn func(Welcome)) {
+	g.handlersMu.Lock()
+	defer g.handlersMu.Unlock()
+	g.onReconnect = append(g.onReconnect, fn)
+}
+
+// Connect 连接游戏服务器并完成握手，之后在后台接收消息，断线时自动重连
+func (g *GameClient) Connect(ctx context.Context) error {
+	conn, pending, err := g.dial(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	if g.isClosed() {
+		conn.Close()
+		return ErrClosed
+	}
+	g.running = true
+	go g.run(conn, pending)
+	return nil
+}
+
+// Welcome 最近一次握手的结果
+func (g *GameClient) Welcome() Welcome {
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	return g.welcome
+}
+
+// Send 发送消息，连接断开期间返回 ErrNotConnected
+func (g *GameClient) Send(msgType string, payload interface{}) error {
+	var raw json.RawMessage
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("序列化 %s 消息失败: %w", msgType, err)
+		}
+		raw = data
+	}
+
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+
+	if g.conn == nil {
+		return ErrNotConnected
+	}
+	g.clientSeq++
+	msg := Message{Type: msgType, Payload: raw, Seq: g.clientSeq, Ack: g.serverSeq.Load()}
+	return g.writeLocked(g.conn, msg)
+}
+
+// RTT 服务端通过应用层心跳测得的往返延迟，尚未测得时为0
+func (g *GameClient) RTT() time.Duration {
+	return time.Duration(g.rtt.Load()) * time.Millisecond
+}
+
+// Done 客户端关闭或重连失败后关闭的通道
+func (g *GameClient) Done() <-chan struct{} {
+	return g.done
+}
+
+// Err 等待客户端停止并返回原因，主动关闭时为 nil
+func (g *GameClient) Err() error {
+	<-g.done
+	return g.err
+}
+
+// Close 关闭连接并停止重连
+func (g *GameClient) Close() error {
+	g.closeOnce.Do(func() {
+		close(g.closed)
+
+		g.writeMu.Lock()
+		if g.conn != nil {
+			g.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+			g.conn.Close()
+		}
+		running := g.running
+		g.writeMu.Unlock()
+
+		// 未连接成功时没有接收协程负责关闭 done
+		if !running {
+			close(g.done)
+		}
+	})
+	<-g.done
+	return nil
+}
+
+// run 接收消息，断线后重连，直到客户端关闭或重连失败
+func (g *GameClient) run(conn *websocket.Conn, pending []*Message) {
+	defer close(g.done)
+
+	for {
+		for _, msg := range pending {
+			g.dispatch(msg)
+		}
+		err := g.readLoop(conn)
+
+		g.writeMu.Lock()
+		g.conn = nil
+		g.writeMu.Unlock()
+		conn.Close()
+
+		if g.isClosed() {
+			return
+		}
+		log.Printf("游戏连接断开，准备重连: %v", err)
+
+		if conn, pending, err = g.reconnect(); err != nil {
+			g.err = err
+			return
+		}
+		if conn == nil {
+			return
+		}
+		g.handlersMu.RLock()
+		callbacks := g.onReconnect
+		g.handlersMu.RUnlock()
+		for _, fn := range callbacks {
+			fn(g.Welcome())
+		}
+	}
+}
+
+// reconnect 按退避间隔重连并请求恢复会话，客户端关闭时返回 nil 连接
+func (g *GameClient) reconnect() (*websocket.Conn, []*Message, error) {
+	delay := g.cfg.ReconnectDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-g.closed:
+			return nil, nil, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+		conn, pending, err := g.dial(ctx, true)
+		cancel()
+		if err == nil {
+			return conn, pending, nil
+		}
+		if g.isClosed() {
+			return nil, nil, nil
+		}
+		// 协议版本过低时重连没有意义
+		if errcode.Of(err) == errcode.UpgradeRequired {
+			return nil, nil, err
+		}
+		if g.cfg.MaxReconnectAttempts > 0 && attempt >= g.cfg.MaxReconnectAttempts {
+			return nil, nil, fmt.Errorf("重连 %d 次失败: %w", attempt, err)
+		}
+		log.Printf("游戏连接重连失败 (第 %d 次): %v", attempt, err)
+		delay = min(delay*2, g.cfg.MaxReconnectDelay)
+	}
+}
+
+// dial 建立连接并完成握手，返回握手期间收到的其他消息
+func (g *GameClient) dial(ctx context.Context, resume bool) (*websocket.Conn, []*Message, error) {
+	u, err := url.Parse(g.cfg.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的游戏服务器地址: %w", err)
+	}
+	query := u.Query()
+	query.Set("player_id", strconv.FormatInt(g.cfg.PlayerID, 10))
+	query.Set("token", g.cfg.Token)
+	u.RawQuery = query.Encode()
+
+	conn, resp, err := g.dialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		if resp != nil {
+			return nil, nil, fmt.Errorf("连接游戏服务器失败 (%s): %w", resp.Status, err)
+		}
+		return nil, nil, fmt.Errorf("连接游戏服务器失败: %w", err)
+	}
+
+	pending, err := g.handshake(ctx, conn, resume)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, pending, nil
+}
+
+// handshake 发送 hello 并等待 welcome。握手消息不编号，会话未恢复时双方序号从头开始
+func (g *GameClient) handshake(ctx context.Context, conn *websocket.Conn, resume bool) ([]*Message, error) {
+	payload, err := json.Marshal(hello{
+		ProtocolVersion: protocol.ProtocolVersion,
+		Capabilities:    append([]string{}, g.cfg.Capabilities...),
+		ClientVersion:   g.cfg.ClientVersion,
+		Locale:          g.cfg.Locale,
+		Resume:          resume,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 新连接在握手完成前使用 JSON 编码
+	g.writeMu.Lock()
+	g.encoding = encodingJSON
+	err = g.writeLocked(conn, Message{Type: "hello", Payload: payload, Ack: g.serverSeq.Load()})
+	g.writeMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(handshakeTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	var pending []*Message
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("握手失败: %w", err)
+		}
+		msgs, err := g.decode(messageType, data)
+		if err != nil {
+			return nil, fmt.Errorf("握手失败: %w", err)
+		}
+
+		for i, msg := range msgs {
+			switch msg.Type {
+			case "welcome":
+				var welcome Welcome
+				if err := msg.Decode(&welcome); err != nil {
+					return nil, fmt.Errorf("解析 welcome 消息失败: %w", err)
+				}
+				g.writeMu.Lock()
+				g.conn = conn
+				g.welcome = welcome
+				g.encoding = welcome.Encoding
+				if !welcome.Resumed {
+					g.clientSeq = 0
+					g.serverSeq.Store(0)
+				}
+				g.writeMu.Unlock()
+
+				// welcome 的序号大于随后重发的关键消息，不计入已收到的最大序号以免重发的消息被当作重复消息
+				return append(pending, msgs[i+1:]...), nil
+			case "upgrade_required":
+				var upgrade upgradeRequired
+				msg.Decode(&upgrade)
+				return nil, errcode.New(errcode.UpgradeRequired, upgrade.Message)
+			case "error":
+				var e ErrorPayload
+				msg.Decode(&e)
+				return nil, errcode.New(e.Code, e.Message)
+			default:
+				pending = append(pending, msg)
+			}
+		}
+	}
+}
+
+// readLoop 接收并分发消息，直到连接断开
+func (g *GameClient) readLoop(conn *websocket.Conn) error {
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeTimeout))
+	})
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		msgs, err := g.decode(messageType, data)
+		if err != nil {
+			log.Printf("解析游戏消息失败: %v", err)
+			continue
+		}
+		for _, msg := range msgs {
+			g.dispatch(msg)
+		}
+	}
+}
+
+// dispatch 记录消息序号并调用处理函数，重复的消息直接丢弃
+func (g *GameClient) dispatch(msg *Message) {
+	if !g.receive(msg) {
+		return
+	}
+	if msg.Type == "heartbeat" {
+		g.replyHeartbeat(msg)
+	}
+
+	g.handlersMu.RLock()
+	handlers := g.handlers[msg.Type]
+	g.handlersMu.RUnlock()
+	for _, handler := range handlers {
+		handler(msg)
+	}
+}
+
+// receive 记录服务端消息序号并确认关键消息，返回 false 表示已收到过的重复消息
+func (g *GameClient) receive(msg *Message) bool {
+	if msg.Seq == 0 {
+		return true
+	}
+	if msg.Seq <= g.serverSeq.Load() {
+		return false
+	}
+	g.serverSeq.Store(msg.Seq)
+
+	if msg.Kind == KindReliable {
+		g.writeMu.Lock()
+		if g.conn != nil {
+			if err := g.writeLocked(g.conn, Message{Type: "ack", Ack: msg.Seq}); err != nil {
+				log.Printf("确认消息 %d 失败: %v", msg.Seq, err)
+			}
+		}
+		g.writeMu.Unlock()
+	}
+	return true
+}
+
+// replyHeartbeat 立即回复服务端心跳，并记录服务端报告的往返延迟
+func (g *GameClient) replyHeartbeat(msg *Message) {
+	var hb heartbeat
+	if err := msg.Decode(&hb); err != nil {
+		log.Printf("解析心跳消息失败: %v", err)
+		return
+	}
+	if hb.RTT > 0 {
+		g.rtt.Store(hb.RTT)
+	}
+
+	data, _ := json.Marshal(map[string]uint64{"id": hb.ID})
+	g.writeMu.Lock()
+	defer g.writeMu.Unlock()
+	if g.conn != nil {
+		if err := g.writeLocked(g.conn, Message{Type: "pong", Payload: data}); err != nil {
+			log.Printf("回复心跳失败: %v", err)
+		}
+	}
+}
+
+// decode 解析一帧数据。文本帧可能包含多条以换行分隔的 JSON 消息，
+// 二进制帧为一条 MessagePack 消息或 protobuf 编码的实时消息
+func (g *GameClient) decode(messageType int, data []byte) ([]*Message, error) {
+	if messageType == websocket.BinaryMessage {
+		g.writeMu.Lock()
+		encoding := g.encoding
+		g.writeMu.Unlock()
+
+		if encoding != encodingMsgpack {
+			return decodeServerMessage(data)
+		}
+		var err error
+		if data, err = codec.MsgpackToJSON(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var msgs []*Message
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, &msg)
+	}
+	return msgs, nil
+}
+
+// decodeServerMessage 把 protobuf 编码的实时消息转换为与 JSON 连接相同的消息
+func decodeServerMessage(data []byte) ([]*Message, error) {
+	var serverMsg protocol.ServerMessage
+	if err := proto.Unmarshal(data, &serverMsg); err != nil {
+		return nil, err
+	}
+	frame := serverMsg.GetGameFrame()
+	if frame == nil {
+		return nil, errors.New("未知的 protobuf 消息")
+	}
+	payload, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(frame)
+	if err != nil {
+		return nil, err
+	}
+	return []*Message{{Type: "game_frame", Payload: payload, Kind: KindRealtime}}, nil
+}
+
+// writeLocked 按当前编码写出消息，调用方需持有写锁
+func (g *GameClient) writeLocked(conn *websocket.Conn, msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	messageType := websocket.TextMessage
+	if g.encoding == encodingMsgpack {
+		if data, err = codec.JSONToMsgpack(data); err != nil {
+			return err
+		}
+		messageType = websocket.BinaryMessage
+	}
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteMessage(messageType, data)
+}
+
+// isClosed 客户端是否已关闭
+func (g *GameClient) isClosed() bool {
+	select {
+	case <-g.closed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,48 @@
+// match.go
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// JoinQueue 以当前登录的玩家加入指定模式的匹配队列，匹配成功后通过游戏连接的 match_found 消息通知
+func (c *Client) JoinQueue(ctx context.Context, mode models.GameMode, characterID int) error {
+	return c.do(ctx, http.MethodPost, "/match/join", nil, map[string]interface{}{
+		"player_id":    c.PlayerID(),
+		"character_id": characterID,
+		"game_mode":    mode,
+		"session_id":   c.sessionID,
+	}, nil)
+}
+
+// LeaveQueue 离开指定模式的匹配队列，玩家不在队列中时返回错误
+func (c *Client) LeaveQueue(ctx context.Context, mode models.GameMode) error {
+	query := url.Values{
+		"player_id": {strconv.FormatInt(c.PlayerID(), 10)},
+		"game_mode": {string(mode)},
+	}
+	return c.do(ctx, http.MethodPost, "/match/leave", query, nil, nil)
+}
+
+// QueueStatus 查询各模式匹配队列的人数
+func (c *Client) QueueStatus(ctx context.Context) (map[models.GameMode]int, error) {
+	raw, err := c.send(ctx, http.MethodGet, "/match/status", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Queues map[models.GameMode]int `json:"queues"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	return resp.Queues, nil
+}
@@ -0,0 +1,87 @@
+// notification.go
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// Notifications 订阅通知流（组队邀请、新邮件、匹配成功等），无需维持游戏连接。
+// 通道在 ctx 取消或连接断开后关闭，需要持续接收时由调用方重新订阅
+func (c *Client) Notifications(ctx context.Context) (<-chan *models.PushNotification, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/notifications/stream", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if language := c.Language(); language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+
+	// 通知流是长连接，不使用请求超时
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
+		return nil, newAPIError(resp.StatusCode, raw)
+	}
+
+	ch := make(chan *models.PushNotification)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		readEvents(resp.Body, func(data string) bool {
+			var n models.PushNotification
+			if err := json.Unmarshal([]byte(data), &n); err != nil {
+				log.Printf("解析通知失败: %v", err)
+				return true
+			}
+			select {
+			case ch <- &n:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch, nil
+}
+
+// readEvents 逐条读取 SSE 事件的数据，忽略注释和其他字段，emit 返回 false 时停止
+func readEvents(r io.Reader, emit func(data string) bool) {
+	scanner := bufio.NewScanner(r)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 && !emit(strings.Join(data, "\n")) {
+				return
+			}
+			data = data[:0]
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(value, " "))
+		}
+	}
+}
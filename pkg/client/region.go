@@ -0,0 +1,90 @@
+// region.go
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// pingSamples 每个区域测量的次数，取最小值以排除偶发的抖动
+const pingSamples = 3
+
+// Regions 查询有可用游戏服务器的区域及其延迟探测地址
+func (c *Client) Regions(ctx context.Context) ([]models.RegionInfo, error) {
+	var regions []models.RegionInfo
+	if err := c.do(ctx, http.MethodGet, "/regions", nil, nil, &regions); err != nil {
+		return nil, err
+	}
+	return regions, nil
+}
+
+// Ping 测量到延迟探测地址的往返延迟，多次测量取最小值。首次请求包含建立连接的耗时，不计入结果
+func (c *Client) Ping(ctx context.Context, pingURL string) (time.Duration, error) {
+	best := time.Duration(0)
+	for i := 0; i <= pingSamples; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+		if err != nil {
+			return 0, err
+		}
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		rtt := time.Since(start)
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("延迟探测返回状态码 %d", resp.StatusCode)
+		}
+		if i > 0 && (best == 0 || rtt < best) {
+			best = rtt
+		}
+	}
+	return best, nil
+}
+
+// SaveLatencies 上报当前登录玩家测得的各区域延迟，匹配时优先进入延迟最低的区域
+func (c *Client) SaveLatencies(ctx context.Context, latencies map[string]time.Duration) ([]models.RegionLatency, error) {
+	req := models.SaveLatenciesRequest{Latencies: make(map[string]int, len(latencies))}
+	for region, rtt := range latencies {
+		req.Latencies[region] = int(rtt.Milliseconds())
+	}
+	var saved []models.RegionLatency
+	path := "/players/" + strconv.FormatInt(c.PlayerID(), 10) + "/latencies"
+	if err := c.do(ctx, http.MethodPut, path, nil, req, &saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// ProbeRegions 测量到所有区域的延迟并上报，返回测量成功的区域。无法连接的区域不上报
+func (c *Client) ProbeRegions(ctx context.Context) (map[string]time.Duration, error) {
+	regions, err := c.Regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latencies := make(map[string]time.Duration, len(regions))
+	for _, region := range regions {
+		if region.PingURL == "" {
+			continue
+		}
+		if rtt, err := c.Ping(ctx, region.PingURL); err == nil {
+			latencies[region.Name] = rtt
+		}
+	}
+	if len(latencies) == 0 {
+		return latencies, nil
+	}
+	if _, err := c.SaveLatencies(ctx, latencies); err != nil {
+		return nil, err
+	}
+	return latencies, nil
+}
@@ -0,0 +1,78 @@
+// stats.go
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+)
+
+// MatchPage 分页的对局历史
+type MatchPage struct {
+	Matches []models.PlayerMatchRecord `json:"matches"`
+	Total   int                        `json:"total"`
+	Page    int                        `json:"page"`
+	Limit   int                        `json:"limit"`
+}
+
+// PlayerStats 查询玩家战绩汇总
+func (c *Client) PlayerStats(ctx context.Context, playerID int64) (*models.PlayerStats, error) {
+	var stats models.PlayerStats
+	if err := c.do(ctx, http.MethodGet, "/stats/player/"+strconv.FormatInt(playerID, 10), nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// WindowStats 查询玩家在时间窗口内的战绩汇总
+func (c *Client) WindowStats(ctx context.Context, playerID int64, window models.StatsWindow) (*models.PlayerWindowStats, error) {
+	var stats models.PlayerWindowStats
+	query := url.Values{"window": {string(window)}}
+	if err := c.do(ctx, http.MethodGet, "/stats/player/"+strconv.FormatInt(playerID, 10), query, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// CharacterStats 查询玩家各角色的战绩
+func (c *Client) CharacterStats(ctx context.Context, playerID int64) ([]models.PlayerCharacterStats, error) {
+	var stats []models.PlayerCharacterStats
+	path := "/stats/player/" + strconv.FormatInt(playerID, 10) + "/characters"
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// PlayerMatches 分页查询玩家对局历史，limit 为 0 时使用服务端默认值
+func (c *Client) PlayerMatches(ctx context.Context, playerID int64, limit, offset int) (*MatchPage, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	var page MatchPage
+	if err := c.do(ctx, http.MethodGet, "/stats/matches/"+strconv.FormatInt(playerID, 10), query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Leaderboard 查询排行榜，limit 为 0 时使用服务端默认值
+func (c *Client) Leaderboard(ctx context.Context, leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
+	query := url.Values{"type": {string(leaderboardType)}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var entries []models.LeaderboardEntry
+	if err := c.do(ctx, http.MethodGet, "/stats/leaderboard", query, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,107 @@
+// msgpack.go
+
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	// ContentTypeMsgpack MessagePack 的 MIME 类型
+	ContentTypeMsgpack = "application/msgpack"
+	// contentTypeMsgpackLegacy 部分客户端库使用的旧 MIME 类型
+	contentTypeMsgpackLegacy = "application/x-msgpack"
+)
+
+// JSONToMsgpack 将 JSON 文档转换为结构相同的 MessagePack 文档。
+// 转换基于已序列化的 JSON，字段名和取值与 JSON 响应完全一致
+func JSONToMsgpack(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %w", err)
+	}
+	return msgpack.Marshal(normalizeNumbers(v))
+}
+
+// MsgpackToJSON 将 MessagePack 文档转换为 JSON，用于复用按 JSON 解析的处理逻辑
+func MsgpackToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := msgpack.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("解析MessagePack失败: %w", err)
+	}
+	return json.Marshal(v)
+}
+
+// normalizeNumbers 把 json.Number 转为整数或浮点数，整数在 MessagePack 中使用更紧凑的编码
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if n, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalizeNumbers(value)
+		}
+		return v
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeNumbers(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// IsMsgpack 判断 Content-Type 是否为 MessagePack
+func IsMsgpack(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == ContentTypeMsgpack || mediaType == contentTypeMsgpackLegacy)
+}
+
+// AcceptsMsgpack 按 Accept 头判断客户端是否更希望收到 MessagePack。
+// 只有明确接受 MessagePack 且其优先级不低于 JSON 时才返回 true
+func AcceptsMsgpack(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+
+	msgpackQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case ContentTypeMsgpack, contentTypeMsgpackLegacy:
+			msgpackQ = max(msgpackQ, q)
+		case "application/json":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return msgpackQ > 0 && msgpackQ >= jsonQ
+}
@@ -0,0 +1,39 @@
+// errors.go
+
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// pgUniqueViolation PostgreSQL 唯一约束冲突错误码
+const pgUniqueViolation = "23505"
+
+// UniqueViolation 判断错误是否为唯一约束冲突，返回冲突的约束名（PostgreSQL）
+// 或 "表.列"（SQLite），供调用方区分冲突的字段
+func UniqueViolation(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName, pgErr.Code == pgUniqueViolation
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			// 错误信息形如 "constraint failed: UNIQUE constraint failed: players.username (2067)"
+			msg := sqliteErr.Error()
+			if i := strings.LastIndex(msg, "failed: "); i >= 0 {
+				msg = msg[i+len("failed: "):]
+			}
+			columns, _, _ := strings.Cut(msg, " (")
+			return columns, true
+		}
+	}
+	return "", false
+}
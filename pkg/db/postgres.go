@@ -3,25 +3,30 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jacl-coder/PixelStorm-Server/config"
-	_ "github.com/lib/pq"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
 )
 
 var (
-	// DB 全局数据库连接实例
+	// DB 全局数据库连接实例（主库）
 	DB *sql.DB
 )
 
-// InitPostgres 初始化PostgreSQL连接
+// InitPostgres 初始化PostgreSQL连接，配置了从库时同时连接从库
 func InitPostgres() error {
-	dsn := config.GlobalConfig.Database.GetDSN()
+	dbConfig := config.GlobalConfig.Database
+	var pool *pgxpool.Pool
 	var err error
 
-	DB, err = sql.Open("postgres", dsn)
+	DB, pool, err = openPool(dbConfig.GetDSN(), dbConfig)
 	if err != nil {
 		return fmt.Errorf("连接数据库失败: %w", err)
 	}
@@ -31,14 +36,133 @@ func InitPostgres() error {
 		return fmt.Errorf("数据库Ping失败: %w", err)
 	}
 
-	log.Println("成功连接到PostgreSQL数据库")
+	registerPoolMetrics(pool)
+
+	log.Printf("成功连接到PostgreSQL数据库（最大连接数: %d，常驻连接数: %d，连接最长复用: %v，语句执行模式: %s）",
+		dbConfig.MaxOpenConns, dbConfig.MaxIdleConns, dbConfig.ConnMaxLifetime, dbConfig.QueryExecMode)
+
+	// 从库连接失败不影响启动，标记为不可用，由健康检查恢复
+	Cluster = NewReplicaCluster(DB)
+	for i, dsn := range dbConfig.Replicas {
+		replicaDB, _, err := openPool(dsn, dbConfig)
+		if err != nil {
+			return fmt.Errorf("连接从库 %d 失败: %w", i+1, err)
+		}
+		name := replicaName(i)
+		healthy := true
+		if err := replicaDB.Ping(); err != nil {
+			log.Printf("从库 %s Ping失败，暂不使用: %v", name, err)
+			healthy = false
+		}
+		Cluster.addReplica(name, replicaDB, healthy)
+	}
+	if len(dbConfig.Replicas) > 0 {
+		Cluster.StartHealthCheck(dbConfig.ReplicaCheckInterval)
+		log.Printf("已配置 %d 个只读从库，当前可用 %d 个", len(dbConfig.Replicas), Cluster.HealthyReplicas())
+	}
+	metrics.Default.GaugeFunc("pixelstorm_db_healthy_replicas", "当前可用的只读从库数",
+		func() float64 { return float64(Cluster.HealthyReplicas()) })
+
 	return nil
 }
 
+// pgxPools 已打开的 pgx 连接池，Close 时统一关闭
+var pgxPools []*pgxpool.Pool
+
+// queryExecModes 配置项与 pgx 语句执行模式的对应关系
+var queryExecModes = map[string]pgx.QueryExecMode{
+	"cache_statement": pgx.QueryExecModeCacheStatement,
+	"cache_describe":  pgx.QueryExecModeCacheDescribe,
+	"describe_exec":   pgx.QueryExecModeDescribeExec,
+	"exec":            pgx.QueryExecModeExec,
+	"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+}
+
+// openPool 创建 pgxpool 连接池并应用连接池和语句缓存配置，
+// 返回基于该连接池的 *sql.DB，上层代码继续使用 database/sql 接口
+func openPool(dsn string, dbConfig config.DatabaseConfig) (*sql.DB, *pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析连接配置失败: %w", err)
+	}
+
+	if dbConfig.MaxOpenConns > 0 {
+		poolConfig.MaxConns = int32(dbConfig.MaxOpenConns)
+	}
+	if dbConfig.MaxIdleConns > 0 {
+		poolConfig.MinConns = int32(min(dbConfig.MaxIdleConns, int(poolConfig.MaxConns)))
+	}
+	if dbConfig.ConnMaxLifetime > 0 {
+		poolConfig.MaxConnLifetime = dbConfig.ConnMaxLifetime
+	}
+
+	if dbConfig.QueryExecMode != "" {
+		mode, ok := queryExecModes[dbConfig.QueryExecMode]
+		if !ok {
+			return nil, nil, fmt.Errorf("未知的语句执行模式: %s", dbConfig.QueryExecMode)
+		}
+		poolConfig.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if dbConfig.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = dbConfig.StatementCacheCapacity
+	}
+	poolConfig.ConnConfig.Tracer = &queryTracer{slowThreshold: dbConfig.SlowQueryThreshold}
+	if readOnly {
+		poolConfig.ConnConfig.RuntimeParams["default_transaction_read_only"] = "on"
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	pgxPools = append(pgxPools, pool)
+
+	// 空闲连接由 pgxpool 管理，OpenDBFromPool 会关闭 database/sql 自身的空闲连接缓存
+	return stdlib.OpenDBFromPool(pool), pool, nil
+}
+
+// registerPoolMetrics 注册连接池统计指标
+func registerPoolMetrics(pool *pgxpool.Pool) {
+	gauge := func(name, help string, fn func(*pgxpool.Stat) float64) {
+		metrics.Default.GaugeFunc(name, help, func() float64 { return fn(pool.Stat()) })
+	}
+	counter := func(name, help string, fn func(*pgxpool.Stat) float64) {
+		metrics.Default.CounterFunc(name, help, func() float64 { return fn(pool.Stat()) })
+	}
+
+	gauge("pixelstorm_db_max_open_connections", "数据库最大打开连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) })
+	gauge("pixelstorm_db_open_connections", "数据库当前打开的连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) })
+	gauge("pixelstorm_db_in_use_connections", "数据库正在使用的连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) })
+	gauge("pixelstorm_db_idle_connections", "数据库空闲连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) })
+	counter("pixelstorm_db_acquire_count_total", "获取数据库连接的总次数",
+		func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) })
+	counter("pixelstorm_db_wait_count_total", "等待获取数据库连接的总次数",
+		func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) })
+	counter("pixelstorm_db_acquire_duration_seconds_total", "获取数据库连接的总时长(秒)",
+		func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() })
+	counter("pixelstorm_db_max_idle_time_closed_total", "因空闲超时关闭的连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxIdleDestroyCount()) })
+	counter("pixelstorm_db_max_lifetime_closed_total", "因超过最长复用时间关闭的连接数",
+		func(s *pgxpool.Stat) float64 { return float64(s.MaxLifetimeDestroyCount()) })
+}
+
 // Close 关闭数据库连接
 func Close() {
+	if Cluster != nil {
+		Cluster.Close()
+	}
 	if DB != nil {
 		DB.Close()
+	}
+	for _, pool := range pgxPools {
+		pool.Close()
+	}
+	if len(pgxPools) > 0 {
+		pgxPools = nil
 		log.Println("数据库连接已关闭")
 	}
 }
@@ -13,8 +13,8 @@ import (
 )
 
 var (
-	// RedisClient 全局Redis客户端实例
-	RedisClient *redis.Client
+	// RedisClient 全局Redis客户端实例，按配置为单节点、Sentinel 或 Cluster 客户端
+	RedisClient redis.UniversalClient
 	// Ctx 全局上下文
 	Ctx = context.Background()
 )
@@ -23,11 +23,11 @@ var (
 func InitRedis() error {
 	redisConfig := config.GlobalConfig.Redis
 
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     redisConfig.GetRedisAddr(),
-		Password: redisConfig.Password,
-		DB:       redisConfig.DB,
-	})
+	client, err := newRedisClient(redisConfig)
+	if err != nil {
+		return err
+	}
+	RedisClient = client
 
 	// 测试连接
 	ctx, cancel := context.WithTimeout(Ctx, 5*time.Second)
@@ -37,10 +37,55 @@ func InitRedis() error {
 		return fmt.Errorf("Redis连接失败: %w", err)
 	}
 
-	log.Println("成功连接到Redis服务器")
+	log.Printf("成功连接到Redis服务器（模式: %s）", redisMode(redisConfig))
 	return nil
 }
 
+// redisMode 返回配置的部署模式，未配置时为单节点
+func redisMode(cfg config.RedisConfig) string {
+	if cfg.Mode == "" {
+		return "standalone"
+	}
+	return cfg.Mode
+}
+
+// newRedisClient 按部署模式创建客户端。Sentinel 模式在主节点故障转移后自动连接新主节点，
+// Cluster 模式按槽位路由命令并跟随 MOVED/ASK 重定向
+func newRedisClient(cfg config.RedisConfig) (redis.UniversalClient, error) {
+	switch redisMode(cfg) {
+	case "standalone":
+		return redis.NewClient(&redis.Options{
+			Addr:     cfg.GetRedisAddr(),
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}), nil
+	case "sentinel":
+		if cfg.MasterName == "" || len(cfg.Addrs) == 0 {
+			return nil, fmt.Errorf("Sentinel 模式需要配置 master_name 和 addrs")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+		}), nil
+	case "cluster":
+		if len(cfg.Addrs) == 0 {
+			return nil, fmt.Errorf("Cluster 模式需要配置 addrs")
+		}
+		if cfg.DB != 0 {
+			log.Printf("Redis Cluster 不支持选择数据库，忽略 db=%d", cfg.DB)
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    cfg.Addrs,
+			Password: cfg.Password,
+		}), nil
+	default:
+		return nil, fmt.Errorf("未知的Redis部署模式: %s", cfg.Mode)
+	}
+}
+
 // CloseRedis 关闭Redis连接
 func CloseRedis() {
 	if RedisClient != nil {
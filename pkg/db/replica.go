@@ -0,0 +1,146 @@
+// replica.go
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 默认从库健康检查间隔
+const defaultReplicaCheckInterval = 10 * time.Second
+
+// Cluster 全局主从连接，只配置主库时读写都走主库
+var Cluster *ReplicaCluster
+
+// Reader 返回全局只读查询连接，未初始化主从连接时返回主库
+func Reader() *sql.DB {
+	if Cluster == nil {
+		return DB
+	}
+	return Cluster.Reader()
+}
+
+// replica 只读从库连接及其健康状态
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// ReplicaCluster 主库和只读从库，读请求轮询健康的从库，从库全部不可用时回退到主库
+type ReplicaCluster struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewReplicaCluster 创建主从连接，从库初始视为健康
+func NewReplicaCluster(primary *sql.DB, replicas ...*sql.DB) *ReplicaCluster {
+	c := &ReplicaCluster{primary: primary, stop: make(chan struct{})}
+	for i, db := range replicas {
+		c.addReplica(replicaName(i), db, true)
+	}
+	return c
+}
+
+// replicaName 日志中使用的从库名称
+func replicaName(i int) string {
+	return fmt.Sprintf("replica-%d", i+1)
+}
+
+// addReplica 添加从库
+func (c *ReplicaCluster) addReplica(name string, db *sql.DB, healthy bool) {
+	r := &replica{name: name, db: db}
+	r.healthy.Store(healthy)
+	c.replicas = append(c.replicas, r)
+}
+
+// Primary 返回主库连接，写操作和要求读到最新数据的查询使用主库
+func (c *ReplicaCluster) Primary() *sql.DB {
+	return c.primary
+}
+
+// Reader 返回只读查询使用的连接
+func (c *ReplicaCluster) Reader() *sql.DB {
+	n := len(c.replicas)
+	if n == 0 {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := 0; i < n; i++ {
+		r := c.replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+// HealthyReplicas 返回当前健康的从库数量
+func (c *ReplicaCluster) HealthyReplicas() int {
+	count := 0
+	for _, r := range c.replicas {
+		if r.healthy.Load() {
+			count++
+		}
+	}
+	return count
+}
+
+// StartHealthCheck 定期检查从库，失败的从库暂停使用，恢复后重新加入
+func (c *ReplicaCluster) StartHealthCheck(interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultReplicaCheckInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.checkReplicas()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// checkReplicas 检查所有从库，状态变化时记录日志
+func (c *ReplicaCluster) checkReplicas() {
+	for _, r := range c.replicas {
+		err := r.db.Ping()
+		healthy := err == nil
+		if r.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Printf("从库 %s 已恢复，重新承担只读查询", r.name)
+		} else {
+			log.Printf("从库 %s 不可用，只读查询暂时改走其他节点: %v", r.name, err)
+		}
+	}
+}
+
+// Close 停止健康检查并关闭从库连接，主库由调用方关闭
+func (c *ReplicaCluster) Close() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		for _, r := range c.replicas {
+			r.db.Close()
+		}
+	})
+}
@@ -4,6 +4,9 @@ package db
 
 // 统一的数据库表结构定义
 
+// SchemaVersion 当前程序期望的表结构版本，修改 CreateAllTablesSQL 时递增
+const SchemaVersion = 15
+
 // CreateAllTablesSQL 创建所有表的SQL语句
 const CreateAllTablesSQL = `
 -- 玩家表
@@ -67,6 +70,7 @@ CREATE TABLE IF NOT EXISTS character_skills (
     character_id INT REFERENCES characters(id) ON DELETE CASCADE,
     skill_id INT REFERENCES skills(id) ON DELETE CASCADE,
     slot_index INT NOT NULL,
+    required_level INT DEFAULT 1,
     PRIMARY KEY (character_id, skill_id)
 );
 
@@ -76,6 +80,15 @@ CREATE TABLE IF NOT EXISTS player_characters (
     character_id INT REFERENCES characters(id) ON DELETE CASCADE,
     unlocked BOOLEAN DEFAULT false,
     unlocked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    
+    -- 角色成长与使用统计
+    level INT DEFAULT 1,
+    exp INT DEFAULT 0,
+    usage_count INT DEFAULT 0,
+    win_count INT DEFAULT 0,
+    kill_count INT DEFAULT 0,
+    death_count INT DEFAULT 0,
+    last_played_at TIMESTAMP WITH TIME ZONE,
     PRIMARY KEY (player_id, character_id)
 );
 
@@ -96,6 +109,8 @@ CREATE TABLE IF NOT EXISTS game_maps (
     height INT NOT NULL,
     max_players INT NOT NULL
 );
+-- 地图危险区域，格式见 models.MapHazard
+ALTER TABLE game_maps ADD COLUMN IF NOT EXISTS hazards JSONB NOT NULL DEFAULT '[]';
 
 -- 地图支持的游戏模式表
 CREATE TABLE IF NOT EXISTS map_modes (
@@ -113,7 +128,9 @@ CREATE TABLE IF NOT EXISTS match_records (
     end_time TIMESTAMP WITH TIME ZONE,
     status VARCHAR(20) DEFAULT 'waiting',
     max_players INT NOT NULL,
-    current_players INT DEFAULT 0
+    current_players INT DEFAULT 0,
+    winning_team INT DEFAULT 0,
+    duration INT DEFAULT 0
 );
 
 -- 玩家对局记录表
@@ -129,6 +146,7 @@ CREATE TABLE IF NOT EXISTS player_match_records (
     exp_gained INT DEFAULT 0,
     coins_gained INT DEFAULT 0,
     mvp BOOLEAN DEFAULT false,
+    won BOOLEAN DEFAULT false,
     play_time INT DEFAULT 0,
     join_time TIMESTAMP WITH TIME ZONE NOT NULL,
     leave_time TIMESTAMP WITH TIME ZONE,
@@ -157,6 +175,36 @@ CREATE TABLE IF NOT EXISTS match_history (
     wait_time INT DEFAULT 0 -- 等待时间(秒)
 );
 
+-- 玩家时间窗口战绩汇总表（由后台任务定期刷新）
+CREATE TABLE IF NOT EXISTS player_stats_windows (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    stats_window VARCHAR(10) NOT NULL, -- daily, weekly, monthly
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    matches INT DEFAULT 0,
+    wins INT DEFAULT 0,
+    kills INT DEFAULT 0,
+    deaths INT DEFAULT 0,
+    assists INT DEFAULT 0,
+    score INT DEFAULT 0,
+    mvp INT DEFAULT 0,
+    play_time INT DEFAULT 0,
+    refreshed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, stats_window)
+);
+
+-- 兼容旧版本数据库：补充后续新增的列
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS level INT DEFAULT 1;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS exp INT DEFAULT 0;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS usage_count INT DEFAULT 0;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS win_count INT DEFAULT 0;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS kill_count INT DEFAULT 0;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS death_count INT DEFAULT 0;
+ALTER TABLE player_characters ADD COLUMN IF NOT EXISTS last_played_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE match_records ADD COLUMN IF NOT EXISTS winning_team INT DEFAULT 0;
+ALTER TABLE match_records ADD COLUMN IF NOT EXISTS duration INT DEFAULT 0;
+ALTER TABLE player_match_records ADD COLUMN IF NOT EXISTS won BOOLEAN DEFAULT false;
+ALTER TABLE character_skills ADD COLUMN IF NOT EXISTS required_level INT DEFAULT 1;
+
 -- 创建排行榜视图
 CREATE OR REPLACE VIEW leaderboard AS
 SELECT 
@@ -175,6 +223,519 @@ FROM
 ORDER BY 
     score DESC;
 
+-- 对局位置事件表（击杀/死亡位置，用于热力图）
+CREATE TABLE IF NOT EXISTS match_position_events (
+    id BIGSERIAL PRIMARY KEY,
+    match_id VARCHAR(50) REFERENCES match_records(id) ON DELETE CASCADE,
+    event_type VARCHAR(10) NOT NULL,
+    player_id BIGINT NOT NULL,
+    other_player_id BIGINT,
+    character_id INT,
+    pos_x REAL NOT NULL,
+    pos_y REAL NOT NULL,
+    elapsed REAL DEFAULT 0
+);
+
+-- 对局回放精彩片段标记表（连杀、赛点；对局归档时随对局记录删除）
+CREATE TABLE IF NOT EXISTS match_highlights (
+    id BIGSERIAL PRIMARY KEY,
+    match_id VARCHAR(50) REFERENCES match_records(id) ON DELETE CASCADE,
+    highlight_type VARCHAR(20) NOT NULL,
+    player_id BIGINT NOT NULL DEFAULT 0,
+    team INT NOT NULL DEFAULT 0,
+    kills INT NOT NULL DEFAULT 0,
+    elapsed REAL NOT NULL DEFAULT 0,
+    duration REAL NOT NULL DEFAULT 0
+);
+
+-- 货币交易流水表（金币/宝石的每一笔变动）
+CREATE TABLE IF NOT EXISTS currency_transactions (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    currency VARCHAR(10) NOT NULL,
+    amount BIGINT NOT NULL,
+    balance_after BIGINT NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    reference_id VARCHAR(100),
+    idempotency_key VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 道具定义表
+CREATE TABLE IF NOT EXISTS items (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    item_type VARCHAR(20) NOT NULL,
+    ref_id INT DEFAULT 0,
+    stackable BOOLEAN DEFAULT false,
+    required_character_level INT DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS required_character_level INT DEFAULT 0;
+
+-- 商店上架表
+CREATE TABLE IF NOT EXISTS shop_offers (
+    id SERIAL PRIMARY KEY,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL DEFAULT 1,
+    currency VARCHAR(10) NOT NULL,
+    price BIGINT NOT NULL,
+    available_from TIMESTAMP WITH TIME ZONE,
+    available_until TIMESTAMP WITH TIME ZONE,
+    purchase_limit INT DEFAULT 0,
+    sort_order INT DEFAULT 0,
+    enabled BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家背包表
+CREATE TABLE IF NOT EXISTS player_inventory (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    quantity INT NOT NULL DEFAULT 0,
+    acquired_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, item_id)
+);
+
+-- 商店购买记录表
+CREATE TABLE IF NOT EXISTS shop_purchases (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    offer_id INT REFERENCES shop_offers(id) ON DELETE SET NULL,
+    item_id INT REFERENCES items(id) ON DELETE SET NULL,
+    quantity INT NOT NULL,
+    currency VARCHAR(10) NOT NULL,
+    price BIGINT NOT NULL,
+    transaction_id BIGINT REFERENCES currency_transactions(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家角色皮肤装备表（每个角色一个生效皮肤）
+CREATE TABLE IF NOT EXISTS player_character_skins (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    character_id INT REFERENCES characters(id) ON DELETE CASCADE,
+    item_id INT REFERENCES items(id) ON DELETE CASCADE,
+    equipped_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, character_id)
+);
+
+-- 通行证赛季表
+CREATE TABLE IF NOT EXISTS battle_pass_seasons (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_tier INT NOT NULL DEFAULT 50,
+    xp_per_tier INT NOT NULL DEFAULT 1000,
+    premium_currency VARCHAR(10) NOT NULL DEFAULT 'gems',
+    premium_price BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 通行证等级奖励表
+CREATE TABLE IF NOT EXISTS battle_pass_rewards (
+    season_id INT REFERENCES battle_pass_seasons(id) ON DELETE CASCADE,
+    tier INT NOT NULL,
+    track VARCHAR(10) NOT NULL,
+    reward_type VARCHAR(10) NOT NULL,
+    currency VARCHAR(10),
+    item_id INT REFERENCES items(id),
+    amount BIGINT NOT NULL DEFAULT 1,
+    PRIMARY KEY (season_id, tier, track)
+);
+
+-- 玩家通行证进度表
+CREATE TABLE IF NOT EXISTS player_battle_passes (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    season_id INT REFERENCES battle_pass_seasons(id) ON DELETE CASCADE,
+    xp INT NOT NULL DEFAULT 0,
+    premium BOOLEAN DEFAULT false,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, season_id)
+);
+
+-- 通行证奖励领取记录表
+CREATE TABLE IF NOT EXISTS battle_pass_claims (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    season_id INT REFERENCES battle_pass_seasons(id) ON DELETE CASCADE,
+    tier INT NOT NULL,
+    track VARCHAR(10) NOT NULL,
+    claimed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, season_id, tier, track)
+);
+
+-- 任务定义表
+CREATE TABLE IF NOT EXISTS quests (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    period VARCHAR(10) NOT NULL,
+    objective VARCHAR(20) NOT NULL,
+    target INT NOT NULL,
+    character_id INT REFERENCES characters(id),
+    character_role VARCHAR(20),
+    game_mode VARCHAR(20),
+    reward_currency VARCHAR(10) NOT NULL DEFAULT 'coins',
+    reward_amount BIGINT NOT NULL DEFAULT 0,
+    reward_pass_xp INT NOT NULL DEFAULT 0,
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家任务表
+CREATE TABLE IF NOT EXISTS player_quests (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    quest_id INT REFERENCES quests(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    progress INT NOT NULL DEFAULT 0,
+    rerolled BOOLEAN DEFAULT false,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (player_id, quest_id, period_start)
+);
+
+-- 玩家屏蔽列表
+CREATE TABLE IF NOT EXISTS player_blocks (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    blocked_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, blocked_id)
+);
+
+-- 玩家收藏的对局（收藏的对局不归档）
+CREATE TABLE IF NOT EXISTS player_match_bookmarks (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    match_id VARCHAR(50) REFERENCES match_records(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, match_id)
+);
+
+-- 玩家邮件表
+CREATE TABLE IF NOT EXISTS mails (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    batch_id VARCHAR(36) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    body TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    read_at TIMESTAMP WITH TIME ZONE,
+    claimed_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 邮件附件表
+CREATE TABLE IF NOT EXISTS mail_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    mail_id BIGINT REFERENCES mails(id) ON DELETE CASCADE,
+    attachment_type VARCHAR(10) NOT NULL,
+    currency VARCHAR(10),
+    item_id INT REFERENCES items(id),
+    amount BIGINT NOT NULL DEFAULT 1
+);
+
+-- 头像目录表
+CREATE TABLE IF NOT EXISTS avatars (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    image_url TEXT NOT NULL,
+    unlock_item_id INT REFERENCES items(id),
+    active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家头像表
+CREATE TABLE IF NOT EXISTS player_avatars (
+    player_id BIGINT PRIMARY KEY REFERENCES players(id) ON DELETE CASCADE,
+    avatar_id INT REFERENCES avatars(id) ON DELETE SET NULL,
+    upload_url TEXT,
+    upload_key TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家设置表
+CREATE TABLE IF NOT EXISTS player_settings (
+    player_id BIGINT PRIMARY KEY REFERENCES players(id) ON DELETE CASCADE,
+    data JSONB NOT NULL DEFAULT '{}',
+    version INT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家区域延迟表（客户端测得的到各区域游戏服务器的往返延迟，用于选择匹配服务所在的区域）
+CREATE TABLE IF NOT EXISTS player_region_latencies (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    region VARCHAR(32) NOT NULL,
+    rtt_ms INT NOT NULL CHECK (rtt_ms >= 0),
+    measured_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, region)
+);
+
+-- 玩家赠礼记录表
+CREATE TABLE IF NOT EXISTS player_gifts (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    recipient_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    gift_type VARCHAR(10) NOT NULL,
+    offer_id INT REFERENCES shop_offers(id) ON DELETE SET NULL,
+    item_id INT REFERENCES items(id) ON DELETE SET NULL,
+    quantity INT DEFAULT 0,
+    currency VARCHAR(10) NOT NULL,
+    amount BIGINT NOT NULL,
+    message TEXT,
+    idempotency_key VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 称号定义表
+CREATE TABLE IF NOT EXISTS titles (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    description TEXT,
+    kind VARCHAR(10) NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 玩家称号表
+CREATE TABLE IF NOT EXISTS player_titles (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    title_id INT REFERENCES titles(id) ON DELETE CASCADE,
+    source_ref VARCHAR(100),
+    equipped BOOLEAN DEFAULT false,
+    display_order INT DEFAULT 0,
+    earned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (player_id, title_id)
+);
+
+-- 通行证奖励支持发放称号
+ALTER TABLE battle_pass_rewards ADD COLUMN IF NOT EXISTS title_id INT REFERENCES titles(id);
+
+-- 玩家隐私设置表
+CREATE TABLE IF NOT EXISTS player_privacy (
+    player_id BIGINT PRIMARY KEY REFERENCES players(id) ON DELETE CASCADE,
+    match_history VARCHAR(10) NOT NULL DEFAULT 'public',
+    stats VARCHAR(10) NOT NULL DEFAULT 'public',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 推送设备表
+CREATE TABLE IF NOT EXISTS push_devices (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    platform VARCHAR(10) NOT NULL,
+    token VARCHAR(512) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 推送类别开关表（未设置的类别默认开启）
+CREATE TABLE IF NOT EXISTS push_preferences (
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    category VARCHAR(30) NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    PRIMARY KEY (player_id, category)
+);
+
+-- 应用内购买记录表
+CREATE TABLE IF NOT EXISTS iap_purchases (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE SET NULL,
+    store VARCHAR(10) NOT NULL,
+    product_id VARCHAR(100) NOT NULL,
+    transaction_id VARCHAR(200) NOT NULL,
+    quantity INT NOT NULL DEFAULT 1,
+    gems BIGINT NOT NULL,
+    price_cents BIGINT NOT NULL DEFAULT 0,
+    currency VARCHAR(3),
+    sandbox BOOLEAN DEFAULT false,
+    purchased_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (store, transaction_id)
+);
+
+-- 角色租用表（每周免费轮换按周计算，不写入该表）
+CREATE TABLE IF NOT EXISTS character_trials (
+    id BIGSERIAL PRIMARY KEY,
+    player_id BIGINT REFERENCES players(id) ON DELETE CASCADE,
+    character_id INT REFERENCES characters(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    idempotency_key VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 对局记录归档表，按对局开始时间按月分区，分区由归档任务按需创建
+CREATE TABLE IF NOT EXISTS match_records_archive (
+    id VARCHAR(50) NOT NULL,
+    game_mode VARCHAR(20) NOT NULL,
+    map_id INT,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE,
+    status VARCHAR(20),
+    max_players INT NOT NULL,
+    current_players INT DEFAULT 0,
+    winning_team INT DEFAULT 0,
+    duration INT DEFAULT 0,
+    PRIMARY KEY (id, start_time)
+) PARTITION BY RANGE (start_time);
+
+-- 玩家对局记录归档表，按所属对局的开始时间按月分区
+CREATE TABLE IF NOT EXISTS player_match_records_archive (
+    match_id VARCHAR(50) NOT NULL,
+    player_id BIGINT NOT NULL,
+    character_id INT,
+    team INT,
+    score INT DEFAULT 0,
+    kills INT DEFAULT 0,
+    deaths INT DEFAULT 0,
+    assists INT DEFAULT 0,
+    exp_gained INT DEFAULT 0,
+    coins_gained INT DEFAULT 0,
+    mvp BOOLEAN DEFAULT false,
+    won BOOLEAN DEFAULT false,
+    play_time INT DEFAULT 0,
+    join_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    leave_time TIMESTAMP WITH TIME ZONE,
+    match_start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (match_id, player_id, match_start_time)
+) PARTITION BY RANGE (match_start_time);
+
+-- 对局位置事件归档表，按所属对局的开始时间按月分区
+CREATE TABLE IF NOT EXISTS match_position_events_archive (
+    id BIGINT NOT NULL,
+    match_id VARCHAR(50) NOT NULL,
+    event_type VARCHAR(10) NOT NULL,
+    player_id BIGINT NOT NULL,
+    other_player_id BIGINT,
+    character_id INT,
+    pos_x REAL NOT NULL,
+    pos_y REAL NOT NULL,
+    elapsed REAL DEFAULT 0,
+    match_start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (id, match_start_time)
+) PARTITION BY RANGE (match_start_time);
+
+-- 全部玩家对局记录（在线表和归档表），用于累计战绩的计算
+CREATE OR REPLACE VIEW player_match_records_all AS
+SELECT match_id, player_id, character_id, team, score, kills, deaths, assists, exp_gained,
+       coins_gained, mvp, won, play_time, join_time, leave_time
+FROM player_match_records
+UNION ALL
+SELECT match_id, player_id, character_id, team, score, kills, deaths, assists, exp_gained,
+       coins_gained, mvp, won, play_time, join_time, leave_time
+FROM player_match_records_archive;
+
+-- 表结构版本表（db_manager init 完成后记录当前程序的表结构版本，服务启动时据此检查）
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 功能开关表（未配置的开关使用代码中声明的默认值）
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key VARCHAR(64) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    rollout INT NOT NULL DEFAULT 100 CHECK (rollout BETWEEN 0 AND 100),
+    description TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 游戏平衡配置表（mode 为 default 的行对所有模式生效，各模式的行覆盖其中非零、非空的字段；推送后对新创建的房间生效）
+CREATE TABLE IF NOT EXISTS balance_configs (
+    mode VARCHAR(32) PRIMARY KEY,
+    respawn_time INT NOT NULL DEFAULT 0 CHECK (respawn_time >= 0),
+    time_limit INT NOT NULL DEFAULT 0 CHECK (time_limit >= 0),
+    score_limit INT NOT NULL DEFAULT 0 CHECK (score_limit >= 0),
+    skills JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS warmup_time INT NOT NULL DEFAULT 0 CHECK (warmup_time >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS countdown_time INT NOT NULL DEFAULT 0 CHECK (countdown_time >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS overtime VARCHAR(20) NOT NULL DEFAULT ''
+    CHECK (overtime IN ('', 'none', 'sudden_death', 'extra_time'));
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS overtime_time INT NOT NULL DEFAULT 0 CHECK (overtime_time >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS respawn_max_time INT NOT NULL DEFAULT 0 CHECK (respawn_max_time >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS respawn_wave INT NOT NULL DEFAULT 0 CHECK (respawn_wave >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS kill_score INT CHECK (kill_score >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS assist_score INT CHECK (assist_score >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS capture_tick_score INT CHECK (capture_tick_score >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS flag_capture_score INT CHECK (flag_capture_score >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS healing_per_point INT CHECK (healing_per_point >= 0);
+ALTER TABLE balance_configs ADD COLUMN IF NOT EXISTS body_blocking BOOLEAN;
+-- 得分规则为空时使用上一层的值，0 表示该项不得分
+ALTER TABLE balance_configs
+    ALTER COLUMN kill_score DROP NOT NULL, ALTER COLUMN kill_score DROP DEFAULT,
+    ALTER COLUMN assist_score DROP NOT NULL, ALTER COLUMN assist_score DROP DEFAULT,
+    ALTER COLUMN capture_tick_score DROP NOT NULL, ALTER COLUMN capture_tick_score DROP DEFAULT,
+    ALTER COLUMN flag_capture_score DROP NOT NULL, ALTER COLUMN flag_capture_score DROP DEFAULT,
+    ALTER COLUMN healing_per_point DROP NOT NULL, ALTER COLUMN healing_per_point DROP DEFAULT;
+
+-- 计划维护表（同一时间最多一个未结束且未取消的维护）
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id BIGSERIAL PRIMARY KEY,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    cancelled_at TIMESTAMP WITH TIME ZONE
+);
+
+-- 限时活动表（加成百分比在活动时间内生效，多个活动同时进行时各项取最大值；game_modes 为活动模式的JSON数组）
+CREATE TABLE IF NOT EXISTS live_events (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    exp_bonus INT NOT NULL DEFAULT 0 CHECK (exp_bonus >= 0),
+    coin_bonus INT NOT NULL DEFAULT 0 CHECK (coin_bonus >= 0),
+    shop_discount INT NOT NULL DEFAULT 0 CHECK (shop_discount BETWEEN 0 AND 90),
+    game_modes JSONB NOT NULL DEFAULT '[]',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 管理操作审计日志（所有修改类管理接口的调用记录）
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(64) NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    target TEXT NOT NULL,
+    status INT NOT NULL,
+    before JSONB,
+    payload JSONB,
+    diff JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 角色每日数据物化视图（角色选取率、胜率分析，由后台任务定期刷新）
+CREATE MATERIALIZED VIEW IF NOT EXISTS character_daily_stats AS
+SELECT
+    pmr.character_id,
+    mr.game_mode,
+    date_trunc('day', pmr.join_time) AS day,
+    COUNT(*) AS picks,
+    SUM(CASE WHEN pmr.won THEN 1 ELSE 0 END) AS wins,
+    SUM(pmr.kills) AS kills,
+    SUM(pmr.deaths) AS deaths,
+    SUM(pmr.assists) AS assists,
+    SUM(pmr.score) AS score
+FROM player_match_records pmr
+INNER JOIN match_records mr ON mr.id = pmr.match_id
+WHERE pmr.character_id IS NOT NULL
+GROUP BY pmr.character_id, mr.game_mode, date_trunc('day', pmr.join_time);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_character_daily_stats_key ON character_daily_stats(character_id, game_mode, day);
+
 -- 创建索引以提高查询性能
 CREATE INDEX IF NOT EXISTS idx_players_username ON players(username);
 CREATE INDEX IF NOT EXISTS idx_players_email ON players(email);
@@ -183,15 +744,48 @@ CREATE INDEX IF NOT EXISTS idx_player_match_records_player_id ON player_match_re
 CREATE INDEX IF NOT EXISTS idx_player_match_records_match_id ON player_match_records(match_id);
 CREATE INDEX IF NOT EXISTS idx_match_records_game_mode ON match_records(game_mode);
 CREATE INDEX IF NOT EXISTS idx_match_records_status ON match_records(status);
+CREATE INDEX IF NOT EXISTS idx_match_records_map_id ON match_records(map_id, game_mode);
+CREATE INDEX IF NOT EXISTS idx_match_position_events_match_id ON match_position_events(match_id);
+CREATE INDEX IF NOT EXISTS idx_match_highlights_match_id ON match_highlights(match_id);
+CREATE INDEX IF NOT EXISTS idx_currency_transactions_player_id ON currency_transactions(player_id, id);
+CREATE INDEX IF NOT EXISTS idx_shop_purchases_player_offer ON shop_purchases(player_id, offer_id);
+CREATE INDEX IF NOT EXISTS idx_player_quests_player_period ON player_quests(player_id, period_start);
+CREATE INDEX IF NOT EXISTS idx_player_blocks_blocked_id ON player_blocks(blocked_id);
+CREATE INDEX IF NOT EXISTS idx_player_match_bookmarks_match_id ON player_match_bookmarks(match_id);
+CREATE INDEX IF NOT EXISTS idx_mails_player_id ON mails(player_id, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_mails_batch_id ON mails(batch_id);
+CREATE INDEX IF NOT EXISTS idx_mail_attachments_mail_id ON mail_attachments(mail_id);
+CREATE INDEX IF NOT EXISTS idx_player_gifts_sender ON player_gifts(sender_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_player_gifts_recipient ON player_gifts(recipient_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_player_titles_equipped ON player_titles(player_id) WHERE equipped;
+CREATE INDEX IF NOT EXISTS idx_push_devices_player_id ON push_devices(player_id);
+CREATE INDEX IF NOT EXISTS idx_iap_purchases_player_id ON iap_purchases(player_id);
+CREATE INDEX IF NOT EXISTS idx_iap_purchases_purchased_at ON iap_purchases(purchased_at);
+CREATE INDEX IF NOT EXISTS idx_character_trials_player ON character_trials(player_id, character_id, expires_at);
+CREATE INDEX IF NOT EXISTS idx_match_records_start_time ON match_records(start_time);
+CREATE INDEX IF NOT EXISTS idx_player_match_records_archive_player_id ON player_match_records_archive(player_id);
+CREATE INDEX IF NOT EXISTS idx_match_position_events_archive_match_id ON match_position_events_archive(match_id);
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_ends_at ON maintenance_windows(ends_at) WHERE cancelled_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_live_events_ends_at ON live_events(ends_at) WHERE enabled = true;
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log(created_at);
 CREATE INDEX IF NOT EXISTS idx_match_history_player_id ON match_history(player_id);
+CREATE INDEX IF NOT EXISTS idx_player_match_records_join_time ON player_match_records(join_time);
 CREATE INDEX IF NOT EXISTS idx_character_skills_character_id ON character_skills(character_id);
 `
 
 // InitAllTables 初始化所有数据库表
 func InitAllTables() error {
-	_, err := DB.Exec(CreateAllTablesSQL)
+	var err error
+	if IsSQLite() {
+		err = initSQLiteTables()
+	} else {
+		_, err = DB.Exec(CreateAllTablesSQL)
+	}
 	if err != nil {
 		return err
 	}
-	return nil
+
+	// 记录表结构版本
+	_, err = DB.Exec(`INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, SchemaVersion)
+	return err
 }
@@ -0,0 +1,77 @@
+// schema_check.go
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrSchemaMismatch 数据库表结构版本与程序不一致
+var ErrSchemaMismatch = errors.New("数据库表结构版本与程序不一致")
+
+// readOnly 主库连接是否以只读事务打开
+var readOnly bool
+
+// AppliedSchemaVersion 查询数据库中已记录的表结构版本，没有版本表时返回0
+func AppliedSchemaVersion() (int, error) {
+	var exists bool
+	err := DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = 'schema_migrations')
+	`).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("检查表结构版本表失败: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	if err := DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("查询表结构版本失败: %w", err)
+	}
+	return version, nil
+}
+
+// CheckSchema 检查数据库表结构版本是否与程序一致。mode 为 strict 时不一致返回错误；
+// readonly 时记录警告并将数据库连接切换为只读；off 时不检查。
+// SQLite 在启动时按当前程序建表，无需检查
+func CheckSchema(mode string) error {
+	if mode == "off" || IsSQLite() {
+		return nil
+	}
+
+	applied, err := AppliedSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if applied == SchemaVersion {
+		return nil
+	}
+
+	var mismatch error
+	if applied < SchemaVersion {
+		mismatch = fmt.Errorf("%w: 数据库版本 %d，程序需要 %d。请先执行 go run scripts/db_manager.go -action=init 升级表结构，"+
+			"或将 database.schema_check 设为 readonly 以只读模式启动", ErrSchemaMismatch, applied, SchemaVersion)
+	} else {
+		mismatch = fmt.Errorf("%w: 数据库版本 %d 高于程序支持的 %d，请升级服务程序到对应版本，"+
+			"或将 database.schema_check 设为 readonly 以只读模式启动", ErrSchemaMismatch, applied, SchemaVersion)
+	}
+	if mode != "readonly" {
+		return mismatch
+	}
+
+	log.Printf("警告: %v。服务以只读模式启动，所有写操作都会失败", mismatch)
+	return reopenReadOnly()
+}
+
+// reopenReadOnly 以只读事务模式重新连接主库和从库
+func reopenReadOnly() error {
+	Close()
+	readOnly = true
+	if err := InitPostgres(); err != nil {
+		return fmt.Errorf("以只读模式重新连接数据库失败: %w", err)
+	}
+	return nil
+}
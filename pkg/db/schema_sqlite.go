@@ -0,0 +1,93 @@
+// schema_sqlite.go
+
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sqliteSchemaRewrites 由统一的 PostgreSQL 表结构生成 SQLite 表结构的改写规则，按顺序应用
+var sqliteSchemaRewrites = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{regexp.MustCompile(`(?i)\b(BIG)?SERIAL PRIMARY KEY`), "INTEGER PRIMARY KEY AUTOINCREMENT"},
+	// 驱动只把声明类型恰好为 TIMESTAMP 的列解析为 time.Time
+	{regexp.MustCompile(`(?i)TIMESTAMP WITH TIME ZONE`), "TIMESTAMP"},
+	{regexp.MustCompile(`(?i)DEFAULT (CURRENT_TIMESTAMP|NOW\(\))`), "DEFAULT (now())"},
+	{regexp.MustCompile(`(?i)\b(TEXT|INT)\[\]`), "TEXT"},
+	{regexp.MustCompile(`(?i)\)\s*PARTITION BY RANGE \([^)]*\)`), ")"},
+	{regexp.MustCompile(`(?i)CREATE OR REPLACE VIEW`), "CREATE VIEW IF NOT EXISTS"},
+	{regexp.MustCompile(`(?i)CREATE MATERIALIZED VIEW`), "CREATE VIEW"},
+}
+
+var (
+	sqliteAddColumn   = regexp.MustCompile(`(?is)^ALTER TABLE (\w+) ADD COLUMN IF NOT EXISTS (\w+)\s+(.*)$`)
+	sqliteCreateIndex = regexp.MustCompile(`(?is)^CREATE (UNIQUE )?INDEX IF NOT EXISTS \w+ ON (\w+)`)
+)
+
+// initSQLiteTables 按 CreateAllTablesSQL 创建 SQLite 表结构。分区表建为普通表，
+// 物化视图建为普通视图，补充列的语句在列不存在时才执行
+func initSQLiteTables() error {
+	for _, stmt := range splitStatements(CreateAllTablesSQL) {
+		for _, rule := range sqliteSchemaRewrites {
+			stmt = rule.pattern.ReplaceAllString(stmt, rule.repl)
+		}
+
+		if m := sqliteAddColumn.FindStringSubmatch(stmt); m != nil {
+			exists, err := sqliteColumnExists(m[1], m[2])
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			stmt = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m[1], m[2], m[3])
+		}
+
+		// SQLite 不能在视图上建索引
+		if m := sqliteCreateIndex.FindStringSubmatch(stmt); m != nil {
+			var isView bool
+			if err := DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type = 'view' AND name = $1)`, m[2]).Scan(&isView); err != nil {
+				return err
+			}
+			if isView {
+				continue
+			}
+		}
+
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("%w: %s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// splitStatements 按行尾分号拆分 SQL 脚本，去掉只有注释的片段
+func splitStatements(script string) []string {
+	var stmts []string
+	for _, part := range strings.Split(script, ";\n") {
+		var lines []string
+		for _, line := range strings.Split(part, "\n") {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				lines = append(lines, line)
+			}
+		}
+		if stmt := strings.TrimSuffix(strings.TrimSpace(strings.Join(lines, "\n")), ";"); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// sqliteColumnExists 检查表中是否已有指定列
+func sqliteColumnExists(table, column string) (bool, error) {
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM pragma_table_info($1) WHERE name = $2)`, table, column).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("检查 %s.%s 列失败: %w", table, column, err)
+	}
+	return exists, nil
+}
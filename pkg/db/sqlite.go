@@ -0,0 +1,268 @@
+// sqlite.go
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"modernc.org/sqlite"
+)
+
+const (
+	// DriverPostgres PostgreSQL 数据库（默认）
+	DriverPostgres = "postgres"
+	// DriverSQLite SQLite 数据库，仅用于本地开发
+	DriverSQLite = "sqlite"
+)
+
+// sqliteDriverName 注册到 database/sql 的 SQLite 驱动名
+const sqliteDriverName = "pixelstorm-sqlite"
+
+// sqliteTimeFormat SQLite 中时间值的存储格式，与驱动写入 time.Time 参数时使用的格式一致
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// driverName 当前使用的数据库驱动
+var driverName = DriverPostgres
+
+// Init 按 database.driver 配置初始化数据库连接
+func Init() error {
+	switch driver := config.GlobalConfig.Database.Driver; driver {
+	case "", DriverPostgres:
+		return InitPostgres()
+	case DriverSQLite:
+		return InitSQLite()
+	default:
+		return fmt.Errorf("未知的数据库驱动: %s", driver)
+	}
+}
+
+// IsSQLite 当前是否使用 SQLite，依赖 PostgreSQL 特性的后台任务据此跳过
+func IsSQLite() bool {
+	return driverName == DriverSQLite
+}
+
+// InitSQLite 打开 SQLite 数据库并创建表结构，供本地开发在没有 PostgreSQL 时运行完整服务
+func InitSQLite() error {
+	registerSQLiteDriver()
+
+	path := config.GlobalConfig.Database.SQLitePath
+	if path == "" {
+		path = ":memory:"
+	}
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite", path)
+
+	conn, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		return fmt.Errorf("打开SQLite数据库失败: %w", err)
+	}
+	// 内存数据库的每个连接都是独立的库；文件库也只保留一个连接，避免并发写入时的锁冲突
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return fmt.Errorf("SQLite数据库Ping失败: %w", err)
+	}
+
+	DB = conn
+	Cluster = NewReplicaCluster(DB)
+	driverName = DriverSQLite
+
+	if err := InitAllTables(); err != nil {
+		return fmt.Errorf("创建SQLite表结构失败: %w", err)
+	}
+
+	log.Printf("使用SQLite数据库: %s（仅用于本地开发，依赖行锁和分区表的功能不可用）", path)
+	return nil
+}
+
+var registerSQLiteOnce sync.Once
+
+// registerSQLiteDriver 注册 SQLite 驱动和 PostgreSQL 兼容函数
+func registerSQLiteDriver() {
+	registerSQLiteOnce.Do(func() {
+		sqlite.MustRegisterScalarFunction("now", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+			return time.Now().Format(sqliteTimeFormat), nil
+		})
+		sqlite.MustRegisterDeterministicScalarFunction("date_trunc", 2, sqliteDateTrunc)
+		sqlite.MustRegisterDeterministicScalarFunction("greatest", -1, sqliteExtreme(1))
+		sqlite.MustRegisterDeterministicScalarFunction("least", -1, sqliteExtreme(-1))
+
+		// 兼容函数注册在 modernc 的全局驱动实例上，从已注册的 "sqlite" 驱动取得该实例
+		base, _ := sql.Open("sqlite", "")
+		sql.Register(sqliteDriverName, &sqliteDriver{base: base.Driver()})
+		base.Close()
+	})
+}
+
+// sqliteTimeLayouts 解析 SQLite 中时间文本时依次尝试的格式
+var sqliteTimeLayouts = []string{
+	sqliteTimeFormat,
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// parseSQLiteTime 解析 SQLite 返回的时间值
+func parseSQLiteTime(v driver.Value) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		s := strings.TrimSuffix(v, "Z")
+		for _, layout := range sqliteTimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("无法解析时间: %s", v)
+	default:
+		return time.Time{}, fmt.Errorf("无法解析时间: %v", v)
+	}
+}
+
+// sqliteDateTrunc 实现 PostgreSQL 的 date_trunc(field, timestamp)
+func sqliteDateTrunc(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[1] == nil {
+		return nil, nil
+	}
+	field, _ := args[0].(string)
+	t, err := parseSQLiteTime(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if t, err = TruncTime(field, t); err != nil {
+		return nil, err
+	}
+	return t.Format(sqliteTimeFormat), nil
+}
+
+// TruncTime 按 PostgreSQL date_trunc 的规则截断时间，field 为 year、month、week、day、hour 或 minute
+func TruncTime(field string, t time.Time) (time.Time, error) {
+	y, m, d := t.Date()
+	switch strings.ToLower(field) {
+	case "year":
+		t = time.Date(y, 1, 1, 0, 0, 0, 0, t.Location())
+	case "month":
+		t = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case "week":
+		// PostgreSQL 的周从周一开始
+		offset := (int(t.Weekday()) + 6) % 7
+		t = time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case "day":
+		t = time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case "hour":
+		t = t.Truncate(time.Hour)
+	case "minute":
+		t = t.Truncate(time.Minute)
+	default:
+		return time.Time{}, fmt.Errorf("date_trunc 不支持的精度: %s", field)
+	}
+	return t, nil
+}
+
+// sqliteExtreme 实现 PostgreSQL 的 greatest/least，忽略 NULL 参数
+func sqliteExtreme(sign int) func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+	return func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		var best driver.Value
+		var bestNum float64
+		for _, arg := range args {
+			var n float64
+			switch v := arg.(type) {
+			case int64:
+				n = float64(v)
+			case float64:
+				n = v
+			case nil:
+				continue
+			default:
+				return nil, fmt.Errorf("greatest/least 仅支持数值参数")
+			}
+			if best == nil || (sign > 0 && n > bestNum) || (sign < 0 && n < bestNum) {
+				best, bestNum = arg, n
+			}
+		}
+		return best, nil
+	}
+}
+
+// sqliteUnsupported SQLite 不支持且在单连接下可以忽略的 PostgreSQL 语法
+var sqliteUnsupported = regexp.MustCompile(`(?i)\s+FOR\s+(NO\s+KEY\s+UPDATE|UPDATE|SHARE)(\s+OF\s+\w+)?(\s+(SKIP\s+LOCKED|NOWAIT))?`)
+
+// sqliteDrop PostgreSQL 的级联删除表和视图语句
+var sqliteDrop = regexp.MustCompile(`(?i)DROP\s+(MATERIALIZED\s+)?(TABLE|VIEW)\s+IF\s+EXISTS\s+(\w+)\s+CASCADE`)
+
+// sqliteNoop 在 SQLite 中无需执行的语句（普通视图不需要刷新）
+var sqliteNoop = regexp.MustCompile(`(?i)^\s*REFRESH\s+MATERIALIZED\s+VIEW`)
+
+// rewriteSQLite 将查询改写为 SQLite 可执行的形式。SQLite 只有一个连接，
+// 事务天然串行，去掉行锁子句不改变语义
+func rewriteSQLite(query string) string {
+	if sqliteNoop.MatchString(query) {
+		return "SELECT 1"
+	}
+	query = sqliteDrop.ReplaceAllString(query, "DROP $2 IF EXISTS $3")
+	return sqliteUnsupported.ReplaceAllString(query, "")
+}
+
+// sqliteDriver 包装 SQLite 驱动，执行前改写 PostgreSQL 专有语法
+type sqliteDriver struct {
+	base driver.Driver
+}
+
+// Open 打开连接
+func (d *sqliteDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn: conn}, nil
+}
+
+// sqliteConn 改写语句后转交底层连接
+type sqliteConn struct {
+	conn driver.Conn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(rewriteSQLite(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, rewriteSQLite(query))
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.conn.(driver.ExecerContext).ExecContext(ctx, rewriteSQLite(query), args)
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.conn.(driver.QueryerContext).QueryContext(ctx, rewriteSQLite(query), args)
+}
+
+func (c *sqliteConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqliteConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *sqliteConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *sqliteConn) Close() error {
+	return c.conn.Close()
+}
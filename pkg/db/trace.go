@@ -0,0 +1,112 @@
+// trace.go
+
+package db
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/metrics"
+)
+
+// 慢查询日志中SQL的最大长度
+const slowQueryLogLength = 300
+
+var (
+	queryDuration = metrics.Default.NewHistogramVec("pixelstorm_db_query_duration_seconds",
+		"数据库查询耗时(秒)", nil, "query")
+	queryTotal = metrics.Default.NewCounterVec("pixelstorm_db_queries_total",
+		"数据库查询次数", "query", "status")
+	queryRows = metrics.Default.NewCounterVec("pixelstorm_db_query_rows_total",
+		"数据库查询返回或影响的行数", "query")
+)
+
+var (
+	// queryNamePattern SQL 中 "-- name: xxx" 形式的查询名称注释
+	queryNamePattern = regexp.MustCompile(`--\s*name:\s*([\w.]+)`)
+	// queryTablePattern 推断查询名称时提取的主表
+	queryTablePattern = regexp.MustCompile(`(?i)\b(?:from|into|update)\s+([a-z_][\w.]*)`)
+)
+
+// queryStartKey 查询开始信息在 context 中的键
+type queryStartKey struct{}
+
+// queryStart 查询开始时记录的信息
+type queryStart struct {
+	name  string
+	sql   string
+	start time.Time
+}
+
+// queryTracer 记录每条查询的耗时、错误和行数，超过阈值的查询写入慢查询日志
+type queryTracer struct {
+	slowThreshold time.Duration
+}
+
+// TraceQueryStart 实现 pgx.QueryTracer
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, &queryStart{
+		name:  QueryName(data.SQL),
+		sql:   data.SQL,
+		start: time.Now(),
+	})
+}
+
+// TraceQueryEnd 实现 pgx.QueryTracer，查询结果集关闭时调用
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(queryStartKey{}).(*queryStart)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(qs.start)
+
+	status := "ok"
+	if data.Err != nil {
+		status = "error"
+	}
+	queryDuration.Observe(elapsed.Seconds(), qs.name)
+	queryTotal.Inc(qs.name, status)
+	queryRows.Add(float64(data.CommandTag.RowsAffected()), qs.name)
+
+	if t.slowThreshold > 0 && elapsed >= t.slowThreshold {
+		log.Printf("慢查询 %s 耗时 %v（行数 %d）: %s", qs.name, elapsed.Round(time.Millisecond),
+			data.CommandTag.RowsAffected(), compactSQL(qs.sql))
+	}
+}
+
+// QueryName 返回查询的逻辑名称：优先使用 SQL 中 "-- name: xxx" 注释，
+// 否则按语句类型和主表推断，如 select_players、update_player_characters
+func QueryName(sql string) string {
+	if m := queryNamePattern.FindStringSubmatch(sql); m != nil {
+		return m[1]
+	}
+
+	fields := strings.Fields(sql)
+	for len(fields) > 0 && strings.HasPrefix(fields[0], "--") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	verb := strings.ToLower(strings.TrimRight(fields[0], "(;"))
+	switch verb {
+	case "select", "insert", "update", "delete", "with":
+		if m := queryTablePattern.FindStringSubmatch(sql); m != nil {
+			return verb + "_" + strings.ToLower(m[1])
+		}
+	}
+	return verb
+}
+
+// compactSQL 压缩SQL中的空白并截断，便于写入单行日志
+func compactSQL(sql string) string {
+	s := strings.Join(strings.Fields(sql), " ")
+	if len(s) > slowQueryLogLength {
+		s = s[:slowQueryLogLength] + "..."
+	}
+	return s
+}
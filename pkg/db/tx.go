@@ -0,0 +1,37 @@
+// tx.go
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithTx 在主库事务中执行 fn，fn 返回错误或 panic 时回滚，否则提交
+func WithTx(fn func(tx *sql.Tx) error) error {
+	return RunInTx(DB, fn)
+}
+
+// RunInTx 在指定连接的事务中执行 fn，用于持有独立连接的调用方（如仓储层）
+func RunInTx(conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
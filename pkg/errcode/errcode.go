@@ -0,0 +1,146 @@
+// errcode.go
+
+package errcode
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Code 稳定的错误码，网关 JSON 响应和 WebSocket 错误消息的 code 字段均取自此处。
+// 错误码一经发布不再修改含义，客户端应据此判断错误类型，message 仅供展示
+type Code string
+
+// 通用错误码
+const (
+	BadRequest         Code = "BAD_REQUEST"           // 请求格式或参数无效
+	Unauthorized       Code = "UNAUTHORIZED"          // 未提供登录凭证
+	AuthExpired        Code = "AUTH_EXPIRED"          // 令牌无效或已过期，需重新登录
+	InvalidCredentials Code = "INVALID_CREDENTIALS"   // 用户名或密码错误
+	Forbidden          Code = "FORBIDDEN"             // 无权执行该操作
+	NotFound           Code = "NOT_FOUND"             // 请求的资源不存在
+	MethodNotAllowed   Code = "METHOD_NOT_ALLOWED"    // 不支持的请求方法
+	Conflict           Code = "CONFLICT"              // 与当前状态冲突
+	PayloadTooLarge    Code = "PAYLOAD_TOO_LARGE"     // 请求体或上传内容过大
+	RateLimited        Code = "RATE_LIMIT_EXCEEDED"   // 请求过于频繁
+	ClientBanned       Code = "CLIENT_BANNED"         // 该 IP 或账号已被暂时封禁
+	NotAllowed         Code = "OPERATION_NOT_ALLOWED" // 当前条件下不允许该操作
+	FeatureDisabled    Code = "FEATURE_DISABLED"      // 功能暂未开放
+	LimitReached       Code = "LIMIT_REACHED"         // 已达到次数上限
+	Internal           Code = "INTERNAL_ERROR"        // 服务端内部错误
+	NotImplemented     Code = "NOT_IMPLEMENTED"       // 接口尚未实现
+	UpstreamError      Code = "UPSTREAM_ERROR"        // 依赖的外部服务出错
+	Unavailable        Code = "SERVICE_UNAVAILABLE"   // 服务暂不可用，可稍后重试
+)
+
+// WebSocket 协议错误码
+const (
+	MalformedMessage       Code = "MALFORMED_MESSAGE"         // 消息不是合法的消息信封
+	UnknownMessageType     Code = "UNKNOWN_MESSAGE_TYPE"      // 未知的消息类型
+	InvalidPayload         Code = "INVALID_PAYLOAD"           // 载荷不符合该类型的结构或取值范围
+	TooManyInvalidMessages Code = "TOO_MANY_INVALID_MESSAGES" // 无效消息过多，连接随后关闭
+	UpgradeRequired        Code = "UPGRADE_REQUIRED"          // 客户端协议版本过低
+)
+
+// 账号和角色错误码
+const (
+	UsernameTaken        Code = "USERNAME_TAKEN"          // 用户名已存在
+	EmailTaken           Code = "EMAIL_TAKEN"             // 邮箱已被使用
+	CharacterNotOwned    Code = "CHARACTER_NOT_OWNED"     // 未拥有该角色
+	CharacterLevelTooLow Code = "CHARACTER_LEVEL_TOO_LOW" // 角色等级不足
+	AccountTooNew        Code = "ACCOUNT_TOO_NEW"         // 账号注册时间不足
+	ProfileHidden        Code = "PROFILE_HIDDEN"          // 对方已隐藏该资料
+	Blocked              Code = "BLOCKED"                 // 双方存在屏蔽关系
+	VersionConflict      Code = "VERSION_CONFLICT"        // 数据已在其他设备上修改
+)
+
+// 对局、匹配和组队错误码
+const (
+	ServerFull     Code = "SERVER_FULL"      // 游戏服务器容量已满
+	RoomFull       Code = "ROOM_FULL"        // 房间已满
+	RoomStarted    Code = "ROOM_STARTED"     // 对局已开始，无法加入
+	QueuePaused    Code = "QUEUE_PAUSED"     // 匹配队列已暂停
+	AlreadyQueued  Code = "ALREADY_QUEUED"   // 已在匹配队列中
+	PartyTooLarge  Code = "PARTY_TOO_LARGE"  // 队伍人数超过该模式的房间人数
+	PartyFull      Code = "PARTY_FULL"       // 队伍已满
+	NotInParty     Code = "NOT_IN_PARTY"     // 不在队伍中
+	AlreadyInParty Code = "ALREADY_IN_PARTY" // 已在其他队伍中
+	NotPartyLeader Code = "NOT_PARTY_LEADER" // 只有队长可以执行该操作
+	PartyNotReady  Code = "PARTY_NOT_READY"  // 有队伍成员尚未准备
+)
+
+// 经济和奖励错误码
+const (
+	InsufficientFunds    Code = "INSUFFICIENT_FUNDS"    // 余额不足
+	IdempotencyConflict  Code = "IDEMPOTENCY_CONFLICT"  // 同一幂等键对应了不同的请求
+	AlreadyOwned         Code = "ALREADY_OWNED"         // 已拥有该物品
+	NotOwned             Code = "NOT_OWNED"             // 未拥有该物品、称号或头像
+	OfferUnavailable     Code = "OFFER_UNAVAILABLE"     // 商品当前不可购买
+	AlreadyClaimed       Code = "ALREADY_CLAIMED"       // 奖励已领取
+	NotCompleted         Code = "NOT_COMPLETED"         // 任务未完成
+	TierNotReached       Code = "TIER_NOT_REACHED"      // 未达到通行证等级
+	PremiumRequired      Code = "PREMIUM_REQUIRED"      // 需要解锁高级通行证
+	SeasonNotActive      Code = "SEASON_NOT_ACTIVE"     // 当前没有进行中的赛季
+	AttachmentsUnclaimed Code = "ATTACHMENTS_UNCLAIMED" // 邮件附件尚未领取
+	InvalidReceipt       Code = "INVALID_RECEIPT"       // 购买凭证无效、未付款或来自测试环境
+	ReceiptUsed          Code = "RECEIPT_USED"          // 购买凭证已被其他账号使用
+)
+
+// Error 带错误码的错误，message 为面向用户的说明
+type Error struct {
+	Code    Code
+	Message string
+}
+
+// New 创建带错误码的错误，通常用于声明服务的哨兵错误
+func New(code Code, message string) error {
+	return &Error{Code: code, Message: message}
+}
+
+// Error 实现error接口
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Of 返回错误链中的错误码，没有错误码时返回空字符串
+func Of(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// FromStatus 按 HTTP 状态码返回通用错误码，用于没有更具体错误码的响应
+func FromStatus(statusCode int) Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return BadRequest
+	case http.StatusUnauthorized:
+		return Unauthorized
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusMethodNotAllowed:
+		return MethodNotAllowed
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusRequestEntityTooLarge:
+		return PayloadTooLarge
+	case http.StatusUpgradeRequired:
+		return UpgradeRequired
+	case http.StatusTooManyRequests:
+		return RateLimited
+	case http.StatusNotImplemented:
+		return NotImplemented
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return UpstreamError
+	case http.StatusServiceUnavailable:
+		return Unavailable
+	}
+	if statusCode >= 500 {
+		return Internal
+	}
+	return BadRequest
+}
@@ -0,0 +1,146 @@
+// googleauth.go
+
+package googleauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/jws"
+)
+
+// defaultTokenURL Google OAuth2 令牌地址
+const defaultTokenURL = "https://oauth2.googleapis.com/token"
+
+// ServiceAccount Google服务账号文件中需要的字段
+type ServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// TokenSource 使用服务账号申请并缓存指定权限范围的访问令牌
+type TokenSource struct {
+	account ServiceAccount
+	key     *rsa.PrivateKey
+	scope   string
+	client  *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewTokenSource 读取服务账号文件创建令牌来源
+func NewTokenSource(credentialsFile, scope string) (*TokenSource, error) {
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取服务账号文件失败: %w", err)
+	}
+	var account ServiceAccount
+	if err := json.Unmarshal(data, &account); err != nil {
+		return nil, fmt.Errorf("解析服务账号文件失败: %w", err)
+	}
+	if account.ClientEmail == "" {
+		return nil, errors.New("服务账号文件缺少 client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURL
+	}
+
+	key, err := jws.ParsePKCS8Key([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("服务账号私钥不是RSA密钥")
+	}
+
+	return &TokenSource{
+		account: account,
+		key:     rsaKey,
+		scope:   scope,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// ProjectID 服务账号所属项目
+func (t *TokenSource) ProjectID() string {
+	return t.account.ProjectID
+}
+
+// Token 获取访问令牌，过期前一分钟重新申请
+func (t *TokenSource) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.accessToken != "" && time.Now().Before(t.expiresAt.Add(-time.Minute)) {
+		return t.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jws.Sign(
+		map[string]string{"alg": "RS256", "typ": "JWT"},
+		map[string]interface{}{
+			"iss":   t.account.ClientEmail,
+			"scope": t.scope,
+			"aud":   t.account.TokenURI,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+		},
+		func(input []byte) ([]byte, error) {
+			hash := sha256.Sum256(input)
+			return rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, hash[:])
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("申请访问令牌失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("申请访问令牌失败 %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("解析访问令牌失败: %w", err)
+	}
+
+	t.accessToken = result.AccessToken
+	t.expiresAt = now.Add(time.Duration(result.ExpiresIn) * time.Second)
+	return t.accessToken, nil
+}
@@ -0,0 +1,258 @@
+// i18n.go
+
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jacl-coder/PixelStorm-Server/pkg/errcode"
+)
+
+// Locale 语言
+type Locale string
+
+const (
+	// ZH 简体中文，服务端源码中的提示文本即为中文，无需翻译
+	ZH Locale = "zh"
+	// EN 英文
+	EN Locale = "en"
+)
+
+// Default 客户端未指定或不支持所请求的语言时使用的语言
+const Default = ZH
+
+// Supported 支持的语言
+var Supported = []Locale{ZH, EN}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogFile 语言包文件格式：codes 为各错误码的通用说明，
+// messages 以中文原文为键，含 %d、%s、%q、%v 的键按格式匹配，参数按顺序代入译文
+type catalogFile struct {
+	Codes    map[errcode.Code]string `json:"codes"`
+	Messages map[string]string       `json:"messages"`
+}
+
+// pattern 带格式参数的消息
+type pattern struct {
+	re     *regexp.Regexp
+	format string
+}
+
+// catalog 单个语言的语言包
+type catalog struct {
+	codes    map[errcode.Code]string
+	messages map[string]string
+	patterns []pattern
+}
+
+// verbRe 消息中的格式参数
+var verbRe = regexp.MustCompile(`%[dsqv]`)
+
+// catalogs 各语言的语言包，启动时从内嵌文件加载
+var catalogs = mustLoad()
+
+// mustLoad 加载全部语言包，语言包格式错误属于构建问题，直接 panic
+func mustLoad() map[Locale]*catalog {
+	result := make(map[Locale]*catalog, len(Supported))
+	for _, l := range Supported {
+		c, err := load(l)
+		if err != nil {
+			panic(fmt.Sprintf("加载语言包 %s 失败: %v", l, err))
+		}
+		result[l] = c
+	}
+	return result
+}
+
+// load 加载单个语言包
+func load(l Locale) (*catalog, error) {
+	data, err := localeFiles.ReadFile("locales/" + string(l) + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	c := &catalog{codes: file.Codes, messages: make(map[string]string, len(file.Messages))}
+	for source, text := range file.Messages {
+		if !verbRe.MatchString(source) {
+			c.messages[source] = text
+			continue
+		}
+		if len(verbRe.FindAllString(source, -1)) != len(verbRe.FindAllString(text, -1)) {
+			return nil, fmt.Errorf("%q 的译文参数个数不一致", source)
+		}
+		c.patterns = append(c.patterns, pattern{re: compile(source), format: text})
+	}
+	// 固定文本越长的格式越具体，优先匹配
+	sort.Slice(c.patterns, func(i, j int) bool {
+		return len(c.patterns[i].re.String()) > len(c.patterns[j].re.String())
+	})
+	return c, nil
+}
+
+// compile 把带格式参数的原文转为正则表达式，每个参数对应一个分组
+func compile(source string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range verbRe.FindAllStringIndex(source, -1) {
+		b.WriteString(regexp.QuoteMeta(source[last:loc[0]]))
+		switch source[loc[1]-1] {
+		case 'd':
+			b.WriteString(`(-?\d+)`)
+		case 'q':
+			b.WriteString(`("(?:[^"\\]|\\.)*")`)
+		default:
+			b.WriteString(`(.+?)`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(source[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// expand 按顺序把参数代入译文
+func (p pattern) expand(args []string) string {
+	i := 0
+	return verbRe.ReplaceAllStringFunc(p.format, func(string) string {
+		arg := args[i]
+		i++
+		return arg
+	})
+}
+
+// translate 翻译消息，无对应译文时返回 false
+func (c *catalog) translate(msg string) (string, bool) {
+	if text, ok := c.messages[msg]; ok {
+		return text, true
+	}
+	for _, p := range c.patterns {
+		if m := p.re.FindStringSubmatch(msg); m != nil {
+			return p.expand(m[1:]), true
+		}
+	}
+
+	// "前缀: 详情" 形式的包装错误分段翻译，详情无法翻译时只保留前缀，避免同一句中混杂两种语言
+	if head, tail, ok := strings.Cut(msg, ": "); ok {
+		text, ok := c.translate(head)
+		if !ok {
+			return "", false
+		}
+		if detail, ok := c.translate(tail); ok {
+			return text + ": " + detail, true
+		}
+		if !hasHan(tail) {
+			return text + ": " + tail, true
+		}
+		return text, true
+	}
+	// 公告等消息在译文后附带管理员填写的说明，说明保持原样
+	if head, tail, ok := strings.Cut(msg, "。"); ok {
+		if text, ok := c.translate(head); ok {
+			return text + ". " + tail, true
+		}
+	}
+	return "", false
+}
+
+// hasHan 文本中是否含有汉字
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 解析语言标签，如 en、en-US、zh_CN，不支持时返回 false
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	for _, l := range Supported {
+		if string(l) == base {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate 按 Accept-Language 请求头选择权重最高的受支持语言，没有时返回默认语言
+func Negotiate(acceptLanguage string) Locale {
+	best, bestQ := Default, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		l, ok := Parse(tag)
+		if strings.TrimSpace(tag) == "*" {
+			l, ok = Default, true
+		}
+		if ok && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// FromRequest 请求使用的语言，查询参数 lang 优先于 Accept-Language 请求头。
+// WebSocket 客户端无法设置请求头时可使用查询参数
+func FromRequest(r *http.Request) Locale {
+	if l, ok := Parse(r.URL.Query().Get("lang")); ok {
+		return l
+	}
+	return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Translate 把中文提示翻译为指定语言，无对应译文时返回原文
+func Translate(l Locale, msg string) string {
+	if l == Default || msg == "" {
+		return msg
+	}
+	c, ok := catalogs[l]
+	if !ok {
+		return msg
+	}
+	if text, ok := c.translate(msg); ok {
+		return text
+	}
+	return msg
+}
+
+// Message 错误提示的本地化文本，无对应译文时退回该错误码的通用说明
+func Message(l Locale, code errcode.Code, msg string) string {
+	if l == Default {
+		return msg
+	}
+	c, ok := catalogs[l]
+	if !ok {
+		return msg
+	}
+	if text, ok := c.translate(msg); ok {
+		return text
+	}
+	if text, ok := c.codes[code]; ok {
+		return text
+	}
+	return msg
+}
@@ -0,0 +1,45 @@
+// jws.go
+
+package jws
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Sign 生成JWT，sign 对 header.claims 签名并返回签名字节
+func Sign(header, claims interface{}, sign func(signingInput []byte) ([]byte, error)) (string, error) {
+	h, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(h) + "." + enc.EncodeToString(c)
+	sig, err := sign([]byte(signingInput))
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(sig), nil
+}
+
+// ParsePKCS8Key 解析PEM格式的PKCS#8私钥
+func ParsePKCS8Key(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("无效的PEM私钥")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,61 @@
+// logging.go
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// defaultMaxSizeMB 未配置时单个日志文件的轮转大小
+const defaultMaxSizeMB = 100
+
+// Setup 按配置为服务启用文件日志，控制台输出保持不变。未配置日志目录或该服务禁用时返回nil，
+// 否则返回的文件需在退出前关闭
+func Setup(cfg config.LogConfig, service string) (io.Closer, error) {
+	if cfg.Dir == "" {
+		return nil, nil
+	}
+	override := cfg.Services[service]
+	if override.Disabled {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   filepath.Join(cfg.Dir, service+".log"),
+		MaxSize:    pick(override.MaxSizeMB, cfg.MaxSizeMB),
+		MaxAge:     pick(override.MaxAgeDays, cfg.MaxAgeDays),
+		MaxBackups: pick(override.MaxBackups, cfg.MaxBackups),
+		LocalTime:  true,
+		Compress:   cfg.Compress,
+	}
+	if override.File != "" {
+		file.Filename = filepath.Join(cfg.Dir, override.File)
+	}
+	if file.MaxSize == 0 {
+		file.MaxSize = defaultMaxSizeMB
+	}
+
+	log.SetOutput(io.MultiWriter(os.Stderr, file))
+	log.Printf("日志同时写入文件 %s (单个文件 %dMB, 保留 %d 天 / %d 个)",
+		file.Filename, file.MaxSize, file.MaxAge, file.MaxBackups)
+	return file, nil
+}
+
+// pick 服务级配置非零时优先使用
+func pick(service, global int) int {
+	if service != 0 {
+		return service
+	}
+	return global
+}
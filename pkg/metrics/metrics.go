@@ -0,0 +1,128 @@
+// metrics.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// metricType Prometheus 指标类型
+type metricType string
+
+const (
+	typeCounter   metricType = "counter"
+	typeGauge     metricType = "gauge"
+	typeHistogram metricType = "histogram"
+)
+
+// collector 可导出的指标
+type collector interface {
+	describe() (name, help string, typ metricType)
+	write(w io.Writer, name string)
+}
+
+// Registry 指标注册表，按 Prometheus 文本格式导出
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default 默认注册表，各进程的 /metrics 端点导出该注册表
+var Default = NewRegistry()
+
+// register 注册指标，同名指标重复注册时替换旧的
+func (r *Registry) register(c collector) {
+	name, _, _ := c.describe()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[name]; ok {
+		log.Printf("指标 %s 重复注册，已替换", name)
+	}
+	r.collectors[name] = c
+}
+
+// funcMetric 取值时调用函数的指标
+type funcMetric struct {
+	name string
+	help string
+	typ  metricType
+	fn   func() float64
+}
+
+func (m *funcMetric) describe() (string, string, metricType) {
+	return m.name, m.help, m.typ
+}
+
+func (m *funcMetric) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(m.fn()))
+}
+
+// GaugeFunc 注册取值时调用 fn 的瞬时值指标
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, typ: typeGauge, fn: fn})
+}
+
+// CounterFunc 注册取值时调用 fn 的累计值指标，fn 的返回值应单调递增
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{name: name, help: help, typ: typeCounter, fn: fn})
+}
+
+// Write 按名称排序输出全部指标
+func (r *Registry) Write(w io.Writer) {
+	r.mu.RLock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		a, _, _ := collectors[i].describe()
+		b, _, _ := collectors[j].describe()
+		return a < b
+	})
+	for _, c := range collectors {
+		name, help, typ := c.describe()
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+		c.write(w, name)
+	}
+}
+
+// Handler 返回导出该注册表的HTTP处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Handler 返回导出默认注册表的HTTP处理器
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// formatValue 按 Prometheus 文本格式输出数值
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,168 @@
+// vec.go
+
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefBuckets 默认直方图分桶（秒），适用于数据库查询和HTTP请求耗时
+var DefBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labelSet 按标签值组合存放的序列
+type labelSet struct {
+	names []string
+	mu    sync.Mutex
+	keys  map[string][]string
+}
+
+func newLabelSet(names []string) labelSet {
+	return labelSet{names: names, keys: make(map[string][]string)}
+}
+
+// key 返回标签值组合的键，标签值数量不匹配时 panic
+func (l *labelSet) key(values []string) string {
+	if len(values) != len(l.names) {
+		panic(fmt.Sprintf("标签数量不匹配: 需要 %d 个，实际 %d 个", len(l.names), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := l.keys[key]; !ok {
+		l.keys[key] = append([]string(nil), values...)
+	}
+	return key
+}
+
+// sortedKeys 按标签值排序的键
+func (l *labelSet) sortedKeys() []string {
+	keys := make([]string, 0, len(l.keys))
+	for k := range l.keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// format 输出 {name="value",...} 形式的标签，extra 为附加的标签对
+func (l *labelSet) format(key string, extra ...string) string {
+	values := l.keys[key]
+	parts := make([]string, 0, len(values)+len(extra)/2)
+	for i, name := range l.names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// CounterVec 带标签的累计值指标
+type CounterVec struct {
+	name, help string
+	labels     labelSet
+	values     map[string]float64
+}
+
+// NewCounterVec 注册带标签的累计值指标
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: newLabelSet(labelNames), values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Add 为指定标签值的序列增加 v，v 应为非负数
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.labels.mu.Lock()
+	defer c.labels.mu.Unlock()
+	c.values[c.labels.key(labelValues)] += v
+}
+
+// Inc 为指定标签值的序列加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) describe() (string, string, metricType) {
+	return c.name, c.help, typeCounter
+}
+
+func (c *CounterVec) write(w io.Writer, name string) {
+	c.labels.mu.Lock()
+	defer c.labels.mu.Unlock()
+	for _, key := range c.labels.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", name, c.labels.format(key), formatValue(c.values[key]))
+	}
+}
+
+// histogram 单个序列的分桶计数
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec 带标签的直方图指标
+type HistogramVec struct {
+	name, help string
+	buckets    []float64
+	labels     labelSet
+	series     map[string]*histogram
+}
+
+// NewHistogramVec 注册带标签的直方图指标，buckets 为空时使用 DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		name: name, help: help, buckets: buckets,
+		labels: newLabelSet(labelNames), series: make(map[string]*histogram),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe 记录指定标签值序列的一次观测
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.labels.mu.Lock()
+	defer h.labels.mu.Unlock()
+	key := h.labels.key(labelValues)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) describe() (string, string, metricType) {
+	return h.name, h.help, typeHistogram
+}
+
+func (h *HistogramVec) write(w io.Writer, name string) {
+	h.labels.mu.Lock()
+	defer h.labels.mu.Unlock()
+	for _, key := range h.labels.sortedKeys() {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labels.format(key, "le", formatValue(upper)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labels.format(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, h.labels.format(key), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, h.labels.format(key), s.count)
+	}
+}
@@ -0,0 +1,82 @@
+// local.go
+
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalMountPath 本地存储文件的HTTP访问路径
+const LocalMountPath = "/uploads/"
+
+// LocalStorage 本地目录存储
+type LocalStorage struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocalStorage 创建本地目录存储
+func NewLocalStorage(dir, publicURL string) (*LocalStorage, error) {
+	if dir == "" {
+		dir = "./uploads"
+	}
+	if publicURL == "" {
+		publicURL = strings.TrimSuffix(LocalMountPath, "/")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return &LocalStorage{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+// path 将对象键转换为本地路径，拒绝越出存储目录的键
+func (s *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" {
+		return "", fmt.Errorf("无效的对象键: %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// Put 写入文件
+func (s *LocalStorage) Put(key, contentType string, data []byte) (string, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("写入文件失败: %w", err)
+	}
+	return s.publicURL + "/" + strings.TrimPrefix(key, "/"), nil
+}
+
+// Delete 删除文件
+func (s *LocalStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// Handler 返回用于访问已存储文件的HTTP处理器，挂载在 LocalMountPath 下（不提供目录列表）
+func (s *LocalStorage) Handler() http.Handler {
+	files := http.StripPrefix(LocalMountPath, http.FileServer(http.Dir(s.dir)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,29 @@
+// storage.go
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+)
+
+// Storage 文件存储接口
+type Storage interface {
+	// Put 写入对象，返回对外访问地址
+	Put(key, contentType string, data []byte) (string, error)
+	// Delete 删除对象，对象不存在时不报错
+	Delete(key string) error
+}
+
+// New 根据配置创建文件存储
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalDir, cfg.PublicURL)
+	case "s3":
+		return NewS3Storage(cfg.S3, cfg.PublicURL)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", cfg.Driver)
+	}
+}
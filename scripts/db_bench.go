@@ -0,0 +1,197 @@
+// db_bench.go
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/repository"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// benchQuery 压测的高频查询
+type benchQuery struct {
+	name string
+	run  func(playerID int64) error
+}
+
+// benchResult 单个查询在某种执行模式下的耗时统计
+type benchResult struct {
+	count  int
+	errors int
+	total  time.Duration
+	p50    time.Duration
+	p95    time.Duration
+	p99    time.Duration
+}
+
+// main 对比不同语句执行模式下高频查询的耗时，用法:
+//
+//	go run scripts/db_bench.go -modes=simple_protocol,cache_statement -concurrency=32 -requests=5000
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	modes := flag.String("modes", "simple_protocol,cache_statement", "依次对比的语句执行模式，逗号分隔")
+	concurrency := flag.Int("concurrency", 32, "并发数")
+	requests := flag.Int("requests", 5000, "每个查询的请求数")
+	flag.Parse()
+
+	if err := config.LoadConfig(*configPath); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	var names []string
+	results := make(map[string]map[string]*benchResult)
+	modeList := strings.Split(*modes, ",")
+	for _, mode := range modeList {
+		config.GlobalConfig.Database.QueryExecMode = mode
+		if err := db.InitPostgres(); err != nil {
+			log.Fatalf("初始化PostgreSQL失败: %v", err)
+		}
+
+		playerIDs, err := samplePlayers()
+		if err != nil {
+			db.Close()
+			log.Fatalf("查询玩家失败: %v", err)
+		}
+
+		names = names[:0]
+		results[mode] = make(map[string]*benchResult)
+		for _, q := range hotQueries() {
+			names = append(names, q.name)
+			results[mode][q.name] = runQuery(q, playerIDs, *concurrency, *requests)
+		}
+		db.Close()
+	}
+
+	printResults(names, modeList, results)
+}
+
+// hotQueries 排行榜、玩家战绩和角色列表等高频查询
+func hotQueries() []benchQuery {
+	stats := repository.NewPostgresStatsRepo(db.Cluster)
+	characters := repository.NewPostgresCharacterRepo(db.Cluster)
+	return []benchQuery{
+		{"leaderboard", func(int64) error {
+			_, err := stats.Leaderboard(models.LeaderboardKills, 100)
+			return err
+		}},
+		{"player_stats", func(playerID int64) error {
+			_, err := stats.PlayerStats(playerID)
+			return err
+		}},
+		{"player_characters", func(playerID int64) error {
+			_, err := characters.PlayerCharacters(playerID)
+			return err
+		}},
+		{"characters", func(int64) error {
+			_, err := characters.All()
+			return err
+		}},
+	}
+}
+
+// samplePlayers 抽取压测使用的玩家ID
+func samplePlayers() ([]int64, error) {
+	rows, err := db.DB.Query(`SELECT id FROM players ORDER BY id LIMIT 1000`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("没有玩家数据，请先运行 scripts/init_data.go")
+	}
+	return ids, nil
+}
+
+// runQuery 并发执行查询并统计耗时分布
+func runQuery(q benchQuery, playerIDs []int64, concurrency, requests int) *benchResult {
+	latencies := make([]time.Duration, 0, requests)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errCount := 0
+
+	jobs := make(chan int64)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for playerID := range jobs {
+				start := time.Now()
+				err := q.run(playerID)
+				elapsed := time.Since(start)
+
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil && !errors.Is(err, repository.ErrNotFound) {
+					errCount++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		jobs <- playerIDs[rand.Intn(len(playerIDs))]
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result := &benchResult{count: len(latencies), errors: errCount}
+	for _, d := range latencies {
+		result.total += d
+	}
+	result.p50 = percentile(latencies, 0.50)
+	result.p95 = percentile(latencies, 0.95)
+	result.p99 = percentile(latencies, 0.99)
+	return result
+}
+
+// percentile 返回已排序耗时的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+// printResults 输出各执行模式的耗时对比，以第一个模式为基准
+func printResults(names, modes []string, results map[string]map[string]*benchResult) {
+	log.Println("")
+	log.Printf("%-18s %-16s %10s %10s %10s %10s %8s %8s", "查询", "模式", "平均", "P50", "P95", "P99", "错误", "对比")
+	for _, name := range names {
+		base := results[modes[0]][name]
+		for _, mode := range modes {
+			r := results[mode][name]
+			avg := r.total / time.Duration(max(r.count, 1))
+			change := "-"
+			if mode != modes[0] && base.total > 0 {
+				change = fmt.Sprintf("%+.1f%%", (float64(r.total)/float64(base.total)-1)*100)
+			}
+			log.Printf("%-18s %-16s %10v %10v %10v %10v %8d %8s", name, mode,
+				avg.Round(time.Microsecond), r.p50.Round(time.Microsecond),
+				r.p95.Round(time.Microsecond), r.p99.Round(time.Microsecond), r.errors, change)
+		}
+	}
+}
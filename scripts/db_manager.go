@@ -3,8 +3,15 @@
 package main
 
 import (
+	"database/sql"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/jacl-coder/PixelStorm-Server/config"
 	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
@@ -13,7 +20,10 @@ import (
 func main() {
 	// 解析命令行参数
 	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
-	action := flag.String("action", "help", "操作类型: reset, init, help")
+	action := flag.String("action", "help", "操作类型: reset, init, backup, restore, verify, help")
+	outDir := flag.String("out", "backups", "backup 的输出目录")
+	file := flag.String("file", "", "restore 使用的备份文件")
+	binDir := flag.String("bin-dir", "", "pg_dump 和 pg_restore 所在目录，为空时从 PATH 查找")
 	flag.Parse()
 
 	// 显示帮助信息
@@ -27,9 +37,19 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 备份和恢复通过 PostgreSQL 客户端工具完成，不需要数据库连接
+	switch *action {
+	case "backup":
+		backupDatabase(*binDir, *outDir)
+		return
+	case "restore":
+		restoreDatabase(*binDir, *file)
+		return
+	}
+
 	// 初始化数据库连接
-	if err := db.InitPostgres(); err != nil {
-		log.Fatalf("初始化PostgreSQL失败: %v", err)
+	if err := db.Init(); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
 	}
 	defer db.Close()
 
@@ -39,6 +59,12 @@ func main() {
 		resetDatabase()
 	case "init":
 		initDatabase()
+	case "verify":
+		if problems := verifyDatabase(); problems > 0 {
+			db.Close()
+			log.Fatalf("❌ 校验发现 %d 项问题", problems)
+		}
+		log.Println("✅ 校验通过")
 	default:
 		log.Fatalf("未知操作: %s", *action)
 	}
@@ -52,14 +78,20 @@ func showHelp() {
 	log.Println("  go run scripts/db_manager.go -action=<操作> [-config=<配置文件>]")
 	log.Println("")
 	log.Println("操作:")
-	log.Println("  reset  - 重置数据库（删除所有表和数据）")
-	log.Println("  init   - 初始化数据库（创建表结构）")
-	log.Println("  help   - 显示此帮助信息")
+	log.Println("  reset   - 重置数据库（删除所有表和数据）")
+	log.Println("  init    - 初始化数据库（创建表结构）")
+	log.Println("  backup  - 使用 pg_dump 备份数据库到 -out 目录，文件名带时间戳")
+	log.Println("  restore - 使用 pg_restore 从 -file 指定的备份恢复数据库（覆盖现有数据）")
+	log.Println("  verify  - 检查各表行数、外键完整性和孤立的对局记录，发现问题时以非0状态退出")
+	log.Println("  help    - 显示此帮助信息")
 	log.Println("")
 	log.Println("示例:")
 	log.Println("  go run scripts/db_manager.go -action=reset")
 	log.Println("  go run scripts/db_manager.go -action=init")
 	log.Println("  go run scripts/db_manager.go -action=reset && go run scripts/db_manager.go -action=init")
+	log.Println("  go run scripts/db_manager.go -action=backup -out=/var/backups/pixelstorm")
+	log.Println("  go run scripts/db_manager.go -action=restore -file=backups/pixelstorm_20250101_030000.dump")
+	log.Println("  go run scripts/db_manager.go -action=verify")
 }
 
 // resetDatabase 重置数据库
@@ -71,8 +103,48 @@ func resetDatabase() {
 	resetSQL := `
 -- 删除视图
 DROP VIEW IF EXISTS leaderboard CASCADE;
+DROP MATERIALIZED VIEW IF EXISTS character_daily_stats CASCADE;
 
 -- 删除表（按依赖关系顺序）
+DROP TABLE IF EXISTS admin_audit_log CASCADE;
+DROP TABLE IF EXISTS live_events CASCADE;
+DROP TABLE IF EXISTS maintenance_windows CASCADE;
+DROP TABLE IF EXISTS balance_configs CASCADE;
+DROP TABLE IF EXISTS feature_flags CASCADE;
+DROP TABLE IF EXISTS schema_migrations CASCADE;
+DROP TABLE IF EXISTS match_position_events_archive CASCADE;
+DROP TABLE IF EXISTS player_match_records_archive CASCADE;
+DROP TABLE IF EXISTS match_records_archive CASCADE;
+DROP TABLE IF EXISTS character_trials CASCADE;
+DROP TABLE IF EXISTS iap_purchases CASCADE;
+DROP TABLE IF EXISTS push_devices CASCADE;
+DROP TABLE IF EXISTS push_preferences CASCADE;
+DROP TABLE IF EXISTS player_privacy CASCADE;
+DROP TABLE IF EXISTS player_titles CASCADE;
+DROP TABLE IF EXISTS titles CASCADE;
+DROP TABLE IF EXISTS player_gifts CASCADE;
+DROP TABLE IF EXISTS player_region_latencies CASCADE;
+DROP TABLE IF EXISTS player_settings CASCADE;
+DROP TABLE IF EXISTS player_avatars CASCADE;
+DROP TABLE IF EXISTS avatars CASCADE;
+DROP TABLE IF EXISTS mail_attachments CASCADE;
+DROP TABLE IF EXISTS mails CASCADE;
+DROP TABLE IF EXISTS player_match_bookmarks CASCADE;
+DROP TABLE IF EXISTS player_blocks CASCADE;
+DROP TABLE IF EXISTS player_quests CASCADE;
+DROP TABLE IF EXISTS quests CASCADE;
+DROP TABLE IF EXISTS battle_pass_claims CASCADE;
+DROP TABLE IF EXISTS player_battle_passes CASCADE;
+DROP TABLE IF EXISTS battle_pass_rewards CASCADE;
+DROP TABLE IF EXISTS battle_pass_seasons CASCADE;
+DROP TABLE IF EXISTS player_character_skins CASCADE;
+DROP TABLE IF EXISTS shop_purchases CASCADE;
+DROP TABLE IF EXISTS player_inventory CASCADE;
+DROP TABLE IF EXISTS shop_offers CASCADE;
+DROP TABLE IF EXISTS items CASCADE;
+DROP TABLE IF EXISTS currency_transactions CASCADE;
+DROP TABLE IF EXISTS match_position_events CASCADE;
+DROP TABLE IF EXISTS player_stats_windows CASCADE;
 DROP TABLE IF EXISTS player_match_preferences CASCADE;
 DROP TABLE IF EXISTS match_history CASCADE;
 DROP TABLE IF EXISTS player_match_records CASCADE;
@@ -119,8 +191,278 @@ func initDatabase() {
 	log.Println("  - player_match_records (玩家对局记录表)")
 	log.Println("  - player_match_preferences (玩家匹配偏好表)")
 	log.Println("  - match_history (匹配历史表)")
+	log.Println("  - player_stats_windows (玩家时间窗口战绩汇总表)")
+	log.Println("  - match_position_events (对局位置事件表)")
+	log.Println("  - currency_transactions (货币交易流水表)")
+	log.Println("  - items (道具定义表)")
+	log.Println("  - shop_offers (商店上架表)")
+	log.Println("  - player_inventory (玩家背包表)")
+	log.Println("  - shop_purchases (商店购买记录表)")
+	log.Println("  - player_character_skins (玩家角色皮肤装备表)")
+	log.Println("  - battle_pass_seasons (通行证赛季表)")
+	log.Println("  - battle_pass_rewards (通行证等级奖励表)")
+	log.Println("  - player_battle_passes (玩家通行证进度表)")
+	log.Println("  - battle_pass_claims (通行证奖励领取记录表)")
+	log.Println("  - quests (任务定义表)")
+	log.Println("  - player_quests (玩家任务表)")
+	log.Println("  - player_blocks (玩家屏蔽列表)")
+	log.Println("  - player_match_bookmarks (玩家收藏对局表)")
+	log.Println("  - mails (玩家邮件表)")
+	log.Println("  - mail_attachments (邮件附件表)")
+	log.Println("  - avatars (头像目录表)")
+	log.Println("  - player_avatars (玩家头像表)")
+	log.Println("  - player_settings (玩家设置表)")
+	log.Println("  - player_region_latencies (玩家区域延迟表)")
+	log.Println("  - player_gifts (玩家赠礼记录表)")
+	log.Println("  - titles (称号定义表)")
+	log.Println("  - player_titles (玩家称号表)")
+	log.Println("  - player_privacy (玩家隐私设置表)")
+	log.Println("  - push_preferences (推送类别开关表)")
+	log.Println("  - push_devices (推送设备表)")
+	log.Println("  - iap_purchases (应用内购买记录表)")
+	log.Println("  - character_trials (角色租用表)")
+	log.Println("  - match_records_archive (对局记录归档表)")
+	log.Println("  - player_match_records_archive (玩家对局记录归档表)")
+	log.Println("  - match_position_events_archive (对局位置事件归档表)")
+	log.Println("  - schema_migrations (表结构版本表)")
+	log.Println("  - feature_flags (功能开关表)")
+	log.Println("  - balance_configs (游戏平衡配置表)")
+	log.Println("  - maintenance_windows (计划维护表)")
+	log.Println("  - live_events (限时活动表)")
+	log.Println("  - admin_audit_log (管理操作审计日志表)")
 	log.Println("  - leaderboard (排行榜视图)")
+	log.Println("  - character_daily_stats (角色每日数据物化视图)")
 	log.Println("")
 	log.Println("💡 提示: 使用以下命令初始化测试数据:")
 	log.Println("  go run scripts/init_data.go -config=config/config.yaml -type=all")
 }
+
+// pgTool PostgreSQL 客户端工具的路径和连接参数，密码通过环境变量传递避免出现在进程列表中
+func pgTool(binDir, name string, args ...string) *exec.Cmd {
+	if config.GlobalConfig.Database.Driver == db.DriverSQLite {
+		log.Fatalf("SQLite 仅用于本地开发，直接复制数据库文件 %s 即可", config.GlobalConfig.Database.SQLitePath)
+	}
+	cfg := config.GlobalConfig.Database
+	if binDir != "" {
+		name = filepath.Join(binDir, name)
+	}
+	connArgs := []string{"-h", cfg.Host, "-p", strconv.Itoa(cfg.Port), "-U", cfg.User, "-d", cfg.DBName}
+	cmd := exec.Command(name, append(connArgs, args...)...)
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+cfg.Password)
+	if cfg.SSLMode != "" {
+		cmd.Env = append(cmd.Env, "PGSSLMODE="+cfg.SSLMode)
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// backupDatabase 使用 pg_dump 以自定义格式备份整个数据库，文件名为 <库名>_<时间>.dump
+func backupDatabase(binDir, outDir string) {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		log.Fatalf("创建备份目录失败: %v", err)
+	}
+	path := filepath.Join(outDir, fmt.Sprintf("%s_%s.dump", config.GlobalConfig.Database.DBName, time.Now().Format("20060102_150405")))
+
+	log.Printf("💾 正在备份数据库到 %s ...", path)
+	start := time.Now()
+	if err := pgTool(binDir, "pg_dump", "--format=custom", "--no-owner", "--file="+path).Run(); err != nil {
+		os.Remove(path)
+		log.Fatalf("备份失败: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Fatalf("读取备份文件失败: %v", err)
+	}
+	log.Printf("✅ 备份完成: %s (%.1f MB, 耗时 %s)", path, float64(info.Size())/(1<<20), time.Since(start).Round(time.Second))
+}
+
+// restoreDatabase 使用 pg_restore 在一个事务中恢复备份，先删除备份中包含的对象
+func restoreDatabase(binDir, file string) {
+	if file == "" {
+		log.Fatal("请使用 -file 指定备份文件")
+	}
+	if _, err := os.Stat(file); err != nil {
+		log.Fatalf("读取备份文件失败: %v", err)
+	}
+
+	log.Printf("⚠️  正在从 %s 恢复数据库 %s...", file, config.GlobalConfig.Database.DBName)
+	log.Println("⚠️  这将覆盖备份中包含的所有表和数据！")
+	start := time.Now()
+	if err := pgTool(binDir, "pg_restore", "--clean", "--if-exists", "--no-owner", "--single-transaction", file).Run(); err != nil {
+		log.Fatalf("恢复失败，事务已回滚: %v", err)
+	}
+	log.Printf("✅ 恢复完成，耗时 %s", time.Since(start).Round(time.Second))
+	log.Println("💡 提示: 使用 -action=verify 检查恢复后的数据")
+}
+
+// staleMatchAge 超过这段时间仍未关联到对局或未结束的记录视为异常，排除进行中的对局
+const staleMatchAge = 24 * time.Hour
+
+// integrityCheck 数据一致性检查，query 返回有问题的行数
+type integrityCheck struct {
+	name  string
+	query string
+	stale bool // query 中的 $1 为 staleMatchAge 之前的时间
+}
+
+// integrityChecks 外键无法保证的一致性检查
+var integrityChecks = []integrityCheck{
+	{"没有玩家数据的对局记录", `
+		SELECT COUNT(*) FROM match_records m
+		WHERE NOT EXISTS (SELECT 1 FROM player_match_records pm WHERE pm.match_id = m.id)`, false},
+	{"超过一天仍未结束的对局记录", `
+		SELECT COUNT(*) FROM match_records
+		WHERE (status <> 'ended' OR end_time IS NULL) AND start_time < $1`, true},
+	{"对局不存在的匹配历史", `
+		SELECT COUNT(*) FROM match_history h
+		WHERE h.match_id IS NOT NULL AND h.match_id <> '' AND h.join_time < $1
+		  AND NOT EXISTS (SELECT 1 FROM match_records m WHERE m.id = h.match_id)
+		  AND NOT EXISTS (SELECT 1 FROM match_records_archive a WHERE a.id = h.match_id)`, true},
+	{"玩家不存在的位置事件", `
+		SELECT COUNT(*) FROM match_position_events e
+		WHERE NOT EXISTS (SELECT 1 FROM players p WHERE p.id = e.player_id)
+		   OR (e.other_player_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM players p WHERE p.id = e.other_player_id))`, false},
+	{"对局不存在的归档玩家对局记录", `
+		SELECT COUNT(*) FROM player_match_records_archive pa
+		WHERE NOT EXISTS (SELECT 1 FROM match_records_archive a WHERE a.id = pa.match_id AND a.start_time = pa.match_start_time)`, false},
+	{"同时存在于在线表和归档表的对局", `
+		SELECT COUNT(*) FROM match_records m
+		WHERE EXISTS (SELECT 1 FROM match_records_archive a WHERE a.id = m.id)`, false},
+}
+
+// verifyDatabase 输出各表行数并检查外键完整性和孤立记录，返回发现的问题数
+func verifyDatabase() int {
+	log.Println("🔍 正在校验数据库...")
+	problems := 0
+
+	tables, err := listTables()
+	if err != nil {
+		log.Fatalf("查询表列表失败: %v", err)
+	}
+	log.Println("")
+	log.Println("📋 各表行数:")
+	for _, table := range tables {
+		var count int64
+		if err := db.DB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count); err != nil {
+			log.Printf("  ❌ %s: 查询失败: %v", table, err)
+			problems++
+			continue
+		}
+		log.Printf("  - %-32s %d", table, count)
+	}
+
+	log.Println("")
+	log.Println("🔗 外键完整性:")
+	violations, err := foreignKeyViolations()
+	if err != nil {
+		log.Printf("  ❌ 检查失败: %v", err)
+		problems++
+	}
+	for _, v := range violations {
+		log.Printf("  ❌ %s", v)
+	}
+	if err == nil && len(violations) == 0 {
+		log.Println("  ✓ 所有外键约束均已生效")
+	}
+	problems += len(violations)
+
+	log.Println("")
+	log.Println("🧩 对局数据一致性:")
+	cutoff := time.Now().Add(-staleMatchAge)
+	for _, check := range integrityChecks {
+		var args []interface{}
+		if check.stale {
+			args = append(args, cutoff)
+		}
+		var count int64
+		if err := db.DB.QueryRow(check.query, args...).Scan(&count); err != nil {
+			log.Printf("  ❌ %s: 查询失败: %v", check.name, err)
+			problems++
+			continue
+		}
+		if count > 0 {
+			log.Printf("  ❌ %s: %d", check.name, count)
+			problems++
+		} else {
+			log.Printf("  ✓ %s: 0", check.name)
+		}
+	}
+	log.Println("")
+	return problems
+}
+
+// listTables 当前库中的表，分区表只列出父表
+func listTables() ([]string, error) {
+	query := `
+		SELECT c.relname FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		ORDER BY c.relname`
+	if db.IsSQLite() {
+		query = `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`
+	}
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// foreignKeyViolations 外键问题。PostgreSQL 检查以 NOT VALID 添加、尚未校验已有数据的外键，
+// SQLite 通过 foreign_key_check 逐行检查
+func foreignKeyViolations() ([]string, error) {
+	var violations []string
+	if db.IsSQLite() {
+		rows, err := db.DB.Query("PRAGMA foreign_key_check")
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		counts := make(map[string]int)
+		var order []string
+		for rows.Next() {
+			var table, parent string
+			var rowID, fkID sql.NullInt64
+			if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+				return nil, err
+			}
+			key := fmt.Sprintf("%s → %s", table, parent)
+			if counts[key] == 0 {
+				order = append(order, key)
+			}
+			counts[key]++
+		}
+		for _, key := range order {
+			violations = append(violations, fmt.Sprintf("%s: %d 行引用的记录不存在", key, counts[key]))
+		}
+		return violations, rows.Err()
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT conrelid::regclass::text, conname FROM pg_constraint
+		WHERE contype = 'f' AND NOT convalidated AND connamespace = current_schema()::regnamespace
+		ORDER BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, constraint string
+		if err := rows.Scan(&table, &constraint); err != nil {
+			return nil, err
+		}
+		violations = append(violations, fmt.Sprintf("%s.%s: 外键未校验，可能存在引用不存在记录的行", table, constraint))
+	}
+	return violations, rows.Err()
+}
@@ -0,0 +1,355 @@
+// export_data.go
+
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// keyEnv 未通过参数指定假名密钥时读取的环境变量
+const keyEnv = config.EnvPrefix + "_EXPORT_KEY"
+
+// insertBatchSize SQL 格式中每条 INSERT 语句包含的行数
+const insertBatchSize = 200
+
+// unusablePassword 未指定预发环境密码时写入的密码哈希，不会与任何 SHA-256 结果相同，账号无法登录
+const unusablePassword = "!"
+
+// exportTable 导出的表
+type exportTable struct {
+	name    string
+	columns []string
+	filter  string // WHERE 条件，$1 为 -since 指定的起始时间，未指定 -since 时忽略
+	serial  bool   // id 为自增列，导入后需要重置序列
+	events  bool   // 仅在 -events 时导出
+}
+
+// matchFilter 按对局开始时间过滤对局子表
+const matchFilter = "match_id IN (SELECT id FROM match_records WHERE start_time >= $1)"
+
+// exportTables 按外键依赖顺序排列的导出表。基础数据全部导出，便于外键在预发环境中成立
+var exportTables = []exportTable{
+	{name: "characters", serial: true, columns: []string{"id", "name", "description", "max_hp", "speed", "base_attack", "base_defense", "special_ability", "difficulty", "role", "unlockable", "unlock_cost"}},
+	{name: "skills", serial: true, columns: []string{"id", "name", "description", "type", "damage", "cooldown_time", "range", "effect_time", "projectile_speed", "projectile_count", "projectile_spread", "animation_key", "effect_key"}},
+	{name: "character_skills", columns: []string{"character_id", "skill_id", "slot_index", "required_level"}},
+	{name: "game_maps", serial: true, columns: []string{"id", "name", "description", "image_path", "width", "height", "max_players", "hazards"}},
+	{name: "map_modes", columns: []string{"map_id", "mode"}},
+	{name: "players", serial: true, columns: []string{"id", "username", "password", "email", "created_at", "updated_at", "level", "exp", "coins", "gems", "total_kills", "total_deaths", "total_assists", "total_matches", "total_wins"}},
+	{name: "player_characters", columns: []string{"player_id", "character_id", "unlocked", "unlocked_at", "level", "exp", "usage_count", "win_count", "kill_count", "death_count", "last_played_at"}},
+	{name: "player_default_characters", columns: []string{"player_id", "character_id"}},
+	{name: "match_records", filter: "start_time >= $1", columns: []string{"id", "game_mode", "map_id", "start_time", "end_time", "status", "max_players", "current_players", "winning_team", "duration"}},
+	{name: "player_match_records", filter: matchFilter, columns: []string{"match_id", "player_id", "character_id", "team", "score", "kills", "deaths", "assists", "exp_gained", "coins_gained", "mvp", "won", "play_time", "join_time", "leave_time"}},
+	{name: "match_history", serial: true, filter: "join_time >= $1", columns: []string{"id", "player_id", "match_id", "game_mode", "join_time", "match_time", "status", "wait_time"}},
+	{name: "player_stats_windows", filter: "window_start >= $1", columns: []string{"player_id", "stats_window", "window_start", "matches", "wins", "kills", "deaths", "assists", "score", "mvp", "play_time", "refreshed_at"}},
+	{name: "match_position_events", serial: true, events: true, filter: matchFilter, columns: []string{"id", "match_id", "event_type", "player_id", "other_player_id", "character_id", "pos_x", "pos_y", "elapsed"}},
+	{name: "match_records_archive", filter: "start_time >= $1", columns: []string{"id", "game_mode", "map_id", "start_time", "end_time", "status", "max_players", "current_players", "winning_team", "duration"}},
+	{name: "player_match_records_archive", filter: "match_start_time >= $1", columns: []string{"match_id", "player_id", "character_id", "team", "score", "kills", "deaths", "assists", "exp_gained", "coins_gained", "mvp", "won", "play_time", "join_time", "leave_time", "match_start_time"}},
+	{name: "match_position_events_archive", events: true, filter: "match_start_time >= $1", columns: []string{"id", "match_id", "event_type", "player_id", "other_player_id", "character_id", "pos_x", "pos_y", "elapsed", "match_start_time"}},
+}
+
+// pseudonymizer 把个人信息替换为假名。同一密钥下相同的原值得到相同的假名，没有密钥无法还原
+type pseudonymizer struct {
+	key      []byte
+	password string
+}
+
+// pseudonym 原值的 HMAC-SHA256 摘要前 16 个十六进制字符
+func (p *pseudonymizer) pseudonym(kind, value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(kind + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// player 替换 players 表中的用户名、邮箱和密码
+func (p *pseudonymizer) player(columns []string, values []interface{}) {
+	for i, column := range columns {
+		switch column {
+		case "username":
+			values[i] = "player_" + p.pseudonym("username", toString(values[i]))
+		case "email":
+			values[i] = "user_" + p.pseudonym("email", toString(values[i])) + "@example.invalid"
+		case "password":
+			values[i] = p.password
+		}
+	}
+}
+
+// writer 按格式写出一张表
+type writer interface {
+	begin(table exportTable) error
+	row(values []interface{}) error
+	end() error
+}
+
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	outDir := flag.String("out", "export", "输出目录")
+	format := flag.String("format", "csv", "输出格式 (csv: 每张表一个文件, sql: PostgreSQL INSERT 语句)")
+	since := flag.Duration("since", 0, "只导出这段时间内的对局数据，如 720h，0 表示全部")
+	events := flag.Bool("events", false, "同时导出对局位置事件，数据量较大")
+	key := flag.String("key", os.Getenv(keyEnv), "假名密钥，相同密钥的多次导出中同一玩家的假名相同，默认读取环境变量 "+keyEnv)
+	password := flag.String("password", "", "为所有导出账号设置的登录密码，用于预发环境；为空时账号无法登录")
+	flag.Parse()
+
+	if *format != "csv" && *format != "sql" {
+		log.Fatalf("未知的输出格式: %s", *format)
+	}
+
+	p := &pseudonymizer{key: []byte(*key), password: unusablePassword}
+	if *key == "" {
+		p.key = make([]byte, 32)
+		if _, err := rand.Read(p.key); err != nil {
+			log.Fatalf("生成假名密钥失败: %v", err)
+		}
+		log.Println("未指定假名密钥，使用随机密钥，本次导出的假名无法与其他导出关联")
+	}
+	if *password != "" {
+		sum := sha256.Sum256([]byte(*password))
+		p.password = hex.EncodeToString(sum[:])
+	}
+
+	// 加载配置
+	if err := config.LoadConfig(*configPath); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := db.Init(); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatalf("创建输出目录失败: %v", err)
+	}
+
+	var w writer
+	if *format == "csv" {
+		w = &csvWriter{dir: *outDir}
+	} else {
+		f, err := os.Create(filepath.Join(*outDir, "export.sql"))
+		if err != nil {
+			log.Fatalf("创建输出文件失败: %v", err)
+		}
+		sw := &sqlWriter{file: f, out: bufio.NewWriter(f)}
+		defer func() {
+			if err := sw.close(); err != nil {
+				log.Fatalf("写入输出文件失败: %v", err)
+			}
+		}()
+		w = sw
+	}
+
+	var cutoff time.Time
+	if *since > 0 {
+		cutoff = time.Now().Add(-*since)
+	}
+	for _, table := range exportTables {
+		if table.events && !*events {
+			continue
+		}
+		count, err := exportTableRows(w, table, cutoff, p)
+		if err != nil {
+			log.Fatalf("导出 %s 失败: %v", table.name, err)
+		}
+		log.Printf("✓ %s: %d 行", table.name, count)
+	}
+	log.Printf("🎉 导出完成: %s", *outDir)
+}
+
+// exportTableRows 查询一张表并逐行写出
+func exportTableRows(w writer, table exportTable, cutoff time.Time, p *pseudonymizer) (int, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(table.columns, ", "), table.name)
+	var args []interface{}
+	if table.filter != "" && !cutoff.IsZero() {
+		query += " WHERE " + table.filter
+		args = append(args, cutoff)
+	}
+	query += " ORDER BY " + table.columns[0]
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	if err := w.begin(table); err != nil {
+		return 0, err
+	}
+	values := make([]interface{}, len(table.columns))
+	pointers := make([]interface{}, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return count, err
+		}
+		if table.name == "players" {
+			p.player(table.columns, values)
+		}
+		if err := w.row(values); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+	return count, w.end()
+}
+
+// toString 把查询得到的值转换为字符串
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvWriter 每张表写出一个带表头的 CSV 文件，空值写为空字符串
+type csvWriter struct {
+	dir    string
+	file   *os.File
+	out    *csv.Writer
+	record []string
+}
+
+func (c *csvWriter) begin(table exportTable) error {
+	f, err := os.Create(filepath.Join(c.dir, table.name+".csv"))
+	if err != nil {
+		return err
+	}
+	c.file = f
+	c.out = csv.NewWriter(f)
+	c.record = make([]string, len(table.columns))
+	return c.out.Write(table.columns)
+}
+
+func (c *csvWriter) row(values []interface{}) error {
+	for i, v := range values {
+		c.record[i] = toString(v)
+	}
+	return c.out.Write(c.record)
+}
+
+func (c *csvWriter) end() error {
+	c.out.Flush()
+	if err := c.out.Error(); err != nil {
+		c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+// sqlWriter 写出可用 psql 导入的 INSERT 语句，整个文件在一个事务中执行
+type sqlWriter struct {
+	file    *os.File
+	out     *bufio.Writer
+	table   exportTable
+	pending int
+	serials []string
+	started bool
+}
+
+func (s *sqlWriter) begin(table exportTable) error {
+	if !s.started {
+		s.started = true
+		fmt.Fprintf(s.out, "-- PixelStorm 匿名化导出 %s\nBEGIN;\n", time.Now().Format(time.RFC3339))
+	}
+	s.table = table
+	s.pending = 0
+	if table.serial {
+		s.serials = append(s.serials, table.name)
+	}
+	_, err := fmt.Fprintf(s.out, "\n-- %s\n", table.name)
+	return err
+}
+
+func (s *sqlWriter) row(values []interface{}) error {
+	if s.pending == 0 {
+		fmt.Fprintf(s.out, "INSERT INTO %s (%s) VALUES\n", s.table.name, strings.Join(s.table.columns, ", "))
+	} else {
+		io.WriteString(s.out, ",\n")
+	}
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literals[i] = sqlLiteral(v)
+	}
+	_, err := fmt.Fprintf(s.out, "(%s)", strings.Join(literals, ", "))
+
+	s.pending++
+	if s.pending == insertBatchSize {
+		return s.end()
+	}
+	return err
+}
+
+func (s *sqlWriter) end() error {
+	if s.pending == 0 {
+		return nil
+	}
+	s.pending = 0
+	_, err := io.WriteString(s.out, ";\n")
+	return err
+}
+
+// close 重置自增序列并提交事务
+func (s *sqlWriter) close() error {
+	if s.started {
+		io.WriteString(s.out, "\n")
+		for _, table := range s.serials {
+			fmt.Fprintf(s.out, "SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false);\n", table, table)
+		}
+		io.WriteString(s.out, "COMMIT;\n")
+	}
+	if err := s.out.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
+
+// sqlLiteral 把查询得到的值转换为 SQL 字面量
+func sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return "'" + strings.ReplaceAll(toString(v), "'", "''") + "'"
+	}
+}
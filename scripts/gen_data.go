@@ -0,0 +1,720 @@
+// gen_data.go
+
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/internal/progression"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// 单条 INSERT 语句的参数上限。SQLite 驱动绑定参数的耗时随语句参数数平方增长，使用较短的语句
+const (
+	postgresMaxParams = 30000
+	sqliteMaxParams   = 150
+)
+
+// modeWeights 各模式的对局占比，人数与匹配服务各模式的开局人数一致
+var modeWeights = []struct {
+	mode    models.GameMode
+	weight  float64
+	players int
+}{
+	{models.DeathMatch, 0.40, 4},
+	{models.TeamDeathMatch, 0.30, 6},
+	{models.CapturePoint, 0.15, 8},
+	{models.FlagCapture, 0.15, 6},
+}
+
+// hourWeights 各小时开局数的相对比例，凌晨最少，晚间高峰最多
+var hourWeights = []float64{3, 2, 1, 1, 1, 1, 1, 2, 3, 4, 5, 5, 6, 6, 6, 6, 7, 8, 9, 10, 11, 11, 9, 6}
+
+// genConfig 生成参数
+type genConfig struct {
+	players  int
+	matches  int
+	days     int
+	prefix   string
+	password string
+	batch    int
+	workers  int
+	seed     int64
+}
+
+// genPlayer 生成的玩家及其累计战绩
+type genPlayer struct {
+	id        int64
+	username  string
+	createdAt time.Time
+	skill     float64 // 水平，越高击杀越多、死亡越少
+	main      int     // 常用角色ID
+	owned     []int   // 拥有的角色ID，第一个为常用角色
+
+	kills, deaths, assists, matches, wins int
+	exp, coins                            int
+}
+
+// charKey 玩家角色
+type charKey struct {
+	playerID    int64
+	characterID int
+}
+
+// charStats 玩家角色的使用统计和成长
+type charStats struct {
+	usage, wins, kills, deaths int
+	level, exp                 int
+	lastPlayed                 time.Time
+}
+
+// matchBatch 一次写入的对局和玩家对局记录
+type matchBatch struct {
+	matches [][]interface{}
+	records [][]interface{}
+}
+
+// generator 按分布生成数据，只在一个协程中使用
+type generator struct {
+	cfg        genConfig
+	rng        *rand.Rand
+	curve      *progression.Curve
+	players    []*genPlayer
+	activity   []float64 // 玩家活跃度的累积权重，用于按活跃度抽取玩家
+	characters []int
+	maps       map[models.GameMode][]int
+	charStats  map[charKey]*charStats
+	start      time.Time
+	now        time.Time
+}
+
+// main 生成大量玩家和对局记录，用于上线前验证排行榜、战绩分页、索引和统计刷新的性能，用法:
+//
+//	go run scripts/gen_data.go -players=50000 -matches=500000
+//	go run scripts/gen_data.go -clean
+//
+// 玩家的活跃度呈长尾分布，少数玩家贡献大部分对局；水平服从正态分布，决定击杀和死亡数；
+// 开局时间按小时分布集中在晚间。生成的玩家和对局以 -prefix 开头，可用 -clean 删除
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	var cfg genConfig
+	flag.IntVar(&cfg.players, "players", 50000, "生成的玩家数")
+	flag.IntVar(&cfg.matches, "matches", 500000, "生成的对局数，每局4到8名玩家")
+	flag.IntVar(&cfg.days, "days", 90, "对局分布在最近多少天内")
+	flag.StringVar(&cfg.prefix, "prefix", "gen_", "生成的用户名和对局ID的前缀")
+	flag.StringVar(&cfg.password, "password", "password123", "生成的玩家的登录密码")
+	flag.IntVar(&cfg.batch, "batch", 500, "每个事务写入的对局数")
+	flag.IntVar(&cfg.workers, "workers", 4, "并发写入的连接数，SQLite 固定为1")
+	flag.Int64Var(&cfg.seed, "seed", 1, "随机数种子，相同的种子生成相同分布的数据")
+	clean := flag.Bool("clean", false, "删除以 -prefix 开头的玩家和对局后退出")
+	flag.Parse()
+
+	if cfg.prefix == "" {
+		log.Fatal("-prefix 不能为空")
+	}
+	if cfg.players < 8 || cfg.matches < 1 || cfg.days < 1 || cfg.batch < 1 || cfg.workers < 1 {
+		log.Fatal("-players 至少为8，-matches、-days、-batch、-workers 至少为1")
+	}
+
+	// 加载配置
+	if err := config.LoadConfig(*configPath); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := db.Init(); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	if *clean {
+		if err := cleanGenerated(cfg.prefix); err != nil {
+			log.Fatalf("清理失败: %v", err)
+		}
+		return
+	}
+	if db.IsSQLite() {
+		cfg.workers = 1
+	}
+
+	var existing int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM players WHERE username LIKE $1 ESCAPE '\'`,
+		likePrefix(cfg.prefix)).Scan(&existing); err != nil {
+		log.Fatalf("查询已有数据失败: %v", err)
+	}
+	if existing > 0 {
+		log.Fatalf("已存在 %d 个以 %s 开头的玩家，请先加 -clean 清理或更换 -prefix", existing, cfg.prefix)
+	}
+
+	g, err := newGenerator(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	start := time.Now()
+	if err := g.createPlayers(); err != nil {
+		log.Fatalf("生成玩家失败: %v", err)
+	}
+	log.Printf("✓ 玩家: %d (%v)", len(g.players), time.Since(start).Round(time.Millisecond))
+
+	matchStart := time.Now()
+	records, err := g.generateMatches()
+	if err != nil {
+		log.Fatalf("生成对局失败: %v", err)
+	}
+	elapsed := time.Since(matchStart)
+	log.Printf("✓ 对局: %d, 玩家对局记录: %d (%v, %.0f 条/秒)", cfg.matches, records,
+		elapsed.Round(time.Millisecond), float64(cfg.matches+records)/elapsed.Seconds())
+
+	statsStart := time.Now()
+	if err := g.saveTotals(); err != nil {
+		log.Fatalf("写入累计战绩失败: %v", err)
+	}
+	log.Printf("✓ 玩家累计战绩和角色统计 (%v)", time.Since(statsStart).Round(time.Millisecond))
+
+	if err := analyze(); err != nil {
+		log.Printf("更新表统计信息失败: %v", err)
+	}
+	log.Printf("🎉 生成完成，共耗时 %v", time.Since(start).Round(time.Second))
+	log.Println("Redis 排行榜和时间窗口战绩在下次定时刷新时更新，也可调用 POST /stats/leaderboard/refresh 立即刷新排行榜")
+}
+
+// newGenerator 读取角色和地图，生成前需已写入种子数据
+func newGenerator(cfg genConfig) (*generator, error) {
+	now := time.Now().UTC()
+	g := &generator{
+		cfg:       cfg,
+		rng:       rand.New(rand.NewSource(cfg.seed)),
+		curve:     progression.NewCurve(config.GlobalConfig.CharacterLevel),
+		maps:      make(map[models.GameMode][]int),
+		charStats: make(map[charKey]*charStats),
+		start:     now.AddDate(0, 0, -cfg.days).Truncate(24 * time.Hour),
+		now:       now,
+	}
+
+	var err error
+	if g.characters, err = queryInts(`SELECT id FROM characters ORDER BY id`); err != nil {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	allMaps, err := queryInts(`SELECT id FROM game_maps ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("查询地图失败: %w", err)
+	}
+	if len(g.characters) == 0 || len(allMaps) == 0 {
+		return nil, fmt.Errorf("没有角色或地图数据，请先运行 scripts/init_data.go")
+	}
+
+	// 按地图支持的模式选择地图，没有支持该模式的地图时使用任意地图
+	rows, err := db.DB.Query(`SELECT map_id, mode FROM map_modes`)
+	if err != nil {
+		return nil, fmt.Errorf("查询地图模式失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var mapID int
+		var mode models.GameMode
+		if err := rows.Scan(&mapID, &mode); err != nil {
+			return nil, err
+		}
+		g.maps[mode] = append(g.maps[mode], mapID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, m := range modeWeights {
+		if len(g.maps[m.mode]) == 0 {
+			g.maps[m.mode] = allMaps
+		}
+	}
+	return g, nil
+}
+
+// createPlayers 生成并写入玩家，之后按用户名取回玩家ID
+func (g *generator) createPlayers() error {
+	password := fmt.Sprintf("%x", sha256.Sum256([]byte(g.cfg.password)))
+
+	// 角色受欢迎程度递减，常用角色按此抽取
+	popularity := make([]float64, len(g.characters))
+	for i := range popularity {
+		popularity[i] = 1 / float64(i+1)
+	}
+
+	byName := make(map[string]*genPlayer, g.cfg.players)
+	rows := make([][]interface{}, 0, g.cfg.players)
+	g.activity = make([]float64, 0, g.cfg.players)
+	total := 0.0
+	for i := 0; i < g.cfg.players; i++ {
+		p := &genPlayer{
+			username:  fmt.Sprintf("%s%07d", g.cfg.prefix, i+1),
+			createdAt: g.start.Add(-time.Duration(g.rng.Int63n(int64(180 * 24 * time.Hour)))),
+			skill:     clamp(g.rng.NormFloat64()*0.25+1, 0.3, 2),
+		}
+		p.main = g.characters[weightedIndex(g.rng, popularity)]
+		p.owned = []int{p.main}
+		for _, id := range g.characters {
+			if id != p.main && g.rng.Float64() < 0.3 {
+				p.owned = append(p.owned, id)
+			}
+		}
+		g.players = append(g.players, p)
+		byName[p.username] = p
+
+		// 活跃度服从对数正态分布：大多数玩家偶尔游玩，少数玩家贡献大量对局
+		total += math.Exp(g.rng.NormFloat64() * 1.2)
+		g.activity = append(g.activity, total)
+
+		rows = append(rows, []interface{}{p.username, password, p.username + "@example.com", p.createdAt, p.createdAt})
+	}
+
+	err := db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		return insertRows(tx, "players", []string{"username", "password", "email", "created_at", "updated_at"}, rows)
+	})
+	if err != nil {
+		return err
+	}
+
+	result, err := db.DB.Query(`SELECT id, username FROM players WHERE username LIKE $1 ESCAPE '\'`, likePrefix(g.cfg.prefix))
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+	for result.Next() {
+		var id int64
+		var username string
+		if err := result.Scan(&id, &username); err != nil {
+			return err
+		}
+		if p, ok := byName[username]; ok {
+			p.id = id
+		}
+	}
+	return result.Err()
+}
+
+// generateMatches 生成对局并由多个协程并发写入，返回写入的玩家对局记录数
+func (g *generator) generateMatches() (int, error) {
+	batches := make(chan matchBatch, g.cfg.workers)
+	var written atomic.Int64
+	var firstErr error
+	var errOnce sync.Once
+	var failed atomic.Bool
+
+	var wg sync.WaitGroup
+	for i := 0; i < g.cfg.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if failed.Load() {
+					continue
+				}
+				err := db.RunInTx(db.DB, func(tx *sql.Tx) error {
+					if err := insertRows(tx, "match_records", matchColumns, batch.matches); err != nil {
+						return err
+					}
+					return insertRows(tx, "player_match_records", recordColumns, batch.records)
+				})
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					failed.Store(true)
+					continue
+				}
+				written.Add(int64(len(batch.matches)))
+			}
+		}()
+	}
+
+	// 定期输出进度
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				n := written.Load()
+				log.Printf("  已写入 %d/%d 场对局 (%.0f%%)", n, g.cfg.matches, 100*float64(n)/float64(g.cfg.matches))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	records := 0
+	var batch matchBatch
+	for i := 0; i < g.cfg.matches && !failed.Load(); i++ {
+		match, players := g.match()
+		batch.matches = append(batch.matches, match)
+		batch.records = append(batch.records, players...)
+		records += len(players)
+		if len(batch.matches) >= g.cfg.batch {
+			batches <- batch
+			batch = matchBatch{}
+		}
+	}
+	if len(batch.matches) > 0 && !failed.Load() {
+		batches <- batch
+	}
+	close(batches)
+	wg.Wait()
+	close(done)
+
+	return records, firstErr
+}
+
+var (
+	matchColumns = []string{"id", "game_mode", "map_id", "start_time", "end_time", "status",
+		"max_players", "current_players", "winning_team", "duration"}
+	recordColumns = []string{"match_id", "player_id", "character_id", "team", "score",
+		"kills", "deaths", "assists", "exp_gained", "coins_gained",
+		"mvp", "won", "play_time", "join_time", "leave_time"}
+)
+
+// match 生成一场已结束的对局，同时累加玩家和角色的统计
+func (g *generator) match() ([]interface{}, [][]interface{}) {
+	weights := make([]float64, len(modeWeights))
+	for i, m := range modeWeights {
+		weights[i] = m.weight
+	}
+	m := modeWeights[weightedIndex(g.rng, weights)]
+	maps := g.maps[m.mode]
+
+	id := g.cfg.prefix + uuid.Must(uuid.NewRandomFromReader(g.rng)).String()
+	startTime := g.startTime()
+	duration := int(clamp(g.rng.NormFloat64()*60+300, 60, 600))
+	endTime := startTime.Add(time.Duration(duration) * time.Second)
+	minutes := float64(duration) / 60
+
+	players := g.pickPlayers(m.players)
+	results := make([]models.PlayerMatchRecord, len(players))
+	teamScores := make(map[models.Team]int)
+	for i, p := range players {
+		team := models.TeamNone
+		if m.mode.IsTeamMode() {
+			team = models.TeamRed
+			if i%2 == 1 {
+				team = models.TeamBlue
+			}
+		}
+
+		characterID := p.main
+		if g.rng.Float64() > 0.7 {
+			characterID = p.owned[g.rng.Intn(len(p.owned))]
+		}
+
+		kills := poisson(g.rng, minutes*0.8*p.skill)
+		assists := 0
+		if m.mode.IsTeamMode() {
+			assists = poisson(g.rng, minutes*0.5)
+		}
+		results[i] = models.PlayerMatchRecord{
+			MatchID:     id,
+			PlayerID:    p.id,
+			CharacterID: characterID,
+			Team:        int(team),
+			Score:       kills,
+			Kills:       kills,
+			Deaths:      poisson(g.rng, minutes*0.8/p.skill),
+			Assists:     assists,
+			PlayTime:    duration,
+			JoinTime:    startTime,
+			LeaveTime:   endTime,
+		}
+		teamScores[team] += kills
+	}
+
+	winningTeam := decideWinner(m.mode, results, teamScores)
+	match := []interface{}{id, string(m.mode), maps[g.rng.Intn(len(maps))], startTime, endTime,
+		string(models.RoomEnded), m.players, m.players, winningTeam, duration}
+
+	rows := make([][]interface{}, len(results))
+	for i := range results {
+		r := &results[i]
+		r.ExpGained = progression.MatchExp(r)
+		r.CoinsGained = matchCoins(r)
+		g.accumulate(players[i], r)
+		rows[i] = []interface{}{r.MatchID, r.PlayerID, r.CharacterID, r.Team, r.Score,
+			r.Kills, r.Deaths, r.Assists, r.ExpGained, r.CoinsGained,
+			r.MVP, r.Won, r.PlayTime, r.JoinTime, r.LeaveTime}
+	}
+	return match, rows
+}
+
+// startTime 在统计范围内按小时分布抽取开局时间
+func (g *generator) startTime() time.Time {
+	for {
+		day := g.start.AddDate(0, 0, g.rng.Intn(g.cfg.days+1))
+		t := day.Add(time.Duration(weightedIndex(g.rng, hourWeights))*time.Hour +
+			time.Duration(g.rng.Intn(3600))*time.Second)
+		if t.Before(g.now.Add(-10 * time.Minute)) {
+			return t
+		}
+	}
+}
+
+// pickPlayers 按活跃度抽取 n 名不同的玩家
+func (g *generator) pickPlayers(n int) []*genPlayer {
+	total := g.activity[len(g.activity)-1]
+	picked := make([]*genPlayer, 0, n)
+	seen := make(map[int]bool, n)
+	for len(picked) < n {
+		i := sort.SearchFloat64s(g.activity, g.rng.Float64()*total)
+		if i >= len(g.players) || seen[i] {
+			continue
+		}
+		seen[i] = true
+		picked = append(picked, g.players[i])
+	}
+	return picked
+}
+
+// decideWinner 标记胜者和MVP，返回获胜队伍：团队模式按队伍总分，平局没有胜者；个人模式得分最高者获胜
+func decideWinner(mode models.GameMode, results []models.PlayerMatchRecord, teamScores map[models.Team]int) int {
+	best := 0
+	for i := range results {
+		if results[i].Score > results[best].Score ||
+			results[i].Score == results[best].Score && results[i].Deaths < results[best].Deaths {
+			best = i
+		}
+	}
+	results[best].MVP = true
+
+	if !mode.IsTeamMode() {
+		results[best].Won = true
+		return int(models.TeamNone)
+	}
+	red, blue := teamScores[models.TeamRed], teamScores[models.TeamBlue]
+	if red == blue {
+		return int(models.TeamNone)
+	}
+	winner := models.TeamRed
+	if blue > red {
+		winner = models.TeamBlue
+	}
+	for i := range results {
+		results[i].Won = results[i].Team == int(winner)
+	}
+	return int(winner)
+}
+
+// matchCoins 对局金币奖励，与 internal/game 结算时的规则一致
+func matchCoins(r *models.PlayerMatchRecord) int {
+	coins := 10 + r.Kills*2
+	if r.Won {
+		coins += 20
+	}
+	if r.MVP {
+		coins += 10
+	}
+	return coins
+}
+
+// accumulate 累加玩家总战绩和所用角色的统计，角色经验按成长曲线升级
+func (g *generator) accumulate(p *genPlayer, r *models.PlayerMatchRecord) {
+	won := 0
+	if r.Won {
+		won = 1
+	}
+	p.kills += r.Kills
+	p.deaths += r.Deaths
+	p.assists += r.Assists
+	p.matches++
+	p.wins += won
+	p.exp += r.ExpGained
+	p.coins += r.CoinsGained
+
+	key := charKey{p.id, r.CharacterID}
+	s, ok := g.charStats[key]
+	if !ok {
+		s = &charStats{level: 1}
+		g.charStats[key] = s
+	}
+	s.usage++
+	s.wins += won
+	s.kills += r.Kills
+	s.deaths += r.Deaths
+	s.level, s.exp = g.curve.Apply(s.level, s.exp, r.ExpGained)
+	if r.LeaveTime.After(s.lastPlayed) {
+		s.lastPlayed = r.LeaveTime
+	}
+}
+
+// saveTotals 写入玩家拥有的角色、默认角色和累计战绩
+func (g *generator) saveTotals() error {
+	var characters, defaults [][]interface{}
+	for _, p := range g.players {
+		for _, id := range p.owned {
+			row := []interface{}{p.id, id, true, p.createdAt, 1, 0, 0, 0, 0, 0, nil}
+			if s, ok := g.charStats[charKey{p.id, id}]; ok {
+				row = []interface{}{p.id, id, true, p.createdAt, s.level, s.exp,
+					s.usage, s.wins, s.kills, s.deaths, s.lastPlayed}
+			}
+			characters = append(characters, row)
+		}
+		defaults = append(defaults, []interface{}{p.id, p.main})
+	}
+
+	err := db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		if err := insertRows(tx, "player_characters", []string{"player_id", "character_id", "unlocked", "unlocked_at",
+			"level", "exp", "usage_count", "win_count", "kill_count", "death_count", "last_played_at"}, characters); err != nil {
+			return err
+		}
+		return insertRows(tx, "player_default_characters", []string{"player_id", "character_id"}, defaults)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 玩家等级按累计经验沿用角色成长曲线估算
+	return db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(`
+			UPDATE players SET
+				total_kills = $2, total_deaths = $3, total_assists = $4,
+				total_matches = $5, total_wins = $6, level = $7, exp = $8, coins = $9
+			WHERE id = $1
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, p := range g.players {
+			level, _ := g.curve.Apply(1, 0, p.exp)
+			if _, err := stmt.Exec(p.id, p.kills, p.deaths, p.assists, p.matches, p.wins, level, p.exp, p.coins); err != nil {
+				return fmt.Errorf("更新玩家 %d 失败: %w", p.id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// insertRows 分批执行多行 INSERT，每条语句的参数数不超过所用数据库的上限
+func insertRows(tx *sql.Tx, table string, columns []string, rows [][]interface{}) error {
+	maxParams := postgresMaxParams
+	if db.IsSQLite() {
+		maxParams = sqliteMaxParams
+	}
+	perStatement := max(maxParams/len(columns), 1)
+	for start := 0; start < len(rows); start += perStatement {
+		chunk := rows[start:min(start+perStatement, len(rows))]
+
+		var b strings.Builder
+		args := make([]interface{}, 0, len(chunk)*len(columns))
+		fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+		for i, row := range chunk {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('(')
+			for j, v := range row {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				args = append(args, v)
+				fmt.Fprintf(&b, "$%d", len(args))
+			}
+			b.WriteByte(')')
+		}
+		if _, err := tx.Exec(b.String(), args...); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// cleanGenerated 删除生成的对局和玩家，玩家的对局记录、角色等随玩家级联删除
+func cleanGenerated(prefix string) error {
+	pattern := likePrefix(prefix)
+	return db.RunInTx(db.DB, func(tx *sql.Tx) error {
+		matches, err := tx.Exec(`DELETE FROM match_records WHERE id LIKE $1 ESCAPE '\'`, pattern)
+		if err != nil {
+			return fmt.Errorf("删除对局失败: %w", err)
+		}
+		players, err := tx.Exec(`DELETE FROM players WHERE username LIKE $1 ESCAPE '\'`, pattern)
+		if err != nil {
+			return fmt.Errorf("删除玩家失败: %w", err)
+		}
+		m, _ := matches.RowsAffected()
+		p, _ := players.RowsAffected()
+		log.Printf("✓ 已删除 %d 场对局、%d 个玩家", m, p)
+		return nil
+	})
+}
+
+// analyze 更新表统计信息，使查询计划反映生成后的数据量
+func analyze() error {
+	if db.IsSQLite() {
+		_, err := db.DB.Exec(`ANALYZE`)
+		return err
+	}
+	_, err := db.DB.Exec(`ANALYZE players, player_characters, match_records, player_match_records`)
+	return err
+}
+
+// queryInts 查询单列整数
+func queryInts(query string) ([]int, error) {
+	rows, err := db.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var values []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// likePrefix 前缀匹配的 LIKE 模式，转义前缀中的通配符
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// weightedIndex 按权重随机抽取下标
+func weightedIndex(rng *rand.Rand, weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
+
+// poisson 泊松分布随机数，用于每局的击杀、死亡和助攻数
+func poisson(rng *rand.Rand, lambda float64) int {
+	limit := math.Exp(-lambda)
+	n, p := 0, rng.Float64()
+	for p > limit {
+		n++
+		p *= rng.Float64()
+	}
+	return n
+}
+
+// clamp 把数值限制在区间内
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,119 @@
+// import_balance.go
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/seed"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+// main 从策划维护的表格导出的 CSV 导入技能、角色和技能槽。默认只输出与数据库的差异，确认后加 -apply 写入，用法:
+//
+//	go run scripts/import_balance.go -skills=skills.csv -characters=characters.csv -slots=slots.csv
+//	go run scripts/import_balance.go -characters=characters.csv -apply
+func main() {
+	configPath := flag.String("config", "config/config.yaml", "配置文件路径")
+	skillsPath := flag.String("skills", "", "技能表 CSV，列名与 data/skills.yaml 的字段一致，必须包含 name")
+	charactersPath := flag.String("characters", "", "角色表 CSV，列名与 data/characters.yaml 的字段一致（不含 skills），必须包含 name")
+	slotsPath := flag.String("slots", "", "技能槽表 CSV，列为 character、slot、skill")
+	apply := flag.Bool("apply", false, "写入数据库，否则只输出差异")
+	flag.Parse()
+
+	sheets := []struct {
+		sheet seed.Sheet
+		path  string
+	}{
+		{seed.SheetSkills, *skillsPath},
+		{seed.SheetCharacters, *charactersPath},
+		{seed.SheetSlots, *slotsPath},
+	}
+	if *skillsPath == "" && *charactersPath == "" && *slotsPath == "" {
+		log.Fatal("请至少指定 -skills、-characters、-slots 中的一个")
+	}
+
+	// 加载配置
+	if err := config.LoadConfig(*configPath); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
+	// 初始化数据库连接
+	if err := db.Init(); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	before, err := seed.Current(db.DB)
+	if err != nil {
+		log.Fatalf("读取当前数据失败: %v", err)
+	}
+	// 合并会修改记录，另外读取一份作为合并的基础
+	after, err := seed.Current(db.DB)
+	if err != nil {
+		log.Fatalf("读取当前数据失败: %v", err)
+	}
+
+	// 依次合并表格，技能槽表可以引用同一次导入的技能表和角色表中新增的记录
+	for _, s := range sheets {
+		if s.path == "" {
+			continue
+		}
+		f, err := os.Open(s.path)
+		if err != nil {
+			log.Fatalf("打开 %s 失败: %v", s.path, err)
+		}
+		err = seed.MergeCSV(after, s.sheet, f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", s.path, err)
+		}
+	}
+	if err := after.Validate(); err != nil {
+		log.Fatalf("导入后的数据校验失败:\n%v", err)
+	}
+
+	changes, changed := seed.Diff(before, after)
+	if len(changes) == 0 {
+		log.Println("与数据库中的数据一致，无需导入")
+		return
+	}
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	log.Printf("共 %d 条记录有变化", len(changes))
+
+	if !*apply {
+		log.Println("未写入数据库，确认差异后加 -apply 参数导入")
+		return
+	}
+	for _, kind := range []seed.Kind{seed.KindSkills, seed.KindCharacters} {
+		result, err := seed.Apply(db.DB, changed, kind, seed.ModeUpsert)
+		if err != nil {
+			log.Fatalf("写入 %s 失败: %v", kind, err)
+		}
+		log.Printf("✓ %s: 新增 %d, 更新 %d", kind, result.Inserted, result.Updated)
+	}
+	log.Println("🎉 导入完成")
+	notifyReload()
+}
+
+// notifyReload 通过 Redis 通知运行中的服务重新加载静态游戏数据
+func notifyReload() {
+	if err := db.InitRedis(); err != nil {
+		log.Printf("连接Redis失败，请调用 POST /admin/gamedata/reload 使运行中的服务重新加载: %v", err)
+		return
+	}
+	defer db.CloseRedis()
+	if err := db.RedisClient.Publish(context.Background(), gamedata.InvalidateChannel, "import_balance").Err(); err != nil {
+		log.Printf("发布重新加载通知失败，请调用 POST /admin/gamedata/reload: %v", err)
+		return
+	}
+	log.Println("✓ 已通知运行中的服务重新加载静态游戏数据")
+}
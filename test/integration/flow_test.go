@@ -0,0 +1,260 @@
+// flow_test.go
+
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jacl-coder/PixelStorm-Server/internal/game"
+	"github.com/jacl-coder/PixelStorm-Server/internal/models"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/client"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+)
+
+const (
+	// flowPlayers 死亡竞赛开局需要的人数
+	flowPlayers = 4
+	// flowTimeout 单个步骤的最长等待时间
+	flowTimeout = 20 * time.Second
+	// flowPassword 测试玩家的密码
+	flowPassword = "integration-pass"
+)
+
+// player 测试中的一个玩家
+type player struct {
+	name string
+	api  *client.Client
+	game *client.GameClient
+
+	matchFound chan game.MatchFoundPayload
+	roomStates chan models.Room
+	gameEnd    chan models.MatchResult
+}
+
+// TestMatchFlow 注册 → 登录 → 加入匹配 → 匹配成功 → 进入房间并准备 → 对局结束 → 结果入库
+func TestMatchFlow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	characterID := seededCharacterID(t)
+	suffix := time.Now().UnixNano() % 1_000_000
+
+	// 注册并登录，连接游戏服务器
+	players := make([]*player, flowPlayers)
+	for i := range players {
+		p := newPlayer(fmt.Sprintf("it_%d_%d", suffix, i))
+		players[i] = p
+		p.register(ctx, t)
+		p.grantCharacter(t, characterID)
+		p.connect(ctx, t)
+	}
+
+	// 加入死亡竞赛队列，人数凑齐后所有玩家收到同一个房间
+	for _, p := range players {
+		if err := p.api.JoinQueue(ctx, models.DeathMatch, characterID); err != nil {
+			t.Fatalf("%s 加入匹配失败: %v", p.name, err)
+		}
+	}
+	var roomID string
+	for _, p := range players {
+		found := receive(t, p.matchFound, p.name+" 等待 match_found")
+		if found.Mode != models.DeathMatch {
+			t.Fatalf("%s 匹配到的模式为 %s，应为 %s", p.name, found.Mode, models.DeathMatch)
+		}
+		if roomID == "" {
+			roomID = found.RoomID
+		} else if found.RoomID != roomID {
+			t.Fatalf("%s 匹配到房间 %s，其他玩家为 %s", p.name, found.RoomID, roomID)
+		}
+	}
+	room, ok := env.gameServer.GetRoom(roomID)
+	if !ok {
+		t.Fatalf("游戏服务器中不存在匹配创建的房间 %s", roomID)
+	}
+
+	// 进入房间，全部准备后开始对局
+	for _, p := range players {
+		send(t, p, "join_room", game.JoinRoomPayload{RoomID: roomID, CharacterID: characterID})
+	}
+	waitRoom(t, players[0], func(r models.Room) bool { return len(r.Players) == flowPlayers })
+	for _, p := range players {
+		send(t, p, "ready", nil)
+	}
+	waitFor(t, "对局开始", func() bool { return room.State().Status == models.RoomPlaying })
+
+	// 提前结束对局，所有玩家收到结果
+	room.ForceEnd()
+	for _, p := range players {
+		result := receive(t, p.gameEnd, p.name+" 等待 game_end")
+		if result.Match.ID != roomID || len(result.Players) != flowPlayers {
+			t.Fatalf("%s 收到的对局结果不完整: 对局 %s, %d 名玩家", p.name, result.Match.ID, len(result.Players))
+		}
+	}
+
+	// 对局结果异步写入数据库
+	waitFor(t, "对局结果入库", func() bool {
+		var status string
+		var count int
+		err := db.DB.QueryRow(`
+			SELECT m.status, (SELECT COUNT(*) FROM player_match_records WHERE match_id = m.id)
+			FROM match_records m WHERE m.id = $1
+		`, roomID).Scan(&status, &count)
+		return err == nil && status == string(models.RoomEnded) && count == flowPlayers
+	})
+
+	// 战绩接口能查到本局
+	for _, p := range players {
+		page, err := p.api.PlayerMatches(ctx, p.api.PlayerID(), 10, 0)
+		if err != nil {
+			t.Fatalf("%s 查询战绩失败: %v", p.name, err)
+		}
+		if !containsMatch(page.Matches, roomID) {
+			t.Fatalf("%s 的战绩中没有对局 %s", p.name, roomID)
+		}
+	}
+}
+
+// newPlayer 创建玩家，消息通道在连接前注册
+func newPlayer(name string) *player {
+	return &player{
+		name:       name,
+		api:        client.New(env.gatewayURL),
+		matchFound: make(chan game.MatchFoundPayload, 1),
+		roomStates: make(chan models.Room, 32),
+		gameEnd:    make(chan models.MatchResult, 1),
+	}
+}
+
+// register 注册后重新登录，后续请求使用登录得到的会话
+func (p *player) register(ctx context.Context, t *testing.T) {
+	t.Helper()
+	if _, err := p.api.Register(ctx, p.name, flowPassword, p.name+"@example.com"); err != nil {
+		t.Fatalf("%s 注册失败: %v", p.name, err)
+	}
+	session, err := p.api.Login(ctx, p.name, flowPassword)
+	if err != nil {
+		t.Fatalf("%s 登录失败: %v", p.name, err)
+	}
+	if session.PlayerID <= 0 || session.Token == "" {
+		t.Fatalf("%s 登录返回的会话无效: %+v", p.name, session)
+	}
+}
+
+// grantCharacter 解锁匹配使用的角色，新注册的账号没有角色
+func (p *player) grantCharacter(t *testing.T, characterID int) {
+	t.Helper()
+	_, err := db.DB.Exec(`
+		INSERT INTO player_characters (player_id, character_id, unlocked, unlocked_at)
+		VALUES ($1, $2, true, NOW())
+		ON CONFLICT (player_id, character_id) DO NOTHING
+	`, p.api.PlayerID(), characterID)
+	if err != nil {
+		t.Fatalf("%s 解锁角色失败: %v", p.name, err)
+	}
+}
+
+// connect 连接游戏服务器并转发关心的消息，测试结束时断开
+func (p *player) connect(ctx context.Context, t *testing.T) {
+	t.Helper()
+	p.game = client.NewGameClient(p.api.GameConfig(env.gameWSURL))
+	p.game.On("match_found", forward(t, p.matchFound))
+	p.game.On("room_state", forward(t, p.roomStates))
+	p.game.On("game_end", forward(t, p.gameEnd))
+	p.game.On("error", func(msg *client.Message) {
+		t.Errorf("%s 收到错误消息: %s", p.name, msg.Payload)
+	})
+	if err := p.game.Connect(ctx); err != nil {
+		t.Fatalf("%s 连接游戏服务器失败: %v", p.name, err)
+	}
+	t.Cleanup(func() { p.game.Close() })
+}
+
+// forward 把消息载荷解析后发送到通道，通道已满时丢弃
+func forward[T any](t *testing.T, ch chan T) client.Handler {
+	return func(msg *client.Message) {
+		var v T
+		if err := msg.Decode(&v); err != nil {
+			t.Errorf("解析 %s 消息失败: %v", msg.Type, err)
+			return
+		}
+		select {
+		case ch <- v:
+		default:
+		}
+	}
+}
+
+// send 发送游戏消息
+func send(t *testing.T, p *player, msgType string, payload interface{}) {
+	t.Helper()
+	if err := p.game.Send(msgType, payload); err != nil {
+		t.Fatalf("%s 发送 %s 失败: %v", p.name, msgType, err)
+	}
+}
+
+// receive 等待通道中的下一条消息
+func receive[T any](t *testing.T, ch chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(flowTimeout):
+		t.Fatalf("%s超时", what)
+		var zero T
+		return zero
+	}
+}
+
+// waitRoom 等待玩家收到满足条件的房间状态
+func waitRoom(t *testing.T, p *player, cond func(models.Room) bool) {
+	t.Helper()
+	deadline := time.After(flowTimeout)
+	for {
+		select {
+		case r := <-p.roomStates:
+			if cond(r) {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("%s 等待房间状态超时", p.name)
+		}
+	}
+}
+
+// waitFor 轮询直到条件成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(flowTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// seededCharacterID 种子数据中第一个角色的ID
+func seededCharacterID(t *testing.T) int {
+	t.Helper()
+	name := env.seedData.Characters[0].Name
+	var id int
+	if err := db.DB.QueryRow(`SELECT id FROM characters WHERE name = $1`, name).Scan(&id); err != nil {
+		t.Fatalf("查询角色 %s 失败: %v", name, err)
+	}
+	return id
+}
+
+// containsMatch 战绩列表中是否有该对局
+func containsMatch(matches []models.PlayerMatchRecord, matchID string) bool {
+	for _, m := range matches {
+		if m.MatchID == matchID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+// main_test.go
+
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	"github.com/jacl-coder/PixelStorm-Server/config"
+	"github.com/jacl-coder/PixelStorm-Server/internal/balance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/events"
+	"github.com/jacl-coder/PixelStorm-Server/internal/feature"
+	"github.com/jacl-coder/PixelStorm-Server/internal/game"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gamedata"
+	"github.com/jacl-coder/PixelStorm-Server/internal/gateway"
+	"github.com/jacl-coder/PixelStorm-Server/internal/liveevent"
+	"github.com/jacl-coder/PixelStorm-Server/internal/maintenance"
+	"github.com/jacl-coder/PixelStorm-Server/internal/match"
+	"github.com/jacl-coder/PixelStorm-Server/internal/seed"
+	"github.com/jacl-coder/PixelStorm-Server/pkg/db"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+const (
+	// containerExpire 容器的最长存活时间（秒），测试进程异常退出时由 Docker 回收
+	containerExpire = 600
+	// startTimeout 等待容器和服务就绪的最长时间
+	startTimeout = 2 * time.Minute
+)
+
+// env 测试期间运行的服务，各测试共用
+var env struct {
+	gatewayURL string // 网关 HTTP 地址
+	gameWSURL  string // 游戏服务器 WebSocket 地址
+	gameServer *game.GameServer
+	seedData   *seed.Data
+}
+
+// TestMain 启动 Postgres 和 Redis 容器，建表并写入种子数据，在进程内启动游戏、匹配和网关服务。
+// 需要本机可访问 Docker，运行方式：
+//
+//	go test -tags integration ./test/integration/ -v
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+// run 准备测试环境并运行测试，返回进程退出码。容器在返回前清理
+func run(m *testing.M) int {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Printf("连接Docker失败: %v", err)
+		return 1
+	}
+	if err := pool.Client.Ping(); err != nil {
+		log.Printf("Docker不可用，集成测试需要本机运行Docker: %v", err)
+		return 1
+	}
+	pool.MaxWait = startTimeout
+
+	postgres, err := startContainer(pool, "postgres", "16-alpine", []string{
+		"POSTGRES_USER=pixelstorm",
+		"POSTGRES_PASSWORD=pixelstorm",
+		"POSTGRES_DB=pixelstorm",
+	})
+	if err != nil {
+		log.Printf("启动Postgres失败: %v", err)
+		return 1
+	}
+	defer purge(pool, postgres)
+
+	redisContainer, err := startContainer(pool, "redis", "7-alpine", nil)
+	if err != nil {
+		log.Printf("启动Redis失败: %v", err)
+		return 1
+	}
+	defer purge(pool, redisContainer)
+
+	if err := configure(postgres, redisContainer); err != nil {
+		log.Printf("生成配置失败: %v", err)
+		return 1
+	}
+
+	// 等待容器内的服务接受连接
+	if err := pool.Retry(pingPostgres); err != nil {
+		log.Printf("等待Postgres就绪超时: %v", err)
+		return 1
+	}
+	if err := pool.Retry(pingRedis); err != nil {
+		log.Printf("等待Redis就绪超时: %v", err)
+		return 1
+	}
+
+	stop, err := startServices()
+	if err != nil {
+		log.Printf("启动服务失败: %v", err)
+		return 1
+	}
+	defer stop()
+
+	return m.Run()
+}
+
+// startContainer 启动容器，测试结束或超时后自动删除
+func startContainer(pool *dockertest.Pool, repository, tag string, envs []string) (*dockertest.Resource, error) {
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: repository,
+		Tag:        tag,
+		Env:        envs,
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+		hc.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := resource.Expire(containerExpire); err != nil {
+		purge(pool, resource)
+		return nil, err
+	}
+	return resource, nil
+}
+
+// purge 删除容器
+func purge(pool *dockertest.Pool, resource *dockertest.Resource) {
+	if err := pool.Purge(resource); err != nil {
+		log.Printf("删除容器 %s 失败: %v", resource.Container.Name, err)
+	}
+}
+
+// configure 加载仓库中的默认配置，把数据库、Redis 和服务端口指向测试环境
+func configure(postgres, redisContainer *dockertest.Resource) error {
+	if err := config.LoadConfig("../../config/config.yaml"); err != nil {
+		return err
+	}
+	cfg := &config.GlobalConfig
+
+	cfg.Database.Driver = "postgres"
+	cfg.Database.Host = postgres.GetBoundIP("5432/tcp")
+	cfg.Database.Port, _ = strconv.Atoi(postgres.GetPort("5432/tcp"))
+	cfg.Database.User = "pixelstorm"
+	cfg.Database.Password = "pixelstorm"
+	cfg.Database.DBName = "pixelstorm"
+	cfg.Database.SSLMode = "disable"
+	cfg.Database.Replicas = nil
+
+	cfg.Redis.Mode = "standalone"
+	cfg.Redis.Host = redisContainer.GetBoundIP("6379/tcp")
+	cfg.Redis.Port, _ = strconv.Atoi(redisContainer.GetPort("6379/tcp"))
+	cfg.Redis.Password = ""
+
+	// 流程测试不需要热身和倒计时，全部准备后直接开始正式对局
+	for mode, m := range cfg.GameModes {
+		m.WarmupTime, m.CountdownTime = 0, 0
+		cfg.GameModes[mode] = m
+	}
+
+	// 各服务使用随机空闲端口，避免与本机运行的服务冲突；不向网关注册，网关转发到本机端口
+	ports, err := freePorts(3)
+	if err != nil {
+		return err
+	}
+	cfg.Server.GamePort, cfg.Server.MatchPort, cfg.Server.GatewayPort = ports[0], ports[1], ports[2]
+	cfg.Registry.Secret = ""
+	cfg.Registry.GatewayURL = ""
+
+	env.gatewayURL = fmt.Sprintf("http://localhost:%d", cfg.Server.GatewayPort)
+	env.gameWSURL = fmt.Sprintf("ws://localhost:%d/ws", cfg.Server.GamePort)
+	return nil
+}
+
+// freePorts 向系统申请 n 个空闲端口
+func freePorts(n int) ([]int, error) {
+	ports := make([]int, 0, n)
+	listeners := make([]net.Listener, 0, n)
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		l, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+		ports = append(ports, l.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
+
+// pingPostgres 检查数据库是否接受连接
+func pingPostgres() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := pgx.Connect(ctx, config.GlobalConfig.Database.GetDSN())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	return conn.Ping(ctx)
+}
+
+// pingRedis 检查 Redis 是否接受连接
+func pingRedis() error {
+	client := redis.NewClient(&redis.Options{Addr: config.GlobalConfig.Redis.GetRedisAddr()})
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.Ping(ctx).Err()
+}
+
+// startServices 与 cmd/server 的 all 模式相同的顺序初始化依赖并启动三个服务，
+// 返回按相反顺序停止服务并关闭连接的函数
+func startServices() (func(), error) {
+	if err := db.Init(); err != nil {
+		return nil, err
+	}
+	if err := db.InitAllTables(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("建表失败: %w", err)
+	}
+	data, err := seed.Load("../../data")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("加载种子数据失败: %w", err)
+	}
+	for _, kind := range seed.Kinds {
+		if _, err := seed.Apply(db.DB, data, kind, seed.ModeInsert); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("写入 %s 失败: %w", kind, err)
+		}
+	}
+	env.seedData = data
+
+	if err := db.InitRedis(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	cfg := &config.GlobalConfig
+	events.Init(cfg.Events)
+	gamedata.Init()
+	feature.Init()
+	balance.Init(cfg.GameModes)
+	maintenance.Init(cfg.Maintenance)
+	liveevent.Init()
+
+	gameServer := game.NewGameServer(cfg)
+	if err := gameServer.Start(); err != nil {
+		return nil, fmt.Errorf("启动游戏服务器失败: %w", err)
+	}
+	matchService := match.NewMatchService(cfg, gameServer)
+	if err := matchService.Start(); err != nil {
+		return nil, fmt.Errorf("启动匹配服务失败: %w", err)
+	}
+	gatewayServer := gateway.NewGateway(cfg)
+	if err := gatewayServer.Start(); err != nil {
+		return nil, fmt.Errorf("启动网关服务失败: %w", err)
+	}
+	env.gameServer = gameServer
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		gatewayServer.Stop(ctx)
+		matchService.Stop(ctx)
+		gameServer.Stop(ctx)
+		events.Close()
+		db.CloseRedis()
+		db.Close()
+	}
+
+	// HTTP 服务在后台协程中监听，等待健康检查通过
+	for _, port := range []int{cfg.Server.GamePort, cfg.Server.MatchPort, cfg.Server.GatewayPort} {
+		if err := waitHealthy(port, startTimeout); err != nil {
+			stop()
+			return nil, err
+		}
+	}
+	return stop, nil
+}
+
+// waitHealthy 等待服务的 /health 返回200
+func waitHealthy(port int, timeout time.Duration) error {
+	url := fmt.Sprintf("http://localhost:%d/health", port)
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+			err = fmt.Errorf("状态码 %d", resp.StatusCode)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待 %s 就绪超时: %w", url, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}